@@ -0,0 +1,73 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firefly
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+// SmartContract provides the FireFly batch pinning function
+type SmartContract struct {
+	contractapi.Contract
+}
+
+// BatchPin is the event emitted for each pinned batch, with the same fields as the Ethereum BatchPin event
+type BatchPin struct {
+	Signer     string   `json:"signer"`
+	Timestamp  int64    `json:"timestamp"`
+	Namespace  string   `json:"namespace"`
+	UUIDs      string   `json:"uuids"`
+	BatchHash  string   `json:"batchHash"`
+	PayloadRef string   `json:"payloadRef"`
+	Contexts   []string `json:"contexts"`
+}
+
+// PinBatch emits a BatchPin event. The contexts are passed as a JSON array of hex strings.
+// The signer is reported as "<mspid>::<common name>" of the submitting identity.
+func (s *SmartContract) PinBatch(ctx contractapi.TransactionContextInterface, namespace, uuids, batchHash, payloadRef, contexts string) error {
+	cid := ctx.GetClientIdentity()
+	mspID, err := cid.GetMSPID()
+	if err != nil {
+		return fmt.Errorf("failed to obtain client MSP ID: %s", err)
+	}
+	cert, err := cid.GetX509Certificate()
+	if err != nil {
+		return fmt.Errorf("failed to obtain client certificate: %s", err)
+	}
+	timestamp, err := ctx.GetStub().GetTxTimestamp()
+	if err != nil {
+		return fmt.Errorf("failed to get transaction timestamp: %s", err)
+	}
+	var contextArray []string
+	if err := json.Unmarshal([]byte(contexts), &contextArray); err != nil {
+		return fmt.Errorf("failed to parse contexts: %s", err)
+	}
+	event := BatchPin{
+		Signer:     fmt.Sprintf("%s::%s", mspID, cert.Subject.CommonName),
+		Timestamp:  timestamp.GetSeconds(),
+		Namespace:  namespace,
+		UUIDs:      uuids,
+		BatchHash:  batchHash,
+		PayloadRef: payloadRef,
+		Contexts:   contextArray,
+	}
+	bytes, _ := json.Marshal(event)
+	return ctx.GetStub().SetEvent("BatchPin", bytes)
+}
@@ -0,0 +1,5 @@
+module github.com/hyperledger-labs/firefly/chaincode_firefly
+
+go 1.16
+
+require github.com/hyperledger/fabric-contract-api-go v1.1.1
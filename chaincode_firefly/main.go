@@ -0,0 +1,35 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"log"
+
+	"github.com/hyperledger-labs/firefly/chaincode_firefly/firefly"
+	"github.com/hyperledger/fabric-contract-api-go/contractapi"
+)
+
+func main() {
+	chaincode, err := contractapi.NewChaincode(&firefly.SmartContract{})
+	if err != nil {
+		log.Panicf("Error creating firefly chaincode: %v", err)
+	}
+
+	if err := chaincode.Start(); err != nil {
+		log.Panicf("Error starting firefly chaincode: %v", err)
+	}
+}
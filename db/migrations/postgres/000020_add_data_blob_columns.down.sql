@@ -0,0 +1,5 @@
+BEGIN;
+ALTER TABLE data DROP COLUMN blob_name;
+ALTER TABLE data DROP COLUMN blob_mimetype;
+ALTER TABLE data DROP COLUMN blob_size;
+COMMIT;
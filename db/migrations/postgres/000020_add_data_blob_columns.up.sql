@@ -0,0 +1,5 @@
+BEGIN;
+ALTER TABLE data ADD COLUMN blob_name VARCHAR(1024) NOT NULL DEFAULT '';
+ALTER TABLE data ADD COLUMN blob_mimetype VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE data ADD COLUMN blob_size BIGINT NOT NULL DEFAULT 0;
+COMMIT;
@@ -0,0 +1,13 @@
+BEGIN;
+CREATE TABLE blobs (
+  seq            SERIAL          PRIMARY KEY,
+  namespace      VARCHAR(64)     NOT NULL,
+  data_id        UUID            NOT NULL,
+  hash           CHAR(64)        NOT NULL,
+  peer           VARCHAR(1024)   NOT NULL,
+  created        BIGINT          NOT NULL
+);
+
+CREATE INDEX blobs_data ON blobs(data_id);
+
+COMMIT;
@@ -0,0 +1,3 @@
+BEGIN;
+ALTER TABLE data DROP COLUMN blob_payloadref;
+COMMIT;
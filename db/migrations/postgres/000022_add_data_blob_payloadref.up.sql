@@ -0,0 +1,3 @@
+BEGIN;
+ALTER TABLE data ADD COLUMN blob_payloadref CHAR(64);
+COMMIT;
@@ -0,0 +1,3 @@
+BEGIN;
+ALTER TABLE pins DROP COLUMN ledger;
+COMMIT;
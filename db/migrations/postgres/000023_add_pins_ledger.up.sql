@@ -0,0 +1,3 @@
+BEGIN;
+ALTER TABLE pins ADD COLUMN ledger UUID;
+COMMIT;
@@ -0,0 +1,11 @@
+BEGIN;
+CREATE TABLE leases (
+  seq            SERIAL          PRIMARY KEY,
+  name           VARCHAR(64)     NOT NULL,
+  holder         VARCHAR(1024)   NOT NULL,
+  expires        BIGINT          NOT NULL
+);
+
+CREATE UNIQUE INDEX leases_name ON leases(name);
+
+COMMIT;
@@ -0,0 +1,3 @@
+BEGIN;
+ALTER TABLE orgs DROP COLUMN public_keys;
+COMMIT;
@@ -0,0 +1,3 @@
+BEGIN;
+ALTER TABLE orgs ADD COLUMN public_keys BYTEA;
+COMMIT;
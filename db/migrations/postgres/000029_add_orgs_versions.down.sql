@@ -0,0 +1,6 @@
+BEGIN;
+DROP TABLE IF EXISTS orgs_history;
+ALTER TABLE orgs DROP COLUMN revoked;
+ALTER TABLE orgs DROP COLUMN updated;
+ALTER TABLE orgs DROP COLUMN version;
+COMMIT;
@@ -0,0 +1,26 @@
+BEGIN;
+ALTER TABLE orgs ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
+ALTER TABLE orgs ADD COLUMN updated BIGINT;
+ALTER TABLE orgs ADD COLUMN revoked BIGINT;
+
+CREATE TABLE orgs_history (
+  seq            SERIAL          PRIMARY KEY,
+  id             UUID            NOT NULL,
+  message_id     UUID            NOT NULL,
+  name           VARCHAR(64)     NOT NULL,
+  parent         VARCHAR(1024),
+  identity       VARCHAR(1024)   NOT NULL,
+  description    VARCHAR(4096)   NOT NULL,
+  profile        BYTEA,
+  public_keys    BYTEA,
+  created        BIGINT          NOT NULL,
+  version        BIGINT          NOT NULL,
+  updated        BIGINT,
+  revoked        BIGINT
+);
+
+CREATE UNIQUE INDEX orgs_history_version ON orgs_history(id,version);
+
+INSERT INTO orgs_history (id, message_id, name, parent, identity, description, profile, public_keys, created, version)
+  SELECT id, message_id, name, parent, identity, description, profile, public_keys, created, version FROM orgs;
+COMMIT;
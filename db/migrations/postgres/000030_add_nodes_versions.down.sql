@@ -0,0 +1,6 @@
+BEGIN;
+DROP TABLE IF EXISTS nodes_history;
+ALTER TABLE nodes DROP COLUMN revoked;
+ALTER TABLE nodes DROP COLUMN updated;
+ALTER TABLE nodes DROP COLUMN version;
+COMMIT;
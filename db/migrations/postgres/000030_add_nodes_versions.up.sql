@@ -0,0 +1,25 @@
+BEGIN;
+ALTER TABLE nodes ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
+ALTER TABLE nodes ADD COLUMN updated BIGINT;
+ALTER TABLE nodes ADD COLUMN revoked BIGINT;
+
+CREATE TABLE nodes_history (
+  seq            SERIAL          PRIMARY KEY,
+  id             UUID            NOT NULL,
+  message_id     UUID            NOT NULL,
+  owner          VARCHAR(1024)   NOT NULL,
+  name           VARCHAR(64)     NOT NULL,
+  description    VARCHAR(4096)   NOT NULL,
+  dx_peer        VARCHAR(256),
+  dx_endpoint    BYTEA,
+  created        BIGINT          NOT NULL,
+  version        BIGINT          NOT NULL,
+  updated        BIGINT,
+  revoked        BIGINT
+);
+
+CREATE UNIQUE INDEX nodes_history_version ON nodes_history(id,version);
+
+INSERT INTO nodes_history (id, message_id, owner, name, description, dx_peer, dx_endpoint, created, version)
+  SELECT id, message_id, owner, name, description, dx_peer, dx_endpoint, created, version FROM nodes;
+COMMIT;
@@ -0,0 +1,5 @@
+BEGIN;
+ALTER TABLE groups DROP COLUMN retired;
+ALTER TABLE groups DROP COLUMN successor;
+ALTER TABLE groups DROP COLUMN predecessor;
+COMMIT;
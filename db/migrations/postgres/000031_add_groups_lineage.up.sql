@@ -0,0 +1,5 @@
+BEGIN;
+ALTER TABLE groups ADD COLUMN predecessor CHAR(64);
+ALTER TABLE groups ADD COLUMN successor CHAR(64);
+ALTER TABLE groups ADD COLUMN retired BIGINT;
+COMMIT;
@@ -0,0 +1,3 @@
+BEGIN;
+ALTER TABLE leases DROP COLUMN epoch;
+COMMIT;
@@ -0,0 +1,3 @@
+BEGIN;
+ALTER TABLE leases ADD COLUMN epoch BIGINT NOT NULL DEFAULT 0;
+COMMIT;
@@ -0,0 +1,4 @@
+BEGIN;
+ALTER TABLE orgs DROP COLUMN identity_proof;
+ALTER TABLE orgs_history DROP COLUMN identity_proof;
+COMMIT;
@@ -0,0 +1,4 @@
+BEGIN;
+ALTER TABLE orgs ADD COLUMN identity_proof VARCHAR(1024);
+ALTER TABLE orgs_history ADD COLUMN identity_proof VARCHAR(1024);
+COMMIT;
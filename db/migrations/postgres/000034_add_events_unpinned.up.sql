@@ -0,0 +1,3 @@
+BEGIN;
+ALTER TABLE events ADD COLUMN unpinned BOOLEAN NOT NULL DEFAULT false;
+COMMIT;
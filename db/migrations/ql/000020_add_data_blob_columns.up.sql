@@ -0,0 +1,4 @@
+ALTER TABLE data ADD blob_name string;
+ALTER TABLE data ADD blob_mimetype string;
+ALTER TABLE data ADD blob_size int64;
+UPDATE data SET blob_name = "", blob_mimetype = "", blob_size = 0;
@@ -0,0 +1,9 @@
+CREATE TABLE blobs (
+  namespace      string          NOT NULL,
+  data_id        string          NOT NULL,
+  hash           string          NOT NULL,
+  peer           string          NOT NULL,
+  created        int64           NOT NULL
+);
+
+CREATE INDEX blobs_data ON blobs(data_id);
@@ -0,0 +1 @@
+ALTER TABLE data ADD blob_payloadref string;
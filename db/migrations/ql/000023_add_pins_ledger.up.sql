@@ -0,0 +1 @@
+ALTER TABLE pins ADD ledger string;
@@ -0,0 +1,7 @@
+CREATE TABLE leases (
+  name           string            NOT NULL,
+  holder         string            NOT NULL,
+  expires        int64             NOT NULL
+);
+
+CREATE UNIQUE INDEX leases_name ON leases(name);
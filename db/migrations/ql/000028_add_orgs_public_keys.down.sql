@@ -0,0 +1 @@
+ALTER TABLE orgs DROP COLUMN public_keys;
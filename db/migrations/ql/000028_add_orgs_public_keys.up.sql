@@ -0,0 +1 @@
+ALTER TABLE orgs ADD public_keys blob;
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS orgs_history;
+ALTER TABLE orgs DROP COLUMN revoked;
+ALTER TABLE orgs DROP COLUMN updated;
+ALTER TABLE orgs DROP COLUMN version;
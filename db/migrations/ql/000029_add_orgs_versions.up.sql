@@ -0,0 +1,24 @@
+ALTER TABLE orgs ADD version int64;
+ALTER TABLE orgs ADD updated int64;
+ALTER TABLE orgs ADD revoked int64;
+UPDATE orgs SET version = 1;
+
+CREATE TABLE orgs_history (
+  id             string            NOT NULL,
+  message_id     string            NOT NULL,
+  name           string            NOT NULL,
+  parent         string,
+  identity       string            NOT NULL,
+  description    string            NOT NULL,
+  profile        blob,
+  public_keys    blob,
+  created        int64             NOT NULL,
+  version        int64             NOT NULL,
+  updated        int64,
+  revoked        int64
+);
+
+CREATE UNIQUE INDEX orgs_history_version ON orgs_history(id,version);
+
+INSERT INTO orgs_history (id, message_id, name, parent, identity, description, profile, public_keys, created, version)
+  SELECT id, message_id, name, parent, identity, description, profile, public_keys, created, version FROM orgs;
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS nodes_history;
+ALTER TABLE nodes DROP COLUMN revoked;
+ALTER TABLE nodes DROP COLUMN updated;
+ALTER TABLE nodes DROP COLUMN version;
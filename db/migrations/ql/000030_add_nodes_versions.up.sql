@@ -0,0 +1,23 @@
+ALTER TABLE nodes ADD version int64;
+ALTER TABLE nodes ADD updated int64;
+ALTER TABLE nodes ADD revoked int64;
+UPDATE nodes SET version = 1;
+
+CREATE TABLE nodes_history (
+  id             string          NOT NULL,
+  message_id     string          NOT NULL,
+  owner          string          NOT NULL,
+  name           string          NOT NULL,
+  description    string          NOT NULL,
+  dx_peer        string,
+  dx_endpoint    blob,
+  created        int64           NOT NULL,
+  version        int64           NOT NULL,
+  updated        int64,
+  revoked        int64
+);
+
+CREATE UNIQUE INDEX nodes_history_version ON nodes_history(id,version);
+
+INSERT INTO nodes_history (id, message_id, owner, name, description, dx_peer, dx_endpoint, created, version)
+  SELECT id, message_id, owner, name, description, dx_peer, dx_endpoint, created, version FROM nodes;
@@ -0,0 +1,3 @@
+ALTER TABLE groups DROP COLUMN retired;
+ALTER TABLE groups DROP COLUMN successor;
+ALTER TABLE groups DROP COLUMN predecessor;
@@ -0,0 +1,3 @@
+ALTER TABLE groups ADD predecessor string;
+ALTER TABLE groups ADD successor string;
+ALTER TABLE groups ADD retired int64;
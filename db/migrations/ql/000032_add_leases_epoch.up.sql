@@ -0,0 +1,2 @@
+ALTER TABLE leases ADD epoch int64;
+UPDATE leases SET epoch = 0;
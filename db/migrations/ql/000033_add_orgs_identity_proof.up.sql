@@ -0,0 +1,2 @@
+ALTER TABLE orgs ADD identity_proof string;
+ALTER TABLE orgs_history ADD identity_proof string;
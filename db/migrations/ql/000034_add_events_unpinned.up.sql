@@ -0,0 +1,2 @@
+ALTER TABLE events ADD unpinned bool;
+UPDATE events SET unpinned = false;
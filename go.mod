@@ -8,6 +8,7 @@ require (
 	github.com/aidarkhanov/nanoid v1.0.8
 	github.com/akamensky/base58 v0.0.0-20170920141933-92b0f56f531a
 	github.com/bombsimon/wsl/v2 v2.0.0 // indirect
+	github.com/btcsuite/btcd v0.22.1
 	github.com/docker/go-units v0.4.0
 	github.com/getkin/kin-openapi v0.62.0
 	github.com/ghodss/yaml v1.0.0
@@ -43,6 +44,7 @@ require (
 	github.com/onsi/gomega v1.10.5 // indirect
 	github.com/pelletier/go-toml v1.9.2 // indirect
 	github.com/pkg/errors v0.9.1
+	github.com/prometheus/client_golang v1.11.0
 	github.com/rs/cors v1.7.0
 	github.com/securego/gosec v0.0.0-20191002120514-e680875ea14d // indirect
 	github.com/shirou/gopsutil v0.0.0-20190901111213-e4ec7b275ada // indirect
@@ -57,6 +59,7 @@ require (
 	github.com/ugorji/go v1.1.4 // indirect
 	github.com/x-cray/logrus-prefixed-formatter v0.5.2
 	github.com/xeipuuv/gojsonschema v1.2.0
+	golang.org/x/crypto v0.0.0-20210513164829-c07d793c2f9a
 	golang.org/x/net v0.0.0-20210521195947-fe42d452be8f // indirect
 	golang.org/x/sys v0.0.0-20210603125802-9665404d3644 // indirect
 	golang.org/x/term v0.0.0-20210503060354-a79de5458b56 // indirect
@@ -23,4 +23,5 @@ var adminRoutes = []*oapispec.Route{
 	getConfigRecords,
 	putConfigRecord,
 	deleteConfigRecord,
+	getBlockchainStatus,
 }
@@ -0,0 +1,168 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apiserver
+
+import (
+	"context"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/hyperledger-labs/firefly/internal/auth/authfactory"
+	"github.com/hyperledger-labs/firefly/internal/config"
+	"github.com/hyperledger-labs/firefly/internal/i18n"
+	"github.com/hyperledger-labs/firefly/internal/log"
+	"github.com/hyperledger-labs/firefly/internal/oapispec"
+	"github.com/hyperledger-labs/firefly/pkg/auth"
+	"github.com/hyperledger-labs/firefly/pkg/fftypes"
+)
+
+var authConfig = config.NewPluginConfig("auth")
+
+const groupPrefix = "group:"
+
+// authRule allows matching principals to access matching routes, in matching namespaces.
+// Each entry is a glob pattern, and an empty list matches anything. Principals are matched
+// by subject, or by group using the "group:" prefix. Rules restricted to namespaces do not
+// match routes outside of a namespace.
+type authRule struct {
+	principals []string
+	routes     []string
+	namespaces []string
+}
+
+type authorizer struct {
+	plugin auth.Plugin
+	rules  []*authRule
+}
+
+// apiAuth is nil when authentication is disabled
+var apiAuth *authorizer
+
+func initAuth(ctx context.Context) (err error) {
+	apiAuth = nil
+	authfactory.InitPrefix(authConfig)
+	authType := config.GetString(config.AuthType)
+	if authType == "" {
+		log.L(ctx).Warnf("API authentication is disabled")
+		return nil
+	}
+
+	a := &authorizer{}
+	if a.plugin, err = authfactory.GetPlugin(ctx, authType); err != nil {
+		return err
+	}
+	if err = a.plugin.Init(ctx, authConfig.SubPrefix(a.plugin.Name())); err != nil {
+		return err
+	}
+	for _, r := range config.GetObjectArray(config.AuthRules) {
+		a.rules = append(a.rules, &authRule{
+			principals: ruleEntries(r, "principals"),
+			routes:     ruleEntries(r, "routes"),
+			namespaces: ruleEntries(r, "namespaces"),
+		})
+	}
+	log.L(ctx).Infof("API authentication enabled with plugin '%s' and %d rules", authType, len(a.rules))
+	apiAuth = a
+	return nil
+}
+
+func ruleEntries(rule fftypes.JSONObject, key string) []string {
+	if _, ok := rule[key]; !ok {
+		return nil
+	}
+	return rule.GetStringArray(key)
+}
+
+func globMatch(patterns []string, values ...string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		for _, value := range values {
+			if match, _ := path.Match(pattern, value); match {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (r *authRule) matches(principal *auth.Principal, routeName, ns string) bool {
+	candidates := []string{principal.Subject}
+	for _, group := range principal.Groups {
+		candidates = append(candidates, groupPrefix+group)
+	}
+	if !globMatch(r.principals, candidates...) || !globMatch(r.routes, routeName) {
+		return false
+	}
+	if len(r.namespaces) > 0 && ns == "" {
+		return false
+	}
+	return globMatch(r.namespaces, ns)
+}
+
+// wsRoute is the name auth rules use to match connections to the websockets endpoint
+var wsRoute = &oapispec.Route{Name: "websockets"}
+
+// wsHandler authenticates and authorizes websocket connections in the same way as API routes,
+// before the connection is upgraded
+func wsHandler(handler http.HandlerFunc) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		if apiAuth != nil {
+			ctx, err := apiAuth.authorize(req, wsRoute)
+			if err != nil {
+				apiWrapper(func(res http.ResponseWriter, req *http.Request) (int, error) {
+					return 401, err
+				})(res, req)
+				return
+			}
+			req = req.WithContext(ctx)
+		}
+		handler(res, req)
+	}
+}
+
+// authorize authenticates the request using the plugin, then checks the principal against the rules.
+// If no rules are configured, any authenticated principal is allowed.
+func (a *authorizer) authorize(req *http.Request, route *oapispec.Route) (context.Context, error) {
+	ctx := req.Context()
+	principal, err := a.plugin.Authenticate(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if principal == nil {
+		return nil, i18n.NewError(ctx, i18n.MsgUnauthorized)
+	}
+
+	ns := mux.Vars(req)["ns"]
+	allowed := len(a.rules) == 0
+	for _, rule := range a.rules {
+		if rule.matches(principal, route.Name, ns) {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		log.L(ctx).Warnf("Principal '%s' (groups=%s) denied access to route '%s' in namespace '%s'", principal.Subject, strings.Join(principal.Groups, ","), route.Name, ns)
+		return nil, i18n.NewError(ctx, i18n.MsgForbidden)
+	}
+
+	log.L(ctx).Debugf("Authenticated principal '%s' via %s", principal.Subject, principal.Method)
+	return auth.WithPrincipal(ctx, principal), nil
+}
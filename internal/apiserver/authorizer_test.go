@@ -0,0 +1,245 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apiserver
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hyperledger-labs/firefly/internal/config"
+	"github.com/hyperledger-labs/firefly/mocks/authmocks"
+	"github.com/hyperledger-labs/firefly/mocks/orchestratormocks"
+	"github.com/hyperledger-labs/firefly/pkg/auth"
+	"github.com/hyperledger-labs/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestAuth(rules ...*authRule) (*authmocks.Plugin, func()) {
+	mp := &authmocks.Plugin{}
+	apiAuth = &authorizer{
+		plugin: mp,
+		rules:  rules,
+	}
+	return mp, func() {
+		apiAuth = nil
+	}
+}
+
+func TestInitAuthDisabled(t *testing.T) {
+	config.Reset()
+	err := initAuth(context.Background())
+	assert.NoError(t, err)
+	assert.Nil(t, apiAuth)
+}
+
+func TestInitAuthUnknownPlugin(t *testing.T) {
+	config.Reset()
+	config.Set(config.AuthType, "wrong")
+	err := initAuth(context.Background())
+	assert.Regexp(t, "FF10236.*wrong", err)
+	assert.Nil(t, apiAuth)
+}
+
+func TestInitAuthPluginFail(t *testing.T) {
+	config.Reset()
+	config.Set(config.AuthType, "basic")
+	err := initAuth(context.Background())
+	assert.Regexp(t, "FF10240", err)
+	assert.Nil(t, apiAuth)
+}
+
+func TestInitAuthWithRules(t *testing.T) {
+	config.Reset()
+	config.Set(config.AuthType, "mtls")
+	config.Set(config.AuthRules, []interface{}{
+		map[string]interface{}{
+			"principals": []interface{}{"group:admins"},
+		},
+		map[string]interface{}{
+			"principals": []interface{}{"user1"},
+			"routes":     []interface{}{"get*"},
+			"namespaces": []interface{}{"ns1"},
+		},
+	})
+	err := initAuth(context.Background())
+	defer func() { apiAuth = nil }()
+	assert.NoError(t, err)
+	assert.Equal(t, "mtls", apiAuth.plugin.Name())
+	assert.Len(t, apiAuth.rules, 2)
+	assert.Equal(t, []string{"group:admins"}, apiAuth.rules[0].principals)
+	assert.Nil(t, apiAuth.rules[0].routes)
+	assert.Nil(t, apiAuth.rules[0].namespaces)
+	assert.Equal(t, []string{"get*"}, apiAuth.rules[1].routes)
+	assert.Equal(t, []string{"ns1"}, apiAuth.rules[1].namespaces)
+}
+
+func TestServeAuthFail(t *testing.T) {
+	config.Reset()
+	config.Set(config.AuthType, "wrong")
+	err := Serve(context.Background(), &orchestratormocks.Orchestrator{})
+	assert.Regexp(t, "FF10236", err)
+}
+
+func TestAuthRuleMatching(t *testing.T) {
+	principal := &auth.Principal{Subject: "user1", Groups: []string{"readers"}}
+
+	rule := &authRule{}
+	assert.True(t, rule.matches(principal, "getNamespaces", ""))
+	assert.True(t, rule.matches(principal, "getNamespace", "ns1"))
+
+	rule = &authRule{principals: []string{"group:read*"}, routes: []string{"get*"}}
+	assert.True(t, rule.matches(principal, "getNamespace", "ns1"))
+	assert.False(t, rule.matches(principal, "postNewSubscription", "ns1"))
+	assert.False(t, rule.matches(&auth.Principal{Subject: "readers"}, "getNamespace", "ns1"))
+
+	rule = &authRule{principals: []string{"user1"}, namespaces: []string{"ns1", "ns2"}}
+	assert.True(t, rule.matches(principal, "getNamespace", "ns2"))
+	assert.False(t, rule.matches(principal, "getNamespace", "ns3"))
+	assert.False(t, rule.matches(principal, "getNamespaces", ""))
+}
+
+func TestAuthAllowedNoRules(t *testing.T) {
+	mp, done := newTestAuth()
+	defer done()
+	o := &orchestratormocks.Orchestrator{}
+	r := createMuxRouter(o)
+	req := httptest.NewRequest("GET", "/api/v1/namespaces/ns1", nil)
+	res := httptest.NewRecorder()
+
+	mp.On("Authenticate", mock.Anything, mock.Anything).Return(&auth.Principal{Subject: "user1"}, nil)
+	o.On("GetNamespace", mock.MatchedBy(func(ctx context.Context) bool {
+		return auth.GetPrincipal(ctx).Subject == "user1"
+	}), "ns1").Return(&fftypes.Namespace{}, nil)
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, 200, res.Result().StatusCode)
+	o.AssertExpectations(t)
+}
+
+func TestAuthAllowedByRule(t *testing.T) {
+	mp, done := newTestAuth(
+		&authRule{principals: []string{"admin"}},
+		&authRule{principals: []string{"group:readers"}, routes: []string{"get*"}, namespaces: []string{"ns1"}},
+	)
+	defer done()
+	o := &orchestratormocks.Orchestrator{}
+	r := createMuxRouter(o)
+	req := httptest.NewRequest("GET", "/api/v1/namespaces/ns1", nil)
+	res := httptest.NewRecorder()
+
+	mp.On("Authenticate", mock.Anything, mock.Anything).Return(&auth.Principal{Subject: "user1", Groups: []string{"readers"}}, nil)
+	o.On("GetNamespace", mock.Anything, "ns1").Return(&fftypes.Namespace{}, nil)
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, 200, res.Result().StatusCode)
+}
+
+func TestAuthForbidden(t *testing.T) {
+	mp, done := newTestAuth(
+		&authRule{principals: []string{"group:readers"}, namespaces: []string{"ns1"}},
+	)
+	defer done()
+	o := &orchestratormocks.Orchestrator{}
+	r := createMuxRouter(o)
+	req := httptest.NewRequest("GET", "/api/v1/namespaces/ns2", nil)
+	res := httptest.NewRecorder()
+
+	mp.On("Authenticate", mock.Anything, mock.Anything).Return(&auth.Principal{Subject: "user1", Groups: []string{"readers"}}, nil)
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, 403, res.Result().StatusCode)
+	o.AssertNotCalled(t, "GetNamespace", mock.Anything, mock.Anything)
+}
+
+func TestAuthNoCredentials(t *testing.T) {
+	mp, done := newTestAuth()
+	defer done()
+	o := &orchestratormocks.Orchestrator{}
+	r := createMuxRouter(o)
+	req := httptest.NewRequest("GET", "/api/v1/namespaces", nil)
+	res := httptest.NewRecorder()
+
+	mp.On("Authenticate", mock.Anything, mock.Anything).Return(nil, nil)
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, 401, res.Result().StatusCode)
+}
+
+func TestAuthInvalidCredentials(t *testing.T) {
+	mp, done := newTestAuth()
+	defer done()
+	o := &orchestratormocks.Orchestrator{}
+	r := createMuxRouter(o)
+	req := httptest.NewRequest("GET", "/api/v1/namespaces", nil)
+	res := httptest.NewRecorder()
+
+	mp.On("Authenticate", mock.Anything, mock.Anything).Return(nil, fmt.Errorf("pop"))
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, 401, res.Result().StatusCode)
+}
+
+func TestAuthWebSocketsUnauthorized(t *testing.T) {
+	mp, done := newTestAuth()
+	defer done()
+	o := &orchestratormocks.Orchestrator{}
+	r := createMuxRouter(o)
+	req := httptest.NewRequest("GET", "/ws", nil)
+	res := httptest.NewRecorder()
+
+	mp.On("Authenticate", mock.Anything, mock.Anything).Return(nil, nil)
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, 401, res.Result().StatusCode)
+	assert.Equal(t, "application/json", res.Result().Header.Get("Content-Type"))
+}
+
+func TestAuthWebSocketsForbidden(t *testing.T) {
+	mp, done := newTestAuth(&authRule{routes: []string{"getNamespaces"}})
+	defer done()
+	o := &orchestratormocks.Orchestrator{}
+	r := createMuxRouter(o)
+	req := httptest.NewRequest("GET", "/ws", nil)
+	res := httptest.NewRecorder()
+
+	mp.On("Authenticate", mock.Anything, mock.Anything).Return(&auth.Principal{Subject: "user1"}, nil)
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, 403, res.Result().StatusCode)
+}
+
+func TestAuthWebSocketsAllowed(t *testing.T) {
+	mp, done := newTestAuth(&authRule{routes: []string{"websockets"}})
+	defer done()
+	var authorized *auth.Principal
+	handler := wsHandler(func(res http.ResponseWriter, req *http.Request) {
+		authorized = auth.GetPrincipal(req.Context())
+		res.WriteHeader(200)
+	})
+	req := httptest.NewRequest("GET", "/ws", nil)
+	res := httptest.NewRecorder()
+
+	mp.On("Authenticate", mock.Anything, mock.Anything).Return(&auth.Principal{Subject: "user1"}, nil)
+	handler(res, req)
+
+	assert.Equal(t, 200, res.Result().StatusCode)
+	assert.Equal(t, "user1", authorized.Subject)
+}
@@ -19,6 +19,7 @@ package apiserver
 import (
 	"net/http"
 	"net/url"
+	"reflect"
 	"sort"
 	"strconv"
 	"strings"
@@ -83,22 +84,107 @@ func buildFilter(req *http.Request, ff database.QueryFactory) (database.AndFilte
 		filter.Limit(l)
 	}
 	sortVals := getValues(req.Form, "sort")
+	var sortFields []string
 	for _, sv := range sortVals {
 		subSortVals := strings.Split(sv, ",")
 		for _, ssv := range subSortVals {
 			ssv = strings.TrimSpace(ssv)
 			if ssv != "" {
 				filter.Sort(ssv)
+				sortFields = append(sortFields, ssv)
 			}
 		}
 	}
-	descendingVals := getValues(req.Form, "descending")
-	if len(descendingVals) > 0 && (descendingVals[0] == "" || strings.EqualFold(descendingVals[0], "true")) {
+	descending := isTrue(getValues(req.Form, "descending"))
+	if descending {
 		filter.Descending()
 	}
+	if isTrue(getValues(req.Form, "count")) {
+		filter.Count(true)
+	}
+	afterVals := getValues(req.Form, "after")
+	if len(afterVals) > 0 {
+		// The cursor is only meaningful on a sequenced collection, in sequence order (the default sort)
+		sequenceSort := len(sortFields) == 0 || (len(sortFields) == 1 && strings.EqualFold(sortFields[0], "sequence"))
+		if !hasField(possibleFields, "sequence") || !sequenceSort {
+			return nil, i18n.NewError(req.Context(), i18n.MsgFilterAfterNotSupported)
+		}
+		after, err := strconv.ParseInt(afterVals[0], 10, 64)
+		if err != nil {
+			return nil, i18n.WrapError(req.Context(), err, i18n.MsgFilterAfterInvalid, afterVals[0])
+		}
+		if descending || len(sortFields) == 0 {
+			filter.Condition(fb.Lt("sequence", after))
+		} else {
+			filter.Condition(fb.Gt("sequence", after))
+		}
+	}
 	return filter, nil
 }
 
+func isTrue(vals []string) bool {
+	return len(vals) > 0 && (vals[0] == "" || strings.EqualFold(vals[0], "true"))
+}
+
+func hasField(fields []string, field string) bool {
+	for _, f := range fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// filterResults is returned by the handlers of collection routes, so the items can be returned
+// directly, or in an envelope with the count and next page cursor when requested
+type filterResults struct {
+	items interface{}
+	res   *database.FilterResult
+}
+
+type filterResultsEnvelope struct {
+	Count int64       `json:"count"`
+	Total *int64      `json:"total,omitempty"`
+	Next  string      `json:"next,omitempty"`
+	Items interface{} `json:"items"`
+}
+
+func filterResult(items interface{}, res *database.FilterResult, err error) (interface{}, error) {
+	if err != nil {
+		return nil, err
+	}
+	return &filterResults{items: items, res: res}, nil
+}
+
+func (fr *filterResults) output(req *http.Request, filter database.AndFilter) interface{} {
+	if !isTrue(getValues(req.Form, "count")) && len(getValues(req.Form, "after")) == 0 {
+		return fr.items
+	}
+
+	envelope := &filterResultsEnvelope{Items: fr.items}
+	if fr.res != nil {
+		envelope.Total = fr.res.TotalCount
+	}
+	items := reflect.ValueOf(fr.items)
+	if items.Kind() != reflect.Slice {
+		return envelope
+	}
+	envelope.Count = int64(items.Len())
+
+	// If we returned a full page of a sequenced collection, in sequence order, return the cursor for the next page
+	fi, err := filter.Finalize()
+	sequenceSort := err == nil && (len(fi.Sort) == 0 || (len(fi.Sort) == 1 && fi.Sort[0] == "sequence"))
+	if sequenceSort && fi.Limit > 0 && envelope.Count == int64(fi.Limit) {
+		last := reflect.Indirect(items.Index(items.Len() - 1))
+		if last.Kind() == reflect.Struct {
+			if seq := last.FieldByName("Sequence"); seq.IsValid() && seq.Kind() == reflect.Int64 {
+				envelope.Next = strconv.FormatInt(seq.Int(), 10)
+			}
+		}
+	}
+	return envelope
+}
+
 func getCondition(fb database.FilterBuilder, field, value string) database.Filter {
 	switch {
 	case strings.HasPrefix(value, ">="):
@@ -17,10 +17,12 @@
 package apiserver
 
 import (
+	"fmt"
 	"net/http/httptest"
 	"testing"
 
 	"github.com/hyperledger-labs/firefly/pkg/database"
+	"github.com/hyperledger-labs/firefly/pkg/fftypes"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -51,3 +53,122 @@ func TestBuildFilterLimitLimit(t *testing.T) {
 	_, err := buildFilter(req, database.MessageQueryFactory)
 	assert.Regexp(t, "FF10184.*500", err)
 }
+
+func TestBuildFilterCount(t *testing.T) {
+	req := httptest.NewRequest("GET", "/things?count", nil)
+	filter, err := buildFilter(req, database.MessageQueryFactory)
+	assert.NoError(t, err)
+	fi, err := filter.Finalize()
+	assert.NoError(t, err)
+	assert.True(t, fi.Count)
+}
+
+func TestBuildFilterAfterDefaultSort(t *testing.T) {
+	req := httptest.NewRequest("GET", "/things?after=100", nil)
+	filter, err := buildFilter(req, database.MessageQueryFactory)
+	assert.NoError(t, err)
+	fi, err := filter.Finalize()
+	assert.NoError(t, err)
+	assert.Equal(t, "( sequence < 100 )", fi.String())
+}
+
+func TestBuildFilterAfterAscending(t *testing.T) {
+	req := httptest.NewRequest("GET", "/things?after=100&sort=sequence", nil)
+	filter, err := buildFilter(req, database.MessageQueryFactory)
+	assert.NoError(t, err)
+	fi, err := filter.Finalize()
+	assert.NoError(t, err)
+	assert.Equal(t, "( sequence > 100 ) sort=sequence", fi.String())
+}
+
+func TestBuildFilterAfterDescending(t *testing.T) {
+	req := httptest.NewRequest("GET", "/things?after=100&sort=sequence&descending", nil)
+	filter, err := buildFilter(req, database.MessageQueryFactory)
+	assert.NoError(t, err)
+	fi, err := filter.Finalize()
+	assert.NoError(t, err)
+	assert.Equal(t, "( sequence < 100 ) sort=sequence descending", fi.String())
+}
+
+func TestBuildFilterAfterWrongSort(t *testing.T) {
+	req := httptest.NewRequest("GET", "/things?after=100&sort=tag", nil)
+	_, err := buildFilter(req, database.MessageQueryFactory)
+	assert.Regexp(t, "FF10247", err)
+}
+
+func TestBuildFilterAfterNotSequenced(t *testing.T) {
+	req := httptest.NewRequest("GET", "/things?after=100", nil)
+	_, err := buildFilter(req, database.NamespaceQueryFactory)
+	assert.Regexp(t, "FF10247", err)
+}
+
+func TestBuildFilterAfterInvalid(t *testing.T) {
+	req := httptest.NewRequest("GET", "/things?after=abc", nil)
+	_, err := buildFilter(req, database.MessageQueryFactory)
+	assert.Regexp(t, "FF10248.*abc", err)
+}
+
+func TestFilterResultError(t *testing.T) {
+	_, err := filterResult(nil, nil, fmt.Errorf("pop"))
+	assert.EqualError(t, err, "pop")
+}
+
+func TestFilterResultsOutputItems(t *testing.T) {
+	req := httptest.NewRequest("GET", "/things", nil)
+	filter, err := buildFilter(req, database.MessageQueryFactory)
+	assert.NoError(t, err)
+	items := []*fftypes.Message{}
+	fr, err := filterResult(items, &database.FilterResult{}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, items, fr.(*filterResults).output(req, filter))
+}
+
+func TestFilterResultsOutputEnvelope(t *testing.T) {
+	req := httptest.NewRequest("GET", "/things?count&limit=2", nil)
+	filter, err := buildFilter(req, database.MessageQueryFactory)
+	assert.NoError(t, err)
+	total := int64(10)
+	items := []*fftypes.Message{{Sequence: 12}, {Sequence: 11}}
+	fr, err := filterResult(items, &database.FilterResult{TotalCount: &total}, nil)
+	assert.NoError(t, err)
+	envelope := fr.(*filterResults).output(req, filter).(*filterResultsEnvelope)
+	assert.Equal(t, int64(2), envelope.Count)
+	assert.Equal(t, int64(10), *envelope.Total)
+	assert.Equal(t, "11", envelope.Next)
+	assert.Equal(t, items, envelope.Items)
+}
+
+func TestFilterResultsOutputEnvelopeLastPage(t *testing.T) {
+	req := httptest.NewRequest("GET", "/things?after=11&limit=2", nil)
+	filter, err := buildFilter(req, database.MessageQueryFactory)
+	assert.NoError(t, err)
+	items := []*fftypes.Message{{Sequence: 10}}
+	fr, err := filterResult(items, &database.FilterResult{}, nil)
+	assert.NoError(t, err)
+	envelope := fr.(*filterResults).output(req, filter).(*filterResultsEnvelope)
+	assert.Equal(t, int64(1), envelope.Count)
+	assert.Nil(t, envelope.Total)
+	assert.Empty(t, envelope.Next)
+}
+
+func TestFilterResultsOutputEnvelopeNotSequenced(t *testing.T) {
+	req := httptest.NewRequest("GET", "/things?count&limit=1", nil)
+	filter, err := buildFilter(req, database.NamespaceQueryFactory)
+	assert.NoError(t, err)
+	items := []*fftypes.Namespace{{Name: "ns1"}}
+	fr, err := filterResult(items, nil, nil)
+	assert.NoError(t, err)
+	envelope := fr.(*filterResults).output(req, filter).(*filterResultsEnvelope)
+	assert.Equal(t, int64(1), envelope.Count)
+	assert.Empty(t, envelope.Next)
+}
+
+func TestFilterResultsOutputEnvelopeNotSlice(t *testing.T) {
+	req := httptest.NewRequest("GET", "/things?count", nil)
+	filter, err := buildFilter(req, database.MessageQueryFactory)
+	assert.NoError(t, err)
+	fr, err := filterResult(map[string]string{}, nil, nil)
+	assert.NoError(t, err)
+	envelope := fr.(*filterResults).output(req, filter).(*filterResultsEnvelope)
+	assert.Equal(t, int64(0), envelope.Count)
+}
@@ -39,7 +39,7 @@ var deleteSubscription = &oapispec.Route{
 	JSONInputMask:   nil,
 	JSONOutputValue: nil,
 	JSONOutputCode:  http.StatusNoContent, // Sync operation, no output
-	JSONHandler: func(r oapispec.APIRequest) (output interface{}, err error) {
+	JSONHandler: func(r *oapispec.APIRequest) (output interface{}, err error) {
 		err = r.Or.DeleteSubscription(r.Ctx, r.PP["ns"], r.PP["subid"])
 		return nil, err
 	},
@@ -39,7 +39,7 @@ var getBatchByID = &oapispec.Route{
 	JSONInputValue:  nil,
 	JSONOutputValue: func() interface{} { return &fftypes.Batch{} },
 	JSONOutputCode:  http.StatusOK,
-	JSONHandler: func(r oapispec.APIRequest) (output interface{}, err error) {
+	JSONHandler: func(r *oapispec.APIRequest) (output interface{}, err error) {
 		output, err = r.Or.GetBatchByID(r.Ctx, r.PP["ns"], r.PP["batchid"])
 		return output, err
 	},
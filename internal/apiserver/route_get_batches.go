@@ -39,8 +39,7 @@ var getBatches = &oapispec.Route{
 	JSONInputValue:  nil,
 	JSONOutputValue: func() interface{} { return []*fftypes.Batch{} },
 	JSONOutputCode:  http.StatusOK,
-	JSONHandler: func(r oapispec.APIRequest) (output interface{}, err error) {
-		output, err = r.Or.GetBatches(r.Ctx, r.PP["ns"], r.Filter)
-		return output, err
+	JSONHandler: func(r *oapispec.APIRequest) (output interface{}, err error) {
+		return filterResult(r.Or.GetBatches(r.Ctx, r.PP["ns"], r.Filter))
 	},
 }
@@ -36,8 +36,7 @@ var getConfigRecords = &oapispec.Route{
 	JSONInputValue:  nil,
 	JSONOutputValue: func() interface{} { return []*fftypes.ConfigRecord{} },
 	JSONOutputCode:  http.StatusOK,
-	JSONHandler: func(r oapispec.APIRequest) (output interface{}, err error) {
-		output, err = r.Or.GetConfigRecords(r.Ctx, r.Filter)
-		return output, err
+	JSONHandler: func(r *oapispec.APIRequest) (output interface{}, err error) {
+		return filterResult(r.Or.GetConfigRecords(r.Ctx, r.Filter))
 	},
 }
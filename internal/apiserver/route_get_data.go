@@ -39,8 +39,7 @@ var getData = &oapispec.Route{
 	JSONInputValue:  nil,
 	JSONOutputValue: func() interface{} { return []*fftypes.Data{} },
 	JSONOutputCode:  http.StatusOK,
-	JSONHandler: func(r oapispec.APIRequest) (output interface{}, err error) {
-		output, err = r.Or.GetData(r.Ctx, r.PP["ns"], r.Filter)
-		return output, err
+	JSONHandler: func(r *oapispec.APIRequest) (output interface{}, err error) {
+		return filterResult(r.Or.GetData(r.Ctx, r.PP["ns"], r.Filter))
 	},
 }
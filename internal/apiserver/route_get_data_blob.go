@@ -0,0 +1,63 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apiserver
+
+import (
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/hyperledger-labs/firefly/internal/config"
+	"github.com/hyperledger-labs/firefly/internal/i18n"
+	"github.com/hyperledger-labs/firefly/internal/oapispec"
+)
+
+var getDataBlob = &oapispec.Route{
+	Name:   "getDataBlob",
+	Path:   "namespaces/{ns}/data/{dataid}/blob",
+	Method: http.MethodGet,
+	PathParams: []*oapispec.PathParam{
+		{Name: "ns", ExampleFromConf: config.NamespacesDefault, Description: i18n.MsgTBD},
+		{Name: "dataid", Description: i18n.MsgTBD},
+	},
+	QueryParams:     nil,
+	FilterFactory:   nil,
+	Description:     i18n.MsgTBD,
+	JSONInputValue:  nil,
+	JSONOutputValue: func() interface{} { return []byte{} },
+	JSONOutputCode:  http.StatusOK,
+	JSONHandler: func(r *oapispec.APIRequest) (output interface{}, err error) {
+		data, reader, err := r.Or.Data().DownloadBLOB(r.Ctx, r.PP["ns"], r.PP["dataid"])
+		if err != nil {
+			return nil, err
+		}
+		if data.Blob != nil {
+			if data.Blob.MimeType != "" {
+				r.ResponseHeaders.Set("Content-Type", data.Blob.MimeType)
+			}
+			if data.Blob.Name != "" {
+				r.ResponseHeaders.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": data.Blob.Name}))
+			}
+			// Blobs recorded before the size was stored have a size of zero. We do not know the length
+			// of those, so we stream them without a Content-Length (which also disables Range support)
+			if data.Blob.Size > 0 {
+				r.ResponseHeaders.Set("Content-Length", strconv.FormatInt(data.Blob.Size, 10))
+			}
+		}
+		return reader, nil
+	},
+}
@@ -0,0 +1,276 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apiserver
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hyperledger-labs/firefly/mocks/datamocks"
+	"github.com/hyperledger-labs/firefly/mocks/orchestratormocks"
+	"github.com/hyperledger-labs/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type testSeekableBlob struct {
+	*bytes.Reader
+	read    int64
+	seekErr error
+}
+
+func (b *testSeekableBlob) Read(p []byte) (int, error) {
+	n, err := b.Reader.Read(p)
+	b.read += int64(n)
+	return n, err
+}
+
+func (b *testSeekableBlob) Seek(offset int64, whence int) (int64, error) {
+	if b.seekErr != nil {
+		return 0, b.seekErr
+	}
+	return b.Reader.Seek(offset, whence)
+}
+
+func (b *testSeekableBlob) Close() error {
+	return nil
+}
+
+func newTestBlobDownload(t *testing.T, blob *fftypes.BlobRef, content string) (*orchestratormocks.Orchestrator, *datamocks.Manager) {
+	o := &orchestratormocks.Orchestrator{}
+	mdm := &datamocks.Manager{}
+	o.On("Data").Return(mdm)
+	mdm.On("DownloadBLOB", mock.Anything, "mynamespace", "abcd1234").
+		Return(&fftypes.Data{Blobstore: true, Blob: blob}, ioutil.NopCloser(bytes.NewReader([]byte(content))), nil)
+	return o, mdm
+}
+
+func TestGetDataBlob(t *testing.T) {
+	o, _ := newTestBlobDownload(t, &fftypes.BlobRef{
+		Name:     "file.txt",
+		MimeType: "text/plain",
+		Size:     10,
+	}, "0123456789")
+	r := createMuxRouter(o)
+	req := httptest.NewRequest("GET", "/api/v1/namespaces/mynamespace/data/abcd1234/blob", nil)
+	res := httptest.NewRecorder()
+
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, 200, res.Result().StatusCode)
+	assert.Equal(t, "text/plain", res.Result().Header.Get("Content-Type"))
+	assert.Equal(t, "10", res.Result().Header.Get("Content-Length"))
+	assert.Equal(t, "bytes", res.Result().Header.Get("Accept-Ranges"))
+	assert.Equal(t, `attachment; filename=file.txt`, res.Result().Header.Get("Content-Disposition"))
+	b, _ := ioutil.ReadAll(res.Body)
+	assert.Equal(t, "0123456789", string(b))
+}
+
+func TestGetDataBlobDefaultContentType(t *testing.T) {
+	o, _ := newTestBlobDownload(t, nil, "0123456789")
+	r := createMuxRouter(o)
+	req := httptest.NewRequest("GET", "/api/v1/namespaces/mynamespace/data/abcd1234/blob", nil)
+	req.Header.Set("Range", "bytes=0-1")
+	res := httptest.NewRecorder()
+
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, 200, res.Result().StatusCode)
+	assert.Equal(t, "application/octet-stream", res.Result().Header.Get("Content-Type"))
+	assert.Empty(t, res.Result().Header.Get("Accept-Ranges"))
+	b, _ := ioutil.ReadAll(res.Body)
+	assert.Equal(t, "0123456789", string(b))
+}
+
+func TestGetDataBlobUnknownSize(t *testing.T) {
+	o, _ := newTestBlobDownload(t, &fftypes.BlobRef{Name: "file.txt"}, "0123456789")
+	r := createMuxRouter(o)
+	req := httptest.NewRequest("GET", "/api/v1/namespaces/mynamespace/data/abcd1234/blob", nil)
+	req.Header.Set("Range", "bytes=0-1")
+	res := httptest.NewRecorder()
+
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, 200, res.Result().StatusCode)
+	assert.Empty(t, res.Result().Header.Get("Content-Length"))
+	assert.Empty(t, res.Result().Header.Get("Accept-Ranges"))
+	b, _ := ioutil.ReadAll(res.Body)
+	assert.Equal(t, "0123456789", string(b))
+}
+
+func TestGetDataBlobRange(t *testing.T) {
+	o, _ := newTestBlobDownload(t, &fftypes.BlobRef{Size: 10}, "0123456789")
+	r := createMuxRouter(o)
+	req := httptest.NewRequest("GET", "/api/v1/namespaces/mynamespace/data/abcd1234/blob", nil)
+	req.Header.Set("Range", "bytes=2-5")
+	res := httptest.NewRecorder()
+
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, 206, res.Result().StatusCode)
+	assert.Equal(t, "bytes 2-5/10", res.Result().Header.Get("Content-Range"))
+	assert.Equal(t, "4", res.Result().Header.Get("Content-Length"))
+	b, _ := ioutil.ReadAll(res.Body)
+	assert.Equal(t, "2345", string(b))
+}
+
+func TestGetDataBlobRangeSeek(t *testing.T) {
+	o := &orchestratormocks.Orchestrator{}
+	mdm := &datamocks.Manager{}
+	o.On("Data").Return(mdm)
+	blob := &testSeekableBlob{Reader: bytes.NewReader([]byte("0123456789"))}
+	mdm.On("DownloadBLOB", mock.Anything, "mynamespace", "abcd1234").
+		Return(&fftypes.Data{Blobstore: true, Blob: &fftypes.BlobRef{Size: 10}}, blob, nil)
+	r := createMuxRouter(o)
+	req := httptest.NewRequest("GET", "/api/v1/namespaces/mynamespace/data/abcd1234/blob", nil)
+	req.Header.Set("Range", "bytes=6-7")
+	res := httptest.NewRecorder()
+
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, 206, res.Result().StatusCode)
+	assert.Equal(t, "bytes 6-7/10", res.Result().Header.Get("Content-Range"))
+	b, _ := ioutil.ReadAll(res.Body)
+	assert.Equal(t, "67", string(b))
+	// The content before the range was not read
+	assert.Equal(t, int64(2), blob.read)
+}
+
+func TestGetDataBlobRangeSeekFail(t *testing.T) {
+	o := &orchestratormocks.Orchestrator{}
+	mdm := &datamocks.Manager{}
+	o.On("Data").Return(mdm)
+	blob := &testSeekableBlob{Reader: bytes.NewReader([]byte("0123456789")), seekErr: fmt.Errorf("pop")}
+	mdm.On("DownloadBLOB", mock.Anything, "mynamespace", "abcd1234").
+		Return(&fftypes.Data{Blobstore: true, Blob: &fftypes.BlobRef{Size: 10}}, blob, nil)
+	r := createMuxRouter(o)
+	req := httptest.NewRequest("GET", "/api/v1/namespaces/mynamespace/data/abcd1234/blob", nil)
+	req.Header.Set("Range", "bytes=6-7")
+	res := httptest.NewRecorder()
+
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, 500, res.Result().StatusCode)
+	assert.Equal(t, "application/json", res.Result().Header.Get("Content-Type"))
+}
+
+type testShortSeeker struct {
+	*bytes.Reader
+}
+
+func (s *testShortSeeker) Seek(offset int64, whence int) (int64, error) {
+	return s.Reader.Seek(0, io.SeekEnd)
+}
+
+func TestSkipToSeekShort(t *testing.T) {
+	err := skipTo(&testShortSeeker{Reader: bytes.NewReader([]byte("01"))}, 5)
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+}
+
+func TestGetDataBlobRangeSuffix(t *testing.T) {
+	o, _ := newTestBlobDownload(t, &fftypes.BlobRef{Size: 10}, "0123456789")
+	r := createMuxRouter(o)
+	req := httptest.NewRequest("GET", "/api/v1/namespaces/mynamespace/data/abcd1234/blob", nil)
+	req.Header.Set("Range", "bytes=-3")
+	res := httptest.NewRecorder()
+
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, 206, res.Result().StatusCode)
+	assert.Equal(t, "bytes 7-9/10", res.Result().Header.Get("Content-Range"))
+	b, _ := ioutil.ReadAll(res.Body)
+	assert.Equal(t, "789", string(b))
+}
+
+func TestGetDataBlobRangeUnsatisfiable(t *testing.T) {
+	o, _ := newTestBlobDownload(t, &fftypes.BlobRef{Name: "file.txt", Size: 10}, "0123456789")
+	r := createMuxRouter(o)
+	req := httptest.NewRequest("GET", "/api/v1/namespaces/mynamespace/data/abcd1234/blob", nil)
+	req.Header.Set("Range", "bytes=10-")
+	res := httptest.NewRecorder()
+
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, 416, res.Result().StatusCode)
+	assert.Equal(t, "bytes */10", res.Result().Header.Get("Content-Range"))
+	assert.Equal(t, "application/json", res.Result().Header.Get("Content-Type"))
+	assert.Empty(t, res.Result().Header.Get("Content-Disposition"))
+}
+
+func TestGetDataBlobRangeShortContent(t *testing.T) {
+	o, _ := newTestBlobDownload(t, &fftypes.BlobRef{Size: 10}, "01")
+	r := createMuxRouter(o)
+	req := httptest.NewRequest("GET", "/api/v1/namespaces/mynamespace/data/abcd1234/blob", nil)
+	req.Header.Set("Range", "bytes=5-")
+	res := httptest.NewRecorder()
+
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, 500, res.Result().StatusCode)
+	assert.Equal(t, "application/json", res.Result().Header.Get("Content-Type"))
+}
+
+func TestGetDataBlobNotFound(t *testing.T) {
+	o := &orchestratormocks.Orchestrator{}
+	mdm := &datamocks.Manager{}
+	o.On("Data").Return(mdm)
+	mdm.On("DownloadBLOB", mock.Anything, "mynamespace", "abcd1234").
+		Return(nil, nil, fmt.Errorf("FF10234: not a blob"))
+	r := createMuxRouter(o)
+	req := httptest.NewRequest("GET", "/api/v1/namespaces/mynamespace/data/abcd1234/blob", nil)
+	res := httptest.NewRecorder()
+
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, 404, res.Result().StatusCode)
+}
+
+func TestParseByteRange(t *testing.T) {
+	testCases := []struct {
+		header      string
+		start, end  int64
+		ok, satisfy bool
+	}{
+		{"bytes=0-0", 0, 0, true, true},
+		{"bytes=0-", 0, 9, true, true},
+		{"bytes=3-100", 3, 9, true, true},
+		{"bytes=-100", 0, 9, true, true},
+		{"bytes=-0", 0, 0, true, false},
+		{"bytes=10-11", 10, 11, true, false},
+		{"bytes=5-4", 0, 0, false, false},
+		{"bytes=0-1,3-4", 0, 0, false, false},
+		{"bytes=-", 0, 0, false, false},
+		{"bytes=1", 0, 0, false, false},
+		{"bytes=a-", 0, 0, false, false},
+		{"bytes=-a", 0, 0, false, false},
+		{"bytes=1-a", 0, 0, false, false},
+		{"items=0-1", 0, 0, false, false},
+	}
+	for _, tc := range testCases {
+		start, end, ok, satisfiable := parseByteRange(tc.header, 10)
+		assert.Equal(t, tc.ok, ok, tc.header)
+		assert.Equal(t, tc.satisfy, satisfiable, tc.header)
+		if ok && satisfiable {
+			assert.Equal(t, tc.start, start, tc.header)
+			assert.Equal(t, tc.end, end, tc.header)
+		}
+	}
+}
@@ -40,8 +40,7 @@ var getDataMsgs = &oapispec.Route{
 	JSONInputValue:  nil,
 	JSONOutputValue: func() interface{} { return &fftypes.Message{} },
 	JSONOutputCode:  http.StatusOK,
-	JSONHandler: func(r oapispec.APIRequest) (output interface{}, err error) {
-		output, err = r.Or.GetMessagesForData(r.Ctx, r.PP["ns"], r.PP["dataid"], r.Filter)
-		return output, err
+	JSONHandler: func(r *oapispec.APIRequest) (output interface{}, err error) {
+		return filterResult(r.Or.GetMessagesForData(r.Ctx, r.PP["ns"], r.PP["dataid"], r.Filter))
 	},
 }
@@ -34,7 +34,7 @@ func TestGetEvents(t *testing.T) {
 	res := httptest.NewRecorder()
 
 	o.On("GetEvents", mock.Anything, "mynamespace", mock.Anything).
-		Return([]*fftypes.Event{}, nil)
+		Return([]*fftypes.Event{}, nil, nil)
 	r.ServeHTTP(res, req)
 
 	assert.Equal(t, 200, res.Result().StatusCode)
@@ -40,8 +40,7 @@ var getMsgEvents = &oapispec.Route{
 	JSONInputValue:  nil,
 	JSONOutputValue: func() interface{} { return []*fftypes.Event{} },
 	JSONOutputCode:  http.StatusOK,
-	JSONHandler: func(r oapispec.APIRequest) (output interface{}, err error) {
-		output, err = r.Or.GetMessageEvents(r.Ctx, r.PP["ns"], r.PP["msgid"], r.Filter)
-		return output, err
+	JSONHandler: func(r *oapispec.APIRequest) (output interface{}, err error) {
+		return filterResult(r.Or.GetMessageEvents(r.Ctx, r.PP["ns"], r.PP["msgid"], r.Filter))
 	},
 }
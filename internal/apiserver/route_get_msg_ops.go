@@ -39,7 +39,7 @@ var getMsgOps = &oapispec.Route{
 	JSONInputValue:  nil,
 	JSONOutputValue: func() interface{} { return []*fftypes.Operation{} },
 	JSONOutputCode:  http.StatusOK,
-	JSONHandler: func(r oapispec.APIRequest) (output interface{}, err error) {
+	JSONHandler: func(r *oapispec.APIRequest) (output interface{}, err error) {
 		output, err = r.Or.GetMessageOperations(r.Ctx, r.PP["ns"], r.PP["msgid"])
 		return output, err
 	},
@@ -34,7 +34,7 @@ func TestGetMessages(t *testing.T) {
 	res := httptest.NewRecorder()
 
 	o.On("GetMessages", mock.Anything, "mynamespace", mock.Anything).
-		Return([]*fftypes.Message{}, nil)
+		Return([]*fftypes.Message{}, nil, nil)
 	r.ServeHTTP(res, req)
 
 	assert.Equal(t, 200, res.Result().StatusCode)
@@ -37,7 +37,7 @@ func TestGetNodess(t *testing.T) {
 	res := httptest.NewRecorder()
 
 	mnm.On("GetNodes", mock.Anything, mock.Anything).
-		Return([]*fftypes.Node{}, nil)
+		Return([]*fftypes.Node{}, nil, nil)
 	r.ServeHTTP(res, req)
 
 	assert.Equal(t, 200, res.Result().StatusCode)
@@ -37,7 +37,7 @@ var getNetworkOrg = &oapispec.Route{
 	JSONInputValue:  nil,
 	JSONOutputValue: func() interface{} { return &fftypes.Organization{} },
 	JSONOutputCode:  http.StatusOK,
-	JSONHandler: func(r oapispec.APIRequest) (output interface{}, err error) {
+	JSONHandler: func(r *oapispec.APIRequest) (output interface{}, err error) {
 		output, err = r.Or.NetworkMap().GetOrganizationByID(r.Ctx, r.PP["oid"])
 		return output, err
 	},
@@ -39,7 +39,7 @@ var getTxnOps = &oapispec.Route{
 	JSONInputValue:  nil,
 	JSONOutputValue: func() interface{} { return &[]*fftypes.Transaction{} },
 	JSONOutputCode:  http.StatusOK,
-	JSONHandler: func(r oapispec.APIRequest) (output interface{}, err error) {
+	JSONHandler: func(r *oapispec.APIRequest) (output interface{}, err error) {
 		output, err = r.Or.GetTransactionOperations(r.Ctx, r.PP["ns"], r.PP["txnid"])
 		return output, err
 	},
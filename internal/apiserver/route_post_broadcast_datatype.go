@@ -39,7 +39,7 @@ var postBroadcastDatatype = &oapispec.Route{
 	JSONInputMask:   []string{"ID", "Namespace", "Hash", "Created", "Message"},
 	JSONOutputValue: func() interface{} { return &fftypes.Message{} },
 	JSONOutputCode:  http.StatusAccepted, // Async operation
-	JSONHandler: func(r oapispec.APIRequest) (output interface{}, err error) {
+	JSONHandler: func(r *oapispec.APIRequest) (output interface{}, err error) {
 		output, err = r.Or.Broadcast().BroadcastDatatype(r.Ctx, r.PP["ns"], r.Input.(*fftypes.Datatype))
 		return output, err
 	},
@@ -23,6 +23,7 @@ import (
 	"testing"
 
 	"github.com/hyperledger-labs/firefly/mocks/broadcastmocks"
+	"github.com/hyperledger-labs/firefly/mocks/eventmocks"
 	"github.com/hyperledger-labs/firefly/mocks/orchestratormocks"
 	"github.com/hyperledger-labs/firefly/pkg/fftypes"
 	"github.com/stretchr/testify/assert"
@@ -47,3 +48,28 @@ func TestPostBroadcastMessage(t *testing.T) {
 
 	assert.Equal(t, 202, res.Result().StatusCode)
 }
+
+func TestPostBroadcastMessageConfirm(t *testing.T) {
+	o := &orchestratormocks.Orchestrator{}
+	mbm := &broadcastmocks.Manager{}
+	mem := &eventmocks.EventManager{}
+	o.On("Broadcast").Return(mbm)
+	o.On("Events").Return(mem)
+	r := createMuxRouter(o)
+	input := fftypes.Datatype{}
+	var buf bytes.Buffer
+	json.NewEncoder(&buf).Encode(&input)
+	req := httptest.NewRequest("POST", "/api/v1/namespaces/ns1/broadcast/message?confirm", &buf)
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	res := httptest.NewRecorder()
+
+	msgID := fftypes.NewUUID()
+	mbm.On("BroadcastMessage", mock.Anything, "ns1", mock.AnythingOfType("*fftypes.MessageInput")).
+		Return(&fftypes.Message{Header: fftypes.MessageHeader{ID: msgID}}, nil)
+	mem.On("WaitForMessage", mock.Anything, "ns1", msgID).
+		Return(&fftypes.Message{Header: fftypes.MessageHeader{ID: msgID}}, nil)
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, 200, res.Result().StatusCode)
+	mem.AssertExpectations(t)
+}
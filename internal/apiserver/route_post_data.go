@@ -39,12 +39,16 @@ var postData = &oapispec.Route{
 	JSONInputMask:   []string{"ID", "Namespace", "Created", "Blob", "Blobstore", "Hash"},
 	JSONOutputValue: func() interface{} { return &fftypes.Data{} },
 	JSONOutputCode:  http.StatusCreated, // Sync operation
-	JSONHandler: func(r oapispec.APIRequest) (output interface{}, err error) {
+	JSONHandler: func(r *oapispec.APIRequest) (output interface{}, err error) {
 		output, err = r.Or.Data().UploadJSON(r.Ctx, r.PP["ns"], r.Input.(*fftypes.Data))
 		return output, err
 	},
-	FormUploadHandler: func(r oapispec.APIRequest) (output interface{}, err error) {
-		output, err = r.Or.Data().UploadBLOB(r.Ctx, r.PP["ns"], r.FReader)
+	FormUploadHandler: func(r *oapispec.APIRequest) (output interface{}, err error) {
+		blob := &fftypes.BlobRef{
+			Name:     r.Part.FileName(),
+			MimeType: r.Part.Header.Get("Content-Type"),
+		}
+		output, err = r.Or.Data().UploadBLOB(r.Ctx, r.PP["ns"], blob, r.Part)
 		return output, err
 	},
 }
@@ -69,7 +69,10 @@ func TestPostDataBinary(t *testing.T) {
 
 	res := httptest.NewRecorder()
 
-	mdm.On("UploadBLOB", mock.Anything, "ns1", mock.AnythingOfType("*multipart.Part")).
+	mdm.On("UploadBLOB", mock.Anything, "ns1", &fftypes.BlobRef{
+		Name:     "filename.ext",
+		MimeType: "application/octet-stream",
+	}, mock.AnythingOfType("*multipart.Part")).
 		Return(&fftypes.Data{}, nil)
 	r.ServeHTTP(res, req)
 
@@ -94,7 +97,7 @@ func TestPostDataBinaryMissing(t *testing.T) {
 
 	res := httptest.NewRecorder()
 
-	mdm.On("UploadBLOB", mock.Anything, "ns1", mock.AnythingOfType("*multipart.Part")).
+	mdm.On("UploadBLOB", mock.Anything, "ns1", mock.Anything, mock.AnythingOfType("*multipart.Part")).
 		Return(&fftypes.Data{}, nil)
 	r.ServeHTTP(res, req)
 
@@ -115,7 +118,7 @@ func TestPostDataBadForm(t *testing.T) {
 
 	res := httptest.NewRecorder()
 
-	mdm.On("UploadBLOB", mock.Anything, "ns1", mock.AnythingOfType("*multipart.Part")).
+	mdm.On("UploadBLOB", mock.Anything, "ns1", mock.Anything, mock.AnythingOfType("*multipart.Part")).
 		Return(&fftypes.Data{}, nil)
 	r.ServeHTTP(res, req)
 
@@ -0,0 +1,55 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apiserver
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/hyperledger-labs/firefly/internal/config"
+	"github.com/hyperledger-labs/firefly/internal/i18n"
+	"github.com/hyperledger-labs/firefly/internal/oapispec"
+	"github.com/hyperledger-labs/firefly/pkg/fftypes"
+)
+
+var postMsgReply = &oapispec.Route{
+	Name:   "postMsgReply",
+	Path:   "namespaces/{ns}/messages/{msgid}/reply",
+	Method: http.MethodPost,
+	PathParams: []*oapispec.PathParam{
+		{Name: "ns", ExampleFromConf: config.NamespacesDefault, Description: i18n.MsgTBD},
+		{Name: "msgid", Description: i18n.MsgTBD},
+	},
+	QueryParams: []*oapispec.QueryParam{
+		{Name: "confirm", IsBool: true, Description: i18n.MsgConfirmQueryParam},
+	},
+	FilterFactory:   nil,
+	Description:     i18n.MsgTBD,
+	JSONInputValue:  func() interface{} { return &fftypes.MessageInput{} },
+	JSONInputSchema: broadcastSchema, // the group and topics are inherited from the message being replied to
+	JSONOutputValue: func() interface{} { return &fftypes.Message{} },
+	JSONOutputCode:  http.StatusAccepted, // Async operation
+	JSONHandler: func(r *oapispec.APIRequest) (output interface{}, err error) {
+		msg, err := r.Or.SendReply(r.Ctx, r.PP["ns"], r.PP["msgid"], r.Input.(*fftypes.MessageInput))
+		if err == nil && strings.EqualFold(r.QP["confirm"], "true") {
+			// Block until the message is confirmed (or rejected) by the local node
+			r.SuccessStatus = http.StatusOK
+			return r.Or.Events().WaitForMessage(r.Ctx, r.PP["ns"], msg.Header.ID)
+		}
+		return msg, err
+	},
+}
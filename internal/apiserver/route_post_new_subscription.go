@@ -39,7 +39,7 @@ var postNewSubscription = &oapispec.Route{
 	JSONInputMask:   []string{"ID", "Namespace", "Created", "Ephemeral"},
 	JSONOutputValue: func() interface{} { return &fftypes.Subscription{} },
 	JSONOutputCode:  http.StatusCreated, // Sync operation
-	JSONHandler: func(r oapispec.APIRequest) (output interface{}, err error) {
+	JSONHandler: func(r *oapispec.APIRequest) (output interface{}, err error) {
 		output, err = r.Or.CreateSubscription(r.Ctx, r.PP["ns"], r.Input.(*fftypes.Subscription))
 		return output, err
 	},
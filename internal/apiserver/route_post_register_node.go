@@ -37,7 +37,7 @@ var postRegisterNode = &oapispec.Route{
 	JSONInputSchema: emptyObjectSchema,
 	JSONOutputValue: func() interface{} { return &fftypes.Message{} },
 	JSONOutputCode:  http.StatusAccepted, // Async operation
-	JSONHandler: func(r oapispec.APIRequest) (output interface{}, err error) {
+	JSONHandler: func(r *oapispec.APIRequest) (output interface{}, err error) {
 		output, err = r.Or.NetworkMap().RegisterNode(r.Ctx)
 		return output, err
 	},
@@ -37,7 +37,7 @@ var postRegisterNodeOrg = &oapispec.Route{
 	JSONInputSchema: emptyObjectSchema,
 	JSONOutputValue: func() interface{} { return &fftypes.Message{} },
 	JSONOutputCode:  http.StatusAccepted, // Async operation
-	JSONHandler: func(r oapispec.APIRequest) (output interface{}, err error) {
+	JSONHandler: func(r *oapispec.APIRequest) (output interface{}, err error) {
 		output, err = r.Or.NetworkMap().RegisterNodeOrganization(r.Ctx)
 		return output, err
 	},
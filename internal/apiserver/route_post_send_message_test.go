@@ -18,10 +18,13 @@ package apiserver
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"net/http/httptest"
 	"testing"
 
+	"github.com/hyperledger-labs/firefly/internal/i18n"
+	"github.com/hyperledger-labs/firefly/mocks/eventmocks"
 	"github.com/hyperledger-labs/firefly/mocks/orchestratormocks"
 	"github.com/hyperledger-labs/firefly/mocks/privatemessagingmocks"
 	"github.com/hyperledger-labs/firefly/pkg/fftypes"
@@ -47,3 +50,28 @@ func TestPostSendMessage(t *testing.T) {
 
 	assert.Equal(t, 202, res.Result().StatusCode)
 }
+
+func TestPostSendMessageConfirmInvalid(t *testing.T) {
+	o := &orchestratormocks.Orchestrator{}
+	mpm := &privatemessagingmocks.Manager{}
+	mem := &eventmocks.EventManager{}
+	o.On("PrivateMessaging").Return(mpm)
+	o.On("Events").Return(mem)
+	r := createMuxRouter(o)
+	input := fftypes.Datatype{}
+	var buf bytes.Buffer
+	json.NewEncoder(&buf).Encode(&input)
+	req := httptest.NewRequest("POST", "/api/v1/namespaces/ns1/send/message?confirm=true", &buf)
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	res := httptest.NewRecorder()
+
+	msgID := fftypes.NewUUID()
+	mpm.On("SendMessage", mock.Anything, "ns1", mock.AnythingOfType("*fftypes.MessageInput")).
+		Return(&fftypes.Message{Header: fftypes.MessageHeader{ID: msgID}}, nil)
+	mem.On("WaitForMessage", mock.Anything, "ns1", msgID).
+		Return(nil, i18n.NewError(context.Background(), i18n.MsgConfirmMessageInvalid, msgID))
+	r.ServeHTTP(res, req)
+
+	assert.Equal(t, 409, res.Result().StatusCode)
+	mem.AssertExpectations(t)
+}
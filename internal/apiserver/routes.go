@@ -21,25 +21,35 @@ import "github.com/hyperledger-labs/firefly/internal/oapispec"
 const emptyObjectSchema = `{"type": "object"}`
 
 var routes = []*oapispec.Route{
+	deleteNetworkNode,
+	deleteNetworkOrg,
 	deleteSubscription,
 	getBatchByID,
 	getBatches,
 	getData,
+	getDataBlob,
 	getDataByID,
 	getDataDefByID,
 	getDataDefs,
 	getDataMsgs,
 	getEventByID,
 	getEvents,
+	getGroupByHash,
+	getGroupLineage,
+	getGroupMsgs,
+	getGroups,
 	getMsgByID,
 	getMsgData,
 	getMsgEvents,
 	getMsgOps,
+	getMsgReplies,
 	getMsgTxn,
 	getMsgs,
 	getNetworkOrg,
+	getNetworkOrgVersions,
 	getNetworkOrgs,
 	getNetworkNode,
+	getNetworkNodeVersions,
 	getNetworkNodes,
 	getNamespace,
 	getNamespaces,
@@ -55,9 +65,16 @@ var routes = []*oapispec.Route{
 	postBroadcastMessage,
 	postBroadcastNamespace,
 	postData,
+	postGroupUpdate,
+	postMsgReply,
 	postNewSubscription,
+	postOpCancel,
+	postOpRetry,
 	postRegisterOrg,
 	postRegisterNode,
 	postRegisterNodeOrg,
+	postRequestMessage,
 	postSendMessage,
+	putNetworkNode,
+	putNetworkOrg,
 }
@@ -22,6 +22,7 @@ import (
 	"crypto/x509"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"mime/multipart"
 	"net"
@@ -38,6 +39,7 @@ import (
 	"github.com/hyperledger-labs/firefly/internal/events/websockets"
 	"github.com/hyperledger-labs/firefly/internal/i18n"
 	"github.com/hyperledger-labs/firefly/internal/log"
+	"github.com/hyperledger-labs/firefly/internal/metrics"
 	"github.com/hyperledger-labs/firefly/internal/oapispec"
 	"github.com/hyperledger-labs/firefly/internal/orchestrator"
 	"github.com/hyperledger-labs/firefly/pkg/database"
@@ -48,6 +50,10 @@ var ffcodeExtractor = regexp.MustCompile(`^(FF\d+):`)
 
 // Serve is the main entry point for the API Server
 func Serve(ctx context.Context, o orchestrator.Orchestrator) error {
+	if err := initAuth(ctx); err != nil {
+		return err
+	}
+
 	httpErrChan := make(chan error)
 	adminErrChan := make(chan error)
 
@@ -215,7 +221,16 @@ func getFirstFilePart(req *http.Request) (*multipart.Part, error) {
 
 func routeHandler(o orchestrator.Orchestrator, route *oapispec.Route) http.HandlerFunc {
 	// Check the mandatory parts are ok at startup time
-	return apiWrapper(func(res http.ResponseWriter, req *http.Request) (int, error) {
+	return namedAPIWrapper(route.Name, func(res http.ResponseWriter, req *http.Request) (int, error) {
+
+		// Authenticate and authorize the caller, and make the principal available to the handler
+		if apiAuth != nil {
+			ctx, err := apiAuth.authorize(req, route)
+			if err != nil {
+				return 401, err
+			}
+			req = req.WithContext(ctx)
+		}
 
 		var jsonInput interface{}
 		if route.JSONInputValue != nil {
@@ -276,24 +291,32 @@ func routeHandler(o orchestrator.Orchestrator, route *oapispec.Route) http.Handl
 		}
 
 		if err == nil {
-			status = route.JSONOutputCode
-			req := oapispec.APIRequest{
-				Ctx:     req.Context(),
-				Or:      o,
-				Req:     req,
-				PP:      pathParams,
-				QP:      queryParams,
-				Filter:  filter,
-				Input:   jsonInput,
-				FReader: part,
+			req := &oapispec.APIRequest{
+				Ctx:             req.Context(),
+				Or:              o,
+				Req:             req,
+				PP:              pathParams,
+				QP:              queryParams,
+				Filter:          filter,
+				Input:           jsonInput,
+				Part:            part,
+				ResponseHeaders: res.Header(),
+				SuccessStatus:   route.JSONOutputCode,
 			}
 			if part != nil {
 				output, err = route.FormUploadHandler(req)
 			} else {
 				output, err = route.JSONHandler(req)
 			}
+			if fr, ok := output.(*filterResults); ok && err == nil {
+				output = fr.output(req.Req, filter)
+			}
+			status = req.SuccessStatus
 		}
-		if err == nil {
+		if reader, isStream := output.(io.ReadCloser); err == nil && isStream {
+			defer reader.Close()
+			status, err = streamOutput(res, req, status, reader)
+		} else if err == nil {
 			isNil := output == nil || reflect.ValueOf(output).IsNil()
 			if isNil && status != 204 {
 				err = i18n.NewError(req.Context(), i18n.Msg404NoResult)
@@ -314,6 +337,11 @@ func routeHandler(o orchestrator.Orchestrator, route *oapispec.Route) http.Handl
 }
 
 func apiWrapper(handler func(res http.ResponseWriter, req *http.Request) (status int, err error)) http.HandlerFunc {
+	return namedAPIWrapper("", handler)
+}
+
+// namedAPIWrapper additionally records metrics for the request, against the name of the route
+func namedAPIWrapper(routeName string, handler func(res http.ResponseWriter, req *http.Request) (status int, err error)) http.HandlerFunc {
 	apiTimeout := config.GetDuration(config.APIRequestTimeout) // Query once at startup when wrapping
 	return func(res http.ResponseWriter, req *http.Request) {
 
@@ -332,7 +360,8 @@ func apiWrapper(handler func(res http.ResponseWriter, req *http.Request) (status
 		l.Infof("--> %s %s", req.Method, req.URL.Path)
 		startTime := time.Now()
 		status, err := handler(res, req)
-		durationMS := float64(time.Since(startTime)) / float64(time.Millisecond)
+		duration := time.Since(startTime)
+		durationMS := float64(duration) / float64(time.Millisecond)
 		if err != nil {
 			// Routers don't need to tweak the status code when sending errors.
 			// .. either the FF12345 error they raise is mapped to a status hint
@@ -355,6 +384,9 @@ func apiWrapper(handler func(res http.ResponseWriter, req *http.Request) (status
 		} else {
 			l.Infof("<-- %s %s [%d] (%.2fms)", req.Method, req.URL.Path, status, durationMS)
 		}
+		if routeName != "" {
+			metrics.APIRequest(routeName, req.Method, status, duration)
+		}
 	}
 }
 
@@ -420,7 +452,7 @@ func createMuxRouter(o orchestrator.Orchestrator) *mux.Router {
 	r.HandleFunc(`/api`, apiWrapper(swaggerUIHandler))
 	r.HandleFunc(`/favicon{any:.*}.png`, favIcons)
 
-	r.HandleFunc(`/ws`, ws.(*websockets.WebSockets).ServeHTTP)
+	r.HandleFunc(`/ws`, wsHandler(ws.(*websockets.WebSockets).ServeHTTP))
 
 	uiPath := config.GetString(config.UIPath)
 	if uiPath != "" {
@@ -443,5 +475,9 @@ func createAdminMuxRouter(o orchestrator.Orchestrator) *mux.Router {
 	r.HandleFunc(`/admin/api`, apiWrapper(swaggerAdminUIHandler))
 	r.HandleFunc(`/favicon{any:.*}.png`, favIcons)
 
+	if config.GetBool(config.MetricsEnabled) {
+		r.Handle(config.GetString(config.MetricsPath), metrics.Handler())
+	}
+
 	return r
 }
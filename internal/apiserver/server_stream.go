@@ -0,0 +1,142 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apiserver
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/hyperledger-labs/firefly/internal/i18n"
+	"github.com/hyperledger-labs/firefly/internal/log"
+)
+
+// parseByteRange parses a single "bytes=" range from a Range header, against a known content size.
+// Multiple ranges, and syntactically invalid ranges, are ignored (ok=false) and the full content is served.
+// A syntactically valid range that cannot be satisfied is returned with satisfiable=false.
+func parseByteRange(rangeHeader string, size int64) (start, end int64, ok, satisfiable bool) {
+	if !strings.HasPrefix(rangeHeader, "bytes=") {
+		return 0, 0, false, false
+	}
+	spec := strings.TrimSpace(strings.TrimPrefix(rangeHeader, "bytes="))
+	if strings.Contains(spec, ",") {
+		return 0, 0, false, false
+	}
+	dash := strings.Index(spec, "-")
+	if dash < 0 {
+		return 0, 0, false, false
+	}
+	startStr, endStr := strings.TrimSpace(spec[0:dash]), strings.TrimSpace(spec[dash+1:])
+	var err error
+	switch {
+	case startStr == "" && endStr == "":
+		return 0, 0, false, false
+	case startStr == "":
+		// Suffix range - the final N bytes
+		var suffix int64
+		if suffix, err = strconv.ParseInt(endStr, 10, 64); err != nil || suffix < 0 {
+			return 0, 0, false, false
+		}
+		if suffix == 0 {
+			return 0, 0, true, false
+		}
+		if suffix > size {
+			suffix = size
+		}
+		start, end = size-suffix, size-1
+	default:
+		if start, err = strconv.ParseInt(startStr, 10, 64); err != nil || start < 0 {
+			return 0, 0, false, false
+		}
+		end = size - 1
+		if endStr != "" {
+			if end, err = strconv.ParseInt(endStr, 10, 64); err != nil || end < start {
+				return 0, 0, false, false
+			}
+			if end >= size {
+				end = size - 1
+			}
+		}
+	}
+	return start, end, true, start < size && start <= end
+}
+
+// clearContentHeaders removes the headers describing the binary content, before returning a JSON error
+func clearContentHeaders(headers http.Header) {
+	headers.Del("Content-Type")
+	headers.Del("Content-Length")
+	headers.Del("Content-Disposition")
+	headers.Del("Accept-Ranges")
+}
+
+// skipTo moves the reader to the start of the range, seeking where the reader supports it,
+// and otherwise reading and discarding the content before the range
+func skipTo(reader io.Reader, start int64) error {
+	if seeker, ok := reader.(io.Seeker); ok {
+		pos, err := seeker.Seek(start, io.SeekStart)
+		if err == nil && pos != start {
+			err = io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	_, err := io.CopyN(ioutil.Discard, reader, start)
+	return err
+}
+
+// streamOutput writes binary output returned by a route handler, honoring a single HTTP byte Range
+// when the handler has declared the Content-Length of the content.
+func streamOutput(res http.ResponseWriter, req *http.Request, status int, reader io.Reader) (int, error) {
+	ctx := req.Context()
+	headers := res.Header()
+	if headers.Get("Content-Type") == "" {
+		headers.Set("Content-Type", "application/octet-stream")
+	}
+
+	size, err := strconv.ParseInt(headers.Get("Content-Length"), 10, 64)
+	if err == nil && size >= 0 {
+		headers.Set("Accept-Ranges", "bytes")
+		if rangeHeader := req.Header.Get("Range"); rangeHeader != "" {
+			start, end, ok, satisfiable := parseByteRange(rangeHeader, size)
+			switch {
+			case ok && !satisfiable:
+				clearContentHeaders(headers)
+				headers.Set("Content-Range", fmt.Sprintf("bytes */%d", size))
+				return http.StatusRequestedRangeNotSatisfiable, i18n.NewError(ctx, i18n.MsgInvalidRange)
+			case ok:
+				if err := skipTo(reader, start); err != nil {
+					clearContentHeaders(headers)
+					return 500, i18n.WrapError(ctx, err, i18n.MsgBlobStreamingFailed)
+				}
+				reader = io.LimitReader(reader, end-start+1)
+				headers.Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, size))
+				headers.Set("Content-Length", strconv.FormatInt(end-start+1, 10))
+				status = http.StatusPartialContent
+			}
+		}
+	}
+
+	res.WriteHeader(status)
+	written, err := io.Copy(res, reader)
+	if err != nil {
+		// We cannot return an error to the client once the status has been written
+		log.L(ctx).Errorf("Streaming response failed after %d bytes: %s", written, err)
+	}
+	return status, nil
+}
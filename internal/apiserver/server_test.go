@@ -41,9 +41,12 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/hyperledger-labs/firefly/internal/config"
 	"github.com/hyperledger-labs/firefly/internal/i18n"
+	"github.com/hyperledger-labs/firefly/internal/metrics"
 	"github.com/hyperledger-labs/firefly/internal/oapispec"
 	"github.com/hyperledger-labs/firefly/mocks/orchestratormocks"
+	"github.com/hyperledger-labs/firefly/pkg/fftypes"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 const configDir = "../../test/data/config"
@@ -184,7 +187,7 @@ func TestJSONHTTPServePOST201(t *testing.T) {
 		JSONInputValue:  func() interface{} { return make(map[string]interface{}) },
 		JSONOutputValue: func() interface{} { return make(map[string]interface{}) },
 		JSONOutputCode:  201,
-		JSONHandler: func(r oapispec.APIRequest) (output interface{}, err error) {
+		JSONHandler: func(r *oapispec.APIRequest) (output interface{}, err error) {
 			assert.Equal(t, "value1", r.Input.(map[string]interface{})["input1"])
 			return map[string]interface{}{"output1": "value2"}, nil
 		},
@@ -210,7 +213,7 @@ func TestJSONHTTPResponseEncodeFail(t *testing.T) {
 		JSONInputValue:  nil,
 		JSONOutputValue: func() interface{} { return make(map[string]interface{}) },
 		JSONOutputCode:  200,
-		JSONHandler: func(r oapispec.APIRequest) (output interface{}, err error) {
+		JSONHandler: func(r *oapispec.APIRequest) (output interface{}, err error) {
 			v := map[string]interface{}{"unserializable": map[bool]interface{}{true: "not in JSON"}}
 			return v, nil
 		},
@@ -235,7 +238,7 @@ func TestJSONHTTPNilResponseNon204(t *testing.T) {
 		JSONInputValue:  nil,
 		JSONOutputValue: func() interface{} { return make(map[string]interface{}) },
 		JSONOutputCode:  200,
-		JSONHandler: func(r oapispec.APIRequest) (output interface{}, err error) {
+		JSONHandler: func(r *oapispec.APIRequest) (output interface{}, err error) {
 			return nil, nil
 		},
 	})
@@ -260,7 +263,7 @@ func TestJSONHTTPDefault500Error(t *testing.T) {
 		JSONInputValue:  nil,
 		JSONOutputValue: func() interface{} { return make(map[string]interface{}) },
 		JSONOutputCode:  200,
-		JSONHandler: func(r oapispec.APIRequest) (output interface{}, err error) {
+		JSONHandler: func(r *oapispec.APIRequest) (output interface{}, err error) {
 			return nil, fmt.Errorf("pop")
 		},
 	})
@@ -285,7 +288,7 @@ func TestStatusCodeHintMapping(t *testing.T) {
 		JSONInputValue:  nil,
 		JSONOutputValue: func() interface{} { return make(map[string]interface{}) },
 		JSONOutputCode:  200,
-		JSONHandler: func(r oapispec.APIRequest) (output interface{}, err error) {
+		JSONHandler: func(r *oapispec.APIRequest) (output interface{}, err error) {
 			return nil, i18n.NewError(r.Ctx, i18n.MsgResponseMarshalError)
 		},
 	})
@@ -310,7 +313,7 @@ func TestStatusInvalidContentType(t *testing.T) {
 		JSONInputValue:  nil,
 		JSONOutputValue: func() interface{} { return make(map[string]interface{}) },
 		JSONOutputCode:  204,
-		JSONHandler: func(r oapispec.APIRequest) (output interface{}, err error) {
+		JSONHandler: func(r *oapispec.APIRequest) (output interface{}, err error) {
 			return nil, nil
 		},
 	})
@@ -378,3 +381,36 @@ func TestSwaggerJSON(t *testing.T) {
 	err = json.Unmarshal(b, &openapi3.T{})
 	assert.NoError(t, err)
 }
+
+func TestAdminMetrics(t *testing.T) {
+	config.Reset()
+	metrics.Clear()
+	mo := &orchestratormocks.Orchestrator{}
+	mo.On("GetConfigRecords", mock.Anything, mock.Anything).Return([]*fftypes.ConfigRecord{}, nil, nil)
+	r := createAdminMuxRouter(mo)
+	s := httptest.NewServer(r)
+	defer s.Close()
+
+	res, err := http.Get(fmt.Sprintf("http://%s/admin/api/v1/config", s.Listener.Addr()))
+	assert.NoError(t, err)
+	assert.Equal(t, 200, res.StatusCode)
+
+	res, err = http.Get(fmt.Sprintf("http://%s/metrics", s.Listener.Addr()))
+	assert.NoError(t, err)
+	assert.Equal(t, 200, res.StatusCode)
+	b, _ := ioutil.ReadAll(res.Body)
+	assert.Regexp(t, `ff_api_request_duration_seconds_count\{method="GET",route="getConfigRecords",status="200"\} 1`, string(b))
+}
+
+func TestAdminMetricsDisabled(t *testing.T) {
+	config.Reset()
+	config.Set(config.MetricsEnabled, false)
+	mo := &orchestratormocks.Orchestrator{}
+	r := createAdminMuxRouter(mo)
+	s := httptest.NewServer(r)
+	defer s.Close()
+
+	res, err := http.Get(fmt.Sprintf("http://%s/metrics", s.Listener.Addr()))
+	assert.NoError(t, err)
+	assert.Equal(t, 404, res.StatusCode)
+}
@@ -0,0 +1,132 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package basic
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha1" // #nosec - SHA1 is supported only for compatibility with existing htpasswd files
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/hyperledger-labs/firefly/internal/config"
+	"github.com/hyperledger-labs/firefly/internal/i18n"
+	"github.com/hyperledger-labs/firefly/internal/log"
+	"github.com/hyperledger-labs/firefly/pkg/auth"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const shaPrefix = "{SHA}"
+
+type Basic struct {
+	users  map[string]string
+	groups map[string][]string
+}
+
+func (b *Basic) Name() string {
+	return "basic"
+}
+
+func (b *Basic) Init(ctx context.Context, prefix config.Prefix) (err error) {
+	b.users = make(map[string]string)
+	b.groups = make(map[string][]string)
+
+	passwordFile := prefix.GetString(BasicConfPasswordFile)
+	if err = readEntries(ctx, passwordFile, func(user, hash string) error {
+		if !strings.HasPrefix(hash, "$2") && !strings.HasPrefix(hash, shaPrefix) {
+			return i18n.NewError(ctx, i18n.MsgAuthUnsupportedHash, user)
+		}
+		b.users[user] = hash
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	groupFile := prefix.GetString(BasicConfGroupFile)
+	if groupFile != "" {
+		if err = readEntries(ctx, groupFile, func(group, members string) error {
+			for _, user := range strings.Fields(members) {
+				b.groups[user] = append(b.groups[user], group)
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+
+	log.L(ctx).Infof("Loaded %d users from password file '%s'", len(b.users), passwordFile)
+	return nil
+}
+
+// readEntries parses the "key:value" lines of an htpasswd or htgroup file, skipping blank lines and comments
+func readEntries(ctx context.Context, filename string, entry func(key, value string) error) error {
+	f, err := os.Open(filename)
+	if err != nil {
+		return i18n.WrapError(ctx, err, i18n.MsgAuthFileLoadFailed, filename)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		sep := strings.Index(line, ":")
+		if sep <= 0 {
+			return i18n.NewError(ctx, i18n.MsgAuthFileInvalidEntry, lineNo, filename)
+		}
+		if err := entry(strings.TrimSpace(line[0:sep]), strings.TrimSpace(line[sep+1:])); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return i18n.WrapError(ctx, err, i18n.MsgAuthFileLoadFailed, filename)
+	}
+	return nil
+}
+
+func (b *Basic) Authenticate(ctx context.Context, req *http.Request) (*auth.Principal, error) {
+	user, password, ok := req.BasicAuth()
+	if !ok {
+		return nil, nil
+	}
+
+	hash, ok := b.users[user]
+	if !ok || !b.checkPassword(hash, password) {
+		log.L(ctx).Warnf("Basic auth failed for user '%s'", user)
+		return nil, i18n.NewError(ctx, i18n.MsgAuthInvalidCredentials)
+	}
+
+	return &auth.Principal{
+		Subject: user,
+		Groups:  b.groups[user],
+		Method:  b.Name(),
+	}, nil
+}
+
+func (b *Basic) checkPassword(hash, password string) bool {
+	if strings.HasPrefix(hash, shaPrefix) {
+		sum := sha1.Sum([]byte(password)) // #nosec
+		expected := shaPrefix + base64.StdEncoding.EncodeToString(sum[:])
+		return subtle.ConstantTimeCompare([]byte(hash), []byte(expected)) == 1
+	}
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
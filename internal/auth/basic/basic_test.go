@@ -0,0 +1,172 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package basic
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/hyperledger-labs/firefly/internal/config"
+	"github.com/hyperledger-labs/firefly/pkg/auth"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var utConfPrefix = config.NewPluginConfig("basic_unit_tests")
+
+func newTestBasic(t *testing.T, passwords, groups string) (*Basic, func()) {
+	dir, err := ioutil.TempDir("", "basic")
+	assert.NoError(t, err)
+	passwordFile := path.Join(dir, "htpasswd")
+	err = ioutil.WriteFile(passwordFile, []byte(passwords), 0600)
+	assert.NoError(t, err)
+
+	config.Reset()
+	b := &Basic{}
+	b.InitPrefix(utConfPrefix)
+	utConfPrefix.Set(BasicConfPasswordFile, passwordFile)
+	if groups != "" {
+		groupFile := path.Join(dir, "htgroup")
+		err = ioutil.WriteFile(groupFile, []byte(groups), 0600)
+		assert.NoError(t, err)
+		utConfPrefix.Set(BasicConfGroupFile, groupFile)
+	}
+	return b, func() {
+		os.RemoveAll(dir)
+	}
+}
+
+func testPasswords(t *testing.T) string {
+	bcryptHash, err := bcrypt.GenerateFromPassword([]byte("pass1"), bcrypt.MinCost)
+	assert.NoError(t, err)
+	shaHash := sha1.Sum([]byte("pass2"))
+	return fmt.Sprintf("# comment\nuser1:%s\n\nuser2:{SHA}%s\n", bcryptHash, base64.StdEncoding.EncodeToString(shaHash[:]))
+}
+
+func TestInitAuthenticateOk(t *testing.T) {
+	b, done := newTestBasic(t, testPasswords(t), "admins: user1\nreaders: user1 user2\n")
+	defer done()
+	var p auth.Plugin = b
+	err := p.Init(context.Background(), utConfPrefix)
+	assert.NoError(t, err)
+	assert.Equal(t, "basic", p.Name())
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.SetBasicAuth("user1", "pass1")
+	principal, err := p.Authenticate(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, "user1", principal.Subject)
+	assert.Equal(t, []string{"admins", "readers"}, principal.Groups)
+	assert.Equal(t, "basic", principal.Method)
+
+	req.SetBasicAuth("user2", "pass2")
+	principal, err = p.Authenticate(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, "user2", principal.Subject)
+	assert.Equal(t, []string{"readers"}, principal.Groups)
+}
+
+func TestAuthenticateNoCredentials(t *testing.T) {
+	b, done := newTestBasic(t, testPasswords(t), "")
+	defer done()
+	err := b.Init(context.Background(), utConfPrefix)
+	assert.NoError(t, err)
+
+	principal, err := b.Authenticate(context.Background(), httptest.NewRequest("GET", "/", nil))
+	assert.NoError(t, err)
+	assert.Nil(t, principal)
+}
+
+func TestAuthenticateBadPassword(t *testing.T) {
+	b, done := newTestBasic(t, testPasswords(t), "")
+	defer done()
+	err := b.Init(context.Background(), utConfPrefix)
+	assert.NoError(t, err)
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.SetBasicAuth("user1", "pass2")
+	_, err = b.Authenticate(context.Background(), req)
+	assert.Regexp(t, "FF10239", err)
+
+	req.SetBasicAuth("user2", "pass1")
+	_, err = b.Authenticate(context.Background(), req)
+	assert.Regexp(t, "FF10239", err)
+}
+
+func TestAuthenticateUnknownUser(t *testing.T) {
+	b, done := newTestBasic(t, testPasswords(t), "")
+	defer done()
+	err := b.Init(context.Background(), utConfPrefix)
+	assert.NoError(t, err)
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.SetBasicAuth("user3", "pass1")
+	_, err = b.Authenticate(context.Background(), req)
+	assert.Regexp(t, "FF10239", err)
+}
+
+func TestInitMissingPasswordFile(t *testing.T) {
+	config.Reset()
+	b := &Basic{}
+	b.InitPrefix(utConfPrefix)
+	utConfPrefix.Set(BasicConfPasswordFile, "/does/not/exist")
+	err := b.Init(context.Background(), utConfPrefix)
+	assert.Regexp(t, "FF10240", err)
+}
+
+func TestInitMissingGroupFile(t *testing.T) {
+	b, done := newTestBasic(t, testPasswords(t), "")
+	defer done()
+	utConfPrefix.Set(BasicConfGroupFile, "/does/not/exist")
+	err := b.Init(context.Background(), utConfPrefix)
+	assert.Regexp(t, "FF10240", err)
+}
+
+func TestInitBadPasswordEntry(t *testing.T) {
+	b, done := newTestBasic(t, "user1\n", "")
+	defer done()
+	err := b.Init(context.Background(), utConfPrefix)
+	assert.Regexp(t, "FF10241.*line 1", err)
+}
+
+func TestInitUnsupportedHash(t *testing.T) {
+	b, done := newTestBasic(t, "user1:$apr1$abcdefgh$ijklmnop\n", "")
+	defer done()
+	err := b.Init(context.Background(), utConfPrefix)
+	assert.Regexp(t, "FF10242.*user1", err)
+}
+
+func TestInitBadGroupEntry(t *testing.T) {
+	b, done := newTestBasic(t, testPasswords(t), "admins: user1\n:user2\n")
+	defer done()
+	err := b.Init(context.Background(), utConfPrefix)
+	assert.Regexp(t, "FF10241.*line 2", err)
+}
+
+func TestReadEntriesScannerFail(t *testing.T) {
+	b, done := newTestBasic(t, fmt.Sprintf("user1:%0100000d\n", 0), "")
+	defer done()
+	err := b.Init(context.Background(), utConfPrefix)
+	assert.Regexp(t, "FF10240", err)
+}
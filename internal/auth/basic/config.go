@@ -0,0 +1,33 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package basic
+
+import (
+	"github.com/hyperledger-labs/firefly/internal/config"
+)
+
+const (
+	// BasicConfPasswordFile is the path to an htpasswd format file, containing the users and their bcrypt or SHA1 password hashes
+	BasicConfPasswordFile = "passwordFile"
+	// BasicConfGroupFile is the path to an optional htgroup format file, assigning users to groups
+	BasicConfGroupFile = "groupFile"
+)
+
+func (b *Basic) InitPrefix(prefix config.Prefix) {
+	prefix.AddKnownKey(BasicConfPasswordFile)
+	prefix.AddKnownKey(BasicConfGroupFile)
+}
@@ -0,0 +1,45 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import (
+	"github.com/hyperledger-labs/firefly/internal/config"
+)
+
+const (
+	// JWTConfJWKSFile is the path to a JSON Web Key Set file, containing the public keys used to verify token signatures
+	JWTConfJWKSFile = "jwksFile"
+	// JWTConfIssuer if set, the "iss" claim of each token must match this value
+	JWTConfIssuer = "issuer"
+	// JWTConfAudience if set, the "aud" claim of each token must contain this value
+	JWTConfAudience = "audience"
+	// JWTConfSubjectClaim is the claim to use as the principal
+	JWTConfSubjectClaim = "subjectClaim"
+	// JWTConfGroupsClaim is the claim containing an array of the groups of the principal
+	JWTConfGroupsClaim = "groupsClaim"
+	// JWTConfAllowNoExpiry if true, tokens without an "exp" claim are accepted. Otherwise they are rejected
+	JWTConfAllowNoExpiry = "allowNoExpiry"
+)
+
+func (j *JWT) InitPrefix(prefix config.Prefix) {
+	prefix.AddKnownKey(JWTConfJWKSFile)
+	prefix.AddKnownKey(JWTConfIssuer)
+	prefix.AddKnownKey(JWTConfAudience)
+	prefix.AddKnownKey(JWTConfSubjectClaim, "sub")
+	prefix.AddKnownKey(JWTConfGroupsClaim, "groups")
+	prefix.AddKnownKey(JWTConfAllowNoExpiry, false)
+}
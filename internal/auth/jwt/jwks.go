@@ -0,0 +1,122 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+
+	"github.com/hyperledger-labs/firefly/internal/i18n"
+	"github.com/hyperledger-labs/firefly/internal/log"
+)
+
+type jwk struct {
+	KeyType string `json:"kty"`
+	KeyID   string `json:"kid"`
+	Use     string `json:"use"`
+	N       string `json:"n"`
+	E       string `json:"e"`
+	Curve   string `json:"crv"`
+	X       string `json:"x"`
+	Y       string `json:"y"`
+}
+
+type jwks struct {
+	Keys []*jwk `json:"keys"`
+}
+
+var curves = map[string]elliptic.Curve{
+	"P-256": elliptic.P256(),
+	"P-384": elliptic.P384(),
+	"P-521": elliptic.P521(),
+}
+
+// loadJWKS reads the RSA and EC signing keys from a JWKS file. Keys of other types are ignored.
+func loadJWKS(ctx context.Context, filename string) (map[string]interface{}, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, i18n.WrapError(ctx, err, i18n.MsgAuthFileLoadFailed, filename)
+	}
+	var keySet jwks
+	if err = json.Unmarshal(b, &keySet); err != nil {
+		return nil, i18n.WrapError(ctx, err, i18n.MsgAuthFileLoadFailed, filename)
+	}
+
+	keys := make(map[string]interface{})
+	for _, k := range keySet.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		var key interface{}
+		switch k.KeyType {
+		case "RSA":
+			key, err = k.rsaPublicKey(ctx)
+		case "EC":
+			key, err = k.ecPublicKey(ctx)
+		default:
+			log.L(ctx).Warnf("Ignoring key '%s' with unsupported type '%s' in JWKS file", k.KeyID, k.KeyType)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		keys[k.KeyID] = key
+	}
+	return keys, nil
+}
+
+func (k *jwk) decodeBigInt(ctx context.Context, s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil || len(b) == 0 {
+		return nil, i18n.NewError(ctx, i18n.MsgJWKSInvalidKey, k.KeyID)
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+func (k *jwk) rsaPublicKey(ctx context.Context) (*rsa.PublicKey, error) {
+	n, err := k.decodeBigInt(ctx, k.N)
+	if err != nil {
+		return nil, err
+	}
+	e, err := k.decodeBigInt(ctx, k.E)
+	if err != nil {
+		return nil, err
+	}
+	return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+}
+
+func (k *jwk) ecPublicKey(ctx context.Context) (*ecdsa.PublicKey, error) {
+	curve, ok := curves[k.Curve]
+	if !ok {
+		return nil, i18n.NewError(ctx, i18n.MsgJWKSInvalidKey, k.KeyID)
+	}
+	x, err := k.decodeBigInt(ctx, k.X)
+	if err != nil {
+		return nil, err
+	}
+	y, err := k.decodeBigInt(ctx, k.Y)
+	if err != nil {
+		return nil, err
+	}
+	return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+}
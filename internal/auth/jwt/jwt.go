@@ -0,0 +1,206 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hyperledger-labs/firefly/internal/config"
+	"github.com/hyperledger-labs/firefly/internal/i18n"
+	"github.com/hyperledger-labs/firefly/internal/log"
+	"github.com/hyperledger-labs/firefly/pkg/auth"
+	"github.com/hyperledger-labs/firefly/pkg/fftypes"
+)
+
+var algorithms = map[string]crypto.Hash{
+	"RS256": crypto.SHA256,
+	"RS384": crypto.SHA384,
+	"RS512": crypto.SHA512,
+	"ES256": crypto.SHA256,
+	"ES384": crypto.SHA384,
+	"ES512": crypto.SHA512,
+}
+
+// ecCurves is the only curve each of the EC algorithms can be used with (RFC 7518 section 3.4)
+var ecCurves = map[string]elliptic.Curve{
+	"ES256": elliptic.P256(),
+	"ES384": elliptic.P384(),
+	"ES512": elliptic.P521(),
+}
+
+// JWT verifies bearer tokens signed with one of the keys in a local JWKS file.
+// Only asymmetric RSA and EC signatures are supported.
+type JWT struct {
+	keys          map[string]interface{}
+	issuer        string
+	audience      string
+	subjectClaim  string
+	groupsClaim   string
+	allowNoExpiry bool
+}
+
+type jwtHeader struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid"`
+}
+
+func (j *JWT) Name() string {
+	return "jwt"
+}
+
+func (j *JWT) Init(ctx context.Context, prefix config.Prefix) (err error) {
+	jwksFile := prefix.GetString(JWTConfJWKSFile)
+	if j.keys, err = loadJWKS(ctx, jwksFile); err != nil {
+		return err
+	}
+	j.issuer = prefix.GetString(JWTConfIssuer)
+	j.audience = prefix.GetString(JWTConfAudience)
+	j.subjectClaim = prefix.GetString(JWTConfSubjectClaim)
+	j.groupsClaim = prefix.GetString(JWTConfGroupsClaim)
+	j.allowNoExpiry = prefix.GetBool(JWTConfAllowNoExpiry)
+	log.L(ctx).Infof("Loaded %d keys from JWKS file '%s'", len(j.keys), jwksFile)
+	return nil
+}
+
+func (j *JWT) Authenticate(ctx context.Context, req *http.Request) (*auth.Principal, error) {
+	authHeader := req.Header.Get("Authorization")
+	if len(authHeader) < 7 || !strings.EqualFold(authHeader[0:7], "bearer ") {
+		return nil, nil
+	}
+
+	claims, err := j.verify(ctx, strings.TrimSpace(authHeader[7:]))
+	if err != nil {
+		return nil, err
+	}
+
+	subject := claims.GetString(j.subjectClaim)
+	if subject == "" {
+		return nil, j.invalid(ctx, "missing subject claim '%s'", j.subjectClaim)
+	}
+	var groups []string
+	if _, ok := claims[j.groupsClaim]; ok {
+		groups, _ = fftypes.ToStringArray(claims[j.groupsClaim])
+	}
+
+	return &auth.Principal{
+		Subject: subject,
+		Groups:  groups,
+		Method:  j.Name(),
+	}, nil
+}
+
+// invalid logs the detailed reason a token was rejected, and returns a generic error to the caller
+func (j *JWT) invalid(ctx context.Context, reason string, args ...interface{}) error {
+	log.L(ctx).Warnf("JWT rejected: "+reason, args...)
+	return i18n.NewError(ctx, i18n.MsgJWTInvalid)
+}
+
+func (j *JWT) verify(ctx context.Context, token string) (fftypes.JSONObject, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, j.invalid(ctx, "malformed token")
+	}
+
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, j.invalid(ctx, "bad header: %s", err)
+	}
+	var claims fftypes.JSONObject
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, j.invalid(ctx, "bad claims: %s", err)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, j.invalid(ctx, "bad signature: %s", err)
+	}
+
+	hashType, ok := algorithms[header.Algorithm]
+	if !ok {
+		return nil, j.invalid(ctx, "unsupported algorithm '%s'", header.Algorithm)
+	}
+	key, ok := j.keys[header.KeyID]
+	if !ok {
+		return nil, j.invalid(ctx, "unknown key '%s'", header.KeyID)
+	}
+	hasher := hashType.New()
+	hasher.Write([]byte(parts[0] + "." + parts[1]))
+	digest := hasher.Sum(nil)
+
+	switch key := key.(type) {
+	case *rsa.PublicKey:
+		ok = strings.HasPrefix(header.Algorithm, "RS") && rsa.VerifyPKCS1v15(key, hashType, digest, sig) == nil
+	case *ecdsa.PublicKey:
+		keyBytes := (key.Curve.Params().BitSize + 7) / 8
+		ok = ecCurves[header.Algorithm] == key.Curve && len(sig) == 2*keyBytes &&
+			ecdsa.Verify(key, digest, new(big.Int).SetBytes(sig[:keyBytes]), new(big.Int).SetBytes(sig[keyBytes:]))
+	}
+	if !ok {
+		return nil, j.invalid(ctx, "signature verification failed with key '%s'", header.KeyID)
+	}
+
+	return claims, j.verifyClaims(ctx, claims)
+}
+
+func (j *JWT) verifyClaims(ctx context.Context, claims fftypes.JSONObject) error {
+	now := time.Now().Unix()
+	exp, ok := claims["exp"].(float64)
+	switch {
+	case !ok && claims["exp"] != nil:
+		return j.invalid(ctx, "invalid expiry")
+	case !ok && !j.allowNoExpiry:
+		return j.invalid(ctx, "token has no expiry")
+	case ok && now >= int64(exp):
+		return j.invalid(ctx, "token expired")
+	}
+	if nbf, ok := claims["nbf"].(float64); ok && now < int64(nbf) {
+		return j.invalid(ctx, "token not yet valid")
+	}
+	if j.issuer != "" && claims.GetString("iss") != j.issuer {
+		return j.invalid(ctx, "issuer '%s' does not match", claims.GetString("iss"))
+	}
+	if j.audience != "" {
+		audiences, ok := fftypes.ToStringArray(claims["aud"])
+		if !ok {
+			audiences = []string{claims.GetString("aud")}
+		}
+		for _, aud := range audiences {
+			if aud == j.audience {
+				return nil
+			}
+		}
+		return j.invalid(ctx, "audience %v does not match", audiences)
+	}
+	return nil
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(segment)
+	if err == nil {
+		err = json.Unmarshal(b, v)
+	}
+	return err
+}
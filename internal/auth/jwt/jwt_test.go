@@ -0,0 +1,328 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jwt
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"net/http/httptest"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/hyperledger-labs/firefly/internal/config"
+	"github.com/hyperledger-labs/firefly/pkg/auth"
+	"github.com/hyperledger-labs/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+)
+
+var utConfPrefix = config.NewPluginConfig("jwt_unit_tests")
+
+type testKeys struct {
+	rsaKey *rsa.PrivateKey
+	ecKey  *ecdsa.PrivateKey
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func newTestKeys(t *testing.T) *testKeys {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	return &testKeys{rsaKey: rsaKey, ecKey: ecKey}
+}
+
+func (tk *testKeys) jwks() []*jwk {
+	return []*jwk{
+		{KeyType: "RSA", KeyID: "rsa1", Use: "sig", N: b64(tk.rsaKey.N.Bytes()), E: b64(big.NewInt(int64(tk.rsaKey.E)).Bytes())},
+		{KeyType: "EC", KeyID: "ec1", Curve: "P-256", X: b64(tk.ecKey.X.Bytes()), Y: b64(tk.ecKey.Y.Bytes())},
+		{KeyType: "RSA", KeyID: "enc1", Use: "enc"},
+		{KeyType: "oct", KeyID: "hmac1"},
+	}
+}
+
+func (tk *testKeys) sign(t *testing.T, alg, kid string, claims fftypes.JSONObject) string {
+	header, _ := json.Marshal(&jwtHeader{Algorithm: alg, KeyID: kid})
+	payload, _ := json.Marshal(&claims)
+	signingInput := b64(header) + "." + b64(payload)
+	hashType, ok := algorithms[alg]
+	if !ok {
+		hashType = crypto.SHA256
+	}
+	hasher := hashType.New()
+	hasher.Write([]byte(signingInput))
+	digest := hasher.Sum(nil)
+	var sig []byte
+	var err error
+	switch alg {
+	case "ES256", "ES512":
+		var r, s *big.Int
+		r, s, err = ecdsa.Sign(rand.Reader, tk.ecKey, digest)
+		sig = make([]byte, 64)
+		r.FillBytes(sig[0:32])
+		s.FillBytes(sig[32:])
+	default:
+		sig, err = rsa.SignPKCS1v15(rand.Reader, tk.rsaKey, crypto.SHA256, digest)
+	}
+	assert.NoError(t, err)
+	return signingInput + "." + b64(sig)
+}
+
+func writeJWKS(t *testing.T, keys []*jwk) (string, func()) {
+	dir, err := ioutil.TempDir("", "jwt")
+	assert.NoError(t, err)
+	jwksFile := path.Join(dir, "jwks.json")
+	b, _ := json.Marshal(&jwks{Keys: keys})
+	err = ioutil.WriteFile(jwksFile, b, 0600)
+	assert.NoError(t, err)
+	return jwksFile, func() {
+		os.RemoveAll(dir)
+	}
+}
+
+func newTestJWT(t *testing.T, tk *testKeys) (*JWT, func()) {
+	jwksFile, done := writeJWKS(t, tk.jwks())
+	config.Reset()
+	j := &JWT{}
+	j.InitPrefix(utConfPrefix)
+	utConfPrefix.Set(JWTConfJWKSFile, jwksFile)
+	err := j.Init(context.Background(), utConfPrefix)
+	assert.NoError(t, err)
+	return j, done
+}
+
+func authenticate(j *JWT, token string) (*auth.Principal, error) {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	return j.Authenticate(context.Background(), req)
+}
+
+func TestAuthenticateRSAOk(t *testing.T) {
+	tk := newTestKeys(t)
+	j, done := newTestJWT(t, tk)
+	defer done()
+	var p auth.Plugin = j
+	assert.Equal(t, "jwt", p.Name())
+	assert.Len(t, j.keys, 2)
+
+	principal, err := authenticate(j, tk.sign(t, "RS256", "rsa1", fftypes.JSONObject{
+		"sub":    "user1",
+		"groups": []string{"admins", "readers"},
+		"exp":    time.Now().Add(1 * time.Minute).Unix(),
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, "user1", principal.Subject)
+	assert.Equal(t, []string{"admins", "readers"}, principal.Groups)
+	assert.Equal(t, "jwt", principal.Method)
+}
+
+func TestAuthenticateECOk(t *testing.T) {
+	tk := newTestKeys(t)
+	j, done := newTestJWT(t, tk)
+	defer done()
+
+	principal, err := authenticate(j, tk.sign(t, "ES256", "ec1", fftypes.JSONObject{
+		"sub": "user1",
+		"exp": time.Now().Add(1 * time.Minute).Unix(),
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, "user1", principal.Subject)
+	assert.Nil(t, principal.Groups)
+}
+
+func TestAuthenticateIssuerAudienceOk(t *testing.T) {
+	tk := newTestKeys(t)
+	j, done := newTestJWT(t, tk)
+	defer done()
+	j.issuer = "issuer1"
+	j.audience = "firefly"
+
+	principal, err := authenticate(j, tk.sign(t, "RS256", "rsa1", fftypes.JSONObject{
+		"sub": "user1",
+		"iss": "issuer1",
+		"aud": "firefly",
+		"exp": time.Now().Add(1 * time.Minute).Unix(),
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, "user1", principal.Subject)
+
+	principal, err = authenticate(j, tk.sign(t, "RS256", "rsa1", fftypes.JSONObject{
+		"sub": "user1",
+		"iss": "issuer1",
+		"aud": []string{"other", "firefly"},
+		"exp": time.Now().Add(1 * time.Minute).Unix(),
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, "user1", principal.Subject)
+}
+
+func TestAuthenticateNoExpiryAllowed(t *testing.T) {
+	tk := newTestKeys(t)
+	jwksFile, done := writeJWKS(t, tk.jwks())
+	defer done()
+	config.Reset()
+	j := &JWT{}
+	j.InitPrefix(utConfPrefix)
+	utConfPrefix.Set(JWTConfJWKSFile, jwksFile)
+	utConfPrefix.Set(JWTConfAllowNoExpiry, true)
+	err := j.Init(context.Background(), utConfPrefix)
+	assert.NoError(t, err)
+
+	principal, err := authenticate(j, tk.sign(t, "RS256", "rsa1", fftypes.JSONObject{
+		"sub": "user1",
+	}))
+	assert.NoError(t, err)
+	assert.Equal(t, "user1", principal.Subject)
+
+	_, err = authenticate(j, tk.sign(t, "RS256", "rsa1", fftypes.JSONObject{
+		"sub": "user1",
+		"exp": "tomorrow",
+	}))
+	assert.Regexp(t, "FF10243", err)
+}
+
+func TestAuthenticateNoToken(t *testing.T) {
+	tk := newTestKeys(t)
+	j, done := newTestJWT(t, tk)
+	defer done()
+
+	req := httptest.NewRequest("GET", "/", nil)
+	principal, err := j.Authenticate(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Nil(t, principal)
+
+	req.SetBasicAuth("user1", "pass1")
+	principal, err = j.Authenticate(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Nil(t, principal)
+}
+
+func TestAuthenticateInvalidClaims(t *testing.T) {
+	tk := newTestKeys(t)
+	j, done := newTestJWT(t, tk)
+	defer done()
+	j.issuer = "issuer1"
+	j.audience = "firefly"
+
+	valid := fftypes.JSONObject{"sub": "user1", "iss": "issuer1", "aud": "firefly", "exp": time.Now().Add(1 * time.Minute).Unix()}
+	for name, override := range map[string]fftypes.JSONObject{
+		"expired":       {"exp": time.Now().Add(-1 * time.Minute).Unix()},
+		"noExpiry":      {"exp": nil},
+		"invalidExpiry": {"exp": "tomorrow"},
+		"notYetValid":   {"nbf": time.Now().Add(1 * time.Minute).Unix()},
+		"wrongIssuer":   {"iss": "issuer2"},
+		"wrongAudience": {"aud": []string{"other"}},
+		"noSubject":     {"sub": ""},
+	} {
+		claims := fftypes.JSONObject{}
+		for k, v := range valid {
+			claims[k] = v
+		}
+		for k, v := range override {
+			if v == nil {
+				delete(claims, k)
+			} else {
+				claims[k] = v
+			}
+		}
+		_, err := authenticate(j, tk.sign(t, "RS256", "rsa1", claims))
+		assert.Regexp(t, "FF10243", err, name)
+	}
+}
+
+func TestAuthenticateInvalidTokens(t *testing.T) {
+	tk := newTestKeys(t)
+	j, done := newTestJWT(t, tk)
+	defer done()
+
+	claims := fftypes.JSONObject{"sub": "user1"}
+	header, _ := json.Marshal(&jwtHeader{Algorithm: "RS256", KeyID: "rsa1"})
+	rsaToken := tk.sign(t, "RS256", "rsa1", claims)
+	ecToken := tk.sign(t, "ES256", "ec1", claims)
+	for name, token := range map[string]string{
+		"malformed":      "not.a.jwt.token",
+		"badHeader":      "!!!." + b64([]byte(`{}`)) + ".",
+		"badClaims":      b64(header) + "." + b64([]byte(`[]`)) + ".",
+		"badSignature":   b64(header) + "." + b64([]byte(`{}`)) + ".!!!",
+		"badAlgorithm":   tk.sign(t, "HS256", "rsa1", claims),
+		"unknownKey":     tk.sign(t, "RS256", "rsa2", claims),
+		"wrongKeyType":   tk.sign(t, "ES256", "rsa1", claims),
+		"wrongKeyTypeEC": tk.sign(t, "RS256", "ec1", claims),
+		"wrongCurve":     tk.sign(t, "ES512", "ec1", claims),
+		"rsaTampered":    rsaToken[0:len(rsaToken)-4] + "AAAA",
+		"ecTampered":     ecToken[0:len(ecToken)-4] + "AAAA",
+	} {
+		_, err := authenticate(j, token)
+		assert.Regexp(t, "FF10243", err, name)
+	}
+}
+
+func TestInitMissingJWKSFile(t *testing.T) {
+	config.Reset()
+	j := &JWT{}
+	j.InitPrefix(utConfPrefix)
+	utConfPrefix.Set(JWTConfJWKSFile, "/does/not/exist")
+	err := j.Init(context.Background(), utConfPrefix)
+	assert.Regexp(t, "FF10240", err)
+}
+
+func TestInitBadJWKSFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jwt")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	jwksFile := path.Join(dir, "jwks.json")
+	err = ioutil.WriteFile(jwksFile, []byte("!json"), 0600)
+	assert.NoError(t, err)
+
+	config.Reset()
+	j := &JWT{}
+	j.InitPrefix(utConfPrefix)
+	utConfPrefix.Set(JWTConfJWKSFile, jwksFile)
+	err = j.Init(context.Background(), utConfPrefix)
+	assert.Regexp(t, "FF10240", err)
+}
+
+func TestInitInvalidKeys(t *testing.T) {
+	for name, key := range map[string]*jwk{
+		"badN":     {KeyType: "RSA", KeyID: "key1", N: "!!!", E: "AQAB"},
+		"badE":     {KeyType: "RSA", KeyID: "key1", N: "AQAB", E: ""},
+		"badCurve": {KeyType: "EC", KeyID: "key1", Curve: "P-1"},
+		"badX":     {KeyType: "EC", KeyID: "key1", Curve: "P-256", X: "!!!"},
+		"badY":     {KeyType: "EC", KeyID: "key1", Curve: "P-256", X: "AQAB", Y: "!!!"},
+	} {
+		jwksFile, done := writeJWKS(t, []*jwk{key})
+		config.Reset()
+		j := &JWT{}
+		j.InitPrefix(utConfPrefix)
+		utConfPrefix.Set(JWTConfJWKSFile, jwksFile)
+		err := j.Init(context.Background(), utConfPrefix)
+		assert.Regexp(t, "FF10244.*key1", err, name)
+		done()
+	}
+}
@@ -0,0 +1,30 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mtls
+
+import (
+	"github.com/hyperledger-labs/firefly/internal/config"
+)
+
+const (
+	// MTLSConfSubjectField is the field of the client certificate subject to use as the principal - "cn" (default) or "dn"
+	MTLSConfSubjectField = "subjectField"
+)
+
+func (m *MTLS) InitPrefix(prefix config.Prefix) {
+	prefix.AddKnownKey(MTLSConfSubjectField, "cn")
+}
@@ -0,0 +1,66 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mtls
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/hyperledger-labs/firefly/internal/config"
+	"github.com/hyperledger-labs/firefly/internal/log"
+	"github.com/hyperledger-labs/firefly/pkg/auth"
+)
+
+// MTLS resolves the principal from the subject of the client certificate supplied on a mutual TLS connection.
+// The certificate has already been verified against the configured CA by the HTTP server, so http.tls.clientAuth
+// must be enabled for this plugin to be useful.
+// The organizational units of the subject are mapped to groups.
+type MTLS struct {
+	useDN bool
+}
+
+func (m *MTLS) Name() string {
+	return "mtls"
+}
+
+func (m *MTLS) Init(ctx context.Context, prefix config.Prefix) error {
+	m.useDN = strings.EqualFold(prefix.GetString(MTLSConfSubjectField), "dn")
+	return nil
+}
+
+func (m *MTLS) Authenticate(ctx context.Context, req *http.Request) (*auth.Principal, error) {
+	if req.TLS == nil || len(req.TLS.VerifiedChains) == 0 || len(req.TLS.VerifiedChains[0]) == 0 {
+		return nil, nil
+	}
+
+	cert := req.TLS.VerifiedChains[0][0]
+	subject := cert.Subject.CommonName
+	if m.useDN {
+		subject = cert.Subject.String()
+	}
+	if subject == "" {
+		log.L(ctx).Warnf("Client certificate has an empty subject: %s", cert.Subject)
+		return nil, nil
+	}
+
+	return &auth.Principal{
+		Subject: subject,
+		Groups:  cert.Subject.OrganizationalUnit,
+		Method:  m.Name(),
+	}, nil
+}
@@ -0,0 +1,101 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mtls
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hyperledger-labs/firefly/internal/config"
+	"github.com/hyperledger-labs/firefly/pkg/auth"
+	"github.com/stretchr/testify/assert"
+)
+
+var utConfPrefix = config.NewPluginConfig("mtls_unit_tests")
+
+func newTestMTLS(t *testing.T, subjectField string) *MTLS {
+	config.Reset()
+	m := &MTLS{}
+	m.InitPrefix(utConfPrefix)
+	if subjectField != "" {
+		utConfPrefix.Set(MTLSConfSubjectField, subjectField)
+	}
+	err := m.Init(context.Background(), utConfPrefix)
+	assert.NoError(t, err)
+	return m
+}
+
+func TestAuthenticateCommonName(t *testing.T) {
+	var p auth.Plugin = newTestMTLS(t, "")
+	assert.Equal(t, "mtls", p.Name())
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.TLS = &tls.ConnectionState{
+		VerifiedChains: [][]*x509.Certificate{{
+			{Subject: pkix.Name{CommonName: "user1", Organization: []string{"org1"}, OrganizationalUnit: []string{"admins"}}},
+		}},
+	}
+	principal, err := p.Authenticate(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, "user1", principal.Subject)
+	assert.Equal(t, []string{"admins"}, principal.Groups)
+	assert.Equal(t, "mtls", principal.Method)
+}
+
+func TestAuthenticateDistinguishedName(t *testing.T) {
+	m := newTestMTLS(t, "dn")
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.TLS = &tls.ConnectionState{
+		VerifiedChains: [][]*x509.Certificate{{
+			{Subject: pkix.Name{CommonName: "user1", Organization: []string{"org1"}}},
+		}},
+	}
+	principal, err := m.Authenticate(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Equal(t, "CN=user1,O=org1", principal.Subject)
+}
+
+func TestAuthenticateNoCert(t *testing.T) {
+	m := newTestMTLS(t, "")
+
+	req := httptest.NewRequest("GET", "/", nil)
+	principal, err := m.Authenticate(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Nil(t, principal)
+
+	req.TLS = &tls.ConnectionState{}
+	principal, err = m.Authenticate(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Nil(t, principal)
+}
+
+func TestAuthenticateEmptySubject(t *testing.T) {
+	m := newTestMTLS(t, "")
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.TLS = &tls.ConnectionState{
+		VerifiedChains: [][]*x509.Certificate{{{}}},
+	}
+	principal, err := m.Authenticate(context.Background(), req)
+	assert.NoError(t, err)
+	assert.Nil(t, principal)
+}
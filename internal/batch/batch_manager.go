@@ -153,13 +153,17 @@ func (bm *batchManager) removeProcessor(dispatcher *dispatcher, key string) {
 	dispatcher.mux.Unlock()
 }
 
-func (bm *batchManager) getProcessor(batchType fftypes.MessageType, group *fftypes.Bytes32, namespace, author string) (*batchProcessor, error) {
+func (bm *batchManager) getProcessor(batchType fftypes.MessageType, group *fftypes.Bytes32, namespace, author string, txType fftypes.TransactionType) (*batchProcessor, error) {
 	dispatcher, ok := bm.dispatchers[batchType]
 	if !ok {
 		return nil, i18n.NewError(bm.ctx, i18n.MsgUnregisteredBatchType, batchType)
 	}
+	if txType == "" {
+		txType = fftypes.TransactionTypeBatchPin
+	}
 	dispatcher.mux.Lock()
-	key := fmt.Sprintf("%s:%s[group=%v]", namespace, author, group)
+	// Messages that are not pinned to the blockchain cannot share a batch with those that are
+	key := fmt.Sprintf("%s:%s[group=%v,tx=%s]", namespace, author, group, txType)
 	processor, ok := dispatcher.processors[key]
 	if !ok {
 		processor = newBatchProcessor(
@@ -170,6 +174,7 @@ func (bm *batchManager) getProcessor(batchType fftypes.MessageType, group *fftyp
 				namespace: namespace,
 				author:    author,
 				group:     group,
+				txType:    txType,
 				dispatch:  dispatcher.handler,
 				processorQuiescing: func() {
 					bm.removeProcessor(dispatcher, key)
@@ -220,7 +225,7 @@ func (bm *batchManager) readPage() ([]*fftypes.Message, error) {
 	var msgs []*fftypes.Message
 	err := bm.retry.Do(bm.ctx, "retrieve messages", func(attempt int) (retry bool, err error) {
 		fb := database.MessageQueryFactory.NewFilterLimit(bm.ctx, bm.readPageSize)
-		msgs, err = bm.database.GetMessages(bm.ctx, fb.And(
+		msgs, _, err = bm.database.GetMessages(bm.ctx, fb.And(
 			fb.Gt("sequence", bm.offset),
 			fb.Eq("local", true),
 		).Sort("sequence").Limit(bm.readPageSize))
@@ -346,7 +351,7 @@ func (bm *batchManager) updateOffset(infiniteRetry bool, newOffset int64) (err e
 
 func (bm *batchManager) dispatchMessage(dispatched chan *batchDispatch, msg *fftypes.Message, data ...*fftypes.Data) error {
 	l := log.L(bm.ctx)
-	processor, err := bm.getProcessor(msg.Header.Type, msg.Header.Group, msg.Header.Namespace, msg.Header.Author)
+	processor, err := bm.getProcessor(msg.Header.Type, msg.Header.Group, msg.Header.Namespace, msg.Header.Author, msg.Header.TxType)
 	if err != nil {
 		return err
 	}
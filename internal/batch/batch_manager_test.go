@@ -99,8 +99,8 @@ func TestE2EDispatchBroadcast(t *testing.T) {
 		Hash: dataHash,
 	}
 	mdm.On("GetMessageData", mock.Anything, mock.Anything, true).Return([]*fftypes.Data{data}, true, nil)
-	mdi.On("GetMessages", mock.Anything, mock.Anything).Return([]*fftypes.Message{msg}, nil).Once()
-	mdi.On("GetMessages", mock.Anything, mock.Anything).Return([]*fftypes.Message{}, nil)
+	mdi.On("GetMessages", mock.Anything, mock.Anything).Return([]*fftypes.Message{msg}, nil, nil).Once()
+	mdi.On("GetMessages", mock.Anything, mock.Anything).Return([]*fftypes.Message{}, nil, nil)
 	mdi.On("UpsertBatch", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
 	mdi.On("UpdateBatch", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
 	rag := mdi.On("RunAsGroup", mock.Anything, mock.Anything, mock.Anything).Return(nil)
@@ -204,8 +204,8 @@ func TestE2EDispatchPrivate(t *testing.T) {
 		Hash: dataHash,
 	}
 	mdm.On("GetMessageData", mock.Anything, mock.Anything, true).Return([]*fftypes.Data{data}, true, nil)
-	mdi.On("GetMessages", mock.Anything, mock.Anything).Return([]*fftypes.Message{msg}, nil).Once()
-	mdi.On("GetMessages", mock.Anything, mock.Anything).Return([]*fftypes.Message{}, nil)
+	mdi.On("GetMessages", mock.Anything, mock.Anything).Return([]*fftypes.Message{msg}, nil, nil).Once()
+	mdi.On("GetMessages", mock.Anything, mock.Anything).Return([]*fftypes.Message{}, nil, nil)
 	mdi.On("UpsertBatch", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
 	mdi.On("UpdateBatch", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
 	rag := mdi.On("RunAsGroup", mock.Anything, mock.Anything, mock.Anything).Return(nil)
@@ -305,10 +305,32 @@ func TestGetInvalidBatchTypeMsg(t *testing.T) {
 	assert.Regexp(t, "FF10126", err)
 }
 
+func TestGetProcessorByTxType(t *testing.T) {
+
+	mdi := &databasemocks.Plugin{}
+	mdm := &datamocks.Manager{}
+	bmi, _ := NewBatchManager(context.Background(), mdi, mdm)
+	bm := bmi.(*batchManager)
+	defer bm.Close()
+	bm.RegisterDispatcher([]fftypes.MessageType{fftypes.MessageTypePrivate}, nil, Options{})
+
+	group := fftypes.NewRandB32()
+	pinned, err := bm.getProcessor(fftypes.MessageTypePrivate, group, "ns1", "0x12345", "")
+	assert.NoError(t, err)
+	assert.Equal(t, fftypes.TransactionTypeBatchPin, pinned.conf.txType)
+	samePinned, err := bm.getProcessor(fftypes.MessageTypePrivate, group, "ns1", "0x12345", fftypes.TransactionTypeBatchPin)
+	assert.NoError(t, err)
+	assert.Equal(t, pinned, samePinned)
+	unpinned, err := bm.getProcessor(fftypes.MessageTypePrivate, group, "ns1", "0x12345", fftypes.TransactionTypeNone)
+	assert.NoError(t, err)
+	assert.Equal(t, fftypes.TransactionTypeNone, unpinned.conf.txType)
+	assert.NotEqual(t, pinned, unpinned)
+}
+
 func TestMessageSequencerCancelledContext(t *testing.T) {
 	mdi := &databasemocks.Plugin{}
 	mdm := &datamocks.Manager{}
-	mdi.On("GetMessages", mock.Anything, mock.Anything, mock.Anything).Return(nil, fmt.Errorf("pop"))
+	mdi.On("GetMessages", mock.Anything, mock.Anything, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
 	bm, _ := NewBatchManager(context.Background(), mdi, mdm)
 	defer bm.Close()
 	ctx, cancel := context.WithCancel(context.Background())
@@ -333,7 +355,7 @@ func TestMessageSequencerMissingMessageData(t *testing.T) {
 			Data: []*fftypes.DataRef{
 				{ID: dataID},
 			}},
-	}, nil)
+	}, nil, nil)
 	gmMock.RunFn = func(a mock.Arguments) {
 		bm.Close() // so we only go round once
 	}
@@ -360,7 +382,7 @@ func TestMessageSequencerDispatchFail(t *testing.T) {
 			Data: []*fftypes.DataRef{
 				{ID: dataID},
 			}},
-	}, nil)
+	}, nil, nil)
 	gmMock.RunFn = func(a mock.Arguments) {
 		bm.Close() // so we only go round once
 	}
@@ -391,7 +413,7 @@ func TestMessageSequencerUpdateMessagesFail(t *testing.T) {
 			Data: []*fftypes.DataRef{
 				{ID: dataID},
 			}},
-	}, nil)
+	}, nil, nil)
 	mdm.On("GetMessageData", mock.Anything, mock.Anything, true).Return([]*fftypes.Data{{ID: dataID}}, true, nil)
 	mdi.On("UpdateMessages", mock.Anything, mock.Anything, mock.Anything).Return(fmt.Errorf("fizzle"))
 	rag := mdi.On("RunAsGroup", mock.Anything, mock.Anything, mock.Anything)
@@ -431,7 +453,7 @@ func TestMessageSequencerUpdateBatchFail(t *testing.T) {
 			Data: []*fftypes.DataRef{
 				{ID: dataID},
 			}},
-	}, nil)
+	}, nil, nil)
 	mdm.On("GetMessageData", mock.Anything, mock.Anything, true).Return([]*fftypes.Data{{ID: dataID}}, true, nil)
 	mdi.On("UpdateMessages", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 	mdi.On("UpsertBatch", mock.Anything, mock.Anything, true, mock.Anything).Return(fmt.Errorf("fizzle"))
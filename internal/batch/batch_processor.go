@@ -25,6 +25,7 @@ import (
 	"time"
 
 	"github.com/hyperledger-labs/firefly/internal/log"
+	"github.com/hyperledger-labs/firefly/internal/metrics"
 	"github.com/hyperledger-labs/firefly/internal/retry"
 	"github.com/hyperledger-labs/firefly/pkg/database"
 	"github.com/hyperledger-labs/firefly/pkg/fftypes"
@@ -47,6 +48,7 @@ type batchProcessorConf struct {
 	namespace          string
 	author             string
 	group              *fftypes.Bytes32
+	txType             fftypes.TransactionType
 	dispatch           DispatchHandler
 	processorQuiescing func()
 }
@@ -235,13 +237,16 @@ func (bp *batchProcessor) maskContexts(ctx context.Context, batch *fftypes.Batch
 
 func (bp *batchProcessor) dispatchBatch(batch *fftypes.Batch, pins []*fftypes.Bytes32) {
 	// Call the dispatcher to do the heavy lifting - will only exit if we're closed
-	_ = bp.retry.Do(bp.ctx, "batch dispatch", func(attempt int) (retry bool, err error) {
+	err := bp.retry.Do(bp.ctx, "batch dispatch", func(attempt int) (retry bool, err error) {
 		err = bp.conf.dispatch(bp.ctx, batch, pins)
 		if err != nil {
 			return !bp.closed, err
 		}
 		return false, nil
 	})
+	if err == nil {
+		metrics.BatchDispatched(batch)
+	}
 }
 
 func (bp *batchProcessor) persistBatch(batch *fftypes.Batch, newWork []*batchWork, seal bool) (contexts []*fftypes.Bytes32, err error) {
@@ -264,10 +269,13 @@ func (bp *batchProcessor) persistBatch(batch *fftypes.Batch, newWork []*batchWor
 			if err == nil && seal {
 				// Generate a new Transaction reference, which will be used to record status of the associated transaction as it happens
 				batch.Payload.TX = fftypes.TransactionRef{
-					Type: fftypes.TransactionTypeBatchPin,
+					Type: bp.conf.txType,
 					ID:   fftypes.NewUUID(),
 				}
-				contexts, err = bp.maskContexts(bp.ctx, batch)
+				// Batches that are not pinned to the blockchain do not consume any nonces, as there are no pins to mask
+				if bp.conf.txType != fftypes.TransactionTypeNone {
+					contexts, err = bp.maskContexts(bp.ctx, batch)
+				}
 				batch.Hash = batch.Payload.Hash()
 				log.L(ctx).Debugf("Batch %s sealed. Hash=%s", batch.ID, batch.Hash)
 			}
@@ -343,6 +351,8 @@ func (bp *batchProcessor) persistenceLoop() {
 		}
 
 		if seal {
+			metrics.BatchSealed(currentBatch)
+
 			// At this point the batch is sealed, and the assember can start
 			// queing up the next batch. We only let them get one batch ahead
 			// (due to the size of the channel being the maxBatchSize) before
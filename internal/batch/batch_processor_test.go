@@ -34,6 +34,7 @@ func newTestBatchProcessor(dispatch DispatchHandler) (*databasemocks.Plugin, *ba
 	bp := newBatchProcessor(context.Background(), mdi, &batchProcessorConf{
 		namespace:          "ns1",
 		author:             "0x12345",
+		txType:             fftypes.TransactionTypeBatchPin,
 		dispatch:           dispatch,
 		processorQuiescing: func() {},
 		Options: Options{
@@ -107,6 +108,48 @@ func TestUnfilledBatch(t *testing.T) {
 
 }
 
+func TestUnpinnedBatch(t *testing.T) {
+	log.SetLevel("debug")
+
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+
+	var dispatched *fftypes.Batch
+	var dispatchedContexts []*fftypes.Bytes32
+	mdi, bp := newTestBatchProcessor(func(c context.Context, b *fftypes.Batch, s []*fftypes.Bytes32) error {
+		dispatched = b
+		dispatchedContexts = s
+		wg.Done()
+		return nil
+	})
+	bp.conf.txType = fftypes.TransactionTypeNone
+	mockRunAsGroupPassthrough(mdi)
+	mdi.On("UpdateMessages", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mdi.On("UpsertBatch", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	work := &batchWork{
+		msg: &fftypes.Message{Header: fftypes.MessageHeader{
+			ID:     fftypes.NewUUID(),
+			Group:  fftypes.NewRandB32(),
+			Topics: fftypes.FFNameArray{"topic1"},
+			TxType: fftypes.TransactionTypeNone,
+		}},
+		dispatched: make(chan *batchDispatch, 1),
+	}
+	bp.newWork <- work
+	wg.Wait()
+
+	// No nonces are allocated, and no pins are calculated
+	assert.Equal(t, fftypes.TransactionTypeNone, dispatched.Payload.TX.Type)
+	assert.Empty(t, dispatchedContexts)
+	assert.Empty(t, dispatched.Payload.Messages[0].Pins)
+	mdi.AssertNotCalled(t, "UpsertNonceNext", mock.Anything, mock.Anything)
+
+	bp.close()
+	bp.waitClosed()
+
+}
+
 func TestFilledBatchSlowPersistence(t *testing.T) {
 	log.SetLevel("debug")
 
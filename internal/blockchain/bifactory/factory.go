@@ -20,6 +20,8 @@ import (
 	"context"
 
 	"github.com/hyperledger-labs/firefly/internal/blockchain/ethereum"
+	"github.com/hyperledger-labs/firefly/internal/blockchain/ethrpc"
+	"github.com/hyperledger-labs/firefly/internal/blockchain/fabric"
 	"github.com/hyperledger-labs/firefly/internal/blockchain/utdbql"
 	"github.com/hyperledger-labs/firefly/internal/config"
 	"github.com/hyperledger-labs/firefly/internal/i18n"
@@ -28,6 +30,8 @@ import (
 
 var plugins = []blockchain.Plugin{
 	&ethereum.Ethereum{},
+	&ethrpc.EthRPC{},
+	&fabric.Fabric{},
 	&utdbql.UTDBQL{},
 }
 
@@ -22,8 +22,10 @@ import (
 )
 
 const (
-	defaultBatchSize    = 50
-	defaultBatchTimeout = 500
+	defaultBatchSize           = 50
+	defaultBatchTimeout        = 500
+	defaultFromBlock           = "0"
+	defaultHealthCheckInterval = "1m"
 )
 
 const (
@@ -41,6 +43,21 @@ const (
 	EthconnectConfigBatchTimeout = "batchTimeout"
 	// EthconnectConfigSkipEventstreamInit disables auto-configuration of event streams
 	EthconnectConfigSkipEventstreamInit = "skipEventstreamInit"
+	// EthconnectConfigFromBlock is the block to start listening from when creating subscriptions. Can also be set per ledger.
+	// Existing subscriptions are not affected by changing it, as they resume from their own checkpoint
+	EthconnectConfigFromBlock = "fromBlock"
+	// EthconnectConfigHealthCheckInterval is how often to check the event streams and subscriptions still exist with the right settings, repairing them if not. Zero disables the check
+	EthconnectConfigHealthCheckInterval = "healthCheckInterval"
+	// EthconnectConfigLedgers is an array of additional named ledgers, each with its own contract instance and topic.
+	// The instance and topic configured at the top level are used for the default ledger
+	EthconnectConfigLedgers = "ledgers"
+)
+
+const (
+	// EthconnectLedgerConfigID is the UUID of the ledger, as referred to by groups
+	EthconnectLedgerConfigID = "id"
+	// EthconnectLedgerConfigName is a friendly name for the ledger
+	EthconnectLedgerConfigName = "name"
 )
 
 func (e *Ethereum) InitPrefix(prefix config.Prefix) {
@@ -51,4 +68,7 @@ func (e *Ethereum) InitPrefix(prefix config.Prefix) {
 	ethconnectConf.AddKnownKey(EthconnectConfigSkipEventstreamInit)
 	ethconnectConf.AddKnownKey(EthconnectConfigBatchSize, defaultBatchSize)
 	ethconnectConf.AddKnownKey(EthconnectConfigBatchTimeout, defaultBatchTimeout)
+	ethconnectConf.AddKnownKey(EthconnectConfigFromBlock, defaultFromBlock)
+	ethconnectConf.AddKnownKey(EthconnectConfigHealthCheckInterval, defaultHealthCheckInterval)
+	ethconnectConf.AddKnownKey(EthconnectConfigLedgers)
 }
@@ -22,7 +22,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"regexp"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/go-resty/resty/v2"
 	"github.com/hyperledger-labs/firefly/internal/config"
@@ -41,17 +44,36 @@ const (
 var zeroBytes32 = fftypes.Bytes32{}
 
 type Ethereum struct {
-	ctx          context.Context
+	ctx                 context.Context
+	capabilities        *blockchain.Capabilities
+	callbacks           blockchain.Callbacks
+	client              *resty.Client
+	ledgers             []*ethLedger
+	batchSize           uint
+	batchTimeoutMS      uint
+	skipEventstreamInit bool
+	healthCheckInterval time.Duration
+}
+
+// ethLedger is a contract instance, with its own event stream, websocket topic and connection.
+// The default ledger has a nil ID, and is always the first in the list.
+type ethLedger struct {
+	id           *fftypes.UUID
+	name         string
 	topic        string
 	instancePath string
-	capabilities *blockchain.Capabilities
-	callbacks    blockchain.Callbacks
-	client       *resty.Client
-	initInfo     struct {
+	fromBlock    string
+	// ensureMux serializes checking the stream and subscriptions, which happens at init, on reconnect, and on each health check
+	ensureMux sync.Mutex
+	// mux protects the status fields below, which are read by the admin API
+	mux      sync.Mutex
+	initInfo struct {
 		stream *eventStream
 		subs   []*subscription
 	}
-	wsconn wsclient.WSClient
+	lastBlock int64
+	connected bool
+	wsconn    wsclient.WSClient
 }
 
 type eventStream struct {
@@ -114,48 +136,195 @@ func (e *Ethereum) Init(ctx context.Context, prefix config.Prefix, callbacks blo
 	if ethconnectConf.GetString(restclient.HTTPConfigURL) == "" {
 		return i18n.NewError(ctx, i18n.MsgMissingPluginConfig, "url", "blockchain.ethconnect")
 	}
-	e.instancePath = ethconnectConf.GetString(EthconnectConfigInstancePath)
-	if e.instancePath == "" {
+	defaultLedger := &ethLedger{
+		name:         "default",
+		instancePath: ethconnectConf.GetString(EthconnectConfigInstancePath),
+		topic:        ethconnectConf.GetString(EthconnectConfigTopic),
+		fromBlock:    ethconnectConf.GetString(EthconnectConfigFromBlock),
+	}
+	if defaultLedger.instancePath == "" {
 		return i18n.NewError(ctx, i18n.MsgMissingPluginConfig, "instance", "blockchain.ethconnect")
 	}
-	e.topic = ethconnectConf.GetString(EthconnectConfigTopic)
-	if e.topic == "" {
+	if defaultLedger.topic == "" {
 		return i18n.NewError(ctx, i18n.MsgMissingPluginConfig, "topic", "blockchain.ethconnect")
 	}
+	e.ledgers = []*ethLedger{defaultLedger}
+	for i, ledgerConf := range ethconnectConf.GetObjectArray(EthconnectConfigLedgers) {
+		l, err := e.parseLedgerConfig(ctx, i, ledgerConf)
+		if err != nil {
+			return err
+		}
+		e.ledgers = append(e.ledgers, l)
+	}
 
 	e.client = restclient.New(e.ctx, ethconnectConf)
 	e.capabilities = &blockchain.Capabilities{
 		GlobalSequencer: true,
 	}
+	e.batchSize = ethconnectConf.GetUint(EthconnectConfigBatchSize)
+	e.batchTimeoutMS = uint(ethconnectConf.GetDuration(EthconnectConfigBatchTimeout).Milliseconds())
+	e.skipEventstreamInit = ethconnectConf.GetBool(EthconnectConfigSkipEventstreamInit)
+	e.healthCheckInterval = ethconnectConf.GetDuration(EthconnectConfigHealthCheckInterval)
 
 	if ethconnectConf.GetString(wsclient.WSConfigKeyPath) == "" {
 		ethconnectConf.Set(wsclient.WSConfigKeyPath, "/ws")
 	}
-	e.wsconn, err = wsclient.New(ctx, ethconnectConf, e.afterConnect)
+	for _, l := range e.ledgers {
+		if l.wsconn, err = wsclient.New(ctx, ethconnectConf, e.afterConnect(l)); err != nil {
+			return err
+		}
+
+		if !e.skipEventstreamInit {
+			if err = e.ensureEventStreams(l); err != nil {
+				return err
+			}
+		}
+
+		go e.eventLoop(l)
+	}
+
+	return nil
+}
+
+func (e *Ethereum) parseLedgerConfig(ctx context.Context, i int, ledgerConf fftypes.JSONObject) (*ethLedger, error) {
+	confKey := fmt.Sprintf("blockchain.ethconnect.%s[%d]", EthconnectConfigLedgers, i)
+	l := &ethLedger{
+		name:         ledgerConf.GetString(EthconnectLedgerConfigName),
+		instancePath: ledgerConf.GetString(EthconnectConfigInstancePath),
+		topic:        ledgerConf.GetString(EthconnectConfigTopic),
+		fromBlock:    ledgerConf.GetString(EthconnectConfigFromBlock),
+	}
+	if l.fromBlock == "" {
+		l.fromBlock = e.ledgers[0].fromBlock
+	}
+	idStr := ledgerConf.GetString(EthconnectLedgerConfigID)
+	switch {
+	case idStr == "":
+		return nil, i18n.NewError(ctx, i18n.MsgMissingPluginConfig, EthconnectLedgerConfigID, confKey)
+	case l.name == "":
+		return nil, i18n.NewError(ctx, i18n.MsgMissingPluginConfig, EthconnectLedgerConfigName, confKey)
+	case l.instancePath == "":
+		return nil, i18n.NewError(ctx, i18n.MsgMissingPluginConfig, EthconnectConfigInstancePath, confKey)
+	case l.topic == "":
+		return nil, i18n.NewError(ctx, i18n.MsgMissingPluginConfig, EthconnectConfigTopic, confKey)
+	}
+	id, err := fftypes.ParseUUID(ctx, idStr)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	for _, existing := range e.ledgers {
+		if id.Equals(existing.id) || l.topic == existing.topic {
+			return nil, i18n.NewError(ctx, i18n.MsgDuplicateLedger, l.name)
+		}
 	}
+	l.id = id
+	return l, nil
+}
+
+func (e *Ethereum) getLedger(ctx context.Context, ledgerID *fftypes.UUID) (*ethLedger, error) {
+	for _, l := range e.ledgers {
+		if ledgerID.Equals(l.id) {
+			return l, nil
+		}
+	}
+	return nil, i18n.NewError(ctx, i18n.MsgUnknownLedger, ledgerID)
+}
 
-	if !ethconnectConf.GetBool(EthconnectConfigSkipEventstreamInit) {
-		if err = e.ensureEventStreams(ethconnectConf); err != nil {
+func (e *Ethereum) Start() error {
+	for _, l := range e.ledgers {
+		if err := l.wsconn.Connect(); err != nil {
 			return err
 		}
 	}
+	if !e.skipEventstreamInit && e.healthCheckInterval > 0 {
+		go e.healthCheckLoop()
+	}
+	return nil
+}
+
+// healthCheckLoop periodically re-verifies the event streams and subscriptions, so they are
+// repaired if they are deleted or modified on ethconnect while we are connected
+func (e *Ethereum) healthCheckLoop() {
+	l := log.L(e.ctx).WithField("role", "health-check")
+	for {
+		select {
+		case <-e.ctx.Done():
+			l.Debugf("Health check exiting (context cancelled)")
+			return
+		case <-time.After(e.healthCheckInterval):
+			for _, ledger := range e.ledgers {
+				if err := e.ensureEventStreams(ledger); err != nil {
+					l.Errorf("Health check failed for ledger '%s': %s", ledger.name, err)
+				}
+			}
+		}
+	}
+}
 
-	go e.eventLoop()
+func (e *Ethereum) Status() fftypes.JSONObject {
+	ledgers := make([]interface{}, len(e.ledgers))
+	for i, l := range e.ledgers {
+		ledgers[i] = l.status()
+	}
+	return fftypes.JSONObject{"ledgers": ledgers}
+}
 
-	return nil
+func (l *ethLedger) status() map[string]interface{} {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	streamID := ""
+	if l.initInfo.stream != nil {
+		streamID = l.initInfo.stream.ID
+	}
+	subIDs := make([]interface{}, len(l.initInfo.subs))
+	for i, sub := range l.initInfo.subs {
+		subIDs[i] = sub.ID
+	}
+	return map[string]interface{}{
+		"id":            l.id,
+		"name":          l.name,
+		"topic":         l.topic,
+		"eventStream":   streamID,
+		"subscriptions": subIDs,
+		"lastBlock":     l.lastBlock,
+	}
 }
 
-func (e *Ethereum) Start() error {
-	return e.wsconn.Connect()
+func (e *Ethereum) TrackPendingBatchPins(ctx context.Context, txTrackingIDs []string) {
+	// ethconnect tracks the receipts of the transactions it submits, across restarts of firefly
 }
 
 func (e *Ethereum) Capabilities() *blockchain.Capabilities {
 	return e.capabilities
 }
 
-func (e *Ethereum) ensureEventStreams(ethconnectConf config.Prefix) error {
+// streamMatches checks an existing stream has the settings we would create it with
+func (e *Ethereum) streamMatches(stream *eventStream) bool {
+	return stream.Type == "websocket" &&
+		stream.ErrorHandling == "block" &&
+		stream.BatchSize == e.batchSize &&
+		stream.BatchTimeoutMS == e.batchTimeoutMS
+}
+
+// newEventStream returns the event stream settings we require for the ledger
+func (e *Ethereum) newEventStream(l *ethLedger) *eventStream {
+	stream := &eventStream{
+		Name:           l.topic,
+		ErrorHandling:  "block",
+		BatchSize:      e.batchSize,
+		BatchTimeoutMS: e.batchTimeoutMS,
+		Type:           "websocket",
+	}
+	stream.WebSocket.Topic = l.topic
+	return stream
+}
+
+// ensureEventStreams checks the event stream and subscriptions for the ledger exist on ethconnect with the
+// expected settings. Missing streams and subscriptions are recreated. A stream that has drifted is updated
+// in place, as deleting it would delete its subscriptions - and with them the checkpoint of the events we have processed
+func (e *Ethereum) ensureEventStreams(l *ethLedger) error {
+	l.ensureMux.Lock()
+	defer l.ensureMux.Unlock()
 
 	var existingStreams []*eventStream
 	res, err := e.client.R().SetContext(e.ctx).SetResult(&existingStreams).Get("/eventstreams")
@@ -163,62 +332,95 @@ func (e *Ethereum) ensureEventStreams(ethconnectConf config.Prefix) error {
 		return restclient.WrapRestErr(e.ctx, res, err, i18n.MsgEthconnectRESTErr)
 	}
 
-	for _, stream := range existingStreams {
-		if stream.WebSocket.Topic == e.topic {
-			e.initInfo.stream = stream
+	var stream *eventStream
+	for _, s := range existingStreams {
+		if s.WebSocket.Topic == l.topic {
+			stream = s
+			break
 		}
 	}
 
-	if e.initInfo.stream == nil {
-		newStream := eventStream{
-			Name:           e.topic,
-			ErrorHandling:  "block",
-			BatchSize:      ethconnectConf.GetUint(EthconnectConfigBatchSize),
-			BatchTimeoutMS: uint(ethconnectConf.GetDuration(EthconnectConfigBatchTimeout).Milliseconds()),
-			Type:           "websocket",
+	switch {
+	case stream == nil:
+		newStream := e.newEventStream(l)
+		res, err = e.client.R().SetContext(e.ctx).SetBody(newStream).SetResult(newStream).Post("/eventstreams")
+		if err != nil || !res.IsSuccess() {
+			return restclient.WrapRestErr(e.ctx, res, err, i18n.MsgEthconnectRESTErr)
 		}
-		newStream.WebSocket.Topic = e.topic
-		res, err = e.client.R().SetBody(&newStream).SetResult(&newStream).Post("/eventstreams")
+		stream = newStream
+	case !e.streamMatches(stream):
+		log.L(e.ctx).Warnf("Event stream %s for ledger '%s' has incorrect settings and will be updated: %+v", stream.ID, l.name, stream)
+		updatedStream := e.newEventStream(l)
+		updatedStream.ID = stream.ID
+		res, err = e.client.R().SetContext(e.ctx).SetBody(updatedStream).SetResult(updatedStream).Patch(fmt.Sprintf("/eventstreams/%s", stream.ID))
 		if err != nil || !res.IsSuccess() {
 			return restclient.WrapRestErr(e.ctx, res, err, i18n.MsgEthconnectRESTErr)
 		}
-		e.initInfo.stream = &newStream
+		stream = updatedStream
 	}
 
-	log.L(e.ctx).Infof("Event stream: %s", e.initInfo.stream.ID)
+	log.L(e.ctx).Infof("Event stream for ledger '%s': %s", l.name, stream.ID)
 
-	return e.ensureSusbscriptions(e.initInfo.stream.ID)
+	subs, err := e.ensureSusbscriptions(l, stream.ID)
+	if err != nil {
+		return err
+	}
+
+	l.mux.Lock()
+	l.initInfo.stream = stream
+	l.initInfo.subs = subs
+	l.mux.Unlock()
+	return nil
 }
 
-func (e *Ethereum) afterConnect(ctx context.Context, w wsclient.WSClient) error {
-	// Send a subscribe to our topic after each connect/reconnect
-	b, _ := json.Marshal(&ethWSCommandPayload{
-		Type:  "listen",
-		Topic: e.topic,
-	})
-	err := w.Send(ctx, b)
-	if err == nil {
-		b, _ = json.Marshal(&ethWSCommandPayload{
-			Type: "listenreplies",
+// afterConnect returns the handler run by the websocket client after each connect. On reconnect the stream is
+// verified before we listen again, as ethconnect might have been restarted or reconfigured while we were disconnected
+func (e *Ethereum) afterConnect(l *ethLedger) wsclient.WSPostConnectHandler {
+	return func(ctx context.Context, w wsclient.WSClient) error {
+		l.mux.Lock()
+		reconnect := l.connected
+		l.connected = true
+		l.mux.Unlock()
+		if reconnect && !e.skipEventstreamInit {
+			if err := e.ensureEventStreams(l); err != nil {
+				return err
+			}
+		}
+
+		// Send a subscribe to our topic after each connect/reconnect
+		b, _ := json.Marshal(&ethWSCommandPayload{
+			Type:  "listen",
+			Topic: l.topic,
 		})
-		err = w.Send(ctx, b)
+		err := w.Send(ctx, b)
+		if err == nil {
+			b, _ = json.Marshal(&ethWSCommandPayload{
+				Type: "listenreplies",
+			})
+			err = w.Send(ctx, b)
+		}
+		return err
 	}
-	return err
 }
 
-func (e *Ethereum) ensureSusbscriptions(streamID string) error {
+func (e *Ethereum) ensureSusbscriptions(l *ethLedger, streamID string) ([]*subscription, error) {
+	var subs []*subscription
 	for eventType, subDesc := range requiredSubscriptions {
 
 		var existingSubs []*subscription
 		res, err := e.client.R().SetResult(&existingSubs).Get("/subscriptions")
 		if err != nil || !res.IsSuccess() {
-			return restclient.WrapRestErr(e.ctx, res, err, i18n.MsgEthconnectRESTErr)
+			return nil, restclient.WrapRestErr(e.ctx, res, err, i18n.MsgEthconnectRESTErr)
 		}
 
 		var sub *subscription
 		for _, s := range existingSubs {
-			if s.Name == eventType {
+			if s.Name == eventType && s.Stream == streamID {
 				sub = s
+				if s.FromBlock != l.fromBlock {
+					// The start block only applies when the subscription is created. Recreating it would lose its checkpoint
+					log.L(e.ctx).Warnf("%s subscription %s for ledger '%s' was created from block '%s' - it will resume from its checkpoint, rather than block '%s'", eventType, s.ID, l.name, s.FromBlock, l.fromBlock)
+				}
 			}
 		}
 
@@ -227,25 +429,25 @@ func (e *Ethereum) ensureSusbscriptions(streamID string) error {
 				Name:        eventType,
 				Description: subDesc,
 				StreamID:    streamID,
-				Stream:      e.initInfo.stream.ID,
-				FromBlock:   "0",
+				Stream:      streamID,
+				FromBlock:   l.fromBlock,
 			}
 			res, err = e.client.R().
 				SetContext(e.ctx).
 				SetBody(&newSub).
 				SetResult(&newSub).
-				Post(fmt.Sprintf("%s/%s", e.instancePath, eventType))
+				Post(fmt.Sprintf("%s/%s", l.instancePath, eventType))
 			if err != nil || !res.IsSuccess() {
-				return restclient.WrapRestErr(e.ctx, res, err, i18n.MsgEthconnectRESTErr)
+				return nil, restclient.WrapRestErr(e.ctx, res, err, i18n.MsgEthconnectRESTErr)
 			}
 			sub = &newSub
 		}
 
-		log.L(e.ctx).Infof("%s subscription: %s", eventType, sub.ID)
-		e.initInfo.subs = append(e.initInfo.subs, sub)
+		log.L(e.ctx).Infof("%s subscription for ledger '%s': %s", eventType, l.name, sub.ID)
+		subs = append(subs, sub)
 
 	}
-	return nil
+	return subs, nil
 }
 
 func ethHexFormatB32(b *fftypes.Bytes32) string {
@@ -255,7 +457,7 @@ func ethHexFormatB32(b *fftypes.Bytes32) string {
 	return "0x" + hex.EncodeToString(b[0:32])
 }
 
-func (e *Ethereum) handleBatchPinEvent(ctx context.Context, msgJSON fftypes.JSONObject) (err error) {
+func (e *Ethereum) handleBatchPinEvent(ctx context.Context, ledgerID *fftypes.UUID, msgJSON fftypes.JSONObject) (err error) {
 	sBlockNumber := msgJSON.GetString("blockNumber")
 	sTransactionIndex := msgJSON.GetString("transactionIndex")
 	sTransactionHash := msgJSON.GetString("transactionHash")
@@ -333,7 +535,7 @@ func (e *Ethereum) handleBatchPinEvent(ctx context.Context, msgJSON fftypes.JSON
 	}
 
 	// If there's an error dispatching the event, we must return the error and shutdown
-	return e.callbacks.BatchPinComplete(batch, authorAddress, sTransactionHash, msgJSON)
+	return e.callbacks.BatchPinComplete(ledgerID, batch, authorAddress, sTransactionHash, msgJSON)
 }
 
 func (e *Ethereum) handleReceipt(ctx context.Context, reply fftypes.JSONObject) error {
@@ -356,7 +558,7 @@ func (e *Ethereum) handleReceipt(ctx context.Context, reply fftypes.JSONObject)
 	return e.callbacks.TxSubmissionUpdate(requestID, updateType, txHash, message, reply)
 }
 
-func (e *Ethereum) handleMessageBatch(ctx context.Context, messages []interface{}) error {
+func (e *Ethereum) handleMessageBatch(ctx context.Context, ledgerID *fftypes.UUID, messages []interface{}) error {
 	l := log.L(ctx)
 
 	for i, msgI := range messages {
@@ -375,7 +577,7 @@ func (e *Ethereum) handleMessageBatch(ctx context.Context, messages []interface{
 
 		switch signature {
 		case broadcastBatchEventSignature:
-			if err := e.handleBatchPinEvent(ctx1, msgJSON); err != nil {
+			if err := e.handleBatchPinEvent(ctx1, ledgerID, msgJSON); err != nil {
 				return err
 			}
 		default:
@@ -386,16 +588,30 @@ func (e *Ethereum) handleMessageBatch(ctx context.Context, messages []interface{
 	return nil
 }
 
-func (e *Ethereum) eventLoop() {
-	l := log.L(e.ctx).WithField("role", "event-loop")
+// updateLastBlock records the highest block number in a batch of events that has been processed
+func (l *ethLedger) updateLastBlock(messages []interface{}) {
+	l.mux.Lock()
+	defer l.mux.Unlock()
+	for _, msgI := range messages {
+		if msgMap, ok := msgI.(map[string]interface{}); ok {
+			blockNumber, err := strconv.ParseInt(fftypes.JSONObject(msgMap).GetString("blockNumber"), 10, 64)
+			if err == nil && blockNumber > l.lastBlock {
+				l.lastBlock = blockNumber
+			}
+		}
+	}
+}
+
+func (e *Ethereum) eventLoop(ledger *ethLedger) {
+	l := log.L(e.ctx).WithField("role", "event-loop").WithField("ledger", ledger.name)
 	ctx := log.WithLogger(e.ctx, l)
-	ack, _ := json.Marshal(map[string]string{"type": "ack", "topic": e.topic})
+	ack, _ := json.Marshal(map[string]string{"type": "ack", "topic": ledger.topic})
 	for {
 		select {
 		case <-ctx.Done():
 			l.Debugf("Event loop exiting (context cancelled)")
 			return
-		case msgBytes, ok := <-e.wsconn.Receive():
+		case msgBytes, ok := <-ledger.wsconn.Receive():
 			if !ok {
 				l.Debugf("Event loop exiting (receive channel closed)")
 				return
@@ -409,9 +625,10 @@ func (e *Ethereum) eventLoop() {
 			}
 			switch msgTyped := msgParsed.(type) {
 			case []interface{}:
-				err = e.handleMessageBatch(ctx, msgTyped)
+				err = e.handleMessageBatch(ctx, ledger.id, msgTyped)
 				if err == nil {
-					err = e.wsconn.Send(ctx, ack)
+					ledger.updateLastBlock(msgTyped)
+					err = ledger.wsconn.Send(ctx, ack)
 				}
 			case map[string]interface{}:
 				err = e.handleReceipt(ctx, fftypes.JSONObject(msgTyped))
@@ -443,6 +660,10 @@ func (e *Ethereum) validateEthAddress(ctx context.Context, identity string) (str
 }
 
 func (e *Ethereum) SubmitBatchPin(ctx context.Context, ledgerID *fftypes.UUID, identity *fftypes.Identity, batch *blockchain.BatchPin) (txTrackingID string, err error) {
+	l, err := e.getLedger(ctx, ledgerID)
+	if err != nil {
+		return "", err
+	}
 	tx := &asyncTXSubmission{}
 	ethHashes := make([]string, len(batch.Contexts))
 	for i, v := range batch.Contexts {
@@ -458,7 +679,7 @@ func (e *Ethereum) SubmitBatchPin(ctx context.Context, ledgerID *fftypes.UUID, i
 		PayloadRef: ethHexFormatB32(batch.BatchPaylodRef),
 		Contexts:   ethHashes,
 	}
-	path := fmt.Sprintf("%s/pinBatch", e.instancePath)
+	path := fmt.Sprintf("%s/pinBatch", l.instancePath)
 	res, err := e.client.R().
 		SetContext(ctx).
 		SetQueryParam("fly-from", identity.OnChain).
@@ -23,6 +23,7 @@ import (
 	"net/http"
 	"net/url"
 	"testing"
+	"time"
 
 	"github.com/go-resty/resty/v2"
 	"github.com/hyperledger-labs/firefly/internal/config"
@@ -41,6 +42,18 @@ import (
 var utConfPrefix = config.NewPluginConfig("eth_unit_tests")
 var utEthconnectConf = utConfPrefix.SubPrefix(EthconnectConfigKey)
 
+// testStream is an existing stream with the settings the plugin creates by default
+func testStream(id, topic string) *eventStream {
+	return &eventStream{
+		ID:             id,
+		ErrorHandling:  "block",
+		BatchSize:      defaultBatchSize,
+		BatchTimeoutMS: defaultBatchTimeout,
+		Type:           "websocket",
+		WebSocket:      eventStreamWebsocket{Topic: topic},
+	}
+}
+
 func resetConf() {
 	config.Reset()
 	e := &Ethereum{}
@@ -115,8 +128,8 @@ func TestInitAllNewStreamsAndWSEvent(t *testing.T) {
 
 	assert.Equal(t, "ethereum", e.Name())
 	assert.Equal(t, 4, httpmock.GetTotalCallCount())
-	assert.Equal(t, "es12345", e.initInfo.stream.ID)
-	assert.Equal(t, "sub12345", e.initInfo.subs[0].ID)
+	assert.Equal(t, "es12345", e.ledgers[0].initInfo.stream.ID)
+	assert.Equal(t, "sub12345", e.ledgers[0].initInfo.subs[0].ID)
 	assert.True(t, e.Capabilities().GlobalSequencer)
 
 	err = e.Start()
@@ -152,12 +165,97 @@ func TestWSInitFail(t *testing.T) {
 
 }
 
+func TestInitLedgersConfigErrors(t *testing.T) {
+	for _, tc := range []struct {
+		ledger fftypes.JSONObject
+		err    string
+	}{
+		{fftypes.JSONObject{"name": "ledger2", "instance": "/instances/0x67890", "topic": "topic2"}, "FF10138.*id.*ledgers\\[0\\]"},
+		{fftypes.JSONObject{"id": fftypes.NewUUID().String(), "instance": "/instances/0x67890", "topic": "topic2"}, "FF10138.*name"},
+		{fftypes.JSONObject{"id": fftypes.NewUUID().String(), "name": "ledger2", "topic": "topic2"}, "FF10138.*instance"},
+		{fftypes.JSONObject{"id": fftypes.NewUUID().String(), "name": "ledger2", "instance": "/instances/0x67890"}, "FF10138.*topic"},
+		{fftypes.JSONObject{"id": "!uuid", "name": "ledger2", "instance": "/instances/0x67890", "topic": "topic2"}, "FF10142"},
+		{fftypes.JSONObject{"id": fftypes.NewUUID().String(), "name": "ledger2", "instance": "/instances/0x67890", "topic": "topic1"}, "FF10262"},
+	} {
+		e := &Ethereum{}
+		resetConf()
+		utEthconnectConf.Set(restclient.HTTPConfigURL, "http://localhost:12345")
+		utEthconnectConf.Set(EthconnectConfigInstancePath, "/instances/0x12345")
+		utEthconnectConf.Set(EthconnectConfigTopic, "topic1")
+		utEthconnectConf.Set(EthconnectConfigLedgers, []interface{}{map[string]interface{}(tc.ledger)})
+
+		err := e.Init(context.Background(), utConfPrefix, &blockchainmocks.Callbacks{})
+		assert.Regexp(t, tc.err, err)
+	}
+}
+
+func TestInitMultipleLedgers(t *testing.T) {
+
+	e := &Ethereum{}
+
+	mockedClient := &http.Client{}
+	httpmock.ActivateNonDefault(mockedClient)
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "http://localhost:12345/eventstreams",
+		httpmock.NewJsonResponderOrPanic(200, []*eventStream{
+			testStream("es12345", "topic1"),
+		}))
+	httpmock.RegisterResponder("POST", "http://localhost:12345/eventstreams",
+		func(req *http.Request) (*http.Response, error) {
+			var body eventStream
+			json.NewDecoder(req.Body).Decode(&body)
+			assert.Equal(t, "topic2", body.WebSocket.Topic)
+			body.ID = "es67890"
+			return httpmock.NewJsonResponderOrPanic(200, &body)(req)
+		})
+	httpmock.RegisterResponder("GET", "http://localhost:12345/subscriptions",
+		httpmock.NewJsonResponderOrPanic(200, []subscription{
+			{ID: "sub12345", Name: "BatchPin", Stream: "es12345", FromBlock: "0"},
+		}))
+	httpmock.RegisterResponder("POST", "http://localhost:12345/instances/0x67890/BatchPin",
+		func(req *http.Request) (*http.Response, error) {
+			var body subscription
+			json.NewDecoder(req.Body).Decode(&body)
+			assert.Equal(t, "es67890", body.Stream)
+			return httpmock.NewJsonResponderOrPanic(200, subscription{ID: "sub67890"})(req)
+		})
+
+	ledgerID := fftypes.NewUUID()
+	resetConf()
+	utEthconnectConf.Set(restclient.HTTPConfigURL, "http://localhost:12345")
+	utEthconnectConf.Set(restclient.HTTPCustomClient, mockedClient)
+	utEthconnectConf.Set(EthconnectConfigInstancePath, "/instances/0x12345")
+	utEthconnectConf.Set(EthconnectConfigTopic, "topic1")
+	utEthconnectConf.Set(EthconnectConfigLedgers, []interface{}{
+		map[string]interface{}{
+			"id":       ledgerID.String(),
+			"name":     "ledger2",
+			"instance": "/instances/0x67890",
+			"topic":    "topic2",
+		},
+	})
+
+	err := e.Init(context.Background(), utConfPrefix, &blockchainmocks.Callbacks{})
+	assert.NoError(t, err)
+
+	assert.Len(t, e.ledgers, 2)
+	assert.Nil(t, e.ledgers[0].id)
+	assert.Equal(t, "es12345", e.ledgers[0].initInfo.stream.ID)
+	assert.Equal(t, "sub12345", e.ledgers[0].initInfo.subs[0].ID)
+	assert.Equal(t, *ledgerID, *e.ledgers[1].id)
+	assert.Equal(t, "ledger2", e.ledgers[1].name)
+	assert.Equal(t, "es67890", e.ledgers[1].initInfo.stream.ID)
+	assert.Equal(t, "sub67890", e.ledgers[1].initInfo.subs[0].ID)
+
+}
+
 func TestWSConnectFail(t *testing.T) {
 
 	wsm := &wsmocks.WSClient{}
 	e := &Ethereum{
-		ctx:    context.Background(),
-		wsconn: wsm,
+		ctx:     context.Background(),
+		ledgers: []*ethLedger{{wsconn: wsm}},
 	}
 	wsm.On("Connect").Return(fmt.Errorf("pop"))
 
@@ -174,10 +272,10 @@ func TestInitAllExistingStreams(t *testing.T) {
 	defer httpmock.DeactivateAndReset()
 
 	httpmock.RegisterResponder("GET", "http://localhost:12345/eventstreams",
-		httpmock.NewJsonResponderOrPanic(200, []eventStream{{ID: "es12345", WebSocket: eventStreamWebsocket{Topic: "topic1"}}}))
+		httpmock.NewJsonResponderOrPanic(200, []*eventStream{testStream("es12345", "topic1")}))
 	httpmock.RegisterResponder("GET", "http://localhost:12345/subscriptions",
 		httpmock.NewJsonResponderOrPanic(200, []subscription{
-			{ID: "sub12345", Name: "BatchPin"},
+			{ID: "sub12345", Name: "BatchPin", Stream: "es12345", FromBlock: "0"},
 		}))
 
 	resetConf()
@@ -189,8 +287,8 @@ func TestInitAllExistingStreams(t *testing.T) {
 	err := e.Init(context.Background(), utConfPrefix, &blockchainmocks.Callbacks{})
 
 	assert.Equal(t, 2, httpmock.GetTotalCallCount())
-	assert.Equal(t, "es12345", e.initInfo.stream.ID)
-	assert.Equal(t, "sub12345", e.initInfo.subs[0].ID)
+	assert.Equal(t, "es12345", e.ledgers[0].initInfo.stream.ID)
+	assert.Equal(t, "sub12345", e.ledgers[0].initInfo.subs[0].ID)
 
 	assert.NoError(t, err)
 
@@ -310,10 +408,11 @@ func TestSubQueryCreateError(t *testing.T) {
 
 func newTestEthereum() *Ethereum {
 	return &Ethereum{
-		ctx:          context.Background(),
-		client:       resty.New().SetHostURL("http://localhost:12345"),
-		instancePath: "/instances/0x12345",
-		topic:        "topic1",
+		ctx:    context.Background(),
+		client: resty.New().SetHostURL("http://localhost:12345"),
+		ledgers: []*ethLedger{
+			{instancePath: "/instances/0x12345", topic: "topic1"},
+		},
 	}
 }
 
@@ -354,6 +453,41 @@ func TestSubmitBatchPinOK(t *testing.T) {
 
 }
 
+func TestSubmitBatchPinLedger(t *testing.T) {
+
+	e := newTestEthereum()
+	ledgerID := fftypes.NewUUID()
+	e.ledgers = append(e.ledgers, &ethLedger{id: ledgerID, instancePath: "/instances/0x67890", topic: "topic2"})
+	httpmock.ActivateNonDefault(e.client.GetClient())
+	defer httpmock.DeactivateAndReset()
+
+	addr := ethHexFormatB32(fftypes.NewRandB32())
+	batch := &blockchain.BatchPin{
+		TransactionID: fftypes.NewUUID(),
+		BatchID:       fftypes.NewUUID(),
+		BatchHash:     fftypes.NewRandB32(),
+		Contexts:      []*fftypes.Bytes32{},
+	}
+
+	httpmock.RegisterResponder("POST", `http://localhost:12345/instances/0x67890/pinBatch`,
+		httpmock.NewJsonResponderOrPanic(200, asyncTXSubmission{ID: "abcd1234"}))
+
+	txid, err := e.SubmitBatchPin(context.Background(), ledgerID, &fftypes.Identity{OnChain: addr}, batch)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "abcd1234", txid)
+
+}
+
+func TestSubmitBatchPinUnknownLedger(t *testing.T) {
+
+	e := newTestEthereum()
+
+	_, err := e.SubmitBatchPin(context.Background(), fftypes.NewUUID(), &fftypes.Identity{}, &blockchain.BatchPin{})
+	assert.Regexp(t, "FF10261", err)
+
+}
+
 func TestSubmitBatchNilPayloadRef(t *testing.T) {
 
 	e := newTestEthereum()
@@ -418,6 +552,11 @@ func TestSubmitBatchPinFail(t *testing.T) {
 
 }
 
+func TestTrackPendingBatchPinsNoop(t *testing.T) {
+	e := &Ethereum{}
+	e.TrackPendingBatchPins(context.Background(), []string{"tx1"})
+}
+
 func TestVerifyEthAddress(t *testing.T) {
 	e := &Ethereum{}
 
@@ -503,22 +642,24 @@ func TestHandleMessageBatchPinOK(t *testing.T) {
 		callbacks: em,
 	}
 
-	em.On("BatchPinComplete", mock.Anything, "0x91d2b4381a4cd5c7c0f27565a7d4b829844c8635", mock.Anything, mock.Anything).Return(nil)
+	em.On("BatchPinComplete", mock.Anything, mock.Anything, "0x91d2b4381a4cd5c7c0f27565a7d4b829844c8635", mock.Anything, mock.Anything).Return(nil)
 
 	var events []interface{}
 	err := json.Unmarshal(data, &events)
 	assert.NoError(t, err)
-	err = e.handleMessageBatch(context.Background(), events)
+	ledgerID := fftypes.NewUUID()
+	err = e.handleMessageBatch(context.Background(), ledgerID, events)
 	assert.NoError(t, err)
 
-	b := em.Calls[0].Arguments[0].(*blockchain.BatchPin)
+	assert.Equal(t, ledgerID, em.Calls[0].Arguments[0])
+	b := em.Calls[0].Arguments[1].(*blockchain.BatchPin)
 	assert.Equal(t, "ns1", b.Namespace)
 	assert.Equal(t, "e19af8b3-9060-4051-812d-7597d19adfb9", b.TransactionID.String())
 	assert.Equal(t, "847d3bfd-0742-49ef-b65d-3fed15f5b0a6", b.BatchID.String())
 	assert.Equal(t, "d71eb138d74c229a388eb0e1abc03f4c7cbb21d4fc4b839fbf0ec73e4263f6be", b.BatchHash.String())
 	assert.Equal(t, "eda586bd8f3c4bc1db5c4b5755113b9a9b4174abe28679fdbc219129400dd7ae", b.BatchPaylodRef.String())
-	assert.Equal(t, "0x91d2b4381a4cd5c7c0f27565a7d4b829844c8635", em.Calls[0].Arguments[1])
-	assert.Equal(t, "0xc26df2bf1a733e9249372d61eb11bd8662d26c8129df76890b1beb2f6fa72628", em.Calls[0].Arguments[2])
+	assert.Equal(t, "0x91d2b4381a4cd5c7c0f27565a7d4b829844c8635", em.Calls[0].Arguments[2])
+	assert.Equal(t, "0xc26df2bf1a733e9249372d61eb11bd8662d26c8129df76890b1beb2f6fa72628", em.Calls[0].Arguments[3])
 	assert.Len(t, b.Contexts, 2)
 	assert.Equal(t, "68e4da79f805bca5b912bcda9c63d03e6e867108dabb9b944109aea541ef522a", b.Contexts[0].String())
 	assert.Equal(t, "19b82093de5ce92a01e333048e877e2374354bf846dd034864ef6ffbd6438771", b.Contexts[1].String())
@@ -558,22 +699,22 @@ func TestHandleMessageNilPayloadRef(t *testing.T) {
 		callbacks: em,
 	}
 
-	em.On("BatchPinComplete", mock.Anything, "0x91d2b4381a4cd5c7c0f27565a7d4b829844c8635", mock.Anything, mock.Anything).Return(nil)
+	em.On("BatchPinComplete", mock.Anything, mock.Anything, "0x91d2b4381a4cd5c7c0f27565a7d4b829844c8635", mock.Anything, mock.Anything).Return(nil)
 
 	var events []interface{}
 	err := json.Unmarshal(data, &events)
 	assert.NoError(t, err)
-	err = e.handleMessageBatch(context.Background(), events)
+	err = e.handleMessageBatch(context.Background(), nil, events)
 	assert.NoError(t, err)
 
-	b := em.Calls[0].Arguments[0].(*blockchain.BatchPin)
+	b := em.Calls[0].Arguments[1].(*blockchain.BatchPin)
 	assert.Equal(t, "ns1", b.Namespace)
 	assert.Equal(t, "e19af8b3-9060-4051-812d-7597d19adfb9", b.TransactionID.String())
 	assert.Equal(t, "847d3bfd-0742-49ef-b65d-3fed15f5b0a6", b.BatchID.String())
 	assert.Equal(t, "d71eb138d74c229a388eb0e1abc03f4c7cbb21d4fc4b839fbf0ec73e4263f6be", b.BatchHash.String())
 	assert.Nil(t, b.BatchPaylodRef)
-	assert.Equal(t, "0x91d2b4381a4cd5c7c0f27565a7d4b829844c8635", em.Calls[0].Arguments[1])
-	assert.Equal(t, "0xc26df2bf1a733e9249372d61eb11bd8662d26c8129df76890b1beb2f6fa72628", em.Calls[0].Arguments[2])
+	assert.Equal(t, "0x91d2b4381a4cd5c7c0f27565a7d4b829844c8635", em.Calls[0].Arguments[2])
+	assert.Equal(t, "0xc26df2bf1a733e9249372d61eb11bd8662d26c8129df76890b1beb2f6fa72628", em.Calls[0].Arguments[3])
 	assert.Len(t, b.Contexts, 2)
 	assert.Equal(t, "68e4da79f805bca5b912bcda9c63d03e6e867108dabb9b944109aea541ef522a", b.Contexts[0].String())
 	assert.Equal(t, "19b82093de5ce92a01e333048e877e2374354bf846dd034864ef6ffbd6438771", b.Contexts[1].String())
@@ -609,12 +750,12 @@ func TestHandleMessageBatchPinExit(t *testing.T) {
 		callbacks: em,
 	}
 
-	em.On("BatchPinComplete", mock.Anything, "0x91d2b4381a4cd5c7c0f27565a7d4b829844c8635", mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+	em.On("BatchPinComplete", mock.Anything, mock.Anything, "0x91d2b4381a4cd5c7c0f27565a7d4b829844c8635", mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
 
 	var events []interface{}
 	err := json.Unmarshal(data, &events)
 	assert.NoError(t, err)
-	err = e.handleMessageBatch(context.Background(), events)
+	err = e.handleMessageBatch(context.Background(), nil, events)
 	assert.EqualError(t, err, "pop")
 
 }
@@ -625,7 +766,7 @@ func TestHandleMessageBatchPinEmpty(t *testing.T) {
 	var events []interface{}
 	err := json.Unmarshal([]byte(`[{"signature": "BatchPin(address,uint256,string,bytes32,bytes32,bytes32,bytes32[])"}]`), &events)
 	assert.NoError(t, err)
-	err = e.handleMessageBatch(context.Background(), events)
+	err = e.handleMessageBatch(context.Background(), nil, events)
 	assert.NoError(t, err)
 	assert.Equal(t, 0, len(em.Calls))
 }
@@ -654,7 +795,7 @@ func TestHandleMessageBatchPinBadTransactionID(t *testing.T) {
 	var events []interface{}
 	err := json.Unmarshal(data, &events)
 	assert.NoError(t, err)
-	err = e.handleMessageBatch(context.Background(), events)
+	err = e.handleMessageBatch(context.Background(), nil, events)
 	assert.NoError(t, err)
 	assert.Equal(t, 0, len(em.Calls))
 }
@@ -683,7 +824,7 @@ func TestHandleMessageBatchPinBadIDentity(t *testing.T) {
 	var events []interface{}
 	err := json.Unmarshal(data, &events)
 	assert.NoError(t, err)
-	err = e.handleMessageBatch(context.Background(), events)
+	err = e.handleMessageBatch(context.Background(), nil, events)
 	assert.NoError(t, err)
 	assert.Equal(t, 0, len(em.Calls))
 }
@@ -712,7 +853,7 @@ func TestHandleMessageBatchPinBadBatchHash(t *testing.T) {
 	var events []interface{}
 	err := json.Unmarshal(data, &events)
 	assert.NoError(t, err)
-	err = e.handleMessageBatch(context.Background(), events)
+	err = e.handleMessageBatch(context.Background(), nil, events)
 	assert.NoError(t, err)
 	assert.Equal(t, 0, len(em.Calls))
 }
@@ -741,7 +882,7 @@ func TestHandleMessageBatchPinBadPayloadRef(t *testing.T) {
 	var events []interface{}
 	err := json.Unmarshal(data, &events)
 	assert.NoError(t, err)
-	err = e.handleMessageBatch(context.Background(), events)
+	err = e.handleMessageBatch(context.Background(), nil, events)
 	assert.NoError(t, err)
 	assert.Equal(t, 0, len(em.Calls))
 }
@@ -770,7 +911,7 @@ func TestHandleMessageBatchPinBadPin(t *testing.T) {
 	var events []interface{}
 	err := json.Unmarshal(data, &events)
 	assert.NoError(t, err)
-	err = e.handleMessageBatch(context.Background(), events)
+	err = e.handleMessageBatch(context.Background(), nil, events)
 	assert.NoError(t, err)
 	assert.Equal(t, 0, len(em.Calls))
 }
@@ -778,7 +919,7 @@ func TestHandleMessageBatchPinBadPin(t *testing.T) {
 func TestHandleMessageBatchBadJSON(t *testing.T) {
 	em := &blockchainmocks.Callbacks{}
 	e := &Ethereum{callbacks: em}
-	err := e.handleMessageBatch(context.Background(), []interface{}{10, 20})
+	err := e.handleMessageBatch(context.Background(), nil, []interface{}{10, 20})
 	assert.NoError(t, err)
 	assert.Equal(t, 0, len(em.Calls))
 }
@@ -790,13 +931,15 @@ func TestEventLoopContextCancelled(t *testing.T) {
 	cancel()
 	e := &Ethereum{
 		ctx:       ctxCancelled,
-		topic:     "topic1",
 		callbacks: em,
-		wsconn:    wsm,
+	}
+	ledger := &ethLedger{
+		topic:  "topic1",
+		wsconn: wsm,
 	}
 	r := make(<-chan []byte)
 	wsm.On("Receive").Return(r)
-	e.eventLoop() // we're simply looking for it exiting
+	e.eventLoop(ledger) // we're simply looking for it exiting
 }
 
 func TestEventLoopReceiveClosed(t *testing.T) {
@@ -804,14 +947,16 @@ func TestEventLoopReceiveClosed(t *testing.T) {
 	wsm := &wsmocks.WSClient{}
 	e := &Ethereum{
 		ctx:       context.Background(),
-		topic:     "topic1",
 		callbacks: em,
-		wsconn:    wsm,
+	}
+	ledger := &ethLedger{
+		topic:  "topic1",
+		wsconn: wsm,
 	}
 	r := make(chan []byte)
 	close(r)
 	wsm.On("Receive").Return((<-chan []byte)(r))
-	e.eventLoop() // we're simply looking for it exiting
+	e.eventLoop(ledger) // we're simply looking for it exiting
 }
 
 func TestEventLoopSendClosed(t *testing.T) {
@@ -819,25 +964,24 @@ func TestEventLoopSendClosed(t *testing.T) {
 	wsm := &wsmocks.WSClient{}
 	e := &Ethereum{
 		ctx:       context.Background(),
-		topic:     "topic1",
 		callbacks: em,
-		wsconn:    wsm,
+	}
+	ledger := &ethLedger{
+		topic:  "topic1",
+		wsconn: wsm,
 	}
 	r := make(chan []byte, 1)
 	r <- []byte(`[]`)
 	wsm.On("Receive").Return((<-chan []byte)(r))
 	wsm.On("Send", mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
-	e.eventLoop() // we're simply looking for it exiting
+	e.eventLoop(ledger) // we're simply looking for it exiting
 }
 
 func TestHandleReceiptTXSuccess(t *testing.T) {
 	em := &blockchainmocks.Callbacks{}
-	wsm := &wsmocks.WSClient{}
 	e := &Ethereum{
 		ctx:       context.Background(),
-		topic:     "topic1",
 		callbacks: em,
-		wsconn:    wsm,
 	}
 
 	var reply fftypes.JSONObject
@@ -880,12 +1024,9 @@ func TestHandleReceiptTXSuccess(t *testing.T) {
 
 func TestHandleReceiptTXFail(t *testing.T) {
 	em := &blockchainmocks.Callbacks{}
-	wsm := &wsmocks.WSClient{}
 	e := &Ethereum{
 		ctx:       context.Background(),
-		topic:     "topic1",
 		callbacks: em,
-		wsconn:    wsm,
 	}
 
 	var reply fftypes.JSONObject
@@ -917,3 +1058,255 @@ func TestHandleReceiptTXFail(t *testing.T) {
 	assert.NoError(t, err)
 
 }
+
+func newTestEthereumStreams() *Ethereum {
+	e := newTestEthereum()
+	e.batchSize = defaultBatchSize
+	e.batchTimeoutMS = defaultBatchTimeout
+	e.ledgers[0].name = "default"
+	e.ledgers[0].fromBlock = "0"
+	return e
+}
+
+func TestEnsureEventStreamsDriftUpdated(t *testing.T) {
+
+	e := newTestEthereumStreams()
+	httpmock.ActivateNonDefault(e.client.GetClient())
+	defer httpmock.DeactivateAndReset()
+
+	driftedStream := testStream("es12345", "topic1")
+	driftedStream.BatchSize = 10
+	httpmock.RegisterResponder("GET", "http://localhost:12345/eventstreams",
+		httpmock.NewJsonResponderOrPanic(200, []*eventStream{
+			driftedStream,
+			testStream("es67890", "topic2"),
+		}))
+	httpmock.RegisterResponder("PATCH", "http://localhost:12345/eventstreams/es12345",
+		func(req *http.Request) (*http.Response, error) {
+			var body eventStream
+			json.NewDecoder(req.Body).Decode(&body)
+			assert.Equal(t, uint(defaultBatchSize), body.BatchSize)
+			assert.Equal(t, "topic1", body.WebSocket.Topic)
+			return httpmock.NewJsonResponderOrPanic(200, &body)(req)
+		})
+	httpmock.RegisterResponder("GET", "http://localhost:12345/subscriptions",
+		httpmock.NewJsonResponderOrPanic(200, []subscription{
+			{ID: "sub12345", Name: "BatchPin", Stream: "es12345", FromBlock: "0"},
+			{ID: "sub67890", Name: "BatchPin", Stream: "es67890", FromBlock: "0"},
+		}))
+
+	err := e.ensureEventStreams(e.ledgers[0])
+	assert.NoError(t, err)
+	assert.Equal(t, 3, httpmock.GetTotalCallCount())
+	assert.Equal(t, "es12345", e.ledgers[0].initInfo.stream.ID)
+	assert.Equal(t, uint(defaultBatchSize), e.ledgers[0].initInfo.stream.BatchSize)
+	assert.Equal(t, "sub12345", e.ledgers[0].initInfo.subs[0].ID)
+}
+
+func TestEnsureEventStreamsDriftUpdateFail(t *testing.T) {
+
+	e := newTestEthereumStreams()
+	httpmock.ActivateNonDefault(e.client.GetClient())
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "http://localhost:12345/eventstreams",
+		httpmock.NewJsonResponderOrPanic(200, []*eventStream{{ID: "es12345", WebSocket: eventStreamWebsocket{Topic: "topic1"}}}))
+	httpmock.RegisterResponder("PATCH", "http://localhost:12345/eventstreams/es12345",
+		httpmock.NewStringResponder(500, "pop"))
+
+	err := e.ensureEventStreams(e.ledgers[0])
+	assert.Regexp(t, "FF10111.*pop", err)
+}
+
+func TestEnsureEventStreamsSubscriptionFromBlockChanged(t *testing.T) {
+
+	e := newTestEthereumStreams()
+	e.ledgers[0].fromBlock = "1000"
+	httpmock.ActivateNonDefault(e.client.GetClient())
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "http://localhost:12345/eventstreams",
+		httpmock.NewJsonResponderOrPanic(200, []*eventStream{testStream("es12345", "topic1")}))
+	httpmock.RegisterResponder("GET", "http://localhost:12345/subscriptions",
+		httpmock.NewJsonResponderOrPanic(200, []subscription{
+			{ID: "sub12345", Name: "BatchPin", Stream: "es12345", FromBlock: "0"},
+		}))
+
+	// The existing subscription is kept, so it resumes from its checkpoint
+	err := e.ensureEventStreams(e.ledgers[0])
+	assert.NoError(t, err)
+	assert.Equal(t, "es12345", e.ledgers[0].initInfo.stream.ID)
+	assert.Equal(t, "sub12345", e.ledgers[0].initInfo.subs[0].ID)
+	assert.Equal(t, 2, httpmock.GetTotalCallCount())
+}
+
+func TestInitLedgerFromBlock(t *testing.T) {
+
+	e := &Ethereum{}
+	resetConf()
+	utEthconnectConf.Set(restclient.HTTPConfigURL, "http://localhost:12345")
+	utEthconnectConf.Set(EthconnectConfigInstancePath, "/instances/0x12345")
+	utEthconnectConf.Set(EthconnectConfigTopic, "topic1")
+	utEthconnectConf.Set(EthconnectConfigSkipEventstreamInit, true)
+	utEthconnectConf.Set(EthconnectConfigFromBlock, "latest")
+	utEthconnectConf.Set(EthconnectConfigLedgers, []interface{}{
+		map[string]interface{}{"id": fftypes.NewUUID().String(), "name": "ledger2", "instance": "/instances/0x67890", "topic": "topic2"},
+		map[string]interface{}{"id": fftypes.NewUUID().String(), "name": "ledger3", "instance": "/instances/0xabcde", "topic": "topic3", "fromBlock": "12345"},
+	})
+
+	err := e.Init(context.Background(), utConfPrefix, &blockchainmocks.Callbacks{})
+	assert.NoError(t, err)
+	assert.Equal(t, "latest", e.ledgers[0].fromBlock)
+	assert.Equal(t, "latest", e.ledgers[1].fromBlock)
+	assert.Equal(t, "12345", e.ledgers[2].fromBlock)
+}
+
+func TestAfterConnectReconnectVerifiesStream(t *testing.T) {
+
+	e := newTestEthereumStreams()
+	httpmock.ActivateNonDefault(e.client.GetClient())
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "http://localhost:12345/eventstreams",
+		httpmock.NewJsonResponderOrPanic(200, []*eventStream{}))
+	httpmock.RegisterResponder("POST", "http://localhost:12345/eventstreams",
+		httpmock.NewJsonResponderOrPanic(200, eventStream{ID: "es67890"}))
+	httpmock.RegisterResponder("GET", "http://localhost:12345/subscriptions",
+		httpmock.NewJsonResponderOrPanic(200, []subscription{}))
+	httpmock.RegisterResponder("POST", "http://localhost:12345/instances/0x12345/BatchPin",
+		httpmock.NewJsonResponderOrPanic(200, subscription{ID: "sub67890"}))
+
+	wsm := &wsmocks.WSClient{}
+	wsm.On("Send", mock.Anything, mock.Anything).Return(nil)
+	afterConnect := e.afterConnect(e.ledgers[0])
+
+	// First connect does not verify, as that happened during init
+	err := afterConnect(context.Background(), wsm)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, httpmock.GetTotalCallCount())
+
+	// Stream was deleted while disconnected, so is recreated before we listen
+	err = afterConnect(context.Background(), wsm)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, httpmock.GetTotalCallCount())
+	assert.Equal(t, "es67890", e.ledgers[0].initInfo.stream.ID)
+	wsm.AssertNumberOfCalls(t, "Send", 4)
+}
+
+func TestAfterConnectReconnectVerifyFail(t *testing.T) {
+
+	e := newTestEthereumStreams()
+	e.ledgers[0].connected = true
+	httpmock.ActivateNonDefault(e.client.GetClient())
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "http://localhost:12345/eventstreams",
+		httpmock.NewStringResponder(500, "pop"))
+
+	wsm := &wsmocks.WSClient{}
+	err := e.afterConnect(e.ledgers[0])(context.Background(), wsm)
+	assert.Regexp(t, "FF10111.*pop", err)
+	wsm.AssertNotCalled(t, "Send", mock.Anything, mock.Anything)
+}
+
+func TestAfterConnectReconnectSkipEventstreamInit(t *testing.T) {
+
+	e := newTestEthereumStreams()
+	e.skipEventstreamInit = true
+	e.ledgers[0].connected = true
+
+	wsm := &wsmocks.WSClient{}
+	wsm.On("Send", mock.Anything, mock.Anything).Return(nil)
+	err := e.afterConnect(e.ledgers[0])(context.Background(), wsm)
+	assert.NoError(t, err)
+}
+
+func TestStartHealthCheck(t *testing.T) {
+
+	e := newTestEthereumStreams()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	e.ctx = ctx
+	e.healthCheckInterval = 1 * time.Millisecond
+	httpmock.ActivateNonDefault(e.client.GetClient())
+	defer httpmock.DeactivateAndReset()
+
+	checked := make(chan struct{}, 10)
+	httpmock.RegisterResponder("GET", "http://localhost:12345/eventstreams",
+		func(req *http.Request) (*http.Response, error) {
+			checked <- struct{}{}
+			return httpmock.NewStringResponse(500, "pop"), nil
+		})
+
+	wsm := &wsmocks.WSClient{}
+	wsm.On("Connect").Return(nil)
+	e.ledgers[0].wsconn = wsm
+
+	err := e.Start()
+	assert.NoError(t, err)
+
+	// Failures are logged, and the check continues
+	<-checked
+	<-checked
+}
+
+func TestHealthCheckLoopExits(t *testing.T) {
+	e := newTestEthereumStreams()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	e.ctx = ctx
+	e.healthCheckInterval = 1 * time.Minute
+	e.healthCheckLoop() // we're simply looking for it exiting
+}
+
+func TestStatus(t *testing.T) {
+	ledgerID := fftypes.NewUUID()
+	e := &Ethereum{
+		ledgers: []*ethLedger{
+			{name: "default", topic: "topic1", lastBlock: 12345},
+			{id: ledgerID, name: "ledger2", topic: "topic2"},
+		},
+	}
+	e.ledgers[0].initInfo.stream = &eventStream{ID: "es12345"}
+	e.ledgers[0].initInfo.subs = []*subscription{{ID: "sub12345"}}
+
+	status := e.Status()
+	b, err := json.Marshal(status)
+	assert.NoError(t, err)
+	assert.JSONEq(t, fmt.Sprintf(`{"ledgers":[
+		{"id":null,"name":"default","topic":"topic1","eventStream":"es12345","subscriptions":["sub12345"],"lastBlock":12345},
+		{"id":"%s","name":"ledger2","topic":"topic2","eventStream":"","subscriptions":[],"lastBlock":0}
+	]}`, ledgerID), string(b))
+}
+
+func TestUpdateLastBlock(t *testing.T) {
+	l := &ethLedger{lastBlock: 10}
+	l.updateLastBlock([]interface{}{
+		map[string]interface{}{"blockNumber": "38"},
+		map[string]interface{}{"blockNumber": "12"},
+		map[string]interface{}{"blockNumber": "bad"},
+		map[string]interface{}{},
+		"not a map",
+	})
+	assert.Equal(t, int64(38), l.lastBlock)
+}
+
+func TestEventLoopUpdatesLastBlock(t *testing.T) {
+	em := &blockchainmocks.Callbacks{}
+	wsm := &wsmocks.WSClient{}
+	e := &Ethereum{
+		ctx:       context.Background(),
+		callbacks: em,
+	}
+	ledger := &ethLedger{
+		topic:  "topic1",
+		wsconn: wsm,
+	}
+	r := make(chan []byte, 1)
+	r <- []byte(`[{"signature":"Unknown()","blockNumber":"38"}]`)
+	close(r)
+	wsm.On("Receive").Return((<-chan []byte)(r))
+	wsm.On("Send", mock.Anything, mock.Anything).Return(nil)
+	e.eventLoop(ledger)
+	assert.Equal(t, int64(38), ledger.lastBlock)
+}
@@ -0,0 +1,157 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ethrpc
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/big"
+
+	"github.com/hyperledger-labs/firefly/pkg/blockchain"
+	"github.com/hyperledger-labs/firefly/pkg/fftypes"
+	"golang.org/x/crypto/sha3"
+)
+
+const (
+	pinBatchMethodSignature      = "pinBatch(string,bytes32,bytes32,bytes32,bytes32[])"
+	broadcastBatchEventSignature = "BatchPin(address,uint256,string,bytes32,bytes32,bytes32,bytes32[])"
+	abiWordSize                  = 32
+)
+
+var (
+	pinBatchMethodID    = keccak256([]byte(pinBatchMethodSignature))[0:4]
+	batchPinEventTopic0 = "0x" + fmt.Sprintf("%x", keccak256([]byte(broadcastBatchEventSignature)))
+	zeroBytes32         = fftypes.Bytes32{}
+)
+
+// batchPinEvent is the decoded data of a BatchPin event emitted by Firefly.sol
+type batchPinEvent struct {
+	Author     string
+	Timestamp  *big.Int
+	Namespace  string
+	UUIDs      fftypes.Bytes32
+	BatchHash  fftypes.Bytes32
+	PayloadRef fftypes.Bytes32
+	Contexts   []*fftypes.Bytes32
+}
+
+func keccak256(b ...[]byte) []byte {
+	h := sha3.NewLegacyKeccak256()
+	for _, d := range b {
+		h.Write(d)
+	}
+	return h.Sum(nil)
+}
+
+func abiUint(i uint64) []byte {
+	w := make([]byte, abiWordSize)
+	binary.BigEndian.PutUint64(w[abiWordSize-8:], i)
+	return w
+}
+
+func abiBytes32(b *fftypes.Bytes32) []byte {
+	if b == nil {
+		b = &zeroBytes32
+	}
+	return b[:]
+}
+
+func abiPaddedLen(l int) int {
+	return (l + abiWordSize - 1) / abiWordSize * abiWordSize
+}
+
+// encodePinBatch builds the transaction data for a call to Firefly.sol pinBatch()
+func encodePinBatch(batch *blockchain.BatchPin) []byte {
+	var uuids fftypes.Bytes32
+	copy(uuids[0:16], (*batch.TransactionID)[:])
+	copy(uuids[16:32], (*batch.BatchID)[:])
+
+	headLen := 5 * abiWordSize
+	nsTailLen := abiWordSize + abiPaddedLen(len(batch.Namespace))
+
+	data := make([]byte, 0, 4+headLen+nsTailLen+abiWordSize*(1+len(batch.Contexts)))
+	data = append(data, pinBatchMethodID...)
+	data = append(data, abiUint(uint64(headLen))...)
+	data = append(data, uuids[:]...)
+	data = append(data, abiBytes32(batch.BatchHash)...)
+	data = append(data, abiBytes32(batch.BatchPaylodRef)...)
+	data = append(data, abiUint(uint64(headLen+nsTailLen))...)
+
+	data = append(data, abiUint(uint64(len(batch.Namespace)))...)
+	data = append(data, batch.Namespace...)
+	data = append(data, make([]byte, abiPaddedLen(len(batch.Namespace))-len(batch.Namespace))...)
+
+	data = append(data, abiUint(uint64(len(batch.Contexts)))...)
+	for _, c := range batch.Contexts {
+		data = append(data, abiBytes32(c)...)
+	}
+	return data
+}
+
+// abiReadUint reads a word that is used as an offset or length, checking it fits within the data
+func abiReadUint(data []byte, pos int) (int, error) {
+	if pos < 0 || pos+abiWordSize > len(data) {
+		return -1, fmt.Errorf("position %d out of range", pos)
+	}
+	i := new(big.Int).SetBytes(data[pos : pos+abiWordSize])
+	if !i.IsInt64() || i.Int64() > int64(len(data)) {
+		return -1, fmt.Errorf("value at position %d out of range", pos)
+	}
+	return int(i.Int64()), nil
+}
+
+// decodeBatchPinEvent decodes the non-indexed data of a BatchPin event
+func decodeBatchPinEvent(data []byte) (*batchPinEvent, error) {
+	if len(data) < 7*abiWordSize {
+		return nil, fmt.Errorf("data too short (%d bytes)", len(data))
+	}
+	word := func(i int) []byte { return data[i*abiWordSize : (i+1)*abiWordSize] }
+	event := &batchPinEvent{
+		Author:    fmt.Sprintf("0x%x", word(0)[12:]),
+		Timestamp: new(big.Int).SetBytes(word(1)),
+	}
+	copy(event.UUIDs[:], word(3))
+	copy(event.BatchHash[:], word(4))
+	copy(event.PayloadRef[:], word(5))
+
+	nsOffset, err := abiReadUint(data, 2*abiWordSize)
+	if err != nil {
+		return nil, fmt.Errorf("namespace: %s", err)
+	}
+	nsLen, err := abiReadUint(data, nsOffset)
+	if err != nil || nsOffset+abiWordSize+nsLen > len(data) {
+		return nil, fmt.Errorf("namespace: length out of range")
+	}
+	event.Namespace = string(data[nsOffset+abiWordSize : nsOffset+abiWordSize+nsLen])
+
+	ctxOffset, err := abiReadUint(data, 6*abiWordSize)
+	if err != nil {
+		return nil, fmt.Errorf("contexts: %s", err)
+	}
+	ctxLen, err := abiReadUint(data, ctxOffset)
+	if err != nil || ctxOffset+abiWordSize*(1+ctxLen) > len(data) {
+		return nil, fmt.Errorf("contexts: length out of range")
+	}
+	event.Contexts = make([]*fftypes.Bytes32, ctxLen)
+	for i := 0; i < ctxLen; i++ {
+		var c fftypes.Bytes32
+		start := ctxOffset + abiWordSize*(1+i)
+		copy(c[:], data[start:start+abiWordSize])
+		event.Contexts[i] = &c
+	}
+	return event, nil
+}
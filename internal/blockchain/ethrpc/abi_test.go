@@ -0,0 +1,151 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ethrpc
+
+import (
+	"context"
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger-labs/firefly/pkg/blockchain"
+	"github.com/hyperledger-labs/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+)
+
+// encodeTestBatchPinEvent builds the data of a BatchPin event, as it would be emitted by Firefly.sol
+func encodeTestBatchPinEvent(author string, timestamp int64, batch *blockchain.BatchPin) string {
+	// The event has the same layout as the method inputs, with the author and timestamp in front
+	args := encodePinBatch(batch)[4:]
+	headLen := 7 * abiWordSize
+	nsOffset := headLen
+	ctxOffset := headLen + abiWordSize + abiPaddedLen(len(batch.Namespace))
+	authorBytes, _ := hex.DecodeString(strings.TrimPrefix(author, "0x"))
+	data := make([]byte, 12)
+	data = append(data, authorBytes...)
+	data = append(data, abiUint(uint64(timestamp))...)
+	data = append(data, abiUint(uint64(nsOffset))...)
+	data = append(data, args[abiWordSize:4*abiWordSize]...)
+	data = append(data, abiUint(uint64(ctxOffset))...)
+	data = append(data, args[5*abiWordSize:]...)
+	return "0x" + hex.EncodeToString(data)
+}
+
+func mustParseBytes32(hexStr string) *fftypes.Bytes32 {
+	b32, err := fftypes.ParseBytes32(context.Background(), hexStr)
+	if err != nil {
+		panic(err)
+	}
+	return b32
+}
+
+func testBatchPin() *blockchain.BatchPin {
+	return &blockchain.BatchPin{
+		Namespace:      "ns1",
+		TransactionID:  fftypes.MustParseUUID("9ffc50ff-6bfe-4502-adc7-93aea54cc059"),
+		BatchID:        fftypes.MustParseUUID("c5df767c-fe44-4e03-8eb5-1c5523097db5"),
+		BatchHash:      mustParseBytes32("d71eb138d74c229a388eb0e1abc03f4c7cbb21d4fc4b839fbf0ec73e4263f6be"),
+		BatchPaylodRef: mustParseBytes32("eda586bd8f3c4bc1db5c4b5755113b9a9b4174abe28679fdbc219129400dd7ae"),
+		Contexts: []*fftypes.Bytes32{
+			mustParseBytes32("68e4da79f805bca5b912bcda9c63d03e6e867108dabb9b944109aea541ef522a"),
+			mustParseBytes32("19b82093de5ce92a01e333048e877e2374354bf846dd034864ef6ffbd6438771"),
+		},
+	}
+}
+
+func TestEncodePinBatch(t *testing.T) {
+	data := encodePinBatch(testBatchPin())
+	words := []string{
+		"00000000000000000000000000000000000000000000000000000000000000a0",
+		"9ffc50ff6bfe4502adc793aea54cc059c5df767cfe444e038eb51c5523097db5",
+		"d71eb138d74c229a388eb0e1abc03f4c7cbb21d4fc4b839fbf0ec73e4263f6be",
+		"eda586bd8f3c4bc1db5c4b5755113b9a9b4174abe28679fdbc219129400dd7ae",
+		"00000000000000000000000000000000000000000000000000000000000000e0",
+		"0000000000000000000000000000000000000000000000000000000000000003",
+		"6e73310000000000000000000000000000000000000000000000000000000000",
+		"0000000000000000000000000000000000000000000000000000000000000002",
+		"68e4da79f805bca5b912bcda9c63d03e6e867108dabb9b944109aea541ef522a",
+		"19b82093de5ce92a01e333048e877e2374354bf846dd034864ef6ffbd6438771",
+	}
+	assert.Equal(t, hex.EncodeToString(pinBatchMethodID)+strings.Join(words, ""), hex.EncodeToString(data))
+}
+
+func TestEncodePinBatchNilHashes(t *testing.T) {
+	batch := testBatchPin()
+	batch.BatchPaylodRef = nil
+	batch.Contexts = []*fftypes.Bytes32{nil}
+	data := encodePinBatch(batch)
+	assert.Equal(t, zeroBytes32[:], data[4+3*abiWordSize:4+4*abiWordSize])
+	assert.Equal(t, zeroBytes32[:], data[len(data)-abiWordSize:])
+}
+
+func TestMethodAndEventSignatures(t *testing.T) {
+	// Well known selector of the Firefly.sol pinBatch() method
+	assert.Len(t, pinBatchMethodID, 4)
+	assert.Equal(t, keccak256([]byte("pinBatch(string,bytes32,bytes32,bytes32,bytes32[])"))[0:4], pinBatchMethodID)
+	assert.Len(t, batchPinEventTopic0, 66)
+	// Keccak-256 of the empty string
+	assert.Equal(t, "c5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470", hex.EncodeToString(keccak256()))
+}
+
+func TestDecodeBatchPinEvent(t *testing.T) {
+	batch := testBatchPin()
+	dataHex := encodeTestBatchPinEvent("0x91d2b4381a4cd5c7c0f27565a7d4b829844c8635", 1620576488, batch)
+	data, _ := hex.DecodeString(dataHex[2:])
+	event, err := decodeBatchPinEvent(data)
+	assert.NoError(t, err)
+	assert.Equal(t, "0x91d2b4381a4cd5c7c0f27565a7d4b829844c8635", event.Author)
+	assert.Equal(t, big.NewInt(1620576488), event.Timestamp)
+	assert.Equal(t, "ns1", event.Namespace)
+	assert.Equal(t, "9ffc50ff6bfe4502adc793aea54cc059c5df767cfe444e038eb51c5523097db5", event.UUIDs.String())
+	assert.Equal(t, *batch.BatchHash, event.BatchHash)
+	assert.Equal(t, *batch.BatchPaylodRef, event.PayloadRef)
+	assert.Equal(t, batch.Contexts, event.Contexts)
+}
+
+func TestDecodeBatchPinEventTooShort(t *testing.T) {
+	_, err := decodeBatchPinEvent(make([]byte, 6*abiWordSize))
+	assert.Regexp(t, "data too short", err)
+}
+
+func TestDecodeBatchPinEventBadOffsets(t *testing.T) {
+	batch := testBatchPin()
+	dataHex := encodeTestBatchPinEvent("0x91d2b4381a4cd5c7c0f27565a7d4b829844c8635", 1620576488, batch)
+	valid, _ := hex.DecodeString(dataHex[2:])
+
+	testCases := []struct {
+		name   string
+		modify func(data []byte)
+		errStr string
+	}{
+		{"nsOffsetHuge", func(d []byte) { d[2*abiWordSize] = 0xff }, "namespace: value at position 64 out of range"},
+		{"nsOffsetPastEnd", func(d []byte) { d[3*abiWordSize-1] = 0xff }, "namespace: length out of range"},
+		{"nsLenPastEnd", func(d []byte) { d[8*abiWordSize-1] = 0xff }, "namespace: length out of range"},
+		{"ctxOffsetHuge", func(d []byte) { d[6*abiWordSize] = 0xff }, "contexts: value at position 192 out of range"},
+		{"ctxOffsetPastEnd", func(d []byte) { d[7*abiWordSize-1] = 0x70 }, "contexts: length out of range"},
+		{"ctxLenPastEnd", func(d []byte) { d[len(d)-2*abiWordSize-1] = 0x03 }, "contexts: length out of range"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			data := append([]byte{}, valid...)
+			tc.modify(data)
+			_, err := decodeBatchPinEvent(data)
+			assert.Regexp(t, tc.errStr, err)
+		})
+	}
+}
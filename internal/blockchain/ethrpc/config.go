@@ -0,0 +1,92 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ethrpc
+
+import (
+	"github.com/hyperledger-labs/firefly/internal/config"
+	"github.com/hyperledger-labs/firefly/internal/restclient"
+)
+
+const (
+	defaultGasMultiplier   = 1.5
+	defaultFromBlock       = "0"
+	defaultPollingInterval = "1s"
+	defaultBlockRange      = 500
+	defaultConfirmations   = 0
+	defaultRetryInitDelay  = "250ms"
+	defaultRetryMaxDelay   = "30s"
+	defaultRetryFactor     = 2.0
+)
+
+const (
+	// EthRPCConfigKey is a sub-key in the config to contain all the JSON-RPC specific config
+	EthRPCConfigKey = "ethrpc"
+
+	// EthRPCConfigContract is the address of the deployed Firefly.sol contract, for the default ledger
+	EthRPCConfigContract = "contract"
+	// EthRPCConfigGasMultiplier is applied to the result of eth_estimateGas to set the gas limit of each transaction
+	EthRPCConfigGasMultiplier = "gasMultiplier"
+	// EthRPCConfigKeystorePath is the directory containing encrypted (V3) keystore files for the signing addresses
+	EthRPCConfigKeystorePath = "keystore.path"
+	// EthRPCConfigKeystorePasswordFile is a file containing the password used to decrypt the keystore files
+	EthRPCConfigKeystorePasswordFile = "keystore.passwordFile"
+	// EthRPCConfigEventsFromBlock is the block number to start listening from, when there is no checkpoint
+	EthRPCConfigEventsFromBlock = "events.fromBlock"
+	// EthRPCConfigEventsPollingInterval is how often to poll eth_getLogs when we have caught up with the chain head
+	EthRPCConfigEventsPollingInterval = "events.pollingInterval"
+	// EthRPCConfigEventsBlockRange is the maximum number of blocks queried in a single eth_getLogs call
+	EthRPCConfigEventsBlockRange = "events.blockRange"
+	// EthRPCConfigEventsConfirmations is the number of blocks that must be mined on top of a block, before its events
+	// and transaction receipts are processed. Zero processes blocks as soon as they are mined, with no protection from re-orgs
+	EthRPCConfigEventsConfirmations = "events.confirmations"
+	// EthRPCConfigEventsCheckpointFile is the file used to persist the next block to process, so listening resumes after a restart
+	EthRPCConfigEventsCheckpointFile = "events.checkpointFile"
+	// EthRPCConfigEventsRetryInitDelay is the initial delay before retrying a failed JSON-RPC call in the event loop
+	EthRPCConfigEventsRetryInitDelay = "events.retry.initDelay"
+	// EthRPCConfigEventsRetryMaxDelay is the maximum delay between retries of a failed JSON-RPC call in the event loop
+	EthRPCConfigEventsRetryMaxDelay = "events.retry.maxDelay"
+	// EthRPCConfigEventsRetryFactor is the backoff factor between retries of a failed JSON-RPC call in the event loop
+	EthRPCConfigEventsRetryFactor = "events.retry.factor"
+	// EthRPCConfigLedgers is an array of additional named ledgers, each with its own contract address.
+	// The contract configured at the top level is used for the default ledger
+	EthRPCConfigLedgers = "ledgers"
+)
+
+const (
+	// EthRPCLedgerConfigID is the UUID of the ledger, as referred to by groups
+	EthRPCLedgerConfigID = "id"
+	// EthRPCLedgerConfigName is a friendly name for the ledger
+	EthRPCLedgerConfigName = "name"
+)
+
+func (e *EthRPC) InitPrefix(prefix config.Prefix) {
+	rpcConf := prefix.SubPrefix(EthRPCConfigKey)
+	restclient.InitPrefix(rpcConf)
+	rpcConf.AddKnownKey(EthRPCConfigContract)
+	rpcConf.AddKnownKey(EthRPCConfigGasMultiplier, defaultGasMultiplier)
+	rpcConf.AddKnownKey(EthRPCConfigKeystorePath)
+	rpcConf.AddKnownKey(EthRPCConfigKeystorePasswordFile)
+	rpcConf.AddKnownKey(EthRPCConfigEventsFromBlock, defaultFromBlock)
+	rpcConf.AddKnownKey(EthRPCConfigEventsPollingInterval, defaultPollingInterval)
+	rpcConf.AddKnownKey(EthRPCConfigEventsBlockRange, defaultBlockRange)
+	rpcConf.AddKnownKey(EthRPCConfigEventsConfirmations, defaultConfirmations)
+	rpcConf.AddKnownKey(EthRPCConfigEventsCheckpointFile)
+	rpcConf.AddKnownKey(EthRPCConfigEventsRetryInitDelay, defaultRetryInitDelay)
+	rpcConf.AddKnownKey(EthRPCConfigEventsRetryMaxDelay, defaultRetryMaxDelay)
+	rpcConf.AddKnownKey(EthRPCConfigEventsRetryFactor, defaultRetryFactor)
+	rpcConf.AddKnownKey(EthRPCConfigLedgers)
+}
@@ -0,0 +1,332 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ethrpc
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/go-resty/resty/v2"
+	"github.com/hyperledger-labs/firefly/internal/config"
+	"github.com/hyperledger-labs/firefly/internal/i18n"
+	"github.com/hyperledger-labs/firefly/internal/log"
+	"github.com/hyperledger-labs/firefly/internal/restclient"
+	"github.com/hyperledger-labs/firefly/internal/retry"
+	"github.com/hyperledger-labs/firefly/pkg/blockchain"
+	"github.com/hyperledger-labs/firefly/pkg/fftypes"
+)
+
+// EthRPC is a blockchain plugin that talks directly to an Ethereum node over JSON-RPC,
+// signing transactions with keys from a local keystore, and polling for events with eth_getLogs
+type EthRPC struct {
+	rpcID         int64 // first for 64bit alignment of atomic operations
+	ctx           context.Context
+	capabilities  *blockchain.Capabilities
+	callbacks     blockchain.Callbacks
+	client        *resty.Client
+	ledgers       []*ethrpcLedger
+	keystore      *keystore
+	chainID       *big.Int
+	gasMultiplier float64
+	nonceMux      sync.Mutex
+	nonces        map[string]*addressNonce
+	pendingMux    sync.Mutex
+	pendingTXs    []string
+	events        eventListener
+}
+
+// ethrpcLedger is a deployed instance of the Firefly.sol contract.
+// The default ledger has a nil ID, and is always the first in the list.
+type ethrpcLedger struct {
+	id       *fftypes.UUID
+	name     string
+	contract string
+}
+
+// addressNonce tracks the next nonce for a signing address. The lock is held for the whole of
+// the signing and submission of a transaction, so transactions from one address are serialized.
+type addressNonce struct {
+	mux    sync.Mutex
+	loaded bool
+	nonce  uint64
+}
+
+type ethCall struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Data string `json:"data"`
+}
+
+var addressVerify = regexp.MustCompile("^[0-9a-f]{40}$")
+
+func (e *EthRPC) Name() string {
+	return "ethrpc"
+}
+
+func (e *EthRPC) Init(ctx context.Context, prefix config.Prefix, callbacks blockchain.Callbacks) (err error) {
+
+	rpcConf := prefix.SubPrefix(EthRPCConfigKey)
+
+	e.ctx = log.WithLogField(ctx, "proto", "ethrpc")
+	e.callbacks = callbacks
+	e.nonces = make(map[string]*addressNonce)
+	e.pendingTXs = []string{}
+
+	if rpcConf.GetString(restclient.HTTPConfigURL) == "" {
+		return i18n.NewError(ctx, i18n.MsgMissingPluginConfig, "url", "blockchain.ethrpc")
+	}
+	defaultLedger := &ethrpcLedger{
+		name:     "default",
+		contract: rpcConf.GetString(EthRPCConfigContract),
+	}
+	if defaultLedger.contract == "" {
+		return i18n.NewError(ctx, i18n.MsgMissingPluginConfig, EthRPCConfigContract, "blockchain.ethrpc")
+	}
+	if defaultLedger.contract, err = e.validateEthAddress(ctx, defaultLedger.contract); err != nil {
+		return err
+	}
+	e.ledgers = []*ethrpcLedger{defaultLedger}
+	for i, ledgerConf := range rpcConf.GetObjectArray(EthRPCConfigLedgers) {
+		l, err := e.parseLedgerConfig(ctx, i, ledgerConf)
+		if err != nil {
+			return err
+		}
+		e.ledgers = append(e.ledgers, l)
+	}
+
+	keystorePath := rpcConf.GetString(EthRPCConfigKeystorePath)
+	if keystorePath == "" {
+		return i18n.NewError(ctx, i18n.MsgMissingPluginConfig, EthRPCConfigKeystorePath, "blockchain.ethrpc")
+	}
+	passwordFile := rpcConf.GetString(EthRPCConfigKeystorePasswordFile)
+	if passwordFile == "" {
+		return i18n.NewError(ctx, i18n.MsgMissingPluginConfig, EthRPCConfigKeystorePasswordFile, "blockchain.ethrpc")
+	}
+	if e.keystore, err = newKeystore(ctx, keystorePath, passwordFile); err != nil {
+		return err
+	}
+
+	e.gasMultiplier = rpcConf.GetFloat64(EthRPCConfigGasMultiplier)
+	e.events = eventListener{
+		closed:          make(chan struct{}),
+		checkpointFile:  rpcConf.GetString(EthRPCConfigEventsCheckpointFile),
+		pollingInterval: rpcConf.GetDuration(EthRPCConfigEventsPollingInterval),
+		blockRange:      uint64(rpcConf.GetUint(EthRPCConfigEventsBlockRange)),
+		confirmations:   uint64(rpcConf.GetUint(EthRPCConfigEventsConfirmations)),
+		retry: retry.Retry{
+			InitialDelay: rpcConf.GetDuration(EthRPCConfigEventsRetryInitDelay),
+			MaximumDelay: rpcConf.GetDuration(EthRPCConfigEventsRetryMaxDelay),
+			Factor:       rpcConf.GetFloat64(EthRPCConfigEventsRetryFactor),
+		},
+	}
+	if e.events.checkpointFile == "" {
+		return i18n.NewError(ctx, i18n.MsgMissingPluginConfig, EthRPCConfigEventsCheckpointFile, "blockchain.ethrpc")
+	}
+	if e.events.blockRange == 0 {
+		e.events.blockRange = defaultBlockRange
+	}
+	if e.events.pollingInterval <= 0 {
+		e.events.pollingInterval = time.Second
+	}
+
+	e.client = restclient.New(e.ctx, rpcConf)
+	e.capabilities = &blockchain.Capabilities{
+		GlobalSequencer: true,
+	}
+
+	if e.chainID, err = e.invokeRPCQuantity(e.ctx, "eth_chainId"); err != nil {
+		return err
+	}
+	log.L(e.ctx).Infof("Connected to chain %s", e.chainID)
+
+	return e.initCheckpoint(e.ctx, rpcConf.GetString(EthRPCConfigEventsFromBlock))
+}
+
+func (e *EthRPC) parseLedgerConfig(ctx context.Context, i int, ledgerConf fftypes.JSONObject) (*ethrpcLedger, error) {
+	confKey := fmt.Sprintf("blockchain.ethrpc.%s[%d]", EthRPCConfigLedgers, i)
+	l := &ethrpcLedger{
+		name:     ledgerConf.GetString(EthRPCLedgerConfigName),
+		contract: ledgerConf.GetString(EthRPCConfigContract),
+	}
+	idStr := ledgerConf.GetString(EthRPCLedgerConfigID)
+	switch {
+	case idStr == "":
+		return nil, i18n.NewError(ctx, i18n.MsgMissingPluginConfig, EthRPCLedgerConfigID, confKey)
+	case l.name == "":
+		return nil, i18n.NewError(ctx, i18n.MsgMissingPluginConfig, EthRPCLedgerConfigName, confKey)
+	case l.contract == "":
+		return nil, i18n.NewError(ctx, i18n.MsgMissingPluginConfig, EthRPCConfigContract, confKey)
+	}
+	id, err := fftypes.ParseUUID(ctx, idStr)
+	if err != nil {
+		return nil, err
+	}
+	if l.contract, err = e.validateEthAddress(ctx, l.contract); err != nil {
+		return nil, err
+	}
+	for _, existing := range e.ledgers {
+		if id.Equals(existing.id) || l.contract == existing.contract {
+			return nil, i18n.NewError(ctx, i18n.MsgDuplicateLedger, l.name)
+		}
+	}
+	l.id = id
+	return l, nil
+}
+
+func (e *EthRPC) getLedger(ctx context.Context, ledgerID *fftypes.UUID) (*ethrpcLedger, error) {
+	for _, l := range e.ledgers {
+		if ledgerID.Equals(l.id) {
+			return l, nil
+		}
+	}
+	return nil, i18n.NewError(ctx, i18n.MsgUnknownLedger, ledgerID)
+}
+
+func (e *EthRPC) Start() error {
+	go e.eventLoop()
+	return nil
+}
+
+// TrackPendingBatchPins resumes polling for the receipts of transactions submitted before a restart,
+// as we only hold the list of pending transactions in memory
+func (e *EthRPC) TrackPendingBatchPins(ctx context.Context, txTrackingIDs []string) {
+	for _, txHash := range txTrackingIDs {
+		log.L(ctx).Infof("Resuming tracking of pending transaction %s", txHash)
+		e.addPendingTX(txHash)
+	}
+}
+
+func (e *EthRPC) Capabilities() *blockchain.Capabilities {
+	return e.capabilities
+}
+
+func (e *EthRPC) Status() fftypes.JSONObject {
+	ledgers := make([]interface{}, len(e.ledgers))
+	for i, l := range e.ledgers {
+		ledgers[i] = map[string]interface{}{
+			"id":       l.id,
+			"name":     l.name,
+			"contract": l.contract,
+		}
+	}
+	e.events.mux.Lock()
+	nextBlock := e.events.nextBlock
+	e.events.mux.Unlock()
+	return fftypes.JSONObject{
+		"chainId":             e.chainID.String(),
+		"nextBlock":           nextBlock,
+		"pendingTransactions": len(e.getPendingTXs()),
+		"ledgers":             ledgers,
+	}
+}
+
+func (e *EthRPC) VerifyIdentitySyntax(ctx context.Context, identity *fftypes.Identity) (err error) {
+	identity.OnChain, err = e.validateEthAddress(ctx, identity.OnChain)
+	return
+}
+
+func (e *EthRPC) validateEthAddress(ctx context.Context, identity string) (string, error) {
+	identity = strings.TrimPrefix(strings.ToLower(identity), "0x")
+	if !addressVerify.MatchString(identity) {
+		return "", i18n.NewError(ctx, i18n.MsgInvalidEthAddress)
+	}
+	return "0x" + identity, nil
+}
+
+func (e *EthRPC) getAddressNonce(address string) *addressNonce {
+	e.nonceMux.Lock()
+	defer e.nonceMux.Unlock()
+	n, ok := e.nonces[address]
+	if !ok {
+		n = &addressNonce{}
+		e.nonces[address] = n
+	}
+	return n
+}
+
+// sendTransaction assigns the next nonce for the address, then signs and submits the transaction
+func (e *EthRPC) sendTransaction(ctx context.Context, address string, key *btcec.PrivateKey, tx *ethTransaction) (string, error) {
+	n := e.getAddressNonce(address)
+	n.mux.Lock()
+	defer n.mux.Unlock()
+
+	if !n.loaded {
+		nonce, err := e.invokeRPCQuantity(ctx, "eth_getTransactionCount", address, "pending")
+		if err != nil {
+			return "", err
+		}
+		n.nonce = nonce.Uint64()
+		n.loaded = true
+	}
+
+	tx.Nonce = n.nonce
+	raw, hash, err := tx.sign(key, e.chainID)
+	if err != nil {
+		return "", err
+	}
+	txHash := "0x" + hex.EncodeToString(hash)
+	log.L(ctx).Infof("Submitting transaction %s from %s with nonce %d", txHash, address, tx.Nonce)
+	if err = e.invokeRPC(ctx, nil, "eth_sendRawTransaction", "0x"+hex.EncodeToString(raw)); err != nil {
+		// We cannot be sure whether the node consumed the nonce, so query it again for the next transaction
+		n.loaded = false
+		return "", err
+	}
+	n.nonce++
+	return txHash, nil
+}
+
+func (e *EthRPC) SubmitBatchPin(ctx context.Context, ledgerID *fftypes.UUID, identity *fftypes.Identity, batch *blockchain.BatchPin) (txTrackingID string, err error) {
+	l, err := e.getLedger(ctx, ledgerID)
+	if err != nil {
+		return "", err
+	}
+	key, err := e.keystore.getKey(ctx, identity.OnChain)
+	if err != nil {
+		return "", err
+	}
+	to, _ := hex.DecodeString(strings.TrimPrefix(l.contract, "0x"))
+	tx := &ethTransaction{
+		To:   to,
+		Data: encodePinBatch(batch),
+	}
+	if tx.GasPrice, err = e.invokeRPCQuantity(ctx, "eth_gasPrice"); err != nil {
+		return "", err
+	}
+	gasEstimate, err := e.invokeRPCQuantity(ctx, "eth_estimateGas", &ethCall{
+		From: identity.OnChain,
+		To:   l.contract,
+		Data: "0x" + hex.EncodeToString(tx.Data),
+	})
+	if err != nil {
+		return "", err
+	}
+	tx.GasLimit = uint64(float64(gasEstimate.Uint64()) * e.gasMultiplier)
+
+	txHash, err := e.sendTransaction(ctx, identity.OnChain, key, tx)
+	if err != nil {
+		return "", err
+	}
+	e.addPendingTX(txHash)
+	return txHash, nil
+}
@@ -0,0 +1,440 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ethrpc
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/hyperledger-labs/firefly/internal/config"
+	"github.com/hyperledger-labs/firefly/internal/restclient"
+	"github.com/hyperledger-labs/firefly/mocks/blockchainmocks"
+	"github.com/hyperledger-labs/firefly/pkg/fftypes"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+const testRPCURL = "http://localhost:12345"
+
+const testContract = "0x2ca0a3bb4f5d2ef0e2a8a5ec6a7c2f8d6c8bb8a1"
+
+var utConfPrefix = config.NewPluginConfig("ethrpc_unit_tests")
+var utEthRPCConf = utConfPrefix.SubPrefix(EthRPCConfigKey)
+
+type rpcHandler func(params []interface{}) (interface{}, *rpcError)
+
+// rpcStub is a minimal JSON-RPC server, with a handler for each method
+type rpcStub struct {
+	mux      sync.Mutex
+	handlers map[string]rpcHandler
+	calls    map[string]int
+}
+
+func newRPCStub() *rpcStub {
+	s := &rpcStub{
+		handlers: make(map[string]rpcHandler),
+		calls:    make(map[string]int),
+	}
+	s.result("eth_chainId", "0x7e5")
+	return s
+}
+
+func (s *rpcStub) handle(method string, handler rpcHandler) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	s.handlers[method] = handler
+}
+
+func (s *rpcStub) result(method string, result interface{}) {
+	s.handle(method, func(params []interface{}) (interface{}, *rpcError) {
+		return result, nil
+	})
+}
+
+func (s *rpcStub) fail(method string) {
+	s.handle(method, func(params []interface{}) (interface{}, *rpcError) {
+		return nil, &rpcError{Code: -32000, Message: "pop"}
+	})
+}
+
+func (s *rpcStub) callCount(method string) int {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return s.calls[method]
+}
+
+func (s *rpcStub) respond(req *http.Request) (*http.Response, error) {
+	var rpcReq rpcRequest
+	if err := json.NewDecoder(req.Body).Decode(&rpcReq); err != nil {
+		return httpmock.NewStringResponse(400, err.Error()), nil
+	}
+	s.mux.Lock()
+	s.calls[rpcReq.Method]++
+	handler, ok := s.handlers[rpcReq.Method]
+	s.mux.Unlock()
+	res := map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      rpcReq.ID,
+	}
+	if !ok {
+		res["error"] = &rpcError{Code: -32601, Message: "method not found"}
+	} else if result, rpcErr := handler(rpcReq.Params); rpcErr != nil {
+		res["error"] = rpcErr
+	} else {
+		res["result"] = result
+	}
+	return httpmock.NewJsonResponse(200, res)
+}
+
+func resetConf() {
+	config.Reset()
+	e := &EthRPC{}
+	e.InitPrefix(utConfPrefix)
+}
+
+// setTestConf configures a valid plugin, against the stub and a temporary keystore and checkpoint directory
+func setTestConf(t *testing.T, stub *rpcStub) string {
+	resetConf()
+	mockedClient := &http.Client{}
+	httpmock.ActivateNonDefault(mockedClient)
+	t.Cleanup(httpmock.DeactivateAndReset)
+	httpmock.RegisterResponder("POST", testRPCURL, stub.respond)
+
+	keysDir, passwordFile := newTestKeystoreDir(t, "testpassword", map[string]string{
+		"key1.json": testKeyFilePBKDF2,
+	})
+	utEthRPCConf.Set(restclient.HTTPConfigURL, testRPCURL)
+	utEthRPCConf.Set(restclient.HTTPCustomClient, mockedClient)
+	utEthRPCConf.Set(restclient.HTTPConfigRetryEnabled, false)
+	utEthRPCConf.Set(EthRPCConfigContract, testContract)
+	utEthRPCConf.Set(EthRPCConfigKeystorePath, keysDir)
+	utEthRPCConf.Set(EthRPCConfigKeystorePasswordFile, passwordFile)
+	utEthRPCConf.Set(EthRPCConfigEventsCheckpointFile, filepath.Join(filepath.Dir(keysDir), "checkpoint.json"))
+	utEthRPCConf.Set(EthRPCConfigEventsPollingInterval, "1ms")
+	utEthRPCConf.Set(EthRPCConfigEventsRetryInitDelay, "1ms")
+	utEthRPCConf.Set(EthRPCConfigEventsRetryMaxDelay, "1ms")
+	return filepath.Dir(keysDir)
+}
+
+func newTestEthRPC(t *testing.T, stub *rpcStub) (*EthRPC, *blockchainmocks.Callbacks, context.CancelFunc) {
+	setTestConf(t, stub)
+	ctx, cancel := context.WithCancel(context.Background())
+	e := &EthRPC{}
+	mcb := &blockchainmocks.Callbacks{}
+	err := e.Init(ctx, utConfPrefix, mcb)
+	assert.NoError(t, err)
+	return e, mcb, cancel
+}
+
+func TestInitMissingURL(t *testing.T) {
+	e := &EthRPC{}
+	resetConf()
+	err := e.Init(context.Background(), utConfPrefix, &blockchainmocks.Callbacks{})
+	assert.Regexp(t, "FF10138.*url", err)
+}
+
+func TestInitMissingContract(t *testing.T) {
+	e := &EthRPC{}
+	setTestConf(t, newRPCStub())
+	utEthRPCConf.Set(EthRPCConfigContract, "")
+	err := e.Init(context.Background(), utConfPrefix, &blockchainmocks.Callbacks{})
+	assert.Regexp(t, "FF10138.*contract", err)
+}
+
+func TestInitBadContract(t *testing.T) {
+	e := &EthRPC{}
+	setTestConf(t, newRPCStub())
+	utEthRPCConf.Set(EthRPCConfigContract, "0x12345")
+	err := e.Init(context.Background(), utConfPrefix, &blockchainmocks.Callbacks{})
+	assert.Regexp(t, "FF10141", err)
+}
+
+func TestInitMissingKeystorePath(t *testing.T) {
+	e := &EthRPC{}
+	setTestConf(t, newRPCStub())
+	utEthRPCConf.Set(EthRPCConfigKeystorePath, "")
+	err := e.Init(context.Background(), utConfPrefix, &blockchainmocks.Callbacks{})
+	assert.Regexp(t, "FF10138.*keystore.path", err)
+}
+
+func TestInitMissingKeystorePasswordFile(t *testing.T) {
+	e := &EthRPC{}
+	setTestConf(t, newRPCStub())
+	utEthRPCConf.Set(EthRPCConfigKeystorePasswordFile, "")
+	err := e.Init(context.Background(), utConfPrefix, &blockchainmocks.Callbacks{})
+	assert.Regexp(t, "FF10138.*keystore.passwordFile", err)
+}
+
+func TestInitBadKeystore(t *testing.T) {
+	e := &EthRPC{}
+	dir := setTestConf(t, newRPCStub())
+	utEthRPCConf.Set(EthRPCConfigKeystorePath, filepath.Join(dir, "missing"))
+	err := e.Init(context.Background(), utConfPrefix, &blockchainmocks.Callbacks{})
+	assert.Regexp(t, "FF10266", err)
+}
+
+func TestInitMissingCheckpointFile(t *testing.T) {
+	e := &EthRPC{}
+	setTestConf(t, newRPCStub())
+	utEthRPCConf.Set(EthRPCConfigEventsCheckpointFile, "")
+	err := e.Init(context.Background(), utConfPrefix, &blockchainmocks.Callbacks{})
+	assert.Regexp(t, "FF10138.*events.checkpointFile", err)
+}
+
+func TestInitChainIDFail(t *testing.T) {
+	e := &EthRPC{}
+	stub := newRPCStub()
+	stub.fail("eth_chainId")
+	setTestConf(t, stub)
+	err := e.Init(context.Background(), utConfPrefix, &blockchainmocks.Callbacks{})
+	assert.Regexp(t, "FF10264.*eth_chainId.*pop", err)
+}
+
+func TestInitOK(t *testing.T) {
+	e, _, cancel := newTestEthRPC(t, newRPCStub())
+	defer cancel()
+	assert.Equal(t, "ethrpc", e.Name())
+	assert.True(t, e.Capabilities().GlobalSequencer)
+	assert.Equal(t, int64(2021), e.chainID.Int64())
+	assert.Equal(t, 1.5, e.gasMultiplier)
+	assert.Equal(t, uint64(500), e.events.blockRange)
+	assert.Equal(t, uint64(0), e.events.confirmations)
+	assert.Equal(t, uint64(0), e.events.nextBlock)
+	assert.Len(t, e.ledgers, 1)
+	assert.Nil(t, e.ledgers[0].id)
+	assert.Equal(t, testContract, e.ledgers[0].contract)
+}
+
+func TestInitDefaultsForInvalidEventConfig(t *testing.T) {
+	e := &EthRPC{}
+	setTestConf(t, newRPCStub())
+	utEthRPCConf.Set(EthRPCConfigEventsBlockRange, 0)
+	utEthRPCConf.Set(EthRPCConfigEventsPollingInterval, "0")
+	err := e.Init(context.Background(), utConfPrefix, &blockchainmocks.Callbacks{})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(500), e.events.blockRange)
+	assert.Equal(t, "1s", e.events.pollingInterval.String())
+}
+
+func TestInitMultipleLedgers(t *testing.T) {
+	e := &EthRPC{}
+	setTestConf(t, newRPCStub())
+	utEthRPCConf.Set(EthRPCConfigLedgers, []interface{}{
+		map[string]interface{}{
+			"id":       "0b4e5b4a-0d6f-4f0c-9e1c-4f1d33e4c4d4",
+			"name":     "ledger2",
+			"contract": "0x2CA0A3BB4F5D2EF0E2A8A5EC6A7C2F8D6C8BB8A2",
+		},
+	})
+	err := e.Init(context.Background(), utConfPrefix, &blockchainmocks.Callbacks{})
+	assert.NoError(t, err)
+	assert.Len(t, e.ledgers, 2)
+	assert.Equal(t, "ledger2", e.ledgers[1].name)
+	assert.Equal(t, "0x2ca0a3bb4f5d2ef0e2a8a5ec6a7c2f8d6c8bb8a2", e.ledgers[1].contract)
+
+	l, err := e.getLedger(context.Background(), fftypes.MustParseUUID("0b4e5b4a-0d6f-4f0c-9e1c-4f1d33e4c4d4"))
+	assert.NoError(t, err)
+	assert.Equal(t, e.ledgers[1], l)
+	_, err = e.getLedger(context.Background(), fftypes.NewUUID())
+	assert.Regexp(t, "FF10261", err)
+}
+
+func TestInitLedgerConfigErrors(t *testing.T) {
+	testCases := []struct {
+		name   string
+		ledger map[string]interface{}
+		errStr string
+	}{
+		{"missingID", map[string]interface{}{"name": "l2", "contract": "0x2ca0a3bb4f5d2ef0e2a8a5ec6a7c2f8d6c8bb8a2"}, "FF10138.*id.*ledgers\\[0\\]"},
+		{"missingName", map[string]interface{}{"id": "0b4e5b4a-0d6f-4f0c-9e1c-4f1d33e4c4d4", "contract": "0x2ca0a3bb4f5d2ef0e2a8a5ec6a7c2f8d6c8bb8a2"}, "FF10138.*name"},
+		{"missingContract", map[string]interface{}{"id": "0b4e5b4a-0d6f-4f0c-9e1c-4f1d33e4c4d4", "name": "l2"}, "FF10138.*contract"},
+		{"badID", map[string]interface{}{"id": "bad", "name": "l2", "contract": "0x2ca0a3bb4f5d2ef0e2a8a5ec6a7c2f8d6c8bb8a2"}, "FF10142"},
+		{"badContract", map[string]interface{}{"id": "0b4e5b4a-0d6f-4f0c-9e1c-4f1d33e4c4d4", "name": "l2", "contract": "0x12345"}, "FF10141"},
+		{"duplicateContract", map[string]interface{}{"id": "0b4e5b4a-0d6f-4f0c-9e1c-4f1d33e4c4d4", "name": "l2", "contract": testContract}, "FF10262.*l2"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := &EthRPC{}
+			setTestConf(t, newRPCStub())
+			utEthRPCConf.Set(EthRPCConfigLedgers, []interface{}{tc.ledger})
+			err := e.Init(context.Background(), utConfPrefix, &blockchainmocks.Callbacks{})
+			assert.Regexp(t, tc.errStr, err)
+		})
+	}
+}
+
+func TestVerifyIdentitySyntaxOK(t *testing.T) {
+	e := &EthRPC{}
+	id := &fftypes.Identity{OnChain: "0x2a7c9D5248681CE6c393117E641aD037F5C079F6"}
+	err := e.VerifyIdentitySyntax(context.Background(), id)
+	assert.NoError(t, err)
+	assert.Equal(t, "0x2a7c9d5248681ce6c393117e641ad037f5c079f6", id.OnChain)
+}
+
+func TestVerifyIdentitySyntaxFail(t *testing.T) {
+	e := &EthRPC{}
+	id := &fftypes.Identity{OnChain: "0x2a7c9D5248681CE6c393117E641aD037F5C079F"}
+	err := e.VerifyIdentitySyntax(context.Background(), id)
+	assert.Regexp(t, "FF10141", err)
+}
+
+func stubSubmission(stub *rpcStub, sent *[]string) {
+	stub.result("eth_gasPrice", "0x3b9aca00")
+	stub.handle("eth_estimateGas", func(params []interface{}) (interface{}, *rpcError) {
+		call := params[0].(map[string]interface{})
+		if call["from"] != testKeyAddress || call["to"] != testContract || !strings.HasPrefix(call["data"].(string), "0x"+hex.EncodeToString(pinBatchMethodID)) {
+			return nil, &rpcError{Code: -32000, Message: fmt.Sprintf("unexpected call %+v", call)}
+		}
+		return "0x1000", nil
+	})
+	stub.result("eth_getTransactionCount", "0x5")
+	stub.handle("eth_sendRawTransaction", func(params []interface{}) (interface{}, *rpcError) {
+		raw, _ := hex.DecodeString(strings.TrimPrefix(params[0].(string), "0x"))
+		*sent = append(*sent, params[0].(string))
+		return fmt.Sprintf("0x%x", keccak256(raw)), nil
+	})
+}
+
+func TestSubmitBatchPinOK(t *testing.T) {
+	stub := newRPCStub()
+	var sent []string
+	stubSubmission(stub, &sent)
+	e, _, cancel := newTestEthRPC(t, stub)
+	defer cancel()
+
+	id := &fftypes.Identity{OnChain: testKeyAddress}
+	txHash1, err := e.SubmitBatchPin(context.Background(), nil, id, testBatchPin())
+	assert.NoError(t, err)
+	txHash2, err := e.SubmitBatchPin(context.Background(), nil, id, testBatchPin())
+	assert.NoError(t, err)
+
+	assert.Len(t, sent, 2)
+	raw1, _ := hex.DecodeString(sent[0][2:])
+	assert.Equal(t, fmt.Sprintf("0x%x", keccak256(raw1)), txHash1)
+	assert.NotEqual(t, txHash1, txHash2)
+	// The nonce is queried once, then incremented locally
+	assert.Equal(t, 1, stub.callCount("eth_getTransactionCount"))
+	assert.Equal(t, uint64(7), e.nonces[testKeyAddress].nonce)
+	// Gas limit is the estimate with the multiplier applied, and the nonce is the first field
+	assert.Contains(t, sent[0], "05843b9aca00821800")
+	assert.Contains(t, sent[1], "06843b9aca00821800")
+	assert.Equal(t, []string{txHash1, txHash2}, e.getPendingTXs())
+}
+
+func TestSubmitBatchPinUnknownLedger(t *testing.T) {
+	e, _, cancel := newTestEthRPC(t, newRPCStub())
+	defer cancel()
+	_, err := e.SubmitBatchPin(context.Background(), fftypes.NewUUID(), &fftypes.Identity{OnChain: testKeyAddress}, testBatchPin())
+	assert.Regexp(t, "FF10261", err)
+}
+
+func TestSubmitBatchPinUnknownKey(t *testing.T) {
+	e, _, cancel := newTestEthRPC(t, newRPCStub())
+	defer cancel()
+	_, err := e.SubmitBatchPin(context.Background(), nil, &fftypes.Identity{OnChain: testContract}, testBatchPin())
+	assert.Regexp(t, "FF10268", err)
+}
+
+func TestSubmitBatchPinGasPriceFail(t *testing.T) {
+	stub := newRPCStub()
+	var sent []string
+	stubSubmission(stub, &sent)
+	stub.fail("eth_gasPrice")
+	e, _, cancel := newTestEthRPC(t, stub)
+	defer cancel()
+	_, err := e.SubmitBatchPin(context.Background(), nil, &fftypes.Identity{OnChain: testKeyAddress}, testBatchPin())
+	assert.Regexp(t, "FF10264.*eth_gasPrice", err)
+}
+
+func TestSubmitBatchPinEstimateGasFail(t *testing.T) {
+	stub := newRPCStub()
+	var sent []string
+	stubSubmission(stub, &sent)
+	stub.fail("eth_estimateGas")
+	e, _, cancel := newTestEthRPC(t, stub)
+	defer cancel()
+	_, err := e.SubmitBatchPin(context.Background(), nil, &fftypes.Identity{OnChain: testKeyAddress}, testBatchPin())
+	assert.Regexp(t, "FF10264.*eth_estimateGas", err)
+}
+
+func TestSubmitBatchPinNonceFail(t *testing.T) {
+	stub := newRPCStub()
+	var sent []string
+	stubSubmission(stub, &sent)
+	stub.fail("eth_getTransactionCount")
+	e, _, cancel := newTestEthRPC(t, stub)
+	defer cancel()
+	_, err := e.SubmitBatchPin(context.Background(), nil, &fftypes.Identity{OnChain: testKeyAddress}, testBatchPin())
+	assert.Regexp(t, "FF10264.*eth_getTransactionCount", err)
+}
+
+func TestSubmitBatchPinSendFailReloadsNonce(t *testing.T) {
+	stub := newRPCStub()
+	var sent []string
+	stubSubmission(stub, &sent)
+	stub.fail("eth_sendRawTransaction")
+	e, _, cancel := newTestEthRPC(t, stub)
+	defer cancel()
+	id := &fftypes.Identity{OnChain: testKeyAddress}
+	_, err := e.SubmitBatchPin(context.Background(), nil, id, testBatchPin())
+	assert.Regexp(t, "FF10264.*eth_sendRawTransaction", err)
+	assert.False(t, e.nonces[testKeyAddress].loaded)
+
+	stubSubmission(stub, &sent)
+	_, err = e.SubmitBatchPin(context.Background(), nil, id, testBatchPin())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, stub.callCount("eth_getTransactionCount"))
+	assert.Len(t, e.getPendingTXs(), 1)
+}
+
+func TestStartStop(t *testing.T) {
+	stub := newRPCStub()
+	stub.result("eth_blockNumber", "0x0")
+	stub.result("eth_getLogs", []interface{}{})
+	e, _, cancel := newTestEthRPC(t, stub)
+	err := e.Start()
+	assert.NoError(t, err)
+	cancel()
+	<-e.events.closed
+}
+
+func TestTrackPendingBatchPins(t *testing.T) {
+	e, _, cancel := newTestEthRPC(t, newRPCStub())
+	defer cancel()
+	e.addPendingTX("0x111")
+	e.TrackPendingBatchPins(context.Background(), []string{"0x111", "0x222"})
+	assert.Equal(t, []string{"0x111", "0x222"}, e.getPendingTXs())
+}
+
+func TestStatus(t *testing.T) {
+	e, _, cancel := newTestEthRPC(t, newRPCStub())
+	defer cancel()
+	e.events.nextBlock = 39
+	e.addPendingTX("0x111")
+	status := e.Status()
+	assert.Equal(t, "2021", status.GetString("chainId"))
+	assert.Equal(t, uint64(39), status["nextBlock"])
+	assert.Equal(t, 1, status["pendingTransactions"])
+	assert.Equal(t, testContract, status["ledgers"].([]interface{})[0].(map[string]interface{})["contract"])
+}
@@ -0,0 +1,344 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ethrpc
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hyperledger-labs/firefly/internal/i18n"
+	"github.com/hyperledger-labs/firefly/internal/log"
+	"github.com/hyperledger-labs/firefly/internal/retry"
+	"github.com/hyperledger-labs/firefly/pkg/blockchain"
+	"github.com/hyperledger-labs/firefly/pkg/fftypes"
+)
+
+type eventListener struct {
+	mux             sync.Mutex
+	closed          chan struct{}
+	checkpointFile  string
+	pollingInterval time.Duration
+	blockRange      uint64
+	confirmations   uint64
+	retry           retry.Retry
+	nextBlock       uint64
+}
+
+type blockCheckpoint struct {
+	NextBlock uint64 `json:"nextBlock"`
+}
+
+type ethLog struct {
+	Address          string   `json:"address"`
+	Topics           []string `json:"topics"`
+	Data             string   `json:"data"`
+	BlockNumber      string   `json:"blockNumber"`
+	BlockHash        string   `json:"blockHash"`
+	TransactionHash  string   `json:"transactionHash"`
+	TransactionIndex string   `json:"transactionIndex"`
+	LogIndex         string   `json:"logIndex"`
+	Removed          bool     `json:"removed"`
+}
+
+type logFilter struct {
+	FromBlock string     `json:"fromBlock"`
+	ToBlock   string     `json:"toBlock"`
+	Address   []string   `json:"address"`
+	Topics    [][]string `json:"topics"`
+}
+
+// initCheckpoint sets the first block to process, from the checkpoint file if one has been written,
+// or from the configured starting block (which can be "latest") on first startup
+func (e *EthRPC) initCheckpoint(ctx context.Context, fromBlock string) error {
+	b, err := ioutil.ReadFile(e.events.checkpointFile)
+	if err == nil {
+		var cp blockCheckpoint
+		if err = json.Unmarshal(b, &cp); err != nil {
+			return i18n.WrapError(ctx, err, i18n.MsgEthCheckpointInvalid, e.events.checkpointFile)
+		}
+		e.events.nextBlock = cp.NextBlock
+		log.L(ctx).Infof("Resuming event listener from checkpoint block %d", e.events.nextBlock)
+		return nil
+	}
+	if fromBlock == "latest" {
+		head, err := e.invokeRPCQuantity(ctx, "eth_blockNumber")
+		if err != nil {
+			return err
+		}
+		e.events.nextBlock = head.Uint64()
+	} else if e.events.nextBlock, err = strconv.ParseUint(fromBlock, 10, 64); err != nil {
+		return i18n.WrapError(ctx, err, i18n.MsgEthCheckpointInvalid, fromBlock)
+	}
+	log.L(ctx).Infof("Starting event listener from block %d", e.events.nextBlock)
+	return nil
+}
+
+func (e *EthRPC) writeCheckpoint(ctx context.Context) error {
+	b, _ := json.Marshal(&blockCheckpoint{NextBlock: e.events.nextBlock})
+	// Write then rename, so a crash cannot leave a partial checkpoint behind
+	tmpFile := e.events.checkpointFile + ".tmp"
+	err := ioutil.WriteFile(tmpFile, b, 0600)
+	if err == nil {
+		err = os.Rename(tmpFile, e.events.checkpointFile)
+	}
+	if err != nil {
+		return i18n.WrapError(ctx, err, i18n.MsgEthCheckpointWriteFailed, e.events.checkpointFile)
+	}
+	return nil
+}
+
+func (e *EthRPC) addPendingTX(txHash string) {
+	e.pendingMux.Lock()
+	defer e.pendingMux.Unlock()
+	for _, h := range e.pendingTXs {
+		if h == txHash {
+			return
+		}
+	}
+	e.pendingTXs = append(e.pendingTXs, txHash)
+}
+
+func (e *EthRPC) getPendingTXs() []string {
+	e.pendingMux.Lock()
+	defer e.pendingMux.Unlock()
+	return append([]string{}, e.pendingTXs...)
+}
+
+func (e *EthRPC) removePendingTX(txHash string) {
+	e.pendingMux.Lock()
+	defer e.pendingMux.Unlock()
+	for i, h := range e.pendingTXs {
+		if h == txHash {
+			e.pendingTXs = append(e.pendingTXs[:i], e.pendingTXs[i+1:]...)
+			return
+		}
+	}
+}
+
+// getLogs queries the next range of blocks, up to the last block with the configured number of confirmations
+func (e *EthRPC) getLogs(ctx context.Context) (logs []*ethLog, toBlock uint64, caughtUp bool, err error) {
+	head, err := e.invokeRPCQuantity(ctx, "eth_blockNumber")
+	if err != nil {
+		return nil, 0, false, err
+	}
+	fromBlock := e.events.nextBlock
+	if head.Uint64() < fromBlock+e.events.confirmations {
+		return nil, 0, true, nil
+	}
+	confirmedHead := head.Uint64() - e.events.confirmations
+	toBlock = fromBlock + e.events.blockRange - 1
+	if toBlock >= confirmedHead {
+		toBlock = confirmedHead
+		caughtUp = true
+	}
+	filter := &logFilter{
+		FromBlock: hexQuantity(fromBlock),
+		ToBlock:   hexQuantity(toBlock),
+		Address:   make([]string, len(e.ledgers)),
+		Topics:    [][]string{{batchPinEventTopic0}},
+	}
+	for i, l := range e.ledgers {
+		filter.Address[i] = l.contract
+	}
+	err = e.invokeRPC(ctx, &logs, "eth_getLogs", filter)
+	return logs, toBlock, caughtUp, err
+}
+
+func hexToDecimal(s string) (string, bool) {
+	i, ok := parseHexQuantity(s)
+	if !ok {
+		return "", false
+	}
+	return i.String(), true
+}
+
+func (e *EthRPC) handleBatchPinLog(ctx context.Context, ledger *ethrpcLedger, ethLog *ethLog) error {
+	sBlockNumber, ok1 := hexToDecimal(ethLog.BlockNumber)
+	sTransactionIndex, ok2 := hexToDecimal(ethLog.TransactionIndex)
+	sLogIndex, ok3 := hexToDecimal(ethLog.LogIndex)
+	if !ok1 || !ok2 || !ok3 || ethLog.TransactionHash == "" {
+		log.L(ctx).Errorf("BatchPin event is not valid - missing data: %+v", ethLog)
+		return nil // move on
+	}
+	data, err := hex.DecodeString(strings.TrimPrefix(ethLog.Data, "0x"))
+	if err == nil {
+		var event *batchPinEvent
+		if event, err = decodeBatchPinEvent(data); err == nil {
+			return e.dispatchBatchPin(ctx, ledger, ethLog, event, fftypes.JSONObject{
+				"address":          ethLog.Address,
+				"blockNumber":      sBlockNumber,
+				"blockHash":        ethLog.BlockHash,
+				"transactionIndex": sTransactionIndex,
+				"transactionHash":  ethLog.TransactionHash,
+				"logIndex":         sLogIndex,
+				"signature":        broadcastBatchEventSignature,
+			})
+		}
+	}
+	log.L(ctx).Errorf("BatchPin event is not valid - bad data (%s): %+v", err, ethLog)
+	return nil // move on
+}
+
+func (e *EthRPC) dispatchBatchPin(ctx context.Context, ledger *ethrpcLedger, ethLog *ethLog, event *batchPinEvent, info fftypes.JSONObject) error {
+	var txnID fftypes.UUID
+	copy(txnID[:], event.UUIDs[0:16])
+	var batchID fftypes.UUID
+	copy(batchID[:], event.UUIDs[16:32])
+	payloadRefOrNil := &event.PayloadRef
+	if *payloadRefOrNil == zeroBytes32 {
+		payloadRefOrNil = nil
+	}
+	// The info is stored in the same form as if it had been parsed from JSON
+	contexts := make([]interface{}, len(event.Contexts))
+	for i, c := range event.Contexts {
+		contexts[i] = "0x" + c.String()
+	}
+	info["data"] = map[string]interface{}{
+		"author":     event.Author,
+		"timestamp":  event.Timestamp.String(),
+		"namespace":  event.Namespace,
+		"uuids":      "0x" + event.UUIDs.String(),
+		"batchHash":  "0x" + event.BatchHash.String(),
+		"payloadRef": "0x" + event.PayloadRef.String(),
+		"contexts":   contexts,
+	}
+
+	batch := &blockchain.BatchPin{
+		Namespace:      event.Namespace,
+		TransactionID:  &txnID,
+		BatchID:        &batchID,
+		BatchHash:      &event.BatchHash,
+		BatchPaylodRef: payloadRefOrNil,
+		Contexts:       event.Contexts,
+	}
+
+	// If there's an error dispatching the event, we must return the error and shutdown
+	return e.callbacks.BatchPinComplete(ledger.id, batch, event.Author, ethLog.TransactionHash, info)
+}
+
+func (e *EthRPC) handleLogs(ctx context.Context, logs []*ethLog) error {
+	for _, ethLog := range logs {
+		l := log.L(ctx).WithField("tx", ethLog.TransactionHash)
+		ctx1 := log.WithLogger(ctx, l)
+		if ethLog.Removed {
+			l.Infof("Ignoring removed log: %+v", ethLog)
+			continue
+		}
+		if len(ethLog.Topics) == 0 || ethLog.Topics[0] != batchPinEventTopic0 {
+			l.Infof("Ignoring log with unknown topic: %+v", ethLog.Topics)
+			continue
+		}
+		var ledger *ethrpcLedger
+		for _, candidate := range e.ledgers {
+			if strings.EqualFold(candidate.contract, ethLog.Address) {
+				ledger = candidate
+			}
+		}
+		if ledger == nil {
+			l.Infof("Ignoring log from unknown contract: %s", ethLog.Address)
+			continue
+		}
+		l.Infof("Received BatchPin event in block %s on ledger '%s'", ethLog.BlockNumber, ledger.name)
+		if err := e.handleBatchPinLog(ctx1, ledger, ethLog); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkReceipts looks for receipts for each transaction we have submitted, until it is mined in a block
+// the event listener has processed - so receipts have the same confirmations as events, and are not
+// reported before the events in the same block.
+// Failures to query are ignored, as we will check again on the next poll.
+func (e *EthRPC) checkReceipts(ctx context.Context) error {
+	for _, txHash := range e.getPendingTXs() {
+		var receipt fftypes.JSONObject
+		if err := e.invokeRPC(ctx, &receipt, "eth_getTransactionReceipt", txHash); err != nil {
+			log.L(ctx).Warnf("Failed to query receipt for %s: %s", txHash, err)
+			return nil
+		}
+		if receipt == nil {
+			continue // not yet mined
+		}
+		blockNumber, ok := parseHexQuantity(receipt.GetString("blockNumber"))
+		if !ok || blockNumber.Uint64() >= e.events.nextBlock {
+			continue // not yet confirmed
+		}
+		updateType := fftypes.OpStatusSucceeded
+		message := ""
+		if receipt.GetString("status") != "0x1" {
+			updateType = fftypes.OpStatusFailed
+			message = i18n.NewError(ctx, i18n.MsgEthTxReverted).Error()
+		}
+		log.L(ctx).Infof("Receipt for tx=%s status=%s", txHash, receipt.GetString("status"))
+		if err := e.callbacks.TxSubmissionUpdate(txHash, updateType, txHash, message, receipt); err != nil {
+			return err
+		}
+		e.removePendingTX(txHash)
+	}
+	return nil
+}
+
+func (e *EthRPC) eventLoop() {
+	defer close(e.events.closed)
+	l := log.L(e.ctx).WithField("role", "event-loop")
+	ctx := log.WithLogger(e.ctx, l)
+	for {
+		var logs []*ethLog
+		var toBlock uint64
+		var caughtUp bool
+		// Errors talking to the node are retried indefinitely, until we are shut down
+		err := e.events.retry.Do(ctx, "get logs", func(attempt int) (retry bool, err error) {
+			logs, toBlock, caughtUp, err = e.getLogs(ctx)
+			return true, err
+		})
+		if err == nil && len(logs) > 0 {
+			// Errors from the callbacks only happen on shutdown
+			err = e.handleLogs(ctx, logs)
+		}
+		if err == nil && toBlock >= e.events.nextBlock {
+			e.events.mux.Lock()
+			e.events.nextBlock = toBlock + 1
+			e.events.mux.Unlock()
+			err = e.events.retry.Do(ctx, "write checkpoint", func(attempt int) (retry bool, err error) {
+				return true, e.writeCheckpoint(ctx)
+			})
+		}
+		if err == nil {
+			err = e.checkReceipts(ctx)
+		}
+		if err != nil {
+			l.Errorf("Event loop exiting: %s", err)
+			return
+		}
+		if caughtUp {
+			select {
+			case <-ctx.Done():
+				l.Debugf("Event loop exiting (context cancelled)")
+				return
+			case <-time.After(e.events.pollingInterval):
+			}
+		}
+	}
+}
@@ -0,0 +1,393 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ethrpc
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/hyperledger-labs/firefly/mocks/blockchainmocks"
+	"github.com/hyperledger-labs/firefly/pkg/blockchain"
+	"github.com/hyperledger-labs/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const testAuthor = "0x91d2b4381a4cd5c7c0f27565a7d4b829844c8635"
+
+func testBatchPinLog(contract string, batch *blockchain.BatchPin) *ethLog {
+	return &ethLog{
+		Address:          contract,
+		Topics:           []string{batchPinEventTopic0},
+		Data:             encodeTestBatchPinEvent(testAuthor, 1620576488, batch),
+		BlockNumber:      "0x26",
+		BlockHash:        "0x8f7c8d3a3a6f3a8e5fb0f6a5d7d4d2cde4c9e8b7f5a6e4d3c2b1a09f8e7d6c5b",
+		TransactionHash:  "0xc26df2bf1a733e9249372d61eb11bd8662d26c8129df76890b1beb2f6fa72628",
+		TransactionIndex: "0x0",
+		LogIndex:         "0x1",
+	}
+}
+
+func TestInitCheckpointFromFile(t *testing.T) {
+	dir := setTestConf(t, newRPCStub())
+	err := ioutil.WriteFile(filepath.Join(dir, "checkpoint.json"), []byte(`{"nextBlock":12345}`), 0600)
+	assert.NoError(t, err)
+	e := &EthRPC{}
+	err = e.Init(context.Background(), utConfPrefix, &blockchainmocks.Callbacks{})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(12345), e.events.nextBlock)
+}
+
+func TestInitCheckpointBadFile(t *testing.T) {
+	dir := setTestConf(t, newRPCStub())
+	err := ioutil.WriteFile(filepath.Join(dir, "checkpoint.json"), []byte(`!json`), 0600)
+	assert.NoError(t, err)
+	e := &EthRPC{}
+	err = e.Init(context.Background(), utConfPrefix, &blockchainmocks.Callbacks{})
+	assert.Regexp(t, "FF10270.*checkpoint.json", err)
+}
+
+func TestInitCheckpointFromBlockNumber(t *testing.T) {
+	setTestConf(t, newRPCStub())
+	utEthRPCConf.Set(EthRPCConfigEventsFromBlock, "1000")
+	e := &EthRPC{}
+	err := e.Init(context.Background(), utConfPrefix, &blockchainmocks.Callbacks{})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1000), e.events.nextBlock)
+}
+
+func TestInitCheckpointFromBlockBad(t *testing.T) {
+	setTestConf(t, newRPCStub())
+	utEthRPCConf.Set(EthRPCConfigEventsFromBlock, "earliest")
+	e := &EthRPC{}
+	err := e.Init(context.Background(), utConfPrefix, &blockchainmocks.Callbacks{})
+	assert.Regexp(t, "FF10270.*earliest", err)
+}
+
+func TestInitCheckpointFromLatest(t *testing.T) {
+	stub := newRPCStub()
+	stub.result("eth_blockNumber", "0x3e8")
+	setTestConf(t, stub)
+	utEthRPCConf.Set(EthRPCConfigEventsFromBlock, "latest")
+	e := &EthRPC{}
+	err := e.Init(context.Background(), utConfPrefix, &blockchainmocks.Callbacks{})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1000), e.events.nextBlock)
+}
+
+func TestInitCheckpointFromLatestFail(t *testing.T) {
+	stub := newRPCStub()
+	stub.fail("eth_blockNumber")
+	setTestConf(t, stub)
+	utEthRPCConf.Set(EthRPCConfigEventsFromBlock, "latest")
+	e := &EthRPC{}
+	err := e.Init(context.Background(), utConfPrefix, &blockchainmocks.Callbacks{})
+	assert.Regexp(t, "FF10264.*eth_blockNumber", err)
+}
+
+func TestWriteCheckpointFail(t *testing.T) {
+	e, _, cancel := newTestEthRPC(t, newRPCStub())
+	defer cancel()
+	e.events.checkpointFile = filepath.Join(e.events.checkpointFile, "missing", "checkpoint.json")
+	err := e.writeCheckpoint(context.Background())
+	assert.Regexp(t, "FF10271", err)
+}
+
+func TestGetLogsBlockRange(t *testing.T) {
+	stub := newRPCStub()
+	stub.result("eth_blockNumber", "0x3e8")
+	var filters []map[string]interface{}
+	stub.handle("eth_getLogs", func(params []interface{}) (interface{}, *rpcError) {
+		filters = append(filters, params[0].(map[string]interface{}))
+		return []interface{}{}, nil
+	})
+	e, _, cancel := newTestEthRPC(t, stub)
+	defer cancel()
+	e.ledgers = append(e.ledgers, &ethrpcLedger{contract: "0x2ca0a3bb4f5d2ef0e2a8a5ec6a7c2f8d6c8bb8a2"})
+
+	_, toBlock, caughtUp, err := e.getLogs(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(499), toBlock)
+	assert.False(t, caughtUp)
+	assert.Equal(t, "0x0", filters[0]["fromBlock"])
+	assert.Equal(t, "0x1f3", filters[0]["toBlock"])
+	assert.Equal(t, []interface{}{testContract, "0x2ca0a3bb4f5d2ef0e2a8a5ec6a7c2f8d6c8bb8a2"}, filters[0]["address"])
+	assert.Equal(t, []interface{}{[]interface{}{batchPinEventTopic0}}, filters[0]["topics"])
+
+	e.events.nextBlock = 900
+	_, toBlock, caughtUp, err = e.getLogs(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1000), toBlock)
+	assert.True(t, caughtUp)
+
+	e.events.nextBlock = 1001
+	_, _, caughtUp, err = e.getLogs(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, caughtUp)
+	assert.Len(t, filters, 2)
+}
+
+func TestGetLogsConfirmations(t *testing.T) {
+	stub := newRPCStub()
+	stub.result("eth_blockNumber", "0x3e8")
+	var filters []map[string]interface{}
+	stub.handle("eth_getLogs", func(params []interface{}) (interface{}, *rpcError) {
+		filters = append(filters, params[0].(map[string]interface{}))
+		return []interface{}{}, nil
+	})
+	e, _, cancel := newTestEthRPC(t, stub)
+	defer cancel()
+	e.events.confirmations = 12
+
+	e.events.nextBlock = 900
+	_, toBlock, caughtUp, err := e.getLogs(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(988), toBlock)
+	assert.True(t, caughtUp)
+	assert.Equal(t, "0x3dc", filters[0]["toBlock"])
+
+	e.events.nextBlock = 989
+	_, _, caughtUp, err = e.getLogs(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, caughtUp)
+	assert.Len(t, filters, 1)
+
+	e.events.confirmations = 2000
+	e.events.nextBlock = 0
+	_, _, caughtUp, err = e.getLogs(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, caughtUp)
+	assert.Len(t, filters, 1)
+}
+
+func TestGetLogsBlockNumberFail(t *testing.T) {
+	stub := newRPCStub()
+	stub.fail("eth_blockNumber")
+	e, _, cancel := newTestEthRPC(t, stub)
+	defer cancel()
+	_, _, _, err := e.getLogs(context.Background())
+	assert.Regexp(t, "FF10264", err)
+}
+
+func TestHandleLogsBatchPin(t *testing.T) {
+	e, mcb, cancel := newTestEthRPC(t, newRPCStub())
+	defer cancel()
+	ledgerID := fftypes.NewUUID()
+	e.ledgers = append(e.ledgers, &ethrpcLedger{id: ledgerID, name: "ledger2", contract: "0x2ca0a3bb4f5d2ef0e2a8a5ec6a7c2f8d6c8bb8a2"})
+
+	batch := testBatchPin()
+	batch.BatchPaylodRef = nil
+	mcb.On("BatchPinComplete", ledgerID, mock.Anything, testAuthor, "0xc26df2bf1a733e9249372d61eb11bd8662d26c8129df76890b1beb2f6fa72628", mock.Anything).Return(nil)
+
+	err := e.handleLogs(context.Background(), []*ethLog{
+		testBatchPinLog("0x2CA0A3BB4F5D2EF0E2A8A5EC6A7C2F8D6C8BB8A2", batch),
+	})
+	assert.NoError(t, err)
+
+	mcb.AssertExpectations(t)
+	b := mcb.Calls[0].Arguments[1].(*blockchain.BatchPin)
+	assert.Equal(t, "ns1", b.Namespace)
+	assert.Equal(t, "9ffc50ff-6bfe-4502-adc7-93aea54cc059", b.TransactionID.String())
+	assert.Equal(t, "c5df767c-fe44-4e03-8eb5-1c5523097db5", b.BatchID.String())
+	assert.Equal(t, batch.BatchHash, b.BatchHash)
+	assert.Nil(t, b.BatchPaylodRef)
+	assert.Equal(t, batch.Contexts, b.Contexts)
+	info := mcb.Calls[0].Arguments[4].(fftypes.JSONObject)
+	assert.Equal(t, "38", info.GetString("blockNumber"))
+	assert.Equal(t, "0", info.GetString("transactionIndex"))
+	assert.Equal(t, "1", info.GetString("logIndex"))
+	assert.Equal(t, broadcastBatchEventSignature, info.GetString("signature"))
+	assert.Equal(t, testAuthor, info.GetObject("data").GetString("author"))
+	assert.Equal(t, "1620576488", info.GetObject("data").GetString("timestamp"))
+	assert.Equal(t, "0x68e4da79f805bca5b912bcda9c63d03e6e867108dabb9b944109aea541ef522a", info.GetObject("data").GetStringArray("contexts")[0])
+}
+
+func TestHandleLogsSkipsInvalid(t *testing.T) {
+	e, mcb, cancel := newTestEthRPC(t, newRPCStub())
+	defer cancel()
+
+	removed := testBatchPinLog(testContract, testBatchPin())
+	removed.Removed = true
+	wrongTopic := testBatchPinLog(testContract, testBatchPin())
+	wrongTopic.Topics = []string{"0x12345"}
+	noTopics := testBatchPinLog(testContract, testBatchPin())
+	noTopics.Topics = []string{}
+	unknownContract := testBatchPinLog("0x2ca0a3bb4f5d2ef0e2a8a5ec6a7c2f8d6c8bb8a2", testBatchPin())
+	badBlockNumber := testBatchPinLog(testContract, testBatchPin())
+	badBlockNumber.BlockNumber = "38"
+	noTxHash := testBatchPinLog(testContract, testBatchPin())
+	noTxHash.TransactionHash = ""
+	badHex := testBatchPinLog(testContract, testBatchPin())
+	badHex.Data = "0xzz"
+	badData := testBatchPinLog(testContract, testBatchPin())
+	badData.Data = "0x1234"
+
+	err := e.handleLogs(context.Background(), []*ethLog{
+		removed, wrongTopic, noTopics, unknownContract, badBlockNumber, noTxHash, badHex, badData,
+	})
+	assert.NoError(t, err)
+	mcb.AssertExpectations(t)
+}
+
+func TestHandleLogsCallbackFail(t *testing.T) {
+	e, mcb, cancel := newTestEthRPC(t, newRPCStub())
+	defer cancel()
+	mcb.On("BatchPinComplete", (*fftypes.UUID)(nil), mock.Anything, testAuthor, mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+	err := e.handleLogs(context.Background(), []*ethLog{
+		testBatchPinLog(testContract, testBatchPin()),
+	})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestCheckReceipts(t *testing.T) {
+	stub := newRPCStub()
+	stub.handle("eth_getTransactionReceipt", func(params []interface{}) (interface{}, *rpcError) {
+		switch params[0] {
+		case "0x111":
+			return map[string]interface{}{"transactionHash": "0x111", "status": "0x1", "blockNumber": "0x9"}, nil
+		case "0x222":
+			return map[string]interface{}{"transactionHash": "0x222", "status": "0x0", "blockNumber": "0x9"}, nil
+		case "0x444":
+			// Mined in a block the event listener has not yet processed
+			return map[string]interface{}{"transactionHash": "0x444", "status": "0x1", "blockNumber": "0xa"}, nil
+		default:
+			return nil, nil
+		}
+	})
+	e, mcb, cancel := newTestEthRPC(t, stub)
+	defer cancel()
+	e.events.nextBlock = 10
+	e.addPendingTX("0x111")
+	e.addPendingTX("0x222")
+	e.addPendingTX("0x333")
+	e.addPendingTX("0x444")
+	e.addPendingTX("0x111")
+
+	mcb.On("TxSubmissionUpdate", "0x111", fftypes.OpStatusSucceeded, "0x111", "", mock.Anything).Return(nil)
+	mcb.On("TxSubmissionUpdate", "0x222", fftypes.OpStatusFailed, "0x222", mock.MatchedBy(func(s string) bool {
+		return s != ""
+	}), mock.Anything).Return(nil)
+
+	err := e.checkReceipts(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"0x333", "0x444"}, e.getPendingTXs())
+	mcb.AssertExpectations(t)
+}
+
+func TestCheckReceiptsQueryFail(t *testing.T) {
+	stub := newRPCStub()
+	stub.fail("eth_getTransactionReceipt")
+	e, mcb, cancel := newTestEthRPC(t, stub)
+	defer cancel()
+	e.addPendingTX("0x111")
+	err := e.checkReceipts(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"0x111"}, e.getPendingTXs())
+	mcb.AssertExpectations(t)
+}
+
+func TestCheckReceiptsCallbackFail(t *testing.T) {
+	stub := newRPCStub()
+	stub.result("eth_getTransactionReceipt", map[string]interface{}{"transactionHash": "0x111", "status": "0x1", "blockNumber": "0x0"})
+	e, mcb, cancel := newTestEthRPC(t, stub)
+	defer cancel()
+	e.events.nextBlock = 1
+	e.addPendingTX("0x111")
+	mcb.On("TxSubmissionUpdate", "0x111", fftypes.OpStatusSucceeded, "0x111", "", mock.Anything).Return(fmt.Errorf("pop"))
+	err := e.checkReceipts(context.Background())
+	assert.EqualError(t, err, "pop")
+	assert.Equal(t, []string{"0x111"}, e.getPendingTXs())
+}
+
+func TestRemovePendingTXNotFound(t *testing.T) {
+	e := &EthRPC{pendingTXs: []string{"0x111"}}
+	e.removePendingTX("0x222")
+	assert.Equal(t, []string{"0x111"}, e.getPendingTXs())
+}
+
+func TestEventLoopDispatchAndCheckpoint(t *testing.T) {
+	stub := newRPCStub()
+	blockNumberCalls := 0
+	stub.handle("eth_blockNumber", func(params []interface{}) (interface{}, *rpcError) {
+		blockNumberCalls++
+		if blockNumberCalls == 1 {
+			// Errors talking to the node are retried
+			return nil, &rpcError{Code: -32000, Message: "pop"}
+		}
+		return "0x26", nil
+	})
+	stub.handle("eth_getLogs", func(params []interface{}) (interface{}, *rpcError) {
+		filter := params[0].(map[string]interface{})
+		if filter["fromBlock"] == "0x0" {
+			return []*ethLog{testBatchPinLog(testContract, testBatchPin())}, nil
+		}
+		return []*ethLog{}, nil
+	})
+	receiptPolls := make(chan struct{}, 100)
+	stub.handle("eth_getTransactionReceipt", func(params []interface{}) (interface{}, *rpcError) {
+		receiptPolls <- struct{}{}
+		return nil, nil
+	})
+	e, mcb, cancel := newTestEthRPC(t, stub)
+	defer cancel()
+	e.addPendingTX("0x111")
+
+	dispatched := make(chan struct{})
+	mcb.On("BatchPinComplete", (*fftypes.UUID)(nil), mock.Anything, testAuthor, mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		close(dispatched)
+	})
+
+	err := e.Start()
+	assert.NoError(t, err)
+	<-dispatched
+	// Wait until we have polled for the receipt again, once caught up
+	<-receiptPolls
+	<-receiptPolls
+	cancel()
+	<-e.events.closed
+
+	b, err := ioutil.ReadFile(e.events.checkpointFile)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"nextBlock":39}`, string(b))
+	mcb.AssertExpectations(t)
+}
+
+func TestEventLoopCallbackFailExits(t *testing.T) {
+	stub := newRPCStub()
+	stub.result("eth_blockNumber", "0x26")
+	stub.result("eth_getLogs", []*ethLog{testBatchPinLog(testContract, testBatchPin())})
+	e, mcb, cancel := newTestEthRPC(t, stub)
+	defer cancel()
+	mcb.On("BatchPinComplete", (*fftypes.UUID)(nil), mock.Anything, testAuthor, mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+
+	e.eventLoop()
+
+	_, err := ioutil.ReadFile(e.events.checkpointFile)
+	assert.Error(t, err)
+	mcb.AssertExpectations(t)
+}
+
+func TestEventLoopCancelledDuringRetry(t *testing.T) {
+	stub := newRPCStub()
+	stub.fail("eth_blockNumber")
+	e, _, cancel := newTestEthRPC(t, stub)
+	cancel()
+	e.eventLoop()
+}
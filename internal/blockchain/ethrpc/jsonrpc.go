@@ -0,0 +1,110 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ethrpc
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/hyperledger-labs/firefly/internal/i18n"
+	"github.com/hyperledger-labs/firefly/internal/log"
+	"github.com/hyperledger-labs/firefly/internal/restclient"
+)
+
+type rpcRequest struct {
+	JSONRPC string        `json:"jsonrpc"`
+	ID      int64         `json:"id"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+}
+
+type rpcError struct {
+	Code    int64  `json:"code"`
+	Message string `json:"message"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      int64           `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+// invokeRPC makes a single JSON-RPC call, unmarshaling the result into the supplied pointer.
+// A null result leaves the pointer untouched, which callers use to detect "not found" (such as a pending receipt).
+func (e *EthRPC) invokeRPC(ctx context.Context, result interface{}, method string, params ...interface{}) error {
+	if params == nil {
+		params = []interface{}{}
+	}
+	req := &rpcRequest{
+		JSONRPC: "2.0",
+		ID:      atomic.AddInt64(&e.rpcID, 1),
+		Method:  method,
+		Params:  params,
+	}
+	log.L(ctx).Tracef("RPC[%d] --> %s", req.ID, method)
+	var rpcRes rpcResponse
+	res, err := e.client.R().
+		SetContext(ctx).
+		SetBody(req).
+		SetResult(&rpcRes).
+		SetError(&rpcRes).
+		Post("")
+	if err != nil || !res.IsSuccess() {
+		return restclient.WrapRestErr(ctx, res, err, i18n.MsgEthRPCRESTErr)
+	}
+	if rpcRes.Error != nil {
+		log.L(ctx).Debugf("RPC[%d] <-- %s failed: %d %s", req.ID, method, rpcRes.Error.Code, rpcRes.Error.Message)
+		return i18n.NewError(ctx, i18n.MsgEthRPCError, method, strconv.FormatInt(rpcRes.Error.Code, 10), rpcRes.Error.Message)
+	}
+	log.L(ctx).Tracef("RPC[%d] <-- %s", req.ID, method)
+	if result != nil && len(rpcRes.Result) > 0 {
+		if err := json.Unmarshal(rpcRes.Result, result); err != nil {
+			return i18n.NewError(ctx, i18n.MsgEthRPCInvalidResult, method, err)
+		}
+	}
+	return nil
+}
+
+// invokeRPCQuantity makes a JSON-RPC call that returns a hex encoded quantity
+func (e *EthRPC) invokeRPCQuantity(ctx context.Context, method string, params ...interface{}) (*big.Int, error) {
+	var hexStr string
+	if err := e.invokeRPC(ctx, &hexStr, method, params...); err != nil {
+		return nil, err
+	}
+	i, ok := parseHexQuantity(hexStr)
+	if !ok {
+		return nil, i18n.NewError(ctx, i18n.MsgEthRPCInvalidResult, method, hexStr)
+	}
+	return i, nil
+}
+
+// parseHexQuantity parses a "0x" prefixed hex quantity, as used throughout the Ethereum JSON-RPC API
+func parseHexQuantity(s string) (*big.Int, bool) {
+	if !strings.HasPrefix(s, "0x") || len(s) < 3 {
+		return nil, false
+	}
+	return new(big.Int).SetString(s[2:], 16)
+}
+
+func hexQuantity(i uint64) string {
+	return "0x" + strconv.FormatUint(i, 16)
+}
@@ -0,0 +1,107 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ethrpc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInvokeRPCHTTPError(t *testing.T) {
+	e, _, cancel := newTestEthRPC(t, newRPCStub())
+	defer cancel()
+	httpmock.RegisterResponder("POST", testRPCURL, httpmock.NewStringResponder(500, "pop"))
+	err := e.invokeRPC(context.Background(), nil, "eth_blockNumber")
+	assert.Regexp(t, "FF10263.*pop", err)
+}
+
+func TestInvokeRPCMethodNotFound(t *testing.T) {
+	e, _, cancel := newTestEthRPC(t, newRPCStub())
+	defer cancel()
+	err := e.invokeRPC(context.Background(), nil, "eth_unknown", "param1")
+	assert.Regexp(t, "FF10264.*eth_unknown.*-32601.*method not found", err)
+}
+
+func TestInvokeRPCInvalidResult(t *testing.T) {
+	stub := newRPCStub()
+	e, _, cancel := newTestEthRPC(t, stub)
+	defer cancel()
+	stub.result("eth_blockNumber", map[string]interface{}{"not": "a string"})
+	var result string
+	err := e.invokeRPC(context.Background(), &result, "eth_blockNumber")
+	assert.Regexp(t, "FF10265.*eth_blockNumber", err)
+}
+
+func TestInvokeRPCNullResult(t *testing.T) {
+	stub := newRPCStub()
+	e, _, cancel := newTestEthRPC(t, stub)
+	defer cancel()
+	stub.result("eth_getTransactionReceipt", nil)
+	result := map[string]interface{}{}
+	err := e.invokeRPC(context.Background(), &result, "eth_getTransactionReceipt", "0x12345")
+	assert.NoError(t, err)
+	assert.Nil(t, result)
+}
+
+func TestInvokeRPCQuantityInvalid(t *testing.T) {
+	stub := newRPCStub()
+	e, _, cancel := newTestEthRPC(t, stub)
+	defer cancel()
+	stub.result("eth_blockNumber", "12345")
+	_, err := e.invokeRPCQuantity(context.Background(), "eth_blockNumber")
+	assert.Regexp(t, "FF10265.*eth_blockNumber.*12345", err)
+}
+
+func TestInvokeRPCRequestBody(t *testing.T) {
+	stub := newRPCStub()
+	e, _, cancel := newTestEthRPC(t, stub)
+	defer cancel()
+	httpmock.RegisterResponder("POST", testRPCURL, func(req *http.Request) (*http.Response, error) {
+		var rpcReq map[string]interface{}
+		assert.NoError(t, json.NewDecoder(req.Body).Decode(&rpcReq))
+		assert.Equal(t, "2.0", rpcReq["jsonrpc"])
+		assert.Equal(t, "eth_blockNumber", rpcReq["method"])
+		assert.Equal(t, []interface{}{}, rpcReq["params"])
+		return httpmock.NewJsonResponse(200, map[string]interface{}{
+			"jsonrpc": "2.0",
+			"id":      rpcReq["id"],
+			"result":  "0x10",
+		})
+	})
+	i, err := e.invokeRPCQuantity(context.Background(), "eth_blockNumber")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(16), i.Int64())
+}
+
+func TestHexQuantities(t *testing.T) {
+	_, ok := parseHexQuantity("0x")
+	assert.False(t, ok)
+	_, ok = parseHexQuantity("10")
+	assert.False(t, ok)
+	_, ok = parseHexQuantity("0xzz")
+	assert.False(t, ok)
+	i, ok := parseHexQuantity("0x1f")
+	assert.True(t, ok)
+	assert.Equal(t, int64(31), i.Int64())
+	assert.Equal(t, "0x0", hexQuantity(0))
+	assert.Equal(t, "0x1f", hexQuantity(31))
+}
@@ -0,0 +1,186 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ethrpc
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/hyperledger-labs/firefly/internal/i18n"
+	"github.com/hyperledger-labs/firefly/internal/log"
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/crypto/scrypt"
+)
+
+// keystoreV3 is the Web3 Secret Storage format written by geth, and most Ethereum wallets
+type keystoreV3 struct {
+	Address string `json:"address"`
+	Crypto  struct {
+		Cipher       string `json:"cipher"`
+		CipherText   string `json:"ciphertext"`
+		CipherParams struct {
+			IV string `json:"iv"`
+		} `json:"cipherparams"`
+		KDF       string `json:"kdf"`
+		KDFParams struct {
+			DKLen int    `json:"dklen"`
+			Salt  string `json:"salt"`
+			N     int    `json:"n"`
+			R     int    `json:"r"`
+			P     int    `json:"p"`
+			C     int    `json:"c"`
+			PRF   string `json:"prf"`
+		} `json:"kdfparams"`
+		MAC string `json:"mac"`
+	} `json:"crypto"`
+	Version int `json:"version"`
+}
+
+// keystore indexes a directory of encrypted key files by address, and decrypts each key the first time it is used
+type keystore struct {
+	password string
+	mux      sync.Mutex
+	files    map[string]*keystoreV3
+	keys     map[string]*btcec.PrivateKey
+}
+
+func newKeystore(ctx context.Context, path, passwordFile string) (*keystore, error) {
+	password, err := ioutil.ReadFile(passwordFile)
+	if err != nil {
+		return nil, i18n.WrapError(ctx, err, i18n.MsgEthKeystoreReadFailed, passwordFile)
+	}
+	entries, err := ioutil.ReadDir(path)
+	if err != nil {
+		return nil, i18n.WrapError(ctx, err, i18n.MsgEthKeystoreReadFailed, path)
+	}
+	ks := &keystore{
+		password: strings.TrimRight(string(password), "\r\n"),
+		files:    make(map[string]*keystoreV3),
+		keys:     make(map[string]*btcec.PrivateKey),
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		filename := filepath.Join(path, entry.Name())
+		b, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return nil, i18n.WrapError(ctx, err, i18n.MsgEthKeystoreReadFailed, filename)
+		}
+		var kf keystoreV3
+		if err = json.Unmarshal(b, &kf); err != nil {
+			return nil, i18n.NewError(ctx, i18n.MsgEthKeystoreInvalid, filename, err)
+		}
+		address := strings.TrimPrefix(strings.ToLower(kf.Address), "0x")
+		if kf.Version != 3 || !addressVerify.MatchString(address) {
+			return nil, i18n.NewError(ctx, i18n.MsgEthKeystoreInvalid, filename, "not a V3 keystore file with an address")
+		}
+		log.L(ctx).Debugf("Keystore file for 0x%s: %s", address, filename)
+		ks.files["0x"+address] = &kf
+	}
+	return ks, nil
+}
+
+// getKey returns the private key for an address, in the "0x" prefixed lower case form returned by validateEthAddress
+func (ks *keystore) getKey(ctx context.Context, address string) (*btcec.PrivateKey, error) {
+	ks.mux.Lock()
+	defer ks.mux.Unlock()
+	if key, ok := ks.keys[address]; ok {
+		return key, nil
+	}
+	kf, ok := ks.files[address]
+	if !ok {
+		return nil, i18n.NewError(ctx, i18n.MsgEthKeyNotFound, address)
+	}
+	key, err := kf.decrypt(ks.password)
+	if err != nil {
+		return nil, i18n.WrapError(ctx, err, i18n.MsgEthKeyDecryptFailed, address)
+	}
+	if keyAddress(key) != address {
+		return nil, i18n.NewError(ctx, i18n.MsgEthKeyDecryptFailed, address)
+	}
+	ks.keys[address] = key
+	return key, nil
+}
+
+func keyAddress(key *btcec.PrivateKey) string {
+	pubBytes := key.PubKey().SerializeUncompressed()
+	return fmt.Sprintf("0x%x", keccak256(pubBytes[1:])[12:])
+}
+
+func (kf *keystoreV3) deriveKey(password string) ([]byte, error) {
+	kp := &kf.Crypto.KDFParams
+	salt, err := hex.DecodeString(kp.Salt)
+	if err != nil {
+		return nil, err
+	}
+	switch kf.Crypto.KDF {
+	case "scrypt":
+		return scrypt.Key([]byte(password), salt, kp.N, kp.R, kp.P, kp.DKLen)
+	case "pbkdf2":
+		if kp.PRF != "hmac-sha256" {
+			return nil, fmt.Errorf("unsupported pbkdf2 prf '%s'", kp.PRF)
+		}
+		return pbkdf2.Key([]byte(password), salt, kp.C, kp.DKLen, sha256.New), nil
+	default:
+		return nil, fmt.Errorf("unsupported kdf '%s'", kf.Crypto.KDF)
+	}
+}
+
+func (kf *keystoreV3) decrypt(password string) (*btcec.PrivateKey, error) {
+	if kf.Crypto.Cipher != "aes-128-ctr" {
+		return nil, fmt.Errorf("unsupported cipher '%s'", kf.Crypto.Cipher)
+	}
+	cipherText, err := hex.DecodeString(kf.Crypto.CipherText)
+	if err != nil {
+		return nil, err
+	}
+	iv, err := hex.DecodeString(kf.Crypto.CipherParams.IV)
+	if err != nil {
+		return nil, err
+	}
+	mac, err := hex.DecodeString(kf.Crypto.MAC)
+	if err != nil {
+		return nil, err
+	}
+	derivedKey, err := kf.deriveKey(password)
+	if err != nil {
+		return nil, err
+	}
+	if len(derivedKey) < 32 || len(iv) != aes.BlockSize {
+		return nil, fmt.Errorf("invalid key derivation parameters")
+	}
+	if !hmac.Equal(keccak256(derivedKey[16:32], cipherText), mac) {
+		return nil, fmt.Errorf("mac mismatch (incorrect password)")
+	}
+	block, _ := aes.NewCipher(derivedKey[0:16])
+	keyBytes := make([]byte, len(cipherText))
+	cipher.NewCTR(block, iv).XORKeyStream(keyBytes, cipherText)
+	key, _ := btcec.PrivKeyFromBytes(btcec.S256(), keyBytes)
+	return key, nil
+}
@@ -0,0 +1,209 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ethrpc
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Test vectors from the Web3 Secret Storage Definition, with password "testpassword"
+const testKeyAddress = "0x008aeeda4d805471df9b2a5b0f38a0c3bcba786b"
+
+const testKeyPrivate = "7a28b5ba57c53603b0b07b56bba752f7784bf506fa95edc395f5cf6c7514fe9d"
+
+const testKeyFilePBKDF2 = `{
+	"address": "008aeeda4d805471df9b2a5b0f38a0c3bcba786b",
+	"crypto": {
+		"cipher": "aes-128-ctr",
+		"cipherparams": {"iv": "6087dab2f9fdbbfaddc31a909735c1e6"},
+		"ciphertext": "5318b4d5bcd28de64ee5559e671353e16f075ecae9f99c7a79a38af5f869aa46",
+		"kdf": "pbkdf2",
+		"kdfparams": {
+			"c": 262144,
+			"dklen": 32,
+			"prf": "hmac-sha256",
+			"salt": "ae3cd4e7013836a3df6bd7241b12db061dbe2c6785853cce422d148a624ce0bd"
+		},
+		"mac": "517ead924a9d0dc3124507e3393d175ce3ff7c1e96529c6c555ce9e51205e9b2"
+	},
+	"version": 3
+}`
+
+const testKeyFileScrypt = `{
+	"address": "008aeeda4d805471df9b2a5b0f38a0c3bcba786b",
+	"crypto": {
+		"cipher": "aes-128-ctr",
+		"cipherparams": {"iv": "83dbcc02d8ccb40e466191a123791e0e"},
+		"ciphertext": "d172bf743a674da9cdad04534d56926ef8358534d458fffccd4e6ad2fbde479c",
+		"kdf": "scrypt",
+		"kdfparams": {
+			"dklen": 32,
+			"n": 262144,
+			"p": 8,
+			"r": 1,
+			"salt": "ab0c7876052600dd703518d6fc3fe8984592145b591fc8fb5c6d43190334ba19"
+		},
+		"mac": "2103ac29920d71da29f15d75b4a16dbe95cfd7ff8faea1056c33131d846e3097"
+	},
+	"version": 3
+}`
+
+func newTestKeystoreDir(t *testing.T, password string, files map[string]string) (string, string) {
+	dir, err := ioutil.TempDir("", "ethrpc")
+	assert.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	keysDir := filepath.Join(dir, "keys")
+	os.Mkdir(keysDir, 0700)
+	for name, content := range files {
+		err = ioutil.WriteFile(filepath.Join(keysDir, name), []byte(content), 0600)
+		assert.NoError(t, err)
+	}
+	passwordFile := filepath.Join(dir, "password")
+	err = ioutil.WriteFile(passwordFile, []byte(password+"\n"), 0600)
+	assert.NoError(t, err)
+	return keysDir, passwordFile
+}
+
+func TestKeystoreSpecVectors(t *testing.T) {
+	for _, kf := range []string{testKeyFilePBKDF2, testKeyFileScrypt} {
+		keysDir, passwordFile := newTestKeystoreDir(t, "testpassword", map[string]string{
+			"key1.json": kf,
+		})
+		ks, err := newKeystore(context.Background(), keysDir, passwordFile)
+		assert.NoError(t, err)
+		key, err := ks.getKey(context.Background(), testKeyAddress)
+		assert.NoError(t, err)
+		assert.Equal(t, testKeyPrivate, hex.EncodeToString(key.Serialize()))
+		assert.Equal(t, testKeyAddress, keyAddress(key))
+	}
+}
+
+func TestKeystoreCached(t *testing.T) {
+	keysDir, passwordFile := newTestKeystoreDir(t, "testpassword", map[string]string{
+		"key1.json": testKeyFilePBKDF2,
+		".hidden":   "!json",
+	})
+	os.Mkdir(filepath.Join(keysDir, "subdir"), 0700)
+	ks, err := newKeystore(context.Background(), keysDir, passwordFile)
+	assert.NoError(t, err)
+	key1, err := ks.getKey(context.Background(), testKeyAddress)
+	assert.NoError(t, err)
+	ks.password = "changed"
+	key2, err := ks.getKey(context.Background(), testKeyAddress)
+	assert.NoError(t, err)
+	assert.Equal(t, key1, key2)
+}
+
+func TestKeystoreMissingPasswordFile(t *testing.T) {
+	keysDir, _ := newTestKeystoreDir(t, "testpassword", map[string]string{})
+	_, err := newKeystore(context.Background(), keysDir, filepath.Join(keysDir, "missing"))
+	assert.Regexp(t, "FF10266", err)
+}
+
+func TestKeystoreMissingDir(t *testing.T) {
+	keysDir, passwordFile := newTestKeystoreDir(t, "testpassword", map[string]string{})
+	_, err := newKeystore(context.Background(), filepath.Join(keysDir, "missing"), passwordFile)
+	assert.Regexp(t, "FF10266", err)
+}
+
+func TestKeystoreUnreadableFile(t *testing.T) {
+	keysDir, passwordFile := newTestKeystoreDir(t, "testpassword", map[string]string{})
+	os.Symlink(filepath.Join(keysDir, "missing"), filepath.Join(keysDir, "broken.json"))
+	_, err := newKeystore(context.Background(), keysDir, passwordFile)
+	assert.Regexp(t, "FF10266", err)
+}
+
+func TestKeystoreBadJSON(t *testing.T) {
+	keysDir, passwordFile := newTestKeystoreDir(t, "testpassword", map[string]string{
+		"key1.json": "!json",
+	})
+	_, err := newKeystore(context.Background(), keysDir, passwordFile)
+	assert.Regexp(t, "FF10267", err)
+}
+
+func TestKeystoreNotV3(t *testing.T) {
+	keysDir, passwordFile := newTestKeystoreDir(t, "testpassword", map[string]string{
+		"key1.json": `{"address":"008aeeda4d805471df9b2a5b0f38a0c3bcba786b","version":1}`,
+	})
+	_, err := newKeystore(context.Background(), keysDir, passwordFile)
+	assert.Regexp(t, "FF10267", err)
+}
+
+func TestKeystoreKeyNotFound(t *testing.T) {
+	keysDir, passwordFile := newTestKeystoreDir(t, "testpassword", map[string]string{})
+	ks, err := newKeystore(context.Background(), keysDir, passwordFile)
+	assert.NoError(t, err)
+	_, err = ks.getKey(context.Background(), testKeyAddress)
+	assert.Regexp(t, "FF10268", err)
+}
+
+func TestKeystoreWrongPassword(t *testing.T) {
+	keysDir, passwordFile := newTestKeystoreDir(t, "wrong", map[string]string{
+		"key1.json": testKeyFilePBKDF2,
+	})
+	ks, err := newKeystore(context.Background(), keysDir, passwordFile)
+	assert.NoError(t, err)
+	_, err = ks.getKey(context.Background(), testKeyAddress)
+	assert.Regexp(t, "FF10269.*mac mismatch", err)
+}
+
+func TestKeystoreAddressMismatch(t *testing.T) {
+	keysDir, passwordFile := newTestKeystoreDir(t, "testpassword", map[string]string{
+		"key1.json": testKeyFilePBKDF2,
+	})
+	ks, err := newKeystore(context.Background(), keysDir, passwordFile)
+	assert.NoError(t, err)
+	ks.files["0x1111111111111111111111111111111111111111"] = ks.files[testKeyAddress]
+	_, err = ks.getKey(context.Background(), "0x1111111111111111111111111111111111111111")
+	assert.Regexp(t, "FF10269", err)
+}
+
+func TestKeystoreDecryptErrors(t *testing.T) {
+	testCases := []struct {
+		name   string
+		modify func(kf *keystoreV3)
+		errStr string
+	}{
+		{"cipher", func(kf *keystoreV3) { kf.Crypto.Cipher = "aes-256-gcm" }, "unsupported cipher"},
+		{"ciphertext", func(kf *keystoreV3) { kf.Crypto.CipherText = "!hex" }, "invalid byte"},
+		{"iv", func(kf *keystoreV3) { kf.Crypto.CipherParams.IV = "!hex" }, "invalid byte"},
+		{"mac", func(kf *keystoreV3) { kf.Crypto.MAC = "!hex" }, "invalid byte"},
+		{"salt", func(kf *keystoreV3) { kf.Crypto.KDFParams.Salt = "!hex" }, "invalid byte"},
+		{"kdf", func(kf *keystoreV3) { kf.Crypto.KDF = "argon2" }, "unsupported kdf"},
+		{"prf", func(kf *keystoreV3) { kf.Crypto.KDFParams.PRF = "hmac-sha512" }, "unsupported pbkdf2 prf"},
+		{"dklen", func(kf *keystoreV3) { kf.Crypto.KDFParams.DKLen = 16; kf.Crypto.KDFParams.C = 1 }, "invalid key derivation"},
+		{"scrypt", func(kf *keystoreV3) { kf.Crypto.KDF = "scrypt"; kf.Crypto.KDFParams.N = 3 }, "scrypt"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var kf keystoreV3
+			err := json.Unmarshal([]byte(testKeyFilePBKDF2), &kf)
+			assert.NoError(t, err)
+			tc.modify(&kf)
+			_, err = kf.decrypt("testpassword")
+			assert.Regexp(t, tc.errStr, err)
+		})
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ethrpc
+
+import (
+	"encoding/binary"
+	"math/big"
+
+	"github.com/btcsuite/btcd/btcec"
+)
+
+// rlpList is a nested list in an RLP encoding. All other items must be byte strings or integers.
+type rlpList []interface{}
+
+func rlpEncodeLength(l int, offset byte) []byte {
+	if l < 56 {
+		return []byte{offset + byte(l)}
+	}
+	lb := make([]byte, 8)
+	binary.BigEndian.PutUint64(lb, uint64(l))
+	for lb[0] == 0 {
+		lb = lb[1:]
+	}
+	return append([]byte{offset + 55 + byte(len(lb))}, lb...)
+}
+
+// rlpEncode provides the subset of Recursive Length Prefix encoding required to serialize a transaction
+func rlpEncode(item interface{}) []byte {
+	switch v := item.(type) {
+	case rlpList:
+		var payload []byte
+		for _, i := range v {
+			payload = append(payload, rlpEncode(i)...)
+		}
+		return append(rlpEncodeLength(len(payload), 0xc0), payload...)
+	case uint64:
+		return rlpEncode(new(big.Int).SetUint64(v))
+	case *big.Int:
+		// Integers are encoded as big-endian with no leading zeros, so zero is the empty string
+		return rlpEncode(v.Bytes())
+	default:
+		b := v.([]byte)
+		if len(b) == 1 && b[0] < 0x80 {
+			return b
+		}
+		return append(rlpEncodeLength(len(b), 0x80), b...)
+	}
+}
+
+// ethTransaction is a legacy (pre EIP-2718) transaction, signed with EIP-155 replay protection
+type ethTransaction struct {
+	Nonce    uint64
+	GasPrice *big.Int
+	GasLimit uint64
+	To       []byte
+	Value    *big.Int
+	Data     []byte
+}
+
+func (tx *ethTransaction) fields() rlpList {
+	value := tx.Value
+	if value == nil {
+		value = big.NewInt(0)
+	}
+	return rlpList{tx.Nonce, tx.GasPrice, tx.GasLimit, tx.To, value, tx.Data}
+}
+
+// sign returns the raw signed transaction bytes, ready for eth_sendRawTransaction, and the transaction hash
+func (tx *ethTransaction) sign(key *btcec.PrivateKey, chainID *big.Int) (raw []byte, hash []byte, err error) {
+	sigPayload := append(tx.fields(), chainID, uint64(0), uint64(0))
+	sig, err := btcec.SignCompact(btcec.S256(), key, keccak256(rlpEncode(sigPayload)), false)
+	if err != nil {
+		return nil, nil, err
+	}
+	// The compact signature is [27+recid][R][S], and EIP-155 requires v = recid + chainId*2 + 35
+	v := new(big.Int).Mul(chainID, big.NewInt(2))
+	v.Add(v, big.NewInt(int64(sig[0]-27)+35))
+	r := new(big.Int).SetBytes(sig[1:33])
+	s := new(big.Int).SetBytes(sig[33:65])
+	raw = rlpEncode(append(tx.fields(), v, r, s))
+	return raw, keccak256(raw), nil
+}
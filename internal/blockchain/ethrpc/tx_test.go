@@ -0,0 +1,69 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ethrpc
+
+import (
+	"encoding/hex"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRLPEncode(t *testing.T) {
+	assert.Equal(t, "80", hex.EncodeToString(rlpEncode(uint64(0))))
+	assert.Equal(t, "0f", hex.EncodeToString(rlpEncode(uint64(15))))
+	assert.Equal(t, "820400", hex.EncodeToString(rlpEncode(uint64(1024))))
+	assert.Equal(t, "83646f67", hex.EncodeToString(rlpEncode([]byte("dog"))))
+	assert.Equal(t, "c0", hex.EncodeToString(rlpEncode(rlpList{})))
+	assert.Equal(t, "c88363617483646f67", hex.EncodeToString(rlpEncode(rlpList{[]byte("cat"), []byte("dog")})))
+	lorem := []byte("Lorem ipsum dolor sit amet, consectetur adipisicing elit")
+	assert.Equal(t, "b838"+hex.EncodeToString(lorem), hex.EncodeToString(rlpEncode(lorem)))
+}
+
+func TestSignEIP155(t *testing.T) {
+	// Example from https://eips.ethereum.org/EIPS/eip-155
+	keyBytes, _ := hex.DecodeString(strings.Repeat("46", 32))
+	key, _ := btcec.PrivKeyFromBytes(btcec.S256(), keyBytes)
+	to, _ := hex.DecodeString(strings.Repeat("35", 20))
+	value, _ := new(big.Int).SetString("1000000000000000000", 10)
+	tx := &ethTransaction{
+		Nonce:    9,
+		GasPrice: big.NewInt(20000000000),
+		GasLimit: 21000,
+		To:       to,
+		Value:    value,
+	}
+	raw, hash, err := tx.sign(key, big.NewInt(1))
+	assert.NoError(t, err)
+	assert.Equal(t, "f86c098504a817c800825208943535353535353535353535353535353535353535880de0b6b3a76400008025a028ef61340bd939bc2195fe537567866003e1a15d3c71ff63e1590620aa636276a067cbe9d8997f761aecb703304b3800ccf555c9f3dc64214b297fb1966a3b6d83", hex.EncodeToString(raw))
+	assert.Equal(t, hex.EncodeToString(keccak256(raw)), hex.EncodeToString(hash))
+}
+
+func TestSignZeroValue(t *testing.T) {
+	keyBytes, _ := hex.DecodeString(strings.Repeat("46", 32))
+	key, _ := btcec.PrivKeyFromBytes(btcec.S256(), keyBytes)
+	tx := &ethTransaction{
+		GasPrice: big.NewInt(0),
+		Data:     []byte{0x01, 0x02},
+	}
+	raw, _, err := tx.sign(key, big.NewInt(2021))
+	assert.NoError(t, err)
+	assert.Equal(t, byte(0xf8), raw[0])
+}
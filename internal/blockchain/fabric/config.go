@@ -0,0 +1,71 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fabric
+
+import (
+	"github.com/hyperledger-labs/firefly/internal/config"
+	"github.com/hyperledger-labs/firefly/internal/wsclient"
+)
+
+const (
+	defaultBatchSize    = 50
+	defaultBatchTimeout = 500
+)
+
+const (
+	// FabconnectConfigKey is a sub-key in the config to contain all the fabconnect specific config,
+	FabconnectConfigKey = "fabconnect"
+
+	// FabconnectConfigDefaultChannel is the channel that the FireFly chaincode is deployed on
+	FabconnectConfigDefaultChannel = "channel"
+	// FabconnectConfigChaincode is the name of the FireFly chaincode, as deployed on the channel
+	FabconnectConfigChaincode = "chaincode"
+	// FabconnectConfigSigner is the signer identity fabconnect uses to query the channel, when subscribing to events
+	FabconnectConfigSigner = "signer"
+	// FabconnectConfigTopic is the websocket listen topic that the node should register on, which is important if there are multiple
+	// nodes using a single fabconnect
+	FabconnectConfigTopic = "topic"
+	// FabconnectConfigBatchSize is the batch size to configure on event streams, when auto-defining them
+	FabconnectConfigBatchSize = "batchSize"
+	// FabconnectConfigBatchTimeout is the batch timeout to configure on event streams, when auto-defining them
+	FabconnectConfigBatchTimeout = "batchTimeout"
+	// FabconnectConfigSkipEventstreamInit disables auto-configuration of event streams
+	FabconnectConfigSkipEventstreamInit = "skipEventstreamInit"
+	// FabconnectConfigLedgers is an array of additional named ledgers, each with its own channel and topic.
+	// The channel, chaincode and topic configured at the top level are used for the default ledger
+	FabconnectConfigLedgers = "ledgers"
+)
+
+const (
+	// FabconnectLedgerConfigID is the UUID of the ledger, as referred to by groups
+	FabconnectLedgerConfigID = "id"
+	// FabconnectLedgerConfigName is a friendly name for the ledger
+	FabconnectLedgerConfigName = "name"
+)
+
+func (f *Fabric) InitPrefix(prefix config.Prefix) {
+	fabconnectConf := prefix.SubPrefix(FabconnectConfigKey)
+	wsclient.InitPrefix(fabconnectConf)
+	fabconnectConf.AddKnownKey(FabconnectConfigDefaultChannel)
+	fabconnectConf.AddKnownKey(FabconnectConfigChaincode)
+	fabconnectConf.AddKnownKey(FabconnectConfigSigner)
+	fabconnectConf.AddKnownKey(FabconnectConfigTopic)
+	fabconnectConf.AddKnownKey(FabconnectConfigSkipEventstreamInit)
+	fabconnectConf.AddKnownKey(FabconnectConfigBatchSize, defaultBatchSize)
+	fabconnectConf.AddKnownKey(FabconnectConfigBatchTimeout, defaultBatchTimeout)
+	fabconnectConf.AddKnownKey(FabconnectConfigLedgers)
+}
@@ -0,0 +1,616 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fabric
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/hyperledger-labs/firefly/internal/config"
+	"github.com/hyperledger-labs/firefly/internal/i18n"
+	"github.com/hyperledger-labs/firefly/internal/log"
+	"github.com/hyperledger-labs/firefly/internal/restclient"
+	"github.com/hyperledger-labs/firefly/internal/wsclient"
+	"github.com/hyperledger-labs/firefly/pkg/blockchain"
+	"github.com/hyperledger-labs/firefly/pkg/fftypes"
+)
+
+const (
+	batchPinEventName = "BatchPin"
+	batchPinFunction  = "PinBatch"
+)
+
+var zeroBytes32 = fftypes.Bytes32{}
+
+type Fabric struct {
+	ctx          context.Context
+	signer       string
+	capabilities *blockchain.Capabilities
+	callbacks    blockchain.Callbacks
+	client       *resty.Client
+	ledgers      []*fabLedger
+}
+
+// fabLedger is a channel with the FireFly chaincode deployed, with its own event stream, websocket topic and connection.
+// The default ledger has a nil ID, and is always the first in the list.
+type fabLedger struct {
+	id        *fftypes.UUID
+	name      string
+	channel   string
+	chaincode string
+	topic     string
+	initInfo  struct {
+		stream *eventStream
+		subs   []*subscription
+	}
+	wsconn wsclient.WSClient
+}
+
+type eventStream struct {
+	ID             string               `json:"id"`
+	Name           string               `json:"name"`
+	ErrorHandling  string               `json:"errorHandling"`
+	BatchSize      uint                 `json:"batchSize"`
+	BatchTimeoutMS uint                 `json:"batchTimeoutMS"`
+	Type           string               `json:"type"`
+	WebSocket      eventStreamWebsocket `json:"websocket"`
+}
+
+type eventStreamWebsocket struct {
+	Topic string `json:"topic"`
+}
+
+type subscription struct {
+	ID        string             `json:"id"`
+	Name      string             `json:"name"`
+	Stream    string             `json:"stream"`
+	Channel   string             `json:"channel"`
+	Signer    string             `json:"signer"`
+	FromBlock string             `json:"fromBlock"`
+	Filter    subscriptionFilter `json:"filter"`
+}
+
+type subscriptionFilter struct {
+	ChaincodeID string `json:"chaincodeId"`
+	EventFilter string `json:"eventFilter"`
+}
+
+type asyncTXSubmission struct {
+	ID string `json:"id"`
+}
+
+type fabTxInputHeaders struct {
+	Type      string `json:"type"`
+	Signer    string `json:"signer"`
+	Channel   string `json:"channel"`
+	Chaincode string `json:"chaincode"`
+}
+
+type fabTxInput struct {
+	Headers fabTxInputHeaders `json:"headers"`
+	Func    string            `json:"func"`
+	Args    []string          `json:"args"`
+}
+
+type fabWSCommandPayload struct {
+	Type  string `json:"type"`
+	Topic string `json:"topic,omitempty"`
+}
+
+// fabBatchPinEvent is the payload of the BatchPin chaincode event, as emitted by the FireFly chaincode
+type fabBatchPinEvent struct {
+	Signer     string   `json:"signer"`
+	Timestamp  int64    `json:"timestamp"`
+	Namespace  string   `json:"namespace"`
+	UUIDs      string   `json:"uuids"`
+	BatchHash  string   `json:"batchHash"`
+	PayloadRef string   `json:"payloadRef"`
+	Contexts   []string `json:"contexts"`
+}
+
+// Fabric identities are qualified by the MSP of the organization that issued them: <mspid>::<name>
+var fabricIdentityVerify = regexp.MustCompile(`^([a-zA-Z0-9._-]+)::([^\s]+)$`)
+
+func (f *Fabric) Name() string {
+	return "fabric"
+}
+
+func (f *Fabric) Init(ctx context.Context, prefix config.Prefix, callbacks blockchain.Callbacks) (err error) {
+
+	fabconnectConf := prefix.SubPrefix(FabconnectConfigKey)
+
+	f.ctx = log.WithLogField(ctx, "proto", "fabric")
+	f.callbacks = callbacks
+
+	if fabconnectConf.GetString(restclient.HTTPConfigURL) == "" {
+		return i18n.NewError(ctx, i18n.MsgMissingPluginConfig, "url", "blockchain.fabconnect")
+	}
+	defaultLedger := &fabLedger{
+		name:      "default",
+		channel:   fabconnectConf.GetString(FabconnectConfigDefaultChannel),
+		chaincode: fabconnectConf.GetString(FabconnectConfigChaincode),
+		topic:     fabconnectConf.GetString(FabconnectConfigTopic),
+	}
+	if defaultLedger.channel == "" {
+		return i18n.NewError(ctx, i18n.MsgMissingPluginConfig, "channel", "blockchain.fabconnect")
+	}
+	if defaultLedger.chaincode == "" {
+		return i18n.NewError(ctx, i18n.MsgMissingPluginConfig, "chaincode", "blockchain.fabconnect")
+	}
+	f.signer = fabconnectConf.GetString(FabconnectConfigSigner)
+	if f.signer == "" {
+		return i18n.NewError(ctx, i18n.MsgMissingPluginConfig, "signer", "blockchain.fabconnect")
+	}
+	if defaultLedger.topic == "" {
+		return i18n.NewError(ctx, i18n.MsgMissingPluginConfig, "topic", "blockchain.fabconnect")
+	}
+	f.ledgers = []*fabLedger{defaultLedger}
+	for i, ledgerConf := range fabconnectConf.GetObjectArray(FabconnectConfigLedgers) {
+		l, err := f.parseLedgerConfig(ctx, i, ledgerConf)
+		if err != nil {
+			return err
+		}
+		f.ledgers = append(f.ledgers, l)
+	}
+
+	f.client = restclient.New(f.ctx, fabconnectConf)
+	f.capabilities = &blockchain.Capabilities{
+		GlobalSequencer: true,
+	}
+
+	if fabconnectConf.GetString(wsclient.WSConfigKeyPath) == "" {
+		fabconnectConf.Set(wsclient.WSConfigKeyPath, "/ws")
+	}
+	for _, l := range f.ledgers {
+		if l.wsconn, err = wsclient.New(ctx, fabconnectConf, l.afterConnect); err != nil {
+			return err
+		}
+
+		if !fabconnectConf.GetBool(FabconnectConfigSkipEventstreamInit) {
+			if err = f.ensureEventStreams(fabconnectConf, l); err != nil {
+				return err
+			}
+		}
+
+		go f.eventLoop(l)
+	}
+
+	return nil
+}
+
+func (f *Fabric) parseLedgerConfig(ctx context.Context, i int, ledgerConf fftypes.JSONObject) (*fabLedger, error) {
+	confKey := fmt.Sprintf("blockchain.fabconnect.%s[%d]", FabconnectConfigLedgers, i)
+	l := &fabLedger{
+		name:      ledgerConf.GetString(FabconnectLedgerConfigName),
+		channel:   ledgerConf.GetString(FabconnectConfigDefaultChannel),
+		chaincode: ledgerConf.GetString(FabconnectConfigChaincode),
+		topic:     ledgerConf.GetString(FabconnectConfigTopic),
+	}
+	if l.chaincode == "" {
+		// Most networks will deploy the chaincode with the same name on each channel
+		l.chaincode = f.ledgers[0].chaincode
+	}
+	idStr := ledgerConf.GetString(FabconnectLedgerConfigID)
+	switch {
+	case idStr == "":
+		return nil, i18n.NewError(ctx, i18n.MsgMissingPluginConfig, FabconnectLedgerConfigID, confKey)
+	case l.name == "":
+		return nil, i18n.NewError(ctx, i18n.MsgMissingPluginConfig, FabconnectLedgerConfigName, confKey)
+	case l.channel == "":
+		return nil, i18n.NewError(ctx, i18n.MsgMissingPluginConfig, FabconnectConfigDefaultChannel, confKey)
+	case l.topic == "":
+		return nil, i18n.NewError(ctx, i18n.MsgMissingPluginConfig, FabconnectConfigTopic, confKey)
+	}
+	id, err := fftypes.ParseUUID(ctx, idStr)
+	if err != nil {
+		return nil, err
+	}
+	for _, existing := range f.ledgers {
+		if id.Equals(existing.id) || l.topic == existing.topic {
+			return nil, i18n.NewError(ctx, i18n.MsgDuplicateLedger, l.name)
+		}
+	}
+	l.id = id
+	return l, nil
+}
+
+func (f *Fabric) getLedger(ctx context.Context, ledgerID *fftypes.UUID) (*fabLedger, error) {
+	for _, l := range f.ledgers {
+		if ledgerID.Equals(l.id) {
+			return l, nil
+		}
+	}
+	return nil, i18n.NewError(ctx, i18n.MsgUnknownLedger, ledgerID)
+}
+
+func (f *Fabric) Start() error {
+	for _, l := range f.ledgers {
+		if err := l.wsconn.Connect(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *Fabric) TrackPendingBatchPins(ctx context.Context, txTrackingIDs []string) {
+	// fabconnect tracks the receipts of the transactions it submits, across restarts of firefly
+}
+
+func (f *Fabric) Capabilities() *blockchain.Capabilities {
+	return f.capabilities
+}
+
+func (f *Fabric) Status() fftypes.JSONObject {
+	ledgers := make([]interface{}, len(f.ledgers))
+	for i, l := range f.ledgers {
+		streamID := ""
+		if l.initInfo.stream != nil {
+			streamID = l.initInfo.stream.ID
+		}
+		subIDs := make([]interface{}, len(l.initInfo.subs))
+		for j, sub := range l.initInfo.subs {
+			subIDs[j] = sub.ID
+		}
+		ledgers[i] = map[string]interface{}{
+			"id":            l.id,
+			"name":          l.name,
+			"channel":       l.channel,
+			"topic":         l.topic,
+			"eventStream":   streamID,
+			"subscriptions": subIDs,
+		}
+	}
+	return fftypes.JSONObject{"ledgers": ledgers}
+}
+
+func (f *Fabric) ensureEventStreams(fabconnectConf config.Prefix, l *fabLedger) error {
+
+	var existingStreams []*eventStream
+	res, err := f.client.R().SetContext(f.ctx).SetResult(&existingStreams).Get("/eventstreams")
+	if err != nil || !res.IsSuccess() {
+		return restclient.WrapRestErr(f.ctx, res, err, i18n.MsgFabconnectRESTErr)
+	}
+
+	for _, stream := range existingStreams {
+		if stream.WebSocket.Topic == l.topic {
+			l.initInfo.stream = stream
+		}
+	}
+
+	if l.initInfo.stream == nil {
+		newStream := eventStream{
+			Name:           l.topic,
+			ErrorHandling:  "block",
+			BatchSize:      fabconnectConf.GetUint(FabconnectConfigBatchSize),
+			BatchTimeoutMS: uint(fabconnectConf.GetDuration(FabconnectConfigBatchTimeout).Milliseconds()),
+			Type:           "websocket",
+		}
+		newStream.WebSocket.Topic = l.topic
+		res, err = f.client.R().SetContext(f.ctx).SetBody(&newStream).SetResult(&newStream).Post("/eventstreams")
+		if err != nil || !res.IsSuccess() {
+			return restclient.WrapRestErr(f.ctx, res, err, i18n.MsgFabconnectRESTErr)
+		}
+		l.initInfo.stream = &newStream
+	}
+
+	log.L(f.ctx).Infof("Event stream for ledger '%s': %s", l.name, l.initInfo.stream.ID)
+
+	return f.ensureSusbscriptions(l)
+}
+
+func (l *fabLedger) afterConnect(ctx context.Context, w wsclient.WSClient) error {
+	// Send a subscribe to our topic after each connect/reconnect
+	b, _ := json.Marshal(&fabWSCommandPayload{
+		Type:  "listen",
+		Topic: l.topic,
+	})
+	err := w.Send(ctx, b)
+	if err == nil {
+		b, _ = json.Marshal(&fabWSCommandPayload{
+			Type: "listenreplies",
+		})
+		err = w.Send(ctx, b)
+	}
+	return err
+}
+
+func (f *Fabric) ensureSusbscriptions(l *fabLedger) error {
+	streamID := l.initInfo.stream.ID
+
+	var existingSubs []*subscription
+	res, err := f.client.R().SetContext(f.ctx).SetResult(&existingSubs).Get("/subscriptions")
+	if err != nil || !res.IsSuccess() {
+		return restclient.WrapRestErr(f.ctx, res, err, i18n.MsgFabconnectRESTErr)
+	}
+
+	var sub *subscription
+	for _, s := range existingSubs {
+		if s.Name == batchPinEventName && s.Stream == streamID {
+			sub = s
+		}
+	}
+
+	if sub == nil {
+		newSub := subscription{
+			Name:      batchPinEventName,
+			Stream:    streamID,
+			Channel:   l.channel,
+			Signer:    f.signer,
+			FromBlock: "oldest",
+			Filter: subscriptionFilter{
+				ChaincodeID: l.chaincode,
+				EventFilter: batchPinEventName,
+			},
+		}
+		res, err = f.client.R().
+			SetContext(f.ctx).
+			SetBody(&newSub).
+			SetResult(&newSub).
+			Post("/subscriptions")
+		if err != nil || !res.IsSuccess() {
+			return restclient.WrapRestErr(f.ctx, res, err, i18n.MsgFabconnectRESTErr)
+		}
+		sub = &newSub
+	}
+
+	log.L(f.ctx).Infof("%s subscription for ledger '%s': %s", batchPinEventName, l.name, sub.ID)
+	l.initInfo.subs = append(l.initInfo.subs, sub)
+	return nil
+}
+
+func hexFormatB32(b *fftypes.Bytes32) string {
+	if b == nil {
+		return "0x0000000000000000000000000000000000000000000000000000000000000000"
+	}
+	return "0x" + hex.EncodeToString(b[0:32])
+}
+
+func (f *Fabric) handleBatchPinEvent(ctx context.Context, ledgerID *fftypes.UUID, msgJSON fftypes.JSONObject) (err error) {
+	sTransactionID := msgJSON.GetString("transactionId")
+	sPayload := msgJSON.GetString("payload")
+
+	var event fabBatchPinEvent
+	payload, err := base64.StdEncoding.DecodeString(sPayload)
+	if err == nil {
+		err = json.Unmarshal(payload, &event)
+	}
+	if err != nil {
+		log.L(ctx).Errorf("BatchPin event is not valid - bad payload (%s): %+v", err, msgJSON)
+		return nil // move on
+	}
+
+	if sTransactionID == "" ||
+		event.Signer == "" ||
+		event.UUIDs == "" ||
+		event.BatchHash == "" ||
+		event.PayloadRef == "" {
+		log.L(ctx).Errorf("BatchPin event is not valid - missing data: %+v", msgJSON)
+		return nil // move on
+	}
+
+	signer, err := f.validateFabricIdentity(ctx, event.Signer)
+	if err != nil {
+		log.L(ctx).Errorf("BatchPin event is not valid - bad signer (%s): %+v", err, msgJSON)
+		return nil // move on
+	}
+
+	uuids, err := fftypes.ParseBytes32(ctx, event.UUIDs)
+	if err != nil {
+		log.L(ctx).Errorf("BatchPin event is not valid - bad uuids (%s): %+v", err, msgJSON)
+		return nil // move on
+	}
+	var txnID fftypes.UUID
+	copy(txnID[:], uuids[0:16])
+	var batchID fftypes.UUID
+	copy(batchID[:], uuids[16:32])
+
+	batchHash, err := fftypes.ParseBytes32(ctx, event.BatchHash)
+	if err != nil {
+		log.L(ctx).Errorf("BatchPin event is not valid - bad batchHash (%s): %+v", err, msgJSON)
+		return nil // move on
+	}
+
+	payloadRefOrNil, err := fftypes.ParseBytes32(ctx, event.PayloadRef)
+	if err != nil {
+		log.L(ctx).Errorf("BatchPin event is not valid - bad payloadRef (%s): %+v", err, msgJSON)
+		return nil // move on
+	}
+	if *payloadRefOrNil == zeroBytes32 {
+		payloadRefOrNil = nil
+	}
+
+	contexts := make([]*fftypes.Bytes32, len(event.Contexts))
+	for i, sHash := range event.Contexts {
+		contexts[i], err = fftypes.ParseBytes32(ctx, sHash)
+		if err != nil {
+			log.L(ctx).Errorf("BatchPin event is not valid - bad pin %d (%s): %+v", i, err, msgJSON)
+			return nil // move on
+		}
+	}
+
+	batch := &blockchain.BatchPin{
+		Namespace:      event.Namespace,
+		TransactionID:  &txnID,
+		BatchID:        &batchID,
+		BatchHash:      batchHash,
+		BatchPaylodRef: payloadRefOrNil,
+		Contexts:       contexts,
+	}
+
+	// Store the decoded event in the additional info, in place of the base64 payload
+	msgJSON["payload"] = &event
+
+	// If there's an error dispatching the event, we must return the error and shutdown
+	return f.callbacks.BatchPinComplete(ledgerID, batch, signer, sTransactionID, msgJSON)
+}
+
+func (f *Fabric) handleReceipt(ctx context.Context, reply fftypes.JSONObject) error {
+	l := log.L(ctx)
+
+	headers := reply.GetObject("headers")
+	requestID := headers.GetString("requestId")
+	replyType := headers.GetString("type")
+	txID := reply.GetString("transactionId")
+	message := reply.GetString("errorMessage")
+	if requestID == "" || replyType == "" {
+		l.Errorf("Reply cannot be processed: %+v", reply)
+		return nil // Swallow this and move on
+	}
+	updateType := fftypes.OpStatusSucceeded
+	if replyType != "TransactionSuccess" {
+		updateType = fftypes.OpStatusFailed
+	}
+	l.Infof("Fabconnect '%s' reply tx=%s (request=%s) %s", replyType, txID, requestID, message)
+	return f.callbacks.TxSubmissionUpdate(requestID, updateType, txID, message, reply)
+}
+
+func (f *Fabric) handleMessageBatch(ctx context.Context, ledgerID *fftypes.UUID, messages []interface{}) error {
+	l := log.L(ctx)
+
+	for i, msgI := range messages {
+		msgMap, ok := msgI.(map[string]interface{})
+		if !ok {
+			l.Errorf("Message cannot be parsed as JSON: %+v", msgI)
+			return nil // Swallow this and move on
+		}
+		msgJSON := fftypes.JSONObject(msgMap)
+
+		l1 := l.WithField("fabmsgidx", i)
+		ctx1 := log.WithLogger(ctx, l1)
+		eventName := msgJSON.GetString("eventName")
+		l1.Infof("Received '%s' message", eventName)
+		l1.Tracef("Message: %+v", msgJSON)
+
+		switch eventName {
+		case batchPinEventName:
+			if err := f.handleBatchPinEvent(ctx1, ledgerID, msgJSON); err != nil {
+				return err
+			}
+		default:
+			l.Infof("Ignoring event with unknown name: %s", eventName)
+		}
+	}
+
+	return nil
+}
+
+func (f *Fabric) eventLoop(ledger *fabLedger) {
+	l := log.L(f.ctx).WithField("role", "event-loop").WithField("ledger", ledger.name)
+	ctx := log.WithLogger(f.ctx, l)
+	ack, _ := json.Marshal(map[string]string{"type": "ack", "topic": ledger.topic})
+	for {
+		select {
+		case <-ctx.Done():
+			l.Debugf("Event loop exiting (context cancelled)")
+			return
+		case msgBytes, ok := <-ledger.wsconn.Receive():
+			if !ok {
+				l.Debugf("Event loop exiting (receive channel closed)")
+				return
+			}
+
+			var msgParsed interface{}
+			err := json.Unmarshal(msgBytes, &msgParsed)
+			if err != nil {
+				l.Errorf("Message cannot be parsed as JSON: %s\n%s", err, string(msgBytes))
+				continue // Swallow this and move on
+			}
+			switch msgTyped := msgParsed.(type) {
+			case []interface{}:
+				err = f.handleMessageBatch(ctx, ledger.id, msgTyped)
+				if err == nil {
+					err = ledger.wsconn.Send(ctx, ack)
+				}
+			case map[string]interface{}:
+				err = f.handleReceipt(ctx, fftypes.JSONObject(msgTyped))
+			default:
+				l.Errorf("Message unexpected: %+v", msgTyped)
+				continue
+			}
+
+			// Send the ack - only fails if shutting down
+			if err != nil {
+				l.Errorf("Event loop exiting: %s", err)
+				return
+			}
+		}
+	}
+}
+
+func (f *Fabric) VerifyIdentitySyntax(ctx context.Context, identity *fftypes.Identity) (err error) {
+	identity.OnChain, err = f.validateFabricIdentity(ctx, identity.OnChain)
+	return
+}
+
+func (f *Fabric) validateFabricIdentity(ctx context.Context, identity string) (string, error) {
+	if !fabricIdentityVerify.MatchString(identity) {
+		return "", i18n.NewError(ctx, i18n.MsgInvalidFabricIdentity, identity)
+	}
+	return identity, nil
+}
+
+func (f *Fabric) SubmitBatchPin(ctx context.Context, ledgerID *fftypes.UUID, identity *fftypes.Identity, batch *blockchain.BatchPin) (txTrackingID string, err error) {
+	l, err := f.getLedger(ctx, ledgerID)
+	if err != nil {
+		return "", err
+	}
+	// The MSP is implied by the fabconnect wallet entry for the signer name
+	signer := fabricIdentityVerify.FindStringSubmatch(identity.OnChain)
+	if signer == nil {
+		return "", i18n.NewError(ctx, i18n.MsgInvalidFabricIdentity, identity.OnChain)
+	}
+	hashes := make([]string, len(batch.Contexts))
+	for i, v := range batch.Contexts {
+		hashes[i] = hexFormatB32(v)
+	}
+	contextsJSON, _ := json.Marshal(hashes)
+	var uuids fftypes.Bytes32
+	copy(uuids[0:16], (*batch.TransactionID)[:])
+	copy(uuids[16:32], (*batch.BatchID)[:])
+	input := &fabTxInput{
+		Headers: fabTxInputHeaders{
+			Type:      "SendTransaction",
+			Signer:    signer[2],
+			Channel:   l.channel,
+			Chaincode: l.chaincode,
+		},
+		Func: batchPinFunction,
+		Args: []string{
+			batch.Namespace,
+			hexFormatB32(&uuids),
+			hexFormatB32(batch.BatchHash),
+			hexFormatB32(batch.BatchPaylodRef),
+			string(contextsJSON),
+		},
+	}
+	tx := &asyncTXSubmission{}
+	res, err := f.client.R().
+		SetContext(ctx).
+		SetQueryParam("fly-sync", "false").
+		SetBody(input).
+		SetResult(tx).
+		Post("/transactions")
+	if err != nil || !res.IsSuccess() {
+		return "", restclient.WrapRestErr(ctx, res, err, i18n.MsgFabconnectRESTErr)
+	}
+	return tx.ID, nil
+}
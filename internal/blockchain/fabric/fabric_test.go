@@ -0,0 +1,859 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fabric
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/hyperledger-labs/firefly/internal/config"
+	"github.com/hyperledger-labs/firefly/internal/log"
+	"github.com/hyperledger-labs/firefly/internal/restclient"
+	"github.com/hyperledger-labs/firefly/internal/wsclient"
+	"github.com/hyperledger-labs/firefly/mocks/blockchainmocks"
+	"github.com/hyperledger-labs/firefly/mocks/wsmocks"
+	"github.com/hyperledger-labs/firefly/pkg/blockchain"
+	"github.com/hyperledger-labs/firefly/pkg/fftypes"
+	"github.com/jarcoal/httpmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+var utConfPrefix = config.NewPluginConfig("fab_unit_tests")
+var utFabconnectConf = utConfPrefix.SubPrefix(FabconnectConfigKey)
+
+func resetConf() {
+	config.Reset()
+	f := &Fabric{}
+	f.InitPrefix(utConfPrefix)
+}
+
+func setValidConf(url string) {
+	resetConf()
+	utFabconnectConf.Set(restclient.HTTPConfigURL, url)
+	utFabconnectConf.Set(FabconnectConfigDefaultChannel, "firefly")
+	utFabconnectConf.Set(FabconnectConfigChaincode, "fireflych")
+	utFabconnectConf.Set(FabconnectConfigSigner, "signer001")
+	utFabconnectConf.Set(FabconnectConfigTopic, "topic1")
+}
+
+func newTestFabric() *Fabric {
+	return &Fabric{
+		ctx:    context.Background(),
+		client: resty.New().SetHostURL("http://localhost:12345"),
+		signer: "signer001",
+		ledgers: []*fabLedger{
+			{channel: "firefly", chaincode: "fireflych", topic: "topic1"},
+		},
+	}
+}
+
+func batchPinPayload(event *fabBatchPinEvent) string {
+	b, _ := json.Marshal(event)
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+func validBatchPinEvent() *fabBatchPinEvent {
+	return &fabBatchPinEvent{
+		Signer:     "Org1MSP::user1",
+		Timestamp:  1620576488,
+		Namespace:  "ns1",
+		UUIDs:      "0xe19af8b390604051812d7597d19adfb9847d3bfd074249efb65d3fed15f5b0a6",
+		BatchHash:  "0xd71eb138d74c229a388eb0e1abc03f4c7cbb21d4fc4b839fbf0ec73e4263f6be",
+		PayloadRef: "0xeda586bd8f3c4bc1db5c4b5755113b9a9b4174abe28679fdbc219129400dd7ae",
+		Contexts: []string{
+			"0x68e4da79f805bca5b912bcda9c63d03e6e867108dabb9b944109aea541ef522a",
+			"0x19b82093de5ce92a01e333048e877e2374354bf846dd034864ef6ffbd6438771",
+		},
+	}
+}
+
+func chaincodeEvents(events ...*fabBatchPinEvent) []interface{} {
+	var msgs []interface{}
+	for i, event := range events {
+		msgs = append(msgs, map[string]interface{}{
+			"chaincodeId":      "fireflych",
+			"blockNumber":      float64(91),
+			"transactionId":    fmt.Sprintf("ce79343000e851a0c742f63a733ce19a5f8b9ce1c719b6cecd14f01bcf81fff%d", i),
+			"transactionIndex": float64(i),
+			"eventIndex":       float64(0),
+			"eventName":        "BatchPin",
+			"payload":          batchPinPayload(event),
+		})
+	}
+	return msgs
+}
+
+func TestInitMissingURL(t *testing.T) {
+	f := &Fabric{}
+	resetConf()
+	err := f.Init(context.Background(), utConfPrefix, &blockchainmocks.Callbacks{})
+	assert.Regexp(t, "FF10138.*url", err)
+}
+
+func TestInitMissingChannel(t *testing.T) {
+	f := &Fabric{}
+	setValidConf("http://localhost:12345")
+	utFabconnectConf.Set(FabconnectConfigDefaultChannel, "")
+	err := f.Init(context.Background(), utConfPrefix, &blockchainmocks.Callbacks{})
+	assert.Regexp(t, "FF10138.*channel", err)
+}
+
+func TestInitMissingChaincode(t *testing.T) {
+	f := &Fabric{}
+	setValidConf("http://localhost:12345")
+	utFabconnectConf.Set(FabconnectConfigChaincode, "")
+	err := f.Init(context.Background(), utConfPrefix, &blockchainmocks.Callbacks{})
+	assert.Regexp(t, "FF10138.*chaincode", err)
+}
+
+func TestInitMissingSigner(t *testing.T) {
+	f := &Fabric{}
+	setValidConf("http://localhost:12345")
+	utFabconnectConf.Set(FabconnectConfigSigner, "")
+	err := f.Init(context.Background(), utConfPrefix, &blockchainmocks.Callbacks{})
+	assert.Regexp(t, "FF10138.*signer", err)
+}
+
+func TestInitMissingTopic(t *testing.T) {
+	f := &Fabric{}
+	setValidConf("http://localhost:12345")
+	utFabconnectConf.Set(FabconnectConfigTopic, "")
+	err := f.Init(context.Background(), utConfPrefix, &blockchainmocks.Callbacks{})
+	assert.Regexp(t, "FF10138.*topic", err)
+}
+
+func TestInitAllNewStreamsAndWSEvent(t *testing.T) {
+
+	log.SetLevel("trace")
+	f := &Fabric{}
+
+	toServer, fromServer, wsURL, done := wsclient.NewTestWSServer(nil)
+	defer done()
+
+	mockedClient := &http.Client{}
+	httpmock.ActivateNonDefault(mockedClient)
+	defer httpmock.DeactivateAndReset()
+
+	u, _ := url.Parse(wsURL)
+	u.Scheme = "http"
+	httpURL := u.String()
+
+	httpmock.RegisterResponder("GET", fmt.Sprintf("%s/eventstreams", httpURL),
+		httpmock.NewJsonResponderOrPanic(200, []eventStream{}))
+	httpmock.RegisterResponder("POST", fmt.Sprintf("%s/eventstreams", httpURL),
+		httpmock.NewJsonResponderOrPanic(200, eventStream{ID: "es12345"}))
+	httpmock.RegisterResponder("GET", fmt.Sprintf("%s/subscriptions", httpURL),
+		httpmock.NewJsonResponderOrPanic(200, []subscription{}))
+	httpmock.RegisterResponder("POST", fmt.Sprintf("%s/subscriptions", httpURL),
+		func(req *http.Request) (*http.Response, error) {
+			var body subscription
+			json.NewDecoder(req.Body).Decode(&body)
+			assert.Equal(t, "es12345", body.Stream)
+			assert.Equal(t, "firefly", body.Channel)
+			assert.Equal(t, "signer001", body.Signer)
+			assert.Equal(t, "fireflych", body.Filter.ChaincodeID)
+			assert.Equal(t, "BatchPin", body.Filter.EventFilter)
+			body.ID = "sub12345"
+			return httpmock.NewJsonResponderOrPanic(200, &body)(req)
+		})
+
+	setValidConf(httpURL)
+	utFabconnectConf.Set(restclient.HTTPCustomClient, mockedClient)
+
+	err := f.Init(context.Background(), utConfPrefix, &blockchainmocks.Callbacks{})
+	assert.NoError(t, err)
+
+	assert.Equal(t, "fabric", f.Name())
+	assert.Equal(t, 4, httpmock.GetTotalCallCount())
+	assert.Equal(t, "es12345", f.ledgers[0].initInfo.stream.ID)
+	assert.Equal(t, "sub12345", f.ledgers[0].initInfo.subs[0].ID)
+	assert.True(t, f.Capabilities().GlobalSequencer)
+
+	err = f.Start()
+	assert.NoError(t, err)
+
+	startupMessage := <-toServer
+	assert.Equal(t, `{"type":"listen","topic":"topic1"}`, startupMessage)
+	startupMessage = <-toServer
+	assert.Equal(t, `{"type":"listenreplies"}`, startupMessage)
+	fromServer <- `[]` // empty batch, will be ignored, but acked
+	reply := <-toServer
+	assert.Equal(t, `{"topic":"topic1","type":"ack"}`, reply)
+
+	// Bad data will be ignored
+	fromServer <- `!json`
+	fromServer <- `{"not": "a reply"}`
+	fromServer <- `42`
+
+}
+
+func TestWSInitFail(t *testing.T) {
+
+	f := &Fabric{}
+
+	setValidConf("!!!://")
+	utFabconnectConf.Set(FabconnectConfigSkipEventstreamInit, true)
+
+	err := f.Init(context.Background(), utConfPrefix, &blockchainmocks.Callbacks{})
+	assert.Regexp(t, "FF10162", err)
+
+}
+
+func TestInitLedgersConfigErrors(t *testing.T) {
+	for _, tc := range []struct {
+		ledger fftypes.JSONObject
+		err    string
+	}{
+		{fftypes.JSONObject{"name": "ledger2", "channel": "channel2", "topic": "topic2"}, "FF10138.*id.*ledgers\\[0\\]"},
+		{fftypes.JSONObject{"id": fftypes.NewUUID().String(), "channel": "channel2", "topic": "topic2"}, "FF10138.*name"},
+		{fftypes.JSONObject{"id": fftypes.NewUUID().String(), "name": "ledger2", "topic": "topic2"}, "FF10138.*channel"},
+		{fftypes.JSONObject{"id": fftypes.NewUUID().String(), "name": "ledger2", "channel": "channel2"}, "FF10138.*topic"},
+		{fftypes.JSONObject{"id": "!uuid", "name": "ledger2", "channel": "channel2", "topic": "topic2"}, "FF10142"},
+		{fftypes.JSONObject{"id": fftypes.NewUUID().String(), "name": "ledger2", "channel": "channel2", "topic": "topic1"}, "FF10262"},
+	} {
+		f := &Fabric{}
+		setValidConf("http://localhost:12345")
+		utFabconnectConf.Set(FabconnectConfigLedgers, []interface{}{map[string]interface{}(tc.ledger)})
+
+		err := f.Init(context.Background(), utConfPrefix, &blockchainmocks.Callbacks{})
+		assert.Regexp(t, tc.err, err)
+	}
+}
+
+func TestInitMultipleLedgers(t *testing.T) {
+
+	f := &Fabric{}
+
+	mockedClient := &http.Client{}
+	httpmock.ActivateNonDefault(mockedClient)
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "http://localhost:12345/eventstreams",
+		httpmock.NewJsonResponderOrPanic(200, []eventStream{
+			{ID: "es12345", WebSocket: eventStreamWebsocket{Topic: "topic1"}},
+			{ID: "es67890", WebSocket: eventStreamWebsocket{Topic: "topic2"}},
+		}))
+	httpmock.RegisterResponder("GET", "http://localhost:12345/subscriptions",
+		httpmock.NewJsonResponderOrPanic(200, []subscription{
+			{ID: "sub12345", Name: "BatchPin", Stream: "es12345"},
+		}))
+	httpmock.RegisterResponder("POST", "http://localhost:12345/subscriptions",
+		func(req *http.Request) (*http.Response, error) {
+			var body subscription
+			json.NewDecoder(req.Body).Decode(&body)
+			assert.Equal(t, "es67890", body.Stream)
+			assert.Equal(t, "channel2", body.Channel)
+			assert.Equal(t, "fireflych", body.Filter.ChaincodeID)
+			body.ID = "sub67890"
+			return httpmock.NewJsonResponderOrPanic(200, &body)(req)
+		})
+
+	ledgerID := fftypes.NewUUID()
+	setValidConf("http://localhost:12345")
+	utFabconnectConf.Set(restclient.HTTPCustomClient, mockedClient)
+	utFabconnectConf.Set(FabconnectConfigLedgers, []interface{}{
+		map[string]interface{}{
+			"id":      ledgerID.String(),
+			"name":    "ledger2",
+			"channel": "channel2",
+			"topic":   "topic2",
+		},
+	})
+
+	err := f.Init(context.Background(), utConfPrefix, &blockchainmocks.Callbacks{})
+	assert.NoError(t, err)
+
+	assert.Len(t, f.ledgers, 2)
+	assert.Nil(t, f.ledgers[0].id)
+	assert.Equal(t, "sub12345", f.ledgers[0].initInfo.subs[0].ID)
+	assert.Equal(t, *ledgerID, *f.ledgers[1].id)
+	assert.Equal(t, "fireflych", f.ledgers[1].chaincode)
+	assert.Equal(t, "es67890", f.ledgers[1].initInfo.stream.ID)
+	assert.Equal(t, "sub67890", f.ledgers[1].initInfo.subs[0].ID)
+
+}
+
+func TestWSConnectFail(t *testing.T) {
+
+	wsm := &wsmocks.WSClient{}
+	f := &Fabric{
+		ctx:     context.Background(),
+		ledgers: []*fabLedger{{wsconn: wsm}},
+	}
+	wsm.On("Connect").Return(fmt.Errorf("pop"))
+
+	err := f.Start()
+	assert.EqualError(t, err, "pop")
+}
+
+func TestInitAllExistingStreams(t *testing.T) {
+
+	f := &Fabric{}
+
+	mockedClient := &http.Client{}
+	httpmock.ActivateNonDefault(mockedClient)
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "http://localhost:12345/eventstreams",
+		httpmock.NewJsonResponderOrPanic(200, []eventStream{{ID: "es12345", WebSocket: eventStreamWebsocket{Topic: "topic1"}}}))
+	httpmock.RegisterResponder("GET", "http://localhost:12345/subscriptions",
+		httpmock.NewJsonResponderOrPanic(200, []subscription{
+			{ID: "sub12345", Name: "BatchPin", Stream: "es12345"},
+		}))
+
+	setValidConf("http://localhost:12345")
+	utFabconnectConf.Set(restclient.HTTPCustomClient, mockedClient)
+
+	err := f.Init(context.Background(), utConfPrefix, &blockchainmocks.Callbacks{})
+
+	assert.Equal(t, 2, httpmock.GetTotalCallCount())
+	assert.Equal(t, "es12345", f.ledgers[0].initInfo.stream.ID)
+	assert.Equal(t, "sub12345", f.ledgers[0].initInfo.subs[0].ID)
+
+	assert.NoError(t, err)
+
+}
+
+func TestStreamQueryError(t *testing.T) {
+
+	f := &Fabric{}
+
+	mockedClient := &http.Client{}
+	httpmock.ActivateNonDefault(mockedClient)
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "http://localhost:12345/eventstreams",
+		httpmock.NewStringResponder(500, `pop`))
+
+	setValidConf("http://localhost:12345")
+	utFabconnectConf.Set(restclient.HTTPConfigRetryEnabled, false)
+	utFabconnectConf.Set(restclient.HTTPCustomClient, mockedClient)
+
+	err := f.Init(context.Background(), utConfPrefix, &blockchainmocks.Callbacks{})
+
+	assert.Regexp(t, "FF10259", err)
+	assert.Regexp(t, "pop", err)
+
+}
+
+func TestStreamCreateError(t *testing.T) {
+
+	f := &Fabric{}
+
+	mockedClient := &http.Client{}
+	httpmock.ActivateNonDefault(mockedClient)
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "http://localhost:12345/eventstreams",
+		httpmock.NewJsonResponderOrPanic(200, []eventStream{}))
+	httpmock.RegisterResponder("POST", "http://localhost:12345/eventstreams",
+		httpmock.NewStringResponder(500, `pop`))
+
+	setValidConf("http://localhost:12345")
+	utFabconnectConf.Set(restclient.HTTPConfigRetryEnabled, false)
+	utFabconnectConf.Set(restclient.HTTPCustomClient, mockedClient)
+
+	err := f.Init(context.Background(), utConfPrefix, &blockchainmocks.Callbacks{})
+
+	assert.Regexp(t, "FF10259", err)
+	assert.Regexp(t, "pop", err)
+
+}
+
+func TestSubQueryError(t *testing.T) {
+
+	f := &Fabric{}
+
+	mockedClient := &http.Client{}
+	httpmock.ActivateNonDefault(mockedClient)
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "http://localhost:12345/eventstreams",
+		httpmock.NewJsonResponderOrPanic(200, []eventStream{}))
+	httpmock.RegisterResponder("POST", "http://localhost:12345/eventstreams",
+		httpmock.NewJsonResponderOrPanic(200, eventStream{ID: "es12345"}))
+	httpmock.RegisterResponder("GET", "http://localhost:12345/subscriptions",
+		httpmock.NewStringResponder(500, `pop`))
+
+	setValidConf("http://localhost:12345")
+	utFabconnectConf.Set(restclient.HTTPConfigRetryEnabled, false)
+	utFabconnectConf.Set(restclient.HTTPCustomClient, mockedClient)
+
+	err := f.Init(context.Background(), utConfPrefix, &blockchainmocks.Callbacks{})
+
+	assert.Regexp(t, "FF10259", err)
+	assert.Regexp(t, "pop", err)
+
+}
+
+func TestSubQueryCreateError(t *testing.T) {
+
+	f := &Fabric{}
+
+	mockedClient := &http.Client{}
+	httpmock.ActivateNonDefault(mockedClient)
+	defer httpmock.DeactivateAndReset()
+
+	httpmock.RegisterResponder("GET", "http://localhost:12345/eventstreams",
+		httpmock.NewJsonResponderOrPanic(200, []eventStream{}))
+	httpmock.RegisterResponder("POST", "http://localhost:12345/eventstreams",
+		httpmock.NewJsonResponderOrPanic(200, eventStream{ID: "es12345"}))
+	httpmock.RegisterResponder("GET", "http://localhost:12345/subscriptions",
+		httpmock.NewJsonResponderOrPanic(200, []subscription{}))
+	httpmock.RegisterResponder("POST", "http://localhost:12345/subscriptions",
+		httpmock.NewStringResponder(500, `pop`))
+
+	setValidConf("http://localhost:12345")
+	utFabconnectConf.Set(restclient.HTTPConfigRetryEnabled, false)
+	utFabconnectConf.Set(restclient.HTTPCustomClient, mockedClient)
+
+	err := f.Init(context.Background(), utConfPrefix, &blockchainmocks.Callbacks{})
+
+	assert.Regexp(t, "FF10259", err)
+	assert.Regexp(t, "pop", err)
+
+}
+
+func TestSubmitBatchPinOK(t *testing.T) {
+
+	f := newTestFabric()
+	httpmock.ActivateNonDefault(f.client.GetClient())
+	defer httpmock.DeactivateAndReset()
+
+	batch := &blockchain.BatchPin{
+		Namespace:      "ns1",
+		TransactionID:  fftypes.MustParseUUID("9ffc50ff-6bfe-4502-adc7-93aea54cc059"),
+		BatchID:        fftypes.MustParseUUID("c5df767c-fe44-4e03-8eb5-1c5523097db5"),
+		BatchHash:      fftypes.NewRandB32(),
+		BatchPaylodRef: fftypes.NewRandB32(),
+		Contexts: []*fftypes.Bytes32{
+			fftypes.NewRandB32(),
+			fftypes.NewRandB32(),
+		},
+	}
+
+	httpmock.RegisterResponder("POST", `http://localhost:12345/transactions`,
+		func(req *http.Request) (*http.Response, error) {
+			var body fabTxInput
+			json.NewDecoder(req.Body).Decode(&body)
+			assert.Equal(t, "false", req.FormValue("fly-sync"))
+			assert.Equal(t, "SendTransaction", body.Headers.Type)
+			assert.Equal(t, "user1", body.Headers.Signer)
+			assert.Equal(t, "firefly", body.Headers.Channel)
+			assert.Equal(t, "fireflych", body.Headers.Chaincode)
+			assert.Equal(t, "PinBatch", body.Func)
+			assert.Equal(t, "ns1", body.Args[0])
+			assert.Equal(t, "0x9ffc50ff6bfe4502adc793aea54cc059c5df767cfe444e038eb51c5523097db5", body.Args[1])
+			assert.Equal(t, hexFormatB32(batch.BatchHash), body.Args[2])
+			assert.Equal(t, hexFormatB32(batch.BatchPaylodRef), body.Args[3])
+			var contexts []string
+			json.Unmarshal([]byte(body.Args[4]), &contexts)
+			assert.Equal(t, []string{hexFormatB32(batch.Contexts[0]), hexFormatB32(batch.Contexts[1])}, contexts)
+			return httpmock.NewJsonResponderOrPanic(200, asyncTXSubmission{ID: "abcd1234"})(req)
+		})
+
+	txid, err := f.SubmitBatchPin(context.Background(), nil, &fftypes.Identity{OnChain: "Org1MSP::user1"}, batch)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "abcd1234", txid)
+
+}
+
+func TestSubmitBatchNilPayloadRef(t *testing.T) {
+
+	f := newTestFabric()
+	httpmock.ActivateNonDefault(f.client.GetClient())
+	defer httpmock.DeactivateAndReset()
+
+	batch := &blockchain.BatchPin{
+		Namespace:     "ns1",
+		TransactionID: fftypes.NewUUID(),
+		BatchID:       fftypes.NewUUID(),
+		BatchHash:     fftypes.NewRandB32(),
+		Contexts:      []*fftypes.Bytes32{},
+	}
+
+	httpmock.RegisterResponder("POST", `http://localhost:12345/transactions`,
+		func(req *http.Request) (*http.Response, error) {
+			var body fabTxInput
+			json.NewDecoder(req.Body).Decode(&body)
+			assert.Equal(t, "0x0000000000000000000000000000000000000000000000000000000000000000", body.Args[3])
+			assert.Equal(t, "[]", body.Args[4])
+			return httpmock.NewJsonResponderOrPanic(200, asyncTXSubmission{ID: "abcd1234"})(req)
+		})
+
+	txid, err := f.SubmitBatchPin(context.Background(), nil, &fftypes.Identity{OnChain: "Org1MSP::user1"}, batch)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "abcd1234", txid)
+
+}
+
+func TestSubmitBatchPinLedger(t *testing.T) {
+
+	f := newTestFabric()
+	ledgerID := fftypes.NewUUID()
+	f.ledgers = append(f.ledgers, &fabLedger{id: ledgerID, channel: "channel2", chaincode: "fireflych2", topic: "topic2"})
+	httpmock.ActivateNonDefault(f.client.GetClient())
+	defer httpmock.DeactivateAndReset()
+
+	batch := &blockchain.BatchPin{
+		Namespace:     "ns1",
+		TransactionID: fftypes.NewUUID(),
+		BatchID:       fftypes.NewUUID(),
+		BatchHash:     fftypes.NewRandB32(),
+		Contexts:      []*fftypes.Bytes32{},
+	}
+
+	httpmock.RegisterResponder("POST", `http://localhost:12345/transactions`,
+		func(req *http.Request) (*http.Response, error) {
+			var body fabTxInput
+			json.NewDecoder(req.Body).Decode(&body)
+			assert.Equal(t, "channel2", body.Headers.Channel)
+			assert.Equal(t, "fireflych2", body.Headers.Chaincode)
+			return httpmock.NewJsonResponderOrPanic(200, asyncTXSubmission{ID: "abcd1234"})(req)
+		})
+
+	txid, err := f.SubmitBatchPin(context.Background(), ledgerID, &fftypes.Identity{OnChain: "Org1MSP::user1"}, batch)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "abcd1234", txid)
+
+}
+
+func TestSubmitBatchPinUnknownLedger(t *testing.T) {
+
+	f := newTestFabric()
+
+	_, err := f.SubmitBatchPin(context.Background(), fftypes.NewUUID(), &fftypes.Identity{OnChain: "Org1MSP::user1"}, &blockchain.BatchPin{})
+	assert.Regexp(t, "FF10261", err)
+
+}
+
+func TestSubmitBatchPinBadIdentity(t *testing.T) {
+
+	f := newTestFabric()
+	batch := &blockchain.BatchPin{
+		TransactionID: fftypes.NewUUID(),
+		BatchID:       fftypes.NewUUID(),
+		BatchHash:     fftypes.NewRandB32(),
+	}
+
+	_, err := f.SubmitBatchPin(context.Background(), nil, &fftypes.Identity{OnChain: "user1"}, batch)
+	assert.Regexp(t, "FF10260", err)
+
+}
+
+func TestSubmitBatchPinFail(t *testing.T) {
+
+	f := newTestFabric()
+	httpmock.ActivateNonDefault(f.client.GetClient())
+	defer httpmock.DeactivateAndReset()
+
+	batch := &blockchain.BatchPin{
+		TransactionID:  fftypes.NewUUID(),
+		BatchID:        fftypes.NewUUID(),
+		BatchHash:      fftypes.NewRandB32(),
+		BatchPaylodRef: fftypes.NewRandB32(),
+		Contexts: []*fftypes.Bytes32{
+			fftypes.NewRandB32(),
+		},
+	}
+
+	httpmock.RegisterResponder("POST", `http://localhost:12345/transactions`,
+		httpmock.NewStringResponder(500, "pop"))
+
+	_, err := f.SubmitBatchPin(context.Background(), nil, &fftypes.Identity{OnChain: "Org1MSP::user1"}, batch)
+
+	assert.Regexp(t, "FF10259", err)
+	assert.Regexp(t, "pop", err)
+
+}
+
+func TestTrackPendingBatchPinsNoop(t *testing.T) {
+	f := &Fabric{}
+	f.TrackPendingBatchPins(context.Background(), []string{"tx1"})
+}
+
+func TestVerifyFabricIdentity(t *testing.T) {
+	f := &Fabric{}
+
+	id := &fftypes.Identity{OnChain: "user1"}
+	err := f.VerifyIdentitySyntax(context.Background(), id)
+	assert.Regexp(t, "FF10260", err)
+
+	id = &fftypes.Identity{OnChain: "Org1 MSP::user1"}
+	err = f.VerifyIdentitySyntax(context.Background(), id)
+	assert.Regexp(t, "FF10260", err)
+
+	id = &fftypes.Identity{OnChain: "Org1MSP::user1@example.com"}
+	err = f.VerifyIdentitySyntax(context.Background(), id)
+	assert.NoError(t, err)
+	assert.Equal(t, "Org1MSP::user1@example.com", id.OnChain)
+
+}
+
+func TestHandleMessageBatchPinOK(t *testing.T) {
+
+	em := &blockchainmocks.Callbacks{}
+	f := &Fabric{
+		callbacks: em,
+	}
+
+	em.On("BatchPinComplete", mock.Anything, mock.Anything, "Org1MSP::user1", mock.Anything, mock.Anything).Return(nil)
+
+	event2 := validBatchPinEvent()
+	event2.PayloadRef = "0x0000000000000000000000000000000000000000000000000000000000000000"
+	events := chaincodeEvents(validBatchPinEvent(), event2)
+	events = append(events, map[string]interface{}{
+		"eventName": "Random",
+	})
+	ledgerID := fftypes.NewUUID()
+	err := f.handleMessageBatch(context.Background(), ledgerID, events)
+	assert.NoError(t, err)
+
+	assert.Len(t, em.Calls, 2)
+	assert.Equal(t, ledgerID, em.Calls[0].Arguments[0])
+	b := em.Calls[0].Arguments[1].(*blockchain.BatchPin)
+	assert.Equal(t, "ns1", b.Namespace)
+	assert.Equal(t, "e19af8b3-9060-4051-812d-7597d19adfb9", b.TransactionID.String())
+	assert.Equal(t, "847d3bfd-0742-49ef-b65d-3fed15f5b0a6", b.BatchID.String())
+	assert.Equal(t, "d71eb138d74c229a388eb0e1abc03f4c7cbb21d4fc4b839fbf0ec73e4263f6be", b.BatchHash.String())
+	assert.Equal(t, "eda586bd8f3c4bc1db5c4b5755113b9a9b4174abe28679fdbc219129400dd7ae", b.BatchPaylodRef.String())
+	assert.Equal(t, "Org1MSP::user1", em.Calls[0].Arguments[2])
+	assert.Equal(t, "ce79343000e851a0c742f63a733ce19a5f8b9ce1c719b6cecd14f01bcf81fff0", em.Calls[0].Arguments[3])
+	assert.Len(t, b.Contexts, 2)
+	assert.Equal(t, "68e4da79f805bca5b912bcda9c63d03e6e867108dabb9b944109aea541ef522a", b.Contexts[0].String())
+	assert.Equal(t, "19b82093de5ce92a01e333048e877e2374354bf846dd034864ef6ffbd6438771", b.Contexts[1].String())
+	info := em.Calls[0].Arguments[4].(fftypes.JSONObject)
+	assert.Equal(t, "ns1", info["payload"].(*fabBatchPinEvent).Namespace)
+
+	b = em.Calls[1].Arguments[1].(*blockchain.BatchPin)
+	assert.Nil(t, b.BatchPaylodRef)
+
+	em.AssertExpectations(t)
+
+}
+
+func TestHandleMessageBatchPinExit(t *testing.T) {
+
+	em := &blockchainmocks.Callbacks{}
+	f := &Fabric{
+		callbacks: em,
+	}
+
+	em.On("BatchPinComplete", mock.Anything, mock.Anything, "Org1MSP::user1", mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+
+	err := f.handleMessageBatch(context.Background(), nil, chaincodeEvents(validBatchPinEvent()))
+	assert.EqualError(t, err, "pop")
+
+}
+
+func TestHandleMessageBatchPinBadPayload(t *testing.T) {
+	em := &blockchainmocks.Callbacks{}
+	f := &Fabric{callbacks: em}
+	err := f.handleMessageBatch(context.Background(), nil, []interface{}{
+		map[string]interface{}{"eventName": "BatchPin", "transactionId": "tx1", "payload": "!base64"},
+		map[string]interface{}{"eventName": "BatchPin", "transactionId": "tx1", "payload": base64.StdEncoding.EncodeToString([]byte("!json"))},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(em.Calls))
+}
+
+func TestHandleMessageBatchPinInvalidEvents(t *testing.T) {
+	em := &blockchainmocks.Callbacks{}
+	f := &Fabric{callbacks: em}
+
+	missingData := validBatchPinEvent()
+	missingData.UUIDs = ""
+	badSigner := validBatchPinEvent()
+	badSigner.Signer = "!good"
+	badUUIDs := validBatchPinEvent()
+	badUUIDs.UUIDs = "!good"
+	badBatchHash := validBatchPinEvent()
+	badBatchHash.BatchHash = "!good"
+	badPayloadRef := validBatchPinEvent()
+	badPayloadRef.PayloadRef = "!good"
+	badPin := validBatchPinEvent()
+	badPin.Contexts = []string{"!good"}
+
+	for _, event := range []*fabBatchPinEvent{missingData, badSigner, badUUIDs, badBatchHash, badPayloadRef, badPin} {
+		err := f.handleMessageBatch(context.Background(), nil, chaincodeEvents(event))
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 0, len(em.Calls))
+}
+
+func TestHandleMessageBatchBadJSON(t *testing.T) {
+	em := &blockchainmocks.Callbacks{}
+	f := &Fabric{callbacks: em}
+	err := f.handleMessageBatch(context.Background(), nil, []interface{}{10, 20})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(em.Calls))
+}
+
+func TestEventLoopContextCancelled(t *testing.T) {
+	em := &blockchainmocks.Callbacks{}
+	wsm := &wsmocks.WSClient{}
+	ctxCancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	f := &Fabric{
+		ctx:       ctxCancelled,
+		callbacks: em,
+	}
+	ledger := &fabLedger{
+		topic:  "topic1",
+		wsconn: wsm,
+	}
+	r := make(<-chan []byte)
+	wsm.On("Receive").Return(r)
+	f.eventLoop(ledger) // we're simply looking for it exiting
+}
+
+func TestEventLoopReceiveClosed(t *testing.T) {
+	em := &blockchainmocks.Callbacks{}
+	wsm := &wsmocks.WSClient{}
+	f := &Fabric{
+		ctx:       context.Background(),
+		callbacks: em,
+	}
+	ledger := &fabLedger{
+		topic:  "topic1",
+		wsconn: wsm,
+	}
+	r := make(chan []byte)
+	close(r)
+	wsm.On("Receive").Return((<-chan []byte)(r))
+	f.eventLoop(ledger) // we're simply looking for it exiting
+}
+
+func TestEventLoopSendClosed(t *testing.T) {
+	em := &blockchainmocks.Callbacks{}
+	wsm := &wsmocks.WSClient{}
+	f := &Fabric{
+		ctx:       context.Background(),
+		callbacks: em,
+	}
+	ledger := &fabLedger{
+		topic:  "topic1",
+		wsconn: wsm,
+	}
+	r := make(chan []byte, 1)
+	r <- []byte(`[]`)
+	wsm.On("Receive").Return((<-chan []byte)(r))
+	wsm.On("Send", mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+	f.eventLoop(ledger) // we're simply looking for it exiting
+}
+
+func TestHandleReceiptTXSuccess(t *testing.T) {
+	em := &blockchainmocks.Callbacks{}
+	f := &Fabric{
+		ctx:       context.Background(),
+		callbacks: em,
+	}
+
+	var reply fftypes.JSONObject
+	data := []byte(`{
+		"_id": "748e7587-9e72-4244-7351-808f69b88291",
+		"headers": {
+			"id": "0ef91fb6-09c5-4ca2-721c-74b4869097c2",
+			"requestId": "748e7587-9e72-4244-7351-808f69b88291",
+			"requestOffset": "",
+			"timeElapsed": 0.475721,
+			"timeReceived": "2021-08-27T03:04:34.199742Z",
+			"type": "TransactionSuccess"
+		},
+		"receivedAt": 1630033474675,
+		"responseType": "TransactionSuccess",
+		"status": "VALID",
+		"transactionId": "ce79343000e851a0c742f63a733ce19a5f8b9ce1c719b6cecd14f01bcf81fff2"
+	}`)
+
+	em.On("TxSubmissionUpdate",
+		"748e7587-9e72-4244-7351-808f69b88291",
+		fftypes.OpStatusSucceeded,
+		"ce79343000e851a0c742f63a733ce19a5f8b9ce1c719b6cecd14f01bcf81fff2",
+		"",
+		mock.Anything).Return(nil)
+
+	err := json.Unmarshal(data, &reply)
+	assert.NoError(t, err)
+	err = f.handleReceipt(context.Background(), reply)
+	assert.NoError(t, err)
+
+	em.AssertExpectations(t)
+}
+
+func TestHandleReceiptTXFail(t *testing.T) {
+	em := &blockchainmocks.Callbacks{}
+	f := &Fabric{
+		ctx:       context.Background(),
+		callbacks: em,
+	}
+
+	var reply fftypes.JSONObject
+	data := []byte(`{
+		"_id": "6fb94fff-81d3-4094-567d-e031b1871694",
+		"errorMessage": "Failed to submit: chaincode 'fireflych' not found",
+		"headers": {
+			"id": "3a37b17b-13b6-4dc5-647a-07c11eae0be3",
+			"requestId": "6fb94fff-81d3-4094-567d-e031b1871694",
+			"requestOffset": "",
+			"timeElapsed": 0.020969053,
+			"timeReceived": "2021-05-31T02:35:11.458880504Z",
+			"type": "Error"
+		},
+		"receivedAt": 1622428511616
+	}`)
+
+	em.On("TxSubmissionUpdate",
+		"6fb94fff-81d3-4094-567d-e031b1871694",
+		fftypes.OpStatusFailed,
+		"",
+		"Failed to submit: chaincode 'fireflych' not found",
+		mock.Anything).Return(nil)
+
+	err := json.Unmarshal(data, &reply)
+	assert.NoError(t, err)
+	err = f.handleReceipt(context.Background(), reply)
+	assert.NoError(t, err)
+
+	em.AssertExpectations(t)
+}
+
+func TestStatus(t *testing.T) {
+	e := newTestFabric()
+	e.ledgers[0].name = "default"
+	e.ledgers[0].initInfo.stream = &eventStream{ID: "es12345"}
+	e.ledgers[0].initInfo.subs = []*subscription{{ID: "sub12345"}}
+	ledgerID := fftypes.NewUUID()
+	e.ledgers = append(e.ledgers, &fabLedger{id: ledgerID, name: "ledger2", channel: "channel2", topic: "topic2"})
+
+	b, err := json.Marshal(e.Status())
+	assert.NoError(t, err)
+	assert.JSONEq(t, fmt.Sprintf(`{"ledgers":[
+		{"id":null,"name":"default","channel":"firefly","topic":"topic1","eventStream":"es12345","subscriptions":["sub12345"]},
+		{"id":"%s","name":"ledger2","channel":"channel2","topic":"topic2","eventStream":"","subscriptions":[]}
+	]}`, ledgerID), string(b))
+}
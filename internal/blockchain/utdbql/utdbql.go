@@ -93,10 +93,18 @@ func (u *UTDBQL) Init(ctx context.Context, prefix config.Prefix, callbacks block
 	return nil
 }
 
+func (u *UTDBQL) TrackPendingBatchPins(ctx context.Context, txTrackingIDs []string) {
+	// Receipts are delivered from the same database table as the pins, so none are lost over a restart
+}
+
 func (u *UTDBQL) Capabilities() *blockchain.Capabilities {
 	return u.capabilities
 }
 
+func (u *UTDBQL) Status() fftypes.JSONObject {
+	return fftypes.JSONObject{}
+}
+
 func (u *UTDBQL) Start() error {
 	go u.eventLoop()
 	return nil
@@ -169,7 +177,7 @@ func (u *UTDBQL) dispatchEvent(ev *utEvent) {
 			log.L(u.ctx).Errorf("Failed to unmarshal '%s' event '%s': %s", ev.txType, ev.txID, err)
 			return
 		}
-		err = u.callbacks.BatchPinComplete(batch, ev.identity, ev.trackingID, nil)
+		err = u.callbacks.BatchPinComplete(nil, batch, ev.identity, ev.trackingID, nil)
 	case utDBQLEventTypeMined:
 		err = u.callbacks.TxSubmissionUpdate(ev.trackingID, fftypes.OpStatusSucceeded, ev.txID, "", nil)
 	}
@@ -49,6 +49,7 @@ func TestInit(t *testing.T) {
 
 	assert.Equal(t, "utdbql", u.Name())
 	assert.NotNil(t, u.Capabilities())
+	assert.Empty(t, u.Status())
 	u.Close()
 }
 
@@ -62,6 +63,11 @@ func TestInitBadURL(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestTrackPendingBatchPinsNoop(t *testing.T) {
+	u := &UTDBQL{}
+	u.TrackPendingBatchPins(context.Background(), []string{"tx1"})
+}
+
 func TestVerifyIdentitySyntaxOK(t *testing.T) {
 	u := &UTDBQL{}
 	err := u.VerifyIdentitySyntax(context.Background(), &fftypes.Identity{OnChain: "good"})
@@ -123,7 +129,7 @@ func TestCloseOnEventDispatchError(t *testing.T) {
 	me := &blockchainmocks.Callbacks{}
 
 	sbbEv := make(chan bool, 1)
-	sbb := me.On("BatchPinComplete", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(fmt.Errorf("Pop"))
+	sbb := me.On("BatchPinComplete", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(fmt.Errorf("Pop"))
 	sbb.RunFn = func(a mock.Arguments) {
 		sbbEv <- true
 	}
@@ -26,6 +26,7 @@ import (
 	"github.com/hyperledger-labs/firefly/internal/data"
 	"github.com/hyperledger-labs/firefly/internal/i18n"
 	"github.com/hyperledger-labs/firefly/internal/log"
+	"github.com/hyperledger-labs/firefly/internal/metrics"
 	"github.com/hyperledger-labs/firefly/pkg/blockchain"
 	"github.com/hyperledger-labs/firefly/pkg/database"
 	"github.com/hyperledger-labs/firefly/pkg/dataexchange"
@@ -93,6 +94,11 @@ func (bm *broadcastManager) GetNodeSigningIdentity(ctx context.Context) (*fftype
 
 func (bm *broadcastManager) dispatchBatch(ctx context.Context, batch *fftypes.Batch, pins []*fftypes.Bytes32) error {
 
+	// Any blobs in the batch must be published to public storage first, so the payload we publish refers to them
+	if err := bm.publishBlobs(ctx, batch); err != nil {
+		return err
+	}
+
 	// Serialize the full payload, which has already been sealed for us by the BatchManager
 	payload, err := json.Marshal(batch)
 	if err != nil {
@@ -112,6 +118,40 @@ func (bm *broadcastManager) dispatchBatch(ctx context.Context, batch *fftypes.Ba
 	})
 }
 
+// publishBlobs streams each blob in the batch from the local data exchange into public storage, and records
+// the reference in the data. As the references are part of the payload, the batch is re-hashed if any are added.
+func (bm *broadcastManager) publishBlobs(ctx context.Context, batch *fftypes.Batch) error {
+	published := false
+	for _, data := range batch.Payload.Data {
+		if data == nil || !data.Blobstore || data.Blob == nil || data.Blob.PayloadRef != nil {
+			continue
+		}
+
+		reader, err := bm.exchange.DownloadBLOB(ctx, data.Namespace, *data.ID)
+		if err != nil {
+			return err
+		}
+		payloadRef, backendID, err := bm.publicstorage.PublishData(ctx, reader)
+		reader.Close()
+		if err != nil {
+			return err
+		}
+		log.L(ctx).Infof("Published blob %s to public storage payloadRef=%s backendID=%s", data.ID, payloadRef, backendID)
+
+		err = bm.database.UpdateData(ctx, data.ID, database.DataQueryFactory.NewUpdate(ctx).Set("blob.payloadref", payloadRef))
+		if err != nil {
+			return err
+		}
+		data.Blob.PayloadRef = payloadRef
+		published = true
+	}
+	if published {
+		batch.Hash = batch.Payload.Hash()
+		return bm.database.UpsertBatch(ctx, batch, true, true /* the hash changes as the references are added */)
+	}
+	return nil
+}
+
 func (bm *broadcastManager) submitTXAndUpdateDB(ctx context.Context, batch *fftypes.Batch, contexts []*fftypes.Bytes32, publicstorageID string) error {
 
 	id, err := bm.identity.Resolve(ctx, batch.Author)
@@ -147,14 +187,19 @@ func (bm *broadcastManager) submitTXAndUpdateDB(ctx context.Context, batch *ffty
 	}
 
 	// Write the batch pin to the blockchain
-	blockchainTrackingID, err := bm.blockchain.SubmitBatchPin(ctx, nil, id, &blockchain.BatchPin{
-		Namespace:      batch.Namespace,
-		TransactionID:  batch.Payload.TX.ID,
-		BatchID:        batch.ID,
-		BatchHash:      batch.Hash,
-		BatchPaylodRef: batch.PayloadRef,
-		Contexts:       contexts,
-	})
+	submission := &blockchain.BatchPinSubmission{
+		Signer: id,
+		BatchPin: &blockchain.BatchPin{
+			Namespace:      batch.Namespace,
+			TransactionID:  batch.Payload.TX.ID,
+			BatchID:        batch.ID,
+			BatchHash:      batch.Hash,
+			BatchPaylodRef: batch.PayloadRef,
+			Contexts:       contexts,
+		},
+	}
+	blockchainTrackingID, err := bm.blockchain.SubmitBatchPin(ctx, submission.LedgerID, submission.Signer, submission.BatchPin)
+	metrics.BlockchainSubmission(bm.blockchain.Name(), err)
 	if err != nil {
 		return err
 	}
@@ -168,9 +213,14 @@ func (bm *broadcastManager) submitTXAndUpdateDB(ctx context.Context, batch *ffty
 		fftypes.OpTypeBlockchainBatchPin,
 		fftypes.OpStatusPending,
 		"")
+	op.Input = submission.OperationInput()
 	if err := bm.database.UpsertOperation(ctx, op, false); err != nil {
 		return err
 	}
+	event := fftypes.NewEvent(fftypes.EventTypeTransactionSubmitted, batch.Namespace, batch.Payload.TX.ID, nil)
+	if err := bm.database.UpsertEvent(ctx, event, false); err != nil {
+		return err
+	}
 
 	// The completed PublicStorage upload
 	op = fftypes.NewTXOperation(
@@ -191,6 +241,11 @@ func (bm *broadcastManager) broadcastMessageCommon(ctx context.Context, msg *fft
 		return err
 	}
 
+	// Sign the hash of the message, if our identity plugin holds keys for the author
+	if msg.Signature, err = bm.identity.Sign(ctx, msg.Header.Author, msg.Hash); err != nil {
+		return err
+	}
+
 	// Store the message - this asynchronously triggers the next step in process
 	return bm.database.InsertMessageLocal(ctx, msg)
 }
@@ -17,8 +17,10 @@
 package broadcast
 
 import (
+	"bytes"
 	"context"
 	"fmt"
+	"io/ioutil"
 	"testing"
 
 	"github.com/hyperledger-labs/firefly/internal/config"
@@ -47,6 +49,7 @@ func newTestBroadcast(t *testing.T) (*broadcastManager, func()) {
 	mbi.On("Name").Return("ut_blockchain").Maybe()
 	defaultIdentity := &fftypes.Identity{Identifier: "UTNodeID", OnChain: "0x12345"}
 	mii.On("Resolve", mock.Anything, "UTNodeID").Return(defaultIdentity, nil).Maybe()
+	mii.On("Sign", mock.Anything, mock.Anything, mock.Anything).Return("", nil).Maybe()
 	mbi.On("VerifyIdentitySyntax", mock.Anything, defaultIdentity).Return(nil).Maybe()
 	mba.On("RegisterDispatcher", []fftypes.MessageType{fftypes.MessageTypeBroadcast, fftypes.MessageTypeDefinition}, mock.Anything, mock.Anything).Return()
 	ctx, cancel := context.WithCancel(context.Background())
@@ -64,16 +67,33 @@ func TestBroadcastMessageGood(t *testing.T) {
 	bm, cancel := newTestBroadcast(t)
 	defer cancel()
 
-	msg := &fftypes.Message{}
+	mii := &identitymocks.Plugin{}
+	bm.identity = mii
+	msg := &fftypes.Message{Header: fftypes.MessageHeader{Author: "0x12345"}}
+	mii.On("Sign", mock.Anything, "0x12345", mock.Anything).Return("c2lnbmF0dXJl", nil)
 	bm.database.(*databasemocks.Plugin).On("InsertMessageLocal", mock.Anything, msg).Return(nil)
 
 	err := bm.broadcastMessageCommon(context.Background(), msg)
 	assert.NoError(t, err)
+	assert.Equal(t, "c2lnbmF0dXJl", msg.Signature)
 
 	bm.Start()
 	bm.WaitStop()
 }
 
+func TestBroadcastMessageSignFail(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	mii := &identitymocks.Plugin{}
+	bm.identity = mii
+	msg := &fftypes.Message{Header: fftypes.MessageHeader{Author: "0x12345"}}
+	mii.On("Sign", mock.Anything, "0x12345", mock.Anything).Return("", fmt.Errorf("pop"))
+
+	err := bm.broadcastMessageCommon(context.Background(), msg)
+	assert.EqualError(t, err, "pop")
+}
+
 func TestBroadcastMessageBad(t *testing.T) {
 	bm, cancel := newTestBroadcast(t)
 	defer cancel()
@@ -128,6 +148,107 @@ func TestDispatchBatchSubmitBatchPinSucceed(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestDispatchBatchWithBlobs(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+	mdi := bm.database.(*databasemocks.Plugin)
+	mdx := bm.exchange.(*dataexchangemocks.Plugin)
+	mpi := bm.publicstorage.(*publicstoragemocks.Plugin)
+	blobID := fftypes.NewUUID()
+	payloadRef := fftypes.NewRandB32()
+	batch := &fftypes.Batch{
+		ID: fftypes.NewUUID(),
+		Payload: fftypes.BatchPayload{
+			Data: []*fftypes.Data{
+				{ID: fftypes.NewUUID(), Value: fftypes.Byteable(`{}`)},
+				{ID: fftypes.NewUUID(), Namespace: "ns1", Blobstore: true, Blob: &fftypes.BlobRef{PayloadRef: fftypes.NewRandB32()}},
+				{ID: blobID, Namespace: "ns1", Blobstore: true, Blob: &fftypes.BlobRef{}},
+			},
+		},
+	}
+	batch.Hash = batch.Payload.Hash()
+	originalHash := batch.Hash
+
+	mdx.On("DownloadBLOB", mock.Anything, "ns1", *blobID).Return(ioutil.NopCloser(bytes.NewReader([]byte(`some data`))), nil)
+	mpi.On("PublishData", mock.Anything, mock.Anything).Return(payloadRef, "backend1", nil).Once()
+	mdi.On("UpdateData", mock.Anything, blobID, mock.Anything).Return(nil)
+	mdi.On("UpsertBatch", mock.Anything, batch, true, true).Return(nil)
+	mpi.On("PublishData", mock.Anything, mock.Anything).Return(fftypes.NewRandB32(), "id1", nil).Once()
+	mdi.On("RunAsGroup", mock.Anything, mock.Anything).Return(nil)
+
+	err := bm.dispatchBatch(context.Background(), batch, []*fftypes.Bytes32{fftypes.NewRandB32()})
+	assert.NoError(t, err)
+	assert.Equal(t, *payloadRef, *batch.Payload.Data[2].Blob.PayloadRef)
+	assert.NotEqual(t, *originalHash, *batch.Hash)
+	assert.Equal(t, *batch.Payload.Hash(), *batch.Hash)
+
+	mdi.AssertExpectations(t)
+	mdx.AssertExpectations(t)
+	mpi.AssertExpectations(t)
+}
+
+func TestDispatchBatchPublishBlobsFail(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+	mdx := bm.exchange.(*dataexchangemocks.Plugin)
+	blobID := fftypes.NewUUID()
+
+	mdx.On("DownloadBLOB", mock.Anything, "ns1", *blobID).Return(nil, fmt.Errorf("pop"))
+
+	err := bm.dispatchBatch(context.Background(), &fftypes.Batch{
+		Payload: fftypes.BatchPayload{
+			Data: []*fftypes.Data{
+				{ID: blobID, Namespace: "ns1", Blobstore: true, Blob: &fftypes.BlobRef{}},
+			},
+		},
+	}, []*fftypes.Bytes32{fftypes.NewRandB32()})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestPublishBlobsPublishFail(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+	mdx := bm.exchange.(*dataexchangemocks.Plugin)
+	mpi := bm.publicstorage.(*publicstoragemocks.Plugin)
+	blobID := fftypes.NewUUID()
+
+	mdx.On("DownloadBLOB", mock.Anything, "ns1", *blobID).Return(ioutil.NopCloser(bytes.NewReader([]byte(`some data`))), nil)
+	mpi.On("PublishData", mock.Anything, mock.Anything).Return(nil, "", fmt.Errorf("pop"))
+
+	err := bm.publishBlobs(context.Background(), &fftypes.Batch{
+		Payload: fftypes.BatchPayload{
+			Data: []*fftypes.Data{
+				{ID: blobID, Namespace: "ns1", Blobstore: true, Blob: &fftypes.BlobRef{}},
+			},
+		},
+	})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestPublishBlobsUpdateDataFail(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+	mdi := bm.database.(*databasemocks.Plugin)
+	mdx := bm.exchange.(*dataexchangemocks.Plugin)
+	mpi := bm.publicstorage.(*publicstoragemocks.Plugin)
+	blobID := fftypes.NewUUID()
+
+	mdx.On("DownloadBLOB", mock.Anything, "ns1", *blobID).Return(ioutil.NopCloser(bytes.NewReader([]byte(`some data`))), nil)
+	mpi.On("PublishData", mock.Anything, mock.Anything).Return(fftypes.NewRandB32(), "backend1", nil)
+	mdi.On("UpdateData", mock.Anything, blobID, mock.Anything).Return(fmt.Errorf("pop"))
+
+	batch := &fftypes.Batch{
+		Payload: fftypes.BatchPayload{
+			Data: []*fftypes.Data{
+				{ID: blobID, Namespace: "ns1", Blobstore: true, Blob: &fftypes.BlobRef{}},
+			},
+		},
+	}
+	err := bm.publishBlobs(context.Background(), batch)
+	assert.EqualError(t, err, "pop")
+	assert.Nil(t, batch.Payload.Data[0].Blob.PayloadRef)
+}
+
 func TestGetOrgIdentityEmpty(t *testing.T) {
 	bm, cancel := newTestBroadcast(t)
 	defer cancel()
@@ -255,6 +376,34 @@ func TestSubmitTXAndUpdateDBAddOp1Fail(t *testing.T) {
 	assert.Regexp(t, "pop", err)
 }
 
+func TestSubmitTXAndUpdateDBAddEventFail(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	mdi := bm.database.(*databasemocks.Plugin)
+	mbi := bm.blockchain.(*blockchainmocks.Plugin)
+	mdi.On("UpsertTransaction", mock.Anything, mock.Anything, true, false).Return(nil)
+	mdi.On("UpdateBatch", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mdi.On("UpsertOperation", mock.Anything, mock.Anything, false).Return(nil)
+	mdi.On("UpsertEvent", mock.Anything, mock.Anything, false).Return(fmt.Errorf("pop"))
+	mbi.On("SubmitBatchPin", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return("txid", nil)
+	mbi.On("Name").Return("unittest")
+
+	batch := &fftypes.Batch{
+		Author: "UTNodeID",
+		Payload: fftypes.BatchPayload{
+			Messages: []*fftypes.Message{
+				{Header: fftypes.MessageHeader{
+					ID: fftypes.NewUUID(),
+				}},
+			},
+		},
+	}
+
+	err := bm.submitTXAndUpdateDB(context.Background(), batch, []*fftypes.Bytes32{fftypes.NewRandB32()}, "id1")
+	assert.Regexp(t, "pop", err)
+}
+
 func TestSubmitTXAndUpdateDBAddOp2Fail(t *testing.T) {
 	bm, cancel := newTestBroadcast(t)
 	defer cancel()
@@ -264,6 +413,7 @@ func TestSubmitTXAndUpdateDBAddOp2Fail(t *testing.T) {
 	mdi.On("UpsertTransaction", mock.Anything, mock.Anything, true, false).Return(nil)
 	mdi.On("UpdateBatch", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 	mdi.On("UpsertOperation", mock.Anything, mock.Anything, false).Once().Return(nil)
+	mdi.On("UpsertEvent", mock.Anything, mock.Anything, false).Return(nil)
 	mdi.On("UpsertOperation", mock.Anything, mock.Anything, false).Once().Return(fmt.Errorf("pop"))
 	mbi.On("SubmitBatchPin", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return("txid", nil)
 	mbi.On("Name").Return("ut_blockchain")
@@ -295,6 +445,7 @@ func TestSubmitTXAndUpdateDBSucceed(t *testing.T) {
 	mdi.On("UpdateBatch", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 	mdi.On("UpsertOperation", mock.Anything, mock.Anything, false).Once().Return(nil)
 	mdi.On("UpsertOperation", mock.Anything, mock.Anything, false).Once().Return(nil)
+	mdi.On("UpsertEvent", mock.Anything, mock.Anything, false).Return(nil)
 	mbi.On("SubmitBatchPin", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return("blockchain_id", nil)
 
 	bm.publicstorage.(*publicstoragemocks.Plugin).On("Name").Return("ut_publicstorage")
@@ -324,7 +475,11 @@ func TestSubmitTXAndUpdateDBSucceed(t *testing.T) {
 	assert.Equal(t, "blockchain_id", op1.BackendID)
 	assert.Equal(t, fftypes.OpTypeBlockchainBatchPin, op1.Type)
 
-	op2 := mdi.Calls[3].Arguments[1].(*fftypes.Operation)
+	event := mdi.Calls[3].Arguments[1].(*fftypes.Event)
+	assert.Equal(t, fftypes.EventTypeTransactionSubmitted, event.Type)
+	assert.Equal(t, *batch.Payload.TX.ID, *event.Reference)
+
+	op2 := mdi.Calls[4].Arguments[1].(*fftypes.Operation)
 	assert.Equal(t, *batch.Payload.TX.ID, *op2.Transaction)
 	assert.Equal(t, "ut_publicstorage", op2.Plugin)
 	assert.Equal(t, "ipfs_id", op2.BackendID)
@@ -20,6 +20,7 @@ import (
 	"context"
 
 	"github.com/hyperledger-labs/firefly/internal/config"
+	"github.com/hyperledger-labs/firefly/internal/i18n"
 	"github.com/hyperledger-labs/firefly/pkg/fftypes"
 )
 
@@ -32,6 +33,10 @@ func (bm *broadcastManager) BroadcastMessage(ctx context.Context, ns string, in
 	if in.Header.TxType == "" {
 		in.Header.TxType = fftypes.TransactionTypeBatchPin
 	}
+	if in.Header.TxType == fftypes.TransactionTypeNone {
+		// Broadcasts are retrieved from public storage by the reference in the pinning transaction
+		return nil, i18n.NewError(ctx, i18n.MsgTxTypeNotSupported, in.Header.TxType, in.Header.Type)
+	}
 
 	// We optimize the DB storage of all the parts of the message using transaction semantics (assuming those are supported by the DB plugin
 	err = bm.database.RunAsGroup(ctx, func(ctx context.Context) error {
@@ -93,3 +93,17 @@ func TestBroadcastMessageBadInput(t *testing.T) {
 	mdi.AssertExpectations(t)
 	mdm.AssertExpectations(t)
 }
+
+func TestBroadcastMessageTxTypeNone(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	_, err := bm.BroadcastMessage(context.Background(), "ns1", &fftypes.MessageInput{
+		Message: fftypes.Message{
+			Header: fftypes.MessageHeader{
+				TxType: fftypes.TransactionTypeNone,
+			},
+		},
+	})
+	assert.Regexp(t, "FF10295", err)
+}
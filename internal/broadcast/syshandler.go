@@ -36,6 +36,14 @@ func (bm *broadcastManager) HandleSystemBroadcast(ctx context.Context, msg *ffty
 		return bm.handleOrganizationBroadcast(ctx, msg, data)
 	case fftypes.SystemTagDefineNode:
 		return bm.handleNodeBroadcast(ctx, msg, data)
+	case fftypes.SystemTagUpdateOrganization:
+		return bm.handleOrganizationUpdateBroadcast(ctx, msg, data)
+	case fftypes.SystemTagRevokeOrganization:
+		return bm.handleOrganizationRevokeBroadcast(ctx, msg, data)
+	case fftypes.SystemTagUpdateNode:
+		return bm.handleNodeUpdateBroadcast(ctx, msg, data)
+	case fftypes.SystemTagRevokeNode:
+		return bm.handleNodeRevokeBroadcast(ctx, msg, data)
 	default:
 		l.Warnf("Unknown topic '%s' for system broadcast ID '%s'", msg.Header.Tag, msg.Header.ID)
 	}
@@ -45,6 +45,10 @@ func (bm *broadcastManager) handleNodeBroadcast(ctx context.Context, msg *fftype
 		l.Warnf("Unable to process node broadcast %s - parent identity not found: %s", msg.Header.ID, node.Owner)
 		return false, nil
 	}
+	if owner.Revoked != nil {
+		l.Warnf("Unable to process node broadcast %s - parent identity revoked: %s", msg.Header.ID, node.Owner)
+		return false, nil
+	}
 
 	id, err := bm.identity.Resolve(ctx, node.Owner)
 	if err != nil {
@@ -65,17 +69,29 @@ func (bm *broadcastManager) handleNodeBroadcast(ctx context.Context, msg *fftype
 		return false, err // We only return database errors
 	}
 	if existing != nil {
-		if existing.Owner != node.Owner {
+		if existing.Owner != node.Owner || existing.Revoked != nil {
 			l.Warnf("Unable to process node broadcast %s - mismatch with existing %v", msg.Header.ID, existing.ID)
 			return false, nil
 		}
 		node.ID = nil // we keep the existing ID
+		node.Version = existing.Version
+		node.Updated = existing.Updated
+	} else {
+		node.Version = 1
+		node.Updated = nil
 	}
+	node.Revoked = nil
 
 	if err = bm.database.UpsertNode(ctx, &node, true); err != nil {
 		return false, err
 	}
 
+	if existing == nil {
+		if err = bm.database.InsertNodeVersion(ctx, &node); err != nil {
+			return false, err
+		}
+	}
+
 	// Tell the data exchange about this node. Treat these errors like database errors - and return for retry processing
 	if err = bm.exchange.AddPeer(ctx, &node); err != nil {
 		return false, err
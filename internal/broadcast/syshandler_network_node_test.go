@@ -57,6 +57,7 @@ func TestHandleSystemBroadcastNodeOk(t *testing.T) {
 	mdi.On("GetNode", mock.Anything, "0x23456", "node1").Return(nil, nil)
 	mdi.On("GetNodeByID", mock.Anything, node.ID).Return(nil, nil)
 	mdi.On("UpsertNode", mock.Anything, mock.Anything, true).Return(nil)
+	mdi.On("InsertNodeVersion", mock.Anything, mock.Anything).Return(nil)
 	mdx := bm.exchange.(*dataexchangemocks.Plugin)
 	mdx.On("AddPeer", mock.Anything, mock.Anything).Return(nil)
 	valid, err := bm.HandleSystemBroadcast(context.Background(), &fftypes.Message{
@@ -141,6 +142,7 @@ func TestHandleSystemBroadcastNodeAddPeerFail(t *testing.T) {
 	mdi.On("GetNode", mock.Anything, "0x23456", "node1").Return(nil, nil)
 	mdi.On("GetNodeByID", mock.Anything, node.ID).Return(nil, nil)
 	mdi.On("UpsertNode", mock.Anything, mock.Anything, true).Return(nil)
+	mdi.On("InsertNodeVersion", mock.Anything, mock.Anything).Return(nil)
 	mdx := bm.exchange.(*dataexchangemocks.Plugin)
 	mdx.On("AddPeer", mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
 	valid, err := bm.HandleSystemBroadcast(context.Background(), &fftypes.Message{
@@ -472,3 +474,80 @@ func TestHandleSystemBroadcastNodeUnmarshalFail(t *testing.T) {
 	assert.False(t, valid)
 	assert.NoError(t, err)
 }
+
+func TestHandleSystemBroadcastNodeOrgRevoked(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	node := &fftypes.Node{
+		ID:          fftypes.NewUUID(),
+		Name:        "node1",
+		Owner:       "0x23456",
+		Description: "my org",
+		DX: fftypes.DXInfo{
+			Peer:     "peer1",
+			Endpoint: fftypes.JSONObject{"some": "info"},
+		},
+	}
+	b, err := json.Marshal(&node)
+	assert.NoError(t, err)
+	data := &fftypes.Data{
+		Value: fftypes.Byteable(b),
+	}
+
+	mdi := bm.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByIdentity", mock.Anything, "0x23456").Return(&fftypes.Organization{Identity: "0x23456", Revoked: fftypes.Now()}, nil)
+	valid, err := bm.HandleSystemBroadcast(context.Background(), &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			Namespace: "ns1",
+			Author:    "0x23456",
+			Tag:       string(fftypes.SystemTagDefineNode),
+		},
+	}, []*fftypes.Data{data})
+	assert.False(t, valid)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleSystemBroadcastNodeInsertVersionFail(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	node := &fftypes.Node{
+		ID:          fftypes.NewUUID(),
+		Name:        "node1",
+		Owner:       "0x23456",
+		Description: "my org",
+		DX: fftypes.DXInfo{
+			Peer:     "peer1",
+			Endpoint: fftypes.JSONObject{"some": "info"},
+		},
+	}
+	b, err := json.Marshal(&node)
+	assert.NoError(t, err)
+	data := &fftypes.Data{
+		Value: fftypes.Byteable(b),
+	}
+
+	mii := bm.identity.(*identitymocks.Plugin)
+	mii.On("Resolve", mock.Anything, "0x23456").Return(&fftypes.Identity{OnChain: "0x23456"}, nil)
+	mdi := bm.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByIdentity", mock.Anything, "0x23456").Return(&fftypes.Organization{ID: fftypes.NewUUID(), Identity: "0x23456"}, nil)
+	mdi.On("GetNode", mock.Anything, "0x23456", "node1").Return(nil, nil)
+	mdi.On("GetNodeByID", mock.Anything, node.ID).Return(nil, nil)
+	mdi.On("UpsertNode", mock.Anything, mock.Anything, true).Return(nil)
+	mdi.On("InsertNodeVersion", mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+	valid, err := bm.HandleSystemBroadcast(context.Background(), &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			Namespace: "ns1",
+			Author:    "0x23456",
+			Tag:       string(fftypes.SystemTagDefineNode),
+		},
+	}, []*fftypes.Data{data})
+	assert.False(t, valid)
+	assert.EqualError(t, err, "pop")
+
+	mii.AssertExpectations(t)
+	mdi.AssertExpectations(t)
+}
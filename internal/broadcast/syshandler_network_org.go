@@ -18,6 +18,7 @@ package broadcast
 
 import (
 	"context"
+	"encoding/json"
 
 	"github.com/hyperledger-labs/firefly/internal/log"
 	"github.com/hyperledger-labs/firefly/pkg/fftypes"
@@ -38,9 +39,10 @@ func (bm *broadcastManager) handleOrganizationBroadcast(ctx context.Context, msg
 	}
 
 	signingIdentity := org.Identity
+	var parent *fftypes.Organization
 	if org.Parent != "" {
 		signingIdentity = org.Parent
-		parent, err := bm.database.GetOrganizationByIdentity(ctx, org.Parent)
+		parent, err = bm.database.GetOrganizationByIdentity(ctx, org.Parent)
 		if err != nil {
 			return false, err // We only return database errors
 		}
@@ -48,6 +50,15 @@ func (bm *broadcastManager) handleOrganizationBroadcast(ctx context.Context, msg
 			l.Warnf("Unable to process organization broadcast %s - parent identity not found: %s", msg.Header.ID, org.Parent)
 			return false, nil
 		}
+		if parent.Revoked != nil {
+			l.Warnf("Unable to process organization broadcast %s - parent identity revoked: %s", msg.Header.ID, org.Parent)
+			return false, nil
+		}
+	}
+
+	if err = bm.identity.VerifyOrganization(ctx, &org, parent, msg.Header.Created); err != nil {
+		l.Warnf("Unable to process organization broadcast %s - credentials invalid: %s", msg.Header.ID, err)
+		return false, nil
 	}
 
 	id, err := bm.identity.Resolve(ctx, signingIdentity)
@@ -61,7 +72,20 @@ func (bm *broadcastManager) handleOrganizationBroadcast(ctx context.Context, msg
 		return false, nil
 	}
 
+	// A root organization is not registered when it signs its own registration, so the signature is verified
+	// against the keys it publishes. Other registrations are verified against the keys of the parent.
+	if parent == nil {
+		if err = msg.VerifySignature(ctx, org.PublicKeys); err != nil {
+			l.Warnf("Unable to process organization broadcast %s - signature invalid: %s", msg.Header.ID, err)
+			return false, nil
+		}
+	}
+
+	retired := false
 	existing, err := bm.database.GetOrganizationByIdentity(ctx, org.Identity)
+	if err == nil && existing == nil {
+		retired, err = bm.isRetiredIdentity(ctx, org.Identity)
+	}
 	if err == nil && existing == nil {
 		existing, err = bm.database.GetOrganizationByName(ctx, org.Name)
 		if err == nil && existing == nil {
@@ -71,17 +95,51 @@ func (bm *broadcastManager) handleOrganizationBroadcast(ctx context.Context, msg
 	if err != nil {
 		return false, err // We only return database errors
 	}
+	if retired {
+		l.Warnf("Unable to process organization broadcast %s - identity '%s' has been retired", msg.Header.ID, org.Identity)
+		return false, nil
+	}
 	if existing != nil {
-		if existing.Parent != org.Parent {
+		// Changes to an organization must be made through a versioned update, signed by the organization or its parent.
+		// So a repeat of the registration is only accepted if it matches the existing organization.
+		if existing.Revoked != nil || !sameOrganizationDefinition(existing, &org) {
 			l.Warnf("Unable to process organization broadcast %s - mismatch with existing %v", msg.Header.ID, existing.ID)
 			return false, nil
 		}
-		org.ID = nil // we keep the existing ID
+		l.Infof("Organization broadcast %s repeats the registration of %v", msg.Header.ID, existing.ID)
+		return true, nil
 	}
+	org.Version = 1
+	org.Updated = nil
+	org.Revoked = nil
+	org.IdentityProof = ""
 
 	if err = bm.database.UpsertOrganization(ctx, &org, true); err != nil {
 		return false, err
 	}
 
+	if err = bm.database.InsertOrganizationVersion(ctx, &org); err != nil {
+		return false, err
+	}
+
 	return true, nil
 }
+
+// sameOrganizationDefinition checks whether the fields of a registration match those of an existing organization
+func sameOrganizationDefinition(existing, org *fftypes.Organization) bool {
+	return existing.Identity == org.Identity &&
+		existing.Parent == org.Parent &&
+		existing.Name == org.Name &&
+		existing.Description == org.Description &&
+		sameJSON(existing.Profile, org.Profile, len(existing.Profile) == 0 && len(org.Profile) == 0) &&
+		sameJSON(existing.PublicKeys, org.PublicKeys, len(existing.PublicKeys) == 0 && len(org.PublicKeys) == 0)
+}
+
+func sameJSON(a, b interface{}, bothEmpty bool) bool {
+	if bothEmpty {
+		return true
+	}
+	aJSON, _ := json.Marshal(a)
+	bJSON, _ := json.Marshal(b)
+	return string(aJSON) == string(bJSON)
+}
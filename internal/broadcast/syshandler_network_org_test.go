@@ -48,13 +48,20 @@ func TestHandleSystemBroadcastOrgOk(t *testing.T) {
 	}
 
 	mii := bm.identity.(*identitymocks.Plugin)
+	mii.On("VerifyOrganization", mock.Anything, mock.MatchedBy(func(o *fftypes.Organization) bool {
+		return o.Identity == "0x12345"
+	}), mock.MatchedBy(func(parent *fftypes.Organization) bool {
+		return parent.Identity == "0x23456"
+	}), mock.Anything).Return(nil)
 	mii.On("Resolve", mock.Anything, "0x23456").Return(&fftypes.Identity{OnChain: "0x23456"}, nil)
 	mdi := bm.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationVersions", mock.Anything, mock.Anything).Return([]*fftypes.Organization{}, nil, nil)
 	mdi.On("GetOrganizationByIdentity", mock.Anything, "0x23456").Return(&fftypes.Organization{ID: fftypes.NewUUID(), Identity: "0x23456"}, nil)
 	mdi.On("GetOrganizationByIdentity", mock.Anything, "0x12345").Return(nil, nil)
 	mdi.On("GetOrganizationByName", mock.Anything, "org1").Return(nil, nil)
 	mdi.On("GetOrganizationByID", mock.Anything, org.ID).Return(nil, nil)
 	mdi.On("UpsertOrganization", mock.Anything, mock.Anything, true).Return(nil)
+	mdi.On("InsertOrganizationVersion", mock.Anything, mock.Anything).Return(nil)
 	valid, err := bm.HandleSystemBroadcast(context.Background(), &fftypes.Message{
 		Header: fftypes.MessageHeader{
 			Namespace: "ns1",
@@ -88,11 +95,19 @@ func TestHandleSystemBroadcastOrgDupOk(t *testing.T) {
 	}
 
 	mii := bm.identity.(*identitymocks.Plugin)
+	mii.On("VerifyOrganization", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
 	mii.On("Resolve", mock.Anything, "0x23456").Return(&fftypes.Identity{OnChain: "0x23456"}, nil)
 	mdi := bm.database.(*databasemocks.Plugin)
 	mdi.On("GetOrganizationByIdentity", mock.Anything, "0x23456").Return(&fftypes.Organization{ID: fftypes.NewUUID(), Identity: "0x23456"}, nil)
-	mdi.On("GetOrganizationByIdentity", mock.Anything, "0x12345").Return(&fftypes.Organization{ID: fftypes.NewUUID(), Identity: "0x12345", Parent: "0x23456"}, nil)
-	mdi.On("UpsertOrganization", mock.Anything, mock.Anything, true).Return(nil)
+	mdi.On("GetOrganizationByIdentity", mock.Anything, "0x12345").Return(&fftypes.Organization{
+		ID:          fftypes.NewUUID(),
+		Name:        "org1",
+		Identity:    "0x12345",
+		Parent:      "0x23456",
+		Description: "my org",
+		Profile:     fftypes.JSONObject{"some": "info"},
+		Version:     2,
+	}, nil)
 	valid, err := bm.HandleSystemBroadcast(context.Background(), &fftypes.Message{
 		Header: fftypes.MessageHeader{
 			Namespace: "ns1",
@@ -103,6 +118,54 @@ func TestHandleSystemBroadcastOrgDupOk(t *testing.T) {
 	assert.True(t, valid)
 	assert.NoError(t, err)
 
+	// A repeat of the registration does not change the organization
+	mdi.AssertNotCalled(t, "UpsertOrganization", mock.Anything, mock.Anything, mock.Anything)
+	mii.AssertExpectations(t)
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleSystemBroadcastOrgDupKeysNotApplied(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	org := &fftypes.Organization{
+		ID:         fftypes.NewUUID(),
+		Name:       "org1",
+		Identity:   "0x12345",
+		Parent:     "0x23456",
+		PublicKeys: fftypes.PublicKeys{{Type: fftypes.KeyTypeP256, Key: "newkey"}},
+	}
+	b, err := json.Marshal(&org)
+	assert.NoError(t, err)
+	data := &fftypes.Data{
+		Value: fftypes.Byteable(b),
+	}
+
+	mii := bm.identity.(*identitymocks.Plugin)
+	mii.On("VerifyOrganization", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mii.On("Resolve", mock.Anything, "0x23456").Return(&fftypes.Identity{OnChain: "0x23456"}, nil)
+	mdi := bm.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByIdentity", mock.Anything, "0x23456").Return(&fftypes.Organization{ID: fftypes.NewUUID(), Identity: "0x23456"}, nil)
+	mdi.On("GetOrganizationByIdentity", mock.Anything, "0x12345").Return(&fftypes.Organization{
+		ID:         fftypes.NewUUID(),
+		Name:       "org1",
+		Identity:   "0x12345",
+		Parent:     "0x23456",
+		PublicKeys: fftypes.PublicKeys{{Type: fftypes.KeyTypeP256, Key: "oldkey"}},
+	}, nil)
+	valid, err := bm.HandleSystemBroadcast(context.Background(), &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			Namespace: "ns1",
+			Author:    "0x23456",
+			Tag:       string(fftypes.SystemTagDefineOrganization),
+		},
+	}, []*fftypes.Data{data})
+	assert.False(t, valid)
+	assert.NoError(t, err)
+
+	// The keys can only be changed by a versioned update
+	mdi.AssertNotCalled(t, "UpsertOrganization", mock.Anything, mock.Anything, mock.Anything)
+	mdi.AssertNotCalled(t, "InsertOrganizationVersion", mock.Anything, mock.Anything)
 	mii.AssertExpectations(t)
 	mdi.AssertExpectations(t)
 }
@@ -126,6 +189,7 @@ func TestHandleSystemBroadcastOrgDupMismatch(t *testing.T) {
 	}
 
 	mii := bm.identity.(*identitymocks.Plugin)
+	mii.On("VerifyOrganization", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
 	mii.On("Resolve", mock.Anything, "0x23456").Return(&fftypes.Identity{OnChain: "0x23456"}, nil)
 	mdi := bm.database.(*databasemocks.Plugin)
 	mdi.On("GetOrganizationByIdentity", mock.Anything, "0x23456").Return(&fftypes.Organization{ID: fftypes.NewUUID(), Identity: "0x23456"}, nil)
@@ -162,8 +226,10 @@ func TestHandleSystemBroadcastOrgUpsertFail(t *testing.T) {
 	}
 
 	mii := bm.identity.(*identitymocks.Plugin)
+	mii.On("VerifyOrganization", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
 	mii.On("Resolve", mock.Anything, "0x12345").Return(&fftypes.Identity{OnChain: "0x12345"}, nil)
 	mdi := bm.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationVersions", mock.Anything, mock.Anything).Return([]*fftypes.Organization{}, nil, nil)
 	mdi.On("GetOrganizationByIdentity", mock.Anything, "0x12345").Return(nil, nil)
 	mdi.On("GetOrganizationByName", mock.Anything, "org1").Return(nil, nil)
 	mdi.On("GetOrganizationByID", mock.Anything, org.ID).Return(nil, nil)
@@ -200,6 +266,7 @@ func TestHandleSystemBroadcastOrgGetOrgFail(t *testing.T) {
 	}
 
 	mii := bm.identity.(*identitymocks.Plugin)
+	mii.On("VerifyOrganization", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
 	mii.On("Resolve", mock.Anything, "0x12345").Return(&fftypes.Identity{OnChain: "0x12345"}, nil)
 	mdi := bm.database.(*databasemocks.Plugin)
 	mdi.On("GetOrganizationByIdentity", mock.Anything, "0x12345").Return(nil, fmt.Errorf("pop"))
@@ -235,6 +302,7 @@ func TestHandleSystemBroadcastOrgAuthorMismatch(t *testing.T) {
 	}
 
 	mii := bm.identity.(*identitymocks.Plugin)
+	mii.On("VerifyOrganization", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
 	mii.On("Resolve", mock.Anything, "0x12345").Return(&fftypes.Identity{OnChain: "0x12345"}, nil)
 	mdi := bm.database.(*databasemocks.Plugin)
 	valid, err := bm.HandleSystemBroadcast(context.Background(), &fftypes.Message{
@@ -269,6 +337,7 @@ func TestHandleSystemBroadcastOrgResolveFail(t *testing.T) {
 	}
 
 	mii := bm.identity.(*identitymocks.Plugin)
+	mii.On("VerifyOrganization", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
 	mii.On("Resolve", mock.Anything, "0x12345").Return(nil, fmt.Errorf("pop"))
 	mdi := bm.database.(*databasemocks.Plugin)
 	valid, err := bm.HandleSystemBroadcast(context.Background(), &fftypes.Message{
@@ -351,6 +420,37 @@ func TestHandleSystemBroadcastGetParentNotFound(t *testing.T) {
 	mdi.AssertExpectations(t)
 }
 
+func TestHandleSystemBroadcastOrgVerifyFail(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	org := &fftypes.Organization{
+		ID:          fftypes.NewUUID(),
+		Name:        "org1",
+		Identity:    "0x12345",
+		Description: "my org",
+	}
+	b, err := json.Marshal(&org)
+	assert.NoError(t, err)
+	data := &fftypes.Data{
+		Value: fftypes.Byteable(b),
+	}
+
+	mii := bm.identity.(*identitymocks.Plugin)
+	mii.On("VerifyOrganization", mock.Anything, mock.Anything, (*fftypes.Organization)(nil), mock.Anything).Return(fmt.Errorf("pop"))
+	valid, err := bm.HandleSystemBroadcast(context.Background(), &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			Namespace: "ns1",
+			Author:    "0x12345",
+			Tag:       string(fftypes.SystemTagDefineOrganization),
+		},
+	}, []*fftypes.Data{data})
+	assert.False(t, valid)
+	assert.NoError(t, err)
+
+	mii.AssertExpectations(t)
+}
+
 func TestHandleSystemBroadcastValidateFail(t *testing.T) {
 	bm, cancel := newTestBroadcast(t)
 	defer cancel()
@@ -396,3 +496,159 @@ func TestHandleSystemBroadcastUnmarshalFail(t *testing.T) {
 	assert.False(t, valid)
 	assert.NoError(t, err)
 }
+
+func TestHandleSystemBroadcastGetParentRevoked(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	org := &fftypes.Organization{
+		ID:          fftypes.NewUUID(),
+		Name:        "org1",
+		Identity:    "0x12345",
+		Parent:      "0x23456",
+		Description: "my org",
+		Profile:     fftypes.JSONObject{"some": "info"},
+	}
+	b, err := json.Marshal(&org)
+	assert.NoError(t, err)
+	data := &fftypes.Data{
+		Value: fftypes.Byteable(b),
+	}
+
+	mdi := bm.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByIdentity", mock.Anything, "0x23456").Return(&fftypes.Organization{Identity: "0x23456", Revoked: fftypes.Now()}, nil)
+	valid, err := bm.HandleSystemBroadcast(context.Background(), &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			Namespace: "ns1",
+			Author:    "0x23456",
+			Tag:       string(fftypes.SystemTagDefineOrganization),
+		},
+	}, []*fftypes.Data{data})
+	assert.False(t, valid)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleSystemBroadcastOrgInsertVersionFail(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	org := &fftypes.Organization{
+		ID:          fftypes.NewUUID(),
+		Name:        "org1",
+		Identity:    "0x12345",
+		Parent:      "0x23456",
+		Description: "my org",
+		Profile:     fftypes.JSONObject{"some": "info"},
+	}
+	b, err := json.Marshal(&org)
+	assert.NoError(t, err)
+	data := &fftypes.Data{
+		Value: fftypes.Byteable(b),
+	}
+
+	mii := bm.identity.(*identitymocks.Plugin)
+	mii.On("VerifyOrganization", mock.Anything, mock.MatchedBy(func(o *fftypes.Organization) bool {
+		return o.Identity == "0x12345"
+	}), mock.MatchedBy(func(parent *fftypes.Organization) bool {
+		return parent.Identity == "0x23456"
+	}), mock.Anything).Return(nil)
+	mii.On("Resolve", mock.Anything, "0x23456").Return(&fftypes.Identity{OnChain: "0x23456"}, nil)
+	mdi := bm.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationVersions", mock.Anything, mock.Anything).Return([]*fftypes.Organization{}, nil, nil)
+	mdi.On("GetOrganizationByIdentity", mock.Anything, "0x23456").Return(&fftypes.Organization{ID: fftypes.NewUUID(), Identity: "0x23456"}, nil)
+	mdi.On("GetOrganizationByIdentity", mock.Anything, "0x12345").Return(nil, nil)
+	mdi.On("GetOrganizationByName", mock.Anything, "org1").Return(nil, nil)
+	mdi.On("GetOrganizationByID", mock.Anything, org.ID).Return(nil, nil)
+	mdi.On("UpsertOrganization", mock.Anything, mock.Anything, true).Return(nil)
+	mdi.On("InsertOrganizationVersion", mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+	valid, err := bm.HandleSystemBroadcast(context.Background(), &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			Namespace: "ns1",
+			Author:    "0x23456",
+			Tag:       string(fftypes.SystemTagDefineOrganization),
+		},
+	}, []*fftypes.Data{data})
+	assert.False(t, valid)
+	assert.EqualError(t, err, "pop")
+
+	mii.AssertExpectations(t)
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleSystemBroadcastOrgRootSignatureInvalid(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	org := &fftypes.Organization{
+		ID:          fftypes.NewUUID(),
+		Name:        "org1",
+		Identity:    "0x12345",
+		Description: "my org",
+		PublicKeys: fftypes.PublicKeys{
+			{Type: fftypes.KeyTypeEd25519, Key: "cHVibGlja2V5"},
+		},
+	}
+	b, err := json.Marshal(&org)
+	assert.NoError(t, err)
+	data := &fftypes.Data{
+		Value: fftypes.Byteable(b),
+	}
+
+	mii := bm.identity.(*identitymocks.Plugin)
+	mii.On("VerifyOrganization", mock.Anything, mock.Anything, (*fftypes.Organization)(nil), mock.Anything).Return(nil)
+	mii.On("Resolve", mock.Anything, "0x12345").Return(&fftypes.Identity{OnChain: "0x12345"}, nil)
+	valid, err := bm.HandleSystemBroadcast(context.Background(), &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			Namespace: "ns1",
+			Author:    "0x12345",
+			Tag:       string(fftypes.SystemTagDefineOrganization),
+		},
+		Hash: fftypes.NewRandB32(),
+	}, []*fftypes.Data{data})
+	assert.False(t, valid)
+	assert.NoError(t, err)
+
+	mii.AssertExpectations(t)
+}
+
+func TestHandleSystemBroadcastOrgIdentityRetired(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	org := &fftypes.Organization{
+		ID:          fftypes.NewUUID(),
+		Name:        "org1",
+		Identity:    "0x12345",
+		Parent:      "0x23456",
+		Description: "my org",
+	}
+	b, err := json.Marshal(&org)
+	assert.NoError(t, err)
+	data := &fftypes.Data{
+		Value: fftypes.Byteable(b),
+	}
+
+	mii := bm.identity.(*identitymocks.Plugin)
+	mii.On("VerifyOrganization", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mii.On("Resolve", mock.Anything, "0x23456").Return(&fftypes.Identity{OnChain: "0x23456"}, nil)
+	mdi := bm.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationVersions", mock.Anything, mock.Anything).Return([]*fftypes.Organization{{Identity: "0x12345"}}, nil, nil)
+	mdi.On("GetOrganizationByIdentity", mock.Anything, "0x23456").Return(&fftypes.Organization{ID: fftypes.NewUUID(), Identity: "0x23456"}, nil)
+	mdi.On("GetOrganizationByIdentity", mock.Anything, "0x12345").Return(nil, nil)
+	mdi.On("GetOrganizationByName", mock.Anything, "org1").Return(nil, nil)
+	mdi.On("GetOrganizationByID", mock.Anything, org.ID).Return(nil, nil)
+	valid, err := bm.HandleSystemBroadcast(context.Background(), &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			Namespace: "ns1",
+			Author:    "0x23456",
+			Tag:       string(fftypes.SystemTagDefineOrganization),
+		},
+	}, []*fftypes.Data{data})
+	assert.False(t, valid)
+	assert.NoError(t, err)
+
+	mii.AssertExpectations(t)
+	mdi.AssertExpectations(t)
+}
@@ -0,0 +1,356 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package broadcast
+
+import (
+	"context"
+
+	"github.com/hyperledger-labs/firefly/internal/log"
+	"github.com/hyperledger-labs/firefly/pkg/database"
+	"github.com/hyperledger-labs/firefly/pkg/fftypes"
+)
+
+// isAuthorizedAuthor checks the author of an update is either the current identity of the
+// organization, or the parent of that organization
+func (bm *broadcastManager) isAuthorizedAuthor(ctx context.Context, msg *fftypes.Message, org *fftypes.Organization) bool {
+	l := log.L(ctx)
+	signers := []string{org.Identity}
+	if org.Parent != "" {
+		signers = append(signers, org.Parent)
+	}
+	for _, signer := range signers {
+		id, err := bm.identity.Resolve(ctx, signer)
+		if err != nil {
+			l.Warnf("Unable to process update %s - resolve identity '%s' failed: %s", msg.Header.ID, signer, err)
+			return false
+		}
+		if msg.Header.Author == id.OnChain {
+			return true
+		}
+	}
+	l.Warnf("Unable to process update %s - incorrect signature. Expected=%v Received=%s", msg.Header.ID, signers, msg.Header.Author)
+	return false
+}
+
+func (bm *broadcastManager) getActiveOrganization(ctx context.Context, msg *fftypes.Message, id *fftypes.UUID) (*fftypes.Organization, error) {
+	org, err := bm.database.GetOrganizationByID(ctx, id)
+	if err != nil {
+		return nil, err // We only return database errors
+	}
+	if org == nil || org.Revoked != nil {
+		log.L(ctx).Warnf("Unable to process update %s - organization %s not found, or revoked", msg.Header.ID, id)
+		return nil, nil
+	}
+	return org, nil
+}
+
+func (bm *broadcastManager) handleOrganizationUpdateBroadcast(ctx context.Context, msg *fftypes.Message, data []*fftypes.Data) (valid bool, err error) {
+	l := log.L(ctx)
+
+	var org fftypes.Organization
+	valid = bm.getSystemBroadcastPayload(ctx, msg, data, &org)
+	if !valid {
+		return false, nil
+	}
+
+	if err = org.Validate(ctx, true); err != nil {
+		l.Warnf("Unable to process organization update %s - validate failed: %s", msg.Header.ID, err)
+		return false, nil
+	}
+
+	existing, err := bm.getActiveOrganization(ctx, msg, org.ID)
+	if err != nil || existing == nil {
+		return false, err
+	}
+	if existing.Parent != org.Parent {
+		l.Warnf("Unable to process organization update %s - parent cannot be changed", msg.Header.ID)
+		return false, nil
+	}
+	if !bm.isAuthorizedAuthor(ctx, msg, existing) {
+		return false, nil
+	}
+
+	var parent *fftypes.Organization
+	if org.Parent != "" {
+		if parent, err = bm.database.GetOrganizationByIdentity(ctx, org.Parent); err != nil {
+			return false, err // We only return database errors
+		}
+		if parent == nil || parent.Revoked != nil {
+			l.Warnf("Unable to process organization update %s - parent identity not found, or revoked: %s", msg.Header.ID, org.Parent)
+			return false, nil
+		}
+	}
+
+	var conflict *fftypes.Organization
+	retired := false
+	if org.Identity != existing.Identity {
+		conflict, err = bm.database.GetOrganizationByIdentity(ctx, org.Identity)
+		if err == nil && conflict == nil {
+			retired, err = bm.isRetiredIdentity(ctx, org.Identity)
+		}
+	}
+	if err == nil && conflict == nil && org.Name != existing.Name {
+		conflict, err = bm.database.GetOrganizationByName(ctx, org.Name)
+	}
+	if err != nil {
+		return false, err // We only return database errors
+	}
+	if conflict != nil {
+		l.Warnf("Unable to process organization update %s - conflict with existing %v", msg.Header.ID, conflict.ID)
+		return false, nil
+	}
+	if retired {
+		l.Warnf("Unable to process organization update %s - identity '%s' has been retired", msg.Header.ID, org.Identity)
+		return false, nil
+	}
+
+	if err = bm.identity.VerifyOrganization(ctx, &org, parent, msg.Header.Created); err != nil {
+		l.Warnf("Unable to process organization update %s - credentials invalid: %s", msg.Header.ID, err)
+		return false, nil
+	}
+
+	if org.Identity != existing.Identity {
+		// Moving to a new identity requires proof of control of it, signed with the keys published for it
+		if err = org.PublicKeys.VerifySignature(ctx, org.IdentityClaimHash(existing.Version+1), org.IdentityProof); err != nil {
+			l.Warnf("Unable to process organization update %s - no proof of control of identity '%s': %s", msg.Header.ID, org.Identity, err)
+			return false, nil
+		}
+	} else {
+		org.IdentityProof = ""
+		// An organization that adopts signing publishes its first keys in an update it signs with them
+		if len(existing.PublicKeys) == 0 && len(org.PublicKeys) > 0 {
+			if err = msg.VerifySignature(ctx, org.PublicKeys); err != nil {
+				l.Warnf("Unable to process organization update %s - not signed with the published keys: %s", msg.Header.ID, err)
+				return false, nil
+			}
+		}
+	}
+
+	org.Version = existing.Version + 1
+	org.Created = existing.Created
+	org.Updated = msg.Header.Created
+	org.Revoked = nil
+
+	if org.Identity != existing.Identity {
+		if err = bm.rotateOrganizationIdentity(ctx, existing, org.Identity); err != nil {
+			return false, err
+		}
+	}
+
+	return bm.storeOrganizationVersion(ctx, msg, &org)
+}
+
+// isRetiredIdentity checks whether an identity was held by an organization that has since moved to a new identity.
+// Retired identities are treated as revoked, so cannot be registered or moved to again.
+func (bm *broadcastManager) isRetiredIdentity(ctx context.Context, identity string) (bool, error) {
+	fb := database.OrganizationQueryFactory.NewFilter(ctx)
+	versions, _, err := bm.database.GetOrganizationVersions(ctx, fb.And(fb.Eq("identity", identity)).Limit(1))
+	if err != nil {
+		return false, err
+	}
+	return len(versions) > 0, nil
+}
+
+// rotateOrganizationIdentity moves the organization, and everything that refers to it by identity, to the new identity
+func (bm *broadcastManager) rotateOrganizationIdentity(ctx context.Context, existing *fftypes.Organization, newIdentity string) error {
+	err := bm.database.UpdateOrganization(ctx, existing.ID, database.OrganizationQueryFactory.NewUpdate(ctx).Set("identity", newIdentity))
+	if err != nil {
+		return err
+	}
+
+	nfb := database.NodeQueryFactory.NewFilter(ctx)
+	nodes, _, err := bm.database.GetNodes(ctx, nfb.Eq("owner", existing.Identity))
+	if err != nil {
+		return err
+	}
+	for _, node := range nodes {
+		if err = bm.database.UpdateNode(ctx, node.ID, database.NodeQueryFactory.NewUpdate(ctx).Set("owner", newIdentity)); err != nil {
+			return err
+		}
+	}
+
+	ofb := database.OrganizationQueryFactory.NewFilter(ctx)
+	children, _, err := bm.database.GetOrganizations(ctx, ofb.Eq("parent", existing.Identity))
+	if err != nil {
+		return err
+	}
+	for _, child := range children {
+		if err = bm.database.UpdateOrganization(ctx, child.ID, database.OrganizationQueryFactory.NewUpdate(ctx).Set("parent", newIdentity)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (bm *broadcastManager) handleOrganizationRevokeBroadcast(ctx context.Context, msg *fftypes.Message, data []*fftypes.Data) (valid bool, err error) {
+	l := log.L(ctx)
+
+	var org fftypes.Organization
+	valid = bm.getSystemBroadcastPayload(ctx, msg, data, &org)
+	if !valid {
+		return false, nil
+	}
+	if org.ID == nil {
+		l.Warnf("Unable to process organization revocation %s - missing ID", msg.Header.ID)
+		return false, nil
+	}
+
+	existing, err := bm.getActiveOrganization(ctx, msg, org.ID)
+	if err != nil || existing == nil {
+		return false, err
+	}
+	if !bm.isAuthorizedAuthor(ctx, msg, existing) {
+		return false, nil
+	}
+
+	existing.Message = msg.Header.ID
+	existing.Version++
+	existing.Updated = msg.Header.Created
+	existing.Revoked = msg.Header.Created
+
+	return bm.storeOrganizationVersion(ctx, msg, existing)
+}
+
+func (bm *broadcastManager) storeOrganizationVersion(ctx context.Context, msg *fftypes.Message, org *fftypes.Organization) (valid bool, err error) {
+	if err = bm.database.UpsertOrganization(ctx, org, true); err != nil {
+		return false, err
+	}
+	if err = bm.database.InsertOrganizationVersion(ctx, org); err != nil {
+		return false, err
+	}
+	event := fftypes.NewEvent(fftypes.EventTypeOrganizationUpdated, fftypes.SystemNamespace, org.ID, msg.Header.Group)
+	if err = bm.database.UpsertEvent(ctx, event, false); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (bm *broadcastManager) getActiveNode(ctx context.Context, msg *fftypes.Message, id *fftypes.UUID) (node *fftypes.Node, owner *fftypes.Organization, err error) {
+	l := log.L(ctx)
+	node, err = bm.database.GetNodeByID(ctx, id)
+	if err != nil {
+		return nil, nil, err // We only return database errors
+	}
+	if node == nil || node.Revoked != nil {
+		l.Warnf("Unable to process update %s - node %s not found, or revoked", msg.Header.ID, id)
+		return nil, nil, nil
+	}
+	owner, err = bm.database.GetOrganizationByIdentity(ctx, node.Owner)
+	if err != nil {
+		return nil, nil, err // We only return database errors
+	}
+	if owner == nil || owner.Revoked != nil {
+		l.Warnf("Unable to process update %s - owner %s not found, or revoked", msg.Header.ID, node.Owner)
+		return nil, nil, nil
+	}
+	return node, owner, nil
+}
+
+func (bm *broadcastManager) handleNodeUpdateBroadcast(ctx context.Context, msg *fftypes.Message, data []*fftypes.Data) (valid bool, err error) {
+	l := log.L(ctx)
+
+	var node fftypes.Node
+	valid = bm.getSystemBroadcastPayload(ctx, msg, data, &node)
+	if !valid {
+		return false, nil
+	}
+
+	if err = node.Validate(ctx, true); err != nil {
+		l.Warnf("Unable to process node update %s - validate failed: %s", msg.Header.ID, err)
+		return false, nil
+	}
+
+	existing, owner, err := bm.getActiveNode(ctx, msg, node.ID)
+	if err != nil || existing == nil {
+		return false, err
+	}
+	if existing.Owner != node.Owner || existing.Name != node.Name {
+		l.Warnf("Unable to process node update %s - owner and name cannot be changed", msg.Header.ID)
+		return false, nil
+	}
+	if !bm.isAuthorizedAuthor(ctx, msg, owner) {
+		return false, nil
+	}
+
+	node.Version = existing.Version + 1
+	node.Created = existing.Created
+	node.Updated = msg.Header.Created
+	node.Revoked = nil
+
+	if valid, err = bm.storeNodeVersion(ctx, msg, &node); !valid || err != nil {
+		return valid, err
+	}
+
+	// Tell the data exchange about the new details. Treat these errors like database errors - and return for retry processing
+	if err = bm.exchange.AddPeer(ctx, &node); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (bm *broadcastManager) handleNodeRevokeBroadcast(ctx context.Context, msg *fftypes.Message, data []*fftypes.Data) (valid bool, err error) {
+	l := log.L(ctx)
+
+	var node fftypes.Node
+	valid = bm.getSystemBroadcastPayload(ctx, msg, data, &node)
+	if !valid {
+		return false, nil
+	}
+	if node.ID == nil {
+		l.Warnf("Unable to process node revocation %s - missing ID", msg.Header.ID)
+		return false, nil
+	}
+
+	existing, owner, err := bm.getActiveNode(ctx, msg, node.ID)
+	if err != nil || existing == nil {
+		return false, err
+	}
+	if !bm.isAuthorizedAuthor(ctx, msg, owner) {
+		return false, nil
+	}
+
+	existing.Message = msg.Header.ID
+	existing.Version++
+	existing.Updated = msg.Header.Created
+	existing.Revoked = msg.Header.Created
+
+	if valid, err = bm.storeNodeVersion(ctx, msg, existing); !valid || err != nil {
+		return valid, err
+	}
+
+	// Tell the data exchange the peer has left. Treat these errors like database errors - and return for retry processing
+	if err = bm.exchange.RemovePeer(ctx, existing); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (bm *broadcastManager) storeNodeVersion(ctx context.Context, msg *fftypes.Message, node *fftypes.Node) (valid bool, err error) {
+	if err = bm.database.UpsertNode(ctx, node, true); err != nil {
+		return false, err
+	}
+	if err = bm.database.InsertNodeVersion(ctx, node); err != nil {
+		return false, err
+	}
+	event := fftypes.NewEvent(fftypes.EventTypeNodeUpdated, fftypes.SystemNamespace, node.ID, msg.Header.Group)
+	if err = bm.database.UpsertEvent(ctx, event, false); err != nil {
+		return false, err
+	}
+	return true, nil
+}
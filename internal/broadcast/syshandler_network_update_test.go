@@ -0,0 +1,1012 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package broadcast
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger-labs/firefly/mocks/databasemocks"
+	"github.com/hyperledger-labs/firefly/mocks/dataexchangemocks"
+	"github.com/hyperledger-labs/firefly/mocks/identitymocks"
+	"github.com/hyperledger-labs/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestUpdateMessage(t *testing.T, tag fftypes.SystemTag, author string, payload interface{}) (*fftypes.Message, []*fftypes.Data) {
+	b, err := json.Marshal(payload)
+	assert.NoError(t, err)
+	return &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			ID:        fftypes.NewUUID(),
+			Namespace: fftypes.SystemNamespace,
+			Author:    author,
+			Tag:       string(tag),
+			Created:   fftypes.Now(),
+		},
+	}, []*fftypes.Data{
+		{Value: fftypes.Byteable(b)},
+	}
+}
+
+func newTestExistingOrg() *fftypes.Organization {
+	return &fftypes.Organization{
+		ID:       fftypes.NewUUID(),
+		Name:     "org1",
+		Identity: "0x12345",
+		Parent:   "0x23456",
+		Version:  1,
+		Created:  fftypes.Now(),
+	}
+}
+
+func newTestRotatedOrg(t *testing.T, existing *fftypes.Organization, identity string) *fftypes.Organization {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	org := *existing
+	org.Identity = identity
+	org.PublicKeys = fftypes.PublicKeys{
+		{Type: fftypes.KeyTypeEd25519, Key: base64.StdEncoding.EncodeToString(pub)},
+	}
+	org.IdentityProof = base64.StdEncoding.EncodeToString(ed25519.Sign(priv, org.IdentityClaimHash(existing.Version + 1)[:]))
+	return &org
+}
+
+func newTestExistingNode() *fftypes.Node {
+	return &fftypes.Node{
+		ID:      fftypes.NewUUID(),
+		Name:    "node1",
+		Owner:   "0x12345",
+		Version: 1,
+		Created: fftypes.Now(),
+		DX: fftypes.DXInfo{
+			Peer: "peer1",
+		},
+	}
+}
+
+func TestHandleSystemBroadcastOrgUpdateOk(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	existing := newTestExistingOrg()
+	org := *existing
+	org.Description = "updated"
+	org.Version = 0
+	msg, data := newTestUpdateMessage(t, fftypes.SystemTagUpdateOrganization, "0x23456", &org)
+
+	mii := bm.identity.(*identitymocks.Plugin)
+	mii.On("Resolve", mock.Anything, "0x12345").Return(&fftypes.Identity{OnChain: "0x12345"}, nil)
+	mii.On("Resolve", mock.Anything, "0x23456").Return(&fftypes.Identity{OnChain: "0x23456"}, nil)
+	mii.On("VerifyOrganization", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mdi := bm.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByID", mock.Anything, existing.ID).Return(existing, nil)
+	mdi.On("GetOrganizationByIdentity", mock.Anything, "0x23456").Return(&fftypes.Organization{Identity: "0x23456"}, nil)
+	mdi.On("UpsertOrganization", mock.Anything, mock.MatchedBy(func(o *fftypes.Organization) bool {
+		return o.Version == 2 && o.Description == "updated" && o.Created == existing.Created && o.Message == msg.Header.ID
+	}), true).Return(nil)
+	mdi.On("InsertOrganizationVersion", mock.Anything, mock.Anything).Return(nil)
+	mdi.On("UpsertEvent", mock.Anything, mock.MatchedBy(func(e *fftypes.Event) bool {
+		return e.Type == fftypes.EventTypeOrganizationUpdated && *e.Reference == *existing.ID
+	}), false).Return(nil)
+
+	valid, err := bm.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.True(t, valid)
+	assert.NoError(t, err)
+
+	mii.AssertExpectations(t)
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleSystemBroadcastOrgUpdateRotateOk(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	existing := newTestExistingOrg()
+	existing.Parent = ""
+	org := newTestRotatedOrg(t, existing, "0x99999")
+	msg, data := newTestUpdateMessage(t, fftypes.SystemTagUpdateOrganization, "0x12345", org)
+
+	node := newTestExistingNode()
+	child := &fftypes.Organization{ID: fftypes.NewUUID(), Parent: "0x12345"}
+	mii := bm.identity.(*identitymocks.Plugin)
+	mii.On("Resolve", mock.Anything, "0x12345").Return(&fftypes.Identity{OnChain: "0x12345"}, nil)
+	mii.On("VerifyOrganization", mock.Anything, mock.Anything, (*fftypes.Organization)(nil), mock.Anything).Return(nil)
+	mdi := bm.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationVersions", mock.Anything, mock.Anything).Return([]*fftypes.Organization{}, nil, nil)
+	mdi.On("GetOrganizationByID", mock.Anything, existing.ID).Return(existing, nil)
+	mdi.On("GetOrganizationByIdentity", mock.Anything, "0x99999").Return(nil, nil)
+	mdi.On("UpdateOrganization", mock.Anything, existing.ID, mock.Anything).Return(nil)
+	mdi.On("GetNodes", mock.Anything, mock.Anything).Return([]*fftypes.Node{node}, nil, nil)
+	mdi.On("UpdateNode", mock.Anything, node.ID, mock.Anything).Return(nil)
+	mdi.On("GetOrganizations", mock.Anything, mock.Anything).Return([]*fftypes.Organization{child}, nil, nil)
+	mdi.On("UpdateOrganization", mock.Anything, child.ID, mock.Anything).Return(nil)
+	mdi.On("UpsertOrganization", mock.Anything, mock.MatchedBy(func(o *fftypes.Organization) bool {
+		return o.Version == 2 && o.Identity == "0x99999"
+	}), true).Return(nil)
+	mdi.On("InsertOrganizationVersion", mock.Anything, mock.Anything).Return(nil)
+	mdi.On("UpsertEvent", mock.Anything, mock.Anything, false).Return(nil)
+
+	valid, err := bm.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.True(t, valid)
+	assert.NoError(t, err)
+
+	mii.AssertExpectations(t)
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleSystemBroadcastOrgUpdateRotateFail(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	existing := newTestExistingOrg()
+	existing.Parent = ""
+	org := newTestRotatedOrg(t, existing, "0x99999")
+	msg, data := newTestUpdateMessage(t, fftypes.SystemTagUpdateOrganization, "0x12345", org)
+
+	mii := bm.identity.(*identitymocks.Plugin)
+	mii.On("Resolve", mock.Anything, "0x12345").Return(&fftypes.Identity{OnChain: "0x12345"}, nil)
+	mii.On("VerifyOrganization", mock.Anything, mock.Anything, (*fftypes.Organization)(nil), mock.Anything).Return(nil)
+	mdi := bm.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationVersions", mock.Anything, mock.Anything).Return([]*fftypes.Organization{}, nil, nil)
+	mdi.On("GetOrganizationByID", mock.Anything, existing.ID).Return(existing, nil)
+	mdi.On("GetOrganizationByIdentity", mock.Anything, "0x99999").Return(nil, nil)
+	mdi.On("UpdateOrganization", mock.Anything, existing.ID, mock.Anything).Return(fmt.Errorf("pop"))
+
+	valid, err := bm.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.False(t, valid)
+	assert.EqualError(t, err, "pop")
+
+	mii.AssertExpectations(t)
+	mdi.AssertExpectations(t)
+}
+
+func TestRotateOrganizationIdentityGetNodesFail(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	mdi := bm.database.(*databasemocks.Plugin)
+	mdi.On("UpdateOrganization", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mdi.On("GetNodes", mock.Anything, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+
+	err := bm.rotateOrganizationIdentity(context.Background(), newTestExistingOrg(), "0x99999")
+	assert.EqualError(t, err, "pop")
+}
+
+func TestRotateOrganizationIdentityUpdateNodeFail(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	mdi := bm.database.(*databasemocks.Plugin)
+	mdi.On("UpdateOrganization", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mdi.On("GetNodes", mock.Anything, mock.Anything).Return([]*fftypes.Node{newTestExistingNode()}, nil, nil)
+	mdi.On("UpdateNode", mock.Anything, mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+
+	err := bm.rotateOrganizationIdentity(context.Background(), newTestExistingOrg(), "0x99999")
+	assert.EqualError(t, err, "pop")
+}
+
+func TestRotateOrganizationIdentityGetChildrenFail(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	mdi := bm.database.(*databasemocks.Plugin)
+	mdi.On("UpdateOrganization", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mdi.On("GetNodes", mock.Anything, mock.Anything).Return([]*fftypes.Node{}, nil, nil)
+	mdi.On("GetOrganizations", mock.Anything, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+
+	err := bm.rotateOrganizationIdentity(context.Background(), newTestExistingOrg(), "0x99999")
+	assert.EqualError(t, err, "pop")
+}
+
+func TestRotateOrganizationIdentityUpdateChildFail(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	existing := newTestExistingOrg()
+	child := &fftypes.Organization{ID: fftypes.NewUUID(), Parent: existing.Identity}
+	mdi := bm.database.(*databasemocks.Plugin)
+	mdi.On("UpdateOrganization", mock.Anything, existing.ID, mock.Anything).Return(nil)
+	mdi.On("GetNodes", mock.Anything, mock.Anything).Return([]*fftypes.Node{}, nil, nil)
+	mdi.On("GetOrganizations", mock.Anything, mock.Anything).Return([]*fftypes.Organization{child}, nil, nil)
+	mdi.On("UpdateOrganization", mock.Anything, child.ID, mock.Anything).Return(fmt.Errorf("pop"))
+
+	err := bm.rotateOrganizationIdentity(context.Background(), existing, "0x99999")
+	assert.EqualError(t, err, "pop")
+}
+
+func TestHandleSystemBroadcastOrgUpdateUnmarshalFail(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	msg, _ := newTestUpdateMessage(t, fftypes.SystemTagUpdateOrganization, "0x12345", nil)
+	valid, err := bm.HandleSystemBroadcast(context.Background(), msg, []*fftypes.Data{{Value: fftypes.Byteable(`!json`)}})
+	assert.False(t, valid)
+	assert.NoError(t, err)
+}
+
+func TestHandleSystemBroadcastOrgUpdateValidateFail(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	msg, data := newTestUpdateMessage(t, fftypes.SystemTagUpdateOrganization, "0x12345", &fftypes.Organization{Name: "org1"})
+	valid, err := bm.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.False(t, valid)
+	assert.NoError(t, err)
+}
+
+func TestHandleSystemBroadcastOrgUpdateGetFail(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	existing := newTestExistingOrg()
+	msg, data := newTestUpdateMessage(t, fftypes.SystemTagUpdateOrganization, "0x12345", existing)
+	mdi := bm.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByID", mock.Anything, existing.ID).Return(nil, fmt.Errorf("pop"))
+
+	valid, err := bm.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.False(t, valid)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestHandleSystemBroadcastOrgUpdateRevoked(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	existing := newTestExistingOrg()
+	existing.Revoked = fftypes.Now()
+	msg, data := newTestUpdateMessage(t, fftypes.SystemTagUpdateOrganization, "0x12345", existing)
+	mdi := bm.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByID", mock.Anything, existing.ID).Return(existing, nil)
+
+	valid, err := bm.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.False(t, valid)
+	assert.NoError(t, err)
+}
+
+func TestHandleSystemBroadcastOrgUpdateParentChanged(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	existing := newTestExistingOrg()
+	org := *existing
+	org.Parent = "0x34567"
+	msg, data := newTestUpdateMessage(t, fftypes.SystemTagUpdateOrganization, "0x12345", &org)
+	mdi := bm.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByID", mock.Anything, existing.ID).Return(existing, nil)
+
+	valid, err := bm.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.False(t, valid)
+	assert.NoError(t, err)
+}
+
+func TestHandleSystemBroadcastOrgUpdateBadAuthor(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	existing := newTestExistingOrg()
+	msg, data := newTestUpdateMessage(t, fftypes.SystemTagUpdateOrganization, "0x99999", existing)
+	mii := bm.identity.(*identitymocks.Plugin)
+	mii.On("Resolve", mock.Anything, "0x12345").Return(&fftypes.Identity{OnChain: "0x12345"}, nil)
+	mii.On("Resolve", mock.Anything, "0x23456").Return(&fftypes.Identity{OnChain: "0x23456"}, nil)
+	mdi := bm.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByID", mock.Anything, existing.ID).Return(existing, nil)
+
+	valid, err := bm.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.False(t, valid)
+	assert.NoError(t, err)
+}
+
+func TestHandleSystemBroadcastOrgUpdateResolveFail(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	existing := newTestExistingOrg()
+	msg, data := newTestUpdateMessage(t, fftypes.SystemTagUpdateOrganization, "0x12345", existing)
+	mii := bm.identity.(*identitymocks.Plugin)
+	mii.On("Resolve", mock.Anything, "0x12345").Return(nil, fmt.Errorf("pop"))
+	mdi := bm.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByID", mock.Anything, existing.ID).Return(existing, nil)
+
+	valid, err := bm.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.False(t, valid)
+	assert.NoError(t, err)
+}
+
+func TestHandleSystemBroadcastOrgUpdateGetParentFail(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	existing := newTestExistingOrg()
+	msg, data := newTestUpdateMessage(t, fftypes.SystemTagUpdateOrganization, "0x12345", existing)
+	mii := bm.identity.(*identitymocks.Plugin)
+	mii.On("Resolve", mock.Anything, "0x12345").Return(&fftypes.Identity{OnChain: "0x12345"}, nil)
+	mdi := bm.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByID", mock.Anything, existing.ID).Return(existing, nil)
+	mdi.On("GetOrganizationByIdentity", mock.Anything, "0x23456").Return(nil, fmt.Errorf("pop"))
+
+	valid, err := bm.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.False(t, valid)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestHandleSystemBroadcastOrgUpdateParentRevoked(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	existing := newTestExistingOrg()
+	msg, data := newTestUpdateMessage(t, fftypes.SystemTagUpdateOrganization, "0x12345", existing)
+	mii := bm.identity.(*identitymocks.Plugin)
+	mii.On("Resolve", mock.Anything, "0x12345").Return(&fftypes.Identity{OnChain: "0x12345"}, nil)
+	mdi := bm.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByID", mock.Anything, existing.ID).Return(existing, nil)
+	mdi.On("GetOrganizationByIdentity", mock.Anything, "0x23456").Return(&fftypes.Organization{Revoked: fftypes.Now()}, nil)
+
+	valid, err := bm.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.False(t, valid)
+	assert.NoError(t, err)
+}
+
+func TestHandleSystemBroadcastOrgUpdateIdentityConflict(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	existing := newTestExistingOrg()
+	existing.Parent = ""
+	org := *existing
+	org.Identity = "0x99999"
+	msg, data := newTestUpdateMessage(t, fftypes.SystemTagUpdateOrganization, "0x12345", &org)
+	mii := bm.identity.(*identitymocks.Plugin)
+	mii.On("Resolve", mock.Anything, "0x12345").Return(&fftypes.Identity{OnChain: "0x12345"}, nil)
+	mdi := bm.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByID", mock.Anything, existing.ID).Return(existing, nil)
+	mdi.On("GetOrganizationByIdentity", mock.Anything, "0x99999").Return(&fftypes.Organization{ID: fftypes.NewUUID()}, nil)
+
+	valid, err := bm.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.False(t, valid)
+	assert.NoError(t, err)
+}
+
+func TestHandleSystemBroadcastOrgUpdateNameLookupFail(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	existing := newTestExistingOrg()
+	existing.Parent = ""
+	org := *existing
+	org.Name = "org2"
+	msg, data := newTestUpdateMessage(t, fftypes.SystemTagUpdateOrganization, "0x12345", &org)
+	mii := bm.identity.(*identitymocks.Plugin)
+	mii.On("Resolve", mock.Anything, "0x12345").Return(&fftypes.Identity{OnChain: "0x12345"}, nil)
+	mdi := bm.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByID", mock.Anything, existing.ID).Return(existing, nil)
+	mdi.On("GetOrganizationByName", mock.Anything, "org2").Return(nil, fmt.Errorf("pop"))
+
+	valid, err := bm.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.False(t, valid)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestHandleSystemBroadcastOrgUpdateVerifyFail(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	existing := newTestExistingOrg()
+	existing.Parent = ""
+	msg, data := newTestUpdateMessage(t, fftypes.SystemTagUpdateOrganization, "0x12345", existing)
+	mii := bm.identity.(*identitymocks.Plugin)
+	mii.On("Resolve", mock.Anything, "0x12345").Return(&fftypes.Identity{OnChain: "0x12345"}, nil)
+	mii.On("VerifyOrganization", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+	mdi := bm.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByID", mock.Anything, existing.ID).Return(existing, nil)
+
+	valid, err := bm.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.False(t, valid)
+	assert.NoError(t, err)
+}
+
+func TestHandleSystemBroadcastOrgUpdateAdoptKeysOk(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	existing := newTestExistingOrg()
+	org := *existing
+	org.PublicKeys = fftypes.PublicKeys{
+		{Type: fftypes.KeyTypeEd25519, Key: base64.StdEncoding.EncodeToString(pub)},
+	}
+	msg, data := newTestUpdateMessage(t, fftypes.SystemTagUpdateOrganization, "0x12345", &org)
+	msg.Hash = fftypes.NewRandB32()
+	msg.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(priv, msg.Hash[:]))
+
+	mii := bm.identity.(*identitymocks.Plugin)
+	mii.On("Resolve", mock.Anything, "0x12345").Return(&fftypes.Identity{OnChain: "0x12345"}, nil)
+	mii.On("VerifyOrganization", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mdi := bm.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByID", mock.Anything, existing.ID).Return(existing, nil)
+	mdi.On("GetOrganizationByIdentity", mock.Anything, "0x23456").Return(&fftypes.Organization{Identity: "0x23456"}, nil)
+	mdi.On("UpsertOrganization", mock.Anything, mock.MatchedBy(func(o *fftypes.Organization) bool {
+		return o.Version == 2 && len(o.PublicKeys) == 1
+	}), true).Return(nil)
+	mdi.On("InsertOrganizationVersion", mock.Anything, mock.Anything).Return(nil)
+	mdi.On("UpsertEvent", mock.Anything, mock.Anything, false).Return(nil)
+
+	valid, err := bm.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.True(t, valid)
+	assert.NoError(t, err)
+
+	mii.AssertExpectations(t)
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleSystemBroadcastOrgUpdateAdoptKeysUnsigned(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	existing := newTestExistingOrg()
+	org := *existing
+	org.PublicKeys = fftypes.PublicKeys{
+		{Type: fftypes.KeyTypeEd25519, Key: "cHVibGlja2V5"},
+	}
+	msg, data := newTestUpdateMessage(t, fftypes.SystemTagUpdateOrganization, "0x12345", &org)
+	msg.Hash = fftypes.NewRandB32()
+
+	mii := bm.identity.(*identitymocks.Plugin)
+	mii.On("Resolve", mock.Anything, "0x12345").Return(&fftypes.Identity{OnChain: "0x12345"}, nil)
+	mii.On("VerifyOrganization", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mdi := bm.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByID", mock.Anything, existing.ID).Return(existing, nil)
+	mdi.On("GetOrganizationByIdentity", mock.Anything, "0x23456").Return(&fftypes.Organization{Identity: "0x23456"}, nil)
+
+	valid, err := bm.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.False(t, valid)
+	assert.NoError(t, err)
+
+	mii.AssertExpectations(t)
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleSystemBroadcastOrgRevokeOk(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	existing := newTestExistingOrg()
+	msg, data := newTestUpdateMessage(t, fftypes.SystemTagRevokeOrganization, "0x12345", &fftypes.Organization{ID: existing.ID})
+	mii := bm.identity.(*identitymocks.Plugin)
+	mii.On("Resolve", mock.Anything, "0x12345").Return(&fftypes.Identity{OnChain: "0x12345"}, nil)
+	mdi := bm.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByID", mock.Anything, existing.ID).Return(existing, nil)
+	mdi.On("UpsertOrganization", mock.Anything, mock.MatchedBy(func(o *fftypes.Organization) bool {
+		return o.Version == 2 && o.Revoked == msg.Header.Created
+	}), true).Return(nil)
+	mdi.On("InsertOrganizationVersion", mock.Anything, mock.Anything).Return(nil)
+	mdi.On("UpsertEvent", mock.Anything, mock.Anything, false).Return(nil)
+
+	valid, err := bm.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.True(t, valid)
+	assert.NoError(t, err)
+
+	mii.AssertExpectations(t)
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleSystemBroadcastOrgRevokeUnmarshalFail(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	msg, _ := newTestUpdateMessage(t, fftypes.SystemTagRevokeOrganization, "0x12345", nil)
+	valid, err := bm.HandleSystemBroadcast(context.Background(), msg, []*fftypes.Data{{Value: fftypes.Byteable(`!json`)}})
+	assert.False(t, valid)
+	assert.NoError(t, err)
+}
+
+func TestHandleSystemBroadcastOrgRevokeMissingID(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	msg, data := newTestUpdateMessage(t, fftypes.SystemTagRevokeOrganization, "0x12345", &fftypes.Organization{})
+	valid, err := bm.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.False(t, valid)
+	assert.NoError(t, err)
+}
+
+func TestHandleSystemBroadcastOrgRevokeNotFound(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	existing := newTestExistingOrg()
+	msg, data := newTestUpdateMessage(t, fftypes.SystemTagRevokeOrganization, "0x12345", existing)
+	mdi := bm.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByID", mock.Anything, existing.ID).Return(nil, nil)
+
+	valid, err := bm.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.False(t, valid)
+	assert.NoError(t, err)
+}
+
+func TestHandleSystemBroadcastOrgRevokeBadAuthor(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	existing := newTestExistingOrg()
+	msg, data := newTestUpdateMessage(t, fftypes.SystemTagRevokeOrganization, "0x99999", existing)
+	mii := bm.identity.(*identitymocks.Plugin)
+	mii.On("Resolve", mock.Anything, "0x12345").Return(&fftypes.Identity{OnChain: "0x12345"}, nil)
+	mii.On("Resolve", mock.Anything, "0x23456").Return(&fftypes.Identity{OnChain: "0x23456"}, nil)
+	mdi := bm.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByID", mock.Anything, existing.ID).Return(existing, nil)
+
+	valid, err := bm.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.False(t, valid)
+	assert.NoError(t, err)
+}
+
+func TestStoreOrganizationVersionUpsertFail(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	mdi := bm.database.(*databasemocks.Plugin)
+	mdi.On("UpsertOrganization", mock.Anything, mock.Anything, true).Return(fmt.Errorf("pop"))
+
+	valid, err := bm.storeOrganizationVersion(context.Background(), &fftypes.Message{}, newTestExistingOrg())
+	assert.False(t, valid)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestStoreOrganizationVersionInsertFail(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	mdi := bm.database.(*databasemocks.Plugin)
+	mdi.On("UpsertOrganization", mock.Anything, mock.Anything, true).Return(nil)
+	mdi.On("InsertOrganizationVersion", mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+
+	valid, err := bm.storeOrganizationVersion(context.Background(), &fftypes.Message{}, newTestExistingOrg())
+	assert.False(t, valid)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestStoreOrganizationVersionEventFail(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	mdi := bm.database.(*databasemocks.Plugin)
+	mdi.On("UpsertOrganization", mock.Anything, mock.Anything, true).Return(nil)
+	mdi.On("InsertOrganizationVersion", mock.Anything, mock.Anything).Return(nil)
+	mdi.On("UpsertEvent", mock.Anything, mock.Anything, false).Return(fmt.Errorf("pop"))
+
+	valid, err := bm.storeOrganizationVersion(context.Background(), &fftypes.Message{}, newTestExistingOrg())
+	assert.False(t, valid)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestHandleSystemBroadcastNodeUpdateOk(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	existing := newTestExistingNode()
+	node := *existing
+	node.DX.Peer = "peer2"
+	msg, data := newTestUpdateMessage(t, fftypes.SystemTagUpdateNode, "0x12345", &node)
+
+	mii := bm.identity.(*identitymocks.Plugin)
+	mii.On("Resolve", mock.Anything, "0x12345").Return(&fftypes.Identity{OnChain: "0x12345"}, nil)
+	mdi := bm.database.(*databasemocks.Plugin)
+	mdi.On("GetNodeByID", mock.Anything, existing.ID).Return(existing, nil)
+	mdi.On("GetOrganizationByIdentity", mock.Anything, "0x12345").Return(&fftypes.Organization{Identity: "0x12345"}, nil)
+	mdi.On("UpsertNode", mock.Anything, mock.MatchedBy(func(n *fftypes.Node) bool {
+		return n.Version == 2 && n.DX.Peer == "peer2" && n.Created == existing.Created
+	}), true).Return(nil)
+	mdi.On("InsertNodeVersion", mock.Anything, mock.Anything).Return(nil)
+	mdi.On("UpsertEvent", mock.Anything, mock.MatchedBy(func(e *fftypes.Event) bool {
+		return e.Type == fftypes.EventTypeNodeUpdated && *e.Reference == *existing.ID
+	}), false).Return(nil)
+	mdx := bm.exchange.(*dataexchangemocks.Plugin)
+	mdx.On("AddPeer", mock.Anything, mock.MatchedBy(func(n *fftypes.Node) bool {
+		return n.DX.Peer == "peer2"
+	})).Return(nil)
+
+	valid, err := bm.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.True(t, valid)
+	assert.NoError(t, err)
+
+	mii.AssertExpectations(t)
+	mdi.AssertExpectations(t)
+	mdx.AssertExpectations(t)
+}
+
+func TestHandleSystemBroadcastNodeUpdateAddPeerFail(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	existing := newTestExistingNode()
+	msg, data := newTestUpdateMessage(t, fftypes.SystemTagUpdateNode, "0x12345", existing)
+
+	mii := bm.identity.(*identitymocks.Plugin)
+	mii.On("Resolve", mock.Anything, "0x12345").Return(&fftypes.Identity{OnChain: "0x12345"}, nil)
+	mdi := bm.database.(*databasemocks.Plugin)
+	mdi.On("GetNodeByID", mock.Anything, existing.ID).Return(existing, nil)
+	mdi.On("GetOrganizationByIdentity", mock.Anything, "0x12345").Return(&fftypes.Organization{Identity: "0x12345"}, nil)
+	mdi.On("UpsertNode", mock.Anything, mock.Anything, true).Return(nil)
+	mdi.On("InsertNodeVersion", mock.Anything, mock.Anything).Return(nil)
+	mdi.On("UpsertEvent", mock.Anything, mock.Anything, false).Return(nil)
+	mdx := bm.exchange.(*dataexchangemocks.Plugin)
+	mdx.On("AddPeer", mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+
+	valid, err := bm.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.False(t, valid)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestHandleSystemBroadcastNodeUpdateStoreFail(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	existing := newTestExistingNode()
+	msg, data := newTestUpdateMessage(t, fftypes.SystemTagUpdateNode, "0x12345", existing)
+
+	mii := bm.identity.(*identitymocks.Plugin)
+	mii.On("Resolve", mock.Anything, "0x12345").Return(&fftypes.Identity{OnChain: "0x12345"}, nil)
+	mdi := bm.database.(*databasemocks.Plugin)
+	mdi.On("GetNodeByID", mock.Anything, existing.ID).Return(existing, nil)
+	mdi.On("GetOrganizationByIdentity", mock.Anything, "0x12345").Return(&fftypes.Organization{Identity: "0x12345"}, nil)
+	mdi.On("UpsertNode", mock.Anything, mock.Anything, true).Return(fmt.Errorf("pop"))
+
+	valid, err := bm.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.False(t, valid)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestHandleSystemBroadcastNodeUpdateUnmarshalFail(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	msg, _ := newTestUpdateMessage(t, fftypes.SystemTagUpdateNode, "0x12345", nil)
+	valid, err := bm.HandleSystemBroadcast(context.Background(), msg, []*fftypes.Data{{Value: fftypes.Byteable(`!json`)}})
+	assert.False(t, valid)
+	assert.NoError(t, err)
+}
+
+func TestHandleSystemBroadcastNodeUpdateValidateFail(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	msg, data := newTestUpdateMessage(t, fftypes.SystemTagUpdateNode, "0x12345", &fftypes.Node{Name: "node1"})
+	valid, err := bm.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.False(t, valid)
+	assert.NoError(t, err)
+}
+
+func TestHandleSystemBroadcastNodeUpdateGetFail(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	existing := newTestExistingNode()
+	msg, data := newTestUpdateMessage(t, fftypes.SystemTagUpdateNode, "0x12345", existing)
+	mdi := bm.database.(*databasemocks.Plugin)
+	mdi.On("GetNodeByID", mock.Anything, existing.ID).Return(nil, fmt.Errorf("pop"))
+
+	valid, err := bm.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.False(t, valid)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestHandleSystemBroadcastNodeUpdateRevoked(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	existing := newTestExistingNode()
+	existing.Revoked = fftypes.Now()
+	msg, data := newTestUpdateMessage(t, fftypes.SystemTagUpdateNode, "0x12345", existing)
+	mdi := bm.database.(*databasemocks.Plugin)
+	mdi.On("GetNodeByID", mock.Anything, existing.ID).Return(existing, nil)
+
+	valid, err := bm.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.False(t, valid)
+	assert.NoError(t, err)
+}
+
+func TestHandleSystemBroadcastNodeUpdateGetOwnerFail(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	existing := newTestExistingNode()
+	msg, data := newTestUpdateMessage(t, fftypes.SystemTagUpdateNode, "0x12345", existing)
+	mdi := bm.database.(*databasemocks.Plugin)
+	mdi.On("GetNodeByID", mock.Anything, existing.ID).Return(existing, nil)
+	mdi.On("GetOrganizationByIdentity", mock.Anything, "0x12345").Return(nil, fmt.Errorf("pop"))
+
+	valid, err := bm.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.False(t, valid)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestHandleSystemBroadcastNodeUpdateOwnerRevoked(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	existing := newTestExistingNode()
+	msg, data := newTestUpdateMessage(t, fftypes.SystemTagUpdateNode, "0x12345", existing)
+	mdi := bm.database.(*databasemocks.Plugin)
+	mdi.On("GetNodeByID", mock.Anything, existing.ID).Return(existing, nil)
+	mdi.On("GetOrganizationByIdentity", mock.Anything, "0x12345").Return(&fftypes.Organization{Revoked: fftypes.Now()}, nil)
+
+	valid, err := bm.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.False(t, valid)
+	assert.NoError(t, err)
+}
+
+func TestHandleSystemBroadcastNodeUpdateRename(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	existing := newTestExistingNode()
+	node := *existing
+	node.Name = "node2"
+	msg, data := newTestUpdateMessage(t, fftypes.SystemTagUpdateNode, "0x12345", &node)
+	mdi := bm.database.(*databasemocks.Plugin)
+	mdi.On("GetNodeByID", mock.Anything, existing.ID).Return(existing, nil)
+	mdi.On("GetOrganizationByIdentity", mock.Anything, "0x12345").Return(&fftypes.Organization{Identity: "0x12345"}, nil)
+
+	valid, err := bm.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.False(t, valid)
+	assert.NoError(t, err)
+}
+
+func TestHandleSystemBroadcastNodeUpdateBadAuthor(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	existing := newTestExistingNode()
+	msg, data := newTestUpdateMessage(t, fftypes.SystemTagUpdateNode, "0x99999", existing)
+	mii := bm.identity.(*identitymocks.Plugin)
+	mii.On("Resolve", mock.Anything, "0x12345").Return(&fftypes.Identity{OnChain: "0x12345"}, nil)
+	mdi := bm.database.(*databasemocks.Plugin)
+	mdi.On("GetNodeByID", mock.Anything, existing.ID).Return(existing, nil)
+	mdi.On("GetOrganizationByIdentity", mock.Anything, "0x12345").Return(&fftypes.Organization{Identity: "0x12345"}, nil)
+
+	valid, err := bm.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.False(t, valid)
+	assert.NoError(t, err)
+}
+
+func TestHandleSystemBroadcastNodeRevokeOk(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	existing := newTestExistingNode()
+	msg, data := newTestUpdateMessage(t, fftypes.SystemTagRevokeNode, "0x23456", &fftypes.Node{ID: existing.ID})
+	mii := bm.identity.(*identitymocks.Plugin)
+	mii.On("Resolve", mock.Anything, "0x12345").Return(&fftypes.Identity{OnChain: "0x12345"}, nil)
+	mii.On("Resolve", mock.Anything, "0x23456").Return(&fftypes.Identity{OnChain: "0x23456"}, nil)
+	mdi := bm.database.(*databasemocks.Plugin)
+	mdi.On("GetNodeByID", mock.Anything, existing.ID).Return(existing, nil)
+	mdi.On("GetOrganizationByIdentity", mock.Anything, "0x12345").Return(&fftypes.Organization{Identity: "0x12345", Parent: "0x23456"}, nil)
+	mdi.On("UpsertNode", mock.Anything, mock.MatchedBy(func(n *fftypes.Node) bool {
+		return n.Version == 2 && n.Revoked == msg.Header.Created
+	}), true).Return(nil)
+	mdi.On("InsertNodeVersion", mock.Anything, mock.Anything).Return(nil)
+	mdi.On("UpsertEvent", mock.Anything, mock.Anything, false).Return(nil)
+	mdx := bm.exchange.(*dataexchangemocks.Plugin)
+	mdx.On("RemovePeer", mock.Anything, mock.MatchedBy(func(n *fftypes.Node) bool {
+		return n.DX.Peer == "peer1"
+	})).Return(nil)
+
+	valid, err := bm.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.True(t, valid)
+	assert.NoError(t, err)
+
+	mii.AssertExpectations(t)
+	mdi.AssertExpectations(t)
+	mdx.AssertExpectations(t)
+}
+
+func TestHandleSystemBroadcastNodeRevokeRemovePeerFail(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	existing := newTestExistingNode()
+	msg, data := newTestUpdateMessage(t, fftypes.SystemTagRevokeNode, "0x12345", &fftypes.Node{ID: existing.ID})
+	mii := bm.identity.(*identitymocks.Plugin)
+	mii.On("Resolve", mock.Anything, "0x12345").Return(&fftypes.Identity{OnChain: "0x12345"}, nil)
+	mdi := bm.database.(*databasemocks.Plugin)
+	mdi.On("GetNodeByID", mock.Anything, existing.ID).Return(existing, nil)
+	mdi.On("GetOrganizationByIdentity", mock.Anything, "0x12345").Return(&fftypes.Organization{Identity: "0x12345"}, nil)
+	mdi.On("UpsertNode", mock.Anything, mock.Anything, true).Return(nil)
+	mdi.On("InsertNodeVersion", mock.Anything, mock.Anything).Return(nil)
+	mdi.On("UpsertEvent", mock.Anything, mock.Anything, false).Return(nil)
+	mdx := bm.exchange.(*dataexchangemocks.Plugin)
+	mdx.On("RemovePeer", mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+
+	valid, err := bm.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.False(t, valid)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestHandleSystemBroadcastNodeRevokeStoreFail(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	existing := newTestExistingNode()
+	msg, data := newTestUpdateMessage(t, fftypes.SystemTagRevokeNode, "0x12345", &fftypes.Node{ID: existing.ID})
+	mii := bm.identity.(*identitymocks.Plugin)
+	mii.On("Resolve", mock.Anything, "0x12345").Return(&fftypes.Identity{OnChain: "0x12345"}, nil)
+	mdi := bm.database.(*databasemocks.Plugin)
+	mdi.On("GetNodeByID", mock.Anything, existing.ID).Return(existing, nil)
+	mdi.On("GetOrganizationByIdentity", mock.Anything, "0x12345").Return(&fftypes.Organization{Identity: "0x12345"}, nil)
+	mdi.On("UpsertNode", mock.Anything, mock.Anything, true).Return(fmt.Errorf("pop"))
+
+	valid, err := bm.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.False(t, valid)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestHandleSystemBroadcastOrgUpdateRotateNoProof(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	existing := newTestExistingOrg()
+	existing.Parent = ""
+	org := newTestRotatedOrg(t, existing, "0x99999")
+	org.IdentityProof = ""
+	msg, data := newTestUpdateMessage(t, fftypes.SystemTagUpdateOrganization, "0x12345", org)
+
+	mii := bm.identity.(*identitymocks.Plugin)
+	mii.On("Resolve", mock.Anything, "0x12345").Return(&fftypes.Identity{OnChain: "0x12345"}, nil)
+	mii.On("VerifyOrganization", mock.Anything, mock.Anything, (*fftypes.Organization)(nil), mock.Anything).Return(nil)
+	mdi := bm.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationVersions", mock.Anything, mock.Anything).Return([]*fftypes.Organization{}, nil, nil)
+	mdi.On("GetOrganizationByID", mock.Anything, existing.ID).Return(existing, nil)
+	mdi.On("GetOrganizationByIdentity", mock.Anything, "0x99999").Return(nil, nil)
+
+	valid, err := bm.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.False(t, valid)
+	assert.NoError(t, err)
+
+	mii.AssertExpectations(t)
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleSystemBroadcastOrgUpdateRotateRetired(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	existing := newTestExistingOrg()
+	existing.Parent = ""
+	org := newTestRotatedOrg(t, existing, "0x99999")
+	msg, data := newTestUpdateMessage(t, fftypes.SystemTagUpdateOrganization, "0x12345", org)
+
+	mii := bm.identity.(*identitymocks.Plugin)
+	mii.On("Resolve", mock.Anything, "0x12345").Return(&fftypes.Identity{OnChain: "0x12345"}, nil)
+	mdi := bm.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationVersions", mock.Anything, mock.Anything).Return([]*fftypes.Organization{{Identity: "0x99999"}}, nil, nil)
+	mdi.On("GetOrganizationByID", mock.Anything, existing.ID).Return(existing, nil)
+	mdi.On("GetOrganizationByIdentity", mock.Anything, "0x99999").Return(nil, nil)
+
+	valid, err := bm.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.False(t, valid)
+	assert.NoError(t, err)
+
+	mii.AssertExpectations(t)
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleSystemBroadcastOrgUpdateRetiredLookupFail(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	existing := newTestExistingOrg()
+	existing.Parent = ""
+	org := newTestRotatedOrg(t, existing, "0x99999")
+	msg, data := newTestUpdateMessage(t, fftypes.SystemTagUpdateOrganization, "0x12345", org)
+
+	mii := bm.identity.(*identitymocks.Plugin)
+	mii.On("Resolve", mock.Anything, "0x12345").Return(&fftypes.Identity{OnChain: "0x12345"}, nil)
+	mdi := bm.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationVersions", mock.Anything, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+	mdi.On("GetOrganizationByID", mock.Anything, existing.ID).Return(existing, nil)
+	mdi.On("GetOrganizationByIdentity", mock.Anything, "0x99999").Return(nil, nil)
+
+	valid, err := bm.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.False(t, valid)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestHandleSystemBroadcastNodeRevokeUnmarshalFail(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	msg, _ := newTestUpdateMessage(t, fftypes.SystemTagRevokeNode, "0x12345", nil)
+	valid, err := bm.HandleSystemBroadcast(context.Background(), msg, []*fftypes.Data{{Value: fftypes.Byteable(`!json`)}})
+	assert.False(t, valid)
+	assert.NoError(t, err)
+}
+
+func TestHandleSystemBroadcastNodeRevokeMissingID(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	msg, data := newTestUpdateMessage(t, fftypes.SystemTagRevokeNode, "0x12345", &fftypes.Node{})
+	valid, err := bm.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.False(t, valid)
+	assert.NoError(t, err)
+}
+
+func TestHandleSystemBroadcastNodeRevokeNotFound(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	existing := newTestExistingNode()
+	msg, data := newTestUpdateMessage(t, fftypes.SystemTagRevokeNode, "0x12345", existing)
+	mdi := bm.database.(*databasemocks.Plugin)
+	mdi.On("GetNodeByID", mock.Anything, existing.ID).Return(nil, nil)
+
+	valid, err := bm.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.False(t, valid)
+	assert.NoError(t, err)
+}
+
+func TestHandleSystemBroadcastNodeRevokeBadAuthor(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	existing := newTestExistingNode()
+	msg, data := newTestUpdateMessage(t, fftypes.SystemTagRevokeNode, "0x99999", existing)
+	mii := bm.identity.(*identitymocks.Plugin)
+	mii.On("Resolve", mock.Anything, "0x12345").Return(&fftypes.Identity{OnChain: "0x12345"}, nil)
+	mdi := bm.database.(*databasemocks.Plugin)
+	mdi.On("GetNodeByID", mock.Anything, existing.ID).Return(existing, nil)
+	mdi.On("GetOrganizationByIdentity", mock.Anything, "0x12345").Return(&fftypes.Organization{Identity: "0x12345"}, nil)
+
+	valid, err := bm.HandleSystemBroadcast(context.Background(), msg, data)
+	assert.False(t, valid)
+	assert.NoError(t, err)
+}
+
+func TestStoreNodeVersionInsertFail(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	mdi := bm.database.(*databasemocks.Plugin)
+	mdi.On("UpsertNode", mock.Anything, mock.Anything, true).Return(nil)
+	mdi.On("InsertNodeVersion", mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+
+	valid, err := bm.storeNodeVersion(context.Background(), &fftypes.Message{}, newTestExistingNode())
+	assert.False(t, valid)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestStoreNodeVersionEventFail(t *testing.T) {
+	bm, cancel := newTestBroadcast(t)
+	defer cancel()
+
+	mdi := bm.database.(*databasemocks.Plugin)
+	mdi.On("UpsertNode", mock.Anything, mock.Anything, true).Return(nil)
+	mdi.On("InsertNodeVersion", mock.Anything, mock.Anything).Return(nil)
+	mdi.On("UpsertEvent", mock.Anything, mock.Anything, false).Return(fmt.Errorf("pop"))
+
+	valid, err := bm.storeNodeVersion(context.Background(), &fftypes.Message{}, newTestExistingNode())
+	assert.False(t, valid)
+	assert.EqualError(t, err, "pop")
+}
@@ -44,6 +44,11 @@ var (
 	APIMaxFilterSkip = rootKey("api.maxFilterLimit")
 	// APIRequestTimeout is the server side timeout for API calls (context timeout), to avoid the server continuing processing when the client gives up
 	APIRequestTimeout = rootKey("api.requestTimeout")
+	// AuthType is the name of the API authentication plugin to use - authentication is disabled if not set
+	AuthType = rootKey("auth.type")
+	// AuthRules is an optional list of rules determining which principals can access which routes and namespaces.
+	// Connections to the websockets endpoint are matched with the route name "websockets"
+	AuthRules = rootKey("auth.rules")
 	// BatchManagerReadPageSize is the size of each page of messages read from the database into memory when assembling batches
 	BatchManagerReadPageSize = rootKey("batch.manager.readPageSize")
 	// BatchManagerReadPollTimeout is how long without any notifications of new messages to wait, before doing a page query
@@ -71,6 +76,8 @@ var (
 	// PrivateMessagingOpCorrelationRetries how many times to correlate an event for an operation (such as tx submission) back to an operation.
 	// Needed because the operation update might come back before we are finished persisting the ID of the request
 	PrivateMessagingOpCorrelationRetries = rootKey("privatemessaging.opCorrelationRetries")
+	// PrivateMessagingRequestTimeout is the maximum time a request will wait for a reply (bounded by the API request timeout)
+	PrivateMessagingRequestTimeout = rootKey("privatemessaging.requestTimeout")
 	// PrivateMessagingRetryFactor the backoff factor to use for retry of database operations
 	PrivateMessagingRetryFactor = rootKey("privatemessaging.retry.factor")
 	// PrivateMessagingRetryInitDelay the initial delay to use for retry of data base operations
@@ -101,6 +108,8 @@ var (
 	EventTransportsDefault = rootKey("event.transports.default")
 	// EventTransportsEnabled which event interface plugins are enabled
 	EventTransportsEnabled = rootKey("event.transports.enabled")
+	// EventAggregatorAcceptUnsigned whether unsigned messages are accepted from organizations that have not published keys
+	EventAggregatorAcceptUnsigned = rootKey("event.aggregator.acceptUnsigned")
 	// EventAggregatorFirstEvent the first event the aggregator should process, if no previous offest is stored in the DB
 	EventAggregatorFirstEvent = rootKey("event.aggregator.firstEvent")
 	// EventAggregatorBatchSize the maximum number of records to read from the DB before performing an aggregation run
@@ -110,6 +119,10 @@ var (
 	// EventAggregatorOpCorrelationRetries how many times to correlate an event for an operation (such as tx submission) back to an operation.
 	// Needed because the operation update might come back before we are finished persisting the ID of the request
 	EventAggregatorOpCorrelationRetries = rootKey("event.aggregator.opCorrelationRetries")
+	// EventAggregatorPinCacheSize cache size for the pin sequences of the messages that established organization versions
+	EventAggregatorPinCacheSize = rootKey("event.aggregator.pinCache.size")
+	// EventAggregatorPinCacheTTL cache time-to-live for the pin sequences of the messages that established organization versions
+	EventAggregatorPinCacheTTL = rootKey("event.aggregator.pinCache.ttl")
 	// EventAggregatorPollTimeout the time to wait without a notification of new events, before trying a select on the table
 	EventAggregatorPollTimeout = rootKey("event.aggregator.pollTimeout")
 	// EventAggregatorRetryFactor the backoff factor to use for retry of database operations
@@ -118,6 +131,16 @@ var (
 	EventAggregatorRetryInitDelay = rootKey("event.aggregator.retry.initDelay")
 	// EventAggregatorRetryMaxDelay the maximum delay to use for retry of data base operations
 	EventAggregatorRetryMaxDelay = rootKey("event.aggregator.retry.maxDelay")
+	// EventResubmitAttempts how many times a failed blockchain batch pin is resubmitted, before the transaction is declared failed
+	EventResubmitAttempts = rootKey("event.resubmit.attempts")
+	// EventResubmitRetryableErrors regular expressions matched against the error of a failed batch pin, to decide if resubmission could succeed
+	EventResubmitRetryableErrors = rootKey("event.resubmit.retryableErrors")
+	// EventResubmitRetryFactor the backoff factor to use between resubmissions of a failed batch pin
+	EventResubmitRetryFactor = rootKey("event.resubmit.retry.factor")
+	// EventResubmitRetryInitDelay the initial delay to use before resubmitting a failed batch pin
+	EventResubmitRetryInitDelay = rootKey("event.resubmit.retry.initDelay")
+	// EventResubmitRetryMaxDelay the maximum delay to use before resubmitting a failed batch pin
+	EventResubmitRetryMaxDelay = rootKey("event.resubmit.retry.maxDelay")
 	// EventDispatcherPollTimeout the time to wait without a notification of new events, before trying a select on the table
 	EventDispatcherPollTimeout = rootKey("event.dispatcher.pollTimeout")
 	// EventDispatcherBufferLength the number of events + attachments an individual dispatcher should hold in memory ready for delivery to the subscription
@@ -176,6 +199,14 @@ var (
 	IdentityType = rootKey("identity.type")
 	// Lang is the language to use for translation
 	Lang = rootKey("lang")
+	// LeaderElectionEnabled enables leader election, so only one instance sharing the database runs the singleton components (batch sequencer, aggregator, and event stream consumers)
+	LeaderElectionEnabled = rootKey("leader.enabled")
+	// LeaderElectionID uniquely identifies this instance as a leader candidate. Setting a stable value lets a restarted leader reclaim its lease without waiting for it to expire
+	LeaderElectionID = rootKey("leader.id")
+	// LeaderElectionLeaseDuration how long a leader's lease lasts without renewal, before another instance can take over
+	LeaderElectionLeaseDuration = rootKey("leader.leaseDuration")
+	// LeaderElectionRenewInterval how often the leader renews its lease, and other instances check if they can take over. Must be less than the lease duration
+	LeaderElectionRenewInterval = rootKey("leader.renewInterval")
 	// LogForceColor forces color to be enabled, even if we do not detect a TTY
 	LogForceColor = rootKey("log.forceColor")
 	// LogLevel is the logging level
@@ -186,6 +217,10 @@ var (
 	LogTimeFormat = rootKey("log.timeFormat")
 	// LogUTC sets log timestamps to the UTC timezone
 	LogUTC = rootKey("log.utc")
+	// MetricsEnabled determines whether the Prometheus metrics endpoint is enabled on the admin interface
+	MetricsEnabled = rootKey("metrics.enabled")
+	// MetricsPath the path on the admin interface to serve Prometheus metrics on
+	MetricsPath = rootKey("metrics.path")
 	// NamespacesDefault is the default namespace - must be in the predefines list
 	NamespacesDefault = rootKey("namespaces.default")
 	// NamespacesPredefined is a list of namespaces to ensure exists, without requiring a broadcast from the network
@@ -236,6 +271,7 @@ type Prefix interface {
 	GetBool(key string) bool
 	GetInt(key string) int
 	GetInt64(key string) int64
+	GetFloat64(key string) float64
 	GetByteSize(key string) int64
 	GetUint(key string) uint
 	GetDuration(key string) time.Duration
@@ -276,18 +312,26 @@ func Reset() {
 	viper.SetDefault(string(CorsMaxAge), 600)
 	viper.SetDefault(string(DataexchangeType), "https")
 	viper.SetDefault(string(DebugPort), -1)
+	viper.SetDefault(string(EventAggregatorAcceptUnsigned), true)
 	viper.SetDefault(string(EventAggregatorFirstEvent), fftypes.SubOptsFirstEventOldest)
 	viper.SetDefault(string(EventAggregatorBatchSize), 50)
 	viper.SetDefault(string(EventAggregatorBatchTimeout), "250ms")
 	viper.SetDefault(string(EventAggregatorPollTimeout), "30s")
+	viper.SetDefault(string(EventAggregatorPinCacheSize), "1Mb")
+	viper.SetDefault(string(EventAggregatorPinCacheTTL), "1h")
 	viper.SetDefault(string(EventAggregatorRetryFactor), 2.0)
 	viper.SetDefault(string(EventAggregatorRetryInitDelay), "100ms")
 	viper.SetDefault(string(EventAggregatorRetryMaxDelay), "30s")
 	viper.SetDefault(string(EventAggregatorOpCorrelationRetries), 3)
+	viper.SetDefault(string(EventResubmitAttempts), 3)
+	viper.SetDefault(string(EventResubmitRetryableErrors), []string{"(?i)gas", "(?i)underpriced", "(?i)nonce too low"})
+	viper.SetDefault(string(EventResubmitRetryFactor), 2.0)
+	viper.SetDefault(string(EventResubmitRetryInitDelay), "1s")
+	viper.SetDefault(string(EventResubmitRetryMaxDelay), "30s")
 	viper.SetDefault(string(EventDispatcherBufferLength), 5)
 	viper.SetDefault(string(EventDispatcherBatchTimeout), "250ms")
 	viper.SetDefault(string(EventDispatcherPollTimeout), "30s")
-	viper.SetDefault(string(EventTransportsEnabled), []string{"websockets"})
+	viper.SetDefault(string(EventTransportsEnabled), []string{"websockets", "webhooks"})
 	viper.SetDefault(string(EventTransportsDefault), "websockets")
 	viper.SetDefault(string(GroupCacheSize), "1Mb")
 	viper.SetDefault(string(GroupCacheTTL), "1h")
@@ -302,9 +346,14 @@ func Reset() {
 	viper.SetDefault(string(AdminHTTPWriteTimeout), "15s")
 	viper.SetDefault(string(IdentityType), "onchain")
 	viper.SetDefault(string(Lang), "en")
+	viper.SetDefault(string(LeaderElectionEnabled), false)
+	viper.SetDefault(string(LeaderElectionLeaseDuration), "30s")
+	viper.SetDefault(string(LeaderElectionRenewInterval), "10s")
 	viper.SetDefault(string(LogLevel), "info")
 	viper.SetDefault(string(LogTimeFormat), "2006-01-02T15:04:05.000Z07:00")
 	viper.SetDefault(string(LogUTC), false)
+	viper.SetDefault(string(MetricsEnabled), true)
+	viper.SetDefault(string(MetricsPath), "/metrics")
 	viper.SetDefault(string(NamespacesDefault), "default")
 	viper.SetDefault(string(NamespacesPredefined), fftypes.JSONObjectArray{{"name": "default", "description": "Default predefined namespace"}})
 	viper.SetDefault(string(OrchestratorStartupAttempts), 5)
@@ -312,6 +361,7 @@ func Reset() {
 	viper.SetDefault(string(PrivateMessagingRetryInitDelay), "100ms")
 	viper.SetDefault(string(PrivateMessagingRetryMaxDelay), "30s")
 	viper.SetDefault(string(PrivateMessagingOpCorrelationRetries), 3)
+	viper.SetDefault(string(PrivateMessagingRequestTimeout), "30s")
 	viper.SetDefault(string(PrivateMessagingBatchAgentTimeout), "2m")
 	viper.SetDefault(string(PrivateMessagingBatchSize), 200)
 	viper.SetDefault(string(PrivateMessagingBatchTimeout), "1s")
@@ -33,23 +33,12 @@ type blobStore struct {
 	exchange dataexchange.Plugin
 }
 
-func (bs *blobStore) UploadBLOB(ctx context.Context, ns string, reader io.Reader) (*fftypes.Data, error) {
-
-	data := &fftypes.Data{
-		ID:        fftypes.NewUUID(),
-		Namespace: ns,
-		Validator: "",
-		Blobstore: true,
-		Datatype:  nil,
-		Created:   fftypes.Now(),
-		Value:     nil,
-	}
-
-	hash := sha256.New()
+// uploadVerifyBLOB streams the content to the data exchange, calculating the hash as it goes
+func (bs *blobStore) uploadVerifyBLOB(ctx context.Context, ns string, id *fftypes.UUID, reader io.Reader) (hash *fftypes.Bytes32, written int64, err error) {
+	hashCalc := sha256.New()
 	dxReader, dx := io.Pipe()
-	storeAndHash := io.MultiWriter(hash, dx)
+	storeAndHash := io.MultiWriter(hashCalc, dx)
 
-	var written int64
 	copyDone := make(chan error, 1)
 	go func() {
 		var err error
@@ -59,22 +48,109 @@ func (bs *blobStore) UploadBLOB(ctx context.Context, ns string, reader io.Reader
 		copyDone <- err
 	}()
 
-	dxErr := bs.exchange.UploadBLOB(ctx, ns, *data.ID, dxReader)
+	dxErr := bs.exchange.UploadBLOB(ctx, ns, *id, dxReader)
 	dxReader.Close()
 	copyErr := <-copyDone
 	if dxErr != nil {
-		return nil, dxErr
+		return nil, -1, dxErr
 	}
 	if copyErr != nil {
-		return nil, i18n.WrapError(ctx, copyErr, i18n.MsgBlobStreamingFailed)
+		return nil, -1, i18n.WrapError(ctx, copyErr, i18n.MsgBlobStreamingFailed)
+	}
+	return fftypes.HashResult(hashCalc), written, nil
+}
+
+// CopyBlobToDX stores the content of a blob that was retrieved from public storage into the local data exchange,
+// and records the blob with the hash calculated as it was stored. The hash is verified against the data
+// by the aggregator, before any message referring to the data is confirmed.
+func (bs *blobStore) CopyBlobToDX(ctx context.Context, data *fftypes.Data, reader io.Reader) (*fftypes.Blob, error) {
+
+	hash, written, err := bs.uploadVerifyBLOB(ctx, data.Namespace, data.ID, reader)
+	if err != nil {
+		return nil, err
+	}
+	log.L(ctx).Infof("Copied BLOB %s %.2fkb hash=%s", data.ID, float64(written)/1024, hash)
+
+	blob := &fftypes.Blob{
+		Namespace: data.Namespace,
+		Data:      data.ID,
+		Hash:      hash,
+		Created:   fftypes.Now(),
+	}
+	if err = bs.database.InsertBlob(ctx, blob); err != nil {
+		return nil, err
+	}
+	return blob, nil
+}
+
+func (bs *blobStore) UploadBLOB(ctx context.Context, ns string, blob *fftypes.BlobRef, reader io.Reader) (*fftypes.Data, error) {
+
+	data := &fftypes.Data{
+		ID:        fftypes.NewUUID(),
+		Namespace: ns,
+		Validator: "",
+		Blobstore: true,
+		Datatype:  nil,
+		Created:   fftypes.Now(),
+		Value:     nil,
+		Blob: &fftypes.BlobRef{
+			Name:     blob.Name,
+			MimeType: blob.MimeType,
+		},
+	}
+
+	hash, written, err := bs.uploadVerifyBLOB(ctx, ns, data.ID, reader)
+	if err != nil {
+		return nil, err
 	}
-	data.Hash = fftypes.HashResult(hash)
+	data.Hash = hash
+	data.Blob.Size = written
 	log.L(ctx).Infof("Uploaded BLOB %.2fkb hash=%s", float64(written)/1024, data.Hash)
 
-	err := bs.database.UpsertData(ctx, data, false, false)
+	err = bs.database.RunAsGroup(ctx, func(ctx context.Context) error {
+		err := bs.database.UpsertData(ctx, data, false, false)
+		if err == nil {
+			// Record that we have the blob locally, so messages referring to it can be dispatched
+			err = bs.database.InsertBlob(ctx, &fftypes.Blob{
+				Namespace: ns,
+				Data:      data.ID,
+				Hash:      data.Hash,
+				Created:   data.Created,
+			})
+		}
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
 
 	return data, nil
 }
+
+func (bs *blobStore) DownloadBLOB(ctx context.Context, ns, dataID string) (*fftypes.Data, io.ReadCloser, error) {
+
+	if err := fftypes.ValidateFFNameField(ctx, ns, "namespace"); err != nil {
+		return nil, nil, err
+	}
+	id, err := fftypes.ParseUUID(ctx, dataID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data, err := bs.database.GetDataByID(ctx, id, false)
+	if err != nil {
+		return nil, nil, err
+	}
+	if data == nil || data.Namespace != ns {
+		return nil, nil, i18n.NewError(ctx, i18n.Msg404NoResult)
+	}
+	if !data.Blobstore {
+		return nil, nil, i18n.NewError(ctx, i18n.MsgDataNotBlob, id)
+	}
+
+	reader, err := bs.exchange.DownloadBLOB(ctx, ns, *data.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, reader, nil
+}
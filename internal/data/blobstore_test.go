@@ -18,6 +18,7 @@ package data
 
 import (
 	"bytes"
+	"context"
 	"crypto/sha256"
 	"fmt"
 	"io"
@@ -44,7 +45,15 @@ func TestUploadBlobOk(t *testing.T) {
 	}
 
 	mdi := dm.database.(*databasemocks.Plugin)
+	rag := mdi.On("RunAsGroup", ctx, mock.Anything)
+	rag.RunFn = func(a mock.Arguments) {
+		err := a[1].(func(context.Context) error)(a[0].(context.Context))
+		rag.ReturnArguments = mock.Arguments{err}
+	}
 	mdi.On("UpsertData", mock.Anything, mock.Anything, false, false).Return(nil)
+	mdi.On("InsertBlob", mock.Anything, mock.MatchedBy(func(blob *fftypes.Blob) bool {
+		return blob.Peer == "" && blob.Namespace == "ns1"
+	})).Return(nil)
 
 	dxID := make(chan fftypes.UUID, 1)
 	mdx := dm.exchange.(*dataexchangemocks.Plugin)
@@ -57,7 +66,7 @@ func TestUploadBlobOk(t *testing.T) {
 		dxUpload.ReturnArguments = mock.Arguments{err}
 	}
 
-	data, err := dm.UploadBLOB(ctx, "ns1", bytes.NewReader(b))
+	data, err := dm.UploadBLOB(ctx, "ns1", &fftypes.BlobRef{Name: "file.txt", MimeType: "text/plain"}, bytes.NewReader(b))
 	assert.NoError(t, err)
 
 	// Check the hashes and other details of the data
@@ -65,6 +74,9 @@ func TestUploadBlobOk(t *testing.T) {
 	assert.Equal(t, <-dxID, *data.ID)
 	assert.Empty(t, data.Validator)
 	assert.Nil(t, data.Datatype)
+	assert.Equal(t, "file.txt", data.Blob.Name)
+	assert.Equal(t, "text/plain", data.Blob.MimeType)
+	assert.Equal(t, int64(len(b)), data.Blob.Size)
 
 	mdi.AssertExpectations(t)
 	mdx.AssertExpectations(t)
@@ -83,7 +95,7 @@ func TestUploadBlobReadFail(t *testing.T) {
 		assert.NoError(t, err)
 	}
 
-	_, err := dm.UploadBLOB(ctx, "ns1", iotest.ErrReader(fmt.Errorf("pop")))
+	_, err := dm.UploadBLOB(ctx, "ns1", &fftypes.BlobRef{}, iotest.ErrReader(fmt.Errorf("pop")))
 	assert.Regexp(t, "FF10217.*pop", err)
 
 }
@@ -96,7 +108,7 @@ func TestUploadBlobWriteFailDoesNotRead(t *testing.T) {
 	mdx := dm.exchange.(*dataexchangemocks.Plugin)
 	mdx.On("UploadBLOB", ctx, "ns1", mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
 
-	_, err := dm.UploadBLOB(ctx, "ns1", bytes.NewReader([]byte(`any old data`)))
+	_, err := dm.UploadBLOB(ctx, "ns1", &fftypes.BlobRef{}, bytes.NewReader([]byte(`any old data`)))
 	assert.Regexp(t, "pop", err)
 
 }
@@ -113,9 +125,216 @@ func TestUploadBlobUpsertFail(t *testing.T) {
 		assert.Nil(t, err)
 	}
 	mdi := dm.database.(*databasemocks.Plugin)
+	rag := mdi.On("RunAsGroup", ctx, mock.Anything)
+	rag.RunFn = func(a mock.Arguments) {
+		err := a[1].(func(context.Context) error)(a[0].(context.Context))
+		rag.ReturnArguments = mock.Arguments{err}
+	}
 	mdi.On("UpsertData", mock.Anything, mock.Anything, false, false).Return(fmt.Errorf("pop"))
 
-	_, err := dm.UploadBLOB(ctx, "ns1", bytes.NewReader([]byte(`any old data`)))
+	_, err := dm.UploadBLOB(ctx, "ns1", &fftypes.BlobRef{}, bytes.NewReader([]byte(`any old data`)))
+	assert.Regexp(t, "pop", err)
+
+}
+
+func TestUploadBlobInsertBlobFail(t *testing.T) {
+
+	dm, ctx, cancel := newTestDataManager(t)
+	defer cancel()
+
+	mdx := dm.exchange.(*dataexchangemocks.Plugin)
+	dxUpload := mdx.On("UploadBLOB", ctx, "ns1", mock.Anything, mock.Anything).Return(nil)
+	dxUpload.RunFn = func(a mock.Arguments) {
+		_, err := ioutil.ReadAll(a[3].(io.Reader))
+		assert.Nil(t, err)
+	}
+	mdi := dm.database.(*databasemocks.Plugin)
+	rag := mdi.On("RunAsGroup", ctx, mock.Anything)
+	rag.RunFn = func(a mock.Arguments) {
+		err := a[1].(func(context.Context) error)(a[0].(context.Context))
+		rag.ReturnArguments = mock.Arguments{err}
+	}
+	mdi.On("UpsertData", mock.Anything, mock.Anything, false, false).Return(nil)
+	mdi.On("InsertBlob", mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+
+	_, err := dm.UploadBLOB(ctx, "ns1", &fftypes.BlobRef{}, bytes.NewReader([]byte(`any old data`)))
+	assert.Regexp(t, "pop", err)
+
+}
+
+func TestCopyBlobToDXOk(t *testing.T) {
+
+	dm, ctx, cancel := newTestDataManager(t)
+	defer cancel()
+
+	b := []byte(`some blob content`)
+	dataID := fftypes.NewUUID()
+
+	mdx := dm.exchange.(*dataexchangemocks.Plugin)
+	dxUpload := mdx.On("UploadBLOB", ctx, "ns1", *dataID, mock.Anything).Return(nil)
+	dxUpload.RunFn = func(a mock.Arguments) {
+		readBytes, err := ioutil.ReadAll(a[3].(io.Reader))
+		assert.Nil(t, err)
+		assert.Equal(t, b, readBytes)
+	}
+	mdi := dm.database.(*databasemocks.Plugin)
+	mdi.On("InsertBlob", ctx, mock.Anything).Return(nil)
+
+	blob, err := dm.CopyBlobToDX(ctx, &fftypes.Data{ID: dataID, Namespace: "ns1"}, bytes.NewReader(b))
+	assert.NoError(t, err)
+	assert.Equal(t, [32]byte(sha256.Sum256(b)), [32]byte(*blob.Hash))
+	assert.Equal(t, *dataID, *blob.Data)
+	assert.Empty(t, blob.Peer)
+
+	mdi.AssertExpectations(t)
+	mdx.AssertExpectations(t)
+}
+
+func TestCopyBlobToDXUploadFail(t *testing.T) {
+
+	dm, ctx, cancel := newTestDataManager(t)
+	defer cancel()
+
+	mdx := dm.exchange.(*dataexchangemocks.Plugin)
+	mdx.On("UploadBLOB", ctx, "ns1", mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+
+	_, err := dm.CopyBlobToDX(ctx, &fftypes.Data{ID: fftypes.NewUUID(), Namespace: "ns1"}, bytes.NewReader([]byte(`any old data`)))
+	assert.Regexp(t, "pop", err)
+}
+
+func TestCopyBlobToDXInsertBlobFail(t *testing.T) {
+
+	dm, ctx, cancel := newTestDataManager(t)
+	defer cancel()
+
+	mdx := dm.exchange.(*dataexchangemocks.Plugin)
+	dxUpload := mdx.On("UploadBLOB", ctx, "ns1", mock.Anything, mock.Anything).Return(nil)
+	dxUpload.RunFn = func(a mock.Arguments) {
+		_, err := ioutil.ReadAll(a[3].(io.Reader))
+		assert.Nil(t, err)
+	}
+	mdi := dm.database.(*databasemocks.Plugin)
+	mdi.On("InsertBlob", ctx, mock.Anything).Return(fmt.Errorf("pop"))
+
+	_, err := dm.CopyBlobToDX(ctx, &fftypes.Data{ID: fftypes.NewUUID(), Namespace: "ns1"}, bytes.NewReader([]byte(`any old data`)))
+	assert.Regexp(t, "pop", err)
+}
+
+func TestDownloadBlobOk(t *testing.T) {
+
+	dm, ctx, cancel := newTestDataManager(t)
+	defer cancel()
+
+	dataID := fftypes.NewUUID()
+	mdi := dm.database.(*databasemocks.Plugin)
+	mdi.On("GetDataByID", ctx, dataID, false).Return(&fftypes.Data{
+		ID:        dataID,
+		Namespace: "ns1",
+		Blobstore: true,
+		Blob:      &fftypes.BlobRef{Name: "file.txt", Size: 12},
+	}, nil)
+
+	mdx := dm.exchange.(*dataexchangemocks.Plugin)
+	mdx.On("DownloadBLOB", ctx, "ns1", *dataID).Return(ioutil.NopCloser(bytes.NewReader([]byte("some blob"))), nil)
+
+	data, reader, err := dm.DownloadBLOB(ctx, "ns1", dataID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, "file.txt", data.Blob.Name)
+	b, err := ioutil.ReadAll(reader)
+	assert.NoError(t, err)
+	assert.Equal(t, "some blob", string(b))
+
+	mdi.AssertExpectations(t)
+	mdx.AssertExpectations(t)
+
+}
+
+func TestDownloadBlobBadNamespace(t *testing.T) {
+
+	dm, ctx, cancel := newTestDataManager(t)
+	defer cancel()
+
+	_, _, err := dm.DownloadBLOB(ctx, "!wrong", fftypes.NewUUID().String())
+	assert.Regexp(t, "FF10131", err)
+
+}
+
+func TestDownloadBlobBadID(t *testing.T) {
+
+	dm, ctx, cancel := newTestDataManager(t)
+	defer cancel()
+
+	_, _, err := dm.DownloadBLOB(ctx, "ns1", "!uuid")
+	assert.Regexp(t, "FF10142", err)
+
+}
+
+func TestDownloadBlobLookupFail(t *testing.T) {
+
+	dm, ctx, cancel := newTestDataManager(t)
+	defer cancel()
+
+	dataID := fftypes.NewUUID()
+	mdi := dm.database.(*databasemocks.Plugin)
+	mdi.On("GetDataByID", ctx, dataID, false).Return(nil, fmt.Errorf("pop"))
+
+	_, _, err := dm.DownloadBLOB(ctx, "ns1", dataID.String())
+	assert.Regexp(t, "pop", err)
+
+}
+
+func TestDownloadBlobNotFound(t *testing.T) {
+
+	dm, ctx, cancel := newTestDataManager(t)
+	defer cancel()
+
+	dataID := fftypes.NewUUID()
+	mdi := dm.database.(*databasemocks.Plugin)
+	mdi.On("GetDataByID", ctx, dataID, false).Return(&fftypes.Data{
+		ID:        dataID,
+		Namespace: "ns2",
+		Blobstore: true,
+	}, nil)
+
+	_, _, err := dm.DownloadBLOB(ctx, "ns1", dataID.String())
+	assert.Regexp(t, "FF10143", err)
+
+}
+
+func TestDownloadBlobNotBlob(t *testing.T) {
+
+	dm, ctx, cancel := newTestDataManager(t)
+	defer cancel()
+
+	dataID := fftypes.NewUUID()
+	mdi := dm.database.(*databasemocks.Plugin)
+	mdi.On("GetDataByID", ctx, dataID, false).Return(&fftypes.Data{
+		ID:        dataID,
+		Namespace: "ns1",
+	}, nil)
+
+	_, _, err := dm.DownloadBLOB(ctx, "ns1", dataID.String())
+	assert.Regexp(t, "FF10234", err)
+
+}
+
+func TestDownloadBlobDXFail(t *testing.T) {
+
+	dm, ctx, cancel := newTestDataManager(t)
+	defer cancel()
+
+	dataID := fftypes.NewUUID()
+	mdi := dm.database.(*databasemocks.Plugin)
+	mdi.On("GetDataByID", ctx, dataID, false).Return(&fftypes.Data{
+		ID:        dataID,
+		Namespace: "ns1",
+		Blobstore: true,
+	}, nil)
+
+	mdx := dm.exchange.(*dataexchangemocks.Plugin)
+	mdx.On("DownloadBLOB", ctx, "ns1", *dataID).Return(nil, fmt.Errorf("pop"))
+
+	_, _, err := dm.DownloadBLOB(ctx, "ns1", dataID.String())
 	assert.Regexp(t, "pop", err)
 
 }
@@ -39,7 +39,9 @@ type Manager interface {
 	VerifyNamespaceExists(ctx context.Context, ns string) error
 
 	UploadJSON(ctx context.Context, ns string, data *fftypes.Data) (*fftypes.Data, error)
-	UploadBLOB(ctx context.Context, ns string, reader io.Reader) (*fftypes.Data, error)
+	UploadBLOB(ctx context.Context, ns string, blob *fftypes.BlobRef, reader io.Reader) (*fftypes.Data, error)
+	DownloadBLOB(ctx context.Context, ns, dataID string) (*fftypes.Data, io.ReadCloser, error)
+	CopyBlobToDX(ctx context.Context, data *fftypes.Data, reader io.Reader) (*fftypes.Blob, error)
 }
 
 type dataManager struct {
@@ -0,0 +1,252 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/hyperledger-labs/firefly/internal/config"
+	"github.com/hyperledger-labs/firefly/internal/database/sqlcommon"
+	"github.com/hyperledger-labs/firefly/internal/log"
+	"github.com/hyperledger-labs/firefly/internal/retry"
+	"github.com/hyperledger-labs/firefly/pkg/database"
+	"github.com/hyperledger-labs/firefly/pkg/fftypes"
+	"github.com/lib/pq"
+)
+
+type clusterEventType string
+
+const (
+	clusterEventMessageCreated      clusterEventType = "message_created"
+	clusterEventPinCreated          clusterEventType = "pin_created"
+	clusterEventEventCreated        clusterEventType = "event_created"
+	clusterEventSubscriptionCreated clusterEventType = "subscription_created"
+	clusterEventSubscriptionDeleted clusterEventType = "subscription_deleted"
+)
+
+// clusterEvent is the JSON payload of each NOTIFY
+type clusterEvent struct {
+	Type     clusterEventType `json:"type"`
+	Sequence int64            `json:"sequence,omitempty"`
+	ID       *fftypes.UUID    `json:"id,omitempty"`
+}
+
+// notificationListener is the subset of pq.Listener we use on the dedicated LISTEN connection
+type notificationListener interface {
+	Listen(channel string) error
+	NotificationChannel() <-chan *pq.Notification
+	Ping() error
+	Close() error
+}
+
+// clusterEvents sits between SQLCommon and the real callbacks. Each post-commit callback is published to
+// every instance sharing the database via NOTIFY, and each notification received via LISTEN (including
+// our own) is dispatched to the real callbacks.
+//
+// Whenever we are not listening - before the LISTEN first succeeds, and while the connection is lost - each
+// callback is also dispatched locally, so this instance is no worse off than without cluster events.
+type clusterEvents struct {
+	ctx          context.Context
+	db           *sql.DB
+	channel      string
+	pingInterval time.Duration
+	retry        retry.Retry
+	callbacks    database.Callbacks
+	listener     notificationListener
+	listening    bool
+	mux          sync.Mutex
+	closed       chan struct{}
+}
+
+func newClusterEvents(ctx context.Context, prefix config.Prefix, callbacks database.Callbacks) *clusterEvents {
+	return &clusterEvents{
+		ctx:          log.WithLogField(ctx, "role", "cluster-events"),
+		channel:      prefix.GetString(PSQLConfClusterEventsChannel),
+		pingInterval: prefix.GetDuration(PSQLConfClusterEventsPingInterval),
+		retry: retry.Retry{
+			InitialDelay: prefix.GetDuration(PSQLConfClusterEventsMinReconnectDelay),
+			MaximumDelay: prefix.GetDuration(PSQLConfClusterEventsMaxReconnectDelay),
+		},
+		callbacks: callbacks,
+		closed:    make(chan struct{}),
+	}
+}
+
+func (ce *clusterEvents) newPQListener(prefix config.Prefix) notificationListener {
+	return pq.NewListener(
+		prefix.GetString(sqlcommon.SQLConfDatasourceURL),
+		prefix.GetDuration(PSQLConfClusterEventsMinReconnectDelay),
+		prefix.GetDuration(PSQLConfClusterEventsMaxReconnectDelay),
+		ce.connectionEvent,
+	)
+}
+
+func (ce *clusterEvents) connectionEvent(ev pq.ListenerEventType, err error) {
+	if err != nil {
+		log.L(ce.ctx).Warnf("Cluster event listener connection event %d: %s", ev, err)
+	}
+	switch ev {
+	case pq.ListenerEventDisconnected:
+		ce.setListening(false)
+	case pq.ListenerEventReconnected:
+		// The listener has re-issued the LISTEN on the new connection before informing us
+		ce.setListening(true)
+	}
+}
+
+func (ce *clusterEvents) setListening(listening bool) {
+	ce.mux.Lock()
+	defer ce.mux.Unlock()
+	ce.listening = listening
+}
+
+func (ce *clusterEvents) isListening() bool {
+	ce.mux.Lock()
+	defer ce.mux.Unlock()
+	return ce.listening
+}
+
+func (ce *clusterEvents) start(db *sql.DB, listener notificationListener) {
+	ce.db = db
+	ce.listener = listener
+	go ce.listenLoop()
+}
+
+func (ce *clusterEvents) listenLoop() {
+	defer close(ce.closed)
+	l := log.L(ce.ctx)
+
+	// Listen blocks until the connection is established, so we close the listener on shutdown to unblock it
+	go func() {
+		<-ce.ctx.Done()
+		_ = ce.listener.Close()
+	}()
+
+	// Listen only fails if the server rejects it, which might be transient, so we retry until we are shut down
+	err := ce.retry.Do(ce.ctx, "listen for cluster events", func(attempt int) (retry bool, err error) {
+		err = ce.listener.Listen(ce.channel)
+		if err == pq.ErrChannelAlreadyOpen {
+			err = nil
+		}
+		return true, err
+	})
+	if err != nil {
+		l.Debugf("Cluster event listener exiting before listening: %s", err)
+		return
+	}
+	ce.setListening(true)
+	l.Debugf("Listening for cluster events on channel '%s'", ce.channel)
+
+	notifications := ce.listener.NotificationChannel()
+	for {
+		select {
+		case <-ce.ctx.Done():
+			l.Debugf("Cluster event listener exiting")
+			return
+		case n, ok := <-notifications:
+			if !ok {
+				l.Debugf("Cluster event listener closed")
+				return
+			}
+			ce.dispatch(n)
+		case <-time.After(ce.pingInterval):
+			if err := ce.listener.Ping(); err != nil {
+				l.Warnf("Cluster event listener ping failed: %s", err)
+			}
+		}
+	}
+}
+
+func (ce *clusterEvents) dispatch(n *pq.Notification) {
+	l := log.L(ce.ctx)
+	if n == nil {
+		// The listener sends nil after reconnecting. Any notifications sent while we were disconnected are lost,
+		// but the pollers will find the new rows on their next timeout.
+		l.Infof("Cluster event listener reconnected")
+		return
+	}
+
+	var ev clusterEvent
+	if err := json.Unmarshal([]byte(n.Extra), &ev); err != nil {
+		l.Warnf("Invalid cluster event '%s': %s", n.Extra, err)
+		return
+	}
+	l.Tracef("Cluster event from pid %d: %s", n.BePid, n.Extra)
+
+	switch ev.Type {
+	case clusterEventMessageCreated:
+		ce.callbacks.MessageCreated(ev.Sequence)
+	case clusterEventPinCreated:
+		ce.callbacks.PinCreated(ev.Sequence)
+	case clusterEventEventCreated:
+		ce.callbacks.EventCreated(ev.Sequence)
+	case clusterEventSubscriptionCreated:
+		ce.callbacks.SubscriptionCreated(ev.ID)
+	case clusterEventSubscriptionDeleted:
+		ce.callbacks.SubscriptionDeleted(ev.ID)
+	default:
+		l.Warnf("Unknown cluster event type '%s'", ev.Type)
+	}
+}
+
+// publish sends the event to every listening instance. If the NOTIFY fails, or we are not listening
+// ourselves, we fall back to informing this instance directly.
+func (ce *clusterEvents) publish(ev *clusterEvent, local func()) {
+	payload, _ := json.Marshal(ev)
+	if _, err := ce.db.ExecContext(ce.ctx, "SELECT pg_notify($1, $2)", ce.channel, string(payload)); err != nil {
+		log.L(ce.ctx).Warnf("Failed to publish cluster event %s: %s", payload, err)
+		local()
+		return
+	}
+	if !ce.isListening() {
+		local()
+	}
+}
+
+func (ce *clusterEvents) MessageCreated(sequence int64) {
+	ce.publish(&clusterEvent{Type: clusterEventMessageCreated, Sequence: sequence}, func() {
+		ce.callbacks.MessageCreated(sequence)
+	})
+}
+
+func (ce *clusterEvents) PinCreated(sequence int64) {
+	ce.publish(&clusterEvent{Type: clusterEventPinCreated, Sequence: sequence}, func() {
+		ce.callbacks.PinCreated(sequence)
+	})
+}
+
+func (ce *clusterEvents) EventCreated(sequence int64) {
+	ce.publish(&clusterEvent{Type: clusterEventEventCreated, Sequence: sequence}, func() {
+		ce.callbacks.EventCreated(sequence)
+	})
+}
+
+func (ce *clusterEvents) SubscriptionCreated(id *fftypes.UUID) {
+	ce.publish(&clusterEvent{Type: clusterEventSubscriptionCreated, ID: id}, func() {
+		ce.callbacks.SubscriptionCreated(id)
+	})
+}
+
+func (ce *clusterEvents) SubscriptionDeleted(id *fftypes.UUID) {
+	ce.publish(&clusterEvent{Type: clusterEventSubscriptionDeleted, ID: id}, func() {
+		ce.callbacks.SubscriptionDeleted(id)
+	})
+}
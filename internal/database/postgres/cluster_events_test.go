@@ -0,0 +1,247 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/hyperledger-labs/firefly/internal/config"
+	"github.com/hyperledger-labs/firefly/mocks/databasemocks"
+	"github.com/hyperledger-labs/firefly/pkg/fftypes"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type testListener struct {
+	listenErrs    []error
+	notifications chan *pq.Notification
+	pings         chan error
+	closed        chan bool
+}
+
+func (tl *testListener) Listen(channel string) error {
+	if len(tl.listenErrs) == 0 {
+		return nil
+	}
+	err := tl.listenErrs[0]
+	tl.listenErrs = tl.listenErrs[1:]
+	return err
+}
+
+func (tl *testListener) NotificationChannel() <-chan *pq.Notification {
+	return tl.notifications
+}
+
+func (tl *testListener) Ping() error {
+	select {
+	case err := <-tl.pings:
+		return err
+	default:
+		return nil
+	}
+}
+
+func (tl *testListener) Close() error {
+	close(tl.closed)
+	return nil
+}
+
+func newTestClusterEvents() (*clusterEvents, *databasemocks.Callbacks, *testListener, func()) {
+	config.Reset()
+	psql := &Postgres{}
+	prefix := config.NewPluginConfig("unittest")
+	psql.InitPrefix(prefix)
+	ctx, cancel := context.WithCancel(context.Background())
+	dcb := &databasemocks.Callbacks{}
+	ce := newClusterEvents(ctx, prefix, dcb)
+	ce.retry.InitialDelay = 1 * time.Microsecond
+	tl := &testListener{
+		notifications: make(chan *pq.Notification, 1),
+		pings:         make(chan error, 2),
+		closed:        make(chan bool),
+	}
+	return ce, dcb, tl, cancel
+}
+
+func TestClusterEventsPublish(t *testing.T) {
+	ce, dcb, tl, cancel := newTestClusterEvents()
+	defer cancel()
+	db, mdb, _ := sqlmock.New()
+	ce.db = db
+	ce.listener = tl
+	ce.listening = true
+
+	id := fftypes.NewUUID()
+	for _, payload := range []string{
+		`{"type":"message_created","sequence":1}`,
+		`{"type":"pin_created","sequence":2}`,
+		`{"type":"event_created","sequence":3}`,
+		fmt.Sprintf(`{"type":"subscription_created","id":"%s"}`, id),
+		fmt.Sprintf(`{"type":"subscription_deleted","id":"%s"}`, id),
+	} {
+		mdb.ExpectExec("SELECT pg_notify").WithArgs("firefly_events", payload).WillReturnResult(sqlmock.NewResult(0, 0))
+	}
+
+	ce.MessageCreated(1)
+	ce.PinCreated(2)
+	ce.EventCreated(3)
+	ce.SubscriptionCreated(id)
+	ce.SubscriptionDeleted(id)
+
+	assert.NoError(t, mdb.ExpectationsWereMet())
+	dcb.AssertExpectations(t)
+}
+
+func TestClusterEventsPublishFailFallsBackToLocal(t *testing.T) {
+	ce, dcb, _, cancel := newTestClusterEvents()
+	defer cancel()
+	db, mdb, _ := sqlmock.New()
+	ce.db = db
+	mdb.MatchExpectationsInOrder(false)
+	for i := 0; i < 5; i++ {
+		mdb.ExpectExec("SELECT pg_notify").WillReturnError(fmt.Errorf("pop"))
+	}
+
+	id := fftypes.NewUUID()
+	dcb.On("MessageCreated", int64(1)).Return()
+	dcb.On("PinCreated", int64(2)).Return()
+	dcb.On("EventCreated", int64(3)).Return()
+	dcb.On("SubscriptionCreated", id).Return()
+	dcb.On("SubscriptionDeleted", id).Return()
+
+	ce.MessageCreated(1)
+	ce.PinCreated(2)
+	ce.EventCreated(3)
+	ce.SubscriptionCreated(id)
+	ce.SubscriptionDeleted(id)
+
+	assert.NoError(t, mdb.ExpectationsWereMet())
+	dcb.AssertExpectations(t)
+}
+
+func TestClusterEventsDispatch(t *testing.T) {
+	ce, dcb, tl, cancel := newTestClusterEvents()
+	ce.start(nil, tl)
+
+	id := fftypes.NewUUID()
+	done := make(chan bool)
+	dcb.On("MessageCreated", int64(1)).Return()
+	dcb.On("PinCreated", int64(2)).Return()
+	dcb.On("EventCreated", int64(3)).Return()
+	dcb.On("SubscriptionCreated", id).Return()
+	dcb.On("SubscriptionDeleted", id).Return().Run(func(args mock.Arguments) {
+		close(done)
+	})
+
+	for _, payload := range []string{
+		`!json`,
+		`{"type":"unknown"}`,
+		`{"type":"message_created","sequence":1}`,
+		`{"type":"pin_created","sequence":2}`,
+		`{"type":"event_created","sequence":3}`,
+		fmt.Sprintf(`{"type":"subscription_created","id":"%s"}`, id),
+		fmt.Sprintf(`{"type":"subscription_deleted","id":"%s"}`, id),
+	} {
+		tl.notifications <- &pq.Notification{Channel: "firefly_events", Extra: payload}
+	}
+	tl.notifications <- nil
+	<-done
+
+	cancel()
+	<-ce.closed
+	<-tl.closed
+	dcb.AssertExpectations(t)
+}
+
+func TestClusterEventsPublishNotListeningAlsoLocal(t *testing.T) {
+	ce, dcb, _, cancel := newTestClusterEvents()
+	defer cancel()
+	db, mdb, _ := sqlmock.New()
+	ce.db = db
+	mdb.ExpectExec("SELECT pg_notify").WillReturnResult(sqlmock.NewResult(0, 0))
+	dcb.On("MessageCreated", int64(1)).Return()
+
+	ce.MessageCreated(1)
+
+	assert.NoError(t, mdb.ExpectationsWereMet())
+	dcb.AssertExpectations(t)
+}
+
+func TestClusterEventsListenRetry(t *testing.T) {
+	ce, _, tl, cancel := newTestClusterEvents()
+	defer cancel()
+	tl.listenErrs = []error{fmt.Errorf("pop"), pq.ErrChannelAlreadyOpen}
+	close(tl.notifications)
+	ce.start(nil, tl)
+	<-ce.closed
+	assert.True(t, ce.isListening())
+}
+
+func TestClusterEventsListenFailUntilClosed(t *testing.T) {
+	ce, _, tl, cancel := newTestClusterEvents()
+	tl.listenErrs = []error{fmt.Errorf("pop")}
+	ce.retry.InitialDelay = 1 * time.Minute
+	ce.start(nil, tl)
+	cancel()
+	<-ce.closed
+	assert.False(t, ce.isListening())
+}
+
+func TestClusterEventsConnectionEvents(t *testing.T) {
+	ce, _, _, cancel := newTestClusterEvents()
+	defer cancel()
+	ce.connectionEvent(pq.ListenerEventConnected, nil)
+	assert.False(t, ce.isListening())
+	ce.connectionEvent(pq.ListenerEventReconnected, nil)
+	assert.True(t, ce.isListening())
+	ce.connectionEvent(pq.ListenerEventDisconnected, fmt.Errorf("pop"))
+	assert.False(t, ce.isListening())
+}
+
+func TestClusterEventsNewPQListener(t *testing.T) {
+	ce, _, _, cancel := newTestClusterEvents()
+	defer cancel()
+	l := ce.newPQListener(config.NewPluginConfig("unittest"))
+	assert.NotNil(t, l)
+	l.Close()
+}
+
+func TestClusterEventsListenerClosed(t *testing.T) {
+	ce, _, tl, cancel := newTestClusterEvents()
+	defer cancel()
+	close(tl.notifications)
+	ce.start(nil, tl)
+	<-ce.closed
+}
+
+func TestClusterEventsPing(t *testing.T) {
+	ce, _, tl, cancel := newTestClusterEvents()
+	ce.pingInterval = 1 * time.Microsecond
+	tl.pings <- nil
+	tl.pings <- fmt.Errorf("pop")
+	ce.start(nil, tl)
+	for len(tl.pings) > 0 {
+		time.Sleep(1 * time.Millisecond)
+	}
+	cancel()
+	<-ce.closed
+}
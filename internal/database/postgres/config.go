@@ -20,6 +20,31 @@ import (
 	"github.com/hyperledger-labs/firefly/internal/config"
 )
 
+const (
+	defaultClusterEventsChannel           = "firefly_events"
+	defaultClusterEventsMinReconnectDelay = "500ms"
+	defaultClusterEventsMaxReconnectDelay = "30s"
+	defaultClusterEventsPingInterval      = "90s"
+)
+
+const (
+	// PSQLConfClusterEventsEnabled publishes database change events with NOTIFY, and receives them with LISTEN, so every instance sharing the database is informed
+	PSQLConfClusterEventsEnabled = "clusterEvents.enabled"
+	// PSQLConfClusterEventsChannel is the name of the NOTIFY/LISTEN channel, which must be the same on all instances sharing the database
+	PSQLConfClusterEventsChannel = "clusterEvents.channel"
+	// PSQLConfClusterEventsMinReconnectDelay is the initial delay before reconnecting the dedicated LISTEN connection when it is lost
+	PSQLConfClusterEventsMinReconnectDelay = "clusterEvents.minReconnectDelay"
+	// PSQLConfClusterEventsMaxReconnectDelay is the maximum delay between attempts to reconnect the dedicated LISTEN connection
+	PSQLConfClusterEventsMaxReconnectDelay = "clusterEvents.maxReconnectDelay"
+	// PSQLConfClusterEventsPingInterval is how long the LISTEN connection can be idle before it is pinged to check it is still alive
+	PSQLConfClusterEventsPingInterval = "clusterEvents.pingInterval"
+)
+
 func (psql *Postgres) InitPrefix(prefix config.Prefix) {
 	psql.SQLCommon.InitPrefix(psql, prefix)
+	prefix.AddKnownKey(PSQLConfClusterEventsEnabled, true)
+	prefix.AddKnownKey(PSQLConfClusterEventsChannel, defaultClusterEventsChannel)
+	prefix.AddKnownKey(PSQLConfClusterEventsMinReconnectDelay, defaultClusterEventsMinReconnectDelay)
+	prefix.AddKnownKey(PSQLConfClusterEventsMaxReconnectDelay, defaultClusterEventsMaxReconnectDelay)
+	prefix.AddKnownKey(PSQLConfClusterEventsPingInterval, defaultClusterEventsPingInterval)
 }
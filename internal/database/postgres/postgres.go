@@ -35,11 +35,23 @@ import (
 
 type Postgres struct {
 	sqlcommon.SQLCommon
+	clusterEvents *clusterEvents
 }
 
 func (psql *Postgres) Init(ctx context.Context, prefix config.Prefix, callbacks database.Callbacks) error {
 	capabilities := &database.Capabilities{}
-	return psql.SQLCommon.Init(ctx, psql, prefix, callbacks, capabilities)
+	if prefix.GetBool(PSQLConfClusterEventsEnabled) {
+		capabilities.ClusterEvents = true
+		psql.clusterEvents = newClusterEvents(ctx, prefix, callbacks)
+		callbacks = psql.clusterEvents
+	}
+	if err := psql.SQLCommon.Init(ctx, psql, prefix, callbacks, capabilities); err != nil {
+		return err
+	}
+	if psql.clusterEvents != nil {
+		psql.clusterEvents.start(psql.DB(), psql.clusterEvents.newPQListener(prefix))
+	}
+	return nil
 }
 
 func (psql *Postgres) Name() string {
@@ -54,6 +66,10 @@ func (psql *Postgres) UpdateInsertForSequenceReturn(insert sq.InsertBuilder) (sq
 	return insert.Suffix(" RETURNING seq"), true
 }
 
+func (psql *Postgres) SelectForShare(q sq.SelectBuilder) sq.SelectBuilder {
+	return q.Suffix("FOR SHARE")
+}
+
 func (psql *Postgres) SequenceField(tableName string) string {
 	if tableName != "" {
 		return fmt.Sprintf("%s.seq", tableName)
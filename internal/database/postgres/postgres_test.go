@@ -33,8 +33,10 @@ func TestPostgresProvider(t *testing.T) {
 	prefix := config.NewPluginConfig("unittest")
 	psql.InitPrefix(prefix)
 	prefix.Set(sqlcommon.SQLConfDatasourceURL, "!bad connection")
-	err := psql.Init(context.Background(), prefix, dcb)
+	ctx, cancel := context.WithCancel(context.Background())
+	err := psql.Init(ctx, prefix, dcb)
 	assert.NoError(t, err)
+	assert.True(t, psql.Capabilities().ClusterEvents)
 	_, err = psql.GetMigrationDriver(psql.DB())
 	assert.Error(t, err)
 
@@ -50,4 +52,37 @@ func TestPostgresProvider(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "INSERT INTO test (col1) VALUES (?)  RETURNING seq", sql)
 	assert.True(t, query)
+
+	sel, _, err := psql.SelectForShare(sq.Select("*").From("test")).ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM test FOR SHARE", sel)
+
+	cancel()
+	<-psql.clusterEvents.closed
+}
+
+func TestPostgresProviderClusterEventsDisabled(t *testing.T) {
+	psql := &Postgres{}
+	dcb := &databasemocks.Callbacks{}
+	prefix := config.NewPluginConfig("unittest")
+	psql.InitPrefix(prefix)
+	prefix.Set(sqlcommon.SQLConfDatasourceURL, "!bad connection")
+	prefix.Set(PSQLConfClusterEventsEnabled, false)
+	err := psql.Init(context.Background(), prefix, dcb)
+	assert.NoError(t, err)
+	assert.False(t, psql.Capabilities().ClusterEvents)
+	assert.Nil(t, psql.clusterEvents)
+}
+
+func TestPostgresProviderInitFail(t *testing.T) {
+	psql := &Postgres{}
+	dcb := &databasemocks.Callbacks{}
+	prefix := config.NewPluginConfig("unittest")
+	psql.InitPrefix(prefix)
+	prefix.Set(sqlcommon.SQLConfDatasourceURL, "!bad connection")
+	prefix.Set(sqlcommon.SQLConfMigrationsAuto, true)
+	prefix.Set(PSQLConfClusterEventsEnabled, true)
+	err := psql.Init(context.Background(), prefix, dcb)
+	assert.Error(t, err)
+	assert.Nil(t, psql.clusterEvents.listener)
 }
@@ -55,6 +55,11 @@ func (ql *QL) UpdateInsertForSequenceReturn(insert sq.InsertBuilder) (sq.InsertB
 	return insert, false
 }
 
+func (ql *QL) SelectForShare(q sq.SelectBuilder) sq.SelectBuilder {
+	// No locking needed, as QL allows a single writer
+	return q
+}
+
 func (ql *QL) SequenceField(tableName string) string {
 	return fmt.Sprintf("id(%s)", tableName)
 }
@@ -50,4 +50,8 @@ func TestQLProvider(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "INSERT INTO test (col1) VALUES (?)", sql)
 	assert.False(t, query)
+
+	sel, _, err := ql.SelectForShare(sq.Select("*").From("test")).ToSql()
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM test", sel)
 }
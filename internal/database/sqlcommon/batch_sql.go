@@ -177,16 +177,16 @@ func (s *SQLCommon) GetBatchByID(ctx context.Context, id *fftypes.UUID) (message
 	return batch, nil
 }
 
-func (s *SQLCommon) GetBatches(ctx context.Context, filter database.Filter) (message []*fftypes.Batch, err error) {
+func (s *SQLCommon) GetBatches(ctx context.Context, filter database.Filter) (message []*fftypes.Batch, res *database.FilterResult, err error) {
 
-	query, err := s.filterSelect(ctx, "", sq.Select(batchColumns...).From("batches"), filter, batchFilterTypeMap)
+	query, fop, fi, err := s.filterSelect(ctx, "", sq.Select(batchColumns...).From("batches"), filter, batchFilterTypeMap)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	rows, err := s.query(ctx, query)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer rows.Close()
 
@@ -194,12 +194,12 @@ func (s *SQLCommon) GetBatches(ctx context.Context, filter database.Filter) (mes
 	for rows.Next() {
 		batch, err := s.batchResult(ctx, rows)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		batches = append(batches, batch)
 	}
 
-	return batches, err
+	return batches, s.queryRes(ctx, "batches", fop, fi), err
 
 }
 
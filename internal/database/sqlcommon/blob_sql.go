@@ -0,0 +1,110 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlcommon
+
+import (
+	"context"
+	"database/sql"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/hyperledger-labs/firefly/internal/i18n"
+	"github.com/hyperledger-labs/firefly/pkg/database"
+	"github.com/hyperledger-labs/firefly/pkg/fftypes"
+)
+
+var (
+	blobColumns = []string{
+		"namespace",
+		"data_id",
+		"hash",
+		"peer",
+		"created",
+	}
+	blobFilterTypeMap = map[string]string{
+		"data": "data_id",
+	}
+)
+
+func (s *SQLCommon) InsertBlob(ctx context.Context, blob *fftypes.Blob) (err error) {
+	ctx, tx, autoCommit, err := s.beginOrUseTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer s.rollbackTx(ctx, tx, autoCommit)
+
+	sequence, err := s.insertTx(ctx, tx,
+		sq.Insert("blobs").
+			Columns(blobColumns...).
+			Values(
+				blob.Namespace,
+				blob.Data,
+				blob.Hash,
+				blob.Peer,
+				blob.Created,
+			),
+	)
+	if err != nil {
+		return err
+	}
+	blob.Sequence = sequence
+
+	return s.commitTx(ctx, tx, autoCommit)
+}
+
+func (s *SQLCommon) blobResult(ctx context.Context, row *sql.Rows) (*fftypes.Blob, error) {
+	blob := fftypes.Blob{}
+	err := row.Scan(
+		&blob.Namespace,
+		&blob.Data,
+		&blob.Hash,
+		&blob.Peer,
+		&blob.Created,
+		&blob.Sequence,
+	)
+	if err != nil {
+		return nil, i18n.WrapError(ctx, err, i18n.MsgDBReadErr, "blobs")
+	}
+	return &blob, nil
+}
+
+func (s *SQLCommon) GetBlobs(ctx context.Context, filter database.Filter) (message []*fftypes.Blob, err error) {
+
+	cols := append([]string{}, blobColumns...)
+	cols = append(cols, s.provider.SequenceField(""))
+	query, _, _, err := s.filterSelect(ctx, "", sq.Select(cols...).From("blobs"), filter, blobFilterTypeMap)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	blob := []*fftypes.Blob{}
+	for rows.Next() {
+		d, err := s.blobResult(ctx, rows)
+		if err != nil {
+			return nil, err
+		}
+		blob = append(blob, d)
+	}
+
+	return blob, err
+
+}
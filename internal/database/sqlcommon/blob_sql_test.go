@@ -0,0 +1,128 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlcommon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/hyperledger-labs/firefly/internal/log"
+	"github.com/hyperledger-labs/firefly/pkg/database"
+	"github.com/hyperledger-labs/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBlobsE2EWithDB(t *testing.T) {
+	log.SetLevel("debug")
+
+	s := newQLTestProvider(t)
+	defer s.Close()
+	ctx := context.Background()
+
+	// Create a new blob entry
+	blob := &fftypes.Blob{
+		Namespace: "ns1",
+		Data:      fftypes.NewUUID(),
+		Hash:      fftypes.NewRandB32(),
+		Peer:      "peer1",
+		Created:   fftypes.Now(),
+	}
+	err := s.InsertBlob(ctx, blob)
+	assert.NoError(t, err)
+
+	// Query back the blob
+	fb := database.BlobQueryFactory.NewFilter(ctx)
+	filter := fb.And(
+		fb.Eq("namespace", blob.Namespace),
+		fb.Eq("data", blob.Data),
+		fb.Eq("hash", blob.Hash),
+		fb.Eq("peer", blob.Peer),
+		fb.Eq("created", blob.Created),
+	)
+	blobRes, err := s.GetBlobs(ctx, filter)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(blobRes))
+	blobJson, _ := json.Marshal(&blob)
+	blobReadJson, _ := json.Marshal(blobRes[0])
+	assert.Equal(t, string(blobJson), string(blobReadJson))
+	assert.Equal(t, blob.Sequence, blobRes[0].Sequence)
+
+	// Check a different data ID does not match
+	filter = fb.And(
+		fb.Eq("data", fftypes.NewUUID()),
+	)
+	blobRes, err = s.GetBlobs(ctx, filter)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(blobRes))
+
+}
+
+func TestInsertBlobFailBegin(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin().WillReturnError(fmt.Errorf("pop"))
+	err := s.InsertBlob(context.Background(), &fftypes.Blob{})
+	assert.Regexp(t, "FF10114", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInsertBlobFailInsert(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT .*").WillReturnError(fmt.Errorf("pop"))
+	mock.ExpectRollback()
+	err := s.InsertBlob(context.Background(), &fftypes.Blob{Hash: fftypes.NewRandB32()})
+	assert.Regexp(t, "FF10116", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInsertBlobFailCommit(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT .*").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit().WillReturnError(fmt.Errorf("pop"))
+	err := s.InsertBlob(context.Background(), &fftypes.Blob{Hash: fftypes.NewRandB32()})
+	assert.Regexp(t, "FF10119", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetBlobsQueryFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
+	f := database.BlobQueryFactory.NewFilter(context.Background()).Eq("hash", "")
+	_, err := s.GetBlobs(context.Background(), f)
+	assert.Regexp(t, "FF10115", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetBlobsBuildQueryFail(t *testing.T) {
+	s, _ := newMockProvider().init()
+	f := database.BlobQueryFactory.NewFilter(context.Background()).Eq("hash", map[bool]bool{true: false})
+	_, err := s.GetBlobs(context.Background(), f)
+	assert.Regexp(t, "FF10149.*type", err)
+}
+
+func TestGetBlobsReadFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"namespace"}).AddRow("only one"))
+	f := database.BlobQueryFactory.NewFilter(context.Background()).Eq("hash", "")
+	_, err := s.GetBlobs(context.Background(), f)
+	assert.Regexp(t, "FF10121", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
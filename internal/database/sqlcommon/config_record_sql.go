@@ -120,15 +120,15 @@ func (s *SQLCommon) GetConfigRecord(ctx context.Context, key string) (result *ff
 	return configRecord, nil
 }
 
-func (s *SQLCommon) GetConfigRecords(ctx context.Context, filter database.Filter) (result []*fftypes.ConfigRecord, err error) {
-	query, err := s.filterSelect(ctx, "", sq.Select(configRecordColumns...).From("config"), filter, configRecordFilterTypeMap)
+func (s *SQLCommon) GetConfigRecords(ctx context.Context, filter database.Filter) (result []*fftypes.ConfigRecord, res *database.FilterResult, err error) {
+	query, fop, fi, err := s.filterSelect(ctx, "", sq.Select(configRecordColumns...).From("config"), filter, configRecordFilterTypeMap)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	rows, err := s.query(ctx, query)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer rows.Close()
 
@@ -136,12 +136,12 @@ func (s *SQLCommon) GetConfigRecords(ctx context.Context, filter database.Filter
 	for rows.Next() {
 		d, err := s.configRecordResult(ctx, rows)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		configRecord = append(configRecord, d)
 	}
 
-	return configRecord, err
+	return configRecord, s.queryRes(ctx, "config", fop, fi), err
 
 }
 
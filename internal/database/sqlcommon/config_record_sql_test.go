@@ -69,7 +69,7 @@ func TestConfigRecordE2EWithDB(t *testing.T) {
 	// Query back the config record
 	fb := database.ConfigRecordQueryFactory.NewFilter(ctx)
 	filter := fb.And()
-	configRecordRes, err := s.GetConfigRecords(ctx, filter)
+	configRecordRes, _, err := s.GetConfigRecords(ctx, filter)
 	assert.NoError(t, err)
 	assert.Equal(t, 1, len(configRecordRes))
 	configRecordReadJson, _ = json.Marshal(configRecordRes[0])
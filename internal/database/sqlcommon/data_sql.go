@@ -37,12 +37,20 @@ var (
 		"hash",
 		"created",
 		"blobstore",
+		"blob_name",
+		"blob_mimetype",
+		"blob_size",
+		"blob_payloadref",
 	}
 	dataColumnsWithValue = append(append([]string{}, dataColumnsNoValue...), "value")
 	dataFilterTypeMap    = map[string]string{
 		"validator":        "validator",
 		"datatype.name":    "datatype_name",
 		"datatype.version": "datatype_version",
+		"blob.name":        "blob_name",
+		"blob.mimetype":    "blob_mimetype",
+		"blob.size":        "blob_size",
+		"blob.payloadref":  "blob_payloadref",
 	}
 )
 
@@ -82,6 +90,10 @@ func (s *SQLCommon) UpsertData(ctx context.Context, data *fftypes.Data, allowExi
 	if datatype == nil {
 		datatype = &fftypes.DatatypeRef{}
 	}
+	blob := data.Blob
+	if blob == nil {
+		blob = &fftypes.BlobRef{}
+	}
 
 	if existing {
 		// Update the data
@@ -94,6 +106,10 @@ func (s *SQLCommon) UpsertData(ctx context.Context, data *fftypes.Data, allowExi
 				Set("hash", data.Hash).
 				Set("created", data.Created).
 				Set("blobstore", data.Blobstore).
+				Set("blob_name", blob.Name).
+				Set("blob_mimetype", blob.MimeType).
+				Set("blob_size", blob.Size).
+				Set("blob_payloadref", blob.PayloadRef).
 				Set("value", data.Value).
 				Where(sq.Eq{"id": data.ID}),
 		); err != nil {
@@ -112,6 +128,10 @@ func (s *SQLCommon) UpsertData(ctx context.Context, data *fftypes.Data, allowExi
 					data.Hash,
 					data.Created,
 					data.Blobstore,
+					blob.Name,
+					blob.MimeType,
+					blob.Size,
+					blob.PayloadRef,
 					data.Value,
 				),
 		); err != nil {
@@ -125,6 +145,7 @@ func (s *SQLCommon) UpsertData(ctx context.Context, data *fftypes.Data, allowExi
 func (s *SQLCommon) dataResult(ctx context.Context, row *sql.Rows, withValue bool) (*fftypes.Data, error) {
 	data := fftypes.Data{
 		Datatype: &fftypes.DatatypeRef{},
+		Blob:     &fftypes.BlobRef{},
 	}
 	results := []interface{}{
 		&data.ID,
@@ -135,6 +156,10 @@ func (s *SQLCommon) dataResult(ctx context.Context, row *sql.Rows, withValue boo
 		&data.Hash,
 		&data.Created,
 		&data.Blobstore,
+		&data.Blob.Name,
+		&data.Blob.MimeType,
+		&data.Blob.Size,
+		&data.Blob.PayloadRef,
 	}
 	if withValue {
 		results = append(results, &data.Value)
@@ -143,6 +168,9 @@ func (s *SQLCommon) dataResult(ctx context.Context, row *sql.Rows, withValue boo
 	if data.Datatype.Name == "" && data.Datatype.Version == "" {
 		data.Datatype = nil
 	}
+	if !data.Blobstore {
+		data.Blob = nil
+	}
 	if err != nil {
 		return nil, i18n.WrapError(ctx, err, i18n.MsgDBReadErr, "data")
 	}
@@ -180,16 +208,16 @@ func (s *SQLCommon) GetDataByID(ctx context.Context, id *fftypes.UUID, withValue
 	return data, nil
 }
 
-func (s *SQLCommon) GetData(ctx context.Context, filter database.Filter) (message []*fftypes.Data, err error) {
+func (s *SQLCommon) GetData(ctx context.Context, filter database.Filter) (message []*fftypes.Data, res *database.FilterResult, err error) {
 
-	query, err := s.filterSelect(ctx, "", sq.Select(dataColumnsWithValue...).From("data"), filter, dataFilterTypeMap)
+	query, fop, fi, err := s.filterSelect(ctx, "", sq.Select(dataColumnsWithValue...).From("data"), filter, dataFilterTypeMap)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	rows, err := s.query(ctx, query)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer rows.Close()
 
@@ -197,18 +225,18 @@ func (s *SQLCommon) GetData(ctx context.Context, filter database.Filter) (messag
 	for rows.Next() {
 		d, err := s.dataResult(ctx, rows, true)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		data = append(data, d)
 	}
 
-	return data, err
+	return data, s.queryRes(ctx, "data", fop, fi), err
 
 }
 
 func (s *SQLCommon) GetDataRefs(ctx context.Context, filter database.Filter) (message fftypes.DataRefs, err error) {
 
-	query, err := s.filterSelect(ctx, "", sq.Select("id", "hash").From("data"), filter, dataFilterTypeMap)
+	query, _, _, err := s.filterSelect(ctx, "", sq.Select("id", "hash").From("data"), filter, dataFilterTypeMap)
 	if err != nil {
 		return nil, err
 	}
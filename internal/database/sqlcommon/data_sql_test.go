@@ -85,9 +85,16 @@ func TestDataE2EWithDB(t *testing.T) {
 			Name:    "customer",
 			Version: "0.0.1",
 		},
-		Hash:    fftypes.NewRandB32(),
-		Created: fftypes.Now(),
-		Value:   []byte(val2.String()),
+		Hash:      fftypes.NewRandB32(),
+		Created:   fftypes.Now(),
+		Value:     []byte(val2.String()),
+		Blobstore: true,
+		Blob: &fftypes.BlobRef{
+			Name:       "customer.json",
+			MimeType:   "application/json",
+			Size:       12345,
+			PayloadRef: fftypes.NewRandB32(),
+		},
 	}
 
 	// Check disallows hash update
@@ -117,9 +124,12 @@ func TestDataE2EWithDB(t *testing.T) {
 		fb.Eq("datatype.name", dataUpdated.Datatype.Name),
 		fb.Eq("datatype.version", dataUpdated.Datatype.Version),
 		fb.Eq("hash", dataUpdated.Hash),
+		fb.Eq("blob.name", dataUpdated.Blob.Name),
+		fb.Eq("blob.size", dataUpdated.Blob.Size),
+		fb.Eq("blob.payloadref", dataUpdated.Blob.PayloadRef),
 		fb.Gt("created", 0),
 	)
-	dataRes, err := s.GetData(ctx, filter)
+	dataRes, _, err := s.GetData(ctx, filter)
 	assert.NoError(t, err)
 	assert.Equal(t, 1, len(dataRes))
 	dataReadJson, _ = json.Marshal(dataRes[0])
@@ -142,7 +152,7 @@ func TestDataE2EWithDB(t *testing.T) {
 		fb.Eq("id", dataUpdated.ID.String()),
 		fb.Eq("datatype.version", v2),
 	)
-	dataRes, err = s.GetData(ctx, filter)
+	dataRes, _, err = s.GetData(ctx, filter)
 	assert.NoError(t, err)
 	assert.Equal(t, 1, len(dataRes))
 
@@ -235,7 +245,7 @@ func TestGetDataQueryFail(t *testing.T) {
 	s, mock := newMockProvider().init()
 	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
 	f := database.DataQueryFactory.NewFilter(context.Background()).Eq("id", "")
-	_, err := s.GetData(context.Background(), f)
+	_, _, err := s.GetData(context.Background(), f)
 	assert.Regexp(t, "FF10115", err)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
@@ -243,7 +253,7 @@ func TestGetDataQueryFail(t *testing.T) {
 func TestGetDataBuildQueryFail(t *testing.T) {
 	s, _ := newMockProvider().init()
 	f := database.DataQueryFactory.NewFilter(context.Background()).Eq("id", map[bool]bool{true: false})
-	_, err := s.GetData(context.Background(), f)
+	_, _, err := s.GetData(context.Background(), f)
 	assert.Regexp(t, "FF10149.*id", err)
 }
 
@@ -251,7 +261,7 @@ func TestGetDataReadMessageFail(t *testing.T) {
 	s, mock := newMockProvider().init()
 	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("only one"))
 	f := database.DataQueryFactory.NewFilter(context.Background()).Eq("id", "")
-	_, err := s.GetData(context.Background(), f)
+	_, _, err := s.GetData(context.Background(), f)
 	assert.Regexp(t, "FF10121", err)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
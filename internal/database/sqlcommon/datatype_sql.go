@@ -159,16 +159,16 @@ func (s *SQLCommon) GetDatatypeByName(ctx context.Context, ns, name, version str
 	return s.getDatatypeEq(ctx, sq.Eq{"namespace": ns, "name": name, "version": version}, fmt.Sprintf("%s:%s", ns, name))
 }
 
-func (s *SQLCommon) GetDatatypes(ctx context.Context, filter database.Filter) (message []*fftypes.Datatype, err error) {
+func (s *SQLCommon) GetDatatypes(ctx context.Context, filter database.Filter) (message []*fftypes.Datatype, res *database.FilterResult, err error) {
 
-	query, err := s.filterSelect(ctx, "", sq.Select(datatypeColumns...).From("datatypes"), filter, datatypeFilterTypeMap)
+	query, fop, fi, err := s.filterSelect(ctx, "", sq.Select(datatypeColumns...).From("datatypes"), filter, datatypeFilterTypeMap)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	rows, err := s.query(ctx, query)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer rows.Close()
 
@@ -176,12 +176,12 @@ func (s *SQLCommon) GetDatatypes(ctx context.Context, filter database.Filter) (m
 	for rows.Next() {
 		datatype, err := s.datatypeResult(ctx, rows)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		datatypes = append(datatypes, datatype)
 	}
 
-	return datatypes, err
+	return datatypes, s.queryRes(ctx, "datatypes", fop, fi), err
 
 }
 
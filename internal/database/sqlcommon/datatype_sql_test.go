@@ -104,7 +104,7 @@ func TestDatatypeE2EWithDB(t *testing.T) {
 		fb.Eq("version", datatypeUpdated.Version),
 		fb.Gt("created", "0"),
 	)
-	datatypes, err := s.GetDatatypes(ctx, filter)
+	datatypes, _, err := s.GetDatatypes(ctx, filter)
 	assert.NoError(t, err)
 	assert.Equal(t, 1, len(datatypes))
 	datatypeReadJson, _ = json.Marshal(datatypes[0])
@@ -121,7 +121,7 @@ func TestDatatypeE2EWithDB(t *testing.T) {
 		fb.Eq("id", datatypeUpdated.ID.String()),
 		fb.Eq("version", v2),
 	)
-	datatypes, err = s.GetDatatypes(ctx, filter)
+	datatypes, _, err = s.GetDatatypes(ctx, filter)
 	assert.NoError(t, err)
 	assert.Equal(t, 1, len(datatypes))
 }
@@ -221,7 +221,7 @@ func TestGetDatatypesQueryFail(t *testing.T) {
 	s, mock := newMockProvider().init()
 	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
 	f := database.DatatypeQueryFactory.NewFilter(context.Background()).Eq("id", "")
-	_, err := s.GetDatatypes(context.Background(), f)
+	_, _, err := s.GetDatatypes(context.Background(), f)
 	assert.Regexp(t, "FF10115", err)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
@@ -229,7 +229,7 @@ func TestGetDatatypesQueryFail(t *testing.T) {
 func TestGetDatatypesBuildQueryFail(t *testing.T) {
 	s, _ := newMockProvider().init()
 	f := database.DatatypeQueryFactory.NewFilter(context.Background()).Eq("id", map[bool]bool{true: false})
-	_, err := s.GetDatatypes(context.Background(), f)
+	_, _, err := s.GetDatatypes(context.Background(), f)
 	assert.Regexp(t, "FF10149.*id", err)
 }
 
@@ -237,7 +237,7 @@ func TestGetDatatypesReadMessageFail(t *testing.T) {
 	s, mock := newMockProvider().init()
 	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("only one"))
 	f := database.DatatypeQueryFactory.NewFilter(context.Background()).Eq("id", "")
-	_, err := s.GetDatatypes(context.Background(), f)
+	_, _, err := s.GetDatatypes(context.Background(), f)
 	assert.Regexp(t, "FF10121", err)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
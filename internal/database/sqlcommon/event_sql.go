@@ -34,6 +34,7 @@ var (
 		"namespace",
 		"ref",
 		"group_hash",
+		"unpinned",
 		"created",
 	}
 	eventFilterTypeMap = map[string]string{
@@ -73,6 +74,7 @@ func (s *SQLCommon) UpsertEvent(ctx context.Context, event *fftypes.Event, allow
 				Set("namespace", event.Namespace).
 				Set("ref", event.Reference).
 				Set("group_hash", event.Group).
+				Set("unpinned", event.Unpinned).
 				Set("created", event.Created).
 				Where(sq.Eq{"id": event.ID}),
 		); err != nil {
@@ -88,6 +90,7 @@ func (s *SQLCommon) UpsertEvent(ctx context.Context, event *fftypes.Event, allow
 					event.Namespace,
 					event.Reference,
 					event.Group,
+					event.Unpinned,
 					event.Created,
 				),
 		)
@@ -112,6 +115,7 @@ func (s *SQLCommon) eventResult(ctx context.Context, row *sql.Rows) (*fftypes.Ev
 		&event.Namespace,
 		&event.Reference,
 		&event.Group,
+		&event.Unpinned,
 		&event.Created,
 		// Must be added to the list of columns in all selects
 		&event.Sequence,
@@ -149,18 +153,18 @@ func (s *SQLCommon) GetEventByID(ctx context.Context, id *fftypes.UUID) (message
 	return event, nil
 }
 
-func (s *SQLCommon) GetEvents(ctx context.Context, filter database.Filter) (message []*fftypes.Event, err error) {
+func (s *SQLCommon) GetEvents(ctx context.Context, filter database.Filter) (message []*fftypes.Event, res *database.FilterResult, err error) {
 
 	cols := append([]string{}, eventColumns...)
 	cols = append(cols, s.provider.SequenceField(""))
-	query, err := s.filterSelect(ctx, "", sq.Select(cols...).From("events"), filter, eventFilterTypeMap)
+	query, fop, fi, err := s.filterSelect(ctx, "", sq.Select(cols...).From("events"), filter, eventFilterTypeMap)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	rows, err := s.query(ctx, query)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer rows.Close()
 
@@ -168,12 +172,12 @@ func (s *SQLCommon) GetEvents(ctx context.Context, filter database.Filter) (mess
 	for rows.Next() {
 		event, err := s.eventResult(ctx, rows)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		events = append(events, event)
 	}
 
-	return events, err
+	return events, s.queryRes(ctx, "events", fop, fi), err
 
 }
 
@@ -67,6 +67,7 @@ func TestEventE2EWithDB(t *testing.T) {
 		Type:      fftypes.EventTypeMessageConfirmed,
 		Reference: fftypes.NewUUID(),
 		Group:     fftypes.NewRandB32(),
+		Unpinned:  true,
 		Created:   fftypes.Now(),
 	}
 	err = s.UpsertEvent(context.Background(), eventUpdated, true)
@@ -86,8 +87,9 @@ func TestEventE2EWithDB(t *testing.T) {
 	filter := fb.And(
 		fb.Eq("id", eventUpdated.ID.String()),
 		fb.Eq("reference", eventUpdated.Reference.String()),
+		fb.Eq("unpinned", true),
 	)
-	events, err := s.GetEvents(ctx, filter)
+	events, _, err := s.GetEvents(ctx, filter)
 	assert.NoError(t, err)
 	assert.Equal(t, 1, len(events))
 	eventReadJson, _ = json.Marshal(events[0])
@@ -98,7 +100,7 @@ func TestEventE2EWithDB(t *testing.T) {
 		fb.Eq("id", eventUpdated.ID.String()),
 		fb.Eq("reference", fftypes.NewUUID().String()),
 	)
-	events, err = s.GetEvents(ctx, filter)
+	events, _, err = s.GetEvents(ctx, filter)
 	assert.NoError(t, err)
 	assert.Equal(t, 0, len(events))
 
@@ -114,7 +116,7 @@ func TestEventE2EWithDB(t *testing.T) {
 		fb.Eq("id", eventUpdated.ID.String()),
 		fb.Eq("reference", newUUID),
 	)
-	events, err = s.GetEvents(ctx, filter)
+	events, _, err = s.GetEvents(ctx, filter)
 	assert.NoError(t, err)
 	assert.Equal(t, 1, len(events))
 
@@ -208,7 +210,7 @@ func TestGetEventsQueryFail(t *testing.T) {
 	s, mock := newMockProvider().init()
 	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
 	f := database.EventQueryFactory.NewFilter(context.Background()).Eq("id", "")
-	_, err := s.GetEvents(context.Background(), f)
+	_, _, err := s.GetEvents(context.Background(), f)
 	assert.Regexp(t, "FF10115", err)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
@@ -216,7 +218,7 @@ func TestGetEventsQueryFail(t *testing.T) {
 func TestGetEventsBuildQueryFail(t *testing.T) {
 	s, _ := newMockProvider().init()
 	f := database.EventQueryFactory.NewFilter(context.Background()).Eq("id", map[bool]bool{true: false})
-	_, err := s.GetEvents(context.Background(), f)
+	_, _, err := s.GetEvents(context.Background(), f)
 	assert.Regexp(t, "FF10149.*id", err)
 }
 
@@ -224,7 +226,7 @@ func TestGettEventsReadMessageFail(t *testing.T) {
 	s, mock := newMockProvider().init()
 	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("only one"))
 	f := database.EventQueryFactory.NewFilter(context.Background()).Eq("id", "")
-	_, err := s.GetEvents(context.Background(), f)
+	_, _, err := s.GetEvents(context.Background(), f)
 	assert.Regexp(t, "FF10121", err)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
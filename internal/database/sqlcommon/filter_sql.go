@@ -23,19 +23,20 @@ import (
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/hyperledger-labs/firefly/internal/i18n"
+	"github.com/hyperledger-labs/firefly/internal/log"
 	"github.com/hyperledger-labs/firefly/pkg/database"
 )
 
-func (s *SQLCommon) filterSelect(ctx context.Context, tableName string, sel sq.SelectBuilder, filter database.Filter, typeMap map[string]string, preconditions ...sq.Sqlizer) (sq.SelectBuilder, error) {
+func (s *SQLCommon) filterSelect(ctx context.Context, tableName string, sel sq.SelectBuilder, filter database.Filter, typeMap map[string]string, preconditions ...sq.Sqlizer) (sq.SelectBuilder, sq.Sqlizer, *database.FilterInfo, error) {
 	fi, err := filter.Finalize()
 	if err != nil {
-		return sel, err
+		return sel, nil, nil, err
 	}
 	if len(fi.Sort) == 0 {
 		fi.Sort = []string{"sequence"}
 		fi.Descending = true
 	}
-	sel, err = s.filterSelectFinalized(ctx, tableName, sel, fi, typeMap, preconditions...)
+	sel, fop, err := s.filterSelectFinalized(ctx, tableName, sel, fi, typeMap, preconditions...)
 	direction := ""
 	if fi.Descending {
 		direction = " DESC"
@@ -53,13 +54,13 @@ func (s *SQLCommon) filterSelect(ctx context.Context, tableName string, sel sq.S
 			sel = sel.Limit(fi.Limit)
 		}
 	}
-	return sel, err
+	return sel, fop, fi, err
 }
 
-func (s *SQLCommon) filterSelectFinalized(ctx context.Context, tableName string, sel sq.SelectBuilder, fi *database.FilterInfo, tm map[string]string, preconditions ...sq.Sqlizer) (sq.SelectBuilder, error) {
+func (s *SQLCommon) filterSelectFinalized(ctx context.Context, tableName string, sel sq.SelectBuilder, fi *database.FilterInfo, tm map[string]string, preconditions ...sq.Sqlizer) (sq.SelectBuilder, sq.Sqlizer, error) {
 	fop, err := s.filterOp(ctx, tableName, fi, tm)
 	if err != nil {
-		return sel, err
+		return sel, nil, err
 	}
 	if len(preconditions) > 0 {
 		and := make(sq.And, len(preconditions)+1)
@@ -69,7 +70,35 @@ func (s *SQLCommon) filterSelectFinalized(ctx context.Context, tableName string,
 		and[len(preconditions)] = fop
 		fop = and
 	}
-	return sel.Where(fop), nil
+	return sel.Where(fop), fop, nil
+}
+
+// queryRes performs a count query with the same conditions as the main query (without sort or pagination),
+// if a count was requested on the filter. The count is best-effort, so failures are logged but not returned.
+func (s *SQLCommon) queryRes(ctx context.Context, tableName string, fop sq.Sqlizer, fi *database.FilterInfo, joins ...string) *database.FilterResult {
+	fr := &database.FilterResult{}
+	if !fi.Count {
+		return fr
+	}
+	countQuery := sq.Select("count(*)").From(tableName)
+	for _, join := range joins {
+		countQuery = countQuery.LeftJoin(join)
+	}
+	rows, err := s.query(ctx, countQuery.Where(fop))
+	if err != nil {
+		log.L(ctx).Warnf("Count query failed on %s: %s", tableName, err)
+		return fr
+	}
+	defer rows.Close()
+	var count int64
+	if rows.Next() {
+		if err = rows.Scan(&count); err != nil {
+			log.L(ctx).Warnf("Count query failed on %s: %s", tableName, err)
+			return fr
+		}
+	}
+	fr.TotalCount = &count
+	return fr
 }
 
 func (s *SQLCommon) buildUpdate(sel sq.UpdateBuilder, update database.Update, typeMap map[string]string) (sq.UpdateBuilder, error) {
@@ -19,8 +19,10 @@ package sqlcommon
 import (
 	"context"
 	"database/sql/driver"
+	"fmt"
 	"testing"
 
+	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/Masterminds/squirrel"
 	"github.com/hyperledger-labs/firefly/pkg/database"
 	"github.com/hyperledger-labs/firefly/pkg/fftypes"
@@ -45,7 +47,7 @@ func TestSQLQueryFactory(t *testing.T) {
 		Descending()
 
 	sel := squirrel.Select("*").From("mytable")
-	sel, err := s.filterSelect(context.Background(), "", sel, f, map[string]string{
+	sel, _, _, err := s.filterSelect(context.Background(), "", sel, f, map[string]string{
 		"namespace": "ns",
 	})
 	assert.NoError(t, err)
@@ -82,7 +84,7 @@ func TestSQLQueryFactoryExtraOps(t *testing.T) {
 	)
 
 	sel := squirrel.Select("*").From("mytable AS mt")
-	sel, err := s.filterSelect(context.Background(), "mt", sel, f, nil)
+	sel, _, _, err := s.filterSelect(context.Background(), "mt", sel, f, nil)
 	assert.NoError(t, err)
 
 	sqlFilter, _, err := sel.ToSql()
@@ -94,7 +96,7 @@ func TestSQLQueryFactoryFinalizeFail(t *testing.T) {
 	s, _ := newMockProvider().init()
 	fb := database.MessageQueryFactory.NewFilter(context.Background())
 	sel := squirrel.Select("*").From("mytable")
-	_, err := s.filterSelect(context.Background(), "ns", sel, fb.Eq("namespace", map[bool]bool{true: false}), nil)
+	_, _, _, err := s.filterSelect(context.Background(), "ns", sel, fb.Eq("namespace", map[bool]bool{true: false}), nil)
 	assert.Regexp(t, "FF10149.*namespace", err)
 }
 
@@ -102,7 +104,7 @@ func TestSQLQueryFactoryBadOp(t *testing.T) {
 
 	s, _ := newMockProvider().init()
 	sel := squirrel.Select("*").From("mytable")
-	_, err := s.filterSelectFinalized(context.Background(), "", sel, &database.FilterInfo{
+	_, _, err := s.filterSelectFinalized(context.Background(), "", sel, &database.FilterInfo{
 		Op: database.FilterOp("wrong"),
 	}, nil)
 	assert.Regexp(t, "FF10150.*wrong", err)
@@ -112,7 +114,7 @@ func TestSQLQueryFactoryBadOpInOr(t *testing.T) {
 
 	s, _ := newMockProvider().init()
 	sel := squirrel.Select("*").From("mytable")
-	_, err := s.filterSelectFinalized(context.Background(), "", sel, &database.FilterInfo{
+	_, _, err := s.filterSelectFinalized(context.Background(), "", sel, &database.FilterInfo{
 		Op: database.FilterOpOr,
 		Children: []*database.FilterInfo{
 			{Op: database.FilterOp("wrong")},
@@ -125,7 +127,7 @@ func TestSQLQueryFactoryBadOpInAnd(t *testing.T) {
 
 	s, _ := newMockProvider().init()
 	sel := squirrel.Select("*").From("mytable")
-	_, err := s.filterSelectFinalized(context.Background(), "", sel, &database.FilterInfo{
+	_, _, err := s.filterSelectFinalized(context.Background(), "", sel, &database.FilterInfo{
 		Op: database.FilterOpAnd,
 		Children: []*database.FilterInfo{
 			{Op: database.FilterOp("wrong")},
@@ -133,3 +135,33 @@ func TestSQLQueryFactoryBadOpInAnd(t *testing.T) {
 	}, nil)
 	assert.Regexp(t, "FF10150.*wrong", err)
 }
+
+func TestQueryResNoCount(t *testing.T) {
+	s, _ := newMockProvider().init()
+	res := s.queryRes(context.Background(), "messages", squirrel.Eq{"id": "1"}, &database.FilterInfo{})
+	assert.Nil(t, res.TotalCount)
+}
+
+func TestQueryResCount(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT count.*").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(10))
+	res := s.queryRes(context.Background(), "messages", squirrel.Eq{"id": "1"}, &database.FilterInfo{Count: true})
+	assert.Equal(t, int64(10), *res.TotalCount)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestQueryResCountQueryFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT count.*").WillReturnError(fmt.Errorf("pop"))
+	res := s.queryRes(context.Background(), "messages", squirrel.Eq{"id": "1"}, &database.FilterInfo{Count: true})
+	assert.Nil(t, res.TotalCount)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestQueryResCountScanFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT count.*").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow("not a number"))
+	res := s.queryRes(context.Background(), "messages", squirrel.Eq{"id": "1"}, &database.FilterInfo{Count: true})
+	assert.Nil(t, res.TotalCount)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
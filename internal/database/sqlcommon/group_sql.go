@@ -35,6 +35,9 @@ var (
 		"ledger",
 		"hash",
 		"created",
+		"predecessor",
+		"successor",
+		"retired",
 	}
 	groupFilterTypeMap = map[string]string{
 		"message": "message_id",
@@ -74,6 +77,7 @@ func (s *SQLCommon) UpsertGroup(ctx context.Context, group *fftypes.Group, allow
 				Set("ledger", group.Ledger).
 				Set("hash", group.Hash).
 				Set("created", group.Created).
+				Set("predecessor", group.Predecessor).
 				Where(sq.Eq{"hash": group.Hash}),
 		); err != nil {
 			return err
@@ -89,6 +93,9 @@ func (s *SQLCommon) UpsertGroup(ctx context.Context, group *fftypes.Group, allow
 					group.Ledger,
 					group.Hash,
 					group.Created,
+					group.Predecessor,
+					group.Successor,
+					group.Retired,
 				),
 		)
 		if err != nil {
@@ -205,6 +212,9 @@ func (s *SQLCommon) groupResult(ctx context.Context, row *sql.Rows) (*fftypes.Gr
 		&group.Ledger,
 		&group.Hash,
 		&group.Created,
+		&group.Predecessor,
+		&group.Successor,
+		&group.Retired,
 	)
 	if err != nil {
 		return nil, i18n.WrapError(ctx, err, i18n.MsgDBReadErr, "groups")
@@ -268,12 +278,13 @@ func (s *SQLCommon) getGroupsQuery(ctx context.Context, query sq.SelectBuilder)
 	return groups, err
 }
 
-func (s *SQLCommon) GetGroups(ctx context.Context, filter database.Filter) (group []*fftypes.Group, err error) {
-	query, err := s.filterSelect(ctx, "", sq.Select(groupColumns...).From("groups"), filter, groupFilterTypeMap)
+func (s *SQLCommon) GetGroups(ctx context.Context, filter database.Filter) (group []*fftypes.Group, res *database.FilterResult, err error) {
+	query, fop, fi, err := s.filterSelect(ctx, "", sq.Select(groupColumns...).From("groups"), filter, groupFilterTypeMap)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	return s.getGroupsQuery(ctx, query)
+	group, err = s.getGroupsQuery(ctx, query)
+	return group, s.queryRes(ctx, "groups", fop, fi), err
 }
 
 func (s *SQLCommon) UpdateGroup(ctx context.Context, hash *fftypes.Bytes32, update database.Update) (err error) {
@@ -47,6 +47,7 @@ func TestUpsertGroupE2EWithDB(t *testing.T) {
 				{Identity: "0x12345", Node: fftypes.NewUUID()},
 				{Identity: "0x23456", Node: fftypes.NewUUID()},
 			},
+			Predecessor: fftypes.NewRandB32(),
 		},
 		Hash:    groupHash,
 		Created: fftypes.Now(),
@@ -71,7 +72,8 @@ func TestUpsertGroupE2EWithDB(t *testing.T) {
 				{Identity: "0x12345", Node: fftypes.NewUUID()},
 				group.Members[0],
 			},
-			Ledger: fftypes.NewUUID(),
+			Ledger:      fftypes.NewUUID(),
+			Predecessor: group.Predecessor,
 		},
 		Created: fftypes.Now(),
 		Message: fftypes.NewUUID(),
@@ -95,9 +97,10 @@ func TestUpsertGroupE2EWithDB(t *testing.T) {
 		fb.Eq("namespace", groupUpdated.Namespace),
 		fb.Eq("message", groupUpdated.Message),
 		fb.Eq("ledger", groupUpdated.Ledger),
+		fb.Eq("predecessor", groupUpdated.Predecessor),
 		fb.Gt("created", "0"),
 	)
-	groups, err := s.GetGroups(ctx, filter)
+	groups, _, err := s.GetGroups(ctx, filter)
 	assert.NoError(t, err)
 	assert.Equal(t, 1, len(groups))
 	groupReadJson, _ = json.Marshal(groups[0])
@@ -108,13 +111,35 @@ func TestUpsertGroupE2EWithDB(t *testing.T) {
 		fb.Eq("hash", groupUpdated.Hash.String()),
 		fb.Eq("created", "0"),
 	)
-	groups, err = s.GetGroups(ctx, filter)
+	groups, _, err = s.GetGroups(ctx, filter)
 	assert.NoError(t, err)
 	assert.Equal(t, 0, len(groups))
 
+	// Retire the group, in favor of a successor
+	successor := fftypes.NewRandB32()
+	retired := fftypes.Now()
+	up := database.GroupQueryFactory.NewUpdate(ctx).
+		Set("successor", successor).
+		Set("retired", retired)
+	err = s.UpdateGroup(ctx, group.Hash, up)
+	assert.NoError(t, err)
+
+	// Upserting the group again does not reset its successor
+	err = s.UpsertGroup(context.Background(), groupUpdated, true)
+	assert.NoError(t, err)
+	filter = fb.And(
+		fb.Eq("successor", successor),
+		fb.Eq("retired", retired),
+	)
+	groups, _, err = s.GetGroups(ctx, filter)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(groups))
+	assert.Equal(t, *successor, *groups[0].Successor)
+	assert.Equal(t, retired.UnixNano(), groups[0].Retired.UnixNano())
+
 	// Update (testing what's possible at the DB layer)
 	newHash := fftypes.NewRandB32()
-	up := database.GroupQueryFactory.NewUpdate(ctx).
+	up = database.GroupQueryFactory.NewUpdate(ctx).
 		Set("hash", newHash)
 	err = s.UpdateGroup(ctx, group.Hash, up)
 	assert.NoError(t, err)
@@ -123,7 +148,7 @@ func TestUpsertGroupE2EWithDB(t *testing.T) {
 	filter = fb.And(
 		fb.Eq("hash", newHash),
 	)
-	groups, err = s.GetGroups(ctx, filter)
+	groups, _, err = s.GetGroups(ctx, filter)
 	assert.NoError(t, err)
 	assert.Equal(t, 1, len(groups))
 
@@ -318,7 +343,7 @@ func TestGetGroupByIDLoadMembersFail(t *testing.T) {
 	s, mock := newMockProvider().init()
 	groupID := fftypes.NewRandB32()
 	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows(groupColumns).
-		AddRow(nil, "ns1", "name1", fftypes.NewUUID(), fftypes.NewRandB32(), fftypes.Now()))
+		AddRow(nil, "ns1", "name1", fftypes.NewUUID(), fftypes.NewRandB32(), fftypes.Now(), nil, nil, nil))
 	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
 	_, err := s.GetGroupByHash(context.Background(), groupID)
 	assert.Regexp(t, "FF10115", err)
@@ -328,7 +353,7 @@ func TestGetGroupByIDLoadMembersFail(t *testing.T) {
 func TestGetGroupsBuildQueryFail(t *testing.T) {
 	s, _ := newMockProvider().init()
 	f := database.GroupQueryFactory.NewFilter(context.Background()).Eq("hash", map[bool]bool{true: false})
-	_, err := s.GetGroups(context.Background(), f)
+	_, _, err := s.GetGroups(context.Background(), f)
 	assert.Regexp(t, "FF10149.*hash", err)
 }
 
@@ -336,7 +361,7 @@ func TestGetGroupsQueryFail(t *testing.T) {
 	s, mock := newMockProvider().init()
 	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
 	f := database.GroupQueryFactory.NewFilter(context.Background()).Eq("hash", "")
-	_, err := s.GetGroups(context.Background(), f)
+	_, _, err := s.GetGroups(context.Background(), f)
 	assert.Regexp(t, "FF10115", err)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
@@ -345,7 +370,7 @@ func TestGetGroupsReadGroupFail(t *testing.T) {
 	s, mock := newMockProvider().init()
 	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"hash"}).AddRow("only one"))
 	f := database.GroupQueryFactory.NewFilter(context.Background()).Eq("hash", "")
-	_, err := s.GetGroups(context.Background(), f)
+	_, _, err := s.GetGroups(context.Background(), f)
 	assert.Regexp(t, "FF10121", err)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
@@ -353,10 +378,10 @@ func TestGetGroupsReadGroupFail(t *testing.T) {
 func TestGetGroupsLoadMembersFail(t *testing.T) {
 	s, mock := newMockProvider().init()
 	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows(groupColumns).
-		AddRow(nil, "ns1", "group1", fftypes.NewUUID(), fftypes.NewRandB32(), fftypes.Now()))
+		AddRow(nil, "ns1", "group1", fftypes.NewUUID(), fftypes.NewRandB32(), fftypes.Now(), nil, nil, nil))
 	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
 	f := database.GroupQueryFactory.NewFilter(context.Background()).Gt("created", "0")
-	_, err := s.GetGroups(context.Background(), f)
+	_, _, err := s.GetGroups(context.Background(), f)
 	assert.Regexp(t, "FF10115", err)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
@@ -381,7 +406,7 @@ func TestGroupsUpdateBuildFilterFail(t *testing.T) {
 	s, mock := newMockProvider().init()
 	mock.ExpectBegin()
 	f := database.GroupQueryFactory.NewFilter(context.Background()).Eq("hash", map[bool]bool{true: false})
-	u := database.GroupQueryFactory.NewUpdate(context.Background()).Set("description", "my desc")
+	u := database.GroupQueryFactory.NewUpdate(context.Background()).Set("name", "my desc")
 	err := s.UpdateGroups(context.Background(), f, u)
 	assert.Regexp(t, "FF10149.*hash", err)
 }
@@ -391,7 +416,7 @@ func TestGroupUpdateFail(t *testing.T) {
 	mock.ExpectBegin()
 	mock.ExpectExec("UPDATE .*").WillReturnError(fmt.Errorf("pop"))
 	mock.ExpectRollback()
-	u := database.GroupQueryFactory.NewUpdate(context.Background()).Set("description", fftypes.NewUUID())
+	u := database.GroupQueryFactory.NewUpdate(context.Background()).Set("name", fftypes.NewUUID())
 	err := s.UpdateGroup(context.Background(), fftypes.NewRandB32(), u)
 	assert.Regexp(t, "FF10117", err)
 }
@@ -0,0 +1,155 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlcommon
+
+import (
+	"context"
+	"database/sql"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/hyperledger-labs/firefly/internal/i18n"
+	"github.com/hyperledger-labs/firefly/internal/log"
+	"github.com/hyperledger-labs/firefly/pkg/fftypes"
+)
+
+var (
+	leaseColumns = []string{
+		"name",
+		"holder",
+		"expires",
+		"epoch",
+	}
+)
+
+func (s *SQLCommon) AcquireLease(ctx context.Context, lease *fftypes.Lease) (acquired bool, err error) {
+	ctx, tx, autoCommit, err := s.beginOrUseTx(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer s.rollbackTx(ctx, tx, autoCommit)
+
+	existing, err := s.getLease(ctx, tx, lease.Name)
+	if err != nil {
+		return false, err
+	}
+
+	if existing == nil {
+		lease.Epoch = 1
+		if _, err = s.insertTx(ctx, tx,
+			sq.Insert("leases").
+				Columns(leaseColumns...).
+				Values(
+					lease.Name,
+					lease.Holder,
+					lease.Expires,
+					lease.Epoch,
+				),
+		); err != nil {
+			return false, err
+		}
+		return true, s.commitTx(ctx, tx, autoCommit)
+	}
+
+	// The conditions are evaluated by the database against the latest committed row, so only one
+	// of two instances racing to take over an expired lease can succeed
+	query := sq.Update("leases").
+		Set("holder", lease.Holder).
+		Set("expires", lease.Expires)
+	if existing.Holder == lease.Holder {
+		// A renewal keeps the epoch, and fails if another instance has taken over since we read the lease
+		lease.Epoch = existing.Epoch
+		query = query.Where(sq.Eq{"name": lease.Name, "holder": lease.Holder, "epoch": existing.Epoch})
+	} else {
+		// A takeover is only possible once the lease has expired, and moves to a new epoch to fence out the writes
+		// of the previous holder
+		lease.Epoch = existing.Epoch + 1
+		query = query.
+			Set("epoch", lease.Epoch).
+			Where(sq.And{
+				sq.Eq{"name": lease.Name, "epoch": existing.Epoch},
+				sq.Lt{"expires": fftypes.Now()},
+			})
+	}
+	updated, err := s.updateTxRows(ctx, tx, query)
+	if err != nil {
+		return false, err
+	}
+
+	if err = s.commitTx(ctx, tx, autoCommit); err != nil {
+		return false, err
+	}
+	return updated > 0, nil
+}
+
+// checkLeaseFence is called at the start of each transaction made under an armed lease fence. The select holds a shared
+// lock on the lease row until the transaction completes, so no other instance can take the lease over part way through it.
+func (s *SQLCommon) checkLeaseFence(ctx context.Context, tx *txWrapper, lease *fftypes.Lease) error {
+	rows, err := s.queryTx(ctx, tx,
+		s.provider.SelectForShare(
+			sq.Select("name").
+				From("leases").
+				Where(sq.Eq{"name": lease.Name, "holder": lease.Holder, "epoch": lease.Epoch}),
+		),
+	)
+	if err != nil {
+		return err
+	}
+	held := rows.Next()
+	rows.Close()
+	if !held {
+		return i18n.NewError(ctx, i18n.MsgLeaseLost, lease.Name, lease.Epoch)
+	}
+	return nil
+}
+
+func (s *SQLCommon) leaseResult(ctx context.Context, row *sql.Rows) (*fftypes.Lease, error) {
+	lease := fftypes.Lease{}
+	err := row.Scan(
+		&lease.Name,
+		&lease.Holder,
+		&lease.Expires,
+		&lease.Epoch,
+	)
+	if err != nil {
+		return nil, i18n.WrapError(ctx, err, i18n.MsgDBReadErr, "leases")
+	}
+	return &lease, nil
+}
+
+func (s *SQLCommon) GetLease(ctx context.Context, name string) (lease *fftypes.Lease, err error) {
+	return s.getLease(ctx, nil, name)
+}
+
+func (s *SQLCommon) getLease(ctx context.Context, tx *txWrapper, name string) (lease *fftypes.Lease, err error) {
+
+	rows, err := s.queryTx(ctx, tx,
+		sq.Select(leaseColumns...).
+			From("leases").
+			Where(sq.Eq{"name": name}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		log.L(ctx).Debugf("Lease '%s' not found", name)
+		return nil, nil
+	}
+
+	return s.leaseResult(ctx, rows)
+}
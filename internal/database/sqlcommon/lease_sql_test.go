@@ -0,0 +1,212 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlcommon
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/hyperledger-labs/firefly/internal/log"
+	"github.com/hyperledger-labs/firefly/pkg/database"
+	"github.com/hyperledger-labs/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLeasesE2EWithDB(t *testing.T) {
+	log.SetLevel("trace")
+
+	s := newQLTestProvider(t)
+	defer s.Close()
+	ctx := context.Background()
+
+	// First claim creates the lease
+	expires := fftypes.FFTime(time.Now().Add(1 * time.Hour))
+	lease := &fftypes.Lease{
+		Name:    "leader",
+		Holder:  "instance1",
+		Expires: &expires,
+	}
+	acquired, err := s.AcquireLease(ctx, lease)
+	assert.NoError(t, err)
+	assert.True(t, acquired)
+	assert.Equal(t, int64(1), lease.Epoch)
+
+	// Check we get the exact same lease back
+	leaseRead, err := s.GetLease(ctx, "leader")
+	assert.NoError(t, err)
+	leaseJson, _ := json.Marshal(&lease)
+	leaseReadJson, _ := json.Marshal(&leaseRead)
+	assert.Equal(t, string(leaseJson), string(leaseReadJson))
+
+	// The holder can renew it, in the same epoch
+	expires = fftypes.FFTime(time.Now().Add(2 * time.Hour))
+	acquired, err = s.AcquireLease(ctx, lease)
+	assert.NoError(t, err)
+	assert.True(t, acquired)
+	assert.Equal(t, int64(1), lease.Epoch)
+
+	// Writes under a fence armed with the lease are allowed
+	fence := &database.LeaseFence{}
+	fence.Arm(&fftypes.Lease{Name: "leader", Holder: "instance1", Epoch: 1})
+	fencedCtx := database.WithLeaseFence(ctx, fence)
+	err = s.RunAsGroup(fencedCtx, func(ctx context.Context) error {
+		return s.UpsertConfigRecord(ctx, &fftypes.ConfigRecord{Key: "key1", Value: fftypes.Byteable(`"value1"`)}, true)
+	})
+	assert.NoError(t, err)
+
+	// Another instance cannot take it while it is current
+	expired := fftypes.FFTime(time.Now().Add(-1 * time.Hour))
+	lease2 := &fftypes.Lease{
+		Name:    "leader",
+		Holder:  "instance2",
+		Expires: &expired,
+	}
+	acquired, err = s.AcquireLease(ctx, lease2)
+	assert.NoError(t, err)
+	assert.False(t, acquired)
+	leaseRead, err = s.GetLease(ctx, "leader")
+	assert.NoError(t, err)
+	assert.Equal(t, "instance1", leaseRead.Holder)
+
+	// Take it, and leave it expired
+	lease.Expires = &expired
+	acquired, err = s.AcquireLease(ctx, lease)
+	assert.NoError(t, err)
+	assert.True(t, acquired)
+
+	// Now the other instance can take it over, in a new epoch
+	acquired, err = s.AcquireLease(ctx, lease2)
+	assert.NoError(t, err)
+	assert.True(t, acquired)
+	assert.Equal(t, int64(2), lease2.Epoch)
+	leaseRead, err = s.GetLease(ctx, "leader")
+	assert.NoError(t, err)
+	assert.Equal(t, "instance2", leaseRead.Holder)
+	assert.Equal(t, int64(2), leaseRead.Epoch)
+
+	// Writes under the fence of the previous holder are now rejected
+	err = s.RunAsGroup(fencedCtx, func(ctx context.Context) error {
+		return s.UpsertConfigRecord(ctx, &fftypes.ConfigRecord{Key: "key1", Value: fftypes.Byteable(`"value2"`)}, true)
+	})
+	assert.Regexp(t, "FF10299", err)
+	err = s.UpsertConfigRecord(fencedCtx, &fftypes.ConfigRecord{Key: "key1", Value: fftypes.Byteable(`"value2"`)}, true)
+	assert.Regexp(t, "FF10299", err)
+	record, err := s.GetConfigRecord(ctx, "key1")
+	assert.NoError(t, err)
+	assert.Equal(t, `"value1"`, string(record.Value))
+
+	// Once the new holder renews, the previous holder cannot take it back
+	lease2.Expires = &expires
+	acquired, err = s.AcquireLease(ctx, lease2)
+	assert.NoError(t, err)
+	assert.True(t, acquired)
+	assert.Equal(t, int64(2), lease2.Epoch)
+	acquired, err = s.AcquireLease(ctx, lease)
+	assert.NoError(t, err)
+	assert.False(t, acquired)
+
+	// Check a missing lease
+	leaseRead, err = s.GetLease(ctx, "missing")
+	assert.NoError(t, err)
+	assert.Nil(t, leaseRead)
+}
+
+func TestAcquireLeaseFailBegin(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin().WillReturnError(fmt.Errorf("pop"))
+	_, err := s.AcquireLease(context.Background(), &fftypes.Lease{})
+	assert.Regexp(t, "FF10114", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAcquireLeaseFailSelect(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
+	mock.ExpectRollback()
+	_, err := s.AcquireLease(context.Background(), &fftypes.Lease{})
+	assert.Regexp(t, "FF10115", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAcquireLeaseFailInsert(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows(leaseColumns))
+	mock.ExpectExec("INSERT .*").WillReturnError(fmt.Errorf("pop"))
+	mock.ExpectRollback()
+	_, err := s.AcquireLease(context.Background(), &fftypes.Lease{})
+	assert.Regexp(t, "FF10116", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAcquireLeaseFailUpdate(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows(leaseColumns).AddRow("leader", "instance1", 12345, 1))
+	mock.ExpectExec("UPDATE .*").WillReturnError(fmt.Errorf("pop"))
+	mock.ExpectRollback()
+	_, err := s.AcquireLease(context.Background(), &fftypes.Lease{Name: "leader", Holder: "instance2"})
+	assert.Regexp(t, "FF10117", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAcquireLeaseFailCommit(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows(leaseColumns).AddRow("leader", "instance1", 12345, 1))
+	mock.ExpectExec("UPDATE .*").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit().WillReturnError(fmt.Errorf("pop"))
+	acquired, err := s.AcquireLease(context.Background(), &fftypes.Lease{Name: "leader", Holder: "instance1"})
+	assert.Regexp(t, "FF10119", err)
+	assert.False(t, acquired)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLeaseFenceCheckFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
+	mock.ExpectRollback()
+	fence := &database.LeaseFence{}
+	fence.Arm(&fftypes.Lease{Name: "leader", Holder: "instance1", Epoch: 1})
+	err := s.RunAsGroup(database.WithLeaseFence(context.Background(), fence), func(ctx context.Context) error {
+		return nil
+	})
+	assert.Regexp(t, "FF10115", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetLeaseQueryFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
+	_, err := s.GetLease(context.Background(), "leader")
+	assert.Regexp(t, "FF10115", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetLeaseReadMessageFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("only one"))
+	_, err := s.GetLease(context.Background(), "leader")
+	assert.Regexp(t, "FF10121", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
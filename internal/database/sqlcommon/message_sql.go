@@ -41,6 +41,7 @@ var (
 		"group_hash",
 		"datahash",
 		"hash",
+		"signature",
 		"pins",
 		"confirmed",
 		"tx_type",
@@ -111,6 +112,7 @@ func (s *SQLCommon) upsertMessageCommon(ctx context.Context, message *fftypes.Me
 				Set("group_hash", message.Header.Group).
 				Set("datahash", message.Header.DataHash).
 				Set("hash", message.Hash).
+				Set("signature", message.Signature).
 				Set("pins", message.Pins).
 				Set("confirmed", message.Confirmed).
 				Set("tx_type", message.Header.TxType).
@@ -136,6 +138,7 @@ func (s *SQLCommon) upsertMessageCommon(ctx context.Context, message *fftypes.Me
 					message.Header.Group,
 					message.Header.DataHash,
 					message.Hash,
+					message.Signature,
 					message.Pins,
 					message.Confirmed,
 					message.Header.TxType,
@@ -276,6 +279,7 @@ func (s *SQLCommon) msgResult(ctx context.Context, row *sql.Rows) (*fftypes.Mess
 		&msg.Header.Group,
 		&msg.Header.DataHash,
 		&msg.Hash,
+		&msg.Signature,
 		&msg.Pins,
 		&msg.Confirmed,
 		&msg.Header.TxType,
@@ -348,34 +352,36 @@ func (s *SQLCommon) getMessagesQuery(ctx context.Context, query sq.SelectBuilder
 	return msgs, err
 }
 
-func (s *SQLCommon) GetMessages(ctx context.Context, filter database.Filter) (message []*fftypes.Message, err error) {
+func (s *SQLCommon) GetMessages(ctx context.Context, filter database.Filter) (message []*fftypes.Message, res *database.FilterResult, err error) {
 	cols := append([]string{}, msgColumns...)
 	cols = append(cols, s.provider.SequenceField(""))
-	query, err := s.filterSelect(ctx, "", sq.Select(cols...).From("messages"), filter, msgFilterTypeMap)
+	query, fop, fi, err := s.filterSelect(ctx, "", sq.Select(cols...).From("messages"), filter, msgFilterTypeMap)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	return s.getMessagesQuery(ctx, query)
+	message, err = s.getMessagesQuery(ctx, query)
+	return message, s.queryRes(ctx, "messages", fop, fi), err
 }
 
-func (s *SQLCommon) GetMessagesForData(ctx context.Context, dataID *fftypes.UUID, filter database.Filter) (message []*fftypes.Message, err error) {
+func (s *SQLCommon) GetMessagesForData(ctx context.Context, dataID *fftypes.UUID, filter database.Filter) (message []*fftypes.Message, res *database.FilterResult, err error) {
 	cols := make([]string, len(msgColumns)+1)
 	for i, col := range msgColumns {
 		cols[i] = fmt.Sprintf("m.%s", col)
 	}
 	cols[len(msgColumns)] = s.provider.SequenceField("m")
-	query, err := s.filterSelect(ctx, "m", sq.Select(cols...).From("messages_data AS md"), filter, msgFilterTypeMap,
+	query, fop, fi, err := s.filterSelect(ctx, "m", sq.Select(cols...).From("messages_data AS md"), filter, msgFilterTypeMap,
 		sq.Eq{"md.data_id": dataID})
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	query = query.LeftJoin("messages AS m ON m.id = md.message_id")
-	return s.getMessagesQuery(ctx, query)
+	message, err = s.getMessagesQuery(ctx, query)
+	return message, s.queryRes(ctx, "messages_data AS md", fop, fi, "messages AS m ON m.id = md.message_id"), err
 }
 
 func (s *SQLCommon) GetMessageRefs(ctx context.Context, filter database.Filter) ([]*fftypes.MessageRef, error) {
-	query, err := s.filterSelect(ctx, "", sq.Select("id", s.provider.SequenceField(""), "hash").From("messages"), filter, msgFilterTypeMap)
+	query, _, _, err := s.filterSelect(ctx, "", sq.Select("id", s.provider.SequenceField(""), "hash").From("messages"), filter, msgFilterTypeMap)
 	if err != nil {
 		return nil, err
 	}
@@ -102,6 +102,7 @@ func TestUpsertE2EWithDB(t *testing.T) {
 			TxType:    fftypes.TransactionTypeBatchPin,
 		},
 		Hash:      fftypes.NewRandB32(),
+		Signature: "c2lnbmF0dXJl",
 		Pins:      []string{fftypes.NewRandB32().String(), fftypes.NewRandB32().String()},
 		Confirmed: fftypes.Now(),
 		BatchID:   bid,
@@ -144,9 +145,10 @@ func TestUpsertE2EWithDB(t *testing.T) {
 		fb.Gt("created", "0"),
 		fb.Gt("confirmed", "0"),
 	)
-	msgs, err := s.GetMessages(ctx, filter)
+	msgs, res, err := s.GetMessages(ctx, filter.Count(true))
 	assert.NoError(t, err)
 	assert.Equal(t, 1, len(msgs))
+	assert.Equal(t, int64(1), *res.TotalCount)
 	msgReadJson, _ = json.Marshal(msgs[0])
 	assert.Equal(t, string(msgJson), string(msgReadJson))
 
@@ -159,9 +161,10 @@ func TestUpsertE2EWithDB(t *testing.T) {
 	assert.Equal(t, msgUpdated.Sequence, msgRefs[0].Sequence)
 
 	// Check we can get it with a filter on only mesasges with a particular data ref
-	msgs, err = s.GetMessagesForData(ctx, dataID3, filter)
+	msgs, res, err = s.GetMessagesForData(ctx, dataID3, filter)
 	assert.NoError(t, err)
 	assert.Equal(t, 1, len(msgs))
+	assert.Equal(t, int64(1), *res.TotalCount)
 	msgReadJson, _ = json.Marshal(msgs[0])
 	assert.Equal(t, string(msgJson), string(msgReadJson))
 
@@ -170,7 +173,7 @@ func TestUpsertE2EWithDB(t *testing.T) {
 		fb.Eq("id", msgUpdated.Header.ID.String()),
 		fb.Eq("created", "0"),
 	)
-	msgs, err = s.GetMessages(ctx, filter)
+	msgs, _, err = s.GetMessages(ctx, filter)
 	assert.NoError(t, err)
 	assert.Equal(t, 0, len(msgs))
 
@@ -188,7 +191,7 @@ func TestUpsertE2EWithDB(t *testing.T) {
 		fb.Eq("id", msgUpdated.Header.ID.String()),
 		fb.Eq("group", gid2),
 	)
-	msgs, err = s.GetMessages(ctx, filter)
+	msgs, _, err = s.GetMessages(ctx, filter)
 	assert.NoError(t, err)
 	assert.Equal(t, 1, len(msgs))
 	assert.Equal(t, *bid2, *msgs[0].BatchID)
@@ -391,7 +394,7 @@ func TestGetMessageByIDLoadRefsFail(t *testing.T) {
 	cols := append([]string{}, msgColumns...)
 	cols = append(cols, "id()")
 	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows(cols).
-		AddRow(msgID.String(), nil, fftypes.MessageTypeBroadcast, "0x12345", 0, "ns1", "t1", "c1", nil, b32.String(), b32.String(), b32.String(), 0, "pin", nil, false, 0))
+		AddRow(msgID.String(), nil, fftypes.MessageTypeBroadcast, "0x12345", 0, "ns1", "t1", "c1", nil, b32.String(), b32.String(), "", b32.String(), 0, "pin", nil, false, 0))
 	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
 	_, err := s.GetMessageByID(context.Background(), msgID)
 	assert.Regexp(t, "FF10115", err)
@@ -401,7 +404,7 @@ func TestGetMessageByIDLoadRefsFail(t *testing.T) {
 func TestGetMessagesBuildQueryFail(t *testing.T) {
 	s, _ := newMockProvider().init()
 	f := database.MessageQueryFactory.NewFilter(context.Background()).Eq("id", map[bool]bool{true: false})
-	_, err := s.GetMessages(context.Background(), f)
+	_, _, err := s.GetMessages(context.Background(), f)
 	assert.Regexp(t, "FF10149.*id", err)
 }
 
@@ -409,7 +412,7 @@ func TestGetMessagesQueryFail(t *testing.T) {
 	s, mock := newMockProvider().init()
 	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
 	f := database.MessageQueryFactory.NewFilter(context.Background()).Eq("id", "")
-	_, err := s.GetMessages(context.Background(), f)
+	_, _, err := s.GetMessages(context.Background(), f)
 	assert.Regexp(t, "FF10115", err)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
@@ -417,7 +420,7 @@ func TestGetMessagesQueryFail(t *testing.T) {
 func TestGetMessagesForDataBadQuery(t *testing.T) {
 	s, mock := newMockProvider().init()
 	f := database.MessageQueryFactory.NewFilter(context.Background()).Eq("!wrong", "")
-	_, err := s.GetMessagesForData(context.Background(), fftypes.NewUUID(), f)
+	_, _, err := s.GetMessagesForData(context.Background(), fftypes.NewUUID(), f)
 	assert.Regexp(t, "FF10148", err)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
@@ -426,7 +429,7 @@ func TestGetMessagesReadMessageFail(t *testing.T) {
 	s, mock := newMockProvider().init()
 	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("only one"))
 	f := database.MessageQueryFactory.NewFilter(context.Background()).Eq("id", "")
-	_, err := s.GetMessages(context.Background(), f)
+	_, _, err := s.GetMessages(context.Background(), f)
 	assert.Regexp(t, "FF10121", err)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
@@ -438,10 +441,10 @@ func TestGetMessagesLoadRefsFail(t *testing.T) {
 	cols := append([]string{}, msgColumns...)
 	cols = append(cols, "id()")
 	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows(cols).
-		AddRow(msgID.String(), nil, fftypes.MessageTypeBroadcast, "0x12345", 0, "ns1", "t1", "c1", nil, b32.String(), b32.String(), b32.String(), 0, "pin", nil, false, 0))
+		AddRow(msgID.String(), nil, fftypes.MessageTypeBroadcast, "0x12345", 0, "ns1", "t1", "c1", nil, b32.String(), b32.String(), "", b32.String(), 0, "pin", nil, false, 0))
 	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
 	f := database.MessageQueryFactory.NewFilter(context.Background()).Gt("confirmed", "0")
-	_, err := s.GetMessages(context.Background(), f)
+	_, _, err := s.GetMessages(context.Background(), f)
 	assert.Regexp(t, "FF10115", err)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
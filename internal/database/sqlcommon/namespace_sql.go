@@ -155,16 +155,16 @@ func (s *SQLCommon) GetNamespace(ctx context.Context, name string) (message *fft
 	return namespace, nil
 }
 
-func (s *SQLCommon) GetNamespaces(ctx context.Context, filter database.Filter) (message []*fftypes.Namespace, err error) {
+func (s *SQLCommon) GetNamespaces(ctx context.Context, filter database.Filter) (message []*fftypes.Namespace, res *database.FilterResult, err error) {
 
-	query, err := s.filterSelect(ctx, "", sq.Select(namespaceColumns...).From("namespaces"), filter, namespaceFilterTypeMap)
+	query, fop, fi, err := s.filterSelect(ctx, "", sq.Select(namespaceColumns...).From("namespaces"), filter, namespaceFilterTypeMap)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	rows, err := s.query(ctx, query)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer rows.Close()
 
@@ -172,12 +172,12 @@ func (s *SQLCommon) GetNamespaces(ctx context.Context, filter database.Filter) (
 	for rows.Next() {
 		d, err := s.namespaceResult(ctx, rows)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		namespace = append(namespace, d)
 	}
 
-	return namespace, err
+	return namespace, s.queryRes(ctx, "namespaces", fop, fi), err
 
 }
 
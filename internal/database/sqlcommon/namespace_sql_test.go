@@ -88,7 +88,7 @@ func TestNamespacesE2EWithDB(t *testing.T) {
 		fb.Eq("type", string(namespaceUpdated.Type)),
 		fb.Eq("name", namespaceUpdated.Name),
 	)
-	namespaceRes, err := s.GetNamespaces(ctx, filter)
+	namespaceRes, _, err := s.GetNamespaces(ctx, filter)
 	assert.NoError(t, err)
 	assert.Equal(t, 1, len(namespaceRes))
 	namespaceReadJson, _ = json.Marshal(namespaceRes[0])
@@ -105,14 +105,14 @@ func TestNamespacesE2EWithDB(t *testing.T) {
 		fb.Eq("name", namespaceUpdated.Name),
 		fb.Eq("created", updateTime.String()),
 	)
-	namespaces, err := s.GetNamespaces(ctx, filter)
+	namespaces, _, err := s.GetNamespaces(ctx, filter)
 	assert.NoError(t, err)
 	assert.Equal(t, 1, len(namespaces))
 
 	// Delete
 	err = s.DeleteNamespace(ctx, namespaceUpdated.ID)
 	assert.NoError(t, err)
-	namespaces, err = s.GetNamespaces(ctx, filter)
+	namespaces, _, err = s.GetNamespaces(ctx, filter)
 	assert.NoError(t, err)
 	assert.Equal(t, 0, len(namespaces))
 }
@@ -198,7 +198,7 @@ func TestGetNamespaceQueryFail(t *testing.T) {
 	s, mock := newMockProvider().init()
 	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
 	f := database.NamespaceQueryFactory.NewFilter(context.Background()).Eq("type", "")
-	_, err := s.GetNamespaces(context.Background(), f)
+	_, _, err := s.GetNamespaces(context.Background(), f)
 	assert.Regexp(t, "FF10115", err)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
@@ -206,7 +206,7 @@ func TestGetNamespaceQueryFail(t *testing.T) {
 func TestGetNamespaceBuildQueryFail(t *testing.T) {
 	s, _ := newMockProvider().init()
 	f := database.NamespaceQueryFactory.NewFilter(context.Background()).Eq("type", map[bool]bool{true: false})
-	_, err := s.GetNamespaces(context.Background(), f)
+	_, _, err := s.GetNamespaces(context.Background(), f)
 	assert.Regexp(t, "FF10149.*type", err)
 }
 
@@ -214,7 +214,7 @@ func TestGetNamespaceReadMessageFail(t *testing.T) {
 	s, mock := newMockProvider().init()
 	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"ntype"}).AddRow("only one"))
 	f := database.NamespaceQueryFactory.NewFilter(context.Background()).Eq("type", "")
-	_, err := s.GetNamespaces(context.Background(), f)
+	_, _, err := s.GetNamespaces(context.Background(), f)
 	assert.Regexp(t, "FF10121", err)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
@@ -122,7 +122,7 @@ func (s *SQLCommon) GetNextPins(ctx context.Context, filter database.Filter) (me
 
 	cols := append([]string{}, nextpinColumns...)
 	cols = append(cols, s.provider.SequenceField(""))
-	query, err := s.filterSelect(ctx, "", sq.Select(cols...).From("nextpins"), filter, nextpinFilterTypeMap)
+	query, _, _, err := s.filterSelect(ctx, "", sq.Select(cols...).From("nextpins"), filter, nextpinFilterTypeMap)
 	if err != nil {
 		return nil, err
 	}
@@ -38,6 +38,9 @@ var (
 		"dx_peer",
 		"dx_endpoint",
 		"created",
+		"version",
+		"updated",
+		"revoked",
 	}
 	nodeFilterTypeMap = map[string]string{
 		"message":     "message_id",
@@ -94,6 +97,9 @@ func (s *SQLCommon) UpsertNode(ctx context.Context, node *fftypes.Node, allowExi
 				Set("dx_peer", node.DX.Peer).
 				Set("dx_endpoint", node.DX.Endpoint).
 				Set("created", node.Created).
+				Set("version", node.Version).
+				Set("updated", node.Updated).
+				Set("revoked", node.Revoked).
 				Where(sq.Eq{"id": node.ID}),
 		); err != nil {
 			return err
@@ -102,16 +108,7 @@ func (s *SQLCommon) UpsertNode(ctx context.Context, node *fftypes.Node, allowExi
 		if _, err = s.insertTx(ctx, tx,
 			sq.Insert("nodes").
 				Columns(nodeColumns...).
-				Values(
-					node.ID,
-					node.Message,
-					node.Owner,
-					node.Name,
-					node.Description,
-					node.DX.Peer,
-					node.DX.Endpoint,
-					node.Created,
-				),
+				Values(nodeValues(node)...),
 		); err != nil {
 			return err
 		}
@@ -120,6 +117,40 @@ func (s *SQLCommon) UpsertNode(ctx context.Context, node *fftypes.Node, allowExi
 	return s.commitTx(ctx, tx, autoCommit)
 }
 
+func nodeValues(node *fftypes.Node) []interface{} {
+	return []interface{}{
+		node.ID,
+		node.Message,
+		node.Owner,
+		node.Name,
+		node.Description,
+		node.DX.Peer,
+		node.DX.Endpoint,
+		node.Created,
+		node.Version,
+		node.Updated,
+		node.Revoked,
+	}
+}
+
+func (s *SQLCommon) InsertNodeVersion(ctx context.Context, node *fftypes.Node) (err error) {
+	ctx, tx, autoCommit, err := s.beginOrUseTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer s.rollbackTx(ctx, tx, autoCommit)
+
+	if _, err = s.insertTx(ctx, tx,
+		sq.Insert("nodes_history").
+			Columns(nodeColumns...).
+			Values(nodeValues(node)...),
+	); err != nil {
+		return err
+	}
+
+	return s.commitTx(ctx, tx, autoCommit)
+}
+
 func (s *SQLCommon) nodeResult(ctx context.Context, row *sql.Rows) (*fftypes.Node, error) {
 	node := fftypes.Node{}
 	err := row.Scan(
@@ -131,6 +162,9 @@ func (s *SQLCommon) nodeResult(ctx context.Context, row *sql.Rows) (*fftypes.Nod
 		&node.DX.Peer,
 		&node.DX.Endpoint,
 		&node.Created,
+		&node.Version,
+		&node.Updated,
+		&node.Revoked,
 	)
 	if err != nil {
 		return nil, i18n.WrapError(ctx, err, i18n.MsgDBReadErr, "nodes")
@@ -171,16 +205,24 @@ func (s *SQLCommon) GetNodeByID(ctx context.Context, id *fftypes.UUID) (message
 	return s.getNodePred(ctx, id.String(), sq.Eq{"id": id})
 }
 
-func (s *SQLCommon) GetNodes(ctx context.Context, filter database.Filter) (message []*fftypes.Node, err error) {
+func (s *SQLCommon) GetNodes(ctx context.Context, filter database.Filter) (message []*fftypes.Node, res *database.FilterResult, err error) {
+	return s.getNodesFrom(ctx, "nodes", filter)
+}
+
+func (s *SQLCommon) GetNodeVersions(ctx context.Context, filter database.Filter) (message []*fftypes.Node, res *database.FilterResult, err error) {
+	return s.getNodesFrom(ctx, "nodes_history", filter)
+}
+
+func (s *SQLCommon) getNodesFrom(ctx context.Context, table string, filter database.Filter) (message []*fftypes.Node, res *database.FilterResult, err error) {
 
-	query, err := s.filterSelect(ctx, "", sq.Select(nodeColumns...).From("nodes"), filter, nodeFilterTypeMap)
+	query, fop, fi, err := s.filterSelect(ctx, "", sq.Select(nodeColumns...).From(table), filter, nodeFilterTypeMap)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	rows, err := s.query(ctx, query)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer rows.Close()
 
@@ -188,12 +230,12 @@ func (s *SQLCommon) GetNodes(ctx context.Context, filter database.Filter) (messa
 	for rows.Next() {
 		d, err := s.nodeResult(ctx, rows)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		node = append(node, d)
 	}
 
-	return node, err
+	return node, s.queryRes(ctx, table, fop, fi), err
 
 }
 
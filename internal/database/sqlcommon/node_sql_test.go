@@ -42,10 +42,13 @@ func TestNodesE2EWithDB(t *testing.T) {
 		Message: fftypes.NewUUID(),
 		Owner:   "0x23456",
 		Name:    "node1",
+		Version: 1,
 		Created: fftypes.Now(),
 	}
 	err := s.UpsertNode(ctx, node, true)
 	assert.NoError(t, err)
+	err = s.InsertNodeVersion(ctx, node)
+	assert.NoError(t, err)
 
 	// Check we get the exact same node back
 	nodeRead, err := s.GetNode(ctx, node.Owner, node.Name)
@@ -75,10 +78,15 @@ func TestNodesE2EWithDB(t *testing.T) {
 			Peer:     "peer1",
 			Endpoint: fftypes.JSONObject{"some": "info"},
 		},
-		Created: fftypes.Now(),
+		Version: 2,
+		Created: node.Created,
+		Updated: fftypes.Now(),
+		Revoked: fftypes.Now(),
 	}
 	err = s.UpsertNode(context.Background(), nodeUpdated, true)
 	assert.NoError(t, err)
+	err = s.InsertNodeVersion(ctx, nodeUpdated)
+	assert.NoError(t, err)
 
 	// Check we get the exact same data back - note the removal of one of the node elements
 	nodeRead, err = s.GetNode(ctx, node.Owner, node.Name)
@@ -93,7 +101,7 @@ func TestNodesE2EWithDB(t *testing.T) {
 		fb.Eq("description", string(nodeUpdated.Description)),
 		fb.Eq("name", nodeUpdated.Name),
 	)
-	nodeRes, err := s.GetNodes(ctx, filter)
+	nodeRes, _, err := s.GetNodes(ctx, filter)
 	assert.NoError(t, err)
 	assert.Equal(t, 1, len(nodeRes))
 	nodeReadJson, _ = json.Marshal(nodeRes[0])
@@ -110,9 +118,35 @@ func TestNodesE2EWithDB(t *testing.T) {
 		fb.Eq("name", nodeUpdated.Name),
 		fb.Eq("created", updateTime.String()),
 	)
-	nodes, err := s.GetNodes(ctx, filter)
+	nodes, _, err := s.GetNodes(ctx, filter)
 	assert.NoError(t, err)
 	assert.Equal(t, 1, len(nodes))
+
+	// Revoked nodes can be excluded
+	filter = fb.And(
+		fb.Eq("name", nodeUpdated.Name),
+		fb.Eq("revoked", nil),
+	)
+	nodes, _, err = s.GetNodes(ctx, filter)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, len(nodes))
+
+	// Query back the history
+	versionFilter := fb.And(
+		fb.Eq("id", node.ID),
+	).Sort("version")
+	versions, _, err := s.GetNodeVersions(ctx, versionFilter)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(versions))
+	assert.Equal(t, int64(1), versions[0].Version)
+	assert.Equal(t, "", versions[0].DX.Peer)
+	assert.Equal(t, int64(2), versions[1].Version)
+	nodeReadJson, _ = json.Marshal(versions[1])
+	assert.Equal(t, string(nodeJson), string(nodeReadJson))
+
+	// Rejects a duplicate version
+	err = s.InsertNodeVersion(ctx, nodeUpdated)
+	assert.Error(t, err)
 }
 
 func TestUpsertNodeFailBegin(t *testing.T) {
@@ -196,7 +230,7 @@ func TestGetNodeQueryFail(t *testing.T) {
 	s, mock := newMockProvider().init()
 	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
 	f := database.NodeQueryFactory.NewFilter(context.Background()).Eq("name", "")
-	_, err := s.GetNodes(context.Background(), f)
+	_, _, err := s.GetNodes(context.Background(), f)
 	assert.Regexp(t, "FF10115", err)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
@@ -204,7 +238,7 @@ func TestGetNodeQueryFail(t *testing.T) {
 func TestGetNodeBuildQueryFail(t *testing.T) {
 	s, _ := newMockProvider().init()
 	f := database.NodeQueryFactory.NewFilter(context.Background()).Eq("name", map[bool]bool{true: false})
-	_, err := s.GetNodes(context.Background(), f)
+	_, _, err := s.GetNodes(context.Background(), f)
 	assert.Regexp(t, "FF10149.*type", err)
 }
 
@@ -212,7 +246,7 @@ func TestGetNodeReadMessageFail(t *testing.T) {
 	s, mock := newMockProvider().init()
 	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("only one"))
 	f := database.NodeQueryFactory.NewFilter(context.Background()).Eq("name", "")
-	_, err := s.GetNodes(context.Background(), f)
+	_, _, err := s.GetNodes(context.Background(), f)
 	assert.Regexp(t, "FF10121", err)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
@@ -242,3 +276,40 @@ func TestNodeUpdateFail(t *testing.T) {
 	err := s.UpdateNode(context.Background(), fftypes.NewUUID(), u)
 	assert.Regexp(t, "FF10117", err)
 }
+
+func TestInsertNodeVersionFailBegin(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin().WillReturnError(fmt.Errorf("pop"))
+	err := s.InsertNodeVersion(context.Background(), &fftypes.Node{})
+	assert.Regexp(t, "FF10114", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInsertNodeVersionFailInsert(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT .*").WillReturnError(fmt.Errorf("pop"))
+	mock.ExpectRollback()
+	err := s.InsertNodeVersion(context.Background(), &fftypes.Node{Name: "node1"})
+	assert.Regexp(t, "FF10116", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInsertNodeVersionFailCommit(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT .*").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit().WillReturnError(fmt.Errorf("pop"))
+	err := s.InsertNodeVersion(context.Background(), &fftypes.Node{Name: "node1"})
+	assert.Regexp(t, "FF10119", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetNodeVersionsQueryFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
+	f := database.NodeQueryFactory.NewFilter(context.Background()).Eq("id", fftypes.NewUUID())
+	_, _, err := s.GetNodeVersions(context.Background(), f)
+	assert.Regexp(t, "FF10115", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -40,7 +40,9 @@ var (
 		"created",
 		"updated",
 		"error",
+		"input",
 		"info",
+		"retries",
 	}
 	opFilterTypeMap = map[string]string{
 		"tx":        "tx_id",
@@ -88,7 +90,9 @@ func (s *SQLCommon) UpsertOperation(ctx context.Context, operation *fftypes.Oper
 				Set("created", operation.Created).
 				Set("updated", operation.Updated).
 				Set("error", operation.Error).
+				Set("input", operation.Input).
 				Set("info", operation.Info).
+				Set("retries", operation.Retries).
 				Where(sq.Eq{"id": operation.ID}),
 		); err != nil {
 			return err
@@ -109,7 +113,9 @@ func (s *SQLCommon) UpsertOperation(ctx context.Context, operation *fftypes.Oper
 					operation.Created,
 					operation.Updated,
 					operation.Error,
+					operation.Input,
 					operation.Info,
+					operation.Retries,
 				),
 		); err != nil {
 			return err
@@ -133,7 +139,9 @@ func (s *SQLCommon) opResult(ctx context.Context, row *sql.Rows) (*fftypes.Opera
 		&op.Created,
 		&op.Updated,
 		&op.Error,
+		&op.Input,
 		&op.Info,
+		&op.Retries,
 	)
 	if err != nil {
 		return nil, i18n.WrapError(ctx, err, i18n.MsgDBReadErr, "operations")
@@ -166,16 +174,16 @@ func (s *SQLCommon) GetOperationByID(ctx context.Context, id *fftypes.UUID) (ope
 	return op, nil
 }
 
-func (s *SQLCommon) GetOperations(ctx context.Context, filter database.Filter) (operation []*fftypes.Operation, err error) {
+func (s *SQLCommon) GetOperations(ctx context.Context, filter database.Filter) (operation []*fftypes.Operation, res *database.FilterResult, err error) {
 
-	query, err := s.filterSelect(ctx, "", sq.Select(opColumns...).From("operations"), filter, opFilterTypeMap)
+	query, fop, fi, err := s.filterSelect(ctx, "", sq.Select(opColumns...).From("operations"), filter, opFilterTypeMap)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	rows, err := s.query(ctx, query)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer rows.Close()
 
@@ -183,12 +191,12 @@ func (s *SQLCommon) GetOperations(ctx context.Context, filter database.Filter) (
 	for rows.Next() {
 		op, err := s.opResult(ctx, rows)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		ops = append(ops, op)
 	}
 
-	return ops, err
+	return ops, s.queryRes(ctx, "operations", fop, fi), err
 }
 
 func (s *SQLCommon) UpdateOperation(ctx context.Context, id *fftypes.UUID, update database.Update) (err error) {
@@ -213,3 +221,31 @@ func (s *SQLCommon) UpdateOperation(ctx context.Context, id *fftypes.UUID, updat
 
 	return s.commitTx(ctx, tx, autoCommit)
 }
+
+func (s *SQLCommon) UpdateOperationIfStatus(ctx context.Context, id *fftypes.UUID, statuses []fftypes.OpStatus, update database.Update) (updated bool, err error) {
+
+	ctx, tx, autoCommit, err := s.beginOrUseTx(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer s.rollbackTx(ctx, tx, autoCommit)
+
+	query, err := s.buildUpdate(sq.Update("operations"), update, opFilterTypeMap)
+	if err != nil {
+		return false, err
+	}
+	query = query.Set("updated", fftypes.Now())
+	// The status condition is evaluated by the database, so only one of two concurrent
+	// callers can move the operation out of a given status
+	query = query.Where(sq.And{
+		sq.Eq{"id": id},
+		sq.Eq{"opstatus": statuses},
+	})
+
+	rows, err := s.updateTxRows(ctx, tx, query)
+	if err != nil {
+		return false, err
+	}
+
+	return rows > 0, s.commitTx(ctx, tx, autoCommit)
+}
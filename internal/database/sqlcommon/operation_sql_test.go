@@ -67,9 +67,13 @@ func TestOperationE2EWithDB(t *testing.T) {
 		Plugin:      "ethereum",
 		BackendID:   fftypes.NewRandB32().String(),
 		Error:       "pop",
+		Input:       fftypes.JSONObject{"some": "input"},
 		Info:        fftypes.JSONObject{"some": "info"},
-		Created:     fftypes.Now(),
-		Updated:     fftypes.Now(),
+		Retries: fftypes.OperationRetries{
+			{BackendID: "previous", Status: fftypes.OpStatusFailed, Error: "pop", Retried: fftypes.Now()},
+		},
+		Created: fftypes.Now(),
+		Updated: fftypes.Now(),
 	}
 	err = s.UpsertOperation(context.Background(), operationUpdated, true)
 	assert.NoError(t, err)
@@ -96,7 +100,7 @@ func TestOperationE2EWithDB(t *testing.T) {
 		fb.Gt("updated", 0),
 	)
 
-	operations, err := s.GetOperations(ctx, filter)
+	operations, _, err := s.GetOperations(ctx, filter)
 	assert.NoError(t, err)
 	assert.Equal(t, 1, len(operations))
 	operationReadJson, _ = json.Marshal(operations[0])
@@ -107,7 +111,7 @@ func TestOperationE2EWithDB(t *testing.T) {
 		fb.Eq("id", operationUpdated.ID.String()),
 		fb.Eq("updated", "0"),
 	)
-	operations, err = s.GetOperations(ctx, filter)
+	operations, _, err = s.GetOperations(ctx, filter)
 	assert.NoError(t, err)
 	assert.Equal(t, 0, len(operations))
 
@@ -126,9 +130,21 @@ func TestOperationE2EWithDB(t *testing.T) {
 		fb.Eq("status", fftypes.OpStatusSucceeded),
 		fb.Eq("error", ""),
 	)
-	operations, err = s.GetOperations(ctx, filter)
+	operations, _, err = s.GetOperations(ctx, filter)
 	assert.NoError(t, err)
 	assert.Equal(t, 1, len(operations))
+
+	// Conditional update only applies from the listed statuses
+	up = database.OperationQueryFactory.NewUpdate(ctx).Set("status", fftypes.OpStatusCancelled)
+	updated, err := s.UpdateOperationIfStatus(ctx, operationUpdated.ID, []fftypes.OpStatus{fftypes.OpStatusPending, fftypes.OpStatusFailed}, up)
+	assert.NoError(t, err)
+	assert.False(t, updated)
+	updated, err = s.UpdateOperationIfStatus(ctx, operationUpdated.ID, []fftypes.OpStatus{fftypes.OpStatusSucceeded}, up)
+	assert.NoError(t, err)
+	assert.True(t, updated)
+	operationRead, err = s.GetOperationByID(ctx, operationUpdated.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, fftypes.OpStatusCancelled, operationRead.Status)
 }
 
 func TestUpsertOperationFailBegin(t *testing.T) {
@@ -218,7 +234,7 @@ func TestGetOperationsQueryFail(t *testing.T) {
 	s, mock := newMockProvider().init()
 	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
 	f := database.OperationQueryFactory.NewFilter(context.Background()).Eq("id", "")
-	_, err := s.GetOperations(context.Background(), f)
+	_, _, err := s.GetOperations(context.Background(), f)
 	assert.Regexp(t, "FF10115", err)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
@@ -226,7 +242,7 @@ func TestGetOperationsQueryFail(t *testing.T) {
 func TestGetOperationsBuildQueryFail(t *testing.T) {
 	s, _ := newMockProvider().init()
 	f := database.OperationQueryFactory.NewFilter(context.Background()).Eq("id", map[bool]bool{true: false})
-	_, err := s.GetOperations(context.Background(), f)
+	_, _, err := s.GetOperations(context.Background(), f)
 	assert.Regexp(t, "FF10149.*id", err)
 }
 
@@ -234,7 +250,7 @@ func TestGettOperationsReadMessageFail(t *testing.T) {
 	s, mock := newMockProvider().init()
 	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("only one"))
 	f := database.OperationQueryFactory.NewFilter(context.Background()).Eq("id", "")
-	_, err := s.GetOperations(context.Background(), f)
+	_, _, err := s.GetOperations(context.Background(), f)
 	assert.Regexp(t, "FF10121", err)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
@@ -264,3 +280,29 @@ func TestOperationUpdateFail(t *testing.T) {
 	err := s.UpdateOperation(context.Background(), fftypes.NewUUID(), u)
 	assert.Regexp(t, "FF10117", err)
 }
+
+func TestOperationUpdateIfStatusBeginFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin().WillReturnError(fmt.Errorf("pop"))
+	u := database.OperationQueryFactory.NewUpdate(context.Background()).Set("id", fftypes.NewUUID())
+	_, err := s.UpdateOperationIfStatus(context.Background(), fftypes.NewUUID(), []fftypes.OpStatus{fftypes.OpStatusFailed}, u)
+	assert.Regexp(t, "FF10114", err)
+}
+
+func TestOperationUpdateIfStatusBuildQueryFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	u := database.OperationQueryFactory.NewUpdate(context.Background()).Set("id", map[bool]bool{true: false})
+	_, err := s.UpdateOperationIfStatus(context.Background(), fftypes.NewUUID(), []fftypes.OpStatus{fftypes.OpStatusFailed}, u)
+	assert.Regexp(t, "FF10149.*id", err)
+}
+
+func TestOperationUpdateIfStatusFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE .*").WillReturnError(fmt.Errorf("pop"))
+	mock.ExpectRollback()
+	u := database.OperationQueryFactory.NewUpdate(context.Background()).Set("id", fftypes.NewUUID())
+	_, err := s.UpdateOperationIfStatus(context.Background(), fftypes.NewUUID(), []fftypes.OpStatus{fftypes.OpStatusFailed}, u)
+	assert.Regexp(t, "FF10117", err)
+}
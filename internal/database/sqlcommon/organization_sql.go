@@ -36,7 +36,12 @@ var (
 		"identity",
 		"description",
 		"profile",
+		"public_keys",
 		"created",
+		"version",
+		"updated",
+		"revoked",
+		"identity_proof",
 	}
 	organizationFilterTypeMap = map[string]string{
 		"message": "message_id",
@@ -83,11 +88,17 @@ func (s *SQLCommon) UpsertOrganization(ctx context.Context, organization *fftype
 			sq.Update("orgs").
 				// Note we do not update ID
 				Set("message_id", organization.Message).
+				Set("name", organization.Name).
 				Set("parent", organization.Parent).
 				Set("identity", organization.Identity).
 				Set("description", organization.Description).
 				Set("profile", organization.Profile).
+				Set("public_keys", organization.PublicKeys).
 				Set("created", organization.Created).
+				Set("version", organization.Version).
+				Set("updated", organization.Updated).
+				Set("revoked", organization.Revoked).
+				Set("identity_proof", organization.IdentityProof).
 				Where(sq.Eq{"identity": organization.Identity}),
 		); err != nil {
 			return err
@@ -96,16 +107,7 @@ func (s *SQLCommon) UpsertOrganization(ctx context.Context, organization *fftype
 		if _, err = s.insertTx(ctx, tx,
 			sq.Insert("orgs").
 				Columns(organizationColumns...).
-				Values(
-					organization.ID,
-					organization.Message,
-					organization.Name,
-					organization.Parent,
-					organization.Identity,
-					organization.Description,
-					organization.Profile,
-					organization.Created,
-				),
+				Values(organizationValues(organization)...),
 		); err != nil {
 			return err
 		}
@@ -114,6 +116,42 @@ func (s *SQLCommon) UpsertOrganization(ctx context.Context, organization *fftype
 	return s.commitTx(ctx, tx, autoCommit)
 }
 
+func organizationValues(organization *fftypes.Organization) []interface{} {
+	return []interface{}{
+		organization.ID,
+		organization.Message,
+		organization.Name,
+		organization.Parent,
+		organization.Identity,
+		organization.Description,
+		organization.Profile,
+		organization.PublicKeys,
+		organization.Created,
+		organization.Version,
+		organization.Updated,
+		organization.Revoked,
+		organization.IdentityProof,
+	}
+}
+
+func (s *SQLCommon) InsertOrganizationVersion(ctx context.Context, organization *fftypes.Organization) (err error) {
+	ctx, tx, autoCommit, err := s.beginOrUseTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer s.rollbackTx(ctx, tx, autoCommit)
+
+	if _, err = s.insertTx(ctx, tx,
+		sq.Insert("orgs_history").
+			Columns(organizationColumns...).
+			Values(organizationValues(organization)...),
+	); err != nil {
+		return err
+	}
+
+	return s.commitTx(ctx, tx, autoCommit)
+}
+
 func (s *SQLCommon) organizationResult(ctx context.Context, row *sql.Rows) (*fftypes.Organization, error) {
 	organization := fftypes.Organization{}
 	err := row.Scan(
@@ -124,7 +162,12 @@ func (s *SQLCommon) organizationResult(ctx context.Context, row *sql.Rows) (*fft
 		&organization.Identity,
 		&organization.Description,
 		&organization.Profile,
+		&organization.PublicKeys,
 		&organization.Created,
+		&organization.Version,
+		&organization.Updated,
+		&organization.Revoked,
+		&organization.IdentityProof,
 	)
 	if err != nil {
 		return nil, i18n.WrapError(ctx, err, i18n.MsgDBReadErr, "orgs")
@@ -169,16 +212,24 @@ func (s *SQLCommon) GetOrganizationByID(ctx context.Context, id *fftypes.UUID) (
 	return s.getOrganizationPred(ctx, id.String(), sq.Eq{"id": id})
 }
 
-func (s *SQLCommon) GetOrganizations(ctx context.Context, filter database.Filter) (message []*fftypes.Organization, err error) {
+func (s *SQLCommon) GetOrganizations(ctx context.Context, filter database.Filter) (message []*fftypes.Organization, res *database.FilterResult, err error) {
+	return s.getOrganizationsFrom(ctx, "orgs", filter)
+}
+
+func (s *SQLCommon) GetOrganizationVersions(ctx context.Context, filter database.Filter) (message []*fftypes.Organization, res *database.FilterResult, err error) {
+	return s.getOrganizationsFrom(ctx, "orgs_history", filter)
+}
+
+func (s *SQLCommon) getOrganizationsFrom(ctx context.Context, table string, filter database.Filter) (message []*fftypes.Organization, res *database.FilterResult, err error) {
 
-	query, err := s.filterSelect(ctx, "", sq.Select(organizationColumns...).From("orgs"), filter, organizationFilterTypeMap)
+	query, fop, fi, err := s.filterSelect(ctx, "", sq.Select(organizationColumns...).From(table), filter, organizationFilterTypeMap)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	rows, err := s.query(ctx, query)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer rows.Close()
 
@@ -186,12 +237,12 @@ func (s *SQLCommon) GetOrganizations(ctx context.Context, filter database.Filter
 	for rows.Next() {
 		d, err := s.organizationResult(ctx, rows)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		organization = append(organization, d)
 	}
 
-	return organization, err
+	return organization, s.queryRes(ctx, table, fop, fi), err
 
 }
 
@@ -42,10 +42,13 @@ func TestOrganizationsE2EWithDB(t *testing.T) {
 		Message:  fftypes.NewUUID(),
 		Name:     "org1",
 		Identity: "0x12345",
+		Version:  1,
 		Created:  fftypes.Now(),
 	}
 	err := s.UpsertOrganization(ctx, organization, true)
 	assert.NoError(t, err)
+	err = s.InsertOrganizationVersion(ctx, organization)
+	assert.NoError(t, err)
 
 	// Check we get the exact same organization back
 	organizationRead, err := s.GetOrganizationByIdentity(ctx, organization.Identity)
@@ -72,10 +75,18 @@ func TestOrganizationsE2EWithDB(t *testing.T) {
 		Identity:    "0x12345",
 		Description: "organization1",
 		Profile:     fftypes.JSONObject{"some": "info"},
-		Created:     fftypes.Now(),
+		PublicKeys: fftypes.PublicKeys{
+			{Type: fftypes.KeyTypeEd25519, Key: "cHVibGlja2V5"},
+		},
+		Version:       2,
+		Created:       organization.Created,
+		Updated:       fftypes.Now(),
+		IdentityProof: "cHJvb2Y=",
 	}
 	err = s.UpsertOrganization(context.Background(), organizationUpdated, true)
 	assert.NoError(t, err)
+	err = s.InsertOrganizationVersion(ctx, organizationUpdated)
+	assert.NoError(t, err)
 
 	// Check we get the exact same data back - note the removal of one of the organization elements
 	organizationRead, err = s.GetOrganizationByName(ctx, organization.Name)
@@ -90,7 +101,7 @@ func TestOrganizationsE2EWithDB(t *testing.T) {
 		fb.Eq("description", string(organizationUpdated.Description)),
 		fb.Eq("identity", organizationUpdated.Identity),
 	)
-	organizationRes, err := s.GetOrganizations(ctx, filter)
+	organizationRes, _, err := s.GetOrganizations(ctx, filter)
 	assert.NoError(t, err)
 	assert.Equal(t, 1, len(organizationRes))
 	organizationReadJson, _ = json.Marshal(organizationRes[0])
@@ -107,9 +118,26 @@ func TestOrganizationsE2EWithDB(t *testing.T) {
 		fb.Eq("identity", organizationUpdated.Identity),
 		fb.Eq("created", updateTime.String()),
 	)
-	organizations, err := s.GetOrganizations(ctx, filter)
+	organizations, _, err := s.GetOrganizations(ctx, filter)
 	assert.NoError(t, err)
 	assert.Equal(t, 1, len(organizations))
+
+	// Query back the history
+	versionFilter := fb.And(
+		fb.Eq("id", organization.ID),
+	).Sort("version")
+	versions, _, err := s.GetOrganizationVersions(ctx, versionFilter)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, len(versions))
+	assert.Equal(t, int64(1), versions[0].Version)
+	assert.Equal(t, "", versions[0].Description)
+	assert.Equal(t, int64(2), versions[1].Version)
+	organizationReadJson, _ = json.Marshal(versions[1])
+	assert.Equal(t, string(organizationJson), string(organizationReadJson))
+
+	// Rejects a duplicate version
+	err = s.InsertOrganizationVersion(ctx, organizationUpdated)
+	assert.Error(t, err)
 }
 
 func TestUpsertOrganizationFailBegin(t *testing.T) {
@@ -209,7 +237,7 @@ func TestGetOrganizationQueryFail(t *testing.T) {
 	s, mock := newMockProvider().init()
 	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
 	f := database.OrganizationQueryFactory.NewFilter(context.Background()).Eq("identity", "")
-	_, err := s.GetOrganizations(context.Background(), f)
+	_, _, err := s.GetOrganizations(context.Background(), f)
 	assert.Regexp(t, "FF10115", err)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
@@ -217,7 +245,7 @@ func TestGetOrganizationQueryFail(t *testing.T) {
 func TestGetOrganizationBuildQueryFail(t *testing.T) {
 	s, _ := newMockProvider().init()
 	f := database.OrganizationQueryFactory.NewFilter(context.Background()).Eq("identity", map[bool]bool{true: false})
-	_, err := s.GetOrganizations(context.Background(), f)
+	_, _, err := s.GetOrganizations(context.Background(), f)
 	assert.Regexp(t, "FF10149.*type", err)
 }
 
@@ -225,7 +253,7 @@ func TestGetOrganizationReadMessageFail(t *testing.T) {
 	s, mock := newMockProvider().init()
 	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"identity"}).AddRow("only one"))
 	f := database.OrganizationQueryFactory.NewFilter(context.Background()).Eq("identity", "")
-	_, err := s.GetOrganizations(context.Background(), f)
+	_, _, err := s.GetOrganizations(context.Background(), f)
 	assert.Regexp(t, "FF10121", err)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
@@ -255,3 +283,40 @@ func TestOrganizationUpdateFail(t *testing.T) {
 	err := s.UpdateOrganization(context.Background(), fftypes.NewUUID(), u)
 	assert.Regexp(t, "FF10117", err)
 }
+
+func TestInsertOrganizationVersionFailBegin(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin().WillReturnError(fmt.Errorf("pop"))
+	err := s.InsertOrganizationVersion(context.Background(), &fftypes.Organization{})
+	assert.Regexp(t, "FF10114", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInsertOrganizationVersionFailInsert(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT .*").WillReturnError(fmt.Errorf("pop"))
+	mock.ExpectRollback()
+	err := s.InsertOrganizationVersion(context.Background(), &fftypes.Organization{Identity: "id1"})
+	assert.Regexp(t, "FF10116", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInsertOrganizationVersionFailCommit(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT .*").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit().WillReturnError(fmt.Errorf("pop"))
+	err := s.InsertOrganizationVersion(context.Background(), &fftypes.Organization{Identity: "id1"})
+	assert.Regexp(t, "FF10119", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestGetOrganizationVersionsQueryFail(t *testing.T) {
+	s, mock := newMockProvider().init()
+	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
+	f := database.OrganizationQueryFactory.NewFilter(context.Background()).Eq("id", fftypes.NewUUID())
+	_, _, err := s.GetOrganizationVersions(context.Background(), f)
+	assert.Regexp(t, "FF10115", err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -29,6 +29,7 @@ import (
 
 var (
 	pinColumns = []string{
+		"ledger",
 		"masked",
 		"hash",
 		"batch_id",
@@ -77,6 +78,7 @@ func (s *SQLCommon) UpsertPin(ctx context.Context, pin *fftypes.Pin) (err error)
 			sq.Insert("pins").
 				Columns(pinColumns...).
 				Values(
+					pin.Ledger,
 					pin.Masked,
 					pin.Hash,
 					pin.Batch,
@@ -100,6 +102,7 @@ func (s *SQLCommon) UpsertPin(ctx context.Context, pin *fftypes.Pin) (err error)
 func (s *SQLCommon) pinResult(ctx context.Context, row *sql.Rows) (*fftypes.Pin, error) {
 	pin := fftypes.Pin{}
 	err := row.Scan(
+		&pin.Ledger,
 		&pin.Masked,
 		&pin.Hash,
 		&pin.Batch,
@@ -118,7 +121,7 @@ func (s *SQLCommon) GetPins(ctx context.Context, filter database.Filter) (messag
 
 	cols := append([]string{}, pinColumns...)
 	cols = append(cols, s.provider.SequenceField(""))
-	query, err := s.filterSelect(ctx, "", sq.Select(cols...).From("pins"), filter, pinFilterTypeMap)
+	query, _, _, err := s.filterSelect(ctx, "", sq.Select(cols...).From("pins"), filter, pinFilterTypeMap)
 	if err != nil {
 		return nil, err
 	}
@@ -40,6 +40,7 @@ func TestPinsE2EWithDB(t *testing.T) {
 
 	// Create a new pin entry
 	pin := &fftypes.Pin{
+		Ledger:     fftypes.NewUUID(),
 		Masked:     true,
 		Hash:       fftypes.NewRandB32(),
 		Batch:      fftypes.NewUUID(),
@@ -53,6 +54,7 @@ func TestPinsE2EWithDB(t *testing.T) {
 	// Query back the pin
 	fb := database.PinQueryFactory.NewFilter(ctx)
 	filter := fb.And(
+		fb.Eq("ledger", pin.Ledger),
 		fb.Eq("masked", pin.Masked),
 		fb.Eq("hash", pin.Hash),
 		fb.Eq("batch", pin.Batch),
@@ -61,6 +63,7 @@ func TestPinsE2EWithDB(t *testing.T) {
 	pinRes, err := s.GetPins(ctx, filter)
 	assert.NoError(t, err)
 	assert.Equal(t, 1, len(pinRes))
+	assert.Equal(t, *pin.Ledger, *pinRes[0].Ledger)
 
 	// Set it dispatched
 	err = s.SetPinDispatched(ctx, pin.Sequence)
@@ -41,6 +41,10 @@ type Provider interface {
 	// UpdateInsertForReturn updates the insert query for returning the Sequenc, and returns whether it needs to be run as a query to return the Sequence field
 	UpdateInsertForSequenceReturn(insert sq.InsertBuilder) (updatedInsert sq.InsertBuilder, runAsQuery bool)
 
+	// SelectForShare updates a select to hold a shared lock on the rows it returns until the end of the transaction,
+	// blocking concurrent updates to them. Databases that only allow a single writer need not change the query.
+	SelectForShare(q sq.SelectBuilder) sq.SelectBuilder
+
 	// SequenceField must be auto added by the database to each table, via appropriate DDL in the migrations
 	// Different formats exist for putting a table prefix. QL is "id(prefix)" rather than "prefix.seq"
 	SequenceField(tableName string) string
@@ -74,6 +74,10 @@ func (mp *mockProvider) UpdateInsertForSequenceReturn(insert sq.InsertBuilder) (
 	return insert, false
 }
 
+func (mp *mockProvider) SelectForShare(q sq.SelectBuilder) sq.SelectBuilder {
+	return q
+}
+
 func (mp *mockProvider) SequenceField(tableName string) string {
 	if tableName != "" {
 		return fmt.Sprintf("%s.seq", tableName)
@@ -76,6 +76,10 @@ func (tp *qlTestProvider) UpdateInsertForSequenceReturn(insert sq.InsertBuilder)
 	return insert, false
 }
 
+func (tp *qlTestProvider) SelectForShare(q sq.SelectBuilder) sq.SelectBuilder {
+	return q
+}
+
 func (tp *qlTestProvider) SequenceField(tableName string) string {
 	return fmt.Sprintf("id(%s)", tableName)
 }
@@ -133,6 +133,12 @@ func (s *SQLCommon) beginOrUseTx(ctx context.Context) (ctx1 context.Context, tx
 	}
 	ctx1 = context.WithValue(ctx, txContextKey{}, tx)
 	l.Debugf("SQL<- begin")
+	if lease := database.GetLeaseFence(ctx).Lease(); lease != nil {
+		if err = s.checkLeaseFence(ctx1, tx, lease); err != nil {
+			s.rollbackTx(ctx1, tx, false)
+			return ctx1, nil, false, err
+		}
+	}
 	return ctx1, tx, false, err
 }
 
@@ -219,21 +225,27 @@ func (s *SQLCommon) deleteTx(ctx context.Context, tx *txWrapper, q sq.DeleteBuil
 }
 
 func (s *SQLCommon) updateTx(ctx context.Context, tx *txWrapper, q sq.UpdateBuilder) error {
+	_, err := s.updateTxRows(ctx, tx, q)
+	return err
+}
+
+// updateTxRows is a variant of updateTx for conditional updates, where the caller needs to know whether the condition matched
+func (s *SQLCommon) updateTxRows(ctx context.Context, tx *txWrapper, q sq.UpdateBuilder) (int64, error) {
 	l := log.L(ctx)
 	sqlQuery, args, err := q.PlaceholderFormat(s.provider.PlaceholderFormat()).ToSql()
 	if err != nil {
-		return i18n.WrapError(ctx, err, i18n.MsgDBQueryBuildFailed)
+		return -1, i18n.WrapError(ctx, err, i18n.MsgDBQueryBuildFailed)
 	}
 	l.Debugf(`SQL-> update: %s`, sqlQuery)
 	l.Tracef(`SQL-> update args: %+v`, args)
 	res, err := tx.sqlTX.ExecContext(ctx, sqlQuery, args...)
 	if err != nil {
 		l.Errorf(`SQL update failed: %s sql=[ %s ]`, err, sqlQuery)
-		return i18n.WrapError(ctx, err, i18n.MsgDBUpdateFailed)
+		return -1, i18n.WrapError(ctx, err, i18n.MsgDBUpdateFailed)
 	}
-	ra, _ := res.RowsAffected() // currently only used for debugging
+	ra, _ := res.RowsAffected()
 	l.Debugf(`SQL<- update affected=%d`, ra)
-	return nil
+	return ra, nil
 }
 
 func (s *SQLCommon) postCommitEvent(tx *txWrapper, fn func()) {
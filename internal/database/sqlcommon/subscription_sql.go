@@ -193,16 +193,16 @@ func (s *SQLCommon) GetSubscriptionByName(ctx context.Context, ns, name string)
 	return s.getSubscriptionEq(ctx, sq.Eq{"namespace": ns, "name": name}, fmt.Sprintf("%s:%s", ns, name))
 }
 
-func (s *SQLCommon) GetSubscriptions(ctx context.Context, filter database.Filter) (message []*fftypes.Subscription, err error) {
+func (s *SQLCommon) GetSubscriptions(ctx context.Context, filter database.Filter) (message []*fftypes.Subscription, res *database.FilterResult, err error) {
 
-	query, err := s.filterSelect(ctx, "", sq.Select(subscriptionColumns...).From("subscriptions"), filter, subscriptionFilterTypeMap)
+	query, fop, fi, err := s.filterSelect(ctx, "", sq.Select(subscriptionColumns...).From("subscriptions"), filter, subscriptionFilterTypeMap)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	rows, err := s.query(ctx, query)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer rows.Close()
 
@@ -210,12 +210,12 @@ func (s *SQLCommon) GetSubscriptions(ctx context.Context, filter database.Filter
 	for rows.Next() {
 		d, err := s.subscriptionResult(ctx, rows)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		subscription = append(subscription, d)
 	}
 
-	return subscription, err
+	return subscription, s.queryRes(ctx, "subscriptions", fop, fi), err
 
 }
 
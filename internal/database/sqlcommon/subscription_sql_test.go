@@ -105,7 +105,7 @@ func TestSubscriptionsE2EWithDB(t *testing.T) {
 		fb.Eq("namespace", subscriptionUpdated.Namespace),
 		fb.Eq("name", subscriptionUpdated.Name),
 	)
-	subscriptionRes, err := s.GetSubscriptions(ctx, filter)
+	subscriptionRes, _, err := s.GetSubscriptions(ctx, filter)
 	assert.NoError(t, err)
 	assert.Equal(t, 1, len(subscriptionRes))
 	subscriptionReadJson, _ = json.Marshal(subscriptionRes[0])
@@ -122,14 +122,14 @@ func TestSubscriptionsE2EWithDB(t *testing.T) {
 		fb.Eq("name", subscriptionUpdated.Name),
 		fb.Eq("created", updateTime.String()),
 	)
-	subscriptions, err := s.GetSubscriptions(ctx, filter)
+	subscriptions, _, err := s.GetSubscriptions(ctx, filter)
 	assert.NoError(t, err)
 	assert.Equal(t, 1, len(subscriptions))
 
 	// Test delete, and refind no return
 	err = s.DeleteSubscriptionByID(ctx, subscriptionUpdated.ID)
 	assert.NoError(t, err)
-	subscriptions, err = s.GetSubscriptions(ctx, filter)
+	subscriptions, _, err = s.GetSubscriptions(ctx, filter)
 	assert.NoError(t, err)
 	assert.Equal(t, 0, len(subscriptions))
 
@@ -217,7 +217,7 @@ func TestGetSubscriptionQueryFail(t *testing.T) {
 	s, mock := newMockProvider().init()
 	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
 	f := database.SubscriptionQueryFactory.NewFilter(context.Background()).Eq("name", "")
-	_, err := s.GetSubscriptions(context.Background(), f)
+	_, _, err := s.GetSubscriptions(context.Background(), f)
 	assert.Regexp(t, "FF10115", err)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
@@ -225,7 +225,7 @@ func TestGetSubscriptionQueryFail(t *testing.T) {
 func TestGetSubscriptionBuildQueryFail(t *testing.T) {
 	s, _ := newMockProvider().init()
 	f := database.SubscriptionQueryFactory.NewFilter(context.Background()).Eq("name", map[bool]bool{true: false})
-	_, err := s.GetSubscriptions(context.Background(), f)
+	_, _, err := s.GetSubscriptions(context.Background(), f)
 	assert.Regexp(t, "FF10149.*type", err)
 }
 
@@ -233,7 +233,7 @@ func TestGetSubscriptionReadMessageFail(t *testing.T) {
 	s, mock := newMockProvider().init()
 	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"ntype"}).AddRow("only one"))
 	f := database.SubscriptionQueryFactory.NewFilter(context.Background()).Eq("name", "")
-	_, err := s.GetSubscriptions(context.Background(), f)
+	_, _, err := s.GetSubscriptions(context.Background(), f)
 	assert.Regexp(t, "FF10121", err)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
@@ -171,18 +171,18 @@ func (s *SQLCommon) GetTransactionByID(ctx context.Context, id *fftypes.UUID) (m
 	return transaction, nil
 }
 
-func (s *SQLCommon) GetTransactions(ctx context.Context, filter database.Filter) (message []*fftypes.Transaction, err error) {
+func (s *SQLCommon) GetTransactions(ctx context.Context, filter database.Filter) (message []*fftypes.Transaction, res *database.FilterResult, err error) {
 
 	cols := append([]string{}, transactionColumns...)
 	cols = append(cols, s.provider.SequenceField(""))
-	query, err := s.filterSelect(ctx, "", sq.Select(cols...).From("transactions"), filter, transactionFilterTypeMap)
+	query, fop, fi, err := s.filterSelect(ctx, "", sq.Select(cols...).From("transactions"), filter, transactionFilterTypeMap)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	rows, err := s.query(ctx, query)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	defer rows.Close()
 
@@ -190,12 +190,12 @@ func (s *SQLCommon) GetTransactions(ctx context.Context, filter database.Filter)
 	for rows.Next() {
 		transaction, err := s.transactionResult(ctx, rows)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		transactions = append(transactions, transaction)
 	}
 
-	return transactions, err
+	return transactions, s.queryRes(ctx, "transactions", fop, fi), err
 
 }
 
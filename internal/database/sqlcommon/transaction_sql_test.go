@@ -104,7 +104,7 @@ func TestTransactionE2EWithDB(t *testing.T) {
 		fb.Eq("signer", transactionUpdated.Subject.Signer),
 		fb.Gt("created", "0"),
 	)
-	transactions, err := s.GetTransactions(ctx, filter)
+	transactions, _, err := s.GetTransactions(ctx, filter)
 	assert.NoError(t, err)
 	assert.Equal(t, 1, len(transactions))
 	transactionReadJson, _ = json.Marshal(transactions[0])
@@ -115,7 +115,7 @@ func TestTransactionE2EWithDB(t *testing.T) {
 		fb.Eq("id", transactionUpdated.ID.String()),
 		fb.Eq("created", "0"),
 	)
-	transactions, err = s.GetTransactions(ctx, filter)
+	transactions, _, err = s.GetTransactions(ctx, filter)
 	assert.NoError(t, err)
 	assert.Equal(t, 0, len(transactions))
 
@@ -130,7 +130,7 @@ func TestTransactionE2EWithDB(t *testing.T) {
 		fb.Eq("id", transactionUpdated.ID.String()),
 		fb.Eq("status", fftypes.OpStatusSucceeded),
 	)
-	transactions, err = s.GetTransactions(ctx, filter)
+	transactions, _, err = s.GetTransactions(ctx, filter)
 	assert.NoError(t, err)
 	assert.Equal(t, 1, len(transactions))
 }
@@ -222,7 +222,7 @@ func TestGetTransactionsQueryFail(t *testing.T) {
 	s, mock := newMockProvider().init()
 	mock.ExpectQuery("SELECT .*").WillReturnError(fmt.Errorf("pop"))
 	f := database.TransactionQueryFactory.NewFilter(context.Background()).Eq("id", "")
-	_, err := s.GetTransactions(context.Background(), f)
+	_, _, err := s.GetTransactions(context.Background(), f)
 	assert.Regexp(t, "FF10115", err)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
@@ -230,7 +230,7 @@ func TestGetTransactionsQueryFail(t *testing.T) {
 func TestGetTransactionsBuildQueryFail(t *testing.T) {
 	s, _ := newMockProvider().init()
 	f := database.TransactionQueryFactory.NewFilter(context.Background()).Eq("id", map[bool]bool{true: false})
-	_, err := s.GetTransactions(context.Background(), f)
+	_, _, err := s.GetTransactions(context.Background(), f)
 	assert.Regexp(t, "FF10149.*id", err)
 }
 
@@ -238,7 +238,7 @@ func TestGettTransactionsReadMessageFail(t *testing.T) {
 	s, mock := newMockProvider().init()
 	mock.ExpectQuery("SELECT .*").WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("only one"))
 	f := database.TransactionQueryFactory.NewFilter(context.Background()).Eq("id", "")
-	_, err := s.GetTransactions(context.Background(), f)
+	_, _, err := s.GetTransactions(context.Background(), f)
 	assert.Regexp(t, "FF10121", err)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
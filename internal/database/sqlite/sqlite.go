@@ -54,6 +54,11 @@ func (sqlite *SQLite) UpdateInsertForSequenceReturn(insert sq.InsertBuilder) (sq
 	return insert, false
 }
 
+func (sqlite *SQLite) SelectForShare(q sq.SelectBuilder) sq.SelectBuilder {
+	// No locking needed, as SQLite allows a single writer
+	return q
+}
+
 func (sqlite *SQLite) SequenceField(tableName string) string {
 	if tableName != "" {
 		return fmt.Sprintf("%s.seq", tableName)
@@ -127,6 +127,15 @@ func (h *HTTPS) AddPeer(ctx context.Context, node *fftypes.Node) (err error) {
 	return nil
 }
 
+func (h *HTTPS) RemovePeer(ctx context.Context, node *fftypes.Node) (err error) {
+	res, err := h.client.R().SetContext(ctx).
+		Delete(fmt.Sprintf("/api/v1/peers/%s", node.DX.Peer))
+	if err != nil || !res.IsSuccess() {
+		return restclient.WrapRestErr(ctx, res, err, i18n.MsgDXRESTErr)
+	}
+	return nil
+}
+
 func (h *HTTPS) UploadBLOB(ctx context.Context, ns string, id fftypes.UUID, content io.Reader) (err error) {
 	res, err := h.client.R().SetContext(ctx).
 		SetFileReader("file", id.String(), content).
@@ -235,7 +244,12 @@ func (h *HTTPS) eventLoop() {
 				h.callbacks.TransferResult(msg.RequestID, fftypes.OpStatusSucceeded, "", nil)
 			case blobReceived:
 				if ns, id := h.extractBlobPath(ctx, msg.Path); id != nil {
-					h.callbacks.BLOBReceived(msg.Sender, ns, *id)
+					hash, err := fftypes.ParseBytes32(ctx, msg.Hash)
+					if err != nil {
+						l.Errorf("Invalid hash '%s' received for BLOB '%s': %s", msg.Hash, msg.Path, err)
+					} else {
+						h.callbacks.BLOBReceived(msg.Sender, *hash, ns, *id)
+					}
 				}
 			default:
 				l.Errorf("Message unexpected: %s", msg.Type)
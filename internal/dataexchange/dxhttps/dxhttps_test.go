@@ -147,6 +147,32 @@ func TestAddPeerError(t *testing.T) {
 	assert.Regexp(t, "FF10229", err)
 }
 
+func TestRemovePeer(t *testing.T) {
+	h, _, _, httpURL, done := newTestHTTPS(t)
+	defer done()
+
+	httpmock.RegisterResponder("DELETE", fmt.Sprintf("%s/api/v1/peers/peer1", httpURL),
+		httpmock.NewJsonResponderOrPanic(204, fftypes.JSONObject{}))
+
+	err := h.RemovePeer(context.Background(), &fftypes.Node{
+		DX: fftypes.DXInfo{Peer: "peer1"},
+	})
+	assert.NoError(t, err)
+}
+
+func TestRemovePeerError(t *testing.T) {
+	h, _, _, httpURL, done := newTestHTTPS(t)
+	defer done()
+
+	httpmock.RegisterResponder("DELETE", fmt.Sprintf("%s/api/v1/peers/peer1", httpURL),
+		httpmock.NewJsonResponderOrPanic(500, fftypes.JSONObject{}))
+
+	err := h.RemovePeer(context.Background(), &fftypes.Node{
+		DX: fftypes.DXInfo{Peer: "peer1"},
+	})
+	assert.Regexp(t, "FF10229", err)
+}
+
 func TestUploadBLOB(t *testing.T) {
 
 	h, _, _, httpURL, done := newTestHTTPS(t)
@@ -318,8 +344,13 @@ func TestEvents(t *testing.T) {
 	msg = <-toServer
 	assert.Equal(t, `{"action":"commit"}`, string(msg))
 
-	mcb.On("BLOBReceived", "peer1", "ns1", mock.Anything).Return()
-	fromServer <- fmt.Sprintf(`{"type":"blob-received","sender":"peer1","path":"ns1/%s"}`, fftypes.NewUUID())
+	fromServer <- fmt.Sprintf(`{"type":"blob-received","sender":"peer1","path":"ns1/%s","hash":"!wrong"}`, fftypes.NewUUID())
+	msg = <-toServer
+	assert.Equal(t, `{"action":"commit"}`, string(msg))
+
+	hash := fftypes.NewRandB32()
+	mcb.On("BLOBReceived", "peer1", *hash, "ns1", mock.Anything).Return()
+	fromServer <- fmt.Sprintf(`{"type":"blob-received","sender":"peer1","path":"ns1/%s","hash":"%s"}`, fftypes.NewUUID(), hash)
 	msg = <-toServer
 	assert.Equal(t, `{"action":"commit"}`, string(msg))
 
@@ -21,15 +21,18 @@ import (
 	"crypto/sha256"
 	"database/sql/driver"
 	"encoding/binary"
+	"time"
 
 	"github.com/hyperledger-labs/firefly/internal/broadcast"
 	"github.com/hyperledger-labs/firefly/internal/config"
 	"github.com/hyperledger-labs/firefly/internal/data"
 	"github.com/hyperledger-labs/firefly/internal/log"
+	"github.com/hyperledger-labs/firefly/internal/metrics"
 	"github.com/hyperledger-labs/firefly/internal/privatemessaging"
 	"github.com/hyperledger-labs/firefly/internal/retry"
 	"github.com/hyperledger-labs/firefly/pkg/database"
 	"github.com/hyperledger-labs/firefly/pkg/fftypes"
+	"github.com/karlseguin/ccache"
 )
 
 const (
@@ -47,6 +50,9 @@ type aggregator struct {
 	offchainBatches chan *fftypes.UUID
 	queuedRewinds   chan *fftypes.UUID
 	retry           *retry.Retry
+	acceptUnsigned  bool
+	pinCache        *ccache.Cache
+	pinCacheTTL     time.Duration
 }
 
 func newAggregator(ctx context.Context, di database.Plugin, bm broadcast.Manager, pm privatemessaging.Manager, dm data.Manager, en *eventNotifier) *aggregator {
@@ -60,6 +66,13 @@ func newAggregator(ctx context.Context, di database.Plugin, bm broadcast.Manager
 		newPins:         make(chan int64),
 		offchainBatches: make(chan *fftypes.UUID, 1), // hops to queuedRewinds with a shouldertab on the event poller
 		queuedRewinds:   make(chan *fftypes.UUID, batchSize),
+		acceptUnsigned:  config.GetBool(config.EventAggregatorAcceptUnsigned),
+		pinCache: ccache.New(
+			// We use a LRU cache with a size-aware max
+			ccache.Configure().
+				MaxSize(config.GetByteSize(config.EventAggregatorPinCacheSize)),
+		),
+		pinCacheTTL: config.GetDuration(config.EventAggregatorPinCacheTTL),
 	}
 	firstEvent := fftypes.SubOptsFirstEvent(config.GetString(config.EventAggregatorFirstEvent))
 	ag.eventPoller = newEventPoller(ctx, di, en, &eventPollerConf{
@@ -139,6 +152,45 @@ func (ag *aggregator) rewindOffchainBatches() (rewind bool, offset int64) {
 	return rewind, offset
 }
 
+// rewindGroupMessages finds any messages on a group that arrived before the group was ready to use, and
+// queues a rewind to the batches that contain them. For example messages sent without a blockchain transaction
+// that arrive before the group is confirmed, or messages on a successor group that arrive before the handover.
+func (ag *aggregator) rewindGroupMessages(ctx context.Context, group *fftypes.Bytes32) error {
+	fb := database.MessageQueryFactory.NewFilter(ctx)
+	filter := fb.And(
+		fb.Eq("group", group),
+		fb.Eq("confirmed", nil),
+	)
+	msgs, _, err := ag.database.GetMessages(ctx, filter)
+	if err != nil {
+		return err
+	}
+	var batchIDs []*fftypes.UUID
+	queued := make(map[fftypes.UUID]bool)
+	for _, msg := range msgs {
+		if msg.BatchID != nil && !queued[*msg.BatchID] {
+			queued[*msg.BatchID] = true
+			batchIDs = append(batchIDs, msg.BatchID)
+		}
+	}
+	if len(batchIDs) > 0 {
+		log.L(ctx).Debugf("Rewinding for messages on group %s. Batches: %v", group, batchIDs)
+		// We are on the event poller routine, so must not block waiting for it to drain the rewinds
+		go ag.queueRewinds(batchIDs)
+	}
+	return nil
+}
+
+func (ag *aggregator) queueRewinds(batchIDs []*fftypes.UUID) {
+	for _, batchID := range batchIDs {
+		select {
+		case ag.offchainBatches <- batchID:
+		case <-ag.ctx.Done():
+			return
+		}
+	}
+}
+
 func (ag *aggregator) processPinsDBGroup(items []fftypes.LocallySequenced) (repoll bool, err error) {
 	pins := make([]*fftypes.Pin, len(items))
 	for i, item := range items {
@@ -148,6 +200,7 @@ func (ag *aggregator) processPinsDBGroup(items []fftypes.LocallySequenced) (repo
 		err = ag.processPins(ctx, pins)
 		return err
 	})
+	metrics.AggregatorPinBacklog(ag.eventPoller.backlog())
 	return false, err
 }
 
@@ -207,7 +260,7 @@ func (ag *aggregator) processPins(ctx context.Context, pins []*fftypes.Pin) (err
 		dupMsgCheck[*msg.Header.ID] = true
 
 		// Attempt to process the message (only returns errors for database persistence issues)
-		if err = ag.processMessage(ctx, batch, pin.Masked, pin.Sequence, msg); err != nil {
+		if err = ag.processMessage(ctx, batch, pin, msg); err != nil {
 			return err
 		}
 	}
@@ -227,8 +280,10 @@ func (ag *aggregator) calcHash(topic string, groupID *fftypes.Bytes32, identity
 	return fftypes.HashResult(h)
 }
 
-func (ag *aggregator) processMessage(ctx context.Context, batch *fftypes.Batch, masked bool, pinnedSequence int64, msg *fftypes.Message) (err error) {
+func (ag *aggregator) processMessage(ctx context.Context, batch *fftypes.Batch, pin *fftypes.Pin, msg *fftypes.Message) (err error) {
 	l := log.L(ctx)
+	masked := pin.Masked
+	pinnedSequence := pin.Sequence
 
 	// Check if it's ready to be processed
 	nextPins := make([]*fftypes.NextPin, len(msg.Pins))
@@ -240,29 +295,42 @@ func (ag *aggregator) processMessage(ctx context.Context, batch *fftypes.Batch,
 			return nil
 		}
 		for i, pinStr := range msg.Pins {
-			var pin fftypes.Bytes32
-			err := pin.UnmarshalText([]byte(pinStr))
+			var msgPin fftypes.Bytes32
+			err := msgPin.UnmarshalText([]byte(pinStr))
 			if err != nil {
 				log.L(ctx).Errorf("Message '%s' in batch '%s' has invalid pin at index %d: '%s'", msg.Header.ID, batch.ID, i, pinStr)
 				return nil
 			}
-			nextPin, err := ag.checkMaskedContextReady(ctx, msg, msg.Header.Topics[i], pinnedSequence, &pin)
+			nextPin, err := ag.checkMaskedContextReady(ctx, msg, msg.Header.Topics[i], pinnedSequence, &msgPin)
 			if err != nil || nextPin == nil {
 				return err
 			}
 			nextPins[i] = nextPin
 		}
 	} else {
-		// We just need to check there's no earlier sequences with the same unmasked context
-		unmaskedContexts := make([]driver.Value, len(msg.Header.Topics))
-		for i, topic := range msg.Header.Topics {
-			h := sha256.New()
-			h.Write([]byte(topic))
-			unmaskedContexts[i] = fftypes.HashResult(h)
+		// We just need to check there's no earlier sequences with the same unmasked context on the same ledger.
+		// Masked contexts do not need this, as the group hash (and hence the context) includes the ledger.
+		var unmaskedContexts []driver.Value
+		if batch.Payload.TX.Type == fftypes.TransactionTypeNone {
+			// Private messages sent without a blockchain transaction are pinned locally as they arrive,
+			// with a single context for each sender in the group - so are confirmed in arrival order
+			ready, err := ag.checkUnpinnedGroupReady(ctx, msg)
+			if err != nil || !ready {
+				return err
+			}
+			unmaskedContexts = []driver.Value{pin.Hash}
+		} else {
+			unmaskedContexts = make([]driver.Value, len(msg.Header.Topics))
+			for i, topic := range msg.Header.Topics {
+				h := sha256.New()
+				h.Write([]byte(topic))
+				unmaskedContexts[i] = fftypes.HashResult(h)
+			}
 		}
 		fb := database.PinQueryFactory.NewFilter(ctx)
 		filter := fb.And(
 			fb.Eq("dispatched", false),
+			fb.Eq("ledger", pin.Ledger),
 			fb.In("hash", unmaskedContexts),
 			fb.Lt("sequence", pinnedSequence),
 		)
@@ -276,7 +344,7 @@ func (ag *aggregator) processMessage(ctx context.Context, batch *fftypes.Batch,
 		}
 	}
 
-	dispatched, err := ag.attemptMessageDispatch(ctx, msg)
+	dispatched, err := ag.attemptMessageDispatch(ctx, msg, pinnedSequence)
 	if err != nil || !dispatched {
 		return err
 	}
@@ -339,6 +407,34 @@ func (ag *aggregator) checkMaskedContextReady(ctx context.Context, msg *fftypes.
 	return nextPin, nil
 }
 
+// checkUnpinnedGroupReady checks the group of a message sent without a blockchain transaction is known.
+// There is no on-chain pin to initialize the group before the message arrives, as there is for pinned messages.
+func (ag *aggregator) checkUnpinnedGroupReady(ctx context.Context, msg *fftypes.Message) (bool, error) {
+	group, err := ag.database.GetGroupByHash(ctx, msg.Header.Group)
+	if err != nil {
+		return false, err
+	}
+	if group == nil {
+		log.L(ctx).Debugf("Group %s not available for unpinned message %s - message is parked", msg.Header.Group, msg.Header.ID)
+		return false, nil
+	}
+	return true, nil
+}
+
+// checkUnpinnedSenderMember checks the author of a message sent without a blockchain transaction is a member
+// of its group. There are no masked pins to prove this, as there are for pinned messages.
+func (ag *aggregator) checkUnpinnedSenderMember(ctx context.Context, msg *fftypes.Message) (bool, error) {
+	group, err := ag.database.GetGroupByHash(ctx, msg.Header.Group)
+	if err != nil {
+		return false, err
+	}
+	if group == nil || !group.IsMember(msg.Header.Author) {
+		log.L(ctx).Errorf("Author '%s' of unpinned message %s is not a member of group %s", msg.Header.Author, msg.Header.ID, msg.Header.Group)
+		return false, nil
+	}
+	return true, nil
+}
+
 func (ag *aggregator) attemptContextInit(ctx context.Context, msg *fftypes.Message, topic string, pinnedSequence int64, contextUnmasked, pin *fftypes.Bytes32) (*fftypes.NextPin, error) {
 	l := log.L(ctx)
 
@@ -402,7 +498,7 @@ func (ag *aggregator) attemptContextInit(ctx context.Context, msg *fftypes.Messa
 	return nextPin, err
 }
 
-func (ag *aggregator) attemptMessageDispatch(ctx context.Context, msg *fftypes.Message) (bool, error) {
+func (ag *aggregator) attemptMessageDispatch(ctx context.Context, msg *fftypes.Message, pinnedSequence int64) (bool, error) {
 
 	// If we don't find all the data, then we don't dispatch
 	data, foundAll, err := ag.data.GetMessageData(ctx, msg, true)
@@ -410,10 +506,39 @@ func (ag *aggregator) attemptMessageDispatch(ctx context.Context, msg *fftypes.M
 		return false, err
 	}
 
+	// We also need all the blobs to be available in the local data exchange, with the correct hashes
+	blobsReady, valid, err := ag.resolveBlobs(ctx, data, msg.Header.Group != nil)
+	if err != nil || !blobsReady {
+		return false, err
+	}
+
+	// The signature must verify against the keys published by the author, before we trust anything in the message
+	if valid {
+		if valid, err = ag.verifySignature(ctx, msg, pinnedSequence); err != nil {
+			return false, err
+		}
+	}
+
+	// A message sent without a blockchain transaction must be from a member of the group
+	unpinned := msg.Header.Group != nil && msg.Header.TxType == fftypes.TransactionTypeNone
+	if valid && unpinned {
+		if valid, err = ag.checkUnpinnedSenderMember(ctx, msg); err != nil {
+			return false, err
+		}
+	}
+
+	// A message to a group that was sequenced after the group was retired is not confirmed on it
+	if valid && msg.Header.Group != nil && msg.Header.Type != fftypes.MessageTypeGroupInit {
+		if valid, err = ag.checkGroupNotRetired(ctx, msg, pinnedSequence); err != nil {
+			return false, err
+		}
+	}
+
 	// We're going to dispatch it at this point, but we need to validate the data first
-	valid := true
 	eventType := fftypes.EventTypeMessageConfirmed
 	switch {
+	case !valid:
+		// Already found to be invalid
 	case msg.Header.Namespace == fftypes.SystemNamespace:
 		// We handle system events in-line on the aggregator, as it would be confusing for apps to be
 		// dispatched subsequent events before we have processed the system events they depend on.
@@ -425,6 +550,22 @@ func (ag *aggregator) attemptMessageDispatch(ctx context.Context, msg *fftypes.M
 		// Already handled as part of resolving the context.
 		valid = true
 		eventType = fftypes.EventTypeGroupConfirmed
+		if err = ag.rewindGroupMessages(ctx, msg.Header.Group); err != nil {
+			return false, err
+		}
+	case msg.Header.Type == fftypes.MessageTypeGroupUpdate:
+		// Retires the group, and hands over to its successor
+		var successor *fftypes.Group
+		if successor, err = ag.messaging.HandleGroupUpdate(ctx, msg, data); err != nil {
+			return false, err
+		}
+		valid = successor != nil
+		if valid {
+			eventType = fftypes.EventTypeGroupUpdated
+			if err = ag.rewindGroupMessages(ctx, successor.Hash); err != nil {
+				return false, err
+			}
+		}
 	case len(msg.Data) > 0:
 		valid, err = ag.data.ValidateAll(ctx, data)
 		if err != nil {
@@ -446,6 +587,7 @@ func (ag *aggregator) attemptMessageDispatch(ctx context.Context, msg *fftypes.M
 
 	// Generate the appropriate event
 	event := fftypes.NewEvent(eventType, msg.Header.Namespace, msg.Header.ID, msg.Header.Group)
+	event.Unpinned = unpinned
 	if err = ag.database.UpsertEvent(ctx, event, false); err != nil {
 		return false, err
 	}
@@ -453,3 +595,200 @@ func (ag *aggregator) attemptMessageDispatch(ctx context.Context, msg *fftypes.M
 
 	return true, nil
 }
+
+// verifySignature checks the signature on a message against the public keys of the organization that authored it,
+// as they were at the point the message was sequenced. Only registered organizations can author messages, with the
+// exception of a root organization registering itself - which is verified against the keys it is publishing,
+// when the registration is handled.
+func (ag *aggregator) verifySignature(ctx context.Context, msg *fftypes.Message, pinnedSequence int64) (bool, error) {
+	l := log.L(ctx)
+	org, err := ag.getOrganizationAtSequence(ctx, msg.Header.Author, pinnedSequence)
+	if err != nil {
+		return false, err
+	}
+	if org == nil {
+		if msg.Header.Namespace == fftypes.SystemNamespace && msg.Header.Tag == string(fftypes.SystemTagDefineOrganization) {
+			l.Debugf("Author '%s' of message %s is registering an organization - signature verified on registration", msg.Header.Author, msg.Header.ID)
+			return true, nil
+		}
+		l.Errorf("Author '%s' of message %s is not a registered organization", msg.Header.Author, msg.Header.ID)
+		return false, nil
+	}
+	if org.Revoked != nil {
+		l.Errorf("Author '%s' of message %s is a revoked organization", msg.Header.Author, msg.Header.ID)
+		return false, nil
+	}
+	if len(org.PublicKeys) == 0 && !ag.acceptUnsigned {
+		// An organization that has not published keys can only send unsigned messages, which can be disabled.
+		// It adopts signing by publishing keys in an update, which applies to the messages sequenced after it.
+		l.Errorf("Author '%s' of message %s has not published keys, and unsigned messages are not accepted", msg.Header.Author, msg.Header.ID)
+		return false, nil
+	}
+	// An organization that has not published keys must send unsigned messages, as any signature cannot be verified.
+	// One that has published keys must sign its messages with one of them.
+	if err = msg.VerifySignature(ctx, org.PublicKeys); err != nil {
+		l.Errorf("Message %s has an invalid signature: %s", msg.Header.ID, err)
+		return false, nil
+	}
+	return true, nil
+}
+
+// getOrganizationAtSequence finds the version of the organization with an identity that was in force at a pin sequence.
+// Each version comes into force at the pin of the message that established it, so messages are verified against
+// the same keys regardless of the order in which they become ready for dispatch.
+func (ag *aggregator) getOrganizationAtSequence(ctx context.Context, identity string, pinnedSequence int64) (*fftypes.Organization, error) {
+	fb := database.OrganizationQueryFactory.NewFilter(ctx)
+	versions, _, err := ag.database.GetOrganizationVersions(ctx, fb.And(fb.Eq("identity", identity)).Sort("version").Descending())
+	if err != nil {
+		return nil, err
+	}
+	for _, version := range versions {
+		sequence, err := ag.getMessagePinSequence(ctx, version.Message)
+		if err != nil {
+			return nil, err
+		}
+		if sequence < pinnedSequence {
+			return ag.checkIdentityRetired(ctx, version, pinnedSequence)
+		}
+	}
+	return nil, nil
+}
+
+// checkIdentityRetired looks for a later version of the organization, in force at the pin sequence, that moved it to
+// a new identity. The identity it moved from is retired, so is treated as revoked from that point.
+func (ag *aggregator) checkIdentityRetired(ctx context.Context, version *fftypes.Organization, pinnedSequence int64) (*fftypes.Organization, error) {
+	fb := database.OrganizationQueryFactory.NewFilter(ctx)
+	later, _, err := ag.database.GetOrganizationVersions(ctx, fb.And(fb.Eq("id", version.ID), fb.Gt("version", version.Version)).Sort("version"))
+	if err != nil {
+		return nil, err
+	}
+	for _, next := range later {
+		sequence, err := ag.getMessagePinSequence(ctx, next.Message)
+		if err != nil {
+			return nil, err
+		}
+		if sequence >= pinnedSequence {
+			break
+		}
+		if next.Identity != version.Identity {
+			retired := *version
+			retired.Revoked = next.Updated
+			if retired.Revoked == nil {
+				retired.Revoked = fftypes.Now()
+			}
+			return &retired, nil
+		}
+	}
+	return version, nil
+}
+
+// getMessagePinSequence returns the sequence of the pin for a message, which is the index of its first topic across
+// all messages in the batch. A message that cannot be found is treated as sequenced before any other.
+func (ag *aggregator) getMessagePinSequence(ctx context.Context, msgID *fftypes.UUID) (int64, error) {
+	if msgID == nil {
+		return -1, nil
+	}
+	if cached := ag.pinCache.Get(msgID.String()); cached != nil {
+		cached.Extend(ag.pinCacheTTL)
+		return cached.Value().(int64), nil
+	}
+	msg, err := ag.database.GetMessageByID(ctx, msgID)
+	if err != nil || msg == nil || msg.BatchID == nil {
+		return -1, err
+	}
+	batch, err := ag.database.GetBatchByID(ctx, msg.BatchID)
+	if err != nil || batch == nil {
+		return -1, err
+	}
+	var index int64
+	for _, m := range batch.Payload.Messages {
+		if m == nil {
+			continue
+		}
+		if m.Header.ID.Equals(msgID) {
+			break
+		}
+		index += int64(len(m.Header.Topics))
+	}
+	fb := database.PinQueryFactory.NewFilter(ctx)
+	pins, err := ag.database.GetPins(ctx, fb.And(fb.Eq("batch", batch.ID), fb.Eq("index", index)))
+	if err != nil || len(pins) == 0 {
+		return -1, err
+	}
+	ag.pinCache.Set(msgID.String(), pins[0].Sequence, ag.pinCacheTTL)
+	return pins[0].Sequence, nil
+}
+
+// checkGroupNotRetired checks a message to a group was sequenced before the update that retired the group, if there is one.
+// The update is the handover point on each of its topics, so later messages belong to the successor.
+func (ag *aggregator) checkGroupNotRetired(ctx context.Context, msg *fftypes.Message, pinnedSequence int64) (bool, error) {
+	group, err := ag.database.GetGroupByHash(ctx, msg.Header.Group)
+	if err != nil {
+		return false, err
+	}
+	if group == nil || group.Successor == nil {
+		return true, nil
+	}
+	fb := database.MessageQueryFactory.NewFilter(ctx)
+	updates, _, err := ag.database.GetMessages(ctx, fb.And(
+		fb.Eq("group", group.Hash),
+		fb.Eq("type", fftypes.MessageTypeGroupUpdate),
+		fb.Gt("confirmed", 0),
+	))
+	if err != nil {
+		return false, err
+	}
+	for _, update := range updates {
+		sequence, err := ag.getMessagePinSequence(ctx, update.Header.ID)
+		if err != nil {
+			return false, err
+		}
+		if sequence < pinnedSequence {
+			log.L(ctx).Errorf("Message %s was sequenced after group %s was retired in favor of %s by update %s", msg.Header.ID, group.Hash, group.Successor, update.Header.ID)
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// resolveBlobs checks that every blob referenced by the data of a message is available in the local data exchange,
+// and that the hash calculated as the blob was stored matches the hash in the data.
+// Private blobs arrive asynchronously via data exchange, so we wait for them. Broadcast blobs are retrieved
+// from public storage before the batch is persisted, so if one is missing it can never arrive.
+func (ag *aggregator) resolveBlobs(ctx context.Context, data []*fftypes.Data, private bool) (ready, valid bool, err error) {
+	l := log.L(ctx)
+
+	for _, d := range data {
+		if !d.Blobstore {
+			continue
+		}
+
+		fb := database.BlobQueryFactory.NewFilter(ctx)
+		blobs, err := ag.database.GetBlobs(ctx, fb.And(fb.Eq("data", d.ID)))
+		if err != nil {
+			return false, false, err
+		}
+		if len(blobs) == 0 {
+			if !private {
+				l.Errorf("Blob for broadcast data %s is not available", d.ID)
+				return true, false, nil
+			}
+			l.Debugf("Blob for data %s not yet available", d.ID)
+			return false, false, nil
+		}
+
+		matched := false
+		for _, blob := range blobs {
+			if blob.Hash.Equals(d.Hash) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			l.Errorf("Blob for data %s has hash %s, which does not match data hash %s", d.ID, blobs[0].Hash, d.Hash)
+			return true, false, nil
+		}
+	}
+
+	return true, true, nil
+}
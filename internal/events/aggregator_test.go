@@ -18,9 +18,14 @@ package events
 
 import (
 	"context"
+	"crypto/ed25519"
+	"crypto/rand"
 	"crypto/sha256"
+	"encoding/base64"
 	"fmt"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/hyperledger-labs/firefly/internal/config"
 	"github.com/hyperledger-labs/firefly/mocks/broadcastmocks"
@@ -38,6 +43,11 @@ func uuidMatches(id1 *fftypes.UUID) interface{} {
 }
 
 func newTestAggregator() (*aggregator, func()) {
+	config.Reset()
+	return newTestAggregatorWithConfig()
+}
+
+func newTestAggregatorWithConfig() (*aggregator, func()) {
 	mdi := &databasemocks.Plugin{}
 	mbm := &broadcastmocks.Manager{}
 	mdm := &datamocks.Manager{}
@@ -68,6 +78,8 @@ func TestAggregationMaskedZeroNonceMatch(t *testing.T) {
 	member2NonceOne := ag.calcHash(topic, groupID, member2, 1)
 
 	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetGroupByHash", ag.ctx, mock.Anything).Return(&fftypes.Group{}, nil)
+	mdi.On("GetOrganizationVersions", ag.ctx, mock.Anything).Return([]*fftypes.Organization{{}}, nil, nil)
 	mdm := ag.data.(*datamocks.Manager)
 	mpm := ag.messaging.(*privatemessagingmocks.Manager)
 
@@ -179,6 +191,8 @@ func TestAggregationMaskedNextSequenceMatch(t *testing.T) {
 	member2Nonce501 := ag.calcHash(topic, groupID, member2, 501)
 
 	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetGroupByHash", ag.ctx, mock.Anything).Return(&fftypes.Group{}, nil)
+	mdi.On("GetOrganizationVersions", ag.ctx, mock.Anything).Return([]*fftypes.Organization{{}}, nil, nil)
 	mdm := ag.data.(*datamocks.Manager)
 
 	// Get the batch
@@ -263,6 +277,7 @@ func TestAggregationBroadcast(t *testing.T) {
 	contextUnmasked := fftypes.HashResult(h)
 
 	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationVersions", ag.ctx, mock.Anything).Return([]*fftypes.Organization{{}}, nil, nil)
 	mdm := ag.data.(*datamocks.Manager)
 
 	// Get the batch
@@ -492,7 +507,7 @@ func TestProcessMsgFailMissingGroup(t *testing.T) {
 	ag, cancel := newTestAggregator()
 	defer cancel()
 
-	err := ag.processMessage(ag.ctx, &fftypes.Batch{}, true, 12345, &fftypes.Message{})
+	err := ag.processMessage(ag.ctx, &fftypes.Batch{}, &fftypes.Pin{Masked: true, Sequence: 12345}, &fftypes.Message{})
 	assert.NoError(t, err)
 
 }
@@ -501,7 +516,7 @@ func TestProcessMsgFailBadPin(t *testing.T) {
 	ag, cancel := newTestAggregator()
 	defer cancel()
 
-	err := ag.processMessage(ag.ctx, &fftypes.Batch{}, true, 12345, &fftypes.Message{
+	err := ag.processMessage(ag.ctx, &fftypes.Batch{}, &fftypes.Pin{Masked: true, Sequence: 12345}, &fftypes.Message{
 		Header: fftypes.MessageHeader{
 			ID:     fftypes.NewUUID(),
 			Group:  fftypes.NewRandB32(),
@@ -520,7 +535,7 @@ func TestProcessMsgFailGetNextPins(t *testing.T) {
 	mdi := ag.database.(*databasemocks.Plugin)
 	mdi.On("GetNextPins", ag.ctx, mock.Anything).Return(nil, fmt.Errorf("pop"))
 
-	err := ag.processMessage(ag.ctx, &fftypes.Batch{}, true, 12345, &fftypes.Message{
+	err := ag.processMessage(ag.ctx, &fftypes.Batch{}, &fftypes.Pin{Masked: true, Sequence: 12345}, &fftypes.Message{
 		Header: fftypes.MessageHeader{
 			ID:     fftypes.NewUUID(),
 			Group:  fftypes.NewRandB32(),
@@ -541,7 +556,7 @@ func TestProcessMsgFailDispatch(t *testing.T) {
 	mdm := ag.data.(*datamocks.Manager)
 	mdm.On("GetMessageData", ag.ctx, mock.Anything, true).Return(nil, false, fmt.Errorf("pop"))
 
-	err := ag.processMessage(ag.ctx, &fftypes.Batch{}, false, 12345, &fftypes.Message{
+	err := ag.processMessage(ag.ctx, &fftypes.Batch{}, &fftypes.Pin{Masked: false, Sequence: 12345}, &fftypes.Message{
 		Header: fftypes.MessageHeader{
 			ID:     fftypes.NewUUID(),
 			Topics: fftypes.FFNameArray{"topic1"},
@@ -552,12 +567,196 @@ func TestProcessMsgFailDispatch(t *testing.T) {
 
 }
 
+func TestProcessMsgUnmaskedBlockedOnLedger(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	defer cancel()
+
+	ledgerID := fftypes.NewUUID()
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetPins", ag.ctx, mock.MatchedBy(func(filter database.Filter) bool {
+		f, _ := filter.Finalize()
+		return strings.Contains(f.String(), fmt.Sprintf("ledger == '%s'", ledgerID))
+	})).Return([]*fftypes.Pin{
+		{Sequence: 12344, Ledger: ledgerID},
+	}, nil)
+
+	err := ag.processMessage(ag.ctx, &fftypes.Batch{}, &fftypes.Pin{Ledger: ledgerID, Sequence: 12345}, &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			ID:     fftypes.NewUUID(),
+			Topics: fftypes.FFNameArray{"topic1"},
+		},
+	})
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestProcessMsgUnpinnedBlockedOnSender(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	defer cancel()
+
+	senderContext := fftypes.NewRandB32()
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetGroupByHash", ag.ctx, mock.Anything).Return(&fftypes.Group{
+		GroupIdentity: fftypes.GroupIdentity{
+			Members: fftypes.Members{{Identity: "author1"}},
+		},
+	}, nil)
+	mdi.On("GetPins", ag.ctx, mock.MatchedBy(func(filter database.Filter) bool {
+		f, _ := filter.Finalize()
+		return strings.Contains(f.String(), senderContext.String())
+	})).Return([]*fftypes.Pin{
+		{Sequence: 12344, Hash: senderContext},
+	}, nil)
+
+	batch := &fftypes.Batch{}
+	batch.Payload.TX.Type = fftypes.TransactionTypeNone
+	err := ag.processMessage(ag.ctx, batch, &fftypes.Pin{Hash: senderContext, Sequence: 12345}, &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			ID:     fftypes.NewUUID(),
+			Group:  fftypes.NewRandB32(),
+			Author: "author1",
+			TxType: fftypes.TransactionTypeNone,
+			Topics: fftypes.FFNameArray{"topic1", "topic2"},
+		},
+	})
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestProcessMsgUnpinnedGroupFail(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	defer cancel()
+
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetGroupByHash", ag.ctx, mock.Anything).Return(nil, fmt.Errorf("pop"))
+
+	batch := &fftypes.Batch{}
+	batch.Payload.TX.Type = fftypes.TransactionTypeNone
+	err := ag.processMessage(ag.ctx, batch, &fftypes.Pin{Hash: fftypes.NewRandB32(), Sequence: 12345}, &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			ID:     fftypes.NewUUID(),
+			Group:  fftypes.NewRandB32(),
+			Topics: fftypes.FFNameArray{"topic1"},
+		},
+	})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestCheckUnpinnedGroupReadyNoGroup(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	defer cancel()
+
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetGroupByHash", ag.ctx, mock.Anything).Return(nil, nil)
+
+	ready, err := ag.checkUnpinnedGroupReady(ag.ctx, &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			ID:    fftypes.NewUUID(),
+			Group: fftypes.NewRandB32(),
+		},
+	})
+	assert.NoError(t, err)
+	assert.False(t, ready)
+}
+
+func TestAttemptMessageDispatchUnpinnedGroupFail(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	defer cancel()
+
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdm := ag.data.(*datamocks.Manager)
+	mdm.On("GetMessageData", ag.ctx, mock.Anything, true).Return([]*fftypes.Data{}, true, nil)
+	mdi.On("GetOrganizationVersions", ag.ctx, mock.Anything).Return([]*fftypes.Organization{{Identity: "author1"}}, nil, nil).Once()
+	mdi.On("GetOrganizationVersions", ag.ctx, mock.Anything).Return([]*fftypes.Organization{}, nil, nil)
+	mdi.On("GetGroupByHash", ag.ctx, mock.Anything).Return(nil, fmt.Errorf("pop"))
+
+	_, err := ag.attemptMessageDispatch(ag.ctx, &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			ID:     fftypes.NewUUID(),
+			Group:  fftypes.NewRandB32(),
+			Author: "author1",
+			TxType: fftypes.TransactionTypeNone,
+		},
+	}, 12345)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestAttemptMessageDispatchUnpinnedNotMember(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	defer cancel()
+
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdm := ag.data.(*datamocks.Manager)
+	mdm.On("GetMessageData", ag.ctx, mock.Anything, true).Return([]*fftypes.Data{}, true, nil)
+	mdi.On("GetOrganizationVersions", ag.ctx, mock.Anything).Return([]*fftypes.Organization{{Identity: "author2"}}, nil, nil).Once()
+	mdi.On("GetOrganizationVersions", ag.ctx, mock.Anything).Return([]*fftypes.Organization{}, nil, nil)
+	mdi.On("GetGroupByHash", ag.ctx, mock.Anything).Return(&fftypes.Group{
+		GroupIdentity: fftypes.GroupIdentity{
+			Members: fftypes.Members{{Identity: "author1"}},
+		},
+	}, nil)
+	mdi.On("UpsertEvent", ag.ctx, mock.MatchedBy(func(event *fftypes.Event) bool {
+		return event.Type == fftypes.EventTypeMessageInvalid && event.Unpinned
+	}), false).Return(nil)
+
+	dispatched, err := ag.attemptMessageDispatch(ag.ctx, &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			ID:     fftypes.NewUUID(),
+			Group:  fftypes.NewRandB32(),
+			Author: "author2",
+			TxType: fftypes.TransactionTypeNone,
+		},
+	}, 12345)
+	assert.NoError(t, err)
+	assert.True(t, dispatched)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestAttemptMessageDispatchUnpinnedConfirmed(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	defer cancel()
+
+	org := &fftypes.Organization{Identity: "author1"}
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdm := ag.data.(*datamocks.Manager)
+	mdm.On("GetMessageData", ag.ctx, mock.Anything, true).Return([]*fftypes.Data{}, true, nil)
+	mdi.On("GetOrganizationVersions", ag.ctx, mock.Anything).Return([]*fftypes.Organization{org}, nil, nil).Once()
+	mdi.On("GetOrganizationVersions", ag.ctx, mock.Anything).Return([]*fftypes.Organization{}, nil, nil)
+	mdi.On("GetGroupByHash", ag.ctx, mock.Anything).Return(&fftypes.Group{
+		GroupIdentity: fftypes.GroupIdentity{
+			Members: fftypes.Members{{Identity: "author1"}},
+		},
+	}, nil)
+	mdi.On("UpdateMessage", ag.ctx, mock.Anything, mock.Anything).Return(nil)
+	mdi.On("UpsertEvent", ag.ctx, mock.MatchedBy(func(event *fftypes.Event) bool {
+		return event.Type == fftypes.EventTypeMessageConfirmed && event.Unpinned
+	}), false).Return(nil)
+
+	dispatched, err := ag.attemptMessageDispatch(ag.ctx, &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			ID:     fftypes.NewUUID(),
+			Group:  fftypes.NewRandB32(),
+			Author: "author1",
+			TxType: fftypes.TransactionTypeNone,
+		},
+	}, 12345)
+	assert.NoError(t, err)
+	assert.True(t, dispatched)
+
+	mdi.AssertExpectations(t)
+}
+
 func TestProcessMsgFailPinUpdate(t *testing.T) {
 	ag, cancel := newTestAggregator()
 	defer cancel()
 	pin := fftypes.NewRandB32()
 
 	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetGroupByHash", ag.ctx, mock.Anything).Return(&fftypes.Group{}, nil)
+	mdi.On("GetOrganizationVersions", ag.ctx, mock.Anything).Return([]*fftypes.Organization{{}}, nil, nil)
 	mdm := ag.data.(*datamocks.Manager)
 	mdi.On("GetNextPins", ag.ctx, mock.Anything).Return([]*fftypes.NextPin{
 		{Context: fftypes.NewRandB32(), Hash: pin},
@@ -568,7 +767,7 @@ func TestProcessMsgFailPinUpdate(t *testing.T) {
 	mdi.On("UpdateMessage", ag.ctx, mock.Anything, mock.Anything).Return(nil)
 	mdi.On("UpdateNextPin", ag.ctx, mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
 
-	err := ag.processMessage(ag.ctx, &fftypes.Batch{}, true, 12345, &fftypes.Message{
+	err := ag.processMessage(ag.ctx, &fftypes.Batch{}, &fftypes.Pin{Masked: true, Sequence: 12345}, &fftypes.Message{
 		Header: fftypes.MessageHeader{
 			ID:     fftypes.NewUUID(),
 			Group:  fftypes.NewRandB32(),
@@ -786,7 +985,7 @@ func TestAttemptMessageDispatchFailGetData(t *testing.T) {
 
 	_, err := ag.attemptMessageDispatch(ag.ctx, &fftypes.Message{
 		Header: fftypes.MessageHeader{ID: fftypes.NewUUID()},
-	})
+	}, 0)
 	assert.EqualError(t, err, "pop")
 
 }
@@ -795,6 +994,9 @@ func TestAttemptMessageDispatchFailValidateData(t *testing.T) {
 	ag, cancel := newTestAggregator()
 	defer cancel()
 
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationVersions", ag.ctx, mock.Anything).Return([]*fftypes.Organization{{}}, nil, nil)
+
 	mdm := ag.data.(*datamocks.Manager)
 	mdm.On("GetMessageData", ag.ctx, mock.Anything, true).Return([]*fftypes.Data{}, true, nil)
 	mdm.On("ValidateAll", ag.ctx, mock.Anything).Return(false, fmt.Errorf("pop"))
@@ -804,7 +1006,7 @@ func TestAttemptMessageDispatchFailValidateData(t *testing.T) {
 		Data: fftypes.DataRefs{
 			{ID: fftypes.NewUUID()},
 		},
-	})
+	}, 0)
 	assert.EqualError(t, err, "pop")
 
 }
@@ -820,6 +1022,7 @@ func TestAttemptMessageDispatchFailValidateBadSystem(t *testing.T) {
 	mdm.On("GetMessageData", ag.ctx, mock.Anything, true).Return([]*fftypes.Data{}, true, nil)
 
 	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationVersions", ag.ctx, mock.Anything).Return([]*fftypes.Organization{{}}, nil, nil)
 	mdi.On("UpsertEvent", ag.ctx, mock.Anything, false).Return(nil)
 
 	_, err := ag.attemptMessageDispatch(ag.ctx, &fftypes.Message{
@@ -830,7 +1033,7 @@ func TestAttemptMessageDispatchFailValidateBadSystem(t *testing.T) {
 		Data: fftypes.DataRefs{
 			{ID: fftypes.NewUUID()},
 		},
-	})
+	}, 0)
 	assert.NoError(t, err)
 
 }
@@ -839,6 +1042,9 @@ func TestAttemptMessageDispatchFailValidateSystemFail(t *testing.T) {
 	ag, cancel := newTestAggregator()
 	defer cancel()
 
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationVersions", ag.ctx, mock.Anything).Return([]*fftypes.Organization{{}}, nil, nil)
+
 	mbm := ag.broadcast.(*broadcastmocks.Manager)
 	mbm.On("HandleSystemBroadcast", mock.Anything, mock.Anything, mock.Anything).Return(false, fmt.Errorf("pop"))
 
@@ -853,7 +1059,7 @@ func TestAttemptMessageDispatchFailValidateSystemFail(t *testing.T) {
 		Data: fftypes.DataRefs{
 			{ID: fftypes.NewUUID()},
 		},
-	})
+	}, 0)
 	assert.EqualError(t, err, "pop")
 
 }
@@ -863,6 +1069,7 @@ func TestAttemptMessageDispatchEventFail(t *testing.T) {
 	defer cancel()
 
 	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationVersions", ag.ctx, mock.Anything).Return([]*fftypes.Organization{{}}, nil, nil)
 	mdm := ag.data.(*datamocks.Manager)
 	mdm.On("GetMessageData", ag.ctx, mock.Anything, true).Return([]*fftypes.Data{}, true, nil)
 	mdm.On("ValidateAll", ag.ctx, mock.Anything).Return(true, nil)
@@ -871,114 +1078,901 @@ func TestAttemptMessageDispatchEventFail(t *testing.T) {
 
 	_, err := ag.attemptMessageDispatch(ag.ctx, &fftypes.Message{
 		Header: fftypes.MessageHeader{ID: fftypes.NewUUID()},
-	})
+	}, 0)
 	assert.EqualError(t, err, "pop")
 
 }
 
-func TestAttemptMessageDispatchGroupInit(t *testing.T) {
+func TestAttemptMessageDispatchMissingBlobs(t *testing.T) {
 	ag, cancel := newTestAggregator()
 	defer cancel()
 
+	dataID := fftypes.NewUUID()
 	mdi := ag.database.(*databasemocks.Plugin)
 	mdm := ag.data.(*datamocks.Manager)
-	mdm.On("GetMessageData", ag.ctx, mock.Anything, true).Return([]*fftypes.Data{}, true, nil)
-	mdm.On("ValidateAll", ag.ctx, mock.Anything).Return(true, nil)
-	mdi.On("UpdateMessage", ag.ctx, mock.Anything, mock.Anything).Return(nil)
-	mdi.On("UpsertEvent", ag.ctx, mock.Anything, false).Return(nil)
+	mdm.On("GetMessageData", ag.ctx, mock.Anything, true).Return([]*fftypes.Data{
+		{ID: fftypes.NewUUID(), Hash: fftypes.NewRandB32()},
+		{ID: dataID, Hash: fftypes.NewRandB32(), Blobstore: true},
+	}, true, nil)
+	mdi.On("GetBlobs", ag.ctx, mock.Anything).Return([]*fftypes.Blob{}, nil)
 
-	_, err := ag.attemptMessageDispatch(ag.ctx, &fftypes.Message{
+	dispatched, err := ag.attemptMessageDispatch(ag.ctx, &fftypes.Message{
 		Header: fftypes.MessageHeader{
-			ID:   fftypes.NewUUID(),
-			Type: fftypes.MessageTypeGroupInit,
+			ID:    fftypes.NewUUID(),
+			Group: fftypes.NewRandB32(),
 		},
-	})
+	}, 0)
 	assert.NoError(t, err)
+	assert.False(t, dispatched)
 
 }
 
-func TestAttemptMessageUpdateMessageFail(t *testing.T) {
+func TestAttemptMessageDispatchMissingBroadcastBlobs(t *testing.T) {
 	ag, cancel := newTestAggregator()
 	defer cancel()
 
 	mdi := ag.database.(*databasemocks.Plugin)
 	mdm := ag.data.(*datamocks.Manager)
-	mdm.On("GetMessageData", ag.ctx, mock.Anything, true).Return([]*fftypes.Data{}, true, nil)
-	mdm.On("ValidateAll", ag.ctx, mock.Anything).Return(true, nil)
-	mdi.On("UpdateMessage", ag.ctx, mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+	mdm.On("GetMessageData", ag.ctx, mock.Anything, true).Return([]*fftypes.Data{
+		{ID: fftypes.NewUUID(), Hash: fftypes.NewRandB32(), Blobstore: true},
+	}, true, nil)
+	mdi.On("GetBlobs", ag.ctx, mock.Anything).Return([]*fftypes.Blob{}, nil)
+	mdi.On("UpsertEvent", ag.ctx, mock.MatchedBy(func(event *fftypes.Event) bool {
+		return event.Type == fftypes.EventTypeMessageInvalid
+	}), false).Return(nil)
 
-	_, err := ag.attemptMessageDispatch(ag.ctx, &fftypes.Message{
-		Header: fftypes.MessageHeader{ID: fftypes.NewUUID()},
-	})
-	assert.EqualError(t, err, "pop")
+	dispatched, err := ag.attemptMessageDispatch(ag.ctx, &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			ID: fftypes.NewUUID(),
+		},
+	}, 0)
+	assert.NoError(t, err)
+	assert.True(t, dispatched)
 
+	mdi.AssertExpectations(t)
 }
 
-func TestRewindOffchainBatchesNoBatches(t *testing.T) {
+func TestAttemptMessageDispatchGetBlobsFail(t *testing.T) {
 	ag, cancel := newTestAggregator()
 	defer cancel()
 
 	mdi := ag.database.(*databasemocks.Plugin)
-	mdi.On("UpdateMessage", ag.ctx, mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+	mdm := ag.data.(*datamocks.Manager)
+	mdm.On("GetMessageData", ag.ctx, mock.Anything, true).Return([]*fftypes.Data{
+		{ID: fftypes.NewUUID(), Hash: fftypes.NewRandB32(), Blobstore: true},
+	}, true, nil)
+	mdi.On("GetBlobs", ag.ctx, mock.Anything).Return(nil, fmt.Errorf("pop"))
 
-	rewind, offset := ag.rewindOffchainBatches()
-	assert.False(t, rewind)
-	assert.Equal(t, int64(0), offset)
-}
+	_, err := ag.attemptMessageDispatch(ag.ctx, &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			ID:    fftypes.NewUUID(),
+			Group: fftypes.NewRandB32(),
+		},
+	}, 0)
+	assert.EqualError(t, err, "pop")
 
-func TestRewindOffchainBatchesBatchesNoRewind(t *testing.T) {
-	config.Set(config.EventAggregatorBatchSize, 10)
+}
 
+func TestAttemptMessageDispatchBlobHashMismatch(t *testing.T) {
 	ag, cancel := newTestAggregator()
 	defer cancel()
-	go ag.offchainListener()
-
-	ag.offchainBatches <- fftypes.NewUUID()
-	ag.offchainBatches <- fftypes.NewUUID()
-	ag.offchainBatches <- fftypes.NewUUID()
-	ag.offchainBatches <- fftypes.NewUUID()
 
 	mdi := ag.database.(*databasemocks.Plugin)
-	mdi.On("GetPins", ag.ctx, mock.Anything, mock.Anything).Return([]*fftypes.Pin{}, nil)
+	mdm := ag.data.(*datamocks.Manager)
+	mdm.On("GetMessageData", ag.ctx, mock.Anything, true).Return([]*fftypes.Data{
+		{ID: fftypes.NewUUID(), Hash: fftypes.NewRandB32(), Blobstore: true},
+	}, true, nil)
+	mdi.On("GetBlobs", ag.ctx, mock.Anything).Return([]*fftypes.Blob{
+		{Hash: fftypes.NewRandB32()},
+	}, nil)
+	mdi.On("UpsertEvent", ag.ctx, mock.MatchedBy(func(event *fftypes.Event) bool {
+		return event.Type == fftypes.EventTypeMessageInvalid
+	}), false).Return(nil)
 
-	rewind, offset := ag.rewindOffchainBatches()
-	assert.False(t, rewind)
-	assert.Equal(t, int64(0), offset)
-}
+	dispatched, err := ag.attemptMessageDispatch(ag.ctx, &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			ID:    fftypes.NewUUID(),
+			Group: fftypes.NewRandB32(),
+		},
+		Data: fftypes.DataRefs{
+			{ID: fftypes.NewUUID()},
+		},
+	}, 0)
+	assert.NoError(t, err)
+	assert.True(t, dispatched)
 
-func TestRewindOffchainBatchesBatchesRewind(t *testing.T) {
-	config.Set(config.EventAggregatorBatchSize, 10)
+	mdi.AssertExpectations(t)
+}
 
+func TestAttemptMessageDispatchBlobsOk(t *testing.T) {
 	ag, cancel := newTestAggregator()
 	defer cancel()
-	go ag.offchainListener()
-
-	ag.offchainBatches <- fftypes.NewUUID()
-	ag.offchainBatches <- fftypes.NewUUID()
-	ag.offchainBatches <- fftypes.NewUUID()
-	ag.offchainBatches <- fftypes.NewUUID()
 
+	hash := fftypes.NewRandB32()
 	mdi := ag.database.(*databasemocks.Plugin)
-	mdi.On("GetPins", ag.ctx, mock.Anything, mock.Anything).Return([]*fftypes.Pin{
-		{Sequence: 12345},
+	mdi.On("GetGroupByHash", ag.ctx, mock.Anything).Return(&fftypes.Group{}, nil)
+	mdi.On("GetOrganizationVersions", ag.ctx, mock.Anything).Return([]*fftypes.Organization{{}}, nil, nil)
+	mdm := ag.data.(*datamocks.Manager)
+	mdm.On("GetMessageData", ag.ctx, mock.Anything, true).Return([]*fftypes.Data{
+		{ID: fftypes.NewUUID(), Hash: hash, Blobstore: true},
+	}, true, nil)
+	mdi.On("GetBlobs", ag.ctx, mock.Anything).Return([]*fftypes.Blob{
+		{Hash: fftypes.NewRandB32()},
+		{Hash: hash},
 	}, nil)
+	mdm.On("ValidateAll", ag.ctx, mock.Anything).Return(true, nil)
+	mdi.On("UpdateMessage", ag.ctx, mock.Anything, mock.Anything).Return(nil)
+	mdi.On("UpsertEvent", ag.ctx, mock.MatchedBy(func(event *fftypes.Event) bool {
+		return event.Type == fftypes.EventTypeMessageConfirmed
+	}), false).Return(nil)
 
-	rewind, offset := ag.rewindOffchainBatches()
-	assert.True(t, rewind)
-	assert.Equal(t, int64(12344) /* one before the batch */, offset)
-}
+	dispatched, err := ag.attemptMessageDispatch(ag.ctx, &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			ID:    fftypes.NewUUID(),
+			Group: fftypes.NewRandB32(),
+		},
+		Data: fftypes.DataRefs{
+			{ID: fftypes.NewUUID()},
+		},
+	}, 0)
+	assert.NoError(t, err)
+	assert.True(t, dispatched)
 
-func TestRewindOffchainBatchesBatchesError(t *testing.T) {
-	config.Set(config.EventAggregatorBatchSize, 10)
+	mdi.AssertExpectations(t)
+}
 
+func TestAttemptMessageDispatchGroupInit(t *testing.T) {
 	ag, cancel := newTestAggregator()
-	cancel()
+	defer cancel()
 
-	ag.queuedRewinds <- fftypes.NewUUID()
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationVersions", ag.ctx, mock.Anything).Return([]*fftypes.Organization{{}}, nil, nil)
+	mdm := ag.data.(*datamocks.Manager)
+	mdm.On("GetMessageData", ag.ctx, mock.Anything, true).Return([]*fftypes.Data{}, true, nil)
+	mdm.On("ValidateAll", ag.ctx, mock.Anything).Return(true, nil)
+	mdi.On("UpdateMessage", ag.ctx, mock.Anything, mock.Anything).Return(nil)
+	mdi.On("UpsertEvent", ag.ctx, mock.Anything, false).Return(nil)
+	mdi.On("GetMessages", ag.ctx, mock.Anything).Return([]*fftypes.Message{}, nil, nil)
+
+	_, err := ag.attemptMessageDispatch(ag.ctx, &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			ID:   fftypes.NewUUID(),
+			Type: fftypes.MessageTypeGroupInit,
+		},
+	}, 0)
+	assert.NoError(t, err)
+
+}
+
+func TestAttemptMessageDispatchGroupInitRewindFail(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	defer cancel()
 
 	mdi := ag.database.(*databasemocks.Plugin)
-	mdi.On("GetPins", ag.ctx, mock.Anything, mock.Anything).Return(nil, fmt.Errorf("pop"))
+	mdi.On("GetOrganizationVersions", ag.ctx, mock.Anything).Return([]*fftypes.Organization{{}}, nil, nil)
+	mdm := ag.data.(*datamocks.Manager)
+	mdm.On("GetMessageData", ag.ctx, mock.Anything, true).Return([]*fftypes.Data{}, true, nil)
+	mdi.On("GetMessages", ag.ctx, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
 
-	rewind, _ := ag.rewindOffchainBatches()
-	assert.False(t, rewind)
+	_, err := ag.attemptMessageDispatch(ag.ctx, &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			ID:   fftypes.NewUUID(),
+			Type: fftypes.MessageTypeGroupInit,
+		},
+	}, 0)
+	assert.EqualError(t, err, "pop")
+
+}
+
+func TestAttemptMessageDispatchGroupUpdate(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	defer cancel()
+
+	successor := &fftypes.Group{Hash: fftypes.NewRandB32()}
+	batchID := fftypes.NewUUID()
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationVersions", ag.ctx, mock.Anything).Return([]*fftypes.Organization{{}}, nil, nil)
+	mdm := ag.data.(*datamocks.Manager)
+	mdm.On("GetMessageData", ag.ctx, mock.Anything, true).Return([]*fftypes.Data{}, true, nil)
+	mpm := ag.messaging.(*privatemessagingmocks.Manager)
+	mpm.On("HandleGroupUpdate", ag.ctx, mock.Anything, mock.Anything).Return(successor, nil)
+	mdi.On("GetMessages", ag.ctx, mock.Anything).Return([]*fftypes.Message{
+		{BatchID: batchID},
+		{BatchID: batchID},
+		{},
+	}, nil, nil)
+	mdi.On("UpdateMessage", ag.ctx, mock.Anything, mock.Anything).Return(nil)
+	mdi.On("UpsertEvent", ag.ctx, mock.MatchedBy(func(event *fftypes.Event) bool {
+		return event.Type == fftypes.EventTypeGroupUpdated
+	}), false).Return(nil)
+
+	dispatched, err := ag.attemptMessageDispatch(ag.ctx, &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			ID:   fftypes.NewUUID(),
+			Type: fftypes.MessageTypeGroupUpdate,
+		},
+	}, 0)
+	assert.NoError(t, err)
+	assert.True(t, dispatched)
+
+	// The parked batch on the successor is queued for a rewind
+	assert.Equal(t, *batchID, *<-ag.offchainBatches)
+
+	mdi.AssertExpectations(t)
+	mpm.AssertExpectations(t)
+}
+
+func TestAttemptMessageDispatchGroupUpdateInvalid(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	defer cancel()
+
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationVersions", ag.ctx, mock.Anything).Return([]*fftypes.Organization{{}}, nil, nil)
+	mdm := ag.data.(*datamocks.Manager)
+	mdm.On("GetMessageData", ag.ctx, mock.Anything, true).Return([]*fftypes.Data{}, true, nil)
+	mpm := ag.messaging.(*privatemessagingmocks.Manager)
+	mpm.On("HandleGroupUpdate", ag.ctx, mock.Anything, mock.Anything).Return(nil, nil)
+	mdi.On("UpsertEvent", ag.ctx, mock.MatchedBy(func(event *fftypes.Event) bool {
+		return event.Type == fftypes.EventTypeMessageInvalid
+	}), false).Return(nil)
+
+	dispatched, err := ag.attemptMessageDispatch(ag.ctx, &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			ID:   fftypes.NewUUID(),
+			Type: fftypes.MessageTypeGroupUpdate,
+		},
+	}, 0)
+	assert.NoError(t, err)
+	assert.True(t, dispatched)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestAttemptMessageDispatchGroupUpdateFail(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	defer cancel()
+
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationVersions", ag.ctx, mock.Anything).Return([]*fftypes.Organization{{}}, nil, nil)
+	mdm := ag.data.(*datamocks.Manager)
+	mdm.On("GetMessageData", ag.ctx, mock.Anything, true).Return([]*fftypes.Data{}, true, nil)
+	mpm := ag.messaging.(*privatemessagingmocks.Manager)
+	mpm.On("HandleGroupUpdate", ag.ctx, mock.Anything, mock.Anything).Return(nil, fmt.Errorf("pop"))
+
+	_, err := ag.attemptMessageDispatch(ag.ctx, &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			ID:   fftypes.NewUUID(),
+			Type: fftypes.MessageTypeGroupUpdate,
+		},
+	}, 0)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestAttemptMessageDispatchGroupUpdateRewindFail(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	defer cancel()
+
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationVersions", ag.ctx, mock.Anything).Return([]*fftypes.Organization{{}}, nil, nil)
+	mdm := ag.data.(*datamocks.Manager)
+	mdm.On("GetMessageData", ag.ctx, mock.Anything, true).Return([]*fftypes.Data{}, true, nil)
+	mpm := ag.messaging.(*privatemessagingmocks.Manager)
+	mpm.On("HandleGroupUpdate", ag.ctx, mock.Anything, mock.Anything).Return(&fftypes.Group{Hash: fftypes.NewRandB32()}, nil)
+	mdi.On("GetMessages", ag.ctx, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+
+	_, err := ag.attemptMessageDispatch(ag.ctx, &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			ID:   fftypes.NewUUID(),
+			Type: fftypes.MessageTypeGroupUpdate,
+		},
+	}, 0)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestQueueRewindsClosed(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	cancel()
+
+	ag.offchainBatches <- fftypes.NewUUID()
+	ag.queueRewinds([]*fftypes.UUID{fftypes.NewUUID()})
+}
+
+func TestAttemptMessageUpdateMessageFail(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	defer cancel()
+
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationVersions", ag.ctx, mock.Anything).Return([]*fftypes.Organization{{}}, nil, nil)
+	mdm := ag.data.(*datamocks.Manager)
+	mdm.On("GetMessageData", ag.ctx, mock.Anything, true).Return([]*fftypes.Data{}, true, nil)
+	mdm.On("ValidateAll", ag.ctx, mock.Anything).Return(true, nil)
+	mdi.On("UpdateMessage", ag.ctx, mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+
+	_, err := ag.attemptMessageDispatch(ag.ctx, &fftypes.Message{
+		Header: fftypes.MessageHeader{ID: fftypes.NewUUID()},
+	}, 0)
+	assert.EqualError(t, err, "pop")
+
+}
+
+func TestRewindOffchainBatchesNoBatches(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	defer cancel()
+
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("UpdateMessage", ag.ctx, mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+
+	rewind, offset := ag.rewindOffchainBatches()
+	assert.False(t, rewind)
+	assert.Equal(t, int64(0), offset)
+}
+
+func TestRewindOffchainBatchesBatchesNoRewind(t *testing.T) {
+	config.Reset()
+	config.Set(config.EventAggregatorBatchSize, 10)
+
+	ag, cancel := newTestAggregatorWithConfig()
+	defer cancel()
+	go ag.offchainListener()
+
+	ag.offchainBatches <- fftypes.NewUUID()
+	ag.offchainBatches <- fftypes.NewUUID()
+	ag.offchainBatches <- fftypes.NewUUID()
+	ag.offchainBatches <- fftypes.NewUUID()
+
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetPins", ag.ctx, mock.Anything, mock.Anything).Return([]*fftypes.Pin{}, nil)
+
+	rewind, offset := ag.rewindOffchainBatches()
+	assert.False(t, rewind)
+	assert.Equal(t, int64(0), offset)
+}
+
+func TestRewindOffchainBatchesBatchesRewind(t *testing.T) {
+	config.Reset()
+	config.Set(config.EventAggregatorBatchSize, 10)
+
+	ag, cancel := newTestAggregatorWithConfig()
+	defer cancel()
+	go ag.offchainListener()
+
+	ag.offchainBatches <- fftypes.NewUUID()
+	ag.offchainBatches <- fftypes.NewUUID()
+	ag.offchainBatches <- fftypes.NewUUID()
+	ag.offchainBatches <- fftypes.NewUUID()
+
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetPins", ag.ctx, mock.Anything, mock.Anything).Return([]*fftypes.Pin{
+		{Sequence: 12345},
+	}, nil)
+
+	rewind, offset := ag.rewindOffchainBatches()
+	assert.True(t, rewind)
+	assert.Equal(t, int64(12344) /* one before the batch */, offset)
+}
+
+func TestRewindOffchainBatchesBatchesError(t *testing.T) {
+	config.Reset()
+	config.Set(config.EventAggregatorBatchSize, 10)
+
+	ag, cancel := newTestAggregatorWithConfig()
+	cancel()
+
+	ag.queuedRewinds <- fftypes.NewUUID()
+
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetPins", ag.ctx, mock.Anything, mock.Anything).Return(nil, fmt.Errorf("pop"))
+
+	rewind, _ := ag.rewindOffchainBatches()
+	assert.False(t, rewind)
+}
+
+func TestAttemptMessageDispatchSignatureOk(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	defer cancel()
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	msg := &fftypes.Message{
+		Header: fftypes.MessageHeader{ID: fftypes.NewUUID(), Author: "0x12345"},
+		Hash:   fftypes.NewRandB32(),
+	}
+	msg.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(priv, msg.Hash[:]))
+
+	mdm := ag.data.(*datamocks.Manager)
+	mdm.On("GetMessageData", ag.ctx, mock.Anything, true).Return([]*fftypes.Data{}, true, nil)
+
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationVersions", ag.ctx, mock.Anything).Return([]*fftypes.Organization{{
+		Identity: "0x12345",
+		PublicKeys: fftypes.PublicKeys{
+			{Type: fftypes.KeyTypeEd25519, Key: base64.StdEncoding.EncodeToString(pub)},
+		},
+	}}, nil, nil)
+	mdi.On("UpdateMessage", ag.ctx, msg.Header.ID, mock.Anything).Return(nil)
+	mdi.On("UpsertEvent", ag.ctx, mock.MatchedBy(func(event *fftypes.Event) bool {
+		return event.Type == fftypes.EventTypeMessageConfirmed
+	}), false).Return(nil)
+
+	dispatched, err := ag.attemptMessageDispatch(ag.ctx, msg, 0)
+	assert.NoError(t, err)
+	assert.True(t, dispatched)
+	mdi.AssertExpectations(t)
+}
+
+func TestAttemptMessageDispatchSignatureInvalid(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	defer cancel()
+
+	mdm := ag.data.(*datamocks.Manager)
+	mdm.On("GetMessageData", ag.ctx, mock.Anything, true).Return([]*fftypes.Data{}, true, nil)
+
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationVersions", ag.ctx, mock.Anything).Return([]*fftypes.Organization{{
+		Identity: "0x12345",
+		PublicKeys: fftypes.PublicKeys{
+			{Type: fftypes.KeyTypeEd25519, Key: "cHVibGlja2V5"},
+		},
+	}}, nil, nil)
+	mdi.On("UpsertEvent", ag.ctx, mock.MatchedBy(func(event *fftypes.Event) bool {
+		return event.Type == fftypes.EventTypeMessageInvalid
+	}), false).Return(nil)
+
+	dispatched, err := ag.attemptMessageDispatch(ag.ctx, &fftypes.Message{
+		Header: fftypes.MessageHeader{ID: fftypes.NewUUID(), Author: "0x12345"},
+		Hash:   fftypes.NewRandB32(),
+	}, 0)
+	assert.NoError(t, err)
+	assert.True(t, dispatched)
+	mdi.AssertExpectations(t)
+}
+
+func TestAttemptMessageDispatchAuthorRevoked(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	defer cancel()
+
+	mdm := ag.data.(*datamocks.Manager)
+	mdm.On("GetMessageData", ag.ctx, mock.Anything, true).Return([]*fftypes.Data{}, true, nil)
+
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationVersions", ag.ctx, mock.Anything).Return([]*fftypes.Organization{{
+		Identity: "0x12345",
+		Revoked:  fftypes.Now(),
+	}}, nil, nil)
+	mdi.On("UpsertEvent", ag.ctx, mock.MatchedBy(func(event *fftypes.Event) bool {
+		return event.Type == fftypes.EventTypeMessageInvalid
+	}), false).Return(nil)
+
+	dispatched, err := ag.attemptMessageDispatch(ag.ctx, &fftypes.Message{
+		Header: fftypes.MessageHeader{ID: fftypes.NewUUID(), Author: "0x12345"},
+	}, 0)
+	assert.NoError(t, err)
+	assert.True(t, dispatched)
+	mdi.AssertExpectations(t)
+}
+
+func TestAttemptMessageDispatchSignatureLookupFail(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	defer cancel()
+
+	mdm := ag.data.(*datamocks.Manager)
+	mdm.On("GetMessageData", ag.ctx, mock.Anything, true).Return([]*fftypes.Data{}, true, nil)
+
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationVersions", ag.ctx, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+
+	_, err := ag.attemptMessageDispatch(ag.ctx, &fftypes.Message{
+		Header: fftypes.MessageHeader{ID: fftypes.NewUUID(), Author: "0x12345"},
+	}, 0)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestAttemptMessageDispatchAuthorNotRegistered(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	defer cancel()
+
+	mdm := ag.data.(*datamocks.Manager)
+	mdm.On("GetMessageData", ag.ctx, mock.Anything, true).Return([]*fftypes.Data{}, true, nil)
+
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationVersions", ag.ctx, mock.Anything).Return([]*fftypes.Organization{}, nil, nil)
+	mdi.On("UpsertEvent", ag.ctx, mock.MatchedBy(func(event *fftypes.Event) bool {
+		return event.Type == fftypes.EventTypeMessageInvalid
+	}), false).Return(nil)
+
+	dispatched, err := ag.attemptMessageDispatch(ag.ctx, &fftypes.Message{
+		Header: fftypes.MessageHeader{ID: fftypes.NewUUID(), Author: "0x12345"},
+	}, 0)
+	assert.NoError(t, err)
+	assert.True(t, dispatched)
+	mdi.AssertExpectations(t)
+}
+
+func TestAttemptMessageDispatchRootOrgRegistration(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	defer cancel()
+
+	mdm := ag.data.(*datamocks.Manager)
+	mdm.On("GetMessageData", ag.ctx, mock.Anything, true).Return([]*fftypes.Data{}, true, nil)
+
+	mbm := ag.broadcast.(*broadcastmocks.Manager)
+	mbm.On("HandleSystemBroadcast", ag.ctx, mock.Anything, mock.Anything).Return(true, nil)
+
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationVersions", ag.ctx, mock.Anything).Return([]*fftypes.Organization{}, nil, nil)
+	mdi.On("UpdateMessage", ag.ctx, mock.Anything, mock.Anything).Return(nil)
+	mdi.On("UpsertEvent", ag.ctx, mock.MatchedBy(func(event *fftypes.Event) bool {
+		return event.Type == fftypes.EventTypeMessageConfirmed
+	}), false).Return(nil)
+
+	dispatched, err := ag.attemptMessageDispatch(ag.ctx, &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			ID:        fftypes.NewUUID(),
+			Author:    "0x12345",
+			Namespace: fftypes.SystemNamespace,
+			Tag:       string(fftypes.SystemTagDefineOrganization),
+		},
+	}, 0)
+	assert.NoError(t, err)
+	assert.True(t, dispatched)
+	mdi.AssertExpectations(t)
+}
+
+func TestAttemptMessageDispatchNoKeysPublished(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	defer cancel()
+
+	mdm := ag.data.(*datamocks.Manager)
+	mdm.On("GetMessageData", ag.ctx, mock.Anything, true).Return([]*fftypes.Data{}, true, nil)
+
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationVersions", ag.ctx, mock.Anything).Return([]*fftypes.Organization{{Identity: "0x12345"}}, nil, nil)
+	mdi.On("UpdateMessage", ag.ctx, mock.Anything, mock.Anything).Return(nil)
+	mdi.On("UpsertEvent", ag.ctx, mock.MatchedBy(func(event *fftypes.Event) bool {
+		return event.Type == fftypes.EventTypeMessageConfirmed
+	}), false).Return(nil)
+
+	dispatched, err := ag.attemptMessageDispatch(ag.ctx, &fftypes.Message{
+		Header: fftypes.MessageHeader{ID: fftypes.NewUUID(), Author: "0x12345"},
+		Hash:   fftypes.NewRandB32(),
+	}, 0)
+	assert.NoError(t, err)
+	assert.True(t, dispatched)
+	mdi.AssertExpectations(t)
+}
+
+func TestVerifySignatureNoKeysPublishedSigned(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	defer cancel()
+
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationVersions", ag.ctx, mock.Anything).Return([]*fftypes.Organization{{Identity: "0x12345"}}, nil, nil)
+
+	// A signature that cannot be verified is not ignored
+	valid, err := ag.verifySignature(ag.ctx, &fftypes.Message{
+		Header:    fftypes.MessageHeader{ID: fftypes.NewUUID(), Author: "0x12345"},
+		Hash:      fftypes.NewRandB32(),
+		Signature: "c2lnbmF0dXJl",
+	}, 0)
+	assert.NoError(t, err)
+	assert.False(t, valid)
+}
+
+func TestVerifySignatureNoKeysPublishedUnsignedNotAccepted(t *testing.T) {
+	config.Reset()
+	config.Set(config.EventAggregatorAcceptUnsigned, false)
+	ag, cancel := newTestAggregatorWithConfig()
+	defer cancel()
+
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationVersions", ag.ctx, mock.Anything).Return([]*fftypes.Organization{{Identity: "0x12345"}}, nil, nil)
+
+	valid, err := ag.verifySignature(ag.ctx, &fftypes.Message{
+		Header: fftypes.MessageHeader{ID: fftypes.NewUUID(), Author: "0x12345"},
+		Hash:   fftypes.NewRandB32(),
+	}, 0)
+	assert.NoError(t, err)
+	assert.False(t, valid)
+}
+
+func TestVerifySignatureKeysPublishedUnsigned(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	defer cancel()
+
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationVersions", ag.ctx, mock.Anything).Return([]*fftypes.Organization{{
+		Identity: "0x12345",
+		PublicKeys: fftypes.PublicKeys{
+			{Type: fftypes.KeyTypeEd25519, Key: base64.StdEncoding.EncodeToString(pub)},
+		},
+	}}, nil, nil)
+
+	valid, err := ag.verifySignature(ag.ctx, &fftypes.Message{
+		Header: fftypes.MessageHeader{ID: fftypes.NewUUID(), Author: "0x12345"},
+		Hash:   fftypes.NewRandB32(),
+	}, 0)
+	assert.NoError(t, err)
+	assert.False(t, valid)
+}
+
+func TestVerifySignatureUsesVersionAtPin(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	defer cancel()
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	msg := &fftypes.Message{
+		Header: fftypes.MessageHeader{ID: fftypes.NewUUID(), Author: "0x12345"},
+		Hash:   fftypes.NewRandB32(),
+	}
+	msg.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(priv, msg.Hash[:]))
+
+	v1 := &fftypes.Organization{
+		Identity: "0x12345",
+		Message:  fftypes.NewUUID(),
+		Version:  1,
+		PublicKeys: fftypes.PublicKeys{
+			{Type: fftypes.KeyTypeEd25519, Key: base64.StdEncoding.EncodeToString(pub)},
+		},
+	}
+	v2 := &fftypes.Organization{
+		Identity: "0x12345",
+		Message:  fftypes.NewUUID(),
+		Version:  2,
+		PublicKeys: fftypes.PublicKeys{
+			{Type: fftypes.KeyTypeEd25519, Key: "cHVibGlja2V5"},
+		},
+	}
+	batchID := fftypes.NewUUID()
+	ag.pinCache.Set(v1.Message.String(), int64(5), time.Minute)
+
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationVersions", ag.ctx, mock.Anything).Return([]*fftypes.Organization{v2, v1}, nil, nil)
+	mdi.On("GetMessageByID", ag.ctx, v2.Message).Return(&fftypes.Message{
+		Header:  fftypes.MessageHeader{ID: v2.Message},
+		BatchID: batchID,
+	}, nil).Once()
+	mdi.On("GetBatchByID", ag.ctx, batchID).Return(&fftypes.Batch{
+		ID: batchID,
+		Payload: fftypes.BatchPayload{
+			Messages: []*fftypes.Message{
+				nil,
+				{Header: fftypes.MessageHeader{ID: fftypes.NewUUID(), Topics: fftypes.FFNameArray{"t1", "t2"}}},
+				{Header: fftypes.MessageHeader{ID: v2.Message, Topics: fftypes.FFNameArray{"t1"}}},
+			},
+		},
+	}, nil).Once()
+	mdi.On("GetPins", ag.ctx, mock.MatchedBy(func(filter database.Filter) bool {
+		info, _ := filter.Finalize()
+		return info.String() == fmt.Sprintf("( batch == '%s' ) && ( index == 2 )", batchID)
+	})).Return([]*fftypes.Pin{{Sequence: 20}}, nil).Once()
+
+	// Sequenced before the update, so verified with the original keys
+	valid, err := ag.verifySignature(ag.ctx, msg, 10)
+	assert.NoError(t, err)
+	assert.True(t, valid)
+
+	// Sequenced after the update, so verified with the new keys
+	valid, err = ag.verifySignature(ag.ctx, msg, 30)
+	assert.NoError(t, err)
+	assert.False(t, valid)
+
+	// Sequenced before the registration
+	valid, err = ag.verifySignature(ag.ctx, msg, 1)
+	assert.NoError(t, err)
+	assert.False(t, valid)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestGetMessagePinSequenceNotFound(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	defer cancel()
+
+	msgID := fftypes.NewUUID()
+	batchID := fftypes.NewUUID()
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetMessageByID", ag.ctx, msgID).Return(nil, nil).Once()
+	mdi.On("GetMessageByID", ag.ctx, msgID).Return(&fftypes.Message{BatchID: batchID}, nil)
+	mdi.On("GetBatchByID", ag.ctx, batchID).Return(nil, nil).Once()
+	mdi.On("GetBatchByID", ag.ctx, batchID).Return(&fftypes.Batch{ID: batchID}, nil)
+	mdi.On("GetPins", ag.ctx, mock.Anything).Return([]*fftypes.Pin{}, nil)
+
+	sequence, err := ag.getMessagePinSequence(ag.ctx, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(-1), sequence)
+
+	for i := 0; i < 3; i++ {
+		sequence, err = ag.getMessagePinSequence(ag.ctx, msgID)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(-1), sequence)
+	}
+	assert.Equal(t, 0, ag.pinCache.ItemCount())
+	mdi.AssertExpectations(t)
+}
+
+func TestGetMessagePinSequenceFail(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	defer cancel()
+
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationVersions", ag.ctx, mock.Anything).Return([]*fftypes.Organization{{Message: fftypes.NewUUID()}}, nil, nil)
+	mdi.On("GetMessageByID", ag.ctx, mock.Anything).Return(nil, fmt.Errorf("pop"))
+
+	_, err := ag.getOrganizationAtSequence(ag.ctx, "0x12345", 10)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestVerifySignatureIdentityRetired(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	defer cancel()
+
+	orgID := fftypes.NewUUID()
+	v1 := &fftypes.Organization{ID: orgID, Identity: "0x12345", Message: fftypes.NewUUID(), Version: 1}
+	v2 := &fftypes.Organization{ID: orgID, Identity: "0x12345", Message: fftypes.NewUUID(), Version: 2, Updated: fftypes.Now()}
+	v3 := &fftypes.Organization{ID: orgID, Identity: "0x23456", Message: fftypes.NewUUID(), Version: 3}
+	ag.pinCache.Set(v1.Message.String(), int64(5), time.Minute)
+	ag.pinCache.Set(v2.Message.String(), int64(10), time.Minute)
+	ag.pinCache.Set(v3.Message.String(), int64(20), time.Minute)
+
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationVersions", ag.ctx, mock.MatchedBy(func(filter database.Filter) bool {
+		info, _ := filter.Finalize()
+		return info.String() == "( identity == '0x12345' ) sort=version descending"
+	})).Return([]*fftypes.Organization{v2, v1}, nil, nil)
+	mdi.On("GetOrganizationVersions", ag.ctx, mock.MatchedBy(func(filter database.Filter) bool {
+		info, _ := filter.Finalize()
+		return info.String() == fmt.Sprintf("( id == '%s' ) && ( version > 1 ) sort=version", orgID)
+	})).Return([]*fftypes.Organization{v2, v3}, nil, nil)
+	mdi.On("GetOrganizationVersions", ag.ctx, mock.MatchedBy(func(filter database.Filter) bool {
+		info, _ := filter.Finalize()
+		return info.String() == fmt.Sprintf("( id == '%s' ) && ( version > 2 ) sort=version", orgID)
+	})).Return([]*fftypes.Organization{v3}, nil, nil)
+
+	// Before the move, the identity was in force
+	org, err := ag.getOrganizationAtSequence(ag.ctx, "0x12345", 7)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), org.Version)
+	assert.Nil(t, org.Revoked)
+
+	// After the move, the identity is retired
+	org, err = ag.getOrganizationAtSequence(ag.ctx, "0x12345", 30)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), org.Version)
+	assert.NotNil(t, org.Revoked)
+
+	msg := &fftypes.Message{Header: fftypes.MessageHeader{ID: fftypes.NewUUID(), Author: "0x12345"}}
+	valid, err := ag.verifySignature(ag.ctx, msg, 30)
+	assert.NoError(t, err)
+	assert.False(t, valid)
+}
+
+func TestCheckIdentityRetiredNoUpdatedTime(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	defer cancel()
+
+	v1 := &fftypes.Organization{ID: fftypes.NewUUID(), Identity: "0x12345", Version: 1}
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationVersions", ag.ctx, mock.Anything).Return([]*fftypes.Organization{{Identity: "0x23456", Version: 2}}, nil, nil)
+
+	org, err := ag.checkIdentityRetired(ag.ctx, v1, 10)
+	assert.NoError(t, err)
+	assert.NotNil(t, org.Revoked)
+}
+
+func TestCheckIdentityRetiredFail(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	defer cancel()
+
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationVersions", ag.ctx, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+
+	_, err := ag.checkIdentityRetired(ag.ctx, &fftypes.Organization{}, 10)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestCheckIdentityRetiredPinLookupFail(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	defer cancel()
+
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationVersions", ag.ctx, mock.Anything).Return([]*fftypes.Organization{{Message: fftypes.NewUUID()}}, nil, nil)
+	mdi.On("GetMessageByID", ag.ctx, mock.Anything).Return(nil, fmt.Errorf("pop"))
+
+	_, err := ag.checkIdentityRetired(ag.ctx, &fftypes.Organization{}, 10)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestAttemptMessageDispatchGroupRetired(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	defer cancel()
+
+	groupHash := fftypes.NewRandB32()
+	updateID := fftypes.NewUUID()
+	ag.pinCache.Set(updateID.String(), int64(20), time.Minute)
+
+	mdm := ag.data.(*datamocks.Manager)
+	mdm.On("GetMessageData", ag.ctx, mock.Anything, true).Return([]*fftypes.Data{}, true, nil)
+
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationVersions", ag.ctx, mock.Anything).Return([]*fftypes.Organization{{}}, nil, nil)
+	mdi.On("GetGroupByHash", ag.ctx, groupHash).Return(&fftypes.Group{
+		Hash:      groupHash,
+		Successor: fftypes.NewRandB32(),
+	}, nil)
+	mdi.On("GetMessages", ag.ctx, mock.MatchedBy(func(filter database.Filter) bool {
+		info, _ := filter.Finalize()
+		return info.String() == fmt.Sprintf("( group == '%s' ) && ( type == 'groupupdate' ) && ( confirmed > 0 )", groupHash)
+	})).Return([]*fftypes.Message{{Header: fftypes.MessageHeader{ID: updateID}}}, nil, nil)
+	mdi.On("UpdateMessage", ag.ctx, mock.Anything, mock.Anything).Return(nil)
+	mdi.On("UpsertEvent", ag.ctx, mock.MatchedBy(func(event *fftypes.Event) bool {
+		return event.Type == fftypes.EventTypeMessageInvalid
+	}), false).Return(nil).Once()
+	mdi.On("UpsertEvent", ag.ctx, mock.MatchedBy(func(event *fftypes.Event) bool {
+		return event.Type == fftypes.EventTypeMessageConfirmed
+	}), false).Return(nil).Once()
+
+	// Sequenced after the update
+	dispatched, err := ag.attemptMessageDispatch(ag.ctx, &fftypes.Message{
+		Header: fftypes.MessageHeader{ID: fftypes.NewUUID(), Group: groupHash},
+	}, 30)
+	assert.NoError(t, err)
+	assert.True(t, dispatched)
+
+	// Sequenced before the update
+	dispatched, err = ag.attemptMessageDispatch(ag.ctx, &fftypes.Message{
+		Header: fftypes.MessageHeader{ID: fftypes.NewUUID(), Group: groupHash},
+	}, 10)
+	assert.NoError(t, err)
+	assert.True(t, dispatched)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestAttemptMessageDispatchGroupLookupFail(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	defer cancel()
+
+	mdm := ag.data.(*datamocks.Manager)
+	mdm.On("GetMessageData", ag.ctx, mock.Anything, true).Return([]*fftypes.Data{}, true, nil)
+
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationVersions", ag.ctx, mock.Anything).Return([]*fftypes.Organization{{}}, nil, nil)
+	mdi.On("GetGroupByHash", ag.ctx, mock.Anything).Return(nil, fmt.Errorf("pop"))
+
+	_, err := ag.attemptMessageDispatch(ag.ctx, &fftypes.Message{
+		Header: fftypes.MessageHeader{ID: fftypes.NewUUID(), Group: fftypes.NewRandB32()},
+	}, 10)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestCheckGroupNotRetiredGetMessagesFail(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	defer cancel()
+
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetGroupByHash", ag.ctx, mock.Anything).Return(&fftypes.Group{Successor: fftypes.NewRandB32()}, nil)
+	mdi.On("GetMessages", ag.ctx, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+
+	_, err := ag.checkGroupNotRetired(ag.ctx, &fftypes.Message{
+		Header: fftypes.MessageHeader{ID: fftypes.NewUUID(), Group: fftypes.NewRandB32()},
+	}, 10)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestCheckGroupNotRetiredPinLookupFail(t *testing.T) {
+	ag, cancel := newTestAggregator()
+	defer cancel()
+
+	mdi := ag.database.(*databasemocks.Plugin)
+	mdi.On("GetGroupByHash", ag.ctx, mock.Anything).Return(&fftypes.Group{Successor: fftypes.NewRandB32()}, nil)
+	mdi.On("GetMessages", ag.ctx, mock.Anything).Return([]*fftypes.Message{{Header: fftypes.MessageHeader{ID: fftypes.NewUUID()}}}, nil, nil)
+	mdi.On("GetMessageByID", ag.ctx, mock.Anything).Return(nil, fmt.Errorf("pop"))
+
+	_, err := ag.checkGroupNotRetired(ag.ctx, &fftypes.Message{
+		Header: fftypes.MessageHeader{ID: fftypes.NewUUID(), Group: fftypes.NewRandB32()},
+	}, 10)
+	assert.EqualError(t, err, "pop")
 }
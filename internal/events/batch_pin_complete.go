@@ -33,21 +33,21 @@ import (
 //
 // We must block here long enough to get the payload from the publicstorage, persist the messages in the correct
 // sequence, and also persist all the data.
-func (em *eventManager) BatchPinComplete(bi blockchain.Plugin, batchPin *blockchain.BatchPin, signingIdentity string, protocolTxID string, additionalInfo fftypes.JSONObject) error {
+func (em *eventManager) BatchPinComplete(bi blockchain.Plugin, ledgerID *fftypes.UUID, batchPin *blockchain.BatchPin, signingIdentity string, protocolTxID string, additionalInfo fftypes.JSONObject) error {
 
-	log.L(em.ctx).Infof("-> BatchPinComplete txn=%s author=%s", protocolTxID, signingIdentity)
+	log.L(em.ctx).Infof("-> BatchPinComplete txn=%s author=%s ledger=%s", protocolTxID, signingIdentity, ledgerID)
 	defer func() {
-		log.L(em.ctx).Infof("<- BatchPinComplete txn=%s author=%s", protocolTxID, signingIdentity)
+		log.L(em.ctx).Infof("<- BatchPinComplete txn=%s author=%s ledger=%s", protocolTxID, signingIdentity, ledgerID)
 	}()
 	log.L(em.ctx).Tracef("BatchPinComplete info: %+v", additionalInfo)
 
 	if batchPin.BatchPaylodRef != nil {
-		return em.handleBroadcastPinComplete(batchPin, signingIdentity, protocolTxID, additionalInfo)
+		return em.handleBroadcastPinComplete(ledgerID, batchPin, signingIdentity, protocolTxID, additionalInfo)
 	}
-	return em.handlePrivatePinComplete(batchPin, signingIdentity, protocolTxID, additionalInfo)
+	return em.handlePrivatePinComplete(ledgerID, batchPin, signingIdentity, protocolTxID, additionalInfo)
 }
 
-func (em *eventManager) handlePrivatePinComplete(batchPin *blockchain.BatchPin, signingIdentity string, protocolTxID string, additionalInfo fftypes.JSONObject) error {
+func (em *eventManager) handlePrivatePinComplete(ledgerID *fftypes.UUID, batchPin *blockchain.BatchPin, signingIdentity string, protocolTxID string, additionalInfo fftypes.JSONObject) error {
 	// Here we simple record all the pins as parked, and emit an event for the aggregator
 	// to check whether the messages in the batch have been written.
 	return em.retry.Do(em.ctx, "persist private batch pins", func(attempt int) (bool, error) {
@@ -56,7 +56,7 @@ func (em *eventManager) handlePrivatePinComplete(batchPin *blockchain.BatchPin,
 		err := em.database.RunAsGroup(em.ctx, func(ctx context.Context) error {
 			err := em.persistBatchTransaction(ctx, batchPin, signingIdentity, protocolTxID, additionalInfo)
 			if err == nil {
-				err = em.persistContexts(ctx, batchPin, true)
+				err = em.persistContexts(ctx, ledgerID, batchPin, true)
 			}
 			return err
 		})
@@ -96,10 +96,14 @@ func (em *eventManager) persistBatchTransaction(ctx context.Context, batchPin *b
 		return nil // This is not retryable. skip this batch
 	}
 
-	// Set the updates on the transaction
+	// Set the updates on the transaction, and re-derive the status now the pin is confirmed
 	tx.ProtocolID = protocolTxID
 	tx.Info = additionalInfo
-	tx.Status = fftypes.OpStatusSucceeded
+	ops, err := em.getTransactionOperations(ctx, tx.ID)
+	if err != nil {
+		return err // a peristence failure here is considered retryable (so returned)
+	}
+	tx.Status, _ = tx.DeriveStatus(ops)
 
 	// Upsert the transaction, ensuring the hash does not change
 	err = em.database.UpsertTransaction(ctx, tx, true, false)
@@ -115,9 +119,10 @@ func (em *eventManager) persistBatchTransaction(ctx context.Context, batchPin *b
 	return nil
 }
 
-func (em *eventManager) persistContexts(ctx context.Context, batchPin *blockchain.BatchPin, private bool) error {
+func (em *eventManager) persistContexts(ctx context.Context, ledgerID *fftypes.UUID, batchPin *blockchain.BatchPin, private bool) error {
 	for idx, hash := range batchPin.Contexts {
 		if err := em.database.UpsertPin(ctx, &fftypes.Pin{
+			Ledger:  ledgerID,
 			Masked:  private,
 			Hash:    hash,
 			Batch:   batchPin.BatchID,
@@ -130,7 +135,7 @@ func (em *eventManager) persistContexts(ctx context.Context, batchPin *blockchai
 	return nil
 }
 
-func (em *eventManager) handleBroadcastPinComplete(batchPin *blockchain.BatchPin, signingIdentity string, protocolTxID string, additionalInfo fftypes.JSONObject) error {
+func (em *eventManager) handleBroadcastPinComplete(ledgerID *fftypes.UUID, batchPin *blockchain.BatchPin, signingIdentity string, protocolTxID string, additionalInfo fftypes.JSONObject) error {
 	var body io.ReadCloser
 	if err := em.retry.Do(em.ctx, "retrieve data", func(attempt int) (retry bool, err error) {
 		body, err = em.publicstorage.RetrieveData(em.ctx, batchPin.BatchPaylodRef)
@@ -148,6 +153,16 @@ func (em *eventManager) handleBroadcastPinComplete(batchPin *blockchain.BatchPin
 	}
 	body.Close()
 
+	// Any blobs in the batch are copied into the local data exchange from public storage, before we persist the batch.
+	// The aggregator will not confirm the messages until the hashes of the blobs have been verified.
+	// We only do this for a batch that matches the pinning transaction, as the blob references come from the author.
+	valid := em.validateBroadcastBatch(em.ctx, batch, batchPin.BatchHash, signingIdentity)
+	if valid {
+		if err := em.retrieveBlobs(batch); err != nil {
+			return err
+		}
+	}
+
 	// At this point the batch is parsed, so any errors in processing need to be considered as:
 	// 1) Retryable - any transient error returned by processBatch is retried indefinitely
 	// 2) Swallowable - the data is invalid, and we have to move onto subsequent messages
@@ -157,14 +172,61 @@ func (em *eventManager) handleBroadcastPinComplete(batchPin *blockchain.BatchPin
 		// efficiency and to minimize the chance of duplicates (although at-least-once delivery is the core model)
 		err := em.database.RunAsGroup(em.ctx, func(ctx context.Context) error {
 			err := em.persistBatchTransaction(ctx, batchPin, signingIdentity, protocolTxID, additionalInfo)
+			if err == nil && valid {
+				_, err = em.persistBatch(ctx, batch)
+			}
 			if err == nil {
-				err = em.persistBatchFromBroadcast(ctx, batch, batchPin.BatchHash, signingIdentity)
-				if err == nil {
-					err = em.persistContexts(ctx, batchPin, false)
-				}
+				err = em.persistContexts(ctx, ledgerID, batchPin, false)
 			}
 			return err
 		})
 		return err != nil, err // retry indefinitely (until context closes)
 	})
 }
+
+func (em *eventManager) retrieveBlobs(batch *fftypes.Batch) error {
+	l := log.L(em.ctx)
+	for _, data := range batch.Payload.Data {
+		if data == nil || data.ID == nil || !data.Blobstore {
+			continue
+		}
+		if data.Blob == nil || data.Blob.PayloadRef == nil {
+			l.Errorf("Invalid blob data '%s' in batch '%s'. Missing public storage reference", data.ID, batch.ID)
+			continue // the aggregator will mark any message referring to it as invalid
+		}
+
+		// We might already have the blob, if we are the author, or this is a replay
+		var blobs []*fftypes.Blob
+		if err := em.retry.Do(em.ctx, "check blob", func(attempt int) (retry bool, err error) {
+			fb := database.BlobQueryFactory.NewFilter(em.ctx)
+			blobs, err = em.database.GetBlobs(em.ctx, fb.And(fb.Eq("data", data.ID)))
+			return err != nil, err // retry indefinitely (until context closes)
+		}); err != nil {
+			return err
+		}
+		if len(blobs) > 0 {
+			continue
+		}
+
+		// The reference is supplied by the author of the batch, so a blob that cannot be retrieved is a
+		// permanent failure. We must not block the events from this ledger, so the aggregator will mark
+		// any message referring to the data as invalid, as there is no blob with a matching hash.
+		blob, err := em.copyBlobFromPublicStorage(data)
+		switch {
+		case err != nil:
+			l.Errorf("Invalid blob data '%s' in batch '%s'. Failed to retrieve from public storage: %s", data.ID, batch.ID, err)
+		case !blob.Hash.Equals(data.Hash):
+			l.Errorf("Invalid blob data '%s' in batch '%s'. Hash of blob '%s' does not match data hash '%s'", data.ID, batch.ID, blob.Hash, data.Hash)
+		}
+	}
+	return nil
+}
+
+func (em *eventManager) copyBlobFromPublicStorage(data *fftypes.Data) (*fftypes.Blob, error) {
+	reader, err := em.publicstorage.RetrieveData(em.ctx, data.Blob.PayloadRef)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return em.data.CopyBlobToDX(em.ctx, data, reader)
+}
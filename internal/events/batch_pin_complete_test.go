@@ -26,6 +26,7 @@ import (
 
 	"github.com/hyperledger-labs/firefly/mocks/blockchainmocks"
 	"github.com/hyperledger-labs/firefly/mocks/databasemocks"
+	"github.com/hyperledger-labs/firefly/mocks/datamocks"
 	"github.com/hyperledger-labs/firefly/mocks/identitymocks"
 	"github.com/hyperledger-labs/firefly/mocks/publicstoragemocks"
 	"github.com/hyperledger-labs/firefly/pkg/blockchain"
@@ -60,6 +61,8 @@ func TestBatchPinCompleteOkBroadcast(t *testing.T) {
 			Data:     []*fftypes.Data{},
 		},
 	}
+	batchData.Hash = batchData.Payload.Hash()
+	batch.BatchHash = batchData.Hash
 	batchDataBytes, err := json.Marshal(&batchData)
 	assert.NoError(t, err)
 	batchReadCloser := ioutil.NopCloser(bytes.NewReader(batchDataBytes))
@@ -72,21 +75,23 @@ func TestBatchPinCompleteOkBroadcast(t *testing.T) {
 	mdi := em.database.(*databasemocks.Plugin)
 	rag := mdi.On("RunAsGroup", mock.Anything, mock.Anything).Return(nil)
 	rag.RunFn = func(a mock.Arguments) {
-		// Call through to persistBatch - the hash of our batch will be invalid,
-		// which is swallowed without error as we cannot retry (it is logged of course)
 		rag.ReturnArguments = mock.Arguments{
 			a[1].(func(ctx context.Context) error)(a[0].(context.Context)),
 		}
 	}
 	mdi.On("GetTransactionByID", mock.Anything, uuidMatches(batchData.Payload.TX.ID)).Return(nil, nil)
-	mdi.On("UpsertTransaction", mock.Anything, mock.Anything, true, false).Return(nil)
+	mdi.On("GetOperations", mock.Anything, mock.Anything).Return([]*fftypes.Operation{}, nil, nil)
+	mdi.On("UpsertTransaction", mock.Anything, mock.MatchedBy(func(tx *fftypes.Transaction) bool {
+		return tx.Status == fftypes.OpStatusSucceeded
+	}), true, false).Return(nil)
+	mdi.On("UpsertBatch", mock.Anything, mock.Anything, true, false).Return(nil)
 	mdi.On("UpsertPin", mock.Anything, mock.Anything).Return(nil)
 	mbi := &blockchainmocks.Plugin{}
 
 	mii := em.identity.(*identitymocks.Plugin)
 	mii.On("Resolve", mock.Anything, "0x12345").Return(&fftypes.Identity{OnChain: "0x12345"}, nil)
 
-	err = em.BatchPinComplete(mbi, batch, "0x12345", "tx1", nil)
+	err = em.BatchPinComplete(mbi, nil, batch, "0x12345", "tx1", nil)
 	assert.NoError(t, err)
 
 	mdi.AssertExpectations(t)
@@ -127,11 +132,17 @@ func TestBatchPinCompleteOkPrivate(t *testing.T) {
 	mdi := em.database.(*databasemocks.Plugin)
 	mdi.On("RunAsGroup", mock.Anything, mock.Anything).Return(nil)
 	mdi.On("GetTransactionByID", mock.Anything, uuidMatches(batchData.Payload.TX.ID)).Return(nil, nil)
-	mdi.On("UpsertTransaction", mock.Anything, mock.Anything, true, false).Return(nil)
-	mdi.On("UpsertPin", mock.Anything, mock.Anything).Return(nil)
+	mdi.On("GetOperations", mock.Anything, mock.Anything).Return([]*fftypes.Operation{}, nil, nil)
+	mdi.On("UpsertTransaction", mock.Anything, mock.MatchedBy(func(tx *fftypes.Transaction) bool {
+		return tx.Status == fftypes.OpStatusSucceeded
+	}), true, false).Return(nil)
+	ledgerID := fftypes.NewUUID()
+	mdi.On("UpsertPin", mock.Anything, mock.MatchedBy(func(pin *fftypes.Pin) bool {
+		return *pin.Ledger == *ledgerID && pin.Masked
+	})).Return(nil)
 	mbi := &blockchainmocks.Plugin{}
 
-	err = em.BatchPinComplete(mbi, batch, "0x12345", "tx1", nil)
+	err = em.BatchPinComplete(mbi, ledgerID, batch, "0x12345", "tx1", nil)
 	assert.NoError(t, err)
 
 	// Call through to persistBatch - the hash of our batch will be invalid,
@@ -158,7 +169,7 @@ func TestSequencedBroadcastRetrieveIPFSFail(t *testing.T) {
 	mpi.On("RetrieveData", mock.Anything, mock.Anything).Return(nil, fmt.Errorf("pop"))
 	mbi := &blockchainmocks.Plugin{}
 
-	err := em.BatchPinComplete(mbi, batch, "0x12345", "tx1", nil)
+	err := em.BatchPinComplete(mbi, nil, batch, "0x12345", "tx1", nil)
 	mpi.AssertExpectations(t)
 	assert.Regexp(t, "FF10158", err)
 }
@@ -179,7 +190,7 @@ func TestBatchPinCompleteBadData(t *testing.T) {
 	mpi.On("RetrieveData", mock.Anything, mock.Anything).Return(batchReadCloser, nil)
 	mbi := &blockchainmocks.Plugin{}
 
-	err := em.BatchPinComplete(mbi, batch, "0x12345", "tx1", nil)
+	err := em.BatchPinComplete(mbi, nil, batch, "0x12345", "tx1", nil)
 	assert.NoError(t, err) // We do not return a blocking error in the case of bad data stored in IPFS
 }
 
@@ -191,6 +202,22 @@ func TestPersistBatchMissingID(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestPersistBatchBadHash(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	valid, err := em.persistBatch(context.Background(), &fftypes.Batch{
+		ID: fftypes.NewUUID(),
+		Payload: fftypes.BatchPayload{
+			TX: fftypes.TransactionRef{
+				ID: fftypes.NewUUID(),
+			},
+		},
+		Hash: fftypes.NewRandB32(),
+	})
+	assert.False(t, valid)
+	assert.NoError(t, err)
+}
+
 func TestPersistBatchAuthorResolveFail(t *testing.T) {
 	em, cancel := newTestEventManager(t)
 	defer cancel()
@@ -209,8 +236,8 @@ func TestPersistBatchAuthorResolveFail(t *testing.T) {
 	mii := em.identity.(*identitymocks.Plugin)
 	mii.On("Resolve", mock.Anything, "0x23456").Return(nil, fmt.Errorf("pop"))
 	batch.Hash = batch.Payload.Hash()
-	err := em.persistBatchFromBroadcast(context.Background(), batch, batchHash, "0x12345")
-	assert.NoError(t, err)
+	valid := em.validateBroadcastBatch(context.Background(), batch, batchHash, "0x12345")
+	assert.False(t, valid)
 }
 
 func TestPersistBatchBadAuthor(t *testing.T) {
@@ -231,8 +258,8 @@ func TestPersistBatchBadAuthor(t *testing.T) {
 	mii := em.identity.(*identitymocks.Plugin)
 	mii.On("Resolve", mock.Anything, "0x23456").Return(&fftypes.Identity{OnChain: "0x23456"}, nil)
 	batch.Hash = batch.Payload.Hash()
-	err := em.persistBatchFromBroadcast(context.Background(), batch, batchHash, "0x12345")
-	assert.NoError(t, err)
+	valid := em.validateBroadcastBatch(context.Background(), batch, batchHash, "0x12345")
+	assert.False(t, valid)
 }
 
 func TestPersistBatchMismatchChainHash(t *testing.T) {
@@ -252,8 +279,23 @@ func TestPersistBatchMismatchChainHash(t *testing.T) {
 	mii := em.identity.(*identitymocks.Plugin)
 	mii.On("Resolve", mock.Anything, "0x12345").Return(&fftypes.Identity{OnChain: "0x12345"}, nil)
 	batch.Hash = batch.Payload.Hash()
-	err := em.persistBatchFromBroadcast(context.Background(), batch, fftypes.NewRandB32(), "0x12345")
-	assert.NoError(t, err)
+	valid := em.validateBroadcastBatch(context.Background(), batch, fftypes.NewRandB32(), "0x12345")
+	assert.False(t, valid)
+}
+
+func TestPersistBatchMismatchPayloadHash(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	batchHash := fftypes.NewRandB32()
+	batch := &fftypes.Batch{
+		ID:     fftypes.NewUUID(),
+		Author: "0x12345",
+		Hash:   batchHash,
+	}
+	mii := em.identity.(*identitymocks.Plugin)
+	mii.On("Resolve", mock.Anything, "0x12345").Return(&fftypes.Identity{OnChain: "0x12345"}, nil)
+	valid := em.validateBroadcastBatch(context.Background(), batch, batchHash, "0x12345")
+	assert.False(t, valid)
 }
 
 func TestPersistBatchUpsertBatchMismatchHash(t *testing.T) {
@@ -378,6 +420,7 @@ func TestPersistBatcNewTXUpsertFail(t *testing.T) {
 
 	mdi := em.database.(*databasemocks.Plugin)
 	mdi.On("GetTransactionByID", mock.Anything, mock.Anything).Return(nil, nil)
+	mdi.On("GetOperations", mock.Anything, mock.Anything).Return([]*fftypes.Operation{}, nil, nil)
 	mdi.On("UpsertTransaction", mock.Anything, mock.Anything, true, false).Return(fmt.Errorf("pop"))
 
 	err := em.persistBatchTransaction(context.Background(), batchPin, "0x12345", "txid1", fftypes.JSONObject{})
@@ -385,6 +428,25 @@ func TestPersistBatcNewTXUpsertFail(t *testing.T) {
 	mdi.AssertExpectations(t)
 }
 
+func TestPersistBatchGetOperationsFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	batchPin := &blockchain.BatchPin{
+		Namespace:     "ns1",
+		TransactionID: fftypes.NewUUID(),
+		BatchID:       fftypes.NewUUID(),
+	}
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetTransactionByID", mock.Anything, mock.Anything).Return(nil, nil)
+	mdi.On("GetOperations", mock.Anything, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+
+	err := em.persistBatchTransaction(context.Background(), batchPin, "0x12345", "txid1", fftypes.JSONObject{})
+	assert.EqualError(t, err, "pop")
+	mdi.AssertExpectations(t)
+}
+
 func TestPersistBatcExistingTXHashMismatch(t *testing.T) {
 	em, cancel := newTestEventManager(t)
 	defer cancel()
@@ -406,6 +468,7 @@ func TestPersistBatcExistingTXHashMismatch(t *testing.T) {
 			Reference: batchPin.BatchID,
 		},
 	}, nil)
+	mdi.On("GetOperations", mock.Anything, mock.Anything).Return([]*fftypes.Operation{}, nil, nil)
 	mdi.On("UpsertTransaction", mock.Anything, mock.Anything, true, false).Return(database.HashMismatch)
 
 	err := em.persistBatchTransaction(context.Background(), batchPin, "0x12345", "txid1", fftypes.JSONObject{})
@@ -547,6 +610,40 @@ func TestPersistBatchDataBadHash(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestPersistBatchDataBlobMissingHash(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	batch := &fftypes.Batch{
+		ID: fftypes.NewUUID(),
+	}
+	data := &fftypes.Data{
+		ID:        fftypes.NewUUID(),
+		Blobstore: true,
+	}
+	err := em.persistBatchData(context.Background(), batch, 0, data)
+	assert.NoError(t, err)
+}
+
+func TestPersistBatchDataBlobOk(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	batch := &fftypes.Batch{
+		ID: fftypes.NewUUID(),
+	}
+	data := &fftypes.Data{
+		ID:        fftypes.NewUUID(),
+		Blobstore: true,
+		Hash:      fftypes.NewRandB32(),
+	}
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("UpsertData", mock.Anything, data, true, false).Return(nil)
+
+	err := em.persistBatchData(context.Background(), batch, 0, data)
+	assert.NoError(t, err)
+	mdi.AssertExpectations(t)
+}
+
 func TestPersistBatchDataUpsertHashMismatch(t *testing.T) {
 	em, cancel := newTestEventManager(t)
 	defer cancel()
@@ -696,7 +793,7 @@ func TestPersistContextsFail(t *testing.T) {
 	mdi := em.database.(*databasemocks.Plugin)
 	mdi.On("UpsertPin", mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
 
-	err := em.persistContexts(em.ctx, &blockchain.BatchPin{
+	err := em.persistContexts(em.ctx, nil, &blockchain.BatchPin{
 		Contexts: []*fftypes.Bytes32{
 			fftypes.NewRandB32(),
 		},
@@ -704,3 +801,189 @@ func TestPersistContextsFail(t *testing.T) {
 	assert.EqualError(t, err, "pop")
 	mdi.AssertExpectations(t)
 }
+
+func TestRetrieveBlobsOk(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	existingID := fftypes.NewUUID()
+	newData := &fftypes.Data{
+		ID:        fftypes.NewUUID(),
+		Namespace: "ns1",
+		Blobstore: true,
+		Blob:      &fftypes.BlobRef{PayloadRef: fftypes.NewRandB32()},
+	}
+	batch := &fftypes.Batch{
+		ID: fftypes.NewUUID(),
+		Payload: fftypes.BatchPayload{
+			Data: []*fftypes.Data{
+				{ID: fftypes.NewUUID(), Value: fftypes.Byteable(`{}`)},
+				{ID: fftypes.NewUUID(), Blobstore: true},
+				{ID: existingID, Blobstore: true, Blob: &fftypes.BlobRef{PayloadRef: fftypes.NewRandB32()}},
+				newData,
+			},
+		},
+	}
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetBlobs", em.ctx, mock.Anything).Return([]*fftypes.Blob{{Data: existingID}}, nil).Once()
+	mdi.On("GetBlobs", em.ctx, mock.Anything).Return([]*fftypes.Blob{}, nil).Once()
+	mpi := em.publicstorage.(*publicstoragemocks.Plugin)
+	mpi.On("RetrieveData", em.ctx, newData.Blob.PayloadRef).Return(ioutil.NopCloser(bytes.NewReader([]byte(`some data`))), nil)
+	mdm := em.data.(*datamocks.Manager)
+	mdm.On("CopyBlobToDX", em.ctx, newData, mock.Anything).Return(&fftypes.Blob{Hash: fftypes.NewRandB32()}, nil)
+
+	err := em.retrieveBlobs(batch)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+	mpi.AssertExpectations(t)
+	mdm.AssertExpectations(t)
+}
+
+func TestRetrieveBlobsGetBlobsFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	cancel() // retryable error
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetBlobs", em.ctx, mock.Anything).Return(nil, fmt.Errorf("pop"))
+
+	err := em.retrieveBlobs(&fftypes.Batch{
+		Payload: fftypes.BatchPayload{
+			Data: []*fftypes.Data{
+				{ID: fftypes.NewUUID(), Blobstore: true, Blob: &fftypes.BlobRef{PayloadRef: fftypes.NewRandB32()}},
+			},
+		},
+	})
+	assert.Regexp(t, "FF10158", err)
+}
+
+func TestRetrieveBlobsRetrieveDataFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetBlobs", em.ctx, mock.Anything).Return([]*fftypes.Blob{}, nil)
+	mpi := em.publicstorage.(*publicstoragemocks.Plugin)
+	mpi.On("RetrieveData", em.ctx, mock.Anything).Return(nil, fmt.Errorf("pop"))
+
+	err := em.retrieveBlobs(&fftypes.Batch{
+		Payload: fftypes.BatchPayload{
+			Data: []*fftypes.Data{
+				{ID: fftypes.NewUUID(), Blobstore: true, Blob: &fftypes.BlobRef{PayloadRef: fftypes.NewRandB32()}},
+			},
+		},
+	})
+	assert.NoError(t, err) // permanent failure, the aggregator marks the message invalid
+}
+
+func TestRetrieveBlobsCopyFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetBlobs", em.ctx, mock.Anything).Return([]*fftypes.Blob{}, nil)
+	mpi := em.publicstorage.(*publicstoragemocks.Plugin)
+	mpi.On("RetrieveData", em.ctx, mock.Anything).Return(ioutil.NopCloser(bytes.NewReader([]byte(`some data`))), nil)
+	mdm := em.data.(*datamocks.Manager)
+	mdm.On("CopyBlobToDX", em.ctx, mock.Anything, mock.Anything).Return(nil, fmt.Errorf("pop"))
+
+	err := em.retrieveBlobs(&fftypes.Batch{
+		Payload: fftypes.BatchPayload{
+			Data: []*fftypes.Data{
+				{ID: fftypes.NewUUID(), Blobstore: true, Blob: &fftypes.BlobRef{PayloadRef: fftypes.NewRandB32()}},
+			},
+		},
+	})
+	assert.NoError(t, err) // permanent failure, the aggregator marks the message invalid
+}
+
+func TestBatchPinCompleteBroadcastRetrieveBlobsFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	cancel() // retryable error
+
+	batch := &blockchain.BatchPin{
+		Namespace:      "ns1",
+		TransactionID:  fftypes.NewUUID(),
+		BatchID:        fftypes.NewUUID(),
+		BatchPaylodRef: fftypes.NewRandB32(),
+	}
+	batchData := &fftypes.Batch{
+		ID:        batch.BatchID,
+		Namespace: "ns1",
+		Author:    "0x12345",
+		Payload: fftypes.BatchPayload{
+			Data: []*fftypes.Data{
+				{ID: fftypes.NewUUID(), Blobstore: true, Blob: &fftypes.BlobRef{PayloadRef: fftypes.NewRandB32()}},
+			},
+		},
+	}
+	batchData.Hash = batchData.Payload.Hash()
+	batch.BatchHash = batchData.Hash
+	batchDataBytes, err := json.Marshal(&batchData)
+	assert.NoError(t, err)
+
+	mpi := em.publicstorage.(*publicstoragemocks.Plugin)
+	mpi.On("RetrieveData", mock.Anything, batch.BatchPaylodRef).
+		Return(ioutil.NopCloser(bytes.NewReader(batchDataBytes)), nil)
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetBlobs", mock.Anything, mock.Anything).Return(nil, fmt.Errorf("pop"))
+	mii := em.identity.(*identitymocks.Plugin)
+	mii.On("Resolve", mock.Anything, "0x12345").Return(&fftypes.Identity{OnChain: "0x12345"}, nil)
+
+	err = em.BatchPinComplete(&blockchainmocks.Plugin{}, nil, batch, "0x12345", "tx1", nil)
+	assert.Regexp(t, "FF10158", err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestBatchPinCompleteBroadcastInvalidBatchSkipsBlobs(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	batch := &blockchain.BatchPin{
+		Namespace:      "ns1",
+		TransactionID:  fftypes.NewUUID(),
+		BatchID:        fftypes.NewUUID(),
+		BatchPaylodRef: fftypes.NewRandB32(),
+		BatchHash:      fftypes.NewRandB32(),
+	}
+	batchData := &fftypes.Batch{
+		ID:        batch.BatchID,
+		Namespace: "ns1",
+		Author:    "0x12345",
+		Payload: fftypes.BatchPayload{
+			Data: []*fftypes.Data{
+				{ID: fftypes.NewUUID(), Blobstore: true, Blob: &fftypes.BlobRef{PayloadRef: fftypes.NewRandB32()}},
+			},
+		},
+	}
+	batchData.Hash = batchData.Payload.Hash()
+	batchDataBytes, err := json.Marshal(&batchData)
+	assert.NoError(t, err)
+
+	mpi := em.publicstorage.(*publicstoragemocks.Plugin)
+	mpi.On("RetrieveData", mock.Anything, batch.BatchPaylodRef).
+		Return(ioutil.NopCloser(bytes.NewReader(batchDataBytes)), nil)
+	mii := em.identity.(*identitymocks.Plugin)
+	mii.On("Resolve", mock.Anything, "0x12345").Return(&fftypes.Identity{OnChain: "0x12345"}, nil)
+	mdi := em.database.(*databasemocks.Plugin)
+	rag := mdi.On("RunAsGroup", mock.Anything, mock.Anything).Return(nil)
+	rag.RunFn = func(a mock.Arguments) {
+		rag.ReturnArguments = mock.Arguments{
+			a[1].(func(ctx context.Context) error)(a[0].(context.Context)),
+		}
+	}
+	mdi.On("GetTransactionByID", mock.Anything, uuidMatches(batch.TransactionID)).Return(nil, nil)
+	mdi.On("GetOperations", mock.Anything, mock.Anything).Return([]*fftypes.Operation{}, nil, nil)
+	mdi.On("UpsertTransaction", mock.Anything, mock.Anything, true, false).Return(nil)
+
+	err = em.BatchPinComplete(&blockchainmocks.Plugin{}, nil, batch, "0x12345", "tx1", nil)
+	assert.NoError(t, err)
+
+	// No blobs are retrieved, and the batch is not persisted, for a batch that does not match the transaction
+	mpi.AssertExpectations(t)
+	mdi.AssertExpectations(t)
+	mdi.AssertNotCalled(t, "GetBlobs", mock.Anything, mock.Anything)
+	mdi.AssertNotCalled(t, "UpsertBatch", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
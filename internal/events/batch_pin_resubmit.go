@@ -0,0 +1,208 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"context"
+	"math"
+	"regexp"
+	"time"
+
+	"github.com/hyperledger-labs/firefly/internal/config"
+	"github.com/hyperledger-labs/firefly/internal/i18n"
+	"github.com/hyperledger-labs/firefly/internal/log"
+	"github.com/hyperledger-labs/firefly/internal/metrics"
+	"github.com/hyperledger-labs/firefly/internal/retry"
+	"github.com/hyperledger-labs/firefly/pkg/blockchain"
+	"github.com/hyperledger-labs/firefly/pkg/database"
+	"github.com/hyperledger-labs/firefly/pkg/fftypes"
+)
+
+// resubmitPolicy determines whether, and when, a failed blockchain batch pin is submitted again
+type resubmitPolicy struct {
+	attempts        int
+	backoff         retry.Retry
+	retryableErrors []*regexp.Regexp
+}
+
+func newResubmitPolicy(ctx context.Context) (*resubmitPolicy, error) {
+	rp := &resubmitPolicy{
+		attempts: config.GetInt(config.EventResubmitAttempts),
+		backoff: retry.Retry{
+			InitialDelay: config.GetDuration(config.EventResubmitRetryInitDelay),
+			MaximumDelay: config.GetDuration(config.EventResubmitRetryMaxDelay),
+			Factor:       config.GetFloat64(config.EventResubmitRetryFactor),
+		},
+	}
+	for _, expr := range config.GetStringSlice(config.EventResubmitRetryableErrors) {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, i18n.WrapError(ctx, err, i18n.MsgInvalidResubmitErrorRegex, expr, err)
+		}
+		rp.retryableErrors = append(rp.retryableErrors, re)
+	}
+	return rp, nil
+}
+
+// retryable classifies the error reported for a failed transaction. With no patterns configured,
+// every failure is considered worth resubmitting.
+func (rp *resubmitPolicy) retryable(errorMessage string) bool {
+	if len(rp.retryableErrors) == 0 {
+		return true
+	}
+	for _, re := range rp.retryableErrors {
+		if re.MatchString(errorMessage) {
+			return true
+		}
+	}
+	return false
+}
+
+func (rp *resubmitPolicy) delay(attempt int) time.Duration {
+	factor := rp.backoff.Factor
+	if factor < 1 {
+		factor = 1
+	}
+	delay := time.Duration(float64(rp.backoff.InitialDelay) * math.Pow(factor, float64(attempt-1)))
+	if delay > rp.backoff.MaximumDelay {
+		delay = rp.backoff.MaximumDelay
+	}
+	return delay
+}
+
+// batchPinFailed is called when a blockchain_batch_pin operation has been reported as failed.
+// The same pin is submitted again under a new operation for the same transaction, until the
+// resubmission policy is exhausted - at which point the transaction is marked failed.
+//
+// The new operation is recorded as pending straight away, so the transaction is not considered failed,
+// but the submission itself happens after the backoff delay on a separate goroutine. This is because
+// we are called on the event loop of the blockchain plugin, which we must not hold up.
+func (em *eventManager) batchPinFailed(bi blockchain.Plugin, failedOp *fftypes.Operation, errorMessage string) error {
+	l := log.L(em.ctx)
+
+	submission, ok := blockchain.BatchPinSubmissionFromOperation(failedOp)
+	if !ok {
+		l.Errorf("Batch pin operation %s for transaction %s cannot be resubmitted: no input stored", failedOp.ID, failedOp.Transaction)
+		return em.transactionFailed(failedOp.Transaction)
+	}
+	if !em.resubmit.retryable(errorMessage) {
+		l.Errorf("Batch pin operation %s for transaction %s failed with non-retryable error: %s", failedOp.ID, failedOp.Transaction, errorMessage)
+		return em.transactionFailed(failedOp.Transaction)
+	}
+
+	fb := database.OperationQueryFactory.NewFilter(em.ctx)
+	previousOps, _, err := em.database.GetOperations(em.ctx, fb.And(
+		fb.Eq("tx", failedOp.Transaction),
+		fb.Eq("type", fftypes.OpTypeBlockchainBatchPin),
+	))
+	if err != nil {
+		return err
+	}
+	attempt := len(previousOps)
+	if attempt > em.resubmit.attempts {
+		l.Errorf("Batch pin for transaction %s failed after %d resubmissions: %s", failedOp.Transaction, em.resubmit.attempts, errorMessage)
+		return em.transactionFailed(failedOp.Transaction)
+	}
+
+	op := fftypes.NewTXOperation(
+		bi,
+		failedOp.Namespace,
+		failedOp.Transaction,
+		"",
+		fftypes.OpTypeBlockchainBatchPin,
+		fftypes.OpStatusPending,
+		"")
+	op.Input = failedOp.Input
+	if err := em.database.UpsertOperation(em.ctx, op, false); err != nil {
+		return err
+	}
+
+	delay := em.resubmit.delay(attempt)
+	l.Infof("Resubmitting batch pin for transaction %s in %s (attempt %d/%d)", failedOp.Transaction, delay, attempt, em.resubmit.attempts)
+	go em.resubmitBatchPin(bi, op, submission, delay)
+	return nil
+}
+
+// resubmitBatchPin waits for the backoff delay, then submits the pin and records the outcome on the operation.
+// Database errors are retried until we are shut down, as there is no caller to return them to.
+func (em *eventManager) resubmitBatchPin(bi blockchain.Plugin, op *fftypes.Operation, submission *blockchain.BatchPinSubmission, delay time.Duration) {
+	l := log.L(em.ctx)
+	select {
+	case <-time.After(delay):
+	case <-em.ctx.Done():
+		l.Debugf("Resubmission of batch pin for transaction %s cancelled", op.Transaction)
+		return
+	}
+
+	blockchainTrackingID, submitErr := bi.SubmitBatchPin(em.ctx, submission.LedgerID, submission.Signer, submission.BatchPin)
+	metrics.BlockchainSubmission(bi.Name(), submitErr)
+	update := database.OperationQueryFactory.NewUpdate(em.ctx).Set("backendid", blockchainTrackingID)
+	if submitErr != nil {
+		op.Status = fftypes.OpStatusFailed
+		op.Error = submitErr.Error()
+		update = update.Set("status", op.Status).Set("error", op.Error)
+	}
+	updated := false
+	err := em.retry.Do(em.ctx, "update resubmitted batch pin", func(attempt int) (retry bool, err error) {
+		// The operation is left alone if it was cancelled while we waited
+		updated, err = em.database.UpdateOperationIfStatus(em.ctx, op.ID, []fftypes.OpStatus{fftypes.OpStatusPending}, update)
+		return true, err
+	})
+	if err == nil && submitErr != nil && updated {
+		err = em.retry.Do(em.ctx, "resubmit batch pin", func(attempt int) (retry bool, err error) {
+			return true, em.batchPinFailed(bi, op, op.Error)
+		})
+	}
+	if err != nil {
+		l.Errorf("Resubmission of batch pin for transaction %s abandoned: %s", op.Transaction, err)
+	}
+}
+
+// transactionFailed marks the transaction as failed, and emits a transaction_failed event
+// for each message in the batch it was pinning
+func (em *eventManager) transactionFailed(txID *fftypes.UUID) error {
+	tx, err := em.database.GetTransactionByID(em.ctx, txID)
+	if err != nil {
+		return err
+	}
+	if tx == nil {
+		log.L(em.ctx).Warnf("Transaction %s not found", txID)
+		return nil
+	}
+	batch, err := em.database.GetBatchByID(em.ctx, tx.Subject.Reference)
+	if err != nil {
+		return err
+	}
+
+	return em.database.RunAsGroup(em.ctx, func(ctx context.Context) error {
+		update := database.TransactionQueryFactory.NewUpdate(ctx).Set("status", fftypes.OpStatusFailed)
+		if err := em.database.UpdateTransaction(ctx, tx.ID, update); err != nil {
+			return err
+		}
+		if batch == nil {
+			log.L(ctx).Warnf("Batch %s for failed transaction %s not found", tx.Subject.Reference, tx.ID)
+			return nil
+		}
+		for _, msg := range batch.Payload.Messages {
+			event := fftypes.NewEvent(fftypes.EventTypeTransactionFailed, msg.Header.Namespace, msg.Header.ID, msg.Header.Group)
+			if err := em.database.UpsertEvent(ctx, event, false); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,455 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hyperledger-labs/firefly/internal/config"
+	"github.com/hyperledger-labs/firefly/mocks/blockchainmocks"
+	"github.com/hyperledger-labs/firefly/mocks/broadcastmocks"
+	"github.com/hyperledger-labs/firefly/mocks/databasemocks"
+	"github.com/hyperledger-labs/firefly/mocks/datamocks"
+	"github.com/hyperledger-labs/firefly/mocks/identitymocks"
+	"github.com/hyperledger-labs/firefly/mocks/privatemessagingmocks"
+	"github.com/hyperledger-labs/firefly/mocks/publicstoragemocks"
+	"github.com/hyperledger-labs/firefly/pkg/blockchain"
+	"github.com/hyperledger-labs/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestResubmitEventManager(t *testing.T) (*eventManager, func()) {
+	em, cancel := newTestEventManager(t)
+	em.opCorrelationRetries = 0
+	em.resubmit.backoff.InitialDelay = 1 * time.Microsecond
+	em.resubmit.backoff.MaximumDelay = 1 * time.Microsecond
+	return em, cancel
+}
+
+func newTestFailedBatchPinOp() *fftypes.Operation {
+	submission := &blockchain.BatchPinSubmission{
+		LedgerID: fftypes.NewUUID(),
+		Signer:   &fftypes.Identity{Identifier: "org1", OnChain: "0x12345"},
+		BatchPin: &blockchain.BatchPin{
+			Namespace:     "ns1",
+			TransactionID: fftypes.NewUUID(),
+			BatchID:       fftypes.NewUUID(),
+			BatchHash:     fftypes.NewRandB32(),
+			Contexts:      []*fftypes.Bytes32{fftypes.NewRandB32()},
+		},
+	}
+	return &fftypes.Operation{
+		ID:          fftypes.NewUUID(),
+		Namespace:   "ns1",
+		Transaction: submission.BatchPin.TransactionID,
+		Type:        fftypes.OpTypeBlockchainBatchPin,
+		Status:      fftypes.OpStatusPending,
+		Plugin:      "ut",
+		Input:       submission.OperationInput(),
+	}
+}
+
+func mockTransactionFailed(em *eventManager, mdi *databasemocks.Plugin, txID *fftypes.UUID) *fftypes.Batch {
+	batch := &fftypes.Batch{
+		ID: fftypes.NewUUID(),
+		Payload: fftypes.BatchPayload{
+			Messages: []*fftypes.Message{
+				{Header: fftypes.MessageHeader{ID: fftypes.NewUUID(), Namespace: "ns1"}},
+				{Header: fftypes.MessageHeader{ID: fftypes.NewUUID(), Namespace: "ns1", Group: fftypes.NewRandB32()}},
+			},
+		},
+	}
+	mdi.On("GetTransactionByID", em.ctx, txID).Return(&fftypes.Transaction{
+		ID:      txID,
+		Subject: fftypes.TransactionSubject{Reference: batch.ID},
+	}, nil)
+	mdi.On("GetBatchByID", em.ctx, batch.ID).Return(batch, nil)
+	rag := mdi.On("RunAsGroup", em.ctx, mock.Anything)
+	rag.RunFn = func(a mock.Arguments) {
+		rag.ReturnArguments = mock.Arguments{
+			a[1].(func(ctx context.Context) error)(a[0].(context.Context)),
+		}
+	}
+	return batch
+}
+
+func TestNewEventManagerBadResubmitRegex(t *testing.T) {
+	config.Reset()
+	config.Set(config.EventResubmitRetryableErrors, []string{"[unclosed"})
+	_, err := NewEventManager(context.Background(), &publicstoragemocks.Plugin{}, &databasemocks.Plugin{}, &identitymocks.Plugin{}, &broadcastmocks.Manager{}, &privatemessagingmocks.Manager{}, &datamocks.Manager{})
+	assert.Regexp(t, "FF10273", err)
+}
+
+func TestResubmitPolicyRetryable(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	assert.True(t, em.resubmit.retryable("Transaction underpriced"))
+	assert.True(t, em.resubmit.retryable("intrinsic GAS too low"))
+	assert.False(t, em.resubmit.retryable("Transaction reverted"))
+
+	em.resubmit.retryableErrors = nil
+	assert.True(t, em.resubmit.retryable("Transaction reverted"))
+}
+
+func TestResubmitPolicyDelay(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	assert.Equal(t, 1*time.Second, em.resubmit.delay(1))
+	assert.Equal(t, 4*time.Second, em.resubmit.delay(3))
+	assert.Equal(t, 30*time.Second, em.resubmit.delay(10))
+
+	em.resubmit.backoff.Factor = 0
+	assert.Equal(t, 1*time.Second, em.resubmit.delay(5))
+}
+
+func TestTxSubmissionUpdateBatchPinResubmitted(t *testing.T) {
+	em, cancel := newTestResubmitEventManager(t)
+	defer cancel()
+	mdi := em.database.(*databasemocks.Plugin)
+	mbi := &blockchainmocks.Plugin{}
+
+	failedOp := newTestFailedBatchPinOp()
+	submission, _ := blockchain.BatchPinSubmissionFromOperation(failedOp)
+	mbi.On("Name").Return("ut")
+	mdi.On("GetOperations", em.ctx, mock.Anything).Return([]*fftypes.Operation{failedOp}, nil, nil)
+	rag := mdi.On("RunAsGroup", em.ctx, mock.Anything)
+	rag.RunFn = func(a mock.Arguments) {
+		rag.ReturnArguments = mock.Arguments{
+			a[1].(func(ctx context.Context) error)(a[0].(context.Context)),
+		}
+	}
+	mdi.On("UpdateOperationIfStatus", em.ctx, uuidMatches(failedOp.ID), mock.Anything, mock.Anything).Return(true, nil)
+	mdi.On("UpsertEvent", em.ctx, mock.MatchedBy(func(e *fftypes.Event) bool {
+		return e.Type == fftypes.EventTypeOperationFailed && *e.Reference == *failedOp.ID
+	}), false).Return(nil)
+	var newOp *fftypes.Operation
+	mdi.On("UpsertOperation", em.ctx, mock.MatchedBy(func(op *fftypes.Operation) bool {
+		return *op.Transaction == *failedOp.Transaction &&
+			*op.ID != *failedOp.ID &&
+			op.BackendID == "" &&
+			op.Type == fftypes.OpTypeBlockchainBatchPin &&
+			op.Status == fftypes.OpStatusPending &&
+			op.Input.GetObject("batchPin").GetString("namespace") == "ns1"
+	}), false).Return(nil).Run(func(args mock.Arguments) {
+		newOp = args[1].(*fftypes.Operation)
+	})
+	mdi.On("GetTransactionByID", em.ctx, failedOp.Transaction).Return(nil, nil)
+
+	// The resubmission happens asynchronously, after the receipt has been processed
+	resubmitted := make(chan struct{})
+	mbi.On("SubmitBatchPin", em.ctx, submission.LedgerID, submission.Signer, submission.BatchPin).Return("tracking67890", nil)
+	mdi.On("UpdateOperationIfStatus", em.ctx, mock.MatchedBy(func(id *fftypes.UUID) bool {
+		return newOp != nil && *id == *newOp.ID
+	}), []fftypes.OpStatus{fftypes.OpStatusPending}, mock.Anything).Return(true, nil).Run(func(args mock.Arguments) {
+		close(resubmitted)
+	})
+
+	err := em.TxSubmissionUpdate(mbi, "tracking12345", fftypes.OpStatusFailed, "", "replacement transaction underpriced", nil)
+	assert.NoError(t, err)
+	<-resubmitted
+
+	mbi.AssertExpectations(t)
+	mdi.AssertExpectations(t)
+}
+
+func TestTxSubmissionUpdateBatchPinAlreadyFailed(t *testing.T) {
+	em, cancel := newTestResubmitEventManager(t)
+	defer cancel()
+	mdi := em.database.(*databasemocks.Plugin)
+	mbi := &blockchainmocks.Plugin{}
+
+	failedOp := newTestFailedBatchPinOp()
+	failedOp.Status = fftypes.OpStatusFailed
+	mbi.On("Name").Return("ut")
+	mdi.On("GetOperations", em.ctx, mock.Anything).Return([]*fftypes.Operation{failedOp}, nil, nil)
+	mdi.On("UpdateOperationIfStatus", em.ctx, uuidMatches(failedOp.ID), mock.Anything, mock.Anything).Return(true, nil)
+
+	err := em.TxSubmissionUpdate(mbi, "tracking12345", fftypes.OpStatusFailed, "", "out of gas", nil)
+	assert.NoError(t, err)
+
+	mbi.AssertExpectations(t)
+	mdi.AssertExpectations(t)
+}
+
+func TestTxSubmissionUpdateBatchPinResubmitFail(t *testing.T) {
+	em, cancel := newTestResubmitEventManager(t)
+	defer cancel()
+	mdi := em.database.(*databasemocks.Plugin)
+	mbi := &blockchainmocks.Plugin{}
+
+	failedOp := newTestFailedBatchPinOp()
+	mbi.On("Name").Return("ut")
+	mdi.On("GetOperations", em.ctx, mock.Anything).Return([]*fftypes.Operation{failedOp}, nil, nil).Once()
+	mdi.On("GetOperations", em.ctx, mock.Anything).Return(nil, nil, fmt.Errorf("pop")).Once()
+	rag := mdi.On("RunAsGroup", em.ctx, mock.Anything)
+	rag.RunFn = func(a mock.Arguments) {
+		rag.ReturnArguments = mock.Arguments{
+			a[1].(func(ctx context.Context) error)(a[0].(context.Context)),
+		}
+	}
+	mdi.On("UpdateOperationIfStatus", em.ctx, uuidMatches(failedOp.ID), mock.Anything, mock.Anything).Return(true, nil)
+	mdi.On("UpsertEvent", em.ctx, mock.Anything, false).Return(nil)
+
+	err := em.TxSubmissionUpdate(mbi, "tracking12345", fftypes.OpStatusFailed, "", "out of gas", nil)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestTxSubmissionUpdateBatchPinResubmitTransactionStatusFail(t *testing.T) {
+	em, cancel := newTestResubmitEventManager(t)
+	defer cancel()
+	mdi := em.database.(*databasemocks.Plugin)
+	mbi := &blockchainmocks.Plugin{}
+	em.resubmit.backoff.InitialDelay = 1 * time.Minute
+	em.resubmit.backoff.MaximumDelay = 1 * time.Minute
+
+	failedOp := newTestFailedBatchPinOp()
+	mbi.On("Name").Return("ut")
+	mdi.On("GetOperations", em.ctx, mock.Anything).Return([]*fftypes.Operation{failedOp}, nil, nil)
+	rag := mdi.On("RunAsGroup", em.ctx, mock.Anything)
+	rag.RunFn = func(a mock.Arguments) {
+		rag.ReturnArguments = mock.Arguments{
+			a[1].(func(ctx context.Context) error)(a[0].(context.Context)),
+		}
+	}
+	mdi.On("UpdateOperationIfStatus", em.ctx, uuidMatches(failedOp.ID), mock.Anything, mock.Anything).Return(true, nil)
+	mdi.On("UpsertEvent", em.ctx, mock.Anything, false).Return(nil)
+	mdi.On("UpsertOperation", em.ctx, mock.Anything, false).Return(nil)
+	mdi.On("GetTransactionByID", em.ctx, failedOp.Transaction).Return(nil, fmt.Errorf("pop"))
+
+	err := em.TxSubmissionUpdate(mbi, "tracking12345", fftypes.OpStatusFailed, "", "out of gas", nil)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestBatchPinFailedNoInput(t *testing.T) {
+	em, cancel := newTestResubmitEventManager(t)
+	defer cancel()
+	mdi := em.database.(*databasemocks.Plugin)
+	mbi := &blockchainmocks.Plugin{}
+
+	failedOp := newTestFailedBatchPinOp()
+	failedOp.Input = nil
+	batch := mockTransactionFailed(em, mdi, failedOp.Transaction)
+	mdi.On("UpdateTransaction", mock.Anything, failedOp.Transaction, mock.Anything).Return(nil)
+	for _, msg := range batch.Payload.Messages {
+		msgID := msg.Header.ID
+		mdi.On("UpsertEvent", mock.Anything, mock.MatchedBy(func(e *fftypes.Event) bool {
+			return e.Type == fftypes.EventTypeTransactionFailed && *e.Reference == *msgID
+		}), false).Return(nil).Once()
+	}
+
+	err := em.batchPinFailed(mbi, failedOp, "out of gas")
+	assert.NoError(t, err)
+
+	mbi.AssertExpectations(t)
+	mdi.AssertExpectations(t)
+}
+
+func TestBatchPinFailedNotRetryable(t *testing.T) {
+	em, cancel := newTestResubmitEventManager(t)
+	defer cancel()
+	mdi := em.database.(*databasemocks.Plugin)
+	mbi := &blockchainmocks.Plugin{}
+
+	failedOp := newTestFailedBatchPinOp()
+	mockTransactionFailed(em, mdi, failedOp.Transaction)
+	mdi.On("UpdateTransaction", mock.Anything, failedOp.Transaction, mock.Anything).Return(nil)
+	mdi.On("UpsertEvent", mock.Anything, mock.Anything, false).Return(nil).Twice()
+
+	err := em.batchPinFailed(mbi, failedOp, "Transaction reverted")
+	assert.NoError(t, err)
+
+	mbi.AssertExpectations(t)
+	mdi.AssertExpectations(t)
+}
+
+func TestBatchPinFailedAttemptsExhausted(t *testing.T) {
+	em, cancel := newTestResubmitEventManager(t)
+	defer cancel()
+	mdi := em.database.(*databasemocks.Plugin)
+	mbi := &blockchainmocks.Plugin{}
+	em.resubmit.attempts = 1
+
+	failedOp := newTestFailedBatchPinOp()
+	mdi.On("GetOperations", em.ctx, mock.Anything).Return([]*fftypes.Operation{failedOp, failedOp}, nil, nil)
+	mockTransactionFailed(em, mdi, failedOp.Transaction)
+	mdi.On("UpdateTransaction", mock.Anything, failedOp.Transaction, mock.Anything).Return(nil)
+	mdi.On("UpsertEvent", mock.Anything, mock.Anything, false).Return(nil).Twice()
+
+	err := em.batchPinFailed(mbi, failedOp, "out of gas")
+	assert.NoError(t, err)
+
+	mbi.AssertExpectations(t)
+	mdi.AssertExpectations(t)
+}
+
+func TestResubmitBatchPinSubmitErrorThenExhausted(t *testing.T) {
+	em, cancel := newTestResubmitEventManager(t)
+	defer cancel()
+	mdi := em.database.(*databasemocks.Plugin)
+	mbi := &blockchainmocks.Plugin{}
+	em.resubmit.retryableErrors = nil
+	em.resubmit.attempts = 1
+
+	op := newTestFailedBatchPinOp()
+	submission, _ := blockchain.BatchPinSubmissionFromOperation(op)
+	mbi.On("Name").Return("ut")
+	mbi.On("SubmitBatchPin", em.ctx, submission.LedgerID, submission.Signer, submission.BatchPin).Return("", fmt.Errorf("pop"))
+	mdi.On("UpdateOperationIfStatus", em.ctx, op.ID, mock.Anything, mock.Anything).Return(true, nil)
+	mdi.On("GetOperations", em.ctx, mock.Anything).Return([]*fftypes.Operation{op, op}, nil, nil)
+	mockTransactionFailed(em, mdi, op.Transaction)
+	mdi.On("UpdateTransaction", mock.Anything, op.Transaction, mock.Anything).Return(nil)
+	mdi.On("UpsertEvent", mock.Anything, mock.Anything, false).Return(nil).Twice()
+
+	em.resubmitBatchPin(mbi, op, submission, 0)
+	assert.Equal(t, fftypes.OpStatusFailed, op.Status)
+	assert.Equal(t, "pop", op.Error)
+
+	mbi.AssertExpectations(t)
+	mdi.AssertExpectations(t)
+}
+
+func TestResubmitBatchPinUpdateOperationFail(t *testing.T) {
+	em, cancel := newTestResubmitEventManager(t)
+	mdi := em.database.(*databasemocks.Plugin)
+	mbi := &blockchainmocks.Plugin{}
+
+	op := newTestFailedBatchPinOp()
+	submission, _ := blockchain.BatchPinSubmissionFromOperation(op)
+	mbi.On("Name").Return("ut")
+	mbi.On("SubmitBatchPin", em.ctx, mock.Anything, mock.Anything, mock.Anything).Return("tracking67890", nil)
+	mdi.On("UpdateOperationIfStatus", em.ctx, op.ID, mock.Anything, mock.Anything).Return(false, fmt.Errorf("pop")).Run(func(args mock.Arguments) {
+		cancel()
+	})
+
+	em.resubmitBatchPin(mbi, op, submission, 0)
+
+	mbi.AssertExpectations(t)
+	mdi.AssertExpectations(t)
+}
+
+func TestBatchPinFailedUpsertOperationFail(t *testing.T) {
+	em, cancel := newTestResubmitEventManager(t)
+	defer cancel()
+	mdi := em.database.(*databasemocks.Plugin)
+	mbi := &blockchainmocks.Plugin{}
+
+	failedOp := newTestFailedBatchPinOp()
+	mbi.On("Name").Return("ut")
+	mdi.On("GetOperations", em.ctx, mock.Anything).Return([]*fftypes.Operation{failedOp}, nil, nil)
+	mdi.On("UpsertOperation", em.ctx, mock.Anything, false).Return(fmt.Errorf("pop"))
+
+	err := em.batchPinFailed(mbi, failedOp, "out of gas")
+	assert.EqualError(t, err, "pop")
+	mbi.AssertNotCalled(t, "SubmitBatchPin", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestResubmitBatchPinContextCancelled(t *testing.T) {
+	em, cancel := newTestResubmitEventManager(t)
+	mbi := &blockchainmocks.Plugin{}
+	cancel()
+
+	op := newTestFailedBatchPinOp()
+	submission, _ := blockchain.BatchPinSubmissionFromOperation(op)
+	em.resubmitBatchPin(mbi, op, submission, 1*time.Minute)
+
+	mbi.AssertExpectations(t)
+}
+
+func TestTransactionFailedGetTransactionFail(t *testing.T) {
+	em, cancel := newTestResubmitEventManager(t)
+	defer cancel()
+	mdi := em.database.(*databasemocks.Plugin)
+
+	mdi.On("GetTransactionByID", em.ctx, mock.Anything).Return(nil, fmt.Errorf("pop"))
+
+	err := em.transactionFailed(fftypes.NewUUID())
+	assert.EqualError(t, err, "pop")
+}
+
+func TestTransactionFailedTransactionNotFound(t *testing.T) {
+	em, cancel := newTestResubmitEventManager(t)
+	defer cancel()
+	mdi := em.database.(*databasemocks.Plugin)
+
+	mdi.On("GetTransactionByID", em.ctx, mock.Anything).Return(nil, nil)
+
+	err := em.transactionFailed(fftypes.NewUUID())
+	assert.NoError(t, err)
+}
+
+func TestTransactionFailedGetBatchFail(t *testing.T) {
+	em, cancel := newTestResubmitEventManager(t)
+	defer cancel()
+	mdi := em.database.(*databasemocks.Plugin)
+
+	mdi.On("GetTransactionByID", em.ctx, mock.Anything).Return(&fftypes.Transaction{ID: fftypes.NewUUID()}, nil)
+	mdi.On("GetBatchByID", em.ctx, mock.Anything).Return(nil, fmt.Errorf("pop"))
+
+	err := em.transactionFailed(fftypes.NewUUID())
+	assert.EqualError(t, err, "pop")
+}
+
+func TestTransactionFailedUpdateTransactionFail(t *testing.T) {
+	em, cancel := newTestResubmitEventManager(t)
+	defer cancel()
+	mdi := em.database.(*databasemocks.Plugin)
+
+	txID := fftypes.NewUUID()
+	mockTransactionFailed(em, mdi, txID)
+	mdi.On("UpdateTransaction", mock.Anything, txID, mock.Anything).Return(fmt.Errorf("pop"))
+
+	err := em.transactionFailed(txID)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestTransactionFailedUpsertEventFail(t *testing.T) {
+	em, cancel := newTestResubmitEventManager(t)
+	defer cancel()
+	mdi := em.database.(*databasemocks.Plugin)
+
+	txID := fftypes.NewUUID()
+	mockTransactionFailed(em, mdi, txID)
+	mdi.On("UpdateTransaction", mock.Anything, txID, mock.Anything).Return(nil)
+	mdi.On("UpsertEvent", mock.Anything, mock.Anything, false).Return(fmt.Errorf("pop"))
+
+	err := em.transactionFailed(txID)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestTransactionFailedBatchNotFound(t *testing.T) {
+	em, cancel := newTestResubmitEventManager(t)
+	defer cancel()
+	mdi := em.database.(*databasemocks.Plugin)
+
+	txID := fftypes.NewUUID()
+	mdi.On("GetTransactionByID", em.ctx, txID).Return(&fftypes.Transaction{ID: txID}, nil)
+	mdi.On("GetBatchByID", em.ctx, mock.Anything).Return(nil, nil)
+	rag := mdi.On("RunAsGroup", em.ctx, mock.Anything)
+	rag.RunFn = func(a mock.Arguments) {
+		rag.ReturnArguments = mock.Arguments{
+			a[1].(func(ctx context.Context) error)(a[0].(context.Context)),
+		}
+	}
+	mdi.On("UpdateTransaction", mock.Anything, txID, mock.Anything).Return(nil)
+
+	err := em.transactionFailed(txID)
+	assert.NoError(t, err)
+}
@@ -17,11 +17,13 @@
 package events
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 
 	"github.com/hyperledger-labs/firefly/internal/i18n"
 	"github.com/hyperledger-labs/firefly/internal/log"
+	"github.com/hyperledger-labs/firefly/internal/metrics"
 	"github.com/hyperledger-labs/firefly/pkg/database"
 	"github.com/hyperledger-labs/firefly/pkg/dataexchange"
 	"github.com/hyperledger-labs/firefly/pkg/fftypes"
@@ -45,7 +47,7 @@ func (em *eventManager) MessageReceived(dx dataexchange.Plugin, peerID string, d
 
 		// Find the node associated with the peer
 		filter := database.NodeQueryFactory.NewFilter(em.ctx).Eq("dx.peer", peerID)
-		nodes, err := em.database.GetNodes(em.ctx, filter)
+		nodes, _, err := em.database.GetNodes(em.ctx, filter)
 		if err != nil {
 			l.Errorf("Failed to retrieve node: %v", err)
 			return true, err // retry for persistence error
@@ -88,7 +90,15 @@ func (em *eventManager) MessageReceived(dx dataexchange.Plugin, peerID string, d
 			return false, nil
 		}
 
-		valid, err := em.persistBatch(em.ctx, &batch)
+		valid := false
+		err = em.database.RunAsGroup(em.ctx, func(ctx context.Context) (err error) {
+			valid, err = em.persistBatch(ctx, &batch)
+			if err == nil && valid && batch.Payload.TX.Type == fftypes.TransactionTypeNone {
+				// There will be no pins from the blockchain for this batch, so we record our own in arrival order
+				err = em.persistUnpinnedBatch(ctx, &batch)
+			}
+			return err
+		})
 		if err != nil {
 			l.Errorf("Batch received from %s/%s invalid: %s", node.Owner, node.Name, err)
 			return true, err // retry - persistBatch only returns retryable errors
@@ -102,11 +112,55 @@ func (em *eventManager) MessageReceived(dx dataexchange.Plugin, peerID string, d
 
 }
 
-func (em *eventManager) BLOBReceived(dx dataexchange.Plugin, peerID string, ns string, id fftypes.UUID) {
+func (em *eventManager) BLOBReceived(dx dataexchange.Plugin, peerID string, hash fftypes.Bytes32, ns string, id fftypes.UUID) {
+	l := log.L(em.ctx)
+	l.Debugf("Blob received event from data exchange: Peer='%s' Hash='%v' Namespace='%s' ID='%s'", peerID, &hash, ns, id)
+
+	// We process the event in a retry loop (which will break only if the context is closed), so that
+	// we only confirm consumption of the event to the plugin once we've processed it.
+	_ = em.retry.Do(em.ctx, "blob reference insert", func(attempt int) (retry bool, err error) {
+		batchIDs := make(map[fftypes.UUID]bool)
+		err = em.database.RunAsGroup(em.ctx, func(ctx context.Context) error {
+			// Insert the blob into the database
+			err := em.database.InsertBlob(ctx, &fftypes.Blob{
+				Namespace: ns,
+				Data:      &id,
+				Hash:      &hash,
+				Peer:      peerID,
+				Created:   fftypes.Now(),
+			})
+			if err != nil {
+				return err
+			}
+
+			// Find any messages that refer to this data, as they might now be ready to dispatch
+			msgs, _, err := em.database.GetMessagesForData(ctx, &id, database.MessageQueryFactory.NewFilter(ctx).Eq("namespace", ns))
+			if err != nil {
+				return err
+			}
+			for _, msg := range msgs {
+				if msg.BatchID != nil {
+					batchIDs[*msg.BatchID] = true
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return true, err
+		}
+
+		// Initiate rewinds for all the batches that might now be ready
+		for batchID := range batchIDs {
+			bid := batchID
+			em.aggregator.offchainBatches <- &bid
+		}
+		return false, nil
+	})
 }
 
 func (em *eventManager) TransferResult(dx dataexchange.Plugin, trackingID string, status fftypes.OpStatus, info string, additionalInfo fftypes.JSONObject) {
 	log.L(em.ctx).Infof("Transfer result %s=%s info='%s'", trackingID, status, info)
+	metrics.DataExchangeTransfer(dx.Name(), status)
 
 	// Find a matching operation, for this plugin, with the specified ID.
 	// We retry a few times, as there's an outside possibility of the event arriving before we're finished persisting the operation itself
@@ -117,7 +171,7 @@ func (em *eventManager) TransferResult(dx dataexchange.Plugin, trackingID string
 		fb.Eq("plugin", dx.Name()),
 	)
 	err := em.retry.Do(em.ctx, fmt.Sprintf("correlate transfer %s", trackingID), func(attempt int) (retry bool, err error) {
-		operations, err = em.database.GetOperations(em.ctx, filter)
+		operations, _, err = em.database.GetOperations(em.ctx, filter)
 		if err == nil && len(operations) == 0 {
 			err = i18n.NewError(em.ctx, i18n.Msg404NotFound)
 		}
@@ -133,7 +187,19 @@ func (em *eventManager) TransferResult(dx dataexchange.Plugin, trackingID string
 		Set("error", info).
 		Set("info", additionalInfo)
 	for _, op := range operations {
-		if err := em.database.UpdateOperation(em.ctx, op.ID, update); err != nil {
+		statusChanged := status != op.Status
+		err := em.database.RunAsGroup(em.ctx, func(ctx context.Context) error {
+			updated, err := em.updateOperation(ctx, op, update)
+			if err != nil || !updated || !statusChanged {
+				return err
+			}
+			if err := em.writeEvents(ctx, operationEvents(op, status)); err != nil {
+				return err
+			}
+			return em.updateTransactionStatus(ctx, op.Transaction)
+		})
+		if err != nil {
+			log.L(em.ctx).Errorf("Failed to update operation %s: %s", op.ID, err)
 			return
 		}
 	}
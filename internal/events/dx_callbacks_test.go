@@ -17,6 +17,7 @@
 package events
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"testing"
@@ -24,6 +25,7 @@ import (
 	"github.com/hyperledger-labs/firefly/mocks/databasemocks"
 	"github.com/hyperledger-labs/firefly/mocks/dataexchangemocks"
 	"github.com/hyperledger-labs/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -47,13 +49,19 @@ func TestMessageReceiveOK(t *testing.T) {
 	mdx := &dataexchangemocks.Plugin{}
 	mdi.On("GetNodes", em.ctx, mock.Anything).Return([]*fftypes.Node{
 		{Name: "node1", Owner: "parentOrg"},
-	}, nil)
+	}, nil, nil)
 	mdi.On("GetOrganizationByIdentity", em.ctx, "signingOrg").Return(&fftypes.Organization{
 		Identity: "signingOrg", Parent: "parentOrg",
 	}, nil)
 	mdi.On("GetOrganizationByIdentity", em.ctx, "parentOrg").Return(&fftypes.Organization{
 		Identity: "parentOrg",
 	}, nil)
+	rag := mdi.On("RunAsGroup", em.ctx, mock.Anything)
+	rag.RunFn = func(a mock.Arguments) {
+		rag.ReturnArguments = mock.Arguments{
+			a[1].(func(context.Context) error)(a[0].(context.Context)),
+		}
+	}
 	mdi.On("UpsertBatch", em.ctx, mock.Anything, true, false).Return(nil, nil)
 	em.MessageReceived(mdx, "peer1", b)
 
@@ -75,13 +83,19 @@ func TestMessageReceiveOkBadBatchIgnored(t *testing.T) {
 	mdx := &dataexchangemocks.Plugin{}
 	mdi.On("GetNodes", em.ctx, mock.Anything).Return([]*fftypes.Node{
 		{Name: "node1", Owner: "parentOrg"},
-	}, nil)
+	}, nil, nil)
 	mdi.On("GetOrganizationByIdentity", em.ctx, "signingOrg").Return(&fftypes.Organization{
 		Identity: "signingOrg", Parent: "parentOrg",
 	}, nil)
 	mdi.On("GetOrganizationByIdentity", em.ctx, "parentOrg").Return(&fftypes.Organization{
 		Identity: "parentOrg",
 	}, nil)
+	rag := mdi.On("RunAsGroup", em.ctx, mock.Anything)
+	rag.RunFn = func(a mock.Arguments) {
+		rag.ReturnArguments = mock.Arguments{
+			a[1].(func(context.Context) error)(a[0].(context.Context)),
+		}
+	}
 	em.MessageReceived(mdx, "peer1", b)
 
 	mdi.AssertExpectations(t)
@@ -108,13 +122,19 @@ func TestMessageReceivePersistBatchError(t *testing.T) {
 	mdx := &dataexchangemocks.Plugin{}
 	mdi.On("GetNodes", em.ctx, mock.Anything).Return([]*fftypes.Node{
 		{Name: "node1", Owner: "parentOrg"},
-	}, nil)
+	}, nil, nil)
 	mdi.On("GetOrganizationByIdentity", em.ctx, "signingOrg").Return(&fftypes.Organization{
 		Identity: "signingOrg", Parent: "parentOrg",
 	}, nil)
 	mdi.On("GetOrganizationByIdentity", em.ctx, "parentOrg").Return(&fftypes.Organization{
 		Identity: "parentOrg",
 	}, nil)
+	rag := mdi.On("RunAsGroup", em.ctx, mock.Anything)
+	rag.RunFn = func(a mock.Arguments) {
+		rag.ReturnArguments = mock.Arguments{
+			a[1].(func(context.Context) error)(a[0].(context.Context)),
+		}
+	}
 	mdi.On("UpsertBatch", em.ctx, mock.Anything, true, false).Return(fmt.Errorf("pop"))
 	em.MessageReceived(mdx, "peer1", b)
 
@@ -122,6 +142,169 @@ func TestMessageReceivePersistBatchError(t *testing.T) {
 	mdx.AssertExpectations(t)
 }
 
+func TestMessageReceiveUnpinnedOK(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	group := fftypes.NewRandB32()
+	msg := &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			ID:     fftypes.NewUUID(),
+			Author: "signingOrg",
+			Group:  group,
+			TxType: fftypes.TransactionTypeNone,
+			Topics: fftypes.FFNameArray{"topic1", "topic2"},
+		},
+	}
+	err := msg.Seal(em.ctx)
+	assert.NoError(t, err)
+	batch := &fftypes.Batch{
+		ID:     fftypes.NewUUID(),
+		Author: "signingOrg",
+		Group:  group,
+		Payload: fftypes.BatchPayload{
+			TX: fftypes.TransactionRef{
+				Type: fftypes.TransactionTypeNone,
+				ID:   fftypes.NewUUID(),
+			},
+			Messages: []*fftypes.Message{msg, msg},
+		},
+	}
+	batch.Hash = batch.Payload.Hash()
+	b, _ := json.Marshal(batch)
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdx := &dataexchangemocks.Plugin{}
+	mdi.On("GetNodes", em.ctx, mock.Anything).Return([]*fftypes.Node{
+		{Name: "node1", Owner: "parentOrg"},
+	}, nil, nil)
+	mdi.On("GetOrganizationByIdentity", em.ctx, "signingOrg").Return(&fftypes.Organization{
+		Identity: "signingOrg", Parent: "parentOrg",
+	}, nil)
+	mdi.On("GetOrganizationByIdentity", em.ctx, "parentOrg").Return(&fftypes.Organization{
+		Identity: "parentOrg",
+	}, nil)
+	rag := mdi.On("RunAsGroup", em.ctx, mock.Anything)
+	rag.RunFn = func(a mock.Arguments) {
+		rag.ReturnArguments = mock.Arguments{
+			a[1].(func(context.Context) error)(a[0].(context.Context)),
+		}
+	}
+	mdi.On("UpsertBatch", em.ctx, mock.Anything, true, false).Return(nil, nil)
+	mdi.On("UpsertMessage", em.ctx, mock.Anything, true, false).Return(nil)
+	var pins []*fftypes.Pin
+	mdi.On("UpsertPin", em.ctx, mock.Anything).Run(func(args mock.Arguments) {
+		pins = append(pins, args[1].(*fftypes.Pin))
+	}).Return(nil)
+	em.MessageReceived(mdx, "peer1", b)
+
+	// One pin for each message, on the same sender context, at the index of its first topic
+	assert.Len(t, pins, 2)
+	assert.Equal(t, *pins[0].Hash, *pins[1].Hash)
+	assert.False(t, pins[0].Masked)
+	assert.Nil(t, pins[0].Ledger)
+	assert.Equal(t, int64(0), pins[0].Index)
+	assert.Equal(t, int64(2), pins[1].Index)
+	assert.Equal(t, *batch.ID, *pins[1].Batch)
+
+	mdi.AssertExpectations(t)
+	mdx.AssertExpectations(t)
+}
+
+func TestMessageReceiveUnpinnedPinFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	cancel() // retryable error
+
+	group := fftypes.NewRandB32()
+	msg := &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			ID:     fftypes.NewUUID(),
+			Author: "signingOrg",
+			Group:  group,
+			TxType: fftypes.TransactionTypeNone,
+		},
+	}
+	err := msg.Seal(em.ctx)
+	assert.NoError(t, err)
+	batch := &fftypes.Batch{
+		ID:     fftypes.NewUUID(),
+		Author: "signingOrg",
+		Group:  group,
+		Payload: fftypes.BatchPayload{
+			TX: fftypes.TransactionRef{
+				Type: fftypes.TransactionTypeNone,
+				ID:   fftypes.NewUUID(),
+			},
+			Messages: []*fftypes.Message{msg},
+		},
+	}
+	batch.Hash = batch.Payload.Hash()
+	b, _ := json.Marshal(batch)
+
+	mdi := em.database.(*databasemocks.Plugin)
+	mdx := &dataexchangemocks.Plugin{}
+	mdi.On("GetNodes", em.ctx, mock.Anything).Return([]*fftypes.Node{
+		{Name: "node1", Owner: "parentOrg"},
+	}, nil, nil)
+	mdi.On("GetOrganizationByIdentity", em.ctx, "signingOrg").Return(&fftypes.Organization{
+		Identity: "signingOrg", Parent: "parentOrg",
+	}, nil)
+	mdi.On("GetOrganizationByIdentity", em.ctx, "parentOrg").Return(&fftypes.Organization{
+		Identity: "parentOrg",
+	}, nil)
+	rag := mdi.On("RunAsGroup", em.ctx, mock.Anything)
+	rag.RunFn = func(a mock.Arguments) {
+		rag.ReturnArguments = mock.Arguments{
+			a[1].(func(context.Context) error)(a[0].(context.Context)),
+		}
+	}
+	mdi.On("UpsertBatch", em.ctx, mock.Anything, true, false).Return(nil, nil)
+	mdi.On("UpsertMessage", em.ctx, mock.Anything, true, false).Return(nil)
+	mdi.On("UpsertPin", em.ctx, mock.Anything).Return(fmt.Errorf("pop"))
+	em.MessageReceived(mdx, "peer1", b)
+
+	mdi.AssertExpectations(t)
+	mdx.AssertExpectations(t)
+}
+
+func TestPersistUnpinnedBatchNoGroup(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	err := em.persistUnpinnedBatch(em.ctx, &fftypes.Batch{
+		ID: fftypes.NewUUID(),
+	})
+	assert.NoError(t, err)
+}
+
+func TestPersistUnpinnedBatchSkipsInvalidMessages(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	group := fftypes.NewRandB32()
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("UpsertPin", em.ctx, mock.MatchedBy(func(pin *fftypes.Pin) bool {
+		return pin.Index == 2
+	})).Return(nil).Once()
+
+	err := em.persistUnpinnedBatch(em.ctx, &fftypes.Batch{
+		ID:     fftypes.NewUUID(),
+		Author: "author1",
+		Group:  group,
+		Payload: fftypes.BatchPayload{
+			Messages: []*fftypes.Message{
+				nil,
+				{Header: fftypes.MessageHeader{Author: "author1", Group: group, Topics: fftypes.FFNameArray{"t1"}}},
+				{Header: fftypes.MessageHeader{Author: "author2", Group: group, TxType: fftypes.TransactionTypeNone, Topics: fftypes.FFNameArray{"t1"}}},
+				{Header: fftypes.MessageHeader{Author: "author1", Group: group, TxType: fftypes.TransactionTypeNone, Topics: fftypes.FFNameArray{"t1"}}},
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
+
 func TestMessageReceivedBadData(t *testing.T) {
 	em, cancel := newTestEventManager(t)
 	defer cancel()
@@ -139,7 +322,7 @@ func TestMessageReceiveNodeLookupError(t *testing.T) {
 
 	mdi := em.database.(*databasemocks.Plugin)
 	mdx := &dataexchangemocks.Plugin{}
-	mdi.On("GetNodes", em.ctx, mock.Anything).Return(nil, fmt.Errorf("pop"))
+	mdi.On("GetNodes", em.ctx, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
 	em.MessageReceived(mdx, "peer1", b)
 }
 
@@ -152,7 +335,7 @@ func TestMessageReceiveNodeNotFound(t *testing.T) {
 
 	mdi := em.database.(*databasemocks.Plugin)
 	mdx := &dataexchangemocks.Plugin{}
-	mdi.On("GetNodes", em.ctx, mock.Anything).Return(nil, nil)
+	mdi.On("GetNodes", em.ctx, mock.Anything).Return(nil, nil, nil)
 	em.MessageReceived(mdx, "peer1", b)
 }
 
@@ -167,7 +350,7 @@ func TestMessageReceiveAuthorLookupError(t *testing.T) {
 	mdx := &dataexchangemocks.Plugin{}
 	mdi.On("GetNodes", em.ctx, mock.Anything).Return([]*fftypes.Node{
 		{Name: "node1", Owner: "org1"},
-	}, nil)
+	}, nil, nil)
 	mdi.On("GetOrganizationByIdentity", em.ctx, mock.Anything).Return(nil, fmt.Errorf("pop"))
 	em.MessageReceived(mdx, "peer1", b)
 }
@@ -183,7 +366,7 @@ func TestMessageReceiveAuthorNotFound(t *testing.T) {
 	mdx := &dataexchangemocks.Plugin{}
 	mdi.On("GetNodes", em.ctx, mock.Anything).Return([]*fftypes.Node{
 		{Name: "node1", Owner: "org1"},
-	}, nil)
+	}, nil, nil)
 	mdi.On("GetOrganizationByIdentity", em.ctx, mock.Anything).Return(nil, nil)
 	em.MessageReceived(mdx, "peer1", b)
 }
@@ -202,7 +385,7 @@ func TestMessageReceiveGetCandidateOrgFail(t *testing.T) {
 	mdx := &dataexchangemocks.Plugin{}
 	mdi.On("GetNodes", em.ctx, mock.Anything).Return([]*fftypes.Node{
 		{Name: "node1", Owner: "parentOrg"},
-	}, nil)
+	}, nil, nil)
 	mdi.On("GetOrganizationByIdentity", em.ctx, "signingOrg").Return(&fftypes.Organization{
 		Identity: "signingOrg", Parent: "parentOrg",
 	}, nil)
@@ -227,7 +410,7 @@ func TestMessageReceiveGetCandidateOrgNotFound(t *testing.T) {
 	mdx := &dataexchangemocks.Plugin{}
 	mdi.On("GetNodes", em.ctx, mock.Anything).Return([]*fftypes.Node{
 		{Name: "node1", Owner: "parentOrg"},
-	}, nil)
+	}, nil, nil)
 	mdi.On("GetOrganizationByIdentity", em.ctx, "signingOrg").Return(&fftypes.Organization{
 		Identity: "signingOrg", Parent: "parentOrg",
 	}, nil)
@@ -252,7 +435,7 @@ func TestMessageReceiveGetCandidateOrgNotMatch(t *testing.T) {
 	mdx := &dataexchangemocks.Plugin{}
 	mdi.On("GetNodes", em.ctx, mock.Anything).Return([]*fftypes.Node{
 		{Name: "node1", Owner: "another"},
-	}, nil)
+	}, nil, nil)
 	mdi.On("GetOrganizationByIdentity", em.ctx, "signingOrg").Return(&fftypes.Organization{
 		Identity: "signingOrg", Parent: "parentOrg",
 	}, nil)
@@ -265,13 +448,79 @@ func TestMessageReceiveGetCandidateOrgNotMatch(t *testing.T) {
 	mdx.AssertExpectations(t)
 }
 
-func TestBLOBReceivedNoop(t *testing.T) {
+func TestBLOBReceivedTriggersRewindOk(t *testing.T) {
 	em, cancel := newTestEventManager(t)
 	defer cancel()
+	hash := fftypes.NewRandB32()
+	dataID := fftypes.NewUUID()
+	batchID := fftypes.NewUUID()
+
+	mdx := &dataexchangemocks.Plugin{}
+	mdi := em.database.(*databasemocks.Plugin)
+	rag := mdi.On("RunAsGroup", em.ctx, mock.Anything)
+	rag.RunFn = func(a mock.Arguments) {
+		rag.ReturnArguments = mock.Arguments{
+			a[1].(func(context.Context) error)(a[0].(context.Context)),
+		}
+	}
+	mdi.On("InsertBlob", em.ctx, mock.MatchedBy(func(blob *fftypes.Blob) bool {
+		return blob.Peer == "peer1" && *blob.Hash == *hash && *blob.Data == *dataID
+	})).Return(nil)
+	mdi.On("GetMessagesForData", em.ctx, dataID, mock.Anything).Return([]*fftypes.Message{
+		{BatchID: batchID},
+		{BatchID: batchID},
+		{ /* no batch */ },
+	}, nil, nil)
+
+	em.BLOBReceived(mdx, "peer1", *hash, "ns1", *dataID)
+
+	bid := <-em.aggregator.offchainBatches
+	assert.Equal(t, *batchID, *bid)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestBLOBReceivedInsertBlobFails(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	cancel() // retryable error
+	hash := fftypes.NewRandB32()
+	dataID := fftypes.NewUUID()
+
+	mdx := &dataexchangemocks.Plugin{}
+	mdi := em.database.(*databasemocks.Plugin)
+	rag := mdi.On("RunAsGroup", em.ctx, mock.Anything)
+	rag.RunFn = func(a mock.Arguments) {
+		rag.ReturnArguments = mock.Arguments{
+			a[1].(func(context.Context) error)(a[0].(context.Context)),
+		}
+	}
+	mdi.On("InsertBlob", em.ctx, mock.Anything).Return(fmt.Errorf("pop"))
+
+	em.BLOBReceived(mdx, "peer1", *hash, "ns1", *dataID)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestBLOBReceivedGetMessagesFails(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	cancel() // retryable error
+	hash := fftypes.NewRandB32()
+	dataID := fftypes.NewUUID()
 
 	mdx := &dataexchangemocks.Plugin{}
-	u := fftypes.NewUUID()
-	em.BLOBReceived(mdx, "peer1", "ns1", *u)
+	mdi := em.database.(*databasemocks.Plugin)
+	rag := mdi.On("RunAsGroup", em.ctx, mock.Anything)
+	rag.RunFn = func(a mock.Arguments) {
+		rag.ReturnArguments = mock.Arguments{
+			a[1].(func(context.Context) error)(a[0].(context.Context)),
+		}
+	}
+	mdi.On("InsertBlob", em.ctx, mock.Anything).Return(nil)
+	mdi.On("GetMessagesForData", em.ctx, dataID, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+
+	em.BLOBReceived(mdx, "peer1", *hash, "ns1", *dataID)
+
+	mdi.AssertExpectations(t)
 }
 
 func TestTransferResultOk(t *testing.T) {
@@ -285,13 +534,109 @@ func TestTransferResultOk(t *testing.T) {
 			ID:        id,
 			BackendID: "tracking12345",
 		},
-	}, nil)
-	mdi.On("UpdateOperation", mock.Anything, id, mock.Anything).Return(nil)
+	}, nil, nil)
+	rag := mdi.On("RunAsGroup", mock.Anything, mock.Anything)
+	rag.RunFn = func(a mock.Arguments) {
+		rag.ReturnArguments = mock.Arguments{
+			a[1].(func(ctx context.Context) error)(a[0].(context.Context)),
+		}
+	}
+	mdi.On("UpdateOperationIfStatus", mock.Anything, id, mock.Anything, mock.Anything).Return(true, nil)
+	mdi.On("UpsertEvent", mock.Anything, mock.MatchedBy(func(e *fftypes.Event) bool {
+		return e.Type == fftypes.EventTypeOperationFailed && *e.Reference == *id
+	}), false).Return(nil)
+	mdi.On("GetTransactionByID", mock.Anything, mock.Anything).Return(nil, nil)
 
 	mdx := &dataexchangemocks.Plugin{}
 	mdx.On("Name").Return("utdx")
 	em.TransferResult(mdx, "tracking12345", fftypes.OpStatusFailed, "error info", fftypes.JSONObject{"extra": "info"})
 
+	mdi.AssertExpectations(t)
+}
+
+func TestTransferResultTransactionStatusFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	mdi := em.database.(*databasemocks.Plugin)
+	id := fftypes.NewUUID()
+	mdi.On("GetOperations", mock.Anything, mock.Anything).Return([]*fftypes.Operation{
+		{
+			ID:        id,
+			BackendID: "tracking12345",
+		},
+	}, nil, nil)
+	rag := mdi.On("RunAsGroup", mock.Anything, mock.Anything)
+	rag.RunFn = func(a mock.Arguments) {
+		rag.ReturnArguments = mock.Arguments{
+			a[1].(func(ctx context.Context) error)(a[0].(context.Context)),
+		}
+	}
+	mdi.On("UpdateOperationIfStatus", mock.Anything, id, mock.Anything, mock.Anything).Return(true, nil)
+	mdi.On("UpsertEvent", mock.Anything, mock.Anything, false).Return(nil)
+	mdi.On("GetTransactionByID", mock.Anything, mock.Anything).Return(nil, fmt.Errorf("pop"))
+
+	mdx := &dataexchangemocks.Plugin{}
+	mdx.On("Name").Return("utdx")
+	em.TransferResult(mdx, "tracking12345", fftypes.OpStatusSucceeded, "", nil)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestTransferResultRedelivery(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	mdi := em.database.(*databasemocks.Plugin)
+	id := fftypes.NewUUID()
+	mdi.On("GetOperations", mock.Anything, mock.Anything).Return([]*fftypes.Operation{
+		{
+			ID:        id,
+			BackendID: "tracking12345",
+			Status:    fftypes.OpStatusSucceeded,
+		},
+	}, nil, nil)
+	rag := mdi.On("RunAsGroup", mock.Anything, mock.Anything)
+	rag.RunFn = func(a mock.Arguments) {
+		rag.ReturnArguments = mock.Arguments{
+			a[1].(func(ctx context.Context) error)(a[0].(context.Context)),
+		}
+	}
+	mdi.On("UpdateOperationIfStatus", mock.Anything, id, mock.Anything, mock.Anything).Return(true, nil)
+
+	mdx := &dataexchangemocks.Plugin{}
+	mdx.On("Name").Return("utdx")
+	em.TransferResult(mdx, "tracking12345", fftypes.OpStatusSucceeded, "", nil)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestTransferResultEventFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+
+	mdi := em.database.(*databasemocks.Plugin)
+	id := fftypes.NewUUID()
+	mdi.On("GetOperations", mock.Anything, mock.Anything).Return([]*fftypes.Operation{
+		{
+			ID:        id,
+			BackendID: "tracking12345",
+		},
+	}, nil, nil)
+	rag := mdi.On("RunAsGroup", mock.Anything, mock.Anything)
+	rag.RunFn = func(a mock.Arguments) {
+		rag.ReturnArguments = mock.Arguments{
+			a[1].(func(ctx context.Context) error)(a[0].(context.Context)),
+		}
+	}
+	mdi.On("UpdateOperationIfStatus", mock.Anything, id, mock.Anything, mock.Anything).Return(true, nil)
+	mdi.On("UpsertEvent", mock.Anything, mock.Anything, false).Return(fmt.Errorf("pop"))
+
+	mdx := &dataexchangemocks.Plugin{}
+	mdx.On("Name").Return("utdx")
+	em.TransferResult(mdx, "tracking12345", fftypes.OpStatusSucceeded, "", nil)
+
+	mdi.AssertExpectations(t)
 }
 
 func TestTransferResultNotFound(t *testing.T) {
@@ -299,7 +644,7 @@ func TestTransferResultNotFound(t *testing.T) {
 	cancel() // avoid retries
 
 	mdi := em.database.(*databasemocks.Plugin)
-	mdi.On("GetOperations", mock.Anything, mock.Anything).Return([]*fftypes.Operation{}, nil)
+	mdi.On("GetOperations", mock.Anything, mock.Anything).Return([]*fftypes.Operation{}, nil, nil)
 
 	mdx := &dataexchangemocks.Plugin{}
 	mdx.On("Name").Return("utdx")
@@ -318,8 +663,14 @@ func TestTransferUpdateFail(t *testing.T) {
 			ID:        id,
 			BackendID: "tracking12345",
 		},
-	}, nil)
-	mdi.On("UpdateOperation", mock.Anything, id, mock.Anything).Return(fmt.Errorf("pop"))
+	}, nil, nil)
+	rag := mdi.On("RunAsGroup", mock.Anything, mock.Anything)
+	rag.RunFn = func(a mock.Arguments) {
+		rag.ReturnArguments = mock.Arguments{
+			a[1].(func(ctx context.Context) error)(a[0].(context.Context)),
+		}
+	}
+	mdi.On("UpdateOperationIfStatus", mock.Anything, id, mock.Anything, mock.Anything).Return(false, fmt.Errorf("pop"))
 
 	mdx := &dataexchangemocks.Plugin{}
 	mdx.On("Name").Return("utdx")
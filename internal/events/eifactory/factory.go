@@ -20,6 +20,7 @@ import (
 	"context"
 
 	"github.com/hyperledger-labs/firefly/internal/config"
+	"github.com/hyperledger-labs/firefly/internal/events/webhooks"
 	"github.com/hyperledger-labs/firefly/internal/events/websockets"
 	"github.com/hyperledger-labs/firefly/internal/i18n"
 	"github.com/hyperledger-labs/firefly/pkg/events"
@@ -27,6 +28,7 @@ import (
 
 var plugins = []events.Plugin{
 	&websockets.WebSockets{},
+	&webhooks.WebHooks{},
 }
 
 var pluginsByName = make(map[string]events.Plugin)
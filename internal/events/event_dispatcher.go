@@ -21,10 +21,14 @@ import (
 	"database/sql/driver"
 	"fmt"
 	"sync"
+	"time"
 
+	"github.com/hyperledger-labs/firefly/internal/broadcast"
 	"github.com/hyperledger-labs/firefly/internal/config"
 	"github.com/hyperledger-labs/firefly/internal/i18n"
 	"github.com/hyperledger-labs/firefly/internal/log"
+	"github.com/hyperledger-labs/firefly/internal/metrics"
+	"github.com/hyperledger-labs/firefly/internal/privatemessaging"
 	"github.com/hyperledger-labs/firefly/internal/retry"
 	"github.com/hyperledger-labs/firefly/pkg/database"
 	"github.com/hyperledger-labs/firefly/pkg/events"
@@ -44,6 +48,8 @@ type eventDispatcher struct {
 	connID        string
 	ctx           context.Context
 	database      database.Plugin
+	broadcast     broadcast.Manager
+	messaging     privatemessaging.Manager
 	transport     events.Plugin
 	elected       bool
 	eventPoller   *eventPoller
@@ -53,9 +59,12 @@ type eventDispatcher struct {
 	namespace     string
 	readAhead     int
 	subscription  *subscription
+
+	redeliveryRetry retry.Retry
+	redeliveryDelay time.Duration
 }
 
-func newEventDispatcher(ctx context.Context, ei events.Plugin, di database.Plugin, connID string, sub *subscription, en *eventNotifier) *eventDispatcher {
+func newEventDispatcher(ctx context.Context, ei events.Plugin, di database.Plugin, bm broadcast.Manager, pm privatemessaging.Manager, connID string, sub *subscription, en *eventNotifier) *eventDispatcher {
 	ctx, cancelCtx := context.WithCancel(ctx)
 	readAhead := int(config.GetUint(config.SubscriptionDefaultsReadAhead))
 	ed := &eventDispatcher{
@@ -63,6 +72,8 @@ func newEventDispatcher(ctx context.Context, ei events.Plugin, di database.Plugi
 			"role", fmt.Sprintf("ed[%s]", connID)),
 			"sub", fmt.Sprintf("%s/%s:%s", sub.definition.ID, sub.definition.Namespace, sub.definition.Name)),
 		database:      di,
+		broadcast:     bm,
+		messaging:     pm,
 		transport:     ei,
 		connID:        connID,
 		cancelCtx:     cancelCtx,
@@ -73,6 +84,11 @@ func newEventDispatcher(ctx context.Context, ei events.Plugin, di database.Plugi
 		readAhead:     readAhead,
 		acksNacks:     make(chan ackNack),
 		closed:        make(chan struct{}),
+		redeliveryRetry: retry.Retry{
+			InitialDelay: config.GetDuration(config.SubscriptionsRetryInitialDelay),
+			MaximumDelay: config.GetDuration(config.SubscriptionsRetryMaxDelay),
+			Factor:       config.GetFloat64(config.SubscriptionsRetryFactor),
+		},
 	}
 
 	pollerConf := &eventPollerConf{
@@ -134,7 +150,7 @@ func (ed *eventDispatcher) electAndStart() {
 }
 
 func (ed *eventDispatcher) getEvents(ctx context.Context, filter database.Filter) ([]fftypes.LocallySequenced, error) {
-	events, err := ed.database.GetEvents(ctx, filter)
+	events, _, err := ed.database.GetEvents(ctx, filter)
 	ls := make([]fftypes.LocallySequenced, len(events))
 	for i, e := range events {
 		ls[i] = e
@@ -145,10 +161,17 @@ func (ed *eventDispatcher) getEvents(ctx context.Context, filter database.Filter
 func (ed *eventDispatcher) enrichEvents(events []fftypes.LocallySequenced) ([]*fftypes.EventDelivery, error) {
 	// We need all the messages that match event references
 	refIDs := make([]driver.Value, len(events))
+	var opIDs, txIDs []driver.Value
 	for i, ls := range events {
 		e := ls.(*fftypes.Event)
 		if e.Reference != nil {
 			refIDs[i] = *e.Reference
+			switch e.Type {
+			case fftypes.EventTypeOperationSucceeded, fftypes.EventTypeOperationFailed:
+				opIDs = append(opIDs, *e.Reference)
+			case fftypes.EventTypeTransactionSubmitted, fftypes.EventTypeTransactionConfirmed:
+				txIDs = append(txIDs, *e.Reference)
+			}
 		}
 	}
 
@@ -157,7 +180,7 @@ func (ed *eventDispatcher) enrichEvents(events []fftypes.LocallySequenced) ([]*f
 		mfb.In("id", refIDs),
 		mfb.Eq("namespace", ed.namespace),
 	)
-	msgs, err := ed.database.GetMessages(ed.ctx, msgFilter)
+	msgs, _, err := ed.database.GetMessages(ed.ctx, msgFilter)
 	if err != nil {
 		return nil, err
 	}
@@ -172,6 +195,30 @@ func (ed *eventDispatcher) enrichEvents(events []fftypes.LocallySequenced) ([]*f
 		return nil, err
 	}
 
+	// Operation and transaction lifecycle events are only queried when present in the page
+	var ops []*fftypes.Operation
+	if len(opIDs) > 0 {
+		ofb := database.OperationQueryFactory.NewFilter(ed.ctx)
+		ops, _, err = ed.database.GetOperations(ed.ctx, ofb.And(
+			ofb.In("id", opIDs),
+			ofb.Eq("namespace", ed.namespace),
+		))
+		if err != nil {
+			return nil, err
+		}
+	}
+	var txs []*fftypes.Transaction
+	if len(txIDs) > 0 {
+		tfb := database.TransactionQueryFactory.NewFilter(ed.ctx)
+		txs, _, err = ed.database.GetTransactions(ed.ctx, tfb.And(
+			tfb.In("id", txIDs),
+			tfb.Eq("namespace", ed.namespace),
+		))
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	enriched := make([]*fftypes.EventDelivery, len(events))
 	for i, ls := range events {
 		e := ls.(*fftypes.Event)
@@ -191,6 +238,18 @@ func (ed *eventDispatcher) enrichEvents(events []fftypes.LocallySequenced) ([]*f
 				break
 			}
 		}
+		for _, op := range ops {
+			if *e.Reference == *op.ID {
+				enriched[i].Operation = op
+				break
+			}
+		}
+		for _, tx := range txs {
+			if *e.Reference == *tx.ID {
+				enriched[i].Transaction = tx
+				break
+			}
+		}
 	}
 
 	return enriched, nil
@@ -278,6 +337,7 @@ func (ed *eventDispatcher) bufferedDelivery(events []fftypes.LocallySequenced) (
 
 		l.Debugf("Dispatcher event state: candidates=%d matched=%d inflight=%d queued=%d dispatched=%d dispatchable=%d lastAck=%d nacks=%d highest=%d",
 			len(candidates), matchCount, inflightCount, len(matching), dispatched, len(disapatchable), lastAck, nacks, highestOffset)
+		metrics.SubscriptionDelivery(ed.namespace, ed.subscription.definition.Name, ed.eventPoller.backlog(), inflightCount)
 
 		for _, event := range disapatchable {
 			ed.mux.Lock()
@@ -302,6 +362,8 @@ func (ed *eventDispatcher) bufferedDelivery(events []fftypes.LocallySequenced) (
 			if an.isNack {
 				nacks++
 				ed.handleNackOffsetUpdate(an)
+				// Everything after the rejected event is redelivered after it, so we do not dispatch the rest of the page
+				matching = nil
 			} else if nacks == 0 {
 				err := ed.handleAckOffsetUpdate(an)
 				if err != nil {
@@ -311,15 +373,43 @@ func (ed *eventDispatcher) bufferedDelivery(events []fftypes.LocallySequenced) (
 			}
 		}
 	}
-	if nacks == 0 && lastAck != highestOffset {
-		err := ed.eventPoller.commitOffset(ed.ctx, highestOffset)
-		if err != nil {
+	if nacks > 0 {
+		if err := ed.redeliveryBackoff(); err != nil {
 			return false, err
 		}
+	} else {
+		ed.redeliveryDelay = 0
+		if lastAck != highestOffset {
+			err := ed.eventPoller.commitOffset(ed.ctx, highestOffset)
+			if err != nil {
+				return false, err
+			}
+		}
 	}
+	metrics.SubscriptionDelivery(ed.namespace, ed.subscription.definition.Name, ed.eventPoller.backlog(), 0)
 	return true, nil // poll again straight away for more messages
 }
 
+// redeliveryBackoff waits before rejected events are redelivered, so a subscriber that keeps rejecting an event
+// is not redelivered to in a tight loop. The delay grows until an event is delivered without a rejection.
+func (ed *eventDispatcher) redeliveryBackoff() error {
+	delay := ed.redeliveryDelay
+	if delay == 0 {
+		delay = ed.redeliveryRetry.InitialDelay
+	}
+	ed.redeliveryDelay = time.Duration(float64(delay) * ed.redeliveryRetry.Factor)
+	if ed.redeliveryDelay > ed.redeliveryRetry.MaximumDelay {
+		ed.redeliveryDelay = ed.redeliveryRetry.MaximumDelay
+	}
+	log.L(ed.ctx).Debugf("Redelivering rejected events in %s", delay)
+	select {
+	case <-time.After(delay):
+		return nil
+	case <-ed.ctx.Done():
+		return i18n.NewError(ed.ctx, i18n.MsgDispatcherClosing)
+	}
+}
+
 func (ed *eventDispatcher) handleNackOffsetUpdate(nack ackNack) {
 	ed.mux.Lock()
 	defer ed.mux.Unlock()
@@ -353,8 +443,16 @@ func (ed *eventDispatcher) handleAckOffsetUpdate(ack ackNack) error {
 
 func (ed *eventDispatcher) deliverEvents() {
 	for event := range ed.eventDelivery {
+		ed.mux.Lock()
+		inflight := ed.inflight[*event.ID] == &event.Event
+		ed.mux.Unlock()
+		if !inflight {
+			// An earlier event was rejected, so this event will be redelivered after it
+			log.L(ed.ctx).Debugf("Skipping event: %.10d/%s, which will be redelivered", event.Sequence, event.ID)
+			continue
+		}
 		log.L(ed.ctx).Debugf("Dispatching event: %.10d/%s [%s]: ref=%s/%s", event.Sequence, event.ID, event.Type, event.Namespace, event.Reference)
-		err := ed.transport.DeliveryRequest(ed.connID, event)
+		err := ed.transport.DeliveryRequest(ed.connID, ed.subscription.definition, event)
 		if err != nil {
 			ed.deliveryResponse(&fftypes.EventDeliveryResponse{ID: event.ID, Rejected: true})
 		}
@@ -381,6 +479,9 @@ func (ed *eventDispatcher) deliveryResponse(response *fftypes.EventDeliveryRespo
 	}
 
 	l.Debugf("Response for event: %.10d/%s [%s]: ref=%s/%s rejected=%t info='%s'", event.Sequence, event.ID, event.Type, event.Namespace, event.Reference, response.Rejected, response.Info)
+	if response.Reply != nil && !response.Rejected {
+		ed.deliveryReply(event, response.Reply)
+	}
 	// We don't do any meaningful work in this call, we just set things up so the right thing
 	// will happen when the poller wakes up. So we need to pass it over
 	select {
@@ -391,6 +492,39 @@ func (ed *eventDispatcher) deliveryResponse(response *fftypes.EventDeliveryRespo
 	}
 }
 
+// deliveryReply sends a reply supplied with the acknowledgement of a message event, to the same
+// group (or as a broadcast), on the same topics, correlated to the original message via the cid.
+// Failures are logged, but do not affect the acknowledgement of the original event.
+func (ed *eventDispatcher) deliveryReply(event *fftypes.Event, reply *fftypes.MessageInput) {
+	l := log.L(ed.ctx)
+	if event.Type != fftypes.EventTypeMessageConfirmed {
+		l.Warnf("Reply ignored for event %s [%s]: only message_confirmed events can be replied to", event.ID, event.Type)
+		return
+	}
+	msg, err := ed.database.GetMessageByID(ed.ctx, event.Reference)
+	if err != nil || msg == nil {
+		l.Errorf("Reply ignored for event %s: failed to retrieve message %s: %v", event.ID, event.Reference, err)
+		return
+	}
+	reply.Header.CID = msg.Header.ID
+	if len(reply.Header.Topics) == 0 {
+		reply.Header.Topics = msg.Header.Topics
+	}
+	var sent *fftypes.Message
+	if msg.Header.Group != nil {
+		reply.Header.Group = msg.Header.Group
+		reply.Group = nil
+		sent, err = ed.messaging.SendMessage(ed.ctx, ed.namespace, reply)
+	} else {
+		sent, err = ed.broadcast.BroadcastMessage(ed.ctx, ed.namespace, reply)
+	}
+	if err != nil {
+		l.Errorf("Failed to send reply to message %s: %s", msg.Header.ID, err)
+		return
+	}
+	l.Infof("Sent reply %s to message %s", sent.Header.ID, msg.Header.ID)
+}
+
 func (ed *eventDispatcher) close() {
 	log.L(ed.ctx).Infof("Dispatcher closing for conn=%s subscription=%s", ed.connID, ed.subscription.definition.ID)
 	ed.cancelCtx()
@@ -399,5 +533,6 @@ func (ed *eventDispatcher) close() {
 		<-ed.eventPoller.closed
 		close(ed.eventDelivery)
 		ed.elected = false
+		metrics.SubscriptionClosed(ed.namespace, ed.subscription.definition.Name)
 	}
 }
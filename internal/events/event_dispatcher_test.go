@@ -21,11 +21,15 @@ import (
 	"fmt"
 	"regexp"
 	"testing"
+	"time"
 
 	"github.com/hyperledger-labs/firefly/internal/config"
 	"github.com/hyperledger-labs/firefly/internal/log"
+	"github.com/hyperledger-labs/firefly/internal/retry"
+	"github.com/hyperledger-labs/firefly/mocks/broadcastmocks"
 	"github.com/hyperledger-labs/firefly/mocks/databasemocks"
 	"github.com/hyperledger-labs/firefly/mocks/eventsmocks"
+	"github.com/hyperledger-labs/firefly/mocks/privatemessagingmocks"
 	"github.com/hyperledger-labs/firefly/pkg/database"
 	"github.com/hyperledger-labs/firefly/pkg/events"
 	"github.com/hyperledger-labs/firefly/pkg/fftypes"
@@ -36,7 +40,7 @@ import (
 func newTestEventDispatcher(mdi database.Plugin, mei events.Plugin, sub *subscription) (*eventDispatcher, func()) {
 	ctx, cancel := context.WithCancel(context.Background())
 	config.Reset()
-	return newEventDispatcher(ctx, mei, mdi, fftypes.NewUUID().String(), sub, newEventNotifier(ctx, "ut")), cancel
+	return newEventDispatcher(ctx, mei, mdi, &broadcastmocks.Manager{}, &privatemessagingmocks.Manager{}, fftypes.NewUUID().String(), sub, newEventNotifier(ctx, "ut")), cancel
 }
 
 func TestEventDispatcherStartStop(t *testing.T) {
@@ -56,7 +60,7 @@ func TestEventDispatcherStartStop(t *testing.T) {
 		},
 	})
 	defer cancel()
-	ge := mdi.On("GetEvents", mock.Anything, mock.Anything, mock.Anything).Return([]*fftypes.Event{}, nil)
+	ge := mdi.On("GetEvents", mock.Anything, mock.Anything, mock.Anything).Return([]*fftypes.Event{}, nil, nil)
 	confirmedElected := make(chan bool)
 	ge.RunFn = func(a mock.Arguments) {
 		<-confirmedElected
@@ -84,7 +88,7 @@ func TestEventDispatcherLeaderElection(t *testing.T) {
 	gev1Done := make(chan struct{})
 	mei := &eventsmocks.Plugin{}
 	mdi1 := &databasemocks.Plugin{}
-	gev1 := mdi1.On("GetEvents", mock.Anything, mock.Anything, mock.Anything).Return([]*fftypes.Event{}, nil)
+	gev1 := mdi1.On("GetEvents", mock.Anything, mock.Anything, mock.Anything).Return([]*fftypes.Event{}, nil, nil)
 	mdi1.On("GetOffset", mock.Anything, fftypes.OffsetTypeSubscription, "ns1", "sub1").Return(&fftypes.Offset{
 		Type:      fftypes.OffsetTypeSubscription,
 		Namespace: "ns1",
@@ -129,9 +133,9 @@ func TestEventDispatcherReadAheadOutOfOrderAcks(t *testing.T) {
 
 	eventDeliveries := make(chan *fftypes.EventDelivery)
 	mei := &eventsmocks.Plugin{}
-	deliveryRequestMock := mei.On("DeliveryRequest", mock.Anything, mock.Anything).Return(nil)
+	deliveryRequestMock := mei.On("DeliveryRequest", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 	deliveryRequestMock.RunFn = func(a mock.Arguments) {
-		eventDeliveries <- a.Get(1).(*fftypes.EventDelivery)
+		eventDeliveries <- a.Get(2).(*fftypes.EventDelivery)
 	}
 
 	ed, cancel := newTestEventDispatcher(mdi, mei, sub)
@@ -167,7 +171,7 @@ func TestEventDispatcherReadAheadOutOfOrderAcks(t *testing.T) {
 		{Header: fftypes.MessageHeader{ID: ref1}},
 		{Header: fftypes.MessageHeader{ID: ref2}},
 		{Header: fftypes.MessageHeader{ID: ref4}},
-	}, nil)
+	}, nil, nil)
 	mdi.On("GetDataRefs", mock.Anything, mock.MatchedBy(func(filter database.Filter) bool {
 		fi, err := filter.Finalize()
 		assert.NoError(t, err)
@@ -237,9 +241,9 @@ func TestEventDispatcherNoReadAheadInOrder(t *testing.T) {
 
 	eventDeliveries := make(chan *fftypes.EventDelivery)
 	mei := &eventsmocks.Plugin{}
-	deliveryRequestMock := mei.On("DeliveryRequest", mock.Anything, mock.Anything).Return(nil)
+	deliveryRequestMock := mei.On("DeliveryRequest", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 	deliveryRequestMock.RunFn = func(a mock.Arguments) {
-		eventDeliveries <- a.Get(1).(*fftypes.EventDelivery)
+		eventDeliveries <- a.Get(2).(*fftypes.EventDelivery)
 	}
 
 	ed, cancel := newTestEventDispatcher(mdi, mei, sub)
@@ -262,7 +266,7 @@ func TestEventDispatcherNoReadAheadInOrder(t *testing.T) {
 		{Header: fftypes.MessageHeader{ID: ref2}},
 		{Header: fftypes.MessageHeader{ID: ref3}},
 		{Header: fftypes.MessageHeader{ID: ref4}},
-	}, nil)
+	}, nil, nil)
 	mdi.On("GetDataRefs", mock.Anything, mock.Anything).Return(fftypes.DataRefs{}, nil)
 
 	// Deliver a batch of messages
@@ -316,7 +320,7 @@ func TestEnrichEventsFailGetMessages(t *testing.T) {
 	ed, cancel := newTestEventDispatcher(mdi, mei, sub)
 	defer cancel()
 
-	mdi.On("GetMessages", mock.Anything, mock.Anything).Return(nil, fmt.Errorf("pop"))
+	mdi.On("GetMessages", mock.Anything, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
 
 	id1 := fftypes.NewUUID()
 	_, err := ed.enrichEvents([]fftypes.LocallySequenced{&fftypes.Event{ID: id1}})
@@ -334,7 +338,7 @@ func TestEnrichEventsFailGetData(t *testing.T) {
 	ed, cancel := newTestEventDispatcher(mdi, mei, sub)
 	defer cancel()
 
-	mdi.On("GetMessages", mock.Anything, mock.Anything).Return([]*fftypes.Message{}, nil)
+	mdi.On("GetMessages", mock.Anything, mock.Anything).Return([]*fftypes.Message{}, nil, nil)
 	mdi.On("GetDataRefs", mock.Anything, mock.Anything).Return(nil, fmt.Errorf("pop"))
 
 	id1 := fftypes.NewUUID()
@@ -343,6 +347,80 @@ func TestEnrichEventsFailGetData(t *testing.T) {
 	assert.EqualError(t, err, "pop")
 }
 
+func TestEnrichEventsOperationsAndTransactions(t *testing.T) {
+
+	sub := &subscription{
+		definition: &fftypes.Subscription{},
+	}
+	mei := &eventsmocks.Plugin{}
+	mdi := &databasemocks.Plugin{}
+	ed, cancel := newTestEventDispatcher(mdi, mei, sub)
+	defer cancel()
+
+	opID := fftypes.NewUUID()
+	txID := fftypes.NewUUID()
+	mdi.On("GetMessages", mock.Anything, mock.Anything).Return([]*fftypes.Message{}, nil, nil)
+	mdi.On("GetDataRefs", mock.Anything, mock.Anything).Return(fftypes.DataRefs{}, nil)
+	mdi.On("GetOperations", mock.Anything, mock.Anything).Return([]*fftypes.Operation{
+		{ID: fftypes.NewUUID()},
+		{ID: opID},
+	}, nil, nil)
+	mdi.On("GetTransactions", mock.Anything, mock.Anything).Return([]*fftypes.Transaction{
+		{ID: fftypes.NewUUID()},
+		{ID: txID},
+	}, nil, nil)
+
+	enriched, err := ed.enrichEvents([]fftypes.LocallySequenced{
+		&fftypes.Event{ID: fftypes.NewUUID(), Type: fftypes.EventTypeOperationFailed, Reference: opID},
+		&fftypes.Event{ID: fftypes.NewUUID(), Type: fftypes.EventTypeTransactionConfirmed, Reference: txID},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, opID, enriched[0].Operation.ID)
+	assert.Nil(t, enriched[0].Transaction)
+	assert.Equal(t, txID, enriched[1].Transaction.ID)
+	assert.Nil(t, enriched[1].Operation)
+}
+
+func TestEnrichEventsFailGetOperations(t *testing.T) {
+
+	sub := &subscription{
+		definition: &fftypes.Subscription{},
+	}
+	mei := &eventsmocks.Plugin{}
+	mdi := &databasemocks.Plugin{}
+	ed, cancel := newTestEventDispatcher(mdi, mei, sub)
+	defer cancel()
+
+	mdi.On("GetMessages", mock.Anything, mock.Anything).Return([]*fftypes.Message{}, nil, nil)
+	mdi.On("GetDataRefs", mock.Anything, mock.Anything).Return(fftypes.DataRefs{}, nil)
+	mdi.On("GetOperations", mock.Anything, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+
+	_, err := ed.enrichEvents([]fftypes.LocallySequenced{
+		&fftypes.Event{ID: fftypes.NewUUID(), Type: fftypes.EventTypeOperationSucceeded, Reference: fftypes.NewUUID()},
+	})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestEnrichEventsFailGetTransactions(t *testing.T) {
+
+	sub := &subscription{
+		definition: &fftypes.Subscription{},
+	}
+	mei := &eventsmocks.Plugin{}
+	mdi := &databasemocks.Plugin{}
+	ed, cancel := newTestEventDispatcher(mdi, mei, sub)
+	defer cancel()
+
+	mdi.On("GetMessages", mock.Anything, mock.Anything).Return([]*fftypes.Message{}, nil, nil)
+	mdi.On("GetDataRefs", mock.Anything, mock.Anything).Return(fftypes.DataRefs{}, nil)
+	mdi.On("GetTransactions", mock.Anything, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+
+	_, err := ed.enrichEvents([]fftypes.LocallySequenced{
+		&fftypes.Event{ID: fftypes.NewUUID(), Type: fftypes.EventTypeTransactionSubmitted, Reference: fftypes.NewUUID()},
+	})
+	assert.EqualError(t, err, "pop")
+}
+
 func TestFilterEventsMatch(t *testing.T) {
 
 	sub := &subscription{
@@ -469,7 +547,7 @@ func TestBufferedDeliveryEnrichFail(t *testing.T) {
 	ed, cancel := newTestEventDispatcher(mdi, mei, sub)
 	defer cancel()
 
-	mdi.On("GetMessages", mock.Anything, mock.Anything).Return(nil, fmt.Errorf("pop"))
+	mdi.On("GetMessages", mock.Anything, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
 
 	repoll, err := ed.bufferedDelivery([]fftypes.LocallySequenced{&fftypes.Event{ID: fftypes.NewUUID()}})
 	assert.False(t, repoll)
@@ -488,9 +566,9 @@ func TestBufferedDeliveryClosedContext(t *testing.T) {
 	go ed.deliverEvents()
 	cancel()
 
-	mdi.On("GetMessages", mock.Anything, mock.Anything).Return(nil, nil)
+	mdi.On("GetMessages", mock.Anything, mock.Anything).Return(nil, nil, nil)
 	mdi.On("GetDataRefs", mock.Anything, mock.Anything).Return(nil, nil)
-	mei.On("DeliveryRequest", mock.Anything, mock.Anything).Return(nil)
+	mei.On("DeliveryRequest", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 
 	repoll, err := ed.bufferedDelivery([]fftypes.LocallySequenced{&fftypes.Event{ID: fftypes.NewUUID()}})
 	assert.False(t, repoll)
@@ -508,13 +586,14 @@ func TestBufferedDeliveryNackRewind(t *testing.T) {
 	ed, cancel := newTestEventDispatcher(mdi, mei, sub)
 	defer cancel()
 	go ed.deliverEvents()
+	ed.redeliveryRetry.InitialDelay = time.Millisecond
 
-	mdi.On("GetMessages", mock.Anything, mock.Anything).Return(nil, nil)
+	mdi.On("GetMessages", mock.Anything, mock.Anything).Return(nil, nil, nil)
 	mdi.On("GetDataRefs", mock.Anything, mock.Anything).Return(nil, nil)
 	mdi.On("UpdateOffset", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 
 	delivered := make(chan struct{})
-	deliver := mei.On("DeliveryRequest", mock.Anything, mock.Anything).Return(nil)
+	deliver := mei.On("DeliveryRequest", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 	deliver.RunFn = func(a mock.Arguments) {
 		close(delivered)
 	}
@@ -539,6 +618,143 @@ func TestBufferedDeliveryNackRewind(t *testing.T) {
 	assert.Equal(t, int64(100001), ed.eventPoller.pollingOffset)
 }
 
+func TestBufferedDeliveryNackStopsDispatch(t *testing.T) {
+
+	sub := &subscription{
+		definition: &fftypes.Subscription{},
+	}
+	mei := &eventsmocks.Plugin{}
+	mdi := &databasemocks.Plugin{}
+	ed, cancel := newTestEventDispatcher(mdi, mei, sub)
+	defer cancel()
+	go ed.deliverEvents()
+	ed.redeliveryRetry.InitialDelay = time.Millisecond
+
+	mdi.On("GetMessages", mock.Anything, mock.Anything).Return(nil, nil, nil)
+	mdi.On("GetDataRefs", mock.Anything, mock.Anything).Return(nil, nil)
+
+	delivered := make(chan *fftypes.EventDelivery, 2)
+	deliver := mei.On("DeliveryRequest", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	deliver.RunFn = func(a mock.Arguments) {
+		delivered <- a[2].(*fftypes.EventDelivery)
+	}
+
+	bdDone := make(chan struct{})
+	ev1 := fftypes.NewUUID()
+	ev2 := fftypes.NewUUID()
+	go func() {
+		repoll, err := ed.bufferedDelivery([]fftypes.LocallySequenced{
+			&fftypes.Event{ID: ev1, Sequence: 100001},
+			&fftypes.Event{ID: ev2, Sequence: 100002},
+		})
+		assert.NoError(t, err)
+		assert.True(t, repoll)
+		close(bdDone)
+	}()
+
+	event := <-delivered
+	assert.Equal(t, *ev1, *event.ID)
+	ed.deliveryResponse(&fftypes.EventDeliveryResponse{
+		ID:       ev1,
+		Rejected: true,
+	})
+
+	// The second event is redelivered after the first, rather than dispatched now
+	<-bdDone
+	assert.Empty(t, delivered)
+	assert.Equal(t, 2*time.Millisecond, ed.redeliveryDelay)
+}
+
+func TestBufferedDeliveryNackClosing(t *testing.T) {
+
+	sub := &subscription{
+		definition: &fftypes.Subscription{},
+	}
+	mei := &eventsmocks.Plugin{}
+	mdi := &databasemocks.Plugin{}
+	ed, cancel := newTestEventDispatcher(mdi, mei, sub)
+	defer cancel()
+	go ed.deliverEvents()
+	ed.redeliveryDelay = time.Minute
+
+	mdi.On("GetMessages", mock.Anything, mock.Anything).Return(nil, nil, nil)
+	mdi.On("GetDataRefs", mock.Anything, mock.Anything).Return(nil, nil)
+
+	delivered := make(chan struct{})
+	deliver := mei.On("DeliveryRequest", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	deliver.RunFn = func(a mock.Arguments) {
+		close(delivered)
+	}
+
+	bdDone := make(chan struct{})
+	ev1 := fftypes.NewUUID()
+	go func() {
+		repoll, err := ed.bufferedDelivery([]fftypes.LocallySequenced{&fftypes.Event{ID: ev1, Sequence: 100001}})
+		assert.Regexp(t, "FF10182", err)
+		assert.False(t, repoll)
+		close(bdDone)
+	}()
+
+	<-delivered
+	ed.deliveryResponse(&fftypes.EventDeliveryResponse{
+		ID:       ev1,
+		Rejected: true,
+	})
+	cancel()
+	<-bdDone
+}
+
+func TestRedeliveryBackoff(t *testing.T) {
+	sub := &subscription{
+		definition: &fftypes.Subscription{},
+	}
+	ed, cancel := newTestEventDispatcher(&databasemocks.Plugin{}, &eventsmocks.Plugin{}, sub)
+	ed.redeliveryRetry = retry.Retry{
+		InitialDelay: time.Millisecond,
+		MaximumDelay: 3 * time.Millisecond,
+		Factor:       2,
+	}
+
+	err := ed.redeliveryBackoff()
+	assert.NoError(t, err)
+	assert.Equal(t, 2*time.Millisecond, ed.redeliveryDelay)
+	err = ed.redeliveryBackoff()
+	assert.NoError(t, err)
+	assert.Equal(t, 3*time.Millisecond, ed.redeliveryDelay)
+
+	cancel()
+	ed.redeliveryDelay = time.Minute
+	err = ed.redeliveryBackoff()
+	assert.Regexp(t, "FF10182", err)
+}
+
+func TestDeliverEventsSkipsRejected(t *testing.T) {
+	sub := &subscription{
+		definition: &fftypes.Subscription{},
+	}
+	mei := &eventsmocks.Plugin{}
+	ed, cancel := newTestEventDispatcher(&databasemocks.Plugin{}, mei, sub)
+	defer cancel()
+
+	delivered := make(chan struct{})
+	deliver := mei.On("DeliveryRequest", mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	deliver.RunFn = func(a mock.Arguments) {
+		close(delivered)
+	}
+
+	// A stale delivery for an event that was in flight before a rejection, then the redelivery of the same event
+	stale := &fftypes.EventDelivery{Event: fftypes.Event{ID: fftypes.NewUUID(), Sequence: 100002}}
+	redelivered := &fftypes.EventDelivery{Event: fftypes.Event{ID: stale.ID, Sequence: 100002}}
+	ed.inflight[*redelivered.ID] = &redelivered.Event
+	go ed.deliverEvents()
+	ed.eventDelivery <- stale
+	ed.eventDelivery <- redelivered
+
+	<-delivered
+	mei.AssertNumberOfCalls(t, "DeliveryRequest", 1)
+	mei.AssertCalled(t, "DeliveryRequest", mock.Anything, mock.Anything, redelivered)
+}
+
 func TestBufferedDeliveryAckFail(t *testing.T) {
 
 	sub := &subscription{
@@ -551,12 +767,12 @@ func TestBufferedDeliveryAckFail(t *testing.T) {
 	go ed.deliverEvents()
 	ed.readAhead = 50
 
-	mdi.On("GetMessages", mock.Anything, mock.Anything).Return(nil, nil)
+	mdi.On("GetMessages", mock.Anything, mock.Anything).Return(nil, nil, nil)
 	mdi.On("GetDataRefs", mock.Anything, mock.Anything).Return(nil, nil)
 	mdi.On("UpdateOffset", mock.Anything, mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
 
 	delivered := make(chan bool)
-	deliver := mei.On("DeliveryRequest", mock.Anything, mock.Anything).Return(nil)
+	deliver := mei.On("DeliveryRequest", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 	deliver.RunFn = func(a mock.Arguments) {
 		delivered <- true
 	}
@@ -598,12 +814,12 @@ func TestBufferedDeliveryFailNack(t *testing.T) {
 	go ed.deliverEvents()
 	ed.readAhead = 50
 
-	mdi.On("GetMessages", mock.Anything, mock.Anything).Return(nil, nil)
+	mdi.On("GetMessages", mock.Anything, mock.Anything).Return(nil, nil, nil)
 	mdi.On("GetDataRefs", mock.Anything, mock.Anything).Return(nil, nil)
 	mdi.On("UpdateOffset", mock.Anything, mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
 
 	failNacked := make(chan bool)
-	deliver := mei.On("DeliveryRequest", mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+	deliver := mei.On("DeliveryRequest", mock.Anything, mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
 	deliver.RunFn = func(a mock.Arguments) {
 		failNacked <- true
 	}
@@ -641,7 +857,7 @@ func TestBufferedFinalAckFail(t *testing.T) {
 	go ed.deliverEvents()
 	ed.readAhead = 50
 
-	mdi.On("GetMessages", mock.Anything, mock.Anything).Return(nil, nil)
+	mdi.On("GetMessages", mock.Anything, mock.Anything).Return(nil, nil, nil)
 	mdi.On("GetDataRefs", mock.Anything, mock.Anything).Return(nil, nil)
 	mdi.On("UpdateOffset", mock.Anything, mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
 
@@ -697,7 +913,7 @@ func TestGetEvents(t *testing.T) {
 	mdi := ag.database.(*databasemocks.Plugin)
 	mdi.On("GetEvents", ag.ctx, mock.Anything).Return([]*fftypes.Event{
 		{Sequence: 12345},
-	}, nil)
+	}, nil, nil)
 
 	ed, cancel := newTestEventDispatcher(mdi, mei, sub)
 	cancel()
@@ -706,3 +922,86 @@ func TestGetEvents(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, int64(12345), lc[0].LocalSequence())
 }
+
+func TestDeliveryResponseReplyBroadcast(t *testing.T) {
+	mdi := &databasemocks.Plugin{}
+	mei := &eventsmocks.Plugin{}
+	sub := &subscription{
+		definition: &fftypes.Subscription{SubscriptionRef: fftypes.SubscriptionRef{ID: fftypes.NewUUID(), Namespace: "ns1"}},
+	}
+	ed, cancel := newTestEventDispatcher(mdi, mei, sub)
+	defer cancel()
+	mbm := ed.broadcast.(*broadcastmocks.Manager)
+
+	origID := fftypes.NewUUID()
+	event := &fftypes.Event{ID: fftypes.NewUUID(), Type: fftypes.EventTypeMessageConfirmed, Reference: origID}
+	ed.inflight[*event.ID] = event
+	mdi.On("GetMessageByID", mock.Anything, origID).Return(&fftypes.Message{
+		Header: fftypes.MessageHeader{ID: origID, Topics: fftypes.FFNameArray{"topic1"}},
+	}, nil)
+	mbm.On("BroadcastMessage", mock.Anything, "ns1", mock.MatchedBy(func(in *fftypes.MessageInput) bool {
+		return *in.Header.CID == *origID && in.Header.Topics[0] == "topic1"
+	})).Return(&fftypes.Message{Header: fftypes.MessageHeader{ID: fftypes.NewUUID()}}, nil)
+
+	go func() { <-ed.acksNacks }()
+	ed.deliveryResponse(&fftypes.EventDeliveryResponse{ID: event.ID, Reply: &fftypes.MessageInput{}})
+
+	mbm.AssertExpectations(t)
+}
+
+func TestDeliveryReplyPrivate(t *testing.T) {
+	mdi := &databasemocks.Plugin{}
+	mei := &eventsmocks.Plugin{}
+	sub := &subscription{
+		definition: &fftypes.Subscription{SubscriptionRef: fftypes.SubscriptionRef{ID: fftypes.NewUUID(), Namespace: "ns1"}},
+	}
+	ed, cancel := newTestEventDispatcher(mdi, mei, sub)
+	defer cancel()
+	mpm := ed.messaging.(*privatemessagingmocks.Manager)
+
+	origID := fftypes.NewUUID()
+	group := fftypes.NewRandB32()
+	mdi.On("GetMessageByID", mock.Anything, origID).Return(&fftypes.Message{
+		Header: fftypes.MessageHeader{ID: origID, Group: group},
+	}, nil)
+	mpm.On("SendMessage", mock.Anything, "ns1", mock.MatchedBy(func(in *fftypes.MessageInput) bool {
+		return *in.Header.Group == *group && in.Group == nil && in.Header.Topics[0] == "mytopic"
+	})).Return(nil, fmt.Errorf("pop"))
+
+	ed.deliveryReply(&fftypes.Event{ID: fftypes.NewUUID(), Type: fftypes.EventTypeMessageConfirmed, Reference: origID}, &fftypes.MessageInput{
+		Message: fftypes.Message{Header: fftypes.MessageHeader{Topics: fftypes.FFNameArray{"mytopic"}}},
+		Group:   &fftypes.InputGroup{},
+	})
+
+	mpm.AssertExpectations(t)
+}
+
+func TestDeliveryReplyMessageNotFound(t *testing.T) {
+	mdi := &databasemocks.Plugin{}
+	mei := &eventsmocks.Plugin{}
+	sub := &subscription{
+		definition: &fftypes.Subscription{SubscriptionRef: fftypes.SubscriptionRef{ID: fftypes.NewUUID(), Namespace: "ns1"}},
+	}
+	ed, cancel := newTestEventDispatcher(mdi, mei, sub)
+	defer cancel()
+
+	mdi.On("GetMessageByID", mock.Anything, mock.Anything).Return(nil, fmt.Errorf("pop"))
+
+	ed.deliveryReply(&fftypes.Event{ID: fftypes.NewUUID(), Type: fftypes.EventTypeMessageConfirmed, Reference: fftypes.NewUUID()}, &fftypes.MessageInput{})
+
+	mdi.AssertExpectations(t)
+}
+
+func TestDeliveryReplyWrongEventType(t *testing.T) {
+	mdi := &databasemocks.Plugin{}
+	mei := &eventsmocks.Plugin{}
+	sub := &subscription{
+		definition: &fftypes.Subscription{SubscriptionRef: fftypes.SubscriptionRef{ID: fftypes.NewUUID(), Namespace: "ns1"}},
+	}
+	ed, cancel := newTestEventDispatcher(mdi, mei, sub)
+	defer cancel()
+
+	ed.deliveryReply(&fftypes.Event{ID: fftypes.NewUUID(), Type: fftypes.EventTypeMessageInvalid}, &fftypes.MessageInput{})
+
+	mdi.AssertNotCalled(t, "GetMessageByID", mock.Anything, mock.Anything)
+}
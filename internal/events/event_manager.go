@@ -42,16 +42,20 @@ type EventManager interface {
 	DeletedSubscriptions() chan<- *fftypes.UUID
 	DeleteDurableSubscription(ctx context.Context, subDef *fftypes.Subscription) (err error)
 	CreateDurableSubscription(ctx context.Context, subDef *fftypes.Subscription) (err error)
+	WaitForMessage(ctx context.Context, ns string, id *fftypes.UUID) (*fftypes.Message, error)
+	WaitForReply(ctx context.Context, ns string, requestID *fftypes.UUID) (*fftypes.Message, error)
 	Start() error
+	StartAggregator() error
 	WaitStop()
+	CancelOperation(ctx context.Context, op *fftypes.Operation) error
 
 	// Bound blockchain callbacks
 	TxSubmissionUpdate(bi blockchain.Plugin, txTrackingID string, txState blockchain.TransactionStatus, protocolTxID, errorMessage string, additionalInfo fftypes.JSONObject) error
-	BatchPinComplete(bi blockchain.Plugin, batch *blockchain.BatchPin, signingIdentity string, protocolTxID string, additionalInfo fftypes.JSONObject) error
+	BatchPinComplete(bi blockchain.Plugin, ledgerID *fftypes.UUID, batch *blockchain.BatchPin, signingIdentity string, protocolTxID string, additionalInfo fftypes.JSONObject) error
 
 	// Bound dataexchange callbacks
 	TransferResult(dx dataexchange.Plugin, trackingID string, status fftypes.OpStatus, info string, additionalInfo fftypes.JSONObject)
-	BLOBReceived(dx dataexchange.Plugin, peerID string, ns string, id fftypes.UUID)
+	BLOBReceived(dx dataexchange.Plugin, peerID string, hash fftypes.Bytes32, ns string, id fftypes.UUID)
 	MessageReceived(dx dataexchange.Plugin, peerID string, data []byte)
 }
 
@@ -64,7 +68,9 @@ type eventManager struct {
 	messaging            privatemessaging.Manager
 	data                 data.Manager
 	subManager           *subscriptionManager
+	aggregatorStarted    bool
 	retry                retry.Retry
+	resubmit             *resubmitPolicy
 	aggregator           *aggregator
 	newEventNotifier     *eventNotifier
 	newPinNotifier       *eventNotifier
@@ -99,17 +105,26 @@ func NewEventManager(ctx context.Context, pi publicstorage.Plugin, di database.P
 	}
 
 	var err error
-	if em.subManager, err = newSubscriptionManager(ctx, di, newEventNotifier); err != nil {
+	if em.resubmit, err = newResubmitPolicy(ctx); err != nil {
+		return nil, err
+	}
+	if em.subManager, err = newSubscriptionManager(ctx, di, bm, pm, newEventNotifier); err != nil {
 		return nil, err
 	}
 
 	return em, nil
 }
 
+// Start starts delivery of events to subscriptions, which every instance does for its own connected applications
 func (em *eventManager) Start() (err error) {
-	err = em.subManager.start()
+	return em.subManager.start()
+}
+
+// StartAggregator starts processing pins into events, which must only be done by the leader in a cluster
+func (em *eventManager) StartAggregator() (err error) {
+	err = em.aggregator.start()
 	if err == nil {
-		err = em.aggregator.start()
+		em.aggregatorStarted = true
 	}
 	return err
 }
@@ -132,7 +147,9 @@ func (em *eventManager) DeletedSubscriptions() chan<- *fftypes.UUID {
 
 func (em *eventManager) WaitStop() {
 	em.subManager.close()
-	<-em.aggregator.eventPoller.closed
+	if em.aggregatorStarted {
+		<-em.aggregator.eventPoller.closed
+	}
 }
 
 func (em *eventManager) CreateDurableSubscription(ctx context.Context, subDef *fftypes.Subscription) (err error) {
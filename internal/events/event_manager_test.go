@@ -60,14 +60,27 @@ func TestStartStop(t *testing.T) {
 		Current:   12345,
 	}, nil)
 	mdi.On("GetPins", mock.Anything, mock.Anything, mock.Anything).Return([]*fftypes.Pin{}, nil)
-	mdi.On("GetSubscriptions", mock.Anything, mock.Anything, mock.Anything).Return([]*fftypes.Subscription{}, nil)
+	mdi.On("GetSubscriptions", mock.Anything, mock.Anything, mock.Anything).Return([]*fftypes.Subscription{}, nil, nil)
 	assert.NoError(t, em.Start())
+	assert.NoError(t, em.StartAggregator())
 	em.NewEvents() <- 12345
 	em.NewPins() <- 12345
 	cancel()
 	em.WaitStop()
 }
 
+func TestStartStopFollower(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	mdi := em.database.(*databasemocks.Plugin)
+	mdi.On("GetSubscriptions", mock.Anything, mock.Anything, mock.Anything).Return([]*fftypes.Subscription{}, nil, nil)
+	assert.NoError(t, em.Start())
+	em.NewEvents() <- 12345
+	em.NewPins() <- 12345
+	cancel()
+	em.WaitStop()
+	assert.False(t, em.aggregatorStarted)
+}
+
 func TestStartStopBadDependencies(t *testing.T) {
 	_, err := NewEventManager(context.Background(), nil, nil, nil, nil, nil, nil)
 	assert.Regexp(t, "FF10128", err)
@@ -98,7 +111,7 @@ func TestEmitSubscriptionEventsNoops(t *testing.T) {
 		Current:   12345,
 	}, nil)
 	mdi.On("GetPins", mock.Anything, mock.Anything, mock.Anything).Return([]*fftypes.Pin{}, nil)
-	mdi.On("GetSubscriptions", mock.Anything, mock.Anything, mock.Anything).Return([]*fftypes.Subscription{}, nil)
+	mdi.On("GetSubscriptions", mock.Anything, mock.Anything, mock.Anything).Return([]*fftypes.Subscription{}, nil, nil)
 
 	getSubCallReady := make(chan bool, 1)
 	getSubCalled := make(chan bool)
@@ -217,7 +230,7 @@ func TestCreateDurableSubscriptionGetHighestSequenceFailure(t *testing.T) {
 		},
 	}
 	mdi.On("GetSubscriptionByName", mock.Anything, "ns1", "sub1").Return(nil, nil)
-	mdi.On("GetEvents", mock.Anything, mock.Anything, mock.Anything).Return(nil, fmt.Errorf("pop"))
+	mdi.On("GetEvents", mock.Anything, mock.Anything, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
 	err := em.CreateDurableSubscription(em.ctx, sub)
 	assert.EqualError(t, err, "pop")
 }
@@ -236,7 +249,7 @@ func TestCreateDurableSubscriptionOk(t *testing.T) {
 	mdi.On("GetSubscriptionByName", mock.Anything, "ns1", "sub1").Return(nil, nil)
 	mdi.On("GetEvents", mock.Anything, mock.Anything).Return([]*fftypes.Event{
 		{Sequence: 12345},
-	}, nil)
+	}, nil, nil)
 	mdi.On("UpsertSubscription", mock.Anything, mock.Anything, false).Return(nil)
 	err := em.CreateDurableSubscription(em.ctx, sub)
 	assert.NoError(t, err)
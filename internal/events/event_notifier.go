@@ -62,6 +62,40 @@ func (en *eventNotifier) waitNext(lastSequence int64) error {
 	return nil
 }
 
+// waitNextContext is a variant of waitNext that also returns if the supplied context is cancelled,
+// for waiters on API requests where the context has a timeout
+func (en *eventNotifier) waitNextContext(ctx context.Context, lastSequence int64) error {
+	// Wake up the wait loop if the context is cancelled before a new event arrives
+	waitDone := make(chan struct{})
+	defer close(waitDone)
+	go func() {
+		select {
+		case <-ctx.Done():
+			en.cond.L.Lock()
+			en.cond.Broadcast()
+			en.cond.L.Unlock()
+		case <-waitDone:
+		}
+	}()
+
+	en.cond.L.Lock()
+	for en.latestSequence <= lastSequence && !en.closed && ctx.Err() == nil {
+		en.cond.Wait()
+	}
+	closed := en.closed
+	en.cond.L.Unlock()
+	if closed {
+		return i18n.NewError(ctx, i18n.MsgEventListenerClosing)
+	}
+	return ctx.Err()
+}
+
+func (en *eventNotifier) currentSequence() int64 {
+	en.cond.L.Lock()
+	defer en.cond.L.Unlock()
+	return en.latestSequence
+}
+
 func (en *eventNotifier) close() {
 	en.cond.L.Lock()
 	en.closed = true
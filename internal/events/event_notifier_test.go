@@ -19,6 +19,8 @@ package events
 import (
 	"context"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func TestEventNotifier(t *testing.T) {
@@ -71,3 +73,33 @@ func TestEventNotifierClosedChannel(t *testing.T) {
 	close(en.newEvents)
 	<-events
 }
+
+func TestEventNotifierWaitNextContext(t *testing.T) {
+	en := newEventNotifier(context.Background(), "ut")
+	assert.Equal(t, int64(-1), en.currentSequence())
+	waited := make(chan error)
+	go func() {
+		waited <- en.waitNextContext(context.Background(), -1)
+	}()
+	en.newEvents <- 1000001
+	assert.NoError(t, <-waited)
+	assert.Equal(t, int64(1000001), en.currentSequence())
+}
+
+func TestEventNotifierWaitNextContextCancelled(t *testing.T) {
+	en := newEventNotifier(context.Background(), "ut")
+	ctx, cancel := context.WithCancel(context.Background())
+	waited := make(chan error)
+	go func() {
+		waited <- en.waitNextContext(ctx, -1)
+	}()
+	cancel()
+	assert.Equal(t, context.Canceled, <-waited)
+}
+
+func TestEventNotifierWaitNextContextClosed(t *testing.T) {
+	en := newEventNotifier(context.Background(), "ut")
+	close(en.newEvents)
+	err := en.waitNextContext(context.Background(), -1)
+	assert.Regexp(t, "FF10186", err)
+}
@@ -136,6 +136,15 @@ func (ep *eventPoller) getPollingOffset() int64 {
 	return ep.pollingOffset
 }
 
+// backlog is the number of sequences that have been notified, beyond the current polling offset
+func (ep *eventPoller) backlog() int64 {
+	backlog := ep.eventNotifier.currentSequence() - ep.getPollingOffset()
+	if backlog < 0 {
+		return 0
+	}
+	return backlog
+}
+
 func (ep *eventPoller) commitOffset(ctx context.Context, offset int64) error {
 	// Next polling cycle should start one higher than this offset
 	ep.pollingOffset = offset
@@ -0,0 +1,105 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"context"
+	"database/sql/driver"
+
+	"github.com/hyperledger-labs/firefly/internal/i18n"
+	"github.com/hyperledger-labs/firefly/internal/log"
+	"github.com/hyperledger-labs/firefly/pkg/database"
+	"github.com/hyperledger-labs/firefly/pkg/fftypes"
+)
+
+// waitForEvents blocks until the check function returns true, or the context is cancelled (such as
+// the API request timeout expiring).
+//
+// The new event notifier wakes the waiter on each new event, and the check is performed each time.
+// Because the latest sequence is read before each check, an event that was emitted before (or during)
+// the check cannot be missed.
+func (em *eventManager) waitForEvents(ctx context.Context, check func() (bool, error)) error {
+	for {
+		lastSequence := em.newEventNotifier.currentSequence()
+		done, err := check()
+		if err != nil || done {
+			return err
+		}
+		if err := em.newEventNotifier.waitNextContext(ctx, lastSequence); err != nil {
+			return err
+		}
+	}
+}
+
+// WaitForMessage blocks until the local node has emitted a message_confirmed or message_invalid event
+// for the message
+func (em *eventManager) WaitForMessage(ctx context.Context, ns string, id *fftypes.UUID) (*fftypes.Message, error) {
+	fb := database.EventQueryFactory.NewFilter(ctx)
+	filter := fb.And(
+		fb.Eq("namespace", ns),
+		fb.Eq("reference", id),
+		fb.In("type", []driver.Value{fftypes.EventTypeMessageConfirmed, fftypes.EventTypeMessageInvalid}),
+	).Limit(1)
+	var event *fftypes.Event
+	err := em.waitForEvents(ctx, func() (bool, error) {
+		events, _, err := em.database.GetEvents(ctx, filter)
+		if err != nil || len(events) == 0 {
+			return false, err
+		}
+		event = events[0]
+		return true, nil
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, i18n.WrapError(ctx, err, i18n.MsgConfirmTimeout, id)
+		}
+		return nil, err
+	}
+	log.L(ctx).Debugf("Wait for message %s complete: %s (%d)", id, event.Type, event.Sequence)
+	if event.Type == fftypes.EventTypeMessageInvalid {
+		return nil, i18n.NewError(ctx, i18n.MsgConfirmMessageInvalid, id)
+	}
+	return em.database.GetMessageByID(ctx, id)
+}
+
+// WaitForReply blocks until a confirmed message is available, with a correlation ID (cid) that
+// matches the ID of the request message
+func (em *eventManager) WaitForReply(ctx context.Context, ns string, requestID *fftypes.UUID) (*fftypes.Message, error) {
+	fb := database.MessageQueryFactory.NewFilter(ctx)
+	filter := fb.And(
+		fb.Eq("namespace", ns),
+		fb.Eq("cid", requestID),
+		fb.Gt("confirmed", 0),
+	).Sort("sequence").Limit(1)
+	var reply *fftypes.Message
+	err := em.waitForEvents(ctx, func() (bool, error) {
+		msgs, _, err := em.database.GetMessages(ctx, filter)
+		if err != nil || len(msgs) == 0 {
+			return false, err
+		}
+		reply = msgs[0]
+		return true, nil
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, i18n.WrapError(ctx, err, i18n.MsgRequestReplyTimeout, requestID)
+		}
+		return nil, err
+	}
+	log.L(ctx).Debugf("Reply %s received for request %s", reply.Header.ID, requestID)
+	return reply, nil
+}
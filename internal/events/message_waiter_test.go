@@ -0,0 +1,152 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hyperledger-labs/firefly/mocks/databasemocks"
+	"github.com/hyperledger-labs/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestWaitForMessageConfirmed(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	mdi := em.database.(*databasemocks.Plugin)
+
+	msgID := fftypes.NewUUID()
+	mdi.On("GetEvents", mock.Anything, mock.Anything).Return([]*fftypes.Event{}, nil, nil).Once()
+	mdi.On("GetEvents", mock.Anything, mock.Anything).Return([]*fftypes.Event{
+		{Type: fftypes.EventTypeMessageConfirmed, Reference: msgID, Sequence: 12345},
+	}, nil, nil).Once()
+	mdi.On("GetMessageByID", mock.Anything, msgID).Return(&fftypes.Message{
+		Header: fftypes.MessageHeader{ID: msgID},
+	}, nil)
+
+	done := make(chan struct{})
+	go func() {
+		// Keep notifying new events until the waiter has returned
+		for i := int64(0); ; i++ {
+			select {
+			case em.NewEvents() <- i:
+				time.Sleep(1 * time.Millisecond)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	msg, err := em.WaitForMessage(context.Background(), "ns1", msgID)
+	close(done)
+	assert.NoError(t, err)
+	assert.Equal(t, *msgID, *msg.Header.ID)
+	mdi.AssertExpectations(t)
+}
+
+func TestWaitForMessageInvalid(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	mdi := em.database.(*databasemocks.Plugin)
+
+	msgID := fftypes.NewUUID()
+	mdi.On("GetEvents", mock.Anything, mock.Anything).Return([]*fftypes.Event{
+		{Type: fftypes.EventTypeMessageInvalid, Reference: msgID, Sequence: 12345},
+	}, nil, nil)
+
+	_, err := em.WaitForMessage(context.Background(), "ns1", msgID)
+	assert.Regexp(t, "FF10249", err)
+}
+
+func TestWaitForMessageQueryFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	mdi := em.database.(*databasemocks.Plugin)
+
+	mdi.On("GetEvents", mock.Anything, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+
+	_, err := em.WaitForMessage(context.Background(), "ns1", fftypes.NewUUID())
+	assert.EqualError(t, err, "pop")
+}
+
+func TestWaitForMessageTimeout(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	mdi := em.database.(*databasemocks.Plugin)
+
+	mdi.On("GetEvents", mock.Anything, mock.Anything).Return([]*fftypes.Event{}, nil, nil)
+
+	ctx, ctxCancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer ctxCancel()
+	_, err := em.WaitForMessage(ctx, "ns1", fftypes.NewUUID())
+	assert.Regexp(t, "FF10250", err)
+}
+
+func TestWaitForMessageClosed(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	mdi := em.database.(*databasemocks.Plugin)
+
+	mdi.On("GetEvents", mock.Anything, mock.Anything).Return([]*fftypes.Event{}, nil, nil)
+
+	cancel()
+	_, err := em.WaitForMessage(context.Background(), "ns1", fftypes.NewUUID())
+	assert.Regexp(t, "FF10186", err)
+}
+
+func TestWaitForReplyOk(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	mdi := em.database.(*databasemocks.Plugin)
+
+	requestID := fftypes.NewUUID()
+	replyID := fftypes.NewUUID()
+	mdi.On("GetMessages", mock.Anything, mock.Anything).Return([]*fftypes.Message{
+		{Header: fftypes.MessageHeader{ID: replyID, CID: requestID}},
+	}, nil, nil)
+
+	reply, err := em.WaitForReply(context.Background(), "ns1", requestID)
+	assert.NoError(t, err)
+	assert.Equal(t, *replyID, *reply.Header.ID)
+}
+
+func TestWaitForReplyQueryFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	mdi := em.database.(*databasemocks.Plugin)
+
+	mdi.On("GetMessages", mock.Anything, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+
+	_, err := em.WaitForReply(context.Background(), "ns1", fftypes.NewUUID())
+	assert.EqualError(t, err, "pop")
+}
+
+func TestWaitForReplyTimeout(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	mdi := em.database.(*databasemocks.Plugin)
+
+	mdi.On("GetMessages", mock.Anything, mock.Anything).Return([]*fftypes.Message{}, nil, nil)
+
+	ctx, ctxCancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer ctxCancel()
+	_, err := em.WaitForReply(ctx, "ns1", fftypes.NewUUID())
+	assert.Regexp(t, "FF10252", err)
+}
@@ -18,33 +18,40 @@ package events
 
 import (
 	"context"
+	"crypto/sha256"
 
 	"github.com/hyperledger-labs/firefly/internal/log"
 	"github.com/hyperledger-labs/firefly/pkg/database"
 	"github.com/hyperledger-labs/firefly/pkg/fftypes"
 )
 
-func (em *eventManager) persistBatchFromBroadcast(ctx context.Context /* db TX context*/, batch *fftypes.Batch, onchainHash *fftypes.Bytes32, author string) error {
+// validateBroadcastBatch checks the author and hash of a batch retrieved from public storage match the
+// pinning transaction, before we do any further work retrieving the content it refers to.
+func (em *eventManager) validateBroadcastBatch(ctx context.Context, batch *fftypes.Batch, onchainHash *fftypes.Bytes32, author string) bool {
 	l := log.L(ctx)
 
 	// Verify the author matches
 	id, err := em.identity.Resolve(ctx, batch.Author)
 	if err != nil {
 		l.Errorf("Invalid batch '%s'. Author '%s' cound not be resolved: %s", batch.ID, batch.Author, err)
-		return nil // This is not retryable. skip this batch
+		return false
 	}
 	if author != id.OnChain {
 		l.Errorf("Invalid batch '%s'. Author '%s' does not match transaction submitter '%s'", batch.ID, id.OnChain, author)
-		return nil // This is not retryable. skip this batch
+		return false
 	}
 
 	if !onchainHash.Equals(batch.Hash) {
 		l.Errorf("Invalid batch '%s'. Hash in batch '%s' does not match transaction hash '%s'", batch.ID, batch.Hash, onchainHash)
-		return nil // This is not retryable. skip this batch
+		return false
+	}
+
+	if hash := batch.Payload.Hash(); !hash.Equals(batch.Hash) {
+		l.Errorf("Invalid batch '%s'. Hash does not match payload. Found=%s Expected=%s", batch.ID, hash, batch.Hash)
+		return false
 	}
 
-	_, err = em.persistBatch(ctx, batch)
-	return err
+	return true
 }
 
 // persistBatch performs very simple validation on each message/data element (hashes) and either persists
@@ -105,10 +112,18 @@ func (em *eventManager) persistBatchData(ctx context.Context /* db TX context*/,
 		return nil // skip data entry
 	}
 
-	hash := data.Value.Hash()
-	if data.Hash == nil || *data.Hash != *hash {
-		l.Errorf("Invalid data entry %d in batch '%s'. Hash does not match value. Found=%s Expected=%s", i, batch.ID, hash, data.Hash)
-		return nil // skip data entry
+	if data.Blobstore {
+		// The hash of a blob is verified against the content received via data exchange, before the message is dispatched
+		if data.Hash == nil {
+			l.Errorf("Invalid data entry %d in batch '%s'. Missing hash for blob", i, batch.ID)
+			return nil // skip data entry
+		}
+	} else {
+		hash := data.Value.Hash()
+		if data.Hash == nil || *data.Hash != *hash {
+			l.Errorf("Invalid data entry %d in batch '%s'. Hash does not match value. Found=%s Expected=%s", i, batch.ID, hash, data.Hash)
+			return nil // skip data entry
+		}
 	}
 
 	// Insert the data, ensuring the hash doesn't change
@@ -157,3 +172,39 @@ func (em *eventManager) persistBatchMessage(ctx context.Context /* db TX context
 
 	return nil
 }
+
+// persistUnpinnedBatch records a pin for each message in a private batch that was sent over data exchange without
+// a blockchain transaction. Every pin from a sender in a group shares the same context, so the aggregator confirms
+// that sender's messages in the order they arrived here - which is not an order that has been proven on-chain.
+func (em *eventManager) persistUnpinnedBatch(ctx context.Context /* db TX context*/, batch *fftypes.Batch) error {
+	l := log.L(ctx)
+
+	if batch.Group == nil {
+		l.Errorf("Invalid batch '%s'. Only private batches can be sent without a transaction", batch.ID)
+		return nil // This is not retryable. skip this batch
+	}
+	h := sha256.New()
+	h.Write((*batch.Group)[:])
+	h.Write([]byte(batch.Author))
+	senderContext := fftypes.HashResult(h)
+
+	// The index of a pin is the index of the first topic of the message, across all messages in the batch
+	var index int64
+	for i, msg := range batch.Payload.Messages {
+		if msg == nil {
+			continue
+		}
+		if msg.Header.TxType != fftypes.TransactionTypeNone || msg.Header.Author != batch.Author || !msg.Header.Group.Equals(batch.Group) || len(msg.Header.Topics) == 0 {
+			l.Errorf("Invalid message entry %d in batch '%s'. Message is not an unpinned message from the batch author to the batch group", i, batch.ID)
+		} else if err := em.database.UpsertPin(ctx, &fftypes.Pin{
+			Hash:    senderContext,
+			Batch:   batch.ID,
+			Index:   index,
+			Created: fftypes.Now(),
+		}); err != nil {
+			return err
+		}
+		index += int64(len(msg.Header.Topics))
+	}
+	return nil
+}
@@ -21,10 +21,12 @@ import (
 	"regexp"
 	"sync"
 
+	"github.com/hyperledger-labs/firefly/internal/broadcast"
 	"github.com/hyperledger-labs/firefly/internal/config"
 	"github.com/hyperledger-labs/firefly/internal/events/eifactory"
 	"github.com/hyperledger-labs/firefly/internal/i18n"
 	"github.com/hyperledger-labs/firefly/internal/log"
+	"github.com/hyperledger-labs/firefly/internal/privatemessaging"
 	"github.com/hyperledger-labs/firefly/internal/retry"
 	"github.com/hyperledger-labs/firefly/pkg/database"
 	"github.com/hyperledger-labs/firefly/pkg/events"
@@ -51,6 +53,8 @@ type connection struct {
 type subscriptionManager struct {
 	ctx                  context.Context
 	database             database.Plugin
+	broadcast            broadcast.Manager
+	messaging            privatemessaging.Manager
 	eventNotifier        *eventNotifier
 	transports           map[string]events.Plugin
 	connections          map[string]*connection
@@ -63,11 +67,13 @@ type subscriptionManager struct {
 	retry                retry.Retry
 }
 
-func newSubscriptionManager(ctx context.Context, di database.Plugin, en *eventNotifier) (*subscriptionManager, error) {
+func newSubscriptionManager(ctx context.Context, di database.Plugin, bm broadcast.Manager, pm privatemessaging.Manager, en *eventNotifier) (*subscriptionManager, error) {
 	ctx, cancelCtx := context.WithCancel(ctx)
 	sm := &subscriptionManager{
 		ctx:                  ctx,
 		database:             di,
+		broadcast:            bm,
+		messaging:            pm,
 		transports:           make(map[string]events.Plugin),
 		connections:          make(map[string]*connection),
 		durableSubs:          make(map[fftypes.UUID]*subscription),
@@ -118,7 +124,7 @@ func (sm *subscriptionManager) initTransports() error {
 func (sm *subscriptionManager) start() error {
 	fb := database.SubscriptionQueryFactory.NewFilter(sm.ctx)
 	filter := fb.And().Limit(sm.maxSubs)
-	persistedSubs, err := sm.database.GetSubscriptions(sm.ctx, filter)
+	persistedSubs, _, err := sm.database.GetSubscriptions(sm.ctx, filter)
 	if err != nil {
 		return err
 	}
@@ -132,6 +138,12 @@ func (sm *subscriptionManager) start() error {
 			continue
 		}
 		sm.durableSubs[*subDef.ID] = newSub
+		// Connections can be registered before we start (such as by connect-out transports during init)
+		for _, conn := range sm.connections {
+			if conn.matcher != nil && conn.matcher(subDef.SubscriptionRef) {
+				sm.matchedSubscriptionWithLock(conn, newSub)
+			}
+		}
 	}
 	go sm.subscriptionEventListener()
 	return nil
@@ -225,9 +237,13 @@ func (sm *subscriptionManager) deletedDurableSubscription(id *fftypes.UUID) {
 func (sm *subscriptionManager) parseSubscriptionDef(ctx context.Context, subDef *fftypes.Subscription) (sub *subscription, err error) {
 	filter := subDef.Filter
 
-	if _, ok := sm.transports[subDef.Transport]; !ok {
+	transport, ok := sm.transports[subDef.Transport]
+	if !ok {
 		return nil, i18n.NewError(ctx, i18n.MsgUnknownEventTransportPlugin, subDef.Transport)
 	}
+	if err := transport.ValidateOptions(&subDef.Options); err != nil {
+		return nil, err
+	}
 
 	var eventFilter *regexp.Regexp
 	if filter.Events != "" {
@@ -329,14 +345,18 @@ func (sm *subscriptionManager) registerConnection(ei events.Plugin, connID strin
 
 func (sm *subscriptionManager) matchedSubscriptionWithLock(conn *connection, sub *subscription) {
 	ei, foundTransport := sm.transports[sub.definition.Transport]
-	if foundTransport {
-		if _, ok := conn.dispatchers[*sub.definition.ID]; !ok {
-			dispatcher := newEventDispatcher(sm.ctx, ei, sm.database, conn.id, sub, sm.eventNotifier)
-			conn.dispatchers[*sub.definition.ID] = dispatcher
-			dispatcher.start()
-		}
-	} else {
+	if !foundTransport {
 		log.L(sm.ctx).Warnf("Subscription %s:%s [%s] defined for unknown transport '%s", sub.definition.Namespace, sub.definition.Name, sub.definition.ID, sub.definition.Transport)
+		return
+	}
+	if ei != conn.ei {
+		// A connection only dispatches subscriptions defined for its own transport
+		return
+	}
+	if _, ok := conn.dispatchers[*sub.definition.ID]; !ok {
+		dispatcher := newEventDispatcher(sm.ctx, ei, sm.database, sm.broadcast, sm.messaging, conn.id, sub, sm.eventNotifier)
+		conn.dispatchers[*sub.definition.ID] = dispatcher
+		dispatcher.start()
 	}
 }
 
@@ -370,7 +390,7 @@ func (sm *subscriptionManager) ephemeralSubscription(ei events.Plugin, connID, n
 	}
 
 	// Create the dispatcher, and start immediately
-	dispatcher := newEventDispatcher(sm.ctx, ei, sm.database, connID, newSub, sm.eventNotifier)
+	dispatcher := newEventDispatcher(sm.ctx, ei, sm.database, sm.broadcast, sm.messaging, connID, newSub, sm.eventNotifier)
 	dispatcher.start()
 
 	conn.dispatchers[*subID] = dispatcher
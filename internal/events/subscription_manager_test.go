@@ -22,8 +22,10 @@ import (
 	"testing"
 
 	"github.com/hyperledger-labs/firefly/internal/config"
+	"github.com/hyperledger-labs/firefly/mocks/broadcastmocks"
 	"github.com/hyperledger-labs/firefly/mocks/databasemocks"
 	"github.com/hyperledger-labs/firefly/mocks/eventsmocks"
+	"github.com/hyperledger-labs/firefly/mocks/privatemessagingmocks"
 	"github.com/hyperledger-labs/firefly/pkg/events"
 	"github.com/hyperledger-labs/firefly/pkg/fftypes"
 	"github.com/stretchr/testify/assert"
@@ -37,9 +39,10 @@ func newTestSubManager(t *testing.T, mdi *databasemocks.Plugin, mei *eventsmocks
 	mei.On("Name").Return("ut")
 	mei.On("InitPrefix", mock.Anything).Return()
 	mei.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
-	mdi.On("GetEvents", mock.Anything, mock.Anything, mock.Anything).Return([]*fftypes.Event{}, nil).Maybe()
+	mei.On("ValidateOptions", mock.Anything).Return(nil).Maybe()
+	mdi.On("GetEvents", mock.Anything, mock.Anything, mock.Anything).Return([]*fftypes.Event{}, nil, nil).Maybe()
 	mdi.On("GetOffset", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(&fftypes.Offset{ID: fftypes.NewUUID(), Current: 0}, nil).Maybe()
-	sm, err := newSubscriptionManager(ctx, mdi, newEventNotifier(ctx, "ut"))
+	sm, err := newSubscriptionManager(ctx, mdi, &broadcastmocks.Manager{}, &privatemessagingmocks.Manager{}, newEventNotifier(ctx, "ut"))
 	assert.NoError(t, err)
 	sm.transports = map[string]events.Plugin{
 		"ut": mei,
@@ -59,7 +62,7 @@ func TestRegisterDurableSubscriptions(t *testing.T) {
 		{SubscriptionRef: fftypes.SubscriptionRef{
 			ID: sub2,
 		}, Transport: "ut"},
-	}, nil)
+	}, nil, nil)
 	sm, cancel := newTestSubManager(t, mdi, mei)
 	defer cancel()
 	err := sm.start()
@@ -99,7 +102,7 @@ func TestRegisterDurableSubscriptions(t *testing.T) {
 func TestRegisterEphemeralSubscriptions(t *testing.T) {
 	mdi := &databasemocks.Plugin{}
 	mei := &eventsmocks.Plugin{}
-	mdi.On("GetSubscriptions", mock.Anything, mock.Anything).Return([]*fftypes.Subscription{}, nil)
+	mdi.On("GetSubscriptions", mock.Anything, mock.Anything).Return([]*fftypes.Subscription{}, nil, nil)
 	sm, cancel := newTestSubManager(t, mdi, mei)
 	defer cancel()
 	err := sm.start()
@@ -124,7 +127,7 @@ func TestRegisterEphemeralSubscriptions(t *testing.T) {
 func TestRegisterEphemeralSubscriptionsFail(t *testing.T) {
 	mdi := &databasemocks.Plugin{}
 	mei := &eventsmocks.Plugin{}
-	mdi.On("GetSubscriptions", mock.Anything, mock.Anything).Return([]*fftypes.Subscription{}, nil)
+	mdi.On("GetSubscriptions", mock.Anything, mock.Anything).Return([]*fftypes.Subscription{}, nil, nil)
 	sm, cancel := newTestSubManager(t, mdi, mei)
 	defer cancel()
 	err := sm.start()
@@ -143,7 +146,7 @@ func TestSubManagerBadPlugin(t *testing.T) {
 	mdi := &databasemocks.Plugin{}
 	config.Reset()
 	config.Set(config.EventTransportsEnabled, []string{"!unknown!"})
-	_, err := newSubscriptionManager(context.Background(), mdi, newEventNotifier(context.Background(), "ut"))
+	_, err := newSubscriptionManager(context.Background(), mdi, &broadcastmocks.Manager{}, &privatemessagingmocks.Manager{}, newEventNotifier(context.Background(), "ut"))
 	assert.Regexp(t, "FF10172", err)
 }
 
@@ -163,7 +166,7 @@ func TestSubManagerTransportInitError(t *testing.T) {
 func TestStartSubRestoreFail(t *testing.T) {
 	mdi := &databasemocks.Plugin{}
 	mei := &eventsmocks.Plugin{}
-	mdi.On("GetSubscriptions", mock.Anything, mock.Anything).Return(nil, fmt.Errorf("pop"))
+	mdi.On("GetSubscriptions", mock.Anything, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
 	sm, cancel := newTestSubManager(t, mdi, mei)
 	defer cancel()
 	err := sm.start()
@@ -180,7 +183,7 @@ func TestStartSubRestoreOkSubsFail(t *testing.T) {
 			Filter: fftypes.SubscriptionFilter{
 				Events: "[[[[[[not a regex",
 			}},
-	}, nil)
+	}, nil, nil)
 	sm, cancel := newTestSubManager(t, mdi, mei)
 	defer cancel()
 	err := sm.start()
@@ -200,7 +203,7 @@ func TestStartSubRestoreOkSubsOK(t *testing.T) {
 				Tag:    ".*",
 				Group:  ".*",
 			}},
-	}, nil)
+	}, nil, nil)
 	sm, cancel := newTestSubManager(t, mdi, mei)
 	defer cancel()
 	err := sm.start()
@@ -275,7 +278,7 @@ func TestCreateSubscriptionBadGroupFilter(t *testing.T) {
 func TestDispatchDeliveryResponseOK(t *testing.T) {
 	mdi := &databasemocks.Plugin{}
 	mei := &eventsmocks.Plugin{}
-	mdi.On("GetSubscriptions", mock.Anything, mock.Anything).Return([]*fftypes.Subscription{}, nil)
+	mdi.On("GetSubscriptions", mock.Anything, mock.Anything).Return([]*fftypes.Subscription{}, nil, nil)
 	sm, cancel := newTestSubManager(t, mdi, mei)
 	defer cancel()
 	err := sm.start()
@@ -304,7 +307,7 @@ func TestDispatchDeliveryResponseOK(t *testing.T) {
 func TestDispatchDeliveryResponseInvalidSubscription(t *testing.T) {
 	mdi := &databasemocks.Plugin{}
 	mei := &eventsmocks.Plugin{}
-	mdi.On("GetSubscriptions", mock.Anything, mock.Anything).Return([]*fftypes.Subscription{}, nil)
+	mdi.On("GetSubscriptions", mock.Anything, mock.Anything).Return([]*fftypes.Subscription{}, nil, nil)
 	sm, cancel := newTestSubManager(t, mdi, mei)
 	defer cancel()
 	err := sm.start()
@@ -477,3 +480,47 @@ func TestDeletewDurableSubscriptionOk(t *testing.T) {
 	assert.Empty(t, sm.durableSubs)
 	<-ed.closed
 }
+
+func TestMatchedSubscriptionWithLockOtherTransport(t *testing.T) {
+	mdi := &databasemocks.Plugin{}
+	mei := &eventsmocks.Plugin{}
+	sm, cancel := newTestSubManager(t, mdi, mei)
+	defer cancel()
+
+	conn := &connection{ei: &eventsmocks.Plugin{}}
+	sm.matchedSubscriptionWithLock(conn, &subscription{definition: &fftypes.Subscription{Transport: "ut"}})
+	assert.Nil(t, conn.dispatchers)
+}
+
+func TestStartMatchesExistingConnections(t *testing.T) {
+	mdi := &databasemocks.Plugin{}
+	mei := &eventsmocks.Plugin{}
+	sub1 := fftypes.NewUUID()
+	mdi.On("GetSubscriptions", mock.Anything, mock.Anything).Return([]*fftypes.Subscription{
+		{SubscriptionRef: fftypes.SubscriptionRef{
+			ID: sub1,
+		}, Transport: "ut"},
+	}, nil, nil)
+	sm, cancel := newTestSubManager(t, mdi, mei)
+	defer cancel()
+
+	// Connect-out transports register their connection during init, before the start
+	be := &boundCallbacks{sm: sm, ei: mei}
+	be.RegisterConnection("conn1", func(sr fftypes.SubscriptionRef) bool { return true })
+	assert.Empty(t, sm.connections["conn1"].dispatchers)
+
+	err := sm.start()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, len(sm.connections["conn1"].dispatchers))
+	sm.close()
+}
+
+func TestCreateDurableSubscriptionBadOptions(t *testing.T) {
+	mdi := &databasemocks.Plugin{}
+	mei := &eventsmocks.Plugin{}
+	mei.On("ValidateOptions", mock.Anything).Return(fmt.Errorf("pop"))
+	sm, cancel := newTestSubManager(t, mdi, mei)
+	defer cancel()
+	_, err := sm.parseSubscriptionDef(sm.ctx, &fftypes.Subscription{Transport: "ut"})
+	assert.EqualError(t, err, "pop")
+}
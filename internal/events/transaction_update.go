@@ -17,16 +17,19 @@
 package events
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/hyperledger-labs/firefly/internal/i18n"
 	"github.com/hyperledger-labs/firefly/internal/log"
+	"github.com/hyperledger-labs/firefly/internal/metrics"
 	"github.com/hyperledger-labs/firefly/pkg/blockchain"
 	"github.com/hyperledger-labs/firefly/pkg/database"
 	"github.com/hyperledger-labs/firefly/pkg/fftypes"
 )
 
 func (em *eventManager) TxSubmissionUpdate(bi blockchain.Plugin, txTrackingID string, txState fftypes.OpStatus, protocolTxID, errorMessage string, additionalInfo fftypes.JSONObject) error {
+	metrics.BlockchainReceipt(bi.Name(), txState)
 
 	// Find a matching operation, for this plugin, with the specified ID.
 	// We retry a few times, as there's an outside possibility of the event arriving before we're finished persisting the operation itself
@@ -37,7 +40,7 @@ func (em *eventManager) TxSubmissionUpdate(bi blockchain.Plugin, txTrackingID st
 		fb.Eq("plugin", bi.Name()),
 	)
 	err := em.retry.Do(em.ctx, fmt.Sprintf("correlate tx %s", txTrackingID), func(attempt int) (retry bool, err error) {
-		operations, err = em.database.GetOperations(em.ctx, filter)
+		operations, _, err = em.database.GetOperations(em.ctx, filter)
 		if err == nil && len(operations) == 0 {
 			err = i18n.NewError(em.ctx, i18n.Msg404NotFound)
 		}
@@ -53,10 +56,123 @@ func (em *eventManager) TxSubmissionUpdate(bi blockchain.Plugin, txTrackingID st
 		Set("error", errorMessage).
 		Set("info", additionalInfo)
 	for _, op := range operations {
-		if err := em.database.UpdateOperation(em.ctx, op.ID, update); err != nil {
+		if txState == op.Status {
+			// No change in status, such as the redelivery of a final status we have already processed
+			if _, err := em.updateOperation(em.ctx, op, update); err != nil {
+				return err
+			}
+			continue
+		}
+
+		events := operationEvents(op, txState)
+		if txState == fftypes.OpStatusSucceeded {
+			events = append(events, fftypes.NewEvent(fftypes.EventTypeTransactionConfirmed, op.Namespace, op.Transaction, nil))
+		}
+		resubmit := txState == fftypes.OpStatusFailed && op.Type == fftypes.OpTypeBlockchainBatchPin
+		updated := false
+		err := em.database.RunAsGroup(em.ctx, func(ctx context.Context) (err error) {
+			if updated, err = em.updateOperation(ctx, op, update); err != nil || !updated {
+				return err
+			}
+			if err := em.writeEvents(ctx, events); err != nil {
+				return err
+			}
+			if resubmit {
+				// The status of the transaction depends on whether the pin is resubmitted, so is derived below
+				return nil
+			}
+			return em.updateTransactionStatus(ctx, op.Transaction)
+		})
+		if err != nil {
 			return err
 		}
+
+		if resubmit && updated {
+			if err := em.batchPinFailed(bi, op, errorMessage); err != nil {
+				return err
+			}
+			if err := em.updateTransactionStatus(em.ctx, op.Transaction); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// updateOperation applies an update reported by a plugin to an operation, only if the operation has not changed
+// status since we read it. Updates to a cancelled operation are ignored, as are any that race with the operation
+// being cancelled or retried.
+func (em *eventManager) updateOperation(ctx context.Context, op *fftypes.Operation, update database.Update) (bool, error) {
+	updated := false
+	var err error
+	if op.Status != fftypes.OpStatusCancelled {
+		updated, err = em.database.UpdateOperationIfStatus(ctx, op.ID, []fftypes.OpStatus{op.Status}, update)
+	}
+	if err == nil && !updated {
+		log.L(ctx).Infof("Ignoring update to operation %s, which is no longer in status %s", op.ID, op.Status)
+	}
+	return updated, err
+}
+
+// CancelOperation moves a pending or failed operation to cancelled, and re-derives the status of its transaction.
+// Any later update from the plugin for the operation is ignored.
+func (em *eventManager) CancelOperation(ctx context.Context, op *fftypes.Operation) error {
+	return em.database.RunAsGroup(ctx, func(ctx context.Context) error {
+		update := database.OperationQueryFactory.NewUpdate(ctx).Set("status", fftypes.OpStatusCancelled)
+		cancelled, err := em.database.UpdateOperationIfStatus(ctx, op.ID, []fftypes.OpStatus{fftypes.OpStatusPending, fftypes.OpStatusFailed}, update)
+		if err != nil {
+			return err
+		}
+		if !cancelled {
+			return i18n.NewError(ctx, i18n.MsgOperationStatusChanged, op.ID)
+		}
+		return em.updateTransactionStatus(ctx, op.Transaction)
+	})
+}
+
+// updateTransactionStatus re-derives the status of a transaction from all of its operations, after one has changed
+func (em *eventManager) updateTransactionStatus(ctx context.Context, txID *fftypes.UUID) error {
+	tx, err := em.database.GetTransactionByID(ctx, txID)
+	if err != nil || tx == nil {
+		return err
+	}
+	ops, err := em.getTransactionOperations(ctx, txID)
+	if err != nil {
+		return err
 	}
+	status, _ := tx.DeriveStatus(ops)
+	if status == tx.Status {
+		return nil
+	}
+	log.L(ctx).Infof("Transaction %s status %s -> %s", txID, tx.Status, status)
+	update := database.TransactionQueryFactory.NewUpdate(ctx).Set("status", status)
+	return em.database.UpdateTransaction(ctx, txID, update)
+}
 
+func (em *eventManager) getTransactionOperations(ctx context.Context, txID *fftypes.UUID) ([]*fftypes.Operation, error) {
+	fb := database.OperationQueryFactory.NewFilter(ctx)
+	ops, _, err := em.database.GetOperations(ctx, fb.Eq("tx", txID))
+	return ops, err
+}
+
+// operationEvents returns the events to notify subscribers that an operation has reached a final status
+func operationEvents(op *fftypes.Operation, status fftypes.OpStatus) []*fftypes.Event {
+	switch status {
+	case fftypes.OpStatusSucceeded:
+		return []*fftypes.Event{fftypes.NewEvent(fftypes.EventTypeOperationSucceeded, op.Namespace, op.ID, nil)}
+	case fftypes.OpStatusFailed:
+		return []*fftypes.Event{fftypes.NewEvent(fftypes.EventTypeOperationFailed, op.Namespace, op.ID, nil)}
+	default:
+		return nil
+	}
+}
+
+func (em *eventManager) writeEvents(ctx context.Context, events []*fftypes.Event) error {
+	for _, event := range events {
+		if err := em.database.UpsertEvent(ctx, event, false); err != nil {
+			return err
+		}
+	}
 	return nil
 }
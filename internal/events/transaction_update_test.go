@@ -17,6 +17,7 @@
 package events
 
 import (
+	"context"
 	"fmt"
 	"testing"
 	"time"
@@ -42,12 +43,23 @@ func TestTxSubmissionUpdateRetryThenFound(t *testing.T) {
 
 	opID := fftypes.NewUUID()
 	mbi.On("Name").Return("ut")
-	mdi.On("GetOperations", em.ctx, mock.Anything).Return(nil, fmt.Errorf("will retry")).Once()
-	mdi.On("GetOperations", em.ctx, mock.Anything).Return(nil, nil).Once() // retry again
+	mdi.On("GetOperations", em.ctx, mock.Anything).Return(nil, nil, fmt.Errorf("will retry")).Once()
+	mdi.On("GetOperations", em.ctx, mock.Anything).Return(nil, nil, nil).Once() // retry again
 	mdi.On("GetOperations", em.ctx, mock.Anything).Return([]*fftypes.Operation{
 		{ID: opID},
-	}, nil)
-	mdi.On("UpdateOperation", em.ctx, uuidMatches(opID), mock.Anything).Return(nil)
+	}, nil, nil)
+	rag := mdi.On("RunAsGroup", em.ctx, mock.Anything)
+	rag.RunFn = func(a mock.Arguments) {
+		rag.ReturnArguments = mock.Arguments{
+			a[1].(func(ctx context.Context) error)(a[0].(context.Context)),
+		}
+	}
+	mdi.On("UpdateOperationIfStatus", em.ctx, uuidMatches(opID), mock.Anything, mock.Anything).Return(true, nil)
+	mdi.On("UpsertEvent", em.ctx, mock.MatchedBy(func(e *fftypes.Event) bool {
+		return e.Type == fftypes.EventTypeOperationFailed && *e.Reference == *opID
+	}), false).Return(nil)
+
+	mdi.On("GetTransactionByID", em.ctx, mock.Anything).Return(nil, nil)
 
 	info := fftypes.JSONObject{"some": "info"}
 	err := em.TxSubmissionUpdate(mbi, "tracking12345", fftypes.OpStatusFailed, "tx12345", "some error", info)
@@ -62,7 +74,7 @@ func TestTransactionLookupNotFound(t *testing.T) {
 	em.opCorrelationRetries = 0
 
 	mbi.On("Name").Return("ut")
-	mdi.On("GetOperations", em.ctx, mock.Anything).Return(nil, fmt.Errorf("pop")).Once()
+	mdi.On("GetOperations", em.ctx, mock.Anything).Return(nil, nil, fmt.Errorf("pop")).Once()
 
 	info := fftypes.JSONObject{"some": "info"}
 	err := em.TxSubmissionUpdate(mbi, "tracking12345", fftypes.OpStatusFailed, "tx12345", "some error", info)
@@ -80,10 +92,318 @@ func TestTxSubmissionUpdateError(t *testing.T) {
 	mbi.On("Name").Return("ut")
 	mdi.On("GetOperations", em.ctx, mock.Anything).Return([]*fftypes.Operation{
 		{ID: opID},
-	}, nil)
-	mdi.On("UpdateOperation", em.ctx, uuidMatches(opID), mock.Anything).Return(fmt.Errorf("pop"))
+	}, nil, nil)
+	rag := mdi.On("RunAsGroup", em.ctx, mock.Anything)
+	rag.RunFn = func(a mock.Arguments) {
+		rag.ReturnArguments = mock.Arguments{
+			a[1].(func(ctx context.Context) error)(a[0].(context.Context)),
+		}
+	}
+	mdi.On("UpdateOperationIfStatus", em.ctx, uuidMatches(opID), mock.Anything, mock.Anything).Return(false, fmt.Errorf("pop"))
 
 	info := fftypes.JSONObject{"some": "info"}
 	err := em.TxSubmissionUpdate(mbi, "tracking12345", fftypes.OpStatusFailed, "tx12345", "some error", info)
 	assert.EqualError(t, err, "pop")
 }
+
+func TestTxSubmissionUpdatePendingUnchanged(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	mdi := em.database.(*databasemocks.Plugin)
+	mbi := &blockchainmocks.Plugin{}
+	em.opCorrelationRetries = 0
+
+	opID := fftypes.NewUUID()
+	txID := fftypes.NewUUID()
+	mbi.On("Name").Return("ut")
+	mdi.On("GetOperations", em.ctx, mock.Anything).Return([]*fftypes.Operation{
+		{ID: opID, Transaction: txID, Status: fftypes.OpStatusPending},
+	}, nil, nil)
+	mdi.On("UpdateOperationIfStatus", em.ctx, uuidMatches(opID), mock.Anything, mock.Anything).Return(true, nil)
+
+	// No events are emitted, as the status has not changed
+	err := em.TxSubmissionUpdate(mbi, "tracking12345", fftypes.OpStatusPending, "tx12345", "", nil)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+	mdi.AssertNotCalled(t, "UpsertEvent", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestTxSubmissionUpdateConfirmed(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	mdi := em.database.(*databasemocks.Plugin)
+	mbi := &blockchainmocks.Plugin{}
+	em.opCorrelationRetries = 0
+
+	opID := fftypes.NewUUID()
+	txID := fftypes.NewUUID()
+	mbi.On("Name").Return("ut")
+	mdi.On("GetOperations", em.ctx, mock.Anything).Return([]*fftypes.Operation{
+		{ID: opID, Transaction: txID, Status: fftypes.OpStatusPending, Type: fftypes.OpTypeBlockchainBatchPin},
+	}, nil, nil)
+	rag := mdi.On("RunAsGroup", em.ctx, mock.Anything)
+	rag.RunFn = func(a mock.Arguments) {
+		rag.ReturnArguments = mock.Arguments{
+			a[1].(func(ctx context.Context) error)(a[0].(context.Context)),
+		}
+	}
+	mdi.On("UpdateOperationIfStatus", em.ctx, uuidMatches(opID), mock.Anything, mock.Anything).Return(true, nil)
+	mdi.On("UpsertEvent", em.ctx, mock.MatchedBy(func(e *fftypes.Event) bool {
+		return e.Type == fftypes.EventTypeOperationSucceeded && *e.Reference == *opID
+	}), false).Return(nil)
+	mdi.On("UpsertEvent", em.ctx, mock.MatchedBy(func(e *fftypes.Event) bool {
+		return e.Type == fftypes.EventTypeTransactionConfirmed && *e.Reference == *txID
+	}), false).Return(nil)
+
+	mdi.On("GetTransactionByID", em.ctx, txID).Return(&fftypes.Transaction{
+		ID:         txID,
+		Status:     fftypes.OpStatusPending,
+		ProtocolID: "tx12345",
+	}, nil)
+	mdi.On("GetOperations", em.ctx, mock.Anything).Return([]*fftypes.Operation{
+		{ID: opID, Transaction: txID, Status: fftypes.OpStatusSucceeded, Type: fftypes.OpTypeBlockchainBatchPin},
+	}, nil, nil)
+	mdi.On("UpdateTransaction", em.ctx, txID, mock.Anything).Return(nil)
+
+	err := em.TxSubmissionUpdate(mbi, "tracking12345", fftypes.OpStatusSucceeded, "tx12345", "", nil)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestTxSubmissionUpdateRedelivery(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	mdi := em.database.(*databasemocks.Plugin)
+	mbi := &blockchainmocks.Plugin{}
+	em.opCorrelationRetries = 0
+
+	opID := fftypes.NewUUID()
+	mbi.On("Name").Return("ut")
+	mdi.On("GetOperations", em.ctx, mock.Anything).Return([]*fftypes.Operation{
+		{ID: opID, Status: fftypes.OpStatusSucceeded},
+	}, nil, nil)
+	mdi.On("UpdateOperationIfStatus", em.ctx, uuidMatches(opID), mock.Anything, mock.Anything).Return(true, nil)
+
+	err := em.TxSubmissionUpdate(mbi, "tracking12345", fftypes.OpStatusSucceeded, "tx12345", "", nil)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestTxSubmissionUpdateRedeliveryUpdateFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	mdi := em.database.(*databasemocks.Plugin)
+	mbi := &blockchainmocks.Plugin{}
+	em.opCorrelationRetries = 0
+
+	opID := fftypes.NewUUID()
+	mbi.On("Name").Return("ut")
+	mdi.On("GetOperations", em.ctx, mock.Anything).Return([]*fftypes.Operation{
+		{ID: opID, Status: fftypes.OpStatusSucceeded},
+	}, nil, nil)
+	mdi.On("UpdateOperationIfStatus", em.ctx, uuidMatches(opID), mock.Anything, mock.Anything).Return(false, fmt.Errorf("pop"))
+
+	err := em.TxSubmissionUpdate(mbi, "tracking12345", fftypes.OpStatusSucceeded, "tx12345", "", nil)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestTxSubmissionUpdateEventFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	mdi := em.database.(*databasemocks.Plugin)
+	mbi := &blockchainmocks.Plugin{}
+	em.opCorrelationRetries = 0
+
+	opID := fftypes.NewUUID()
+	mbi.On("Name").Return("ut")
+	mdi.On("GetOperations", em.ctx, mock.Anything).Return([]*fftypes.Operation{
+		{ID: opID},
+	}, nil, nil)
+	rag := mdi.On("RunAsGroup", em.ctx, mock.Anything)
+	rag.RunFn = func(a mock.Arguments) {
+		rag.ReturnArguments = mock.Arguments{
+			a[1].(func(ctx context.Context) error)(a[0].(context.Context)),
+		}
+	}
+	mdi.On("UpdateOperationIfStatus", em.ctx, uuidMatches(opID), mock.Anything, mock.Anything).Return(true, nil)
+	mdi.On("UpsertEvent", em.ctx, mock.Anything, false).Return(fmt.Errorf("pop"))
+
+	err := em.TxSubmissionUpdate(mbi, "tracking12345", fftypes.OpStatusSucceeded, "tx12345", "", nil)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestOperationEventsPending(t *testing.T) {
+	assert.Empty(t, operationEvents(&fftypes.Operation{}, fftypes.OpStatusPending))
+}
+
+func TestUpdateTransactionStatusUnchanged(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	mdi := em.database.(*databasemocks.Plugin)
+
+	txID := fftypes.NewUUID()
+	mdi.On("GetTransactionByID", em.ctx, txID).Return(&fftypes.Transaction{ID: txID, Status: fftypes.OpStatusPending}, nil)
+	mdi.On("GetOperations", em.ctx, mock.Anything).Return([]*fftypes.Operation{}, nil, nil)
+
+	err := em.updateTransactionStatus(em.ctx, txID)
+	assert.NoError(t, err)
+	mdi.AssertExpectations(t)
+}
+
+func TestUpdateTransactionStatusGetOperationsFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	mdi := em.database.(*databasemocks.Plugin)
+
+	txID := fftypes.NewUUID()
+	mdi.On("GetTransactionByID", em.ctx, txID).Return(&fftypes.Transaction{ID: txID}, nil)
+	mdi.On("GetOperations", em.ctx, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+
+	err := em.updateTransactionStatus(em.ctx, txID)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestTxSubmissionUpdateTransactionStatusFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	mdi := em.database.(*databasemocks.Plugin)
+	mbi := &blockchainmocks.Plugin{}
+	em.opCorrelationRetries = 0
+
+	opID := fftypes.NewUUID()
+	mbi.On("Name").Return("ut")
+	mdi.On("GetOperations", em.ctx, mock.Anything).Return([]*fftypes.Operation{
+		{ID: opID},
+	}, nil, nil)
+	rag := mdi.On("RunAsGroup", em.ctx, mock.Anything)
+	rag.RunFn = func(a mock.Arguments) {
+		rag.ReturnArguments = mock.Arguments{
+			a[1].(func(ctx context.Context) error)(a[0].(context.Context)),
+		}
+	}
+	mdi.On("UpdateOperationIfStatus", em.ctx, uuidMatches(opID), mock.Anything, mock.Anything).Return(true, nil)
+	mdi.On("UpsertEvent", em.ctx, mock.Anything, false).Return(nil)
+	mdi.On("GetTransactionByID", em.ctx, mock.Anything).Return(nil, fmt.Errorf("pop"))
+
+	err := em.TxSubmissionUpdate(mbi, "tracking12345", fftypes.OpStatusSucceeded, "tx12345", "", nil)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestTxSubmissionUpdateCancelledIgnored(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	mdi := em.database.(*databasemocks.Plugin)
+	mbi := &blockchainmocks.Plugin{}
+	em.opCorrelationRetries = 0
+
+	opID := fftypes.NewUUID()
+	mbi.On("Name").Return("ut")
+	mdi.On("GetOperations", em.ctx, mock.Anything).Return([]*fftypes.Operation{
+		{ID: opID, Status: fftypes.OpStatusCancelled},
+	}, nil, nil)
+	rag := mdi.On("RunAsGroup", em.ctx, mock.Anything)
+	rag.RunFn = func(a mock.Arguments) {
+		rag.ReturnArguments = mock.Arguments{
+			a[1].(func(ctx context.Context) error)(a[0].(context.Context)),
+		}
+	}
+
+	err := em.TxSubmissionUpdate(mbi, "tracking12345", fftypes.OpStatusSucceeded, "tx12345", "", nil)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+	mdi.AssertNotCalled(t, "UpdateOperationIfStatus", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestTxSubmissionUpdateStatusChangedConcurrently(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	mdi := em.database.(*databasemocks.Plugin)
+	mbi := &blockchainmocks.Plugin{}
+	em.opCorrelationRetries = 0
+
+	opID := fftypes.NewUUID()
+	mbi.On("Name").Return("ut")
+	mdi.On("GetOperations", em.ctx, mock.Anything).Return([]*fftypes.Operation{
+		{ID: opID, Type: fftypes.OpTypeBlockchainBatchPin, Status: fftypes.OpStatusPending},
+	}, nil, nil)
+	rag := mdi.On("RunAsGroup", em.ctx, mock.Anything)
+	rag.RunFn = func(a mock.Arguments) {
+		rag.ReturnArguments = mock.Arguments{
+			a[1].(func(ctx context.Context) error)(a[0].(context.Context)),
+		}
+	}
+	mdi.On("UpdateOperationIfStatus", em.ctx, uuidMatches(opID), []fftypes.OpStatus{fftypes.OpStatusPending}, mock.Anything).Return(false, nil)
+
+	// No events, and no resubmission, for an operation that was cancelled while we processed the receipt
+	err := em.TxSubmissionUpdate(mbi, "tracking12345", fftypes.OpStatusFailed, "tx12345", "pop", nil)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+	mdi.AssertNotCalled(t, "UpsertEvent", mock.Anything, mock.Anything, mock.Anything)
+	mdi.AssertNotCalled(t, "UpsertOperation", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestCancelOperation(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	mdi := em.database.(*databasemocks.Plugin)
+
+	op := &fftypes.Operation{ID: fftypes.NewUUID(), Transaction: fftypes.NewUUID()}
+	rag := mdi.On("RunAsGroup", em.ctx, mock.Anything)
+	rag.RunFn = func(a mock.Arguments) {
+		rag.ReturnArguments = mock.Arguments{
+			a[1].(func(ctx context.Context) error)(a[0].(context.Context)),
+		}
+	}
+	mdi.On("UpdateOperationIfStatus", em.ctx, op.ID, []fftypes.OpStatus{fftypes.OpStatusPending, fftypes.OpStatusFailed}, mock.Anything).Return(true, nil)
+	mdi.On("GetTransactionByID", em.ctx, op.Transaction).Return(&fftypes.Transaction{ID: op.Transaction, Status: fftypes.OpStatusPending}, nil)
+	mdi.On("GetOperations", em.ctx, mock.Anything).Return([]*fftypes.Operation{
+		{Type: fftypes.OpTypeBlockchainBatchPin, Status: fftypes.OpStatusCancelled},
+	}, nil, nil)
+	mdi.On("UpdateTransaction", em.ctx, op.Transaction, mock.Anything).Return(nil)
+
+	err := em.CancelOperation(em.ctx, op)
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestCancelOperationStatusChanged(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	mdi := em.database.(*databasemocks.Plugin)
+
+	op := &fftypes.Operation{ID: fftypes.NewUUID(), Transaction: fftypes.NewUUID()}
+	rag := mdi.On("RunAsGroup", em.ctx, mock.Anything)
+	rag.RunFn = func(a mock.Arguments) {
+		rag.ReturnArguments = mock.Arguments{
+			a[1].(func(ctx context.Context) error)(a[0].(context.Context)),
+		}
+	}
+	mdi.On("UpdateOperationIfStatus", em.ctx, op.ID, mock.Anything, mock.Anything).Return(false, nil)
+
+	err := em.CancelOperation(em.ctx, op)
+	assert.Regexp(t, "FF10298", err)
+}
+
+func TestCancelOperationUpdateFail(t *testing.T) {
+	em, cancel := newTestEventManager(t)
+	defer cancel()
+	mdi := em.database.(*databasemocks.Plugin)
+
+	op := &fftypes.Operation{ID: fftypes.NewUUID(), Transaction: fftypes.NewUUID()}
+	rag := mdi.On("RunAsGroup", em.ctx, mock.Anything)
+	rag.RunFn = func(a mock.Arguments) {
+		rag.ReturnArguments = mock.Arguments{
+			a[1].(func(ctx context.Context) error)(a[0].(context.Context)),
+		}
+	}
+	mdi.On("UpdateOperationIfStatus", em.ctx, op.ID, mock.Anything, mock.Anything).Return(false, fmt.Errorf("pop"))
+
+	err := em.CancelOperation(em.ctx, op)
+	assert.EqualError(t, err, "pop")
+}
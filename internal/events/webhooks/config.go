@@ -0,0 +1,52 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhooks
+
+import "github.com/hyperledger-labs/firefly/internal/config"
+
+const (
+	defaultRequestTimeout = "30s"
+	defaultRetryInitDelay = "250ms"
+	defaultRetryMaxDelay  = "30s"
+	defaultRetryFactor    = 2.0
+	defaultRetryAttempts  = 10
+)
+
+const (
+	// RequestTimeout is the timeout for each HTTP request to a webhook
+	RequestTimeout = "requestTimeout"
+	// RetryInitDelay is the initial delay before retrying a failed webhook request
+	RetryInitDelay = "retry.initDelay"
+	// RetryMaxDelay is the maximum delay between retries of a failed webhook request
+	RetryMaxDelay = "retry.maxDelay"
+	// RetryFactor is the backoff factor between retries of a failed webhook request
+	RetryFactor = "retry.factor"
+	// RetryMaxAttempts is the number of attempts to deliver an event before rejecting it, so it is redelivered after a backoff
+	RetryMaxAttempts = "retry.maxAttempts"
+	// TLSDirectory is the directory that the TLS files named in the options of a subscription are read from.
+	// Subscriptions cannot use TLS files if this is not set
+	TLSDirectory = "tls.directory"
+)
+
+func (wh *WebHooks) InitPrefix(prefix config.Prefix) {
+	prefix.AddKnownKey(RequestTimeout, defaultRequestTimeout)
+	prefix.AddKnownKey(RetryInitDelay, defaultRetryInitDelay)
+	prefix.AddKnownKey(RetryMaxDelay, defaultRetryMaxDelay)
+	prefix.AddKnownKey(RetryFactor, defaultRetryFactor)
+	prefix.AddKnownKey(RetryMaxAttempts, defaultRetryAttempts)
+	prefix.AddKnownKey(TLSDirectory)
+}
@@ -0,0 +1,261 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/hyperledger-labs/firefly/internal/config"
+	"github.com/hyperledger-labs/firefly/internal/i18n"
+	"github.com/hyperledger-labs/firefly/internal/log"
+	"github.com/hyperledger-labs/firefly/internal/retry"
+	"github.com/hyperledger-labs/firefly/pkg/events"
+	"github.com/hyperledger-labs/firefly/pkg/fftypes"
+)
+
+// WebHooks is a connect-out transport, that delivers each event as an HTTP request to the URL
+// configured on the subscription. A 2xx response acknowledges the event.
+type WebHooks struct {
+	ctx          context.Context
+	capabilities *events.Capabilities
+	callbacks    events.Callbacks
+	connID       string
+	retry        retry.Retry
+	maxAttempts  int
+	tlsDir       string
+	prefix       config.Prefix
+	clients      map[string]*resty.Client
+	clientsMux   sync.Mutex
+}
+
+func (wh *WebHooks) Name() string { return "webhooks" }
+
+func (wh *WebHooks) Init(ctx context.Context, prefix config.Prefix, callbacks events.Callbacks) error {
+	*wh = WebHooks{
+		ctx:          ctx,
+		capabilities: &events.Capabilities{},
+		callbacks:    callbacks,
+		connID:       fftypes.ShortID(),
+		retry: retry.Retry{
+			InitialDelay: prefix.GetDuration(RetryInitDelay),
+			MaximumDelay: prefix.GetDuration(RetryMaxDelay),
+			Factor:       prefix.GetFloat64(RetryFactor),
+		},
+		maxAttempts: prefix.GetInt(RetryMaxAttempts),
+		tlsDir:      prefix.GetString(TLSDirectory),
+		prefix:      prefix,
+		clients:     make(map[string]*resty.Client),
+	}
+	// We have a single logical connection, that matches all subscriptions defined for this transport
+	return callbacks.RegisterConnection(wh.connID, func(sr fftypes.SubscriptionRef) bool { return true })
+}
+
+func (wh *WebHooks) Capabilities() *events.Capabilities {
+	return wh.capabilities
+}
+
+func (wh *WebHooks) ValidateOptions(options *fftypes.SubscriptionOptions) error {
+	if options.Webhook == nil || options.Webhook.URL == "" {
+		return i18n.NewError(wh.ctx, i18n.MsgWebhookURLEmpty)
+	}
+	u, err := url.Parse(options.Webhook.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return i18n.NewError(wh.ctx, i18n.MsgWebhookInvalidURL, options.Webhook.URL)
+	}
+	_, err = wh.buildTLSConfig(options.Webhook.TLS)
+	return err
+}
+
+func (wh *WebHooks) buildTLSConfig(options *fftypes.WebhookTLSOptions) (*tls.Config, error) {
+	if options == nil {
+		return nil, nil
+	}
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: options.InsecureSkipVerify, // #nosec - explicitly requested on the subscription
+	}
+	if options.CAFile != "" {
+		caFile, err := wh.tlsFile(options.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		caPEM, err := ioutil.ReadFile(caFile)
+		if err != nil {
+			return nil, i18n.WrapError(wh.ctx, err, i18n.MsgWebhookTLSInvalid, options.CAFile)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(caPEM) {
+			return nil, i18n.NewError(wh.ctx, i18n.MsgWebhookTLSInvalid, options.CAFile)
+		}
+	}
+	if options.CertFile != "" || options.KeyFile != "" {
+		certFile, err := wh.tlsFile(options.CertFile)
+		if err != nil {
+			return nil, err
+		}
+		keyFile, err := wh.tlsFile(options.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, i18n.WrapError(wh.ctx, err, i18n.MsgWebhookTLSInvalid, options.CertFile)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+// tlsFile resolves a TLS file named in the options of a subscription, which must be within the TLS directory
+func (wh *WebHooks) tlsFile(name string) (string, error) {
+	if wh.tlsDir == "" {
+		return "", i18n.NewError(wh.ctx, i18n.MsgWebhookTLSDirNotSet)
+	}
+	rel := filepath.Clean(name)
+	if filepath.IsAbs(rel) || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", i18n.NewError(wh.ctx, i18n.MsgWebhookTLSInvalid, name)
+	}
+	return filepath.Join(wh.tlsDir, rel), nil
+}
+
+// getClient returns a client for the TLS options of a subscription. Clients are shared by all subscriptions
+// with the same options, rather than being held per subscription, so there is nothing to clean up when a
+// subscription is deleted, and a subscription re-created with different options gets a different client.
+func (wh *WebHooks) getClient(options *fftypes.WebhookTLSOptions) (*resty.Client, error) {
+	key := ""
+	if options != nil {
+		b, _ := json.Marshal(options)
+		key = string(b)
+	}
+	wh.clientsMux.Lock()
+	defer wh.clientsMux.Unlock()
+	if client, ok := wh.clients[key]; ok {
+		return client, nil
+	}
+	tlsConfig, err := wh.buildTLSConfig(options)
+	if err != nil {
+		return nil, err
+	}
+	client := resty.New().SetTimeout(wh.prefix.GetDuration(RequestTimeout))
+	if tlsConfig != nil {
+		client.SetTLSClientConfig(tlsConfig)
+	}
+	wh.clients[key] = client
+	return client, nil
+}
+
+func (wh *WebHooks) DeliveryRequest(connID string, sub *fftypes.Subscription, event *fftypes.EventDelivery) error {
+	if sub.Options.Webhook == nil {
+		return i18n.NewError(wh.ctx, i18n.MsgWebhookURLEmpty)
+	}
+	// Delivery happens with retry on the delivery routine of the subscription, so events are sent to the webhook
+	// one at a time in sequence order. The acknowledgement is sent once the webhook succeeds.
+	wh.deliver(sub, event)
+	return nil
+}
+
+func (wh *WebHooks) deliver(sub *fftypes.Subscription, event *fftypes.EventDelivery) {
+	var reply *fftypes.MessageInput
+	err := wh.retry.Do(wh.ctx, fmt.Sprintf("webhook %s:%s event %s", sub.Namespace, sub.Name, event.ID), func(attempt int) (bool, error) {
+		var retryable bool
+		var err error
+		reply, retryable, err = wh.attemptRequest(sub, event)
+		return retryable && attempt < wh.maxAttempts, err
+	})
+	var rejected bool
+	var info string
+	if err != nil {
+		if wh.ctx.Err() != nil {
+			// We are closing - the event will be redelivered when we restart
+			log.L(wh.ctx).Debugf("Webhook delivery of event %s abandoned: %s", event.ID, err)
+			return
+		}
+		// The event is rejected, so the dispatcher backs off and redelivers it (and the events after it)
+		log.L(wh.ctx).Errorf("Webhook delivery of event %s to subscription %s:%s failed, and will be redelivered: %s", event.ID, sub.Namespace, sub.Name, err)
+		rejected = true
+		info = err.Error()
+	}
+	err = wh.callbacks.DeliveryResponse(wh.connID, fftypes.EventDeliveryResponse{
+		ID:           event.ID,
+		Rejected:     rejected,
+		Info:         info,
+		Subscription: event.Subscription,
+		Reply:        reply,
+	})
+	if err != nil {
+		log.L(wh.ctx).Warnf("Webhook delivery of event %s could not be acknowledged: %s", event.ID, err)
+	}
+}
+
+// attemptRequest makes a single request to the webhook. Client errors from the webhook (other than a timeout
+// or rate limiting) are not retryable, and neither is an invalid TLS configuration.
+func (wh *WebHooks) attemptRequest(sub *fftypes.Subscription, event *fftypes.EventDelivery) (reply *fftypes.MessageInput, retryable bool, err error) {
+	options := sub.Options.Webhook
+	client, err := wh.getClient(options.TLS)
+	if err != nil {
+		return nil, false, err
+	}
+	method := options.Method
+	if method == "" {
+		method = http.MethodPost
+	}
+	res, err := client.R().
+		SetContext(wh.ctx).
+		SetHeaders(options.Headers).
+		SetBody(event).
+		Execute(method, options.URL)
+	if err != nil {
+		return nil, true, i18n.WrapError(wh.ctx, err, i18n.MsgWebhookRequestFailed, options.URL)
+	}
+	if !res.IsSuccess() {
+		status := res.StatusCode()
+		retryable = status >= 500 || status == http.StatusRequestTimeout || status == http.StatusTooManyRequests
+		return nil, retryable, i18n.NewError(wh.ctx, i18n.MsgWebhookFailedStatus, options.URL, status)
+	}
+
+	// In reply mode, the response body is the data of a reply message
+	body := bytes.TrimSpace(res.Body())
+	if !options.Reply || len(body) == 0 {
+		return nil, false, nil
+	}
+	value := fftypes.Byteable(body)
+	if !json.Valid(body) {
+		// Non-JSON responses are sent as a JSON string
+		value, _ = json.Marshal(string(body))
+	}
+	return &fftypes.MessageInput{
+		Message: fftypes.Message{
+			Header: fftypes.MessageHeader{
+				Tag: options.ReplyTag,
+			},
+		},
+		InputData: fftypes.InputData{
+			{Value: value},
+		},
+	}, false, nil
+}
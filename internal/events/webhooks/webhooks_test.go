@@ -0,0 +1,475 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhooks
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hyperledger-labs/firefly/internal/config"
+	"github.com/hyperledger-labs/firefly/mocks/eventsmocks"
+	"github.com/hyperledger-labs/firefly/pkg/events"
+	"github.com/hyperledger-labs/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestWebHooks(t *testing.T) (wh *WebHooks, cbs *eventsmocks.Callbacks, cancel func()) {
+	config.Reset()
+
+	cbs = &eventsmocks.Callbacks{}
+	cbs.On("RegisterConnection", mock.Anything, mock.Anything).Return(nil).Run(func(args mock.Arguments) {
+		// Matches all subscriptions for the transport
+		assert.True(t, args[1].(events.SubscriptionMatcher)(fftypes.SubscriptionRef{}))
+	})
+	wh = &WebHooks{}
+	ctx, cancelCtx := context.WithCancel(context.Background())
+	svrPrefix := config.NewPluginConfig("ut.webhooks")
+	wh.InitPrefix(svrPrefix)
+	svrPrefix.Set(RetryInitDelay, "1ms")
+	svrPrefix.Set(RetryMaxDelay, "1ms")
+	err := wh.Init(ctx, svrPrefix, cbs)
+	assert.NoError(t, err)
+	assert.Equal(t, "webhooks", wh.Name())
+	assert.NotNil(t, wh.Capabilities())
+	return wh, cbs, cancelCtx
+}
+
+func newTestSub(url string) *fftypes.Subscription {
+	return &fftypes.Subscription{
+		SubscriptionRef: fftypes.SubscriptionRef{
+			ID:        fftypes.NewUUID(),
+			Namespace: "ns1",
+			Name:      "sub1",
+		},
+		Transport: "webhooks",
+		Options: fftypes.SubscriptionOptions{
+			Webhook: &fftypes.WebhookSubOptions{
+				URL: url,
+			},
+		},
+	}
+}
+
+func newTestEvent(sub *fftypes.Subscription) *fftypes.EventDelivery {
+	return &fftypes.EventDelivery{
+		Event: fftypes.Event{
+			ID:        fftypes.NewUUID(),
+			Type:      fftypes.EventTypeMessageConfirmed,
+			Namespace: "ns1",
+			Reference: fftypes.NewUUID(),
+		},
+		Subscription: sub.SubscriptionRef,
+	}
+}
+
+func waitForResponse(cbs *eventsmocks.Callbacks, result error) chan fftypes.EventDeliveryResponse {
+	responses := make(chan fftypes.EventDeliveryResponse, 1)
+	cbs.On("DeliveryResponse", mock.Anything, mock.Anything).Return(result).Run(func(args mock.Arguments) {
+		responses <- args[1].(fftypes.EventDeliveryResponse)
+	})
+	return responses
+}
+
+func TestValidateOptions(t *testing.T) {
+	wh, _, cancel := newTestWebHooks(t)
+	defer cancel()
+
+	err := wh.ValidateOptions(&fftypes.SubscriptionOptions{})
+	assert.Regexp(t, "FF10254", err)
+
+	err = wh.ValidateOptions(&newTestSub("ftp://example.com").Options)
+	assert.Regexp(t, "FF10255", err)
+
+	err = wh.ValidateOptions(&newTestSub("!::bad").Options)
+	assert.Regexp(t, "FF10255", err)
+
+	err = wh.ValidateOptions(&newTestSub("https://example.com/hook").Options)
+	assert.NoError(t, err)
+}
+
+func newTestTLSDir(t *testing.T, wh *WebHooks) func() {
+	dir, err := ioutil.TempDir("", "webhooks")
+	assert.NoError(t, err)
+	wh.tlsDir = dir
+	return func() {
+		os.RemoveAll(dir)
+	}
+}
+
+func TestValidateOptionsBadTLS(t *testing.T) {
+	wh, _, cancel := newTestWebHooks(t)
+	defer cancel()
+	done := newTestTLSDir(t, wh)
+	defer done()
+
+	sub := newTestSub("https://example.com/hook")
+	sub.Options.Webhook.TLS = &fftypes.WebhookTLSOptions{CAFile: "does-not-exist"}
+	err := wh.ValidateOptions(&sub.Options)
+	assert.Regexp(t, "FF10256", err)
+
+	err = ioutil.WriteFile(filepath.Join(wh.tlsDir, "ca.pem"), []byte("not a cert"), 0600)
+	assert.NoError(t, err)
+	sub.Options.Webhook.TLS = &fftypes.WebhookTLSOptions{CAFile: "ca.pem"}
+	err = wh.ValidateOptions(&sub.Options)
+	assert.Regexp(t, "FF10256", err)
+
+	sub.Options.Webhook.TLS = &fftypes.WebhookTLSOptions{CertFile: "does-not-exist", KeyFile: "does-not-exist"}
+	err = wh.ValidateOptions(&sub.Options)
+	assert.Regexp(t, "FF10256", err)
+}
+
+func TestValidateOptionsTLSOutsideDirectory(t *testing.T) {
+	wh, _, cancel := newTestWebHooks(t)
+	defer cancel()
+	done := newTestTLSDir(t, wh)
+	defer done()
+
+	for _, tlsOptions := range []*fftypes.WebhookTLSOptions{
+		{CAFile: "/etc/passwd"},
+		{CAFile: "../ca.pem"},
+		{CAFile: "certs/../../ca.pem"},
+		{CertFile: "/etc/cert.pem", KeyFile: "key.pem"},
+		{CertFile: "cert.pem", KeyFile: "../key.pem"},
+	} {
+		sub := newTestSub("https://example.com/hook")
+		sub.Options.Webhook.TLS = tlsOptions
+		err := wh.ValidateOptions(&sub.Options)
+		assert.Regexp(t, "FF10256", err)
+	}
+}
+
+func TestValidateOptionsTLSNoDirectory(t *testing.T) {
+	wh, _, cancel := newTestWebHooks(t)
+	defer cancel()
+
+	sub := newTestSub("https://example.com/hook")
+	sub.Options.Webhook.TLS = &fftypes.WebhookTLSOptions{CAFile: "ca.pem"}
+	err := wh.ValidateOptions(&sub.Options)
+	assert.Regexp(t, "FF10296", err)
+
+	sub.Options.Webhook.TLS = &fftypes.WebhookTLSOptions{InsecureSkipVerify: true}
+	err = wh.ValidateOptions(&sub.Options)
+	assert.NoError(t, err)
+}
+
+func TestDeliveryRequestNoWebhookOptions(t *testing.T) {
+	wh, _, cancel := newTestWebHooks(t)
+	defer cancel()
+
+	err := wh.DeliveryRequest(wh.connID, &fftypes.Subscription{}, &fftypes.EventDelivery{})
+	assert.Regexp(t, "FF10254", err)
+}
+
+func TestDeliveryRequestOk(t *testing.T) {
+	wh, cbs, cancel := newTestWebHooks(t)
+	defer cancel()
+
+	var received fftypes.EventDelivery
+	svr := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, http.MethodPut, req.Method)
+		assert.Equal(t, "value1", req.Header.Get("X-Header1"))
+		err := json.NewDecoder(req.Body).Decode(&received)
+		assert.NoError(t, err)
+		res.WriteHeader(http.StatusNoContent)
+	}))
+	defer svr.Close()
+
+	responses := waitForResponse(cbs, nil)
+	sub := newTestSub(svr.URL)
+	sub.Options.Webhook.Method = http.MethodPut
+	sub.Options.Webhook.Headers = map[string]string{"X-Header1": "value1"}
+	event := newTestEvent(sub)
+	err := wh.DeliveryRequest(wh.connID, sub, event)
+	assert.NoError(t, err)
+
+	response := <-responses
+	assert.Equal(t, *event.ID, *response.ID)
+	assert.False(t, response.Rejected)
+	assert.Nil(t, response.Reply)
+	assert.Equal(t, *event.Reference, *received.Reference)
+}
+
+func TestDeliveryRequestRetry(t *testing.T) {
+	wh, cbs, cancel := newTestWebHooks(t)
+	defer cancel()
+
+	calls := 0
+	svr := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		calls++
+		if calls == 1 {
+			res.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		res.WriteHeader(http.StatusOK)
+	}))
+	defer svr.Close()
+
+	responses := waitForResponse(cbs, fmt.Errorf("pop"))
+	sub := newTestSub(svr.URL)
+	err := wh.DeliveryRequest(wh.connID, sub, newTestEvent(sub))
+	assert.NoError(t, err)
+
+	<-responses
+	assert.Equal(t, 2, calls)
+}
+
+func TestDeliveryRequestClientErrorNotRetried(t *testing.T) {
+	wh, cbs, cancel := newTestWebHooks(t)
+	defer cancel()
+
+	calls := 0
+	svr := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		calls++
+		res.WriteHeader(http.StatusBadRequest)
+		res.Write([]byte(`{"reply": "ignored"}`))
+	}))
+	defer svr.Close()
+
+	responses := waitForResponse(cbs, nil)
+	sub := newTestSub(svr.URL)
+	sub.Options.Webhook.Reply = true
+	event := newTestEvent(sub)
+	err := wh.DeliveryRequest(wh.connID, sub, event)
+	assert.NoError(t, err)
+
+	// The event is rejected without a reply, so the subscription does not move past it
+	response := <-responses
+	assert.Equal(t, *event.ID, *response.ID)
+	assert.True(t, response.Rejected)
+	assert.Regexp(t, "FF10", response.Info)
+	assert.Nil(t, response.Reply)
+	assert.Equal(t, 1, calls)
+}
+
+func TestDeliveryRequestRetriesExhausted(t *testing.T) {
+	wh, cbs, cancel := newTestWebHooks(t)
+	defer cancel()
+	wh.maxAttempts = 3
+
+	calls := 0
+	svr := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		calls++
+		res.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer svr.Close()
+
+	responses := waitForResponse(cbs, nil)
+	sub := newTestSub(svr.URL)
+	err := wh.DeliveryRequest(wh.connID, sub, newTestEvent(sub))
+	assert.NoError(t, err)
+
+	response := <-responses
+	assert.True(t, response.Rejected)
+	assert.Equal(t, 3, calls)
+}
+
+func TestDeliveryRequestInOrder(t *testing.T) {
+	wh, cbs, cancel := newTestWebHooks(t)
+	defer cancel()
+
+	var received []int64
+	svr := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		var event fftypes.EventDelivery
+		err := json.NewDecoder(req.Body).Decode(&event)
+		assert.NoError(t, err)
+		received = append(received, event.Sequence)
+		res.WriteHeader(http.StatusOK)
+	}))
+	defer svr.Close()
+
+	responses := waitForResponse(cbs, nil)
+	sub := newTestSub(svr.URL)
+	for i := int64(1); i <= 3; i++ {
+		event := newTestEvent(sub)
+		event.Sequence = i
+		err := wh.DeliveryRequest(wh.connID, sub, event)
+		assert.NoError(t, err)
+
+		// Each event is acknowledged before the next is delivered
+		response := <-responses
+		assert.Equal(t, *event.ID, *response.ID)
+	}
+	assert.Equal(t, []int64{1, 2, 3}, received)
+}
+
+func TestDeliveryRequestReplyJSON(t *testing.T) {
+	wh, cbs, cancel := newTestWebHooks(t)
+	defer cancel()
+
+	svr := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.Header().Set("Content-Type", "application/json")
+		res.WriteHeader(http.StatusOK)
+		res.Write([]byte(`{"answer": 42}`))
+	}))
+	defer svr.Close()
+
+	responses := waitForResponse(cbs, nil)
+	sub := newTestSub(svr.URL)
+	sub.Options.Webhook.Reply = true
+	sub.Options.Webhook.ReplyTag = "myreply"
+	err := wh.DeliveryRequest(wh.connID, sub, newTestEvent(sub))
+	assert.NoError(t, err)
+
+	response := <-responses
+	assert.Equal(t, "myreply", response.Reply.Header.Tag)
+	assert.Equal(t, `{"answer": 42}`, string(response.Reply.InputData[0].Value))
+}
+
+func TestDeliveryRequestReplyText(t *testing.T) {
+	wh, cbs, cancel := newTestWebHooks(t)
+	defer cancel()
+
+	svr := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.WriteHeader(http.StatusOK)
+		res.Write([]byte("some text"))
+	}))
+	defer svr.Close()
+
+	responses := waitForResponse(cbs, nil)
+	sub := newTestSub(svr.URL)
+	sub.Options.Webhook.Reply = true
+	err := wh.DeliveryRequest(wh.connID, sub, newTestEvent(sub))
+	assert.NoError(t, err)
+
+	response := <-responses
+	assert.Equal(t, `"some text"`, string(response.Reply.InputData[0].Value))
+}
+
+func TestDeliveryRequestTLS(t *testing.T) {
+	wh, cbs, cancel := newTestWebHooks(t)
+	defer cancel()
+	done := newTestTLSDir(t, wh)
+	defer done()
+
+	svr := httptest.NewTLSServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.WriteHeader(http.StatusOK)
+	}))
+	defer svr.Close()
+
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: svr.Certificate().Raw})
+	err := ioutil.WriteFile(filepath.Join(wh.tlsDir, "ca.pem"), caPEM, 0600)
+	assert.NoError(t, err)
+
+	responses := waitForResponse(cbs, nil)
+	sub := newTestSub(svr.URL)
+	sub.Options.Webhook.TLS = &fftypes.WebhookTLSOptions{CAFile: "ca.pem"}
+	assert.NoError(t, wh.ValidateOptions(&sub.Options))
+	event := newTestEvent(sub)
+	err = wh.DeliveryRequest(wh.connID, sub, event)
+	assert.NoError(t, err)
+
+	response := <-responses
+	assert.Equal(t, *event.ID, *response.ID)
+
+	// Check the client is cached for the TLS options, and shared with other subscriptions using them
+	client, err := wh.getClient(&fftypes.WebhookTLSOptions{CAFile: "ca.pem"})
+	assert.NoError(t, err)
+	assert.Len(t, wh.clients, 1)
+	otherSub := newTestSub(svr.URL)
+	otherSub.Options.Webhook.TLS = &fftypes.WebhookTLSOptions{CAFile: "ca.pem"}
+	otherClient, err := wh.getClient(otherSub.Options.Webhook.TLS)
+	assert.NoError(t, err)
+	assert.Same(t, client, otherClient)
+}
+
+func TestAttemptRequestBadTLS(t *testing.T) {
+	wh, _, cancel := newTestWebHooks(t)
+	defer cancel()
+
+	sub := newTestSub("https://localhost:12345")
+	sub.Options.Webhook.TLS = &fftypes.WebhookTLSOptions{CAFile: "ca.pem"}
+	_, retryable, err := wh.attemptRequest(sub, newTestEvent(sub))
+	assert.Regexp(t, "FF10296", err)
+	assert.False(t, retryable)
+}
+
+func TestAttemptRequestFail(t *testing.T) {
+	wh, _, cancel := newTestWebHooks(t)
+	defer cancel()
+
+	svr := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {}))
+	svr.Close()
+
+	sub := newTestSub(svr.URL)
+	_, retryable, err := wh.attemptRequest(sub, newTestEvent(sub))
+	assert.Regexp(t, "FF10257", err)
+	assert.True(t, retryable)
+}
+
+func TestDeliverClosing(t *testing.T) {
+	wh, cbs, cancel := newTestWebHooks(t)
+
+	svr := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		res.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer svr.Close()
+
+	cancel()
+	sub := newTestSub(svr.URL)
+	wh.deliver(sub, newTestEvent(sub))
+	cbs.AssertNotCalled(t, "DeliveryResponse", mock.Anything, mock.Anything)
+}
+
+func TestBuildTLSConfigClientCert(t *testing.T) {
+	wh, _, cancel := newTestWebHooks(t)
+	defer cancel()
+	done := newTestTLSDir(t, wh)
+	defer done()
+
+	privatekey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(privatekey)})
+	err := ioutil.WriteFile(filepath.Join(wh.tlsDir, "key.pem"), keyPEM, 0600)
+	assert.NoError(t, err)
+	serialNumber, _ := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	x509Template := &x509.Certificate{
+		SerialNumber: serialNumber,
+		Subject: pkix.Name{
+			Organization: []string{"Unit Tests"},
+		},
+		NotBefore: time.Now(),
+		NotAfter:  time.Now().Add(100 * time.Second),
+	}
+	derBytes, err := x509.CreateCertificate(rand.Reader, x509Template, x509Template, &privatekey.PublicKey, privatekey)
+	assert.NoError(t, err)
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: derBytes})
+	err = ioutil.WriteFile(filepath.Join(wh.tlsDir, "cert.pem"), certPEM, 0600)
+	assert.NoError(t, err)
+
+	tlsConfig, err := wh.buildTLSConfig(&fftypes.WebhookTLSOptions{
+		CertFile:           "cert.pem",
+		KeyFile:            "key.pem",
+		InsecureSkipVerify: true,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, tlsConfig.Certificates, 1)
+	assert.True(t, tlsConfig.InsecureSkipVerify)
+}
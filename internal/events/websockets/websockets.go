@@ -62,7 +62,11 @@ func (ws *WebSockets) Capabilities() *events.Capabilities {
 	return ws.capabilities
 }
 
-func (ws *WebSockets) DeliveryRequest(connID string, event *fftypes.EventDelivery) error {
+func (ws *WebSockets) ValidateOptions(options *fftypes.SubscriptionOptions) error {
+	return nil
+}
+
+func (ws *WebSockets) DeliveryRequest(connID string, sub *fftypes.Subscription, event *fftypes.EventDelivery) error {
 	ws.connMux.Lock()
 	conn, ok := ws.connections[connID]
 	ws.connMux.Unlock()
@@ -151,4 +151,71 @@ var (
 	MsgInvalidHex                  = ffm("FF10231", "Invalid hex supplied", 400)
 	MsgInvalidWrongLenB32          = ffm("FF10232", "Byte length must be 32 (64 hex characters)", 400)
 	MsgNodeNotFoundInOrg           = ffm("FF10233", "Unable to find any nodes owned by org '%s', or parent orgs", 400)
+	MsgDataNotBlob                 = ffm("FF10234", "Data '%s' does not have a blob attached", 404)
+	MsgInvalidRange                = ffm("FF10235", "Requested range not satisfiable", 416)
+	MsgUnknownAuthPlugin           = ffm("FF10236", "Unknown auth plugin '%s'")
+	MsgUnauthorized                = ffm("FF10237", "Unauthorized", 401)
+	MsgForbidden                   = ffm("FF10238", "Forbidden", 403)
+	MsgAuthInvalidCredentials      = ffm("FF10239", "Invalid credentials", 401)
+	MsgAuthFileLoadFailed          = ffm("FF10240", "Failed to load auth file '%s'")
+	MsgAuthFileInvalidEntry        = ffm("FF10241", "Invalid entry on line %d of auth file '%s'")
+	MsgAuthUnsupportedHash         = ffm("FF10242", "Unsupported password hash for user '%s' - bcrypt and SHA1 are supported")
+	MsgJWTInvalid                  = ffm("FF10243", "Invalid token", 401)
+	MsgJWKSInvalidKey              = ffm("FF10244", "Invalid or unsupported key '%s' in JWKS file")
+	MsgFilterCountDesc             = ffm("FF10245", "Return a total count as well as items (adds extra database processing)")
+	MsgFilterAfterDesc             = ffm("FF10246", "Cursor for sequenced collections. Returns the items after the supplied sequence, in the sort direction. The cursor for the next page is returned in 'next'")
+	MsgFilterAfterNotSupported     = ffm("FF10247", "The 'after' cursor is only supported on sequenced collections, sorted by sequence", 400)
+	MsgFilterAfterInvalid          = ffm("FF10248", "Invalid 'after' cursor '%s'", 400)
+	MsgConfirmMessageInvalid       = ffm("FF10249", "Message '%s' was confirmed as invalid", 409)
+	MsgConfirmTimeout              = ffm("FF10250", "Timed out waiting for message '%s' to be confirmed", 408)
+	MsgConfirmQueryParam           = ffm("FF10251", "When true the HTTP request blocks until the message is confirmed")
+	MsgRequestReplyTimeout         = ffm("FF10252", "Timed out waiting for reply to request '%s'", 408)
+	MsgReplyRequiresGroup          = ffm("FF10253", "Message '%s' is not a private message, so cannot be replied to", 400)
+	MsgWebhookURLEmpty             = ffm("FF10254", "Webhook subscriptions require a 'url' in the 'webhook' options", 400)
+	MsgWebhookInvalidURL           = ffm("FF10255", "Invalid webhook URL '%s'", 400)
+	MsgWebhookTLSInvalid           = ffm("FF10256", "Invalid webhook TLS configuration '%s'", 400)
+	MsgWebhookRequestFailed        = ffm("FF10257", "Webhook request to '%s' failed")
+	MsgWebhookFailedStatus         = ffm("FF10258", "Webhook request to '%s' failed with status %d")
+	MsgFabconnectRESTErr           = ffm("FF10259", "Error from fabconnect: %s")
+	MsgInvalidFabricIdentity       = ffm("FF10260", "Supplied Fabric identity '%s' is invalid - must be in the format '<mspid>::<name>'", 400)
+	MsgUnknownLedger               = ffm("FF10261", "Unknown ledger '%s'", 400)
+	MsgDuplicateLedger             = ffm("FF10262", "Duplicate ledger '%s' in configuration")
+	MsgEthRPCRESTErr               = ffm("FF10263", "Error from Ethereum JSON-RPC endpoint: %s")
+	MsgEthRPCError                 = ffm("FF10264", "Ethereum JSON-RPC '%s' failed with code %s: %s")
+	MsgEthRPCInvalidResult         = ffm("FF10265", "Invalid result from Ethereum JSON-RPC '%s': %s")
+	MsgEthKeystoreReadFailed       = ffm("FF10266", "Failed to read keystore '%s'")
+	MsgEthKeystoreInvalid          = ffm("FF10267", "Invalid keystore file '%s': %s")
+	MsgEthKeyNotFound              = ffm("FF10268", "No key found in the keystore for address '%s'", 400)
+	MsgEthKeyDecryptFailed         = ffm("FF10269", "Failed to decrypt the key for address '%s'")
+	MsgEthCheckpointInvalid        = ffm("FF10270", "Invalid block checkpoint '%s'")
+	MsgEthCheckpointWriteFailed    = ffm("FF10271", "Failed to write block checkpoint file '%s'")
+	MsgEthTxReverted               = ffm("FF10272", "Transaction reverted")
+	MsgInvalidResubmitErrorRegex   = ffm("FF10273", "Invalid regular expression '%s' in retryable errors for batch pin resubmission: %s")
+	MsgOperationNotFailed          = ffm("FF10274", "Operation '%s' has status '%s' - only failed operations can be retried", 409)
+	MsgOperationRetryUnsupported   = ffm("FF10275", "Retry is not supported for operations of type '%s'", 400)
+	MsgOperationRetryNoInput       = ffm("FF10276", "Operation '%s' does not have the input required to retry it", 400)
+	MsgLeaderRenewIntervalTooLong  = ffm("FF10277", "Leader election renew interval %s must be shorter than the lease duration %s")
+	MsgInvalidSignature            = ffm("FF10278", "Signature is not valid for any of the public keys of the author")
+	MsgMissingSignature            = ffm("FF10279", "Message from author '%s' must be signed with one of its published public keys")
+	MsgUnverifiableSignature       = ffm("FF10280", "Message from author '%s' is signed, but the author has not published any public keys")
+	MsgKeystoreKeyInvalid          = ffm("FF10282", "Invalid key file '%s': %s")
+	MsgKeystoreReadFailed          = ffm("FF10283", "Failed to read identity keystore '%s'")
+	MsgX509RootCAsInvalid          = ffm("FF10284", "Failed to load root CA certificates from '%s'")
+	MsgX509NoCertificate           = ffm("FF10285", "No certificate found for identity '%s'", 400)
+	MsgX509InvalidCertificate      = ffm("FF10286", "Invalid certificate chain for organization '%s': %s", 400)
+	MsgX509NotIssuedByParent       = ffm("FF10287", "Certificate of organization '%s' is not issued by the certificate of its parent '%s'", 400)
+	MsgX509InvalidClaim            = ffm("FF10288", "Claim binding the certificate of organization '%s' to its identity is not valid", 400)
+	MsgX509PublicKeyMismatch       = ffm("FF10289", "Public keys of organization '%s' do not match its certificate", 400)
+	MsgX509UnsupportedKey          = ffm("FF10290", "Unsupported key type %T in certificate")
+	MsgOrgRevoked                  = ffm("FF10291", "Organization '%s' has been revoked", 400)
+	MsgNodeRevoked                 = ffm("FF10292", "Node '%s' has been revoked", 400)
+	MsgGroupRetired                = ffm("FF10293", "Group '%s' has been retired, and replaced by successor group '%s'", 400)
+	MsgNotGroupMember              = ffm("FF10294", "Identity '%s' is not a member of group '%s'", 400)
+	MsgTxTypeNotSupported          = ffm("FF10295", "Transaction type '%s' is not supported for %s messages", 400)
+	MsgWebhookTLSDirNotSet         = ffm("FF10296", "Webhook TLS files cannot be used, as no TLS directory is configured for the webhooks plugin", 400)
+	MsgOperationNotCancellable     = ffm("FF10297", "Operation '%s' has status '%s' - only pending or failed operations can be cancelled", 409)
+	MsgOperationStatusChanged      = ffm("FF10298", "Operation '%s' changed status concurrently - it may have been retried or cancelled by another request", 409)
+	MsgLeaseLost                   = ffm("FF10299", "Lease '%s' is no longer held at epoch %d - another instance has taken over", 409)
+	MsgIdentityProofUnavailable    = ffm("FF10300", "No key is held to prove control of identity '%s' - an organization can only move to an identity with published keys", 400)
+	MsgFilterResultsEnvelopeDesc   = ffm("FF10301", "Returned instead of the array of items when count=true or a cursor is supplied")
 )
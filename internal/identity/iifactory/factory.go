@@ -21,12 +21,16 @@ import (
 
 	"github.com/hyperledger-labs/firefly/internal/config"
 	"github.com/hyperledger-labs/firefly/internal/i18n"
+	"github.com/hyperledger-labs/firefly/internal/identity/keystore"
 	"github.com/hyperledger-labs/firefly/internal/identity/onchain"
+	"github.com/hyperledger-labs/firefly/internal/identity/x509id"
 	"github.com/hyperledger-labs/firefly/pkg/identity"
 )
 
 var plugins = []identity.Plugin{
 	&onchain.OnChain{},
+	&keystore.Keystore{},
+	&x509id.X509{},
 }
 
 var pluginsByName = make(map[string]identity.Plugin)
@@ -0,0 +1,201 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package keystore
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/pem"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/hyperledger-labs/firefly/internal/config"
+	"github.com/hyperledger-labs/firefly/internal/i18n"
+	"github.com/hyperledger-labs/firefly/internal/log"
+	"github.com/hyperledger-labs/firefly/pkg/fftypes"
+	"github.com/hyperledger-labs/firefly/pkg/identity"
+)
+
+// oidSecp256k1 is the named curve identifier written by "openssl ecparam -name secp256k1"
+var oidSecp256k1 = asn1.ObjectIdentifier{1, 3, 132, 0, 10}
+
+// ecPrivateKey is the SEC 1 (RFC 5915) structure of an "EC PRIVATE KEY" PEM block
+type ecPrivateKey struct {
+	Version       int
+	PrivateKey    []byte
+	NamedCurveOID asn1.ObjectIdentifier `asn1:"optional,explicit,tag:0"`
+	PublicKey     asn1.BitString        `asn1:"optional,explicit,tag:1"`
+}
+
+type signingKey interface {
+	sign(hash *fftypes.Bytes32) ([]byte, error)
+	publicKey() *fftypes.PublicKey
+}
+
+type ed25519Key ed25519.PrivateKey
+
+type secp256k1Key btcec.PrivateKey
+
+// Keystore signs messages with a private key held locally for each identity, and resolves identities the same
+// way as the onchain plugin
+type Keystore struct {
+	capabilities *identity.Capabilities
+	callbacks    identity.Callbacks
+	keys         map[string]signingKey
+}
+
+func (ks *Keystore) Name() string {
+	return "keystore"
+}
+
+func (ks *Keystore) Init(ctx context.Context, prefix config.Prefix, callbacks identity.Callbacks) (err error) {
+	ks.callbacks = callbacks
+	ks.capabilities = &identity.Capabilities{}
+	ks.keys = make(map[string]signingKey)
+
+	path := prefix.GetString(KeystoreConfigPath)
+	if path == "" {
+		return i18n.NewError(ctx, i18n.MsgMissingPluginConfig, KeystoreConfigPath, "identity.keystore")
+	}
+	entries, err := ioutil.ReadDir(path)
+	if err != nil {
+		return i18n.WrapError(ctx, err, i18n.MsgKeystoreReadFailed, path)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".pem") {
+			continue
+		}
+		filename := filepath.Join(path, entry.Name())
+		b, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return i18n.WrapError(ctx, err, i18n.MsgKeystoreReadFailed, filename)
+		}
+		key, err := parseKey(ctx, filename, b)
+		if err != nil {
+			return err
+		}
+		identifier := strings.TrimSuffix(entry.Name(), ".pem")
+		log.L(ctx).Debugf("Keystore %s key for '%s': %s", key.publicKey().Type, identifier, filename)
+		ks.keys[identifier] = key
+	}
+	return nil
+}
+
+func (ks *Keystore) Start() error {
+	return nil
+}
+
+func (ks *Keystore) Capabilities() *identity.Capabilities {
+	return ks.capabilities
+}
+
+func (ks *Keystore) Resolve(ctx context.Context, identifier string) (*fftypes.Identity, error) {
+	return &fftypes.Identity{
+		Identifier: identifier,
+		OnChain:    identifier,
+	}, nil
+}
+
+func (ks *Keystore) Sign(ctx context.Context, identifier string, hash *fftypes.Bytes32) (string, error) {
+	key, ok := ks.keys[identifier]
+	if !ok {
+		return "", nil
+	}
+	sig, err := key.sign(hash)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(sig), nil
+}
+
+func (ks *Keystore) PublicKeys(ctx context.Context, identifier string) (fftypes.PublicKeys, error) {
+	key, ok := ks.keys[identifier]
+	if !ok {
+		return nil, nil
+	}
+	return fftypes.PublicKeys{key.publicKey()}, nil
+}
+
+func (ks *Keystore) AttachCredentials(ctx context.Context, org *fftypes.Organization) error {
+	return nil
+}
+
+func (ks *Keystore) VerifyOrganization(ctx context.Context, org *fftypes.Organization, parent *fftypes.Organization, at *fftypes.FFTime) error {
+	return nil
+}
+
+func parseKey(ctx context.Context, filename string, b []byte) (signingKey, error) {
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, i18n.NewError(ctx, i18n.MsgKeystoreKeyInvalid, filename, "no PEM block")
+	}
+	switch block.Type {
+	case "PRIVATE KEY":
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, i18n.NewError(ctx, i18n.MsgKeystoreKeyInvalid, filename, err)
+		}
+		edKey, ok := key.(ed25519.PrivateKey)
+		if !ok {
+			return nil, i18n.NewError(ctx, i18n.MsgKeystoreKeyInvalid, filename, "PKCS8 key is not ed25519")
+		}
+		return ed25519Key(edKey), nil
+	case "EC PRIVATE KEY":
+		var ecKey ecPrivateKey
+		if _, err := asn1.Unmarshal(block.Bytes, &ecKey); err != nil {
+			return nil, i18n.NewError(ctx, i18n.MsgKeystoreKeyInvalid, filename, err)
+		}
+		if !ecKey.NamedCurveOID.Equal(oidSecp256k1) {
+			return nil, i18n.NewError(ctx, i18n.MsgKeystoreKeyInvalid, filename, "EC key is not secp256k1")
+		}
+		key, _ := btcec.PrivKeyFromBytes(btcec.S256(), ecKey.PrivateKey)
+		return (*secp256k1Key)(key), nil
+	default:
+		return nil, i18n.NewError(ctx, i18n.MsgKeystoreKeyInvalid, filename, block.Type)
+	}
+}
+
+func (k ed25519Key) sign(hash *fftypes.Bytes32) ([]byte, error) {
+	return ed25519.Sign(ed25519.PrivateKey(k), hash[:]), nil
+}
+
+func (k ed25519Key) publicKey() *fftypes.PublicKey {
+	return &fftypes.PublicKey{
+		Type: fftypes.KeyTypeEd25519,
+		Key:  base64.StdEncoding.EncodeToString(ed25519.PrivateKey(k).Public().(ed25519.PublicKey)),
+	}
+}
+
+func (k *secp256k1Key) sign(hash *fftypes.Bytes32) ([]byte, error) {
+	sig, err := (*btcec.PrivateKey)(k).Sign(hash[:])
+	if err != nil {
+		return nil, err
+	}
+	return sig.Serialize(), nil
+}
+
+func (k *secp256k1Key) publicKey() *fftypes.PublicKey {
+	return &fftypes.PublicKey{
+		Type: fftypes.KeyTypeSecp256k1,
+		Key:  base64.StdEncoding.EncodeToString((*btcec.PrivateKey)(k).PubKey().SerializeCompressed()),
+	}
+}
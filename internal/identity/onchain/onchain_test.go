@@ -22,6 +22,7 @@ import (
 
 	"github.com/hyperledger-labs/firefly/internal/config"
 	"github.com/hyperledger-labs/firefly/mocks/identitymocks"
+	"github.com/hyperledger-labs/firefly/pkg/fftypes"
 	"github.com/hyperledger-labs/firefly/pkg/identity"
 	"github.com/stretchr/testify/assert"
 )
@@ -50,3 +51,31 @@ func TestResolve(t *testing.T) {
 	assert.Equal(t, "0x12345", id.Identifier)
 	assert.Equal(t, "0x12345", id.OnChain)
 }
+
+func TestSignNoop(t *testing.T) {
+	var oc identity.Plugin = &OnChain{}
+	err := oc.Init(context.Background(), utConfPrefix, &identitymocks.Callbacks{})
+	assert.NoError(t, err)
+
+	sig, err := oc.Sign(context.Background(), "0x12345", fftypes.NewRandB32())
+	assert.NoError(t, err)
+	assert.Empty(t, sig)
+
+	keys, err := oc.PublicKeys(context.Background(), "0x12345")
+	assert.NoError(t, err)
+	assert.Empty(t, keys)
+}
+
+func TestCredentialsNoop(t *testing.T) {
+	var oc identity.Plugin = &OnChain{}
+	err := oc.Init(context.Background(), utConfPrefix, &identitymocks.Callbacks{})
+	assert.NoError(t, err)
+
+	org := &fftypes.Organization{Identity: "0x12345"}
+	err = oc.AttachCredentials(context.Background(), org)
+	assert.NoError(t, err)
+	assert.Nil(t, org.Profile)
+
+	err = oc.VerifyOrganization(context.Background(), org, nil, fftypes.Now())
+	assert.NoError(t, err)
+}
@@ -0,0 +1,34 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package x509id
+
+import (
+	"github.com/hyperledger-labs/firefly/internal/config"
+)
+
+const (
+	// X509ConfigRootCAs is a PEM file containing the root CA certificates of the consortium, that all organization certificates chain to
+	X509ConfigRootCAs = "rootCAs"
+	// X509ConfigPath is a directory containing a PEM file for each local identity, named <identity>.pem, that holds
+	// the private key followed by the certificate chain (leaf first) for that identity
+	X509ConfigPath = "path"
+)
+
+func (xi *X509) InitPrefix(prefix config.Prefix) {
+	prefix.AddKnownKey(X509ConfigRootCAs)
+	prefix.AddKnownKey(X509ConfigPath)
+}
@@ -0,0 +1,358 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package x509id
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hyperledger-labs/firefly/internal/config"
+	"github.com/hyperledger-labs/firefly/internal/i18n"
+	"github.com/hyperledger-labs/firefly/internal/log"
+	"github.com/hyperledger-labs/firefly/pkg/fftypes"
+	"github.com/hyperledger-labs/firefly/pkg/identity"
+)
+
+const (
+	// ProfileKey is the key in the profile of an organization, of the object holding its X.509 credentials
+	ProfileKey = "x509"
+	// ProfileCertificate is the PEM encoded certificate chain of the organization, leaf first
+	ProfileCertificate = "certificate"
+	// ProfileClaim is the base64 encoded signature, made with the key of the leaf certificate, over the SHA256 hash of
+	// the identity of the organization. It binds the certificate to the blockchain address the organization submits with
+	ProfileClaim = "claim"
+)
+
+type localIdentity struct {
+	key   crypto.Signer
+	chain []*x509.Certificate
+	pem   string
+}
+
+// X509 identifies organizations by certificates issued under the root CAs of the consortium.
+// The certificate of a child organization must be issued by the certificate of its parent, so the hierarchy of
+// organizations can be verified by certificate chain validation.
+type X509 struct {
+	capabilities *identity.Capabilities
+	callbacks    identity.Callbacks
+	roots        *x509.CertPool
+	identities   map[string]*localIdentity
+}
+
+func (xi *X509) Name() string {
+	return "x509"
+}
+
+func (xi *X509) Init(ctx context.Context, prefix config.Prefix, callbacks identity.Callbacks) (err error) {
+	xi.callbacks = callbacks
+	xi.capabilities = &identity.Capabilities{}
+	xi.identities = make(map[string]*localIdentity)
+
+	rootCAs := prefix.GetString(X509ConfigRootCAs)
+	if rootCAs == "" {
+		return i18n.NewError(ctx, i18n.MsgMissingPluginConfig, X509ConfigRootCAs, "identity.x509")
+	}
+	b, err := ioutil.ReadFile(rootCAs)
+	if err != nil {
+		return i18n.WrapError(ctx, err, i18n.MsgX509RootCAsInvalid, rootCAs)
+	}
+	xi.roots = x509.NewCertPool()
+	if !xi.roots.AppendCertsFromPEM(b) {
+		return i18n.NewError(ctx, i18n.MsgX509RootCAsInvalid, rootCAs)
+	}
+
+	path := prefix.GetString(X509ConfigPath)
+	if path == "" {
+		// We can still verify the organizations of others, without any identities of our own
+		return nil
+	}
+	entries, err := ioutil.ReadDir(path)
+	if err != nil {
+		return i18n.WrapError(ctx, err, i18n.MsgKeystoreReadFailed, path)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".pem") {
+			continue
+		}
+		filename := filepath.Join(path, entry.Name())
+		b, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return i18n.WrapError(ctx, err, i18n.MsgKeystoreReadFailed, filename)
+		}
+		id, err := parseIdentity(ctx, filename, b)
+		if err != nil {
+			return err
+		}
+		identifier := strings.TrimSuffix(entry.Name(), ".pem")
+		log.L(ctx).Debugf("X509 identity '%s': subject='%s' issuer='%s'", identifier, id.chain[0].Subject, id.chain[0].Issuer)
+		xi.identities[identifier] = id
+	}
+	return nil
+}
+
+func (xi *X509) Start() error {
+	return nil
+}
+
+func (xi *X509) Capabilities() *identity.Capabilities {
+	return xi.capabilities
+}
+
+func (xi *X509) Resolve(ctx context.Context, identifier string) (*fftypes.Identity, error) {
+	return &fftypes.Identity{
+		Identifier: identifier,
+		OnChain:    identifier,
+	}, nil
+}
+
+func (xi *X509) Sign(ctx context.Context, identifier string, hash *fftypes.Bytes32) (string, error) {
+	id, ok := xi.identities[identifier]
+	if !ok {
+		return "", nil
+	}
+	return id.sign(hash)
+}
+
+func (xi *X509) PublicKeys(ctx context.Context, identifier string) (fftypes.PublicKeys, error) {
+	id, ok := xi.identities[identifier]
+	if !ok {
+		return nil, nil
+	}
+	key, err := publicKey(ctx, id.chain[0])
+	if err != nil {
+		return nil, err
+	}
+	return fftypes.PublicKeys{key}, nil
+}
+
+func (xi *X509) AttachCredentials(ctx context.Context, org *fftypes.Organization) error {
+	if _, ok := org.Profile[ProfileKey]; ok {
+		// The credentials have been supplied with the organization
+		return nil
+	}
+	id, ok := xi.identities[org.Identity]
+	if !ok {
+		return i18n.NewError(ctx, i18n.MsgX509NoCertificate, org.Identity)
+	}
+	claim, err := id.sign(claimHash(org.Identity))
+	if err != nil {
+		return err
+	}
+	if org.Profile == nil {
+		org.Profile = fftypes.JSONObject{}
+	}
+	org.Profile[ProfileKey] = map[string]interface{}{
+		ProfileCertificate: id.pem,
+		ProfileClaim:       claim,
+	}
+	return nil
+}
+
+func (xi *X509) VerifyOrganization(ctx context.Context, org *fftypes.Organization, parent *fftypes.Organization, at *fftypes.FFTime) error {
+	chain, err := parseChain(ctx, org)
+	if err != nil {
+		return err
+	}
+	leaf := chain[0]
+	intermediates := x509.NewCertPool()
+	for _, cert := range chain[1:] {
+		intermediates.AddCert(cert)
+	}
+	// Every member must reach the same answer, whenever it processes the registration
+	var currentTime time.Time
+	if at != nil {
+		currentTime = time.Time(*at)
+	}
+	verified, err := leaf.Verify(x509.VerifyOptions{
+		Roots:         xi.roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+		CurrentTime:   currentTime,
+	})
+	if err != nil {
+		return i18n.NewError(ctx, i18n.MsgX509InvalidCertificate, org.Identity, err)
+	}
+
+	if parent != nil {
+		parentChain, err := parseChain(ctx, parent)
+		if err != nil {
+			return err
+		}
+		if !issuedBy(verified, parentChain[0]) {
+			return i18n.NewError(ctx, i18n.MsgX509NotIssuedByParent, org.Identity, parent.Identity)
+		}
+	}
+
+	key, err := publicKey(ctx, leaf)
+	if err != nil {
+		return err
+	}
+	claim, err := base64.StdEncoding.DecodeString(org.Profile.GetObject(ProfileKey).GetString(ProfileClaim))
+	if err != nil || !key.Verify(claimHash(org.Identity), claim) {
+		return i18n.NewError(ctx, i18n.MsgX509InvalidClaim, org.Identity)
+	}
+
+	// The keys the organization signs its messages with must be those of its certificate
+	if len(org.PublicKeys) != 1 || org.PublicKeys[0] == nil || *org.PublicKeys[0] != *key {
+		return i18n.NewError(ctx, i18n.MsgX509PublicKeyMismatch, org.Identity)
+	}
+	return nil
+}
+
+// issuedBy checks whether the certificate of the parent is one of the issuers in any of the verified chains of a child
+func issuedBy(verified [][]*x509.Certificate, parent *x509.Certificate) bool {
+	for _, chain := range verified {
+		for _, issuer := range chain[1:] {
+			if issuer.Equal(parent) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func claimHash(identity string) *fftypes.Bytes32 {
+	var hash fftypes.Bytes32 = sha256.Sum256([]byte(identity))
+	return &hash
+}
+
+func (id *localIdentity) sign(hash *fftypes.Bytes32) (string, error) {
+	var opts crypto.SignerOpts = crypto.SHA256
+	if _, ok := id.key.(ed25519.PrivateKey); ok {
+		opts = crypto.Hash(0)
+	}
+	sig, err := id.key.Sign(rand.Reader, hash[:], opts)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(sig), nil
+}
+
+func publicKey(ctx context.Context, cert *x509.Certificate) (*fftypes.PublicKey, error) {
+	switch key := cert.PublicKey.(type) {
+	case ed25519.PublicKey:
+		return &fftypes.PublicKey{
+			Type: fftypes.KeyTypeEd25519,
+			Key:  base64.StdEncoding.EncodeToString(key),
+		}, nil
+	case *ecdsa.PublicKey:
+		if key.Curve != elliptic.P256() {
+			return nil, i18n.NewError(ctx, i18n.MsgX509UnsupportedKey, key.Curve)
+		}
+		return &fftypes.PublicKey{
+			Type: fftypes.KeyTypeP256,
+			Key:  base64.StdEncoding.EncodeToString(elliptic.MarshalCompressed(key.Curve, key.X, key.Y)),
+		}, nil
+	default:
+		return nil, i18n.NewError(ctx, i18n.MsgX509UnsupportedKey, key)
+	}
+}
+
+func parseCertificates(b []byte) (chain []*x509.Certificate, err error) {
+	for {
+		var block *pem.Block
+		block, b = pem.Decode(b)
+		if block == nil {
+			return chain, nil
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		chain = append(chain, cert)
+	}
+}
+
+func parseChain(ctx context.Context, org *fftypes.Organization) ([]*x509.Certificate, error) {
+	chain, err := parseCertificates([]byte(org.Profile.GetObject(ProfileKey).GetString(ProfileCertificate)))
+	if err != nil {
+		return nil, i18n.NewError(ctx, i18n.MsgX509InvalidCertificate, org.Identity, err)
+	}
+	if len(chain) == 0 {
+		return nil, i18n.NewError(ctx, i18n.MsgX509NoCertificate, org.Identity)
+	}
+	return chain, nil
+}
+
+func parseIdentity(ctx context.Context, filename string, b []byte) (*localIdentity, error) {
+	id := &localIdentity{}
+	var certs bytes.Buffer
+	for rest := b; ; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		switch block.Type {
+		case "PRIVATE KEY":
+			key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+			if err != nil {
+				return nil, i18n.NewError(ctx, i18n.MsgKeystoreKeyInvalid, filename, err)
+			}
+			signer, ok := key.(crypto.Signer)
+			if !ok {
+				return nil, i18n.NewError(ctx, i18n.MsgKeystoreKeyInvalid, filename, "unsupported PKCS8 key")
+			}
+			id.key = signer
+		case "EC PRIVATE KEY":
+			key, err := x509.ParseECPrivateKey(block.Bytes)
+			if err != nil {
+				return nil, i18n.NewError(ctx, i18n.MsgKeystoreKeyInvalid, filename, err)
+			}
+			id.key = key
+		case "CERTIFICATE":
+			_ = pem.Encode(&certs, block)
+		}
+	}
+	chain, err := parseCertificates(certs.Bytes())
+	if err != nil {
+		return nil, i18n.NewError(ctx, i18n.MsgKeystoreKeyInvalid, filename, err)
+	}
+	if id.key == nil || len(chain) == 0 {
+		return nil, i18n.NewError(ctx, i18n.MsgKeystoreKeyInvalid, filename, "requires a private key and a certificate chain")
+	}
+	if _, err := publicKey(ctx, chain[0]); err != nil {
+		return nil, i18n.NewError(ctx, i18n.MsgKeystoreKeyInvalid, filename, err)
+	}
+	if !publicKeyEqual(id.key.Public(), chain[0].PublicKey) {
+		return nil, i18n.NewError(ctx, i18n.MsgKeystoreKeyInvalid, filename, "private key does not match the certificate")
+	}
+	id.chain = chain
+	id.pem = certs.String()
+	return id, nil
+}
+
+func publicKeyEqual(a, b crypto.PublicKey) bool {
+	ak, ok := a.(interface{ Equal(crypto.PublicKey) bool })
+	return ok && ak.Equal(b)
+}
@@ -0,0 +1,212 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package leader
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/hyperledger-labs/firefly/internal/config"
+	"github.com/hyperledger-labs/firefly/internal/i18n"
+	"github.com/hyperledger-labs/firefly/internal/log"
+	"github.com/hyperledger-labs/firefly/pkg/database"
+	"github.com/hyperledger-labs/firefly/pkg/fftypes"
+)
+
+const (
+	leaderLeaseName = "ff_leader"
+)
+
+// Elector decides which one of the instances sharing a database runs the singleton components.
+// Leadership is a lease in the database, that the leader must keep renewing. If the leader stops
+// renewing, another instance takes over once the lease expires.
+//
+// Each lease we are granted arms the fence passed in, so writes made under it by the singleton components
+// are rejected by the database if another instance takes over before we notice we have lost leadership.
+type Elector interface {
+	// Start campaigns for leadership in the background, calling onElected when this instance becomes leader.
+	// If leadership is subsequently lost, onLost is called and campaigning stops - so the caller must restart
+	// to campaign again. When leader election is disabled, onElected is called immediately.
+	Start(onElected func() error, onLost func()) error
+	// WaitStop waits for the campaign to stop, after the context is cancelled
+	WaitStop()
+	IsLeader() bool
+	Status() *fftypes.NodeStatusLeader
+}
+
+type elector struct {
+	ctx           context.Context
+	database      database.Plugin
+	enabled       bool
+	id            string
+	leaseDuration time.Duration
+	renewInterval time.Duration
+	mux           sync.Mutex
+	leader        bool
+	lease         *fftypes.Lease
+	fence         *database.LeaseFence
+	onElected     func() error
+	onLost        func()
+	started       bool
+	campaignDone  chan struct{}
+}
+
+func NewElector(ctx context.Context, di database.Plugin, fence *database.LeaseFence) (Elector, error) {
+	if di == nil || fence == nil {
+		return nil, i18n.NewError(ctx, i18n.MsgInitializationNilDepError)
+	}
+	e := &elector{
+		ctx:           log.WithLogField(ctx, "role", "leader-election"),
+		database:      di,
+		fence:         fence,
+		enabled:       config.GetBool(config.LeaderElectionEnabled),
+		id:            config.GetString(config.LeaderElectionID),
+		leaseDuration: config.GetDuration(config.LeaderElectionLeaseDuration),
+		renewInterval: config.GetDuration(config.LeaderElectionRenewInterval),
+		campaignDone:  make(chan struct{}),
+	}
+	if e.id == "" {
+		hostname, _ := os.Hostname()
+		e.id = fmt.Sprintf("%s/%d", hostname, os.Getpid())
+	}
+	if e.enabled && e.renewInterval >= e.leaseDuration {
+		return nil, i18n.NewError(ctx, i18n.MsgLeaderRenewIntervalTooLong, e.renewInterval, e.leaseDuration)
+	}
+	return e, nil
+}
+
+func (e *elector) Start(onElected func() error, onLost func()) error {
+	if !e.enabled {
+		e.mux.Lock()
+		e.leader = true
+		e.mux.Unlock()
+		return onElected()
+	}
+	e.onElected = onElected
+	e.onLost = onLost
+	e.started = true
+	go e.campaignLoop()
+	return nil
+}
+
+func (e *elector) WaitStop() {
+	if e.started {
+		<-e.campaignDone
+	}
+}
+
+func (e *elector) IsLeader() bool {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+	return e.leader
+}
+
+func (e *elector) Status() *fftypes.NodeStatusLeader {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+	status := &fftypes.NodeStatusLeader{
+		Enabled: e.enabled,
+		ID:      e.id,
+		Leader:  e.leader,
+	}
+	if e.lease != nil {
+		status.Holder = e.lease.Holder
+		status.Expires = e.lease.Expires
+		status.Epoch = e.lease.Epoch
+	}
+	return status
+}
+
+func (e *elector) campaignLoop() {
+	defer close(e.campaignDone)
+	l := log.L(e.ctx)
+	l.Infof("Campaigning for leadership as '%s'", e.id)
+	for {
+		if !e.campaign() {
+			return
+		}
+		select {
+		case <-e.ctx.Done():
+			l.Debugf("Leader election exiting (context cancelled)")
+			return
+		case <-time.After(e.renewInterval):
+		}
+	}
+}
+
+// campaign makes a single attempt to claim or renew the lease, and returns false if the campaign must stop
+func (e *elector) campaign() bool {
+	l := log.L(e.ctx)
+	now := time.Now()
+	expires := fftypes.FFTime(now.Add(e.leaseDuration))
+	lease := &fftypes.Lease{
+		Name:    leaderLeaseName,
+		Holder:  e.id,
+		Expires: &expires,
+	}
+	acquired, err := e.database.AcquireLease(e.ctx, lease)
+	if err == nil && !acquired {
+		lease, err = e.database.GetLease(e.ctx, leaderLeaseName)
+	}
+
+	e.mux.Lock()
+	wasLeader := e.leader
+	switch {
+	case err != nil:
+		l.Errorf("Leader election failed to check lease: %s", err)
+		// If we cannot renew before our lease runs out, another instance might take over - so we must step down first
+		if wasLeader && now.Add(e.renewInterval).After(time.Time(*e.lease.Expires)) {
+			e.leader = false
+		}
+	case acquired:
+		e.leader = true
+		e.lease = lease
+		e.fence.Arm(lease)
+	default:
+		e.leader = false
+		e.lease = lease
+	}
+	isLeader := e.leader
+	e.mux.Unlock()
+
+	switch {
+	case isLeader && !wasLeader:
+		l.Infof("Elected leader until %s", expires)
+		if err := e.onElected(); err != nil {
+			l.Errorf("Failed to start as leader: %s", err)
+			e.stepDown()
+			return false
+		}
+	case !isLeader && wasLeader:
+		l.Warnf("Lost leadership")
+		e.stepDown()
+		return false
+	case !isLeader && lease != nil:
+		l.Debugf("Following leader '%s' (lease expires %s)", lease.Holder, lease.Expires)
+	}
+	return true
+}
+
+func (e *elector) stepDown() {
+	e.mux.Lock()
+	e.leader = false
+	e.mux.Unlock()
+	e.onLost()
+}
@@ -0,0 +1,204 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package leader
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hyperledger-labs/firefly/internal/config"
+	"github.com/hyperledger-labs/firefly/mocks/databasemocks"
+	"github.com/hyperledger-labs/firefly/pkg/database"
+	"github.com/hyperledger-labs/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestElector(t *testing.T) (*elector, *databasemocks.Plugin, func()) {
+	config.Reset()
+	config.Set(config.LeaderElectionEnabled, true)
+	config.Set(config.LeaderElectionID, "instance1")
+	config.Set(config.LeaderElectionRenewInterval, "1ms")
+	mdi := &databasemocks.Plugin{}
+	ctx, cancel := context.WithCancel(context.Background())
+	e, err := NewElector(ctx, mdi, &database.LeaseFence{})
+	assert.NoError(t, err)
+	return e.(*elector), mdi, cancel
+}
+
+func TestNewElectorBadDeps(t *testing.T) {
+	_, err := NewElector(context.Background(), nil, nil)
+	assert.Regexp(t, "FF10128", err)
+}
+
+func TestNewElectorBadRenewInterval(t *testing.T) {
+	config.Reset()
+	config.Set(config.LeaderElectionEnabled, true)
+	config.Set(config.LeaderElectionRenewInterval, "1m")
+	config.Set(config.LeaderElectionLeaseDuration, "30s")
+	_, err := NewElector(context.Background(), &databasemocks.Plugin{}, &database.LeaseFence{})
+	assert.Regexp(t, "FF10277", err)
+}
+
+func TestNewElectorDefaultID(t *testing.T) {
+	config.Reset()
+	e, err := NewElector(context.Background(), &databasemocks.Plugin{}, &database.LeaseFence{})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, e.(*elector).id)
+}
+
+func TestElectorDisabled(t *testing.T) {
+	config.Reset()
+	e, err := NewElector(context.Background(), &databasemocks.Plugin{}, &database.LeaseFence{})
+	assert.NoError(t, err)
+	elected := false
+	err = e.Start(func() error {
+		elected = true
+		return nil
+	}, func() {})
+	assert.NoError(t, err)
+	assert.True(t, elected)
+	assert.True(t, e.IsLeader())
+	status := e.Status()
+	assert.False(t, status.Enabled)
+	assert.True(t, status.Leader)
+	e.WaitStop()
+}
+
+func TestElectorElectedThenCancelled(t *testing.T) {
+	e, mdi, cancel := newTestElector(t)
+	mdi.On("AcquireLease", mock.Anything, mock.MatchedBy(func(lease *fftypes.Lease) bool {
+		return lease.Name == leaderLeaseName && lease.Holder == "instance1"
+	})).Return(true, nil)
+
+	elected := make(chan struct{})
+	err := e.Start(func() error {
+		// The fence is armed before the leader components are started
+		assert.Equal(t, "instance1", e.fence.Lease().Holder)
+		close(elected)
+		return nil
+	}, func() {
+		assert.Fail(t, "should not lose leadership")
+	})
+	assert.NoError(t, err)
+	<-elected
+	assert.True(t, e.IsLeader())
+	status := e.Status()
+	assert.True(t, status.Enabled)
+	assert.Equal(t, "instance1", status.Holder)
+	assert.NotNil(t, status.Expires)
+
+	cancel()
+	e.WaitStop()
+}
+
+func TestElectorFollowThenElected(t *testing.T) {
+	e, mdi, cancel := newTestElector(t)
+	defer cancel()
+	expires := fftypes.Now()
+	mdi.On("AcquireLease", mock.Anything, mock.Anything).Return(false, nil).Once()
+	mdi.On("GetLease", mock.Anything, leaderLeaseName).Return(&fftypes.Lease{
+		Name:    leaderLeaseName,
+		Holder:  "instance2",
+		Expires: expires,
+	}, nil).Once()
+	mdi.On("AcquireLease", mock.Anything, mock.Anything).Return(true, nil)
+
+	assert.True(t, e.campaign())
+	assert.False(t, e.IsLeader())
+	assert.Equal(t, "instance2", e.Status().Holder)
+
+	elected := false
+	e.onElected = func() error {
+		elected = true
+		return nil
+	}
+	assert.True(t, e.campaign())
+	assert.True(t, elected)
+	assert.True(t, e.IsLeader())
+	assert.Equal(t, "instance1", e.Status().Holder)
+}
+
+func TestElectorLostLeadership(t *testing.T) {
+	e, mdi, cancel := newTestElector(t)
+	defer cancel()
+	mdi.On("AcquireLease", mock.Anything, mock.Anything).Return(true, nil).Once()
+	mdi.On("AcquireLease", mock.Anything, mock.Anything).Return(false, nil)
+	mdi.On("GetLease", mock.Anything, leaderLeaseName).Return(&fftypes.Lease{
+		Name:    leaderLeaseName,
+		Holder:  "instance2",
+		Expires: fftypes.Now(),
+	}, nil)
+
+	lost := make(chan struct{})
+	err := e.Start(func() error { return nil }, func() { close(lost) })
+	assert.NoError(t, err)
+	<-lost
+	e.WaitStop()
+	assert.False(t, e.IsLeader())
+}
+
+func TestElectorStartFailStepsDown(t *testing.T) {
+	e, mdi, cancel := newTestElector(t)
+	defer cancel()
+	mdi.On("AcquireLease", mock.Anything, mock.Anything).Return(true, nil)
+
+	lost := false
+	e.onElected = func() error { return fmt.Errorf("pop") }
+	e.onLost = func() { lost = true }
+	assert.False(t, e.campaign())
+	assert.True(t, lost)
+	assert.False(t, e.IsLeader())
+}
+
+func TestElectorErrorWhileLeader(t *testing.T) {
+	e, mdi, cancel := newTestElector(t)
+	defer cancel()
+	e.leaseDuration = 1 * time.Hour
+	mdi.On("AcquireLease", mock.Anything, mock.Anything).Return(true, nil).Once()
+	mdi.On("AcquireLease", mock.Anything, mock.Anything).Return(false, fmt.Errorf("pop"))
+
+	lost := false
+	e.onElected = func() error { return nil }
+	e.onLost = func() { lost = true }
+	assert.True(t, e.campaign())
+	assert.True(t, e.IsLeader())
+
+	// The lease has plenty of time left, so we stay leader
+	assert.True(t, e.campaign())
+	assert.True(t, e.IsLeader())
+
+	// The lease will expire before we can renew again, so we step down
+	expiring := fftypes.FFTime(time.Now())
+	e.lease.Expires = &expiring
+	assert.False(t, e.campaign())
+	assert.True(t, lost)
+	assert.False(t, e.IsLeader())
+}
+
+func TestElectorGetLeaseFailWhileFollower(t *testing.T) {
+	e, mdi, cancel := newTestElector(t)
+	defer cancel()
+	mdi.On("AcquireLease", mock.Anything, mock.Anything).Return(false, nil)
+	mdi.On("GetLease", mock.Anything, leaderLeaseName).Return(nil, fmt.Errorf("pop"))
+
+	assert.True(t, e.campaign())
+	assert.False(t, e.IsLeader())
+	assert.Empty(t, e.Status().Holder)
+}
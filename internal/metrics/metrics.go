@@ -0,0 +1,189 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/hyperledger-labs/firefly/pkg/fftypes"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "ff"
+
+var (
+	mux      sync.Mutex
+	registry *prometheus.Registry
+
+	apiRequestDuration    *prometheus.HistogramVec
+	batchesSealed         *prometheus.CounterVec
+	batchesDispatched     *prometheus.CounterVec
+	blockchainSubmissions *prometheus.CounterVec
+	blockchainReceipts    *prometheus.CounterVec
+	aggregatorPinBacklog  prometheus.Gauge
+	subscriptionLag       *prometheus.GaugeVec
+	subscriptionInflight  *prometheus.GaugeVec
+	dataExchangeTransfers *prometheus.CounterVec
+)
+
+func init() {
+	Clear()
+}
+
+// Clear resets all the collectors, on a new registry - for use in tests
+func Clear() {
+	mux.Lock()
+	defer mux.Unlock()
+
+	apiRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "api",
+		Name:      "request_duration_seconds",
+		Help:      "REST API request latency, by route name, method and status code",
+	}, []string{"route", "method", "status"})
+	batchesSealed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "batch",
+		Name:      "sealed_total",
+		Help:      "Number of batches sealed, by message type and namespace",
+	}, []string{"type", "namespace"})
+	batchesDispatched = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "batch",
+		Name:      "dispatched_total",
+		Help:      "Number of batches dispatched, by message type and namespace",
+	}, []string{"type", "namespace"})
+	blockchainSubmissions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "blockchain",
+		Name:      "submissions_total",
+		Help:      "Number of transactions submitted to the blockchain, by plugin and outcome of the submission",
+	}, []string{"plugin", "status"})
+	blockchainReceipts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "blockchain",
+		Name:      "receipts_total",
+		Help:      "Number of transaction receipts received from the blockchain, by plugin and status",
+	}, []string{"plugin", "status"})
+	aggregatorPinBacklog = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "aggregator",
+		Name:      "pin_backlog",
+		Help:      "Number of pins that have been received, but not yet processed by the aggregator",
+	})
+	subscriptionLag = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "subscription",
+		Name:      "delivery_lag",
+		Help:      "Number of events behind the latest event each subscription is, by namespace and subscription name",
+	}, []string{"namespace", "subscription"})
+	subscriptionInflight = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "subscription",
+		Name:      "inflight",
+		Help:      "Number of events delivered to each subscription, but not yet acknowledged",
+	}, []string{"namespace", "subscription"})
+	dataExchangeTransfers = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "dataexchange",
+		Name:      "transfers_total",
+		Help:      "Number of data exchange transfers completed, by plugin and status",
+	}, []string{"plugin", "status"})
+
+	registry = prometheus.NewRegistry()
+	registry.MustRegister(
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+		apiRequestDuration,
+		batchesSealed,
+		batchesDispatched,
+		blockchainSubmissions,
+		blockchainReceipts,
+		aggregatorPinBacklog,
+		subscriptionLag,
+		subscriptionInflight,
+		dataExchangeTransfers,
+	)
+}
+
+// Registry returns the registry all of the collectors are registered on
+func Registry() *prometheus.Registry {
+	mux.Lock()
+	defer mux.Unlock()
+	return registry
+}
+
+// Handler returns the HTTP handler that serves the metrics in the Prometheus exposition format
+func Handler() http.Handler {
+	r := Registry()
+	return promhttp.HandlerFor(r, promhttp.HandlerOpts{Registry: r})
+}
+
+// APIRequest records the latency and status of a REST API request
+func APIRequest(route, method string, status int, duration time.Duration) {
+	apiRequestDuration.WithLabelValues(route, method, strconv.Itoa(status)).Observe(duration.Seconds())
+}
+
+// BatchSealed records a batch being sealed by a batch processor
+func BatchSealed(batch *fftypes.Batch) {
+	batchesSealed.WithLabelValues(string(batch.Type), batch.Namespace).Inc()
+}
+
+// BatchDispatched records a sealed batch being successfully dispatched
+func BatchDispatched(batch *fftypes.Batch) {
+	batchesDispatched.WithLabelValues(string(batch.Type), batch.Namespace).Inc()
+}
+
+// BlockchainSubmission records the outcome of submitting a transaction to a blockchain plugin
+func BlockchainSubmission(plugin string, err error) {
+	status := fftypes.OpStatusPending
+	if err != nil {
+		status = fftypes.OpStatusFailed
+	}
+	blockchainSubmissions.WithLabelValues(plugin, string(status)).Inc()
+}
+
+// BlockchainReceipt records a transaction status update received from a blockchain plugin
+func BlockchainReceipt(plugin string, status fftypes.OpStatus) {
+	blockchainReceipts.WithLabelValues(plugin, string(status)).Inc()
+}
+
+// AggregatorPinBacklog records the number of pins waiting to be processed by the aggregator
+func AggregatorPinBacklog(backlog int64) {
+	aggregatorPinBacklog.Set(float64(backlog))
+}
+
+// SubscriptionDelivery records the delivery lag, and in-flight event count, of a subscription
+func SubscriptionDelivery(ns, name string, lag int64, inflight int) {
+	subscriptionLag.WithLabelValues(ns, name).Set(float64(lag))
+	subscriptionInflight.WithLabelValues(ns, name).Set(float64(inflight))
+}
+
+// SubscriptionClosed removes the metrics for a subscription that is no longer being dispatched
+func SubscriptionClosed(ns, name string) {
+	subscriptionLag.DeleteLabelValues(ns, name)
+	subscriptionInflight.DeleteLabelValues(ns, name)
+}
+
+// DataExchangeTransfer records the outcome of a data exchange transfer
+func DataExchangeTransfer(plugin string, status fftypes.OpStatus) {
+	dataExchangeTransfers.WithLabelValues(plugin, string(status)).Inc()
+}
@@ -0,0 +1,93 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metrics
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hyperledger-labs/firefly/pkg/fftypes"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIRequest(t *testing.T) {
+	Clear()
+	APIRequest("getMessages", http.MethodGet, 200, 10*time.Millisecond)
+	assert.Equal(t, 1, testutil.CollectAndCount(apiRequestDuration))
+}
+
+func TestBatches(t *testing.T) {
+	Clear()
+	batch := &fftypes.Batch{Type: fftypes.MessageTypeBroadcast, Namespace: "ns1"}
+	BatchSealed(batch)
+	BatchSealed(batch)
+	BatchDispatched(batch)
+	assert.Equal(t, float64(2), testutil.ToFloat64(batchesSealed.WithLabelValues("broadcast", "ns1")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(batchesDispatched.WithLabelValues("broadcast", "ns1")))
+}
+
+func TestBlockchain(t *testing.T) {
+	Clear()
+	BlockchainSubmission("ethereum", nil)
+	BlockchainSubmission("ethereum", fmt.Errorf("pop"))
+	BlockchainReceipt("ethereum", fftypes.OpStatusSucceeded)
+	assert.Equal(t, float64(1), testutil.ToFloat64(blockchainSubmissions.WithLabelValues("ethereum", "Pending")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(blockchainSubmissions.WithLabelValues("ethereum", "Failed")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(blockchainReceipts.WithLabelValues("ethereum", "Succeeded")))
+}
+
+func TestAggregatorPinBacklog(t *testing.T) {
+	Clear()
+	AggregatorPinBacklog(12345)
+	assert.Equal(t, float64(12345), testutil.ToFloat64(aggregatorPinBacklog))
+}
+
+func TestSubscriptions(t *testing.T) {
+	Clear()
+	SubscriptionDelivery("ns1", "sub1", 10, 2)
+	assert.Equal(t, float64(10), testutil.ToFloat64(subscriptionLag.WithLabelValues("ns1", "sub1")))
+	assert.Equal(t, float64(2), testutil.ToFloat64(subscriptionInflight.WithLabelValues("ns1", "sub1")))
+	SubscriptionClosed("ns1", "sub1")
+	assert.Equal(t, 0, testutil.CollectAndCount(subscriptionLag))
+	assert.Equal(t, 0, testutil.CollectAndCount(subscriptionInflight))
+}
+
+func TestDataExchangeTransfer(t *testing.T) {
+	Clear()
+	DataExchangeTransfer("https", fftypes.OpStatusFailed)
+	assert.Equal(t, float64(1), testutil.ToFloat64(dataExchangeTransfers.WithLabelValues("https", "Failed")))
+}
+
+func TestHandler(t *testing.T) {
+	Clear()
+	AggregatorPinBacklog(5)
+	svr := httptest.NewServer(Handler())
+	defer svr.Close()
+
+	res, err := http.Get(svr.URL)
+	assert.NoError(t, err)
+	defer res.Body.Close()
+	assert.Equal(t, 200, res.StatusCode)
+	b, _ := ioutil.ReadAll(res.Body)
+	assert.Contains(t, string(b), "ff_aggregator_pin_backlog 5")
+	assert.NotNil(t, Registry())
+}
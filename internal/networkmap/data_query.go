@@ -31,10 +31,20 @@ func (nm *networkMap) GetOrganizationByID(ctx context.Context, id string) (*ffty
 	return nm.database.GetOrganizationByID(ctx, u)
 }
 
-func (nm *networkMap) GetOrganizations(ctx context.Context, filter database.AndFilter) ([]*fftypes.Organization, error) {
+func (nm *networkMap) GetOrganizations(ctx context.Context, filter database.AndFilter) ([]*fftypes.Organization, *database.FilterResult, error) {
 	return nm.database.GetOrganizations(ctx, filter)
 }
 
+func (nm *networkMap) GetOrganizationVersions(ctx context.Context, id string) ([]*fftypes.Organization, error) {
+	u, err := fftypes.ParseUUID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	fb := database.OrganizationQueryFactory.NewFilter(ctx)
+	versions, _, err := nm.database.GetOrganizationVersions(ctx, fb.And(fb.Eq("id", u)).Sort("version"))
+	return versions, err
+}
+
 func (nm *networkMap) GetNodeByID(ctx context.Context, id string) (*fftypes.Node, error) {
 	u, err := fftypes.ParseUUID(ctx, id)
 	if err != nil {
@@ -43,6 +53,16 @@ func (nm *networkMap) GetNodeByID(ctx context.Context, id string) (*fftypes.Node
 	return nm.database.GetNodeByID(ctx, u)
 }
 
-func (nm *networkMap) GetNodes(ctx context.Context, filter database.AndFilter) ([]*fftypes.Node, error) {
+func (nm *networkMap) GetNodes(ctx context.Context, filter database.AndFilter) ([]*fftypes.Node, *database.FilterResult, error) {
 	return nm.database.GetNodes(ctx, filter)
 }
+
+func (nm *networkMap) GetNodeVersions(ctx context.Context, id string) ([]*fftypes.Node, error) {
+	u, err := fftypes.ParseUUID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	fb := database.NodeQueryFactory.NewFilter(ctx)
+	versions, _, err := nm.database.GetNodeVersions(ctx, fb.And(fb.Eq("id", u)).Sort("version"))
+	return versions, err
+}
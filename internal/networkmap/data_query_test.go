@@ -67,8 +67,8 @@ func TestGetNodeByIDBadUUID(t *testing.T) {
 func TestGetOrganizations(t *testing.T) {
 	nm, cancel := newTestNetworkmap(t)
 	defer cancel()
-	nm.database.(*databasemocks.Plugin).On("GetOrganizations", nm.ctx, mock.Anything).Return([]*fftypes.Organization{}, nil)
-	res, err := nm.GetOrganizations(nm.ctx, database.OrganizationQueryFactory.NewFilter(nm.ctx).And())
+	nm.database.(*databasemocks.Plugin).On("GetOrganizations", nm.ctx, mock.Anything).Return([]*fftypes.Organization{}, nil, nil)
+	res, _, err := nm.GetOrganizations(nm.ctx, database.OrganizationQueryFactory.NewFilter(nm.ctx).And())
 	assert.NoError(t, err)
 	assert.Empty(t, res)
 }
@@ -76,8 +76,42 @@ func TestGetOrganizations(t *testing.T) {
 func TestGetNodes(t *testing.T) {
 	nm, cancel := newTestNetworkmap(t)
 	defer cancel()
-	nm.database.(*databasemocks.Plugin).On("GetNodes", nm.ctx, mock.Anything).Return([]*fftypes.Node{}, nil)
-	res, err := nm.GetNodes(nm.ctx, database.NodeQueryFactory.NewFilter(nm.ctx).And())
+	nm.database.(*databasemocks.Plugin).On("GetNodes", nm.ctx, mock.Anything).Return([]*fftypes.Node{}, nil, nil)
+	res, _, err := nm.GetNodes(nm.ctx, database.NodeQueryFactory.NewFilter(nm.ctx).And())
 	assert.NoError(t, err)
 	assert.Empty(t, res)
 }
+
+func TestGetOrganizationVersions(t *testing.T) {
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+	id := fftypes.NewUUID()
+	nm.database.(*databasemocks.Plugin).On("GetOrganizationVersions", nm.ctx, mock.Anything).Return([]*fftypes.Organization{{ID: id, Version: 1}}, nil, nil)
+	res, err := nm.GetOrganizationVersions(nm.ctx, id.String())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), res[0].Version)
+}
+
+func TestGetOrganizationVersionsBadUUID(t *testing.T) {
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+	_, err := nm.GetOrganizationVersions(nm.ctx, "bad")
+	assert.Regexp(t, "FF10142", err)
+}
+
+func TestGetNodeVersions(t *testing.T) {
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+	id := fftypes.NewUUID()
+	nm.database.(*databasemocks.Plugin).On("GetNodeVersions", nm.ctx, mock.Anything).Return([]*fftypes.Node{{ID: id, Version: 1}}, nil, nil)
+	res, err := nm.GetNodeVersions(nm.ctx, id.String())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), res[0].Version)
+}
+
+func TestGetNodeVersionsBadUUID(t *testing.T) {
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+	_, err := nm.GetNodeVersions(nm.ctx, "bad")
+	assert.Regexp(t, "FF10142", err)
+}
@@ -31,11 +31,17 @@ type Manager interface {
 	RegisterOrganization(ctx context.Context, org *fftypes.Organization) (msg *fftypes.Message, err error)
 	RegisterNode(ctx context.Context) (msg *fftypes.Message, err error)
 	RegisterNodeOrganization(ctx context.Context) (msg *fftypes.Message, err error)
+	UpdateOrganization(ctx context.Context, id string, org *fftypes.Organization) (msg *fftypes.Message, err error)
+	RevokeOrganization(ctx context.Context, id string) (msg *fftypes.Message, err error)
+	UpdateNode(ctx context.Context, id string, node *fftypes.Node) (msg *fftypes.Message, err error)
+	RevokeNode(ctx context.Context, id string) (msg *fftypes.Message, err error)
 
 	GetOrganizationByID(ctx context.Context, id string) (*fftypes.Organization, error)
-	GetOrganizations(ctx context.Context, filter database.AndFilter) ([]*fftypes.Organization, error)
+	GetOrganizations(ctx context.Context, filter database.AndFilter) ([]*fftypes.Organization, *database.FilterResult, error)
+	GetOrganizationVersions(ctx context.Context, id string) ([]*fftypes.Organization, error)
 	GetNodeByID(ctx context.Context, id string) (*fftypes.Node, error)
-	GetNodes(ctx context.Context, filter database.AndFilter) ([]*fftypes.Node, error)
+	GetNodes(ctx context.Context, filter database.AndFilter) ([]*fftypes.Node, *database.FilterResult, error)
+	GetNodeVersions(ctx context.Context, id string) ([]*fftypes.Node, error)
 }
 
 type networkMap struct {
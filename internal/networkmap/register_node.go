@@ -50,7 +50,7 @@ func (nm *networkMap) RegisterNode(ctx context.Context) (msg *fftypes.Message, e
 		return nil, err
 	}
 
-	if err = nm.findOrgsToRoot(ctx, "node", node.Name, node.Owner); err != nil {
+	if _, err = nm.findOrgsToRoot(ctx, "node", node.Name, node.Owner); err != nil {
 		return nil, err
 	}
 
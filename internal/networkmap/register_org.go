@@ -24,20 +24,24 @@ import (
 	"github.com/hyperledger-labs/firefly/pkg/fftypes"
 )
 
-func (nm *networkMap) findOrgsToRoot(ctx context.Context, idType, identity, parent string) (err error) {
+// findOrgsToRoot checks the chain of parents up to a root org has been registered, returning the immediate parent
+func (nm *networkMap) findOrgsToRoot(ctx context.Context, idType, identity, parent string) (immediateParent *fftypes.Organization, err error) {
 
 	var root *fftypes.Organization
 	for parent != "" {
 		root, err = nm.database.GetOrganizationByIdentity(ctx, parent)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		if root == nil {
-			return i18n.NewError(ctx, i18n.MsgParentIdentityNotFound, parent, idType, identity)
+			return nil, i18n.NewError(ctx, i18n.MsgParentIdentityNotFound, parent, idType, identity)
+		}
+		if immediateParent == nil {
+			immediateParent = root
 		}
 		parent = root.Parent
 	}
-	return err
+	return immediateParent, err
 }
 
 // RegisterNodeOrganization is a convenience helper to register the org configured on the node, without any extra info
@@ -64,6 +68,7 @@ func (nm *networkMap) RegisterOrganization(ctx context.Context, org *fftypes.Org
 
 	// If we're a root identity, we self-sign
 	signingIdentityString := org.Identity
+	var parent *fftypes.Organization
 	if org.Parent != "" {
 		// Check the identity itself is ok
 		if _, err = nm.identity.Resolve(ctx, org.Identity); err != nil {
@@ -73,7 +78,7 @@ func (nm *networkMap) RegisterOrganization(ctx context.Context, org *fftypes.Org
 		// Otherwise we must have access to the signing key of the parent, and the parents
 		// must already have been broadcast to the network
 		signingIdentityString = org.Parent
-		if err = nm.findOrgsToRoot(ctx, "organization", org.Identity, signingIdentityString); err != nil {
+		if parent, err = nm.findOrgsToRoot(ctx, "organization", org.Identity, signingIdentityString); err != nil {
 			return nil, err
 		}
 	}
@@ -83,5 +88,20 @@ func (nm *networkMap) RegisterOrganization(ctx context.Context, org *fftypes.Org
 		return nil, i18n.WrapError(ctx, err, i18n.MsgInvalidSigningIdentity)
 	}
 
+	// Publish the keys that will verify the signatures on the messages we send as this org
+	if len(org.PublicKeys) == 0 {
+		if org.PublicKeys, err = nm.identity.PublicKeys(ctx, org.Identity); err != nil {
+			return nil, err
+		}
+	}
+
+	// Attach the credentials of the org, and check them as the other members of the network will
+	if err = nm.identity.AttachCredentials(ctx, org); err != nil {
+		return nil, err
+	}
+	if err = nm.identity.VerifyOrganization(ctx, org, parent, fftypes.Now()); err != nil {
+		return nil, err
+	}
+
 	return nm.broadcast.BroadcastDefinition(ctx, org, signingIdentity, fftypes.SystemTagDefineOrganization)
 }
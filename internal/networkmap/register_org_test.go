@@ -45,10 +45,18 @@ func TestRegisterOrganizationChildOk(t *testing.T) {
 	parentID := &fftypes.Identity{OnChain: "0x23456"}
 	mii.On("Resolve", nm.ctx, "0x12345").Return(childID, nil)
 	mii.On("Resolve", nm.ctx, "0x23456").Return(parentID, nil)
+	keys := fftypes.PublicKeys{{Type: fftypes.KeyTypeEd25519, Key: "cHVibGlja2V5"}}
+	mii.On("PublicKeys", nm.ctx, "0x12345").Return(keys, nil)
+	mii.On("AttachCredentials", nm.ctx, mock.Anything).Return(nil)
+	mii.On("VerifyOrganization", nm.ctx, mock.Anything, mock.MatchedBy(func(parent *fftypes.Organization) bool {
+		return parent.Identity == "0x23456"
+	}), mock.Anything).Return(nil)
 
 	mockMsg := &fftypes.Message{Header: fftypes.MessageHeader{ID: fftypes.NewUUID()}}
 	mbm := nm.broadcast.(*broadcastmocks.Manager)
-	mbm.On("BroadcastDefinition", nm.ctx, mock.Anything, parentID, fftypes.SystemTagDefineOrganization).Return(mockMsg, nil)
+	mbm.On("BroadcastDefinition", nm.ctx, mock.MatchedBy(func(org *fftypes.Organization) bool {
+		return org.PublicKeys[0] == keys[0]
+	}), parentID, fftypes.SystemTagDefineOrganization).Return(mockMsg, nil)
 
 	msg, err := nm.RegisterOrganization(nm.ctx, &fftypes.Organization{
 		Name:        "org1",
@@ -61,6 +69,89 @@ func TestRegisterOrganizationChildOk(t *testing.T) {
 
 }
 
+func TestRegisterOrganizationExplicitKeys(t *testing.T) {
+
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+
+	mii := nm.identity.(*identitymocks.Plugin)
+	rootID := &fftypes.Identity{OnChain: "0x12345"}
+	mii.On("Resolve", nm.ctx, "0x12345").Return(rootID, nil)
+	mii.On("AttachCredentials", nm.ctx, mock.Anything).Return(nil)
+	mii.On("VerifyOrganization", nm.ctx, mock.Anything, (*fftypes.Organization)(nil), mock.Anything).Return(nil)
+
+	mockMsg := &fftypes.Message{Header: fftypes.MessageHeader{ID: fftypes.NewUUID()}}
+	mbm := nm.broadcast.(*broadcastmocks.Manager)
+	mbm.On("BroadcastDefinition", nm.ctx, mock.Anything, rootID, fftypes.SystemTagDefineOrganization).Return(mockMsg, nil)
+
+	msg, err := nm.RegisterOrganization(nm.ctx, &fftypes.Organization{
+		Name:       "org1",
+		Identity:   "0x12345",
+		PublicKeys: fftypes.PublicKeys{{Type: fftypes.KeyTypeEd25519, Key: "cHVibGlja2V5"}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, mockMsg, msg)
+	mii.AssertNotCalled(t, "PublicKeys", mock.Anything, mock.Anything)
+
+}
+
+func TestRegisterOrganizationPublicKeysFail(t *testing.T) {
+
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+
+	mii := nm.identity.(*identitymocks.Plugin)
+	rootID := &fftypes.Identity{OnChain: "0x12345"}
+	mii.On("Resolve", nm.ctx, "0x12345").Return(rootID, nil)
+	mii.On("PublicKeys", nm.ctx, "0x12345").Return(nil, fmt.Errorf("pop"))
+
+	_, err := nm.RegisterOrganization(nm.ctx, &fftypes.Organization{
+		Name:     "org1",
+		Identity: "0x12345",
+	})
+	assert.Regexp(t, "pop", err)
+
+}
+
+func TestRegisterOrganizationAttachCredentialsFail(t *testing.T) {
+
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+
+	mii := nm.identity.(*identitymocks.Plugin)
+	rootID := &fftypes.Identity{OnChain: "0x12345"}
+	mii.On("Resolve", nm.ctx, "0x12345").Return(rootID, nil)
+	mii.On("PublicKeys", nm.ctx, "0x12345").Return(nil, nil)
+	mii.On("AttachCredentials", nm.ctx, mock.Anything).Return(fmt.Errorf("pop"))
+
+	_, err := nm.RegisterOrganization(nm.ctx, &fftypes.Organization{
+		Name:     "org1",
+		Identity: "0x12345",
+	})
+	assert.Regexp(t, "pop", err)
+
+}
+
+func TestRegisterOrganizationVerifyFail(t *testing.T) {
+
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+
+	mii := nm.identity.(*identitymocks.Plugin)
+	rootID := &fftypes.Identity{OnChain: "0x12345"}
+	mii.On("Resolve", nm.ctx, "0x12345").Return(rootID, nil)
+	mii.On("PublicKeys", nm.ctx, "0x12345").Return(nil, nil)
+	mii.On("AttachCredentials", nm.ctx, mock.Anything).Return(nil)
+	mii.On("VerifyOrganization", nm.ctx, mock.Anything, (*fftypes.Organization)(nil), mock.Anything).Return(fmt.Errorf("pop"))
+
+	_, err := nm.RegisterOrganization(nm.ctx, &fftypes.Organization{
+		Name:     "org1",
+		Identity: "0x12345",
+	})
+	assert.Regexp(t, "pop", err)
+
+}
+
 func TestRegisterNodeOrganizationRootOk(t *testing.T) {
 
 	nm, cancel := newTestNetworkmap(t)
@@ -73,6 +164,9 @@ func TestRegisterNodeOrganizationRootOk(t *testing.T) {
 	mii := nm.identity.(*identitymocks.Plugin)
 	rootID := &fftypes.Identity{OnChain: "0x12345"}
 	mii.On("Resolve", nm.ctx, "0x12345").Return(rootID, nil)
+	mii.On("PublicKeys", nm.ctx, "0x12345").Return(nil, nil)
+	mii.On("AttachCredentials", nm.ctx, mock.Anything).Return(nil)
+	mii.On("VerifyOrganization", nm.ctx, mock.Anything, (*fftypes.Organization)(nil), mock.Anything).Return(nil)
 
 	mockMsg := &fftypes.Message{Header: fftypes.MessageHeader{ID: fftypes.NewUUID()}}
 	mbm := nm.broadcast.(*broadcastmocks.Manager)
@@ -0,0 +1,90 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networkmap
+
+import (
+	"context"
+
+	"github.com/hyperledger-labs/firefly/internal/i18n"
+	"github.com/hyperledger-labs/firefly/pkg/fftypes"
+)
+
+// getActiveNode returns the existing node with the given ID, as long as it has not been revoked
+func (nm *networkMap) getActiveNode(ctx context.Context, id string) (*fftypes.Node, error) {
+	existing, err := nm.GetNodeByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		return nil, i18n.NewError(ctx, i18n.Msg404NotFound)
+	}
+	if existing.Revoked != nil {
+		return nil, i18n.NewError(ctx, i18n.MsgNodeRevoked, existing.Name)
+	}
+	return existing, nil
+}
+
+// UpdateNode broadcasts a new version of an existing node. The owner and name cannot be changed.
+// If no data exchange peer is supplied, the current details are read from the local data exchange.
+func (nm *networkMap) UpdateNode(ctx context.Context, id string, node *fftypes.Node) (*fftypes.Message, error) {
+
+	existing, err := nm.getActiveNode(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	node.ID = existing.ID
+	node.Owner = existing.Owner
+	node.Name = existing.Name
+	node.Created = existing.Created
+	if node.DX.Peer == "" {
+		node.DX.Peer, node.DX.Endpoint, err = nm.exchange.GetEndpointInfo(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err = node.Validate(ctx, true); err != nil {
+		return nil, err
+	}
+
+	if _, err = nm.findOrgsToRoot(ctx, "node", node.Name, node.Owner); err != nil {
+		return nil, err
+	}
+
+	signingIdentity, err := nm.identity.Resolve(ctx, node.Owner)
+	if err != nil {
+		return nil, i18n.WrapError(ctx, err, i18n.MsgInvalidSigningIdentity)
+	}
+
+	return nm.broadcast.BroadcastDefinition(ctx, node, signingIdentity, fftypes.SystemTagUpdateNode)
+}
+
+// RevokeNode broadcasts that a node has left the network
+func (nm *networkMap) RevokeNode(ctx context.Context, id string) (*fftypes.Message, error) {
+
+	existing, err := nm.getActiveNode(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	signingIdentity, err := nm.identity.Resolve(ctx, existing.Owner)
+	if err != nil {
+		return nil, i18n.WrapError(ctx, err, i18n.MsgInvalidSigningIdentity)
+	}
+
+	return nm.broadcast.BroadcastDefinition(ctx, existing, signingIdentity, fftypes.SystemTagRevokeNode)
+}
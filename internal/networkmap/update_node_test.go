@@ -0,0 +1,200 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networkmap
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger-labs/firefly/mocks/broadcastmocks"
+	"github.com/hyperledger-labs/firefly/mocks/databasemocks"
+	"github.com/hyperledger-labs/firefly/mocks/dataexchangemocks"
+	"github.com/hyperledger-labs/firefly/mocks/identitymocks"
+	"github.com/hyperledger-labs/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestExistingNode() *fftypes.Node {
+	return &fftypes.Node{
+		ID:      fftypes.NewUUID(),
+		Name:    "node1",
+		Owner:   "0x12345",
+		Version: 1,
+		Created: fftypes.Now(),
+		DX: fftypes.DXInfo{
+			Peer: "peer1",
+		},
+	}
+}
+
+func TestUpdateNodeOk(t *testing.T) {
+
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+
+	existing := newTestExistingNode()
+	mdi := nm.database.(*databasemocks.Plugin)
+	mdi.On("GetNodeByID", nm.ctx, uuidMatches(existing.ID)).Return(existing, nil)
+	mdi.On("GetOrganizationByIdentity", nm.ctx, "0x12345").Return(&fftypes.Organization{Identity: "0x12345"}, nil)
+
+	mdx := nm.exchange.(*dataexchangemocks.Plugin)
+	mdx.On("GetEndpointInfo", nm.ctx).Return("peer2", fftypes.JSONObject{"endpoint": "details"}, nil)
+
+	mii := nm.identity.(*identitymocks.Plugin)
+	ownerID := &fftypes.Identity{OnChain: "0x12345"}
+	mii.On("Resolve", nm.ctx, "0x12345").Return(ownerID, nil)
+
+	mockMsg := &fftypes.Message{Header: fftypes.MessageHeader{ID: fftypes.NewUUID()}}
+	mbm := nm.broadcast.(*broadcastmocks.Manager)
+	mbm.On("BroadcastDefinition", nm.ctx, mock.MatchedBy(func(node *fftypes.Node) bool {
+		return *node.ID == *existing.ID && node.Name == "node1" && node.DX.Peer == "peer2" && node.Description == "updated"
+	}), ownerID, fftypes.SystemTagUpdateNode).Return(mockMsg, nil)
+
+	msg, err := nm.UpdateNode(nm.ctx, existing.ID.String(), &fftypes.Node{
+		Name:        "ignored",
+		Description: "updated",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, mockMsg, msg)
+
+}
+
+func TestUpdateNodeBadID(t *testing.T) {
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+	_, err := nm.UpdateNode(nm.ctx, "bad", &fftypes.Node{})
+	assert.Regexp(t, "FF10142", err)
+}
+
+func TestUpdateNodeNotFound(t *testing.T) {
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+	mdi := nm.database.(*databasemocks.Plugin)
+	mdi.On("GetNodeByID", nm.ctx, mock.Anything).Return(nil, nil)
+	_, err := nm.UpdateNode(nm.ctx, fftypes.NewUUID().String(), &fftypes.Node{})
+	assert.Regexp(t, "FF10109", err)
+}
+
+func TestUpdateNodeRevoked(t *testing.T) {
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+	existing := newTestExistingNode()
+	existing.Revoked = fftypes.Now()
+	mdi := nm.database.(*databasemocks.Plugin)
+	mdi.On("GetNodeByID", nm.ctx, mock.Anything).Return(existing, nil)
+	_, err := nm.UpdateNode(nm.ctx, existing.ID.String(), &fftypes.Node{})
+	assert.Regexp(t, "FF10292", err)
+}
+
+func TestUpdateNodeEndpointFail(t *testing.T) {
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+	existing := newTestExistingNode()
+	mdi := nm.database.(*databasemocks.Plugin)
+	mdi.On("GetNodeByID", nm.ctx, mock.Anything).Return(existing, nil)
+	mdx := nm.exchange.(*dataexchangemocks.Plugin)
+	mdx.On("GetEndpointInfo", nm.ctx).Return("", nil, fmt.Errorf("pop"))
+	_, err := nm.UpdateNode(nm.ctx, existing.ID.String(), &fftypes.Node{})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestUpdateNodeValidateFail(t *testing.T) {
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+	existing := newTestExistingNode()
+	mdi := nm.database.(*databasemocks.Plugin)
+	mdi.On("GetNodeByID", nm.ctx, mock.Anything).Return(existing, nil)
+	longDesc := string(make([]byte, 4097))
+	_, err := nm.UpdateNode(nm.ctx, existing.ID.String(), &fftypes.Node{
+		Description: longDesc,
+		DX:          fftypes.DXInfo{Peer: "peer1"},
+	})
+	assert.Regexp(t, "FF10188", err)
+}
+
+func TestUpdateNodeOwnerFail(t *testing.T) {
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+	existing := newTestExistingNode()
+	mdi := nm.database.(*databasemocks.Plugin)
+	mdi.On("GetNodeByID", nm.ctx, mock.Anything).Return(existing, nil)
+	mdi.On("GetOrganizationByIdentity", nm.ctx, "0x12345").Return(nil, fmt.Errorf("pop"))
+	_, err := nm.UpdateNode(nm.ctx, existing.ID.String(), &fftypes.Node{
+		DX: fftypes.DXInfo{Peer: "peer1"},
+	})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestUpdateNodeSignerFail(t *testing.T) {
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+	existing := newTestExistingNode()
+	mdi := nm.database.(*databasemocks.Plugin)
+	mdi.On("GetNodeByID", nm.ctx, mock.Anything).Return(existing, nil)
+	mdi.On("GetOrganizationByIdentity", nm.ctx, "0x12345").Return(&fftypes.Organization{Identity: "0x12345"}, nil)
+	mii := nm.identity.(*identitymocks.Plugin)
+	mii.On("Resolve", nm.ctx, "0x12345").Return(nil, fmt.Errorf("pop"))
+	_, err := nm.UpdateNode(nm.ctx, existing.ID.String(), &fftypes.Node{
+		DX: fftypes.DXInfo{Peer: "peer1"},
+	})
+	assert.Regexp(t, "FF10215", err)
+}
+
+func TestRevokeNodeOk(t *testing.T) {
+
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+
+	existing := newTestExistingNode()
+	mdi := nm.database.(*databasemocks.Plugin)
+	mdi.On("GetNodeByID", nm.ctx, uuidMatches(existing.ID)).Return(existing, nil)
+
+	mii := nm.identity.(*identitymocks.Plugin)
+	ownerID := &fftypes.Identity{OnChain: "0x12345"}
+	mii.On("Resolve", nm.ctx, "0x12345").Return(ownerID, nil)
+
+	mockMsg := &fftypes.Message{Header: fftypes.MessageHeader{ID: fftypes.NewUUID()}}
+	mbm := nm.broadcast.(*broadcastmocks.Manager)
+	mbm.On("BroadcastDefinition", nm.ctx, existing, ownerID, fftypes.SystemTagRevokeNode).Return(mockMsg, nil)
+
+	msg, err := nm.RevokeNode(nm.ctx, existing.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, mockMsg, msg)
+
+}
+
+func TestRevokeNodeGetFail(t *testing.T) {
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+	mdi := nm.database.(*databasemocks.Plugin)
+	mdi.On("GetNodeByID", nm.ctx, mock.Anything).Return(nil, fmt.Errorf("pop"))
+	_, err := nm.RevokeNode(nm.ctx, fftypes.NewUUID().String())
+	assert.EqualError(t, err, "pop")
+}
+
+func TestRevokeNodeSignerFail(t *testing.T) {
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+	existing := newTestExistingNode()
+	mdi := nm.database.(*databasemocks.Plugin)
+	mdi.On("GetNodeByID", nm.ctx, mock.Anything).Return(existing, nil)
+	mii := nm.identity.(*identitymocks.Plugin)
+	mii.On("Resolve", nm.ctx, "0x12345").Return(nil, fmt.Errorf("pop"))
+	_, err := nm.RevokeNode(nm.ctx, existing.ID.String())
+	assert.Regexp(t, "FF10215", err)
+}
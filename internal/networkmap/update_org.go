@@ -0,0 +1,136 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networkmap
+
+import (
+	"context"
+
+	"github.com/hyperledger-labs/firefly/internal/i18n"
+	"github.com/hyperledger-labs/firefly/pkg/fftypes"
+)
+
+// getActiveOrganization returns the existing organization with the given ID, as long as it has not been revoked
+func (nm *networkMap) getActiveOrganization(ctx context.Context, id string) (*fftypes.Organization, error) {
+	existing, err := nm.GetOrganizationByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		return nil, i18n.NewError(ctx, i18n.Msg404NotFound)
+	}
+	if existing.Revoked != nil {
+		return nil, i18n.NewError(ctx, i18n.MsgOrgRevoked, existing.Name)
+	}
+	return existing, nil
+}
+
+// resolveUpdateSigner returns the identity that signs changes to an existing organization.
+// As for registration, that is the parent if there is one, otherwise the organization itself
+func (nm *networkMap) resolveUpdateSigner(ctx context.Context, existing *fftypes.Organization) (*fftypes.Identity, error) {
+	signingIdentityString := existing.Identity
+	if existing.Parent != "" {
+		signingIdentityString = existing.Parent
+	}
+	signingIdentity, err := nm.identity.Resolve(ctx, signingIdentityString)
+	if err != nil {
+		return nil, i18n.WrapError(ctx, err, i18n.MsgInvalidSigningIdentity)
+	}
+	return signingIdentity, nil
+}
+
+// UpdateOrganization broadcasts a new version of an existing organization. The identity can be changed
+// to rotate the signing key of the organization, but the parent cannot be changed.
+func (nm *networkMap) UpdateOrganization(ctx context.Context, id string, org *fftypes.Organization) (*fftypes.Message, error) {
+
+	existing, err := nm.getActiveOrganization(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	org.ID = existing.ID
+	org.Parent = existing.Parent
+	org.Created = existing.Created
+	if org.Name == "" {
+		org.Name = existing.Name
+	}
+	if org.Identity == "" {
+		org.Identity = existing.Identity
+	}
+	if err = org.Validate(ctx, true); err != nil {
+		return nil, err
+	}
+
+	// The identity must be usable, which requires a check when it is being rotated
+	if org.Identity != existing.Identity {
+		if _, err = nm.identity.Resolve(ctx, org.Identity); err != nil {
+			return nil, err
+		}
+	}
+
+	var parent *fftypes.Organization
+	if org.Parent != "" {
+		if parent, err = nm.findOrgsToRoot(ctx, "organization", org.Identity, org.Parent); err != nil {
+			return nil, err
+		}
+	}
+
+	signingIdentity, err := nm.resolveUpdateSigner(ctx, existing)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(org.PublicKeys) == 0 {
+		if org.PublicKeys, err = nm.identity.PublicKeys(ctx, org.Identity); err != nil {
+			return nil, err
+		}
+	}
+	if err = nm.identity.AttachCredentials(ctx, org); err != nil {
+		return nil, err
+	}
+	if err = nm.identity.VerifyOrganization(ctx, org, parent, fftypes.Now()); err != nil {
+		return nil, err
+	}
+
+	// Moving to a new identity requires proof that we control it
+	org.IdentityProof = ""
+	if org.Identity != existing.Identity {
+		if org.IdentityProof, err = nm.identity.Sign(ctx, org.Identity, org.IdentityClaimHash(existing.Version+1)); err != nil {
+			return nil, err
+		}
+		if org.IdentityProof == "" {
+			return nil, i18n.NewError(ctx, i18n.MsgIdentityProofUnavailable, org.Identity)
+		}
+	}
+
+	return nm.broadcast.BroadcastDefinition(ctx, org, signingIdentity, fftypes.SystemTagUpdateOrganization)
+}
+
+// RevokeOrganization broadcasts that an organization has left the network
+func (nm *networkMap) RevokeOrganization(ctx context.Context, id string) (*fftypes.Message, error) {
+
+	existing, err := nm.getActiveOrganization(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	signingIdentity, err := nm.resolveUpdateSigner(ctx, existing)
+	if err != nil {
+		return nil, err
+	}
+
+	return nm.broadcast.BroadcastDefinition(ctx, existing, signingIdentity, fftypes.SystemTagRevokeOrganization)
+}
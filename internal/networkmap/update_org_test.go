@@ -0,0 +1,308 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package networkmap
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger-labs/firefly/mocks/broadcastmocks"
+	"github.com/hyperledger-labs/firefly/mocks/databasemocks"
+	"github.com/hyperledger-labs/firefly/mocks/identitymocks"
+	"github.com/hyperledger-labs/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestExistingOrg() *fftypes.Organization {
+	return &fftypes.Organization{
+		ID:       fftypes.NewUUID(),
+		Name:     "org1",
+		Identity: "0x12345",
+		Parent:   "0x23456",
+		Version:  1,
+		Created:  fftypes.Now(),
+	}
+}
+
+func TestUpdateOrganizationOk(t *testing.T) {
+
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+
+	existing := newTestExistingOrg()
+	mdi := nm.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByID", nm.ctx, uuidMatches(existing.ID)).Return(existing, nil)
+	mdi.On("GetOrganizationByIdentity", nm.ctx, "0x23456").Return(&fftypes.Organization{Identity: "0x23456"}, nil)
+
+	mii := nm.identity.(*identitymocks.Plugin)
+	parentID := &fftypes.Identity{OnChain: "0x23456"}
+	mii.On("Resolve", nm.ctx, "0x23456").Return(parentID, nil)
+	keys := fftypes.PublicKeys{{Type: fftypes.KeyTypeEd25519, Key: "cHVibGlja2V5"}}
+	mii.On("PublicKeys", nm.ctx, "0x12345").Return(keys, nil)
+	mii.On("AttachCredentials", nm.ctx, mock.Anything).Return(nil)
+	mii.On("VerifyOrganization", nm.ctx, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+
+	mockMsg := &fftypes.Message{Header: fftypes.MessageHeader{ID: fftypes.NewUUID()}}
+	mbm := nm.broadcast.(*broadcastmocks.Manager)
+	mbm.On("BroadcastDefinition", nm.ctx, mock.MatchedBy(func(org *fftypes.Organization) bool {
+		return *org.ID == *existing.ID && org.Name == "org1" && org.Identity == "0x12345" &&
+			org.Parent == "0x23456" && org.Description == "updated"
+	}), parentID, fftypes.SystemTagUpdateOrganization).Return(mockMsg, nil)
+
+	msg, err := nm.UpdateOrganization(nm.ctx, existing.ID.String(), &fftypes.Organization{
+		Description: "updated",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, mockMsg, msg)
+
+}
+
+func TestUpdateOrganizationRotateOk(t *testing.T) {
+
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+
+	existing := newTestExistingOrg()
+	existing.Parent = ""
+	mdi := nm.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByID", nm.ctx, uuidMatches(existing.ID)).Return(existing, nil)
+
+	mii := nm.identity.(*identitymocks.Plugin)
+	oldID := &fftypes.Identity{OnChain: "0x12345"}
+	mii.On("Resolve", nm.ctx, "0x12345").Return(oldID, nil)
+	mii.On("Resolve", nm.ctx, "0x99999").Return(&fftypes.Identity{OnChain: "0x99999"}, nil)
+	mii.On("PublicKeys", nm.ctx, "0x99999").Return(fftypes.PublicKeys{}, nil)
+	mii.On("AttachCredentials", nm.ctx, mock.Anything).Return(nil)
+	mii.On("VerifyOrganization", nm.ctx, mock.Anything, (*fftypes.Organization)(nil), mock.Anything).Return(nil)
+	mii.On("Sign", nm.ctx, "0x99999", mock.Anything).Return("cHJvb2Y=", nil)
+
+	mockMsg := &fftypes.Message{Header: fftypes.MessageHeader{ID: fftypes.NewUUID()}}
+	mbm := nm.broadcast.(*broadcastmocks.Manager)
+	mbm.On("BroadcastDefinition", nm.ctx, mock.MatchedBy(func(org *fftypes.Organization) bool {
+		return org.Identity == "0x99999" && org.IdentityProof == "cHJvb2Y="
+	}), oldID, fftypes.SystemTagUpdateOrganization).Return(mockMsg, nil)
+
+	msg, err := nm.UpdateOrganization(nm.ctx, existing.ID.String(), &fftypes.Organization{
+		Identity: "0x99999",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, mockMsg, msg)
+
+}
+
+func TestUpdateOrganizationBadID(t *testing.T) {
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+	_, err := nm.UpdateOrganization(nm.ctx, "bad", &fftypes.Organization{})
+	assert.Regexp(t, "FF10142", err)
+}
+
+func TestUpdateOrganizationNotFound(t *testing.T) {
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+	mdi := nm.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByID", nm.ctx, mock.Anything).Return(nil, nil)
+	_, err := nm.UpdateOrganization(nm.ctx, fftypes.NewUUID().String(), &fftypes.Organization{})
+	assert.Regexp(t, "FF10109", err)
+}
+
+func TestUpdateOrganizationRevoked(t *testing.T) {
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+	existing := newTestExistingOrg()
+	existing.Revoked = fftypes.Now()
+	mdi := nm.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByID", nm.ctx, mock.Anything).Return(existing, nil)
+	_, err := nm.UpdateOrganization(nm.ctx, existing.ID.String(), &fftypes.Organization{})
+	assert.Regexp(t, "FF10291", err)
+}
+
+func TestUpdateOrganizationValidateFail(t *testing.T) {
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+	existing := newTestExistingOrg()
+	mdi := nm.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByID", nm.ctx, mock.Anything).Return(existing, nil)
+	_, err := nm.UpdateOrganization(nm.ctx, existing.ID.String(), &fftypes.Organization{Name: "!bad"})
+	assert.Regexp(t, "FF10131", err)
+}
+
+func TestUpdateOrganizationRotateResolveFail(t *testing.T) {
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+	existing := newTestExistingOrg()
+	mdi := nm.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByID", nm.ctx, mock.Anything).Return(existing, nil)
+	mii := nm.identity.(*identitymocks.Plugin)
+	mii.On("Resolve", nm.ctx, "0x99999").Return(nil, fmt.Errorf("pop"))
+	_, err := nm.UpdateOrganization(nm.ctx, existing.ID.String(), &fftypes.Organization{Identity: "0x99999"})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestUpdateOrganizationParentFail(t *testing.T) {
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+	existing := newTestExistingOrg()
+	mdi := nm.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByID", nm.ctx, mock.Anything).Return(existing, nil)
+	mdi.On("GetOrganizationByIdentity", nm.ctx, "0x23456").Return(nil, fmt.Errorf("pop"))
+	_, err := nm.UpdateOrganization(nm.ctx, existing.ID.String(), &fftypes.Organization{})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestUpdateOrganizationSignerFail(t *testing.T) {
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+	existing := newTestExistingOrg()
+	mdi := nm.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByID", nm.ctx, mock.Anything).Return(existing, nil)
+	mdi.On("GetOrganizationByIdentity", nm.ctx, "0x23456").Return(&fftypes.Organization{Identity: "0x23456"}, nil)
+	mii := nm.identity.(*identitymocks.Plugin)
+	mii.On("Resolve", nm.ctx, "0x23456").Return(nil, fmt.Errorf("pop"))
+	_, err := nm.UpdateOrganization(nm.ctx, existing.ID.String(), &fftypes.Organization{})
+	assert.Regexp(t, "FF10215", err)
+}
+
+func TestUpdateOrganizationPublicKeysFail(t *testing.T) {
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+	existing := newTestExistingOrg()
+	existing.Parent = ""
+	mdi := nm.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByID", nm.ctx, mock.Anything).Return(existing, nil)
+	mii := nm.identity.(*identitymocks.Plugin)
+	mii.On("Resolve", nm.ctx, "0x12345").Return(&fftypes.Identity{OnChain: "0x12345"}, nil)
+	mii.On("PublicKeys", nm.ctx, "0x12345").Return(nil, fmt.Errorf("pop"))
+	_, err := nm.UpdateOrganization(nm.ctx, existing.ID.String(), &fftypes.Organization{})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestUpdateOrganizationAttachCredentialsFail(t *testing.T) {
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+	existing := newTestExistingOrg()
+	existing.Parent = ""
+	mdi := nm.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByID", nm.ctx, mock.Anything).Return(existing, nil)
+	mii := nm.identity.(*identitymocks.Plugin)
+	mii.On("Resolve", nm.ctx, "0x12345").Return(&fftypes.Identity{OnChain: "0x12345"}, nil)
+	mii.On("AttachCredentials", nm.ctx, mock.Anything).Return(fmt.Errorf("pop"))
+	_, err := nm.UpdateOrganization(nm.ctx, existing.ID.String(), &fftypes.Organization{
+		PublicKeys: fftypes.PublicKeys{{Type: fftypes.KeyTypeEd25519, Key: "cHVibGlja2V5"}},
+	})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestUpdateOrganizationVerifyFail(t *testing.T) {
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+	existing := newTestExistingOrg()
+	existing.Parent = ""
+	mdi := nm.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByID", nm.ctx, mock.Anything).Return(existing, nil)
+	mii := nm.identity.(*identitymocks.Plugin)
+	mii.On("Resolve", nm.ctx, "0x12345").Return(&fftypes.Identity{OnChain: "0x12345"}, nil)
+	mii.On("AttachCredentials", nm.ctx, mock.Anything).Return(nil)
+	mii.On("VerifyOrganization", nm.ctx, mock.Anything, mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
+	_, err := nm.UpdateOrganization(nm.ctx, existing.ID.String(), &fftypes.Organization{
+		PublicKeys: fftypes.PublicKeys{{Type: fftypes.KeyTypeEd25519, Key: "cHVibGlja2V5"}},
+	})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestUpdateOrganizationRotateNoProof(t *testing.T) {
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+	existing := newTestExistingOrg()
+	existing.Parent = ""
+	mdi := nm.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByID", nm.ctx, mock.Anything).Return(existing, nil)
+	mii := nm.identity.(*identitymocks.Plugin)
+	mii.On("Resolve", nm.ctx, mock.Anything).Return(&fftypes.Identity{OnChain: "0x12345"}, nil)
+	mii.On("PublicKeys", nm.ctx, "0x99999").Return(fftypes.PublicKeys{}, nil)
+	mii.On("AttachCredentials", nm.ctx, mock.Anything).Return(nil)
+	mii.On("VerifyOrganization", nm.ctx, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mii.On("Sign", nm.ctx, "0x99999", mock.Anything).Return("", nil)
+	_, err := nm.UpdateOrganization(nm.ctx, existing.ID.String(), &fftypes.Organization{
+		Identity: "0x99999",
+	})
+	assert.Regexp(t, "FF10300", err)
+}
+
+func TestUpdateOrganizationRotateSignFail(t *testing.T) {
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+	existing := newTestExistingOrg()
+	existing.Parent = ""
+	mdi := nm.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByID", nm.ctx, mock.Anything).Return(existing, nil)
+	mii := nm.identity.(*identitymocks.Plugin)
+	mii.On("Resolve", nm.ctx, mock.Anything).Return(&fftypes.Identity{OnChain: "0x12345"}, nil)
+	mii.On("PublicKeys", nm.ctx, "0x99999").Return(fftypes.PublicKeys{}, nil)
+	mii.On("AttachCredentials", nm.ctx, mock.Anything).Return(nil)
+	mii.On("VerifyOrganization", nm.ctx, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	mii.On("Sign", nm.ctx, "0x99999", mock.Anything).Return("", fmt.Errorf("pop"))
+	_, err := nm.UpdateOrganization(nm.ctx, existing.ID.String(), &fftypes.Organization{
+		Identity: "0x99999",
+	})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestRevokeOrganizationOk(t *testing.T) {
+
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+
+	existing := newTestExistingOrg()
+	mdi := nm.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByID", nm.ctx, uuidMatches(existing.ID)).Return(existing, nil)
+
+	mii := nm.identity.(*identitymocks.Plugin)
+	parentID := &fftypes.Identity{OnChain: "0x23456"}
+	mii.On("Resolve", nm.ctx, "0x23456").Return(parentID, nil)
+
+	mockMsg := &fftypes.Message{Header: fftypes.MessageHeader{ID: fftypes.NewUUID()}}
+	mbm := nm.broadcast.(*broadcastmocks.Manager)
+	mbm.On("BroadcastDefinition", nm.ctx, existing, parentID, fftypes.SystemTagRevokeOrganization).Return(mockMsg, nil)
+
+	msg, err := nm.RevokeOrganization(nm.ctx, existing.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, mockMsg, msg)
+
+}
+
+func TestRevokeOrganizationGetFail(t *testing.T) {
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+	mdi := nm.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByID", nm.ctx, mock.Anything).Return(nil, fmt.Errorf("pop"))
+	_, err := nm.RevokeOrganization(nm.ctx, fftypes.NewUUID().String())
+	assert.EqualError(t, err, "pop")
+}
+
+func TestRevokeOrganizationSignerFail(t *testing.T) {
+	nm, cancel := newTestNetworkmap(t)
+	defer cancel()
+	existing := newTestExistingOrg()
+	mdi := nm.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByID", nm.ctx, mock.Anything).Return(existing, nil)
+	mii := nm.identity.(*identitymocks.Plugin)
+	mii.On("Resolve", nm.ctx, "0x23456").Return(nil, fmt.Errorf("pop"))
+	_, err := nm.RevokeOrganization(nm.ctx, existing.ID.String())
+	assert.Regexp(t, "FF10215", err)
+}
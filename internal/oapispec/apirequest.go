@@ -18,7 +18,7 @@ package oapispec
 
 import (
 	"context"
-	"io"
+	"mime/multipart"
 	"net/http"
 
 	"github.com/hyperledger-labs/firefly/internal/orchestrator"
@@ -26,12 +26,18 @@ import (
 )
 
 type APIRequest struct {
-	Ctx     context.Context
-	Or      orchestrator.Orchestrator
-	Req     *http.Request
-	QP      map[string]string
-	PP      map[string]string
-	Filter  database.AndFilter
-	Input   interface{}
-	FReader io.Reader
+	Ctx    context.Context
+	Or     orchestrator.Orchestrator
+	Req    *http.Request
+	QP     map[string]string
+	PP     map[string]string
+	Filter database.AndFilter
+	Input  interface{}
+	Part   *multipart.Part
+
+	// ResponseHeaders can be set by handlers that stream binary output, such as Content-Type and Content-Length
+	ResponseHeaders http.Header
+	// SuccessStatus is initialized to the JSONOutputCode of the route, and can be changed by handlers
+	// where the status depends on the request (such as a synchronous variant of an async operation)
+	SuccessStatus int
 }
@@ -156,15 +156,52 @@ func addFormInput(op *openapi3.Operation) {
 }
 
 func addOutput(ctx context.Context, route *Route, output interface{}, op *openapi3.Operation) {
-	schemaRef, _, _ := openapi3gen.NewSchemaRefForValue(output)
 	s := i18n.Expand(ctx, i18n.MsgSuccessResponse)
+	content := openapi3.Content{}
+	if _, isBinary := output.([]byte); isBinary {
+		content["application/octet-stream"] = &openapi3.MediaType{
+			Schema: &openapi3.SchemaRef{
+				Value: &openapi3.Schema{
+					Type:   "string",
+					Format: "binary",
+				},
+			},
+		}
+	} else {
+		schemaRef, _, _ := openapi3gen.NewSchemaRefForValue(output)
+		if route.FilterFactory != nil && schemaRef.Value != nil && schemaRef.Value.Type == "array" {
+			schemaRef = filterResultsSchema(ctx, schemaRef)
+		}
+		content["application/json"] = &openapi3.MediaType{
+			Schema: schemaRef,
+		}
+	}
 	op.Responses[strconv.FormatInt(int64(route.JSONOutputCode), 10)] = &openapi3.ResponseRef{
 		Value: &openapi3.Response{
 			Description: &s,
-			Content: openapi3.Content{
-				"application/json": &openapi3.MediaType{
-					Schema: schemaRef,
-				},
+			Content:     content,
+		},
+	}
+}
+
+// filterResultsSchema describes the output of a collection route, which is the array of items,
+// or an envelope containing the items when the count or a cursor is requested
+func filterResultsSchema(ctx context.Context, items *openapi3.SchemaRef) *openapi3.SchemaRef {
+	envelope := &openapi3.Schema{
+		Type:        "object",
+		Description: i18n.Expand(ctx, i18n.MsgFilterResultsEnvelopeDesc),
+		Properties: openapi3.Schemas{
+			"count": &openapi3.SchemaRef{Value: &openapi3.Schema{Type: "integer", Format: "int64"}},
+			"total": &openapi3.SchemaRef{Value: &openapi3.Schema{Type: "integer", Format: "int64"}},
+			"next":  &openapi3.SchemaRef{Value: &openapi3.Schema{Type: "string"}},
+			"items": items,
+		},
+	}
+	return &openapi3.SchemaRef{
+		Value: &openapi3.Schema{
+			OneOf: openapi3.SchemaRefs{
+				items,
+				&openapi3.SchemaRef{Value: envelope},
 			},
 		},
 	}
@@ -242,13 +279,19 @@ func addRoute(ctx context.Context, doc *openapi3.T, route *Route) {
 		addParam(ctx, op, "query", q.Name, q.Default, example, q.Description)
 	}
 	if route.FilterFactory != nil {
+		sequenced := false
 		for _, field := range route.FilterFactory.NewFilter(ctx).Fields() {
 			addParam(ctx, op, "query", field, "", "", i18n.MsgFilterParamDesc)
+			sequenced = sequenced || field == "sequence"
 		}
 		addParam(ctx, op, "query", "sort", "", "", i18n.MsgFilterSortDesc)
 		addParam(ctx, op, "query", "descending", "", "", i18n.MsgFilterDescendingDesc)
 		addParam(ctx, op, "query", "skip", "", "", i18n.MsgFilterSkipDesc, config.GetUint(config.APIMaxFilterSkip))
 		addParam(ctx, op, "query", "limit", "", config.GetString(config.APIDefaultFilterLimit), i18n.MsgFilterLimitDesc, config.GetUint(config.APIMaxFilterLimit))
+		addParam(ctx, op, "query", "count", "", "", i18n.MsgFilterCountDesc)
+		if sequenced {
+			addParam(ctx, op, "query", "after", "", "", i18n.MsgFilterAfterDesc)
+		}
 	}
 	switch route.Method {
 	case http.MethodGet:
@@ -80,7 +80,7 @@ var testRoutes = []*Route{
 		JSONInputValue:    func() interface{} { return &fftypes.Data{} },
 		JSONOutputValue:   func() interface{} { return nil },
 		JSONOutputCode:    http.StatusNoContent,
-		FormUploadHandler: func(r APIRequest) (output interface{}, err error) { return nil, nil },
+		FormUploadHandler: func(r *APIRequest) (output interface{}, err error) { return nil, nil },
 	},
 	{
 		Name:   "op4",
@@ -108,6 +108,21 @@ func TestOpenAPI3SwaggerGen(t *testing.T) {
 	b, err := yaml.Marshal(doc)
 	assert.NoError(t, err)
 	fmt.Print(string(b))
+
+	// Collection routes return the array of items, or the envelope with the count and cursor
+	listSchema := doc.Paths["/example2"].Get.Responses["200"].Value.Content["application/json"].Schema.Value
+	assert.Len(t, listSchema.OneOf, 2)
+	assert.Equal(t, "array", listSchema.OneOf[0].Value.Type)
+	envelope := listSchema.OneOf[1].Value
+	assert.Equal(t, "object", envelope.Type)
+	for _, prop := range []string{"count", "total", "next"} {
+		assert.Contains(t, envelope.Properties, prop)
+	}
+	assert.Equal(t, listSchema.OneOf[0], envelope.Properties["items"])
+
+	// Other routes are unchanged
+	batchSchema := doc.Paths["/namespaces/{ns}/example1/{id}"].Post.Responses["200"].Value.Content["application/json"].Schema.Value
+	assert.Empty(t, batchSchema.OneOf)
 }
 
 func TestOpenAPI3AdminSwaggerGen(t *testing.T) {
@@ -47,14 +47,15 @@ type Route struct {
 	JSONInputMask []string
 	// JSONInputSchema is a custom schema definition, for the case where the auto-gen + mask isn't good enough
 	JSONInputSchema string
-	// JSONOutputValue is a function that returns a pointer to a structure to take JSON output
+	// JSONOutputValue is a function that returns a pointer to a structure to take JSON output.
+	// Return an empty []byte for routes whose handler streams binary output via an io.ReadCloser
 	JSONOutputValue func() interface{}
 	// JSONOutputCode is the success response code
 	JSONOutputCode int
 	// JSONHandler is a function for handling JSON content type input. Input/Ouptut objects are returned by JSONInputValue/JSONOutputValue funcs
-	JSONHandler func(r APIRequest) (output interface{}, err error)
+	JSONHandler func(r *APIRequest) (output interface{}, err error)
 	// FormUploadHandler takes a single file upload, and returns a JSON object
-	FormUploadHandler func(r APIRequest) (output interface{}, err error)
+	FormUploadHandler func(r *APIRequest) (output interface{}, err error)
 }
 
 // PathParam is a description of a path parameter
@@ -33,16 +33,16 @@ func (bc *boundCallbacks) TxSubmissionUpdate(txTrackingID string, txState blockc
 	return bc.ei.TxSubmissionUpdate(bc.bi, txTrackingID, txState, protocolTxID, errorMessage, additionalInfo)
 }
 
-func (bc *boundCallbacks) BatchPinComplete(batch *blockchain.BatchPin, signingIdentity string, protocolTxID string, additionalInfo fftypes.JSONObject) error {
-	return bc.ei.BatchPinComplete(bc.bi, batch, signingIdentity, protocolTxID, additionalInfo)
+func (bc *boundCallbacks) BatchPinComplete(ledgerID *fftypes.UUID, batch *blockchain.BatchPin, signingIdentity string, protocolTxID string, additionalInfo fftypes.JSONObject) error {
+	return bc.ei.BatchPinComplete(bc.bi, ledgerID, batch, signingIdentity, protocolTxID, additionalInfo)
 }
 
 func (bc *boundCallbacks) TransferResult(trackingID string, status fftypes.OpStatus, info string, additionalInfo fftypes.JSONObject) {
 	bc.ei.TransferResult(bc.dx, trackingID, status, info, additionalInfo)
 }
 
-func (bc *boundCallbacks) BLOBReceived(peerID string, ns string, id fftypes.UUID) {
-	bc.ei.BLOBReceived(bc.dx, peerID, ns, id)
+func (bc *boundCallbacks) BLOBReceived(peerID string, hash fftypes.Bytes32, ns string, id fftypes.UUID) {
+	bc.ei.BLOBReceived(bc.dx, peerID, hash, ns, id)
 }
 
 func (bc *boundCallbacks) MessageReceived(peerID string, data []byte) {
@@ -36,10 +36,11 @@ func TestBoundBlockchainCallbacks(t *testing.T) {
 
 	info := fftypes.JSONObject{"hello": "world"}
 	batch := &blockchain.BatchPin{TransactionID: fftypes.NewUUID()}
+	ledgerID := fftypes.NewUUID()
 	id := fftypes.NewUUID()
 
-	mei.On("BatchPinComplete", mbi, batch, "0x12345", "tx12345", info).Return(fmt.Errorf("pop"))
-	err := bc.BatchPinComplete(batch, "0x12345", "tx12345", info)
+	mei.On("BatchPinComplete", mbi, ledgerID, batch, "0x12345", "tx12345", info).Return(fmt.Errorf("pop"))
+	err := bc.BatchPinComplete(ledgerID, batch, "0x12345", "tx12345", info)
 	assert.EqualError(t, err, "pop")
 
 	mei.On("TxSubmissionUpdate", mbi, "tracking12345", fftypes.OpStatusFailed, "tx12345", "error info", info).Return(fmt.Errorf("pop"))
@@ -49,8 +50,9 @@ func TestBoundBlockchainCallbacks(t *testing.T) {
 	mei.On("TransferResult", mdx, "tracking12345", fftypes.OpStatusFailed, "error info", info).Return()
 	bc.TransferResult("tracking12345", fftypes.OpStatusFailed, "error info", info)
 
-	mei.On("BLOBReceived", mdx, "peer1", "ns1", *id).Return()
-	bc.BLOBReceived("peer1", "ns1", *id)
+	hash := fftypes.NewRandB32()
+	mei.On("BLOBReceived", mdx, "peer1", *hash, "ns1", *id).Return()
+	bc.BLOBReceived("peer1", *hash, "ns1", *id)
 
 	mei.On("MessageReceived", mdx, "peer1", []byte{}).Return()
 	bc.MessageReceived("peer1", []byte{})
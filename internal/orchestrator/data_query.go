@@ -47,7 +47,18 @@ func (or *orchestrator) GetTransactionByID(ctx context.Context, ns, id string) (
 	if err != nil {
 		return nil, err
 	}
-	return or.database.GetTransactionByID(ctx, u)
+	tx, err := or.database.GetTransactionByID(ctx, u)
+	if err != nil || tx == nil {
+		return tx, err
+	}
+	// Add the per-member delivery breakdown, from the operations performed for the transaction
+	fb := database.OperationQueryFactory.NewFilter(ctx)
+	ops, _, err := or.database.GetOperations(ctx, fb.Eq("tx", u))
+	if err != nil {
+		return nil, err
+	}
+	_, tx.Delivery = tx.DeriveStatus(ops)
+	return tx, nil
 }
 
 func (or *orchestrator) GetTransactionOperations(ctx context.Context, ns, id string) ([]*fftypes.Operation, error) {
@@ -60,7 +71,8 @@ func (or *orchestrator) GetTransactionOperations(ctx context.Context, ns, id str
 		fb.Eq("tx", u),
 		fb.Eq("namespace", ns),
 	)
-	return or.database.GetOperations(ctx, filter)
+	ops, _, err := or.database.GetOperations(ctx, filter)
+	return ops, err
 }
 
 func (or *orchestrator) getMessageByID(ctx context.Context, ns, id string) (*fftypes.Message, error) {
@@ -153,7 +165,7 @@ func (or *orchestrator) GetEventByID(ctx context.Context, ns, id string) (*fftyp
 	return or.database.GetEventByID(ctx, u)
 }
 
-func (or *orchestrator) GetNamespaces(ctx context.Context, filter database.AndFilter) ([]*fftypes.Namespace, error) {
+func (or *orchestrator) GetNamespaces(ctx context.Context, filter database.AndFilter) ([]*fftypes.Namespace, *database.FilterResult, error) {
 	return or.database.GetNamespaces(ctx, filter)
 }
 
@@ -161,12 +173,12 @@ func (or *orchestrator) scopeNS(ns string, filter database.AndFilter) database.A
 	return filter.Condition(filter.Builder().Eq("namespace", ns))
 }
 
-func (or *orchestrator) GetTransactions(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Transaction, error) {
+func (or *orchestrator) GetTransactions(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Transaction, *database.FilterResult, error) {
 	filter = or.scopeNS(ns, filter)
 	return or.database.GetTransactions(ctx, filter)
 }
 
-func (or *orchestrator) GetMessages(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Message, error) {
+func (or *orchestrator) GetMessages(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Message, *database.FilterResult, error) {
 	filter = or.scopeNS(ns, filter)
 	return or.database.GetMessages(ctx, filter)
 }
@@ -221,13 +233,14 @@ func (or *orchestrator) GetMessageOperations(ctx context.Context, ns, id string)
 		return nil, err
 	}
 	filter := database.OperationQueryFactory.NewFilter(ctx).Eq("tx", txID)
-	return or.database.GetOperations(ctx, filter)
+	ops, _, err := or.database.GetOperations(ctx, filter)
+	return ops, err
 }
 
-func (or *orchestrator) GetMessageEvents(ctx context.Context, ns, id string, filter database.AndFilter) ([]*fftypes.Event, error) {
+func (or *orchestrator) GetMessageEvents(ctx context.Context, ns, id string, filter database.AndFilter) ([]*fftypes.Event, *database.FilterResult, error) {
 	msg, err := or.getMessageByID(ctx, ns, id)
 	if err != nil || msg == nil {
-		return nil, err
+		return nil, nil, err
 	}
 	// Events can refer to the message, or any data in the message
 	// So scope the event down to those referred UUIDs, in addition to any and conditions passed in
@@ -241,36 +254,49 @@ func (or *orchestrator) GetMessageEvents(ctx context.Context, ns, id string, fil
 	return or.database.GetEvents(ctx, filter)
 }
 
-func (or *orchestrator) GetBatches(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Batch, error) {
+func (or *orchestrator) GetBatches(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Batch, *database.FilterResult, error) {
 	filter = or.scopeNS(ns, filter)
 	return or.database.GetBatches(ctx, filter)
 }
 
-func (or *orchestrator) GetData(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Data, error) {
+func (or *orchestrator) GetData(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Data, *database.FilterResult, error) {
 	filter = or.scopeNS(ns, filter)
 	return or.database.GetData(ctx, filter)
 }
 
-func (or *orchestrator) GetMessagesForData(ctx context.Context, ns, dataID string, filter database.AndFilter) ([]*fftypes.Message, error) {
+func (or *orchestrator) GetMessagesForData(ctx context.Context, ns, dataID string, filter database.AndFilter) ([]*fftypes.Message, *database.FilterResult, error) {
 	filter = or.scopeNS(ns, filter)
 	u, err := or.verifyIDAndNamespace(ctx, ns, dataID)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	return or.database.GetMessagesForData(ctx, u, filter)
 }
 
-func (or *orchestrator) GetDatatypes(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Datatype, error) {
+func (or *orchestrator) GetMessagesForGroup(ctx context.Context, ns, groupHash string, filter database.AndFilter) ([]*fftypes.Message, *database.FilterResult, error) {
+	if err := or.verifyNamespaceSyntax(ctx, ns); err != nil {
+		return nil, nil, err
+	}
+	h, err := fftypes.ParseBytes32(ctx, groupHash)
+	if err != nil {
+		return nil, nil, err
+	}
+	filter = or.scopeNS(ns, filter)
+	filter = filter.Condition(filter.Builder().Eq("group", h))
+	return or.database.GetMessages(ctx, filter)
+}
+
+func (or *orchestrator) GetDatatypes(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Datatype, *database.FilterResult, error) {
 	filter = or.scopeNS(ns, filter)
 	return or.database.GetDatatypes(ctx, filter)
 }
 
-func (or *orchestrator) GetOperations(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Operation, error) {
+func (or *orchestrator) GetOperations(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Operation, *database.FilterResult, error) {
 	filter = or.scopeNS(ns, filter)
 	return or.database.GetOperations(ctx, filter)
 }
 
-func (or *orchestrator) GetEvents(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Event, error) {
+func (or *orchestrator) GetEvents(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Event, *database.FilterResult, error) {
 	filter = or.scopeNS(ns, filter)
 	return or.database.GetEvents(ctx, filter)
 }
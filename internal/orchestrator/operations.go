@@ -0,0 +1,206 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orchestrator
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/hyperledger-labs/firefly/internal/i18n"
+	"github.com/hyperledger-labs/firefly/internal/log"
+	"github.com/hyperledger-labs/firefly/pkg/blockchain"
+	"github.com/hyperledger-labs/firefly/pkg/database"
+	"github.com/hyperledger-labs/firefly/pkg/fftypes"
+)
+
+// RetryOperation re-runs the plugin action behind a failed operation, from the persisted batch.
+// The operation is updated in place with the new tracking ID, and the previous attempt is kept in its retry history.
+//
+// The operation is claimed by moving it from failed to pending before the plugin is called, so of two concurrent
+// requests to retry the same operation only one proceeds.
+func (or *orchestrator) RetryOperation(ctx context.Context, ns, id string) (*fftypes.Operation, error) {
+	op, err := or.getOperationForUpdate(ctx, ns, id)
+	if err != nil {
+		return nil, err
+	}
+	if op.Status != fftypes.OpStatusFailed {
+		return nil, i18n.NewError(ctx, i18n.MsgOperationNotFailed, op.ID, op.Status)
+	}
+
+	var retry func(ctx context.Context, op *fftypes.Operation) (string, error)
+	switch op.Type {
+	case fftypes.OpTypeDataExchangeBatchSend:
+		retry = or.retryBatchSend
+	case fftypes.OpTypeDataExchangeBlobSend:
+		retry = or.retryBlobSend
+	case fftypes.OpTypeBlockchainBatchPin:
+		retry = or.retryBatchPin
+	default:
+		return nil, i18n.NewError(ctx, i18n.MsgOperationRetryUnsupported, op.Type)
+	}
+
+	op.Retries = append(op.Retries, &fftypes.OperationRetry{
+		BackendID: op.BackendID,
+		Status:    op.Status,
+		Error:     op.Error,
+		Info:      op.Info,
+		Retried:   fftypes.Now(),
+	})
+	retries, _ := op.Retries.Value()
+	claim := database.OperationQueryFactory.NewUpdate(ctx).
+		Set("status", fftypes.OpStatusPending).
+		Set("backendid", "").
+		Set("error", "").
+		Set("info", nil).
+		Set("retries", retries)
+	claimed, err := or.database.UpdateOperationIfStatus(ctx, op.ID, []fftypes.OpStatus{fftypes.OpStatusFailed}, claim)
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		return nil, i18n.NewError(ctx, i18n.MsgOperationStatusChanged, op.ID)
+	}
+
+	backendID, retryErr := retry(ctx, op)
+	update := database.OperationQueryFactory.NewUpdate(ctx).Set("backendid", backendID)
+	if retryErr != nil {
+		// Record the failed attempt, so the operation can be retried again
+		update = update.Set("status", fftypes.OpStatusFailed).Set("error", retryErr.Error())
+	}
+	// If the operation was cancelled while the plugin was called, it stays cancelled
+	if _, err = or.database.UpdateOperationIfStatus(ctx, op.ID, []fftypes.OpStatus{fftypes.OpStatusPending}, update); err != nil {
+		return nil, err
+	}
+	if retryErr != nil {
+		return nil, retryErr
+	}
+	log.L(ctx).Infof("Retried operation %s (%s): backendID=%s previous=%s", op.ID, op.Type, backendID, op.BackendID)
+
+	op.BackendID = backendID
+	op.Status = fftypes.OpStatusPending
+	op.Error = ""
+	op.Info = nil
+	op.Updated = fftypes.Now()
+	return op, nil
+}
+
+// CancelOperation moves a pending or failed operation to cancelled, so that its transaction can reach a final status.
+// Any work already submitted to the plugin is not recalled, but later updates for it are ignored.
+func (or *orchestrator) CancelOperation(ctx context.Context, ns, id string) (*fftypes.Operation, error) {
+	op, err := or.getOperationForUpdate(ctx, ns, id)
+	if err != nil {
+		return nil, err
+	}
+	if op.Status != fftypes.OpStatusPending && op.Status != fftypes.OpStatusFailed {
+		return nil, i18n.NewError(ctx, i18n.MsgOperationNotCancellable, op.ID, op.Status)
+	}
+	if err = or.events.CancelOperation(ctx, op); err != nil {
+		return nil, err
+	}
+	log.L(ctx).Infof("Cancelled operation %s (%s) with status %s", op.ID, op.Type, op.Status)
+
+	op.Status = fftypes.OpStatusCancelled
+	op.Updated = fftypes.Now()
+	return op, nil
+}
+
+func (or *orchestrator) getOperationForUpdate(ctx context.Context, ns, id string) (*fftypes.Operation, error) {
+	u, err := or.verifyIDAndNamespace(ctx, ns, id)
+	if err != nil {
+		return nil, err
+	}
+	op, err := or.database.GetOperationByID(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+	if op == nil || op.Namespace != ns {
+		return nil, i18n.NewError(ctx, i18n.Msg404NotFound)
+	}
+	return op, nil
+}
+
+func (or *orchestrator) getOperationBatch(ctx context.Context, op *fftypes.Operation) (*fftypes.Batch, error) {
+	tx, err := or.database.GetTransactionByID(ctx, op.Transaction)
+	if err != nil {
+		return nil, err
+	}
+	if tx == nil {
+		return nil, i18n.NewError(ctx, i18n.Msg404NotFound)
+	}
+	batch, err := or.database.GetBatchByID(ctx, tx.Subject.Reference)
+	if err != nil {
+		return nil, err
+	}
+	if batch == nil {
+		return nil, i18n.NewError(ctx, i18n.Msg404NotFound)
+	}
+	// Restore the batch to the state it was in when it was first dispatched
+	batch.PayloadRef = nil
+	batch.Confirmed = nil
+	return batch, nil
+}
+
+func (or *orchestrator) getOperationNode(ctx context.Context, op *fftypes.Operation) (*fftypes.Node, error) {
+	nodeID, err := fftypes.ParseUUID(ctx, op.Member)
+	if err != nil {
+		return nil, err
+	}
+	node, err := or.database.GetNodeByID(ctx, nodeID)
+	if err != nil {
+		return nil, err
+	}
+	if node == nil {
+		return nil, i18n.NewError(ctx, i18n.Msg404NotFound)
+	}
+	return node, nil
+}
+
+func (or *orchestrator) retryBatchSend(ctx context.Context, op *fftypes.Operation) (string, error) {
+	node, err := or.getOperationNode(ctx, op)
+	if err != nil {
+		return "", err
+	}
+	batch, err := or.getOperationBatch(ctx, op)
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(batch)
+	if err != nil {
+		return "", i18n.WrapError(ctx, err, i18n.MsgSerializationFailed)
+	}
+	return or.dataexchange.SendMessage(ctx, node, payload)
+}
+
+func (or *orchestrator) retryBlobSend(ctx context.Context, op *fftypes.Operation) (string, error) {
+	dataID, err := fftypes.ParseUUID(ctx, op.Input.GetString("data"))
+	if err != nil {
+		return "", i18n.NewError(ctx, i18n.MsgOperationRetryNoInput, op.ID)
+	}
+	node, err := or.getOperationNode(ctx, op)
+	if err != nil {
+		return "", err
+	}
+	return or.dataexchange.TransferBLOB(ctx, node, op.Namespace, *dataID)
+}
+
+func (or *orchestrator) retryBatchPin(ctx context.Context, op *fftypes.Operation) (string, error) {
+	submission, ok := blockchain.BatchPinSubmissionFromOperation(op)
+	if !ok {
+		return "", i18n.NewError(ctx, i18n.MsgOperationRetryNoInput, op.ID)
+	}
+	return or.blockchain.SubmitBatchPin(ctx, submission.LedgerID, submission.Signer, submission.BatchPin)
+}
@@ -0,0 +1,414 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orchestrator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/hyperledger-labs/firefly/pkg/blockchain"
+	"github.com/hyperledger-labs/firefly/pkg/database"
+	"github.com/hyperledger-labs/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestFailedOp(opType fftypes.OpType) *fftypes.Operation {
+	return &fftypes.Operation{
+		ID:          fftypes.NewUUID(),
+		Namespace:   "ns1",
+		Transaction: fftypes.NewUUID(),
+		Type:        opType,
+		Member:      fftypes.NewUUID().String(),
+		Status:      fftypes.OpStatusFailed,
+		BackendID:   "tracking1",
+		Error:       "pop",
+		Info:        fftypes.JSONObject{"some": "info"},
+	}
+}
+
+func mockOperationBatch(or *testOrchestrator, op *fftypes.Operation) *fftypes.Batch {
+	batch := &fftypes.Batch{
+		ID:         fftypes.NewUUID(),
+		Namespace:  "ns1",
+		PayloadRef: fftypes.NewRandB32(),
+		Confirmed:  fftypes.Now(),
+	}
+	or.mdi.On("GetTransactionByID", mock.Anything, op.Transaction).Return(&fftypes.Transaction{
+		ID:      op.Transaction,
+		Subject: fftypes.TransactionSubject{Reference: batch.ID},
+	}, nil)
+	or.mdi.On("GetBatchByID", mock.Anything, batch.ID).Return(batch, nil)
+	return batch
+}
+
+func mockOperationNode(or *testOrchestrator, op *fftypes.Operation) *fftypes.Node {
+	node := &fftypes.Node{ID: fftypes.MustParseUUID(op.Member)}
+	or.mdi.On("GetNodeByID", mock.Anything, node.ID).Return(node, nil)
+	return node
+}
+
+func mockOperationClaim(or *testOrchestrator, op *fftypes.Operation) {
+	or.mdi.On("UpdateOperationIfStatus", mock.Anything, op.ID, []fftypes.OpStatus{fftypes.OpStatusFailed}, mock.Anything).Return(true, nil)
+	or.mdi.On("UpdateOperationIfStatus", mock.Anything, op.ID, []fftypes.OpStatus{fftypes.OpStatusPending}, mock.Anything).Return(true, nil)
+}
+
+// mockOperationRelease expects the operation to be claimed, then returned to failed after the retry fails
+func mockOperationRelease(or *testOrchestrator, op *fftypes.Operation) {
+	or.mdi.On("UpdateOperationIfStatus", mock.Anything, op.ID, []fftypes.OpStatus{fftypes.OpStatusFailed}, mock.Anything).Return(true, nil)
+	or.mdi.On("UpdateOperationIfStatus", mock.Anything, op.ID, []fftypes.OpStatus{fftypes.OpStatusPending}, mock.MatchedBy(func(u database.Update) bool {
+		info, _ := u.Finalize()
+		return strings.Contains(info.String(), "status='Failed'")
+	})).Return(true, nil)
+}
+
+func TestRetryOperationBatchSend(t *testing.T) {
+	or := newTestOrchestrator()
+	op := newTestFailedOp(fftypes.OpTypeDataExchangeBatchSend)
+	or.mdi.On("GetOperationByID", mock.Anything, op.ID).Return(op, nil)
+	node := mockOperationNode(or, op)
+	batch := mockOperationBatch(or, op)
+	or.mdx.On("SendMessage", mock.Anything, node, mock.MatchedBy(func(payload []byte) bool {
+		var sent fftypes.Batch
+		err := json.Unmarshal(payload, &sent)
+		return err == nil && *sent.ID == *batch.ID && sent.PayloadRef == nil && sent.Confirmed == nil
+	})).Return("tracking2", nil)
+	mockOperationClaim(or, op)
+
+	retried, err := or.RetryOperation(context.Background(), "ns1", op.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, "tracking2", retried.BackendID)
+	assert.Equal(t, fftypes.OpStatusPending, retried.Status)
+	assert.Empty(t, retried.Error)
+	assert.Nil(t, retried.Info)
+	assert.Len(t, retried.Retries, 1)
+	assert.Equal(t, "tracking1", retried.Retries[0].BackendID)
+	assert.Equal(t, fftypes.OpStatusFailed, retried.Retries[0].Status)
+	assert.Equal(t, "pop", retried.Retries[0].Error)
+	assert.Equal(t, "info", retried.Retries[0].Info.GetString("some"))
+
+	or.mdi.AssertExpectations(t)
+	or.mdx.AssertExpectations(t)
+}
+
+func TestRetryOperationBadID(t *testing.T) {
+	or := newTestOrchestrator()
+	_, err := or.RetryOperation(context.Background(), "ns1", "!uuid")
+	assert.Regexp(t, "FF10142", err)
+}
+
+func TestRetryOperationGetFail(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mdi.On("GetOperationByID", mock.Anything, mock.Anything).Return(nil, fmt.Errorf("pop"))
+	_, err := or.RetryOperation(context.Background(), "ns1", fftypes.NewUUID().String())
+	assert.EqualError(t, err, "pop")
+}
+
+func TestRetryOperationNotFound(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mdi.On("GetOperationByID", mock.Anything, mock.Anything).Return(nil, nil)
+	_, err := or.RetryOperation(context.Background(), "ns1", fftypes.NewUUID().String())
+	assert.Regexp(t, "FF10109", err)
+}
+
+func TestRetryOperationWrongNamespace(t *testing.T) {
+	or := newTestOrchestrator()
+	op := newTestFailedOp(fftypes.OpTypeDataExchangeBatchSend)
+	or.mdi.On("GetOperationByID", mock.Anything, op.ID).Return(op, nil)
+	_, err := or.RetryOperation(context.Background(), "ns2", op.ID.String())
+	assert.Regexp(t, "FF10109", err)
+}
+
+func TestRetryOperationNotFailed(t *testing.T) {
+	or := newTestOrchestrator()
+	op := newTestFailedOp(fftypes.OpTypeDataExchangeBatchSend)
+	op.Status = fftypes.OpStatusPending
+	or.mdi.On("GetOperationByID", mock.Anything, op.ID).Return(op, nil)
+	_, err := or.RetryOperation(context.Background(), "ns1", op.ID.String())
+	assert.Regexp(t, "FF10274", err)
+}
+
+func TestRetryOperationUnsupported(t *testing.T) {
+	or := newTestOrchestrator()
+	op := newTestFailedOp("unknown")
+	or.mdi.On("GetOperationByID", mock.Anything, op.ID).Return(op, nil)
+	_, err := or.RetryOperation(context.Background(), "ns1", op.ID.String())
+	assert.Regexp(t, "FF10275", err)
+}
+
+func TestRetryOperationClaimFail(t *testing.T) {
+	or := newTestOrchestrator()
+	op := newTestFailedOp(fftypes.OpTypeDataExchangeBatchSend)
+	or.mdi.On("GetOperationByID", mock.Anything, op.ID).Return(op, nil)
+	or.mdi.On("UpdateOperationIfStatus", mock.Anything, op.ID, []fftypes.OpStatus{fftypes.OpStatusFailed}, mock.Anything).Return(false, fmt.Errorf("pop"))
+
+	_, err := or.RetryOperation(context.Background(), "ns1", op.ID.String())
+	assert.EqualError(t, err, "pop")
+}
+
+func TestRetryOperationClaimedConcurrently(t *testing.T) {
+	or := newTestOrchestrator()
+	op := newTestFailedOp(fftypes.OpTypeDataExchangeBatchSend)
+	or.mdi.On("GetOperationByID", mock.Anything, op.ID).Return(op, nil)
+	or.mdi.On("UpdateOperationIfStatus", mock.Anything, op.ID, []fftypes.OpStatus{fftypes.OpStatusFailed}, mock.Anything).Return(false, nil)
+
+	_, err := or.RetryOperation(context.Background(), "ns1", op.ID.String())
+	assert.Regexp(t, "FF10298", err)
+	or.mdx.AssertNotCalled(t, "SendMessage", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestRetryOperationUpdateFail(t *testing.T) {
+	or := newTestOrchestrator()
+	op := newTestFailedOp(fftypes.OpTypeDataExchangeBatchSend)
+	or.mdi.On("GetOperationByID", mock.Anything, op.ID).Return(op, nil)
+	node := mockOperationNode(or, op)
+	mockOperationBatch(or, op)
+	or.mdx.On("SendMessage", mock.Anything, node, mock.Anything).Return("tracking2", nil)
+	or.mdi.On("UpdateOperationIfStatus", mock.Anything, op.ID, []fftypes.OpStatus{fftypes.OpStatusFailed}, mock.Anything).Return(true, nil)
+	or.mdi.On("UpdateOperationIfStatus", mock.Anything, op.ID, []fftypes.OpStatus{fftypes.OpStatusPending}, mock.Anything).Return(false, fmt.Errorf("pop"))
+
+	_, err := or.RetryOperation(context.Background(), "ns1", op.ID.String())
+	assert.EqualError(t, err, "pop")
+}
+
+func TestRetryOperationBatchPublishUnsupported(t *testing.T) {
+	or := newTestOrchestrator()
+	op := newTestFailedOp(fftypes.OpTypePublicStorageBatchBroadcast)
+	or.mdi.On("GetOperationByID", mock.Anything, op.ID).Return(op, nil)
+	_, err := or.RetryOperation(context.Background(), "ns1", op.ID.String())
+	assert.Regexp(t, "FF10275", err)
+}
+
+func TestRetryOperationBatchSendFail(t *testing.T) {
+	or := newTestOrchestrator()
+	op := newTestFailedOp(fftypes.OpTypeDataExchangeBatchSend)
+	or.mdi.On("GetOperationByID", mock.Anything, op.ID).Return(op, nil)
+	mockOperationRelease(or, op)
+	node := mockOperationNode(or, op)
+	mockOperationBatch(or, op)
+	or.mdx.On("SendMessage", mock.Anything, node, mock.Anything).Return("", fmt.Errorf("pop"))
+
+	_, err := or.RetryOperation(context.Background(), "ns1", op.ID.String())
+	assert.EqualError(t, err, "pop")
+}
+
+func TestRetryOperationBatchSendBadMember(t *testing.T) {
+	or := newTestOrchestrator()
+	op := newTestFailedOp(fftypes.OpTypeDataExchangeBatchSend)
+	op.Member = "!uuid"
+	or.mdi.On("GetOperationByID", mock.Anything, op.ID).Return(op, nil)
+	mockOperationRelease(or, op)
+
+	_, err := or.RetryOperation(context.Background(), "ns1", op.ID.String())
+	assert.Regexp(t, "FF10142", err)
+}
+
+func TestRetryOperationBatchSendGetNodeFail(t *testing.T) {
+	or := newTestOrchestrator()
+	op := newTestFailedOp(fftypes.OpTypeDataExchangeBatchSend)
+	or.mdi.On("GetOperationByID", mock.Anything, op.ID).Return(op, nil)
+	mockOperationRelease(or, op)
+	or.mdi.On("GetNodeByID", mock.Anything, mock.Anything).Return(nil, fmt.Errorf("pop"))
+
+	_, err := or.RetryOperation(context.Background(), "ns1", op.ID.String())
+	assert.EqualError(t, err, "pop")
+}
+
+func TestRetryOperationBatchSendNodeNotFound(t *testing.T) {
+	or := newTestOrchestrator()
+	op := newTestFailedOp(fftypes.OpTypeDataExchangeBatchSend)
+	or.mdi.On("GetOperationByID", mock.Anything, op.ID).Return(op, nil)
+	mockOperationRelease(or, op)
+	or.mdi.On("GetNodeByID", mock.Anything, mock.Anything).Return(nil, nil)
+
+	_, err := or.RetryOperation(context.Background(), "ns1", op.ID.String())
+	assert.Regexp(t, "FF10109", err)
+}
+
+func TestRetryOperationBatchSendGetTransactionFail(t *testing.T) {
+	or := newTestOrchestrator()
+	op := newTestFailedOp(fftypes.OpTypeDataExchangeBatchSend)
+	or.mdi.On("GetOperationByID", mock.Anything, op.ID).Return(op, nil)
+	mockOperationRelease(or, op)
+	mockOperationNode(or, op)
+	or.mdi.On("GetTransactionByID", mock.Anything, op.Transaction).Return(nil, fmt.Errorf("pop"))
+
+	_, err := or.RetryOperation(context.Background(), "ns1", op.ID.String())
+	assert.EqualError(t, err, "pop")
+}
+
+func TestRetryOperationBatchSendTransactionNotFound(t *testing.T) {
+	or := newTestOrchestrator()
+	op := newTestFailedOp(fftypes.OpTypeDataExchangeBatchSend)
+	or.mdi.On("GetOperationByID", mock.Anything, op.ID).Return(op, nil)
+	mockOperationRelease(or, op)
+	mockOperationNode(or, op)
+	or.mdi.On("GetTransactionByID", mock.Anything, op.Transaction).Return(nil, nil)
+
+	_, err := or.RetryOperation(context.Background(), "ns1", op.ID.String())
+	assert.Regexp(t, "FF10109", err)
+}
+
+func TestRetryOperationBatchSendGetBatchFail(t *testing.T) {
+	or := newTestOrchestrator()
+	op := newTestFailedOp(fftypes.OpTypeDataExchangeBatchSend)
+	or.mdi.On("GetOperationByID", mock.Anything, op.ID).Return(op, nil)
+	mockOperationRelease(or, op)
+	mockOperationNode(or, op)
+	or.mdi.On("GetTransactionByID", mock.Anything, op.Transaction).Return(&fftypes.Transaction{}, nil)
+	or.mdi.On("GetBatchByID", mock.Anything, mock.Anything).Return(nil, fmt.Errorf("pop"))
+
+	_, err := or.RetryOperation(context.Background(), "ns1", op.ID.String())
+	assert.EqualError(t, err, "pop")
+}
+
+func TestRetryOperationBatchSendBatchNotFound(t *testing.T) {
+	or := newTestOrchestrator()
+	op := newTestFailedOp(fftypes.OpTypeDataExchangeBatchSend)
+	or.mdi.On("GetOperationByID", mock.Anything, op.ID).Return(op, nil)
+	mockOperationRelease(or, op)
+	mockOperationNode(or, op)
+	or.mdi.On("GetTransactionByID", mock.Anything, op.Transaction).Return(&fftypes.Transaction{}, nil)
+	or.mdi.On("GetBatchByID", mock.Anything, mock.Anything).Return(nil, nil)
+
+	_, err := or.RetryOperation(context.Background(), "ns1", op.ID.String())
+	assert.Regexp(t, "FF10109", err)
+}
+
+func TestRetryOperationBatchSendBadPayload(t *testing.T) {
+	or := newTestOrchestrator()
+	op := newTestFailedOp(fftypes.OpTypeDataExchangeBatchSend)
+	or.mdi.On("GetOperationByID", mock.Anything, op.ID).Return(op, nil)
+	mockOperationRelease(or, op)
+	mockOperationNode(or, op)
+	batch := mockOperationBatch(or, op)
+	batch.Payload.Data = []*fftypes.Data{{Value: fftypes.Byteable("!json")}}
+
+	_, err := or.RetryOperation(context.Background(), "ns1", op.ID.String())
+	assert.Regexp(t, "FF10137", err)
+}
+
+func TestRetryOperationBlobSend(t *testing.T) {
+	or := newTestOrchestrator()
+	op := newTestFailedOp(fftypes.OpTypeDataExchangeBlobSend)
+	dataID := fftypes.NewUUID()
+	op.Input = fftypes.JSONObject{"data": dataID.String()}
+	or.mdi.On("GetOperationByID", mock.Anything, op.ID).Return(op, nil)
+	node := mockOperationNode(or, op)
+	or.mdx.On("TransferBLOB", mock.Anything, node, "ns1", *dataID).Return("tracking2", nil)
+	mockOperationClaim(or, op)
+
+	retried, err := or.RetryOperation(context.Background(), "ns1", op.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, "tracking2", retried.BackendID)
+	assert.Equal(t, fftypes.OpStatusPending, retried.Status)
+}
+
+func TestRetryOperationBlobSendNoInput(t *testing.T) {
+	or := newTestOrchestrator()
+	op := newTestFailedOp(fftypes.OpTypeDataExchangeBlobSend)
+	or.mdi.On("GetOperationByID", mock.Anything, op.ID).Return(op, nil)
+	mockOperationRelease(or, op)
+
+	_, err := or.RetryOperation(context.Background(), "ns1", op.ID.String())
+	assert.Regexp(t, "FF10276", err)
+}
+
+func TestRetryOperationBlobSendNodeFail(t *testing.T) {
+	or := newTestOrchestrator()
+	op := newTestFailedOp(fftypes.OpTypeDataExchangeBlobSend)
+	op.Input = fftypes.JSONObject{"data": fftypes.NewUUID().String()}
+	or.mdi.On("GetOperationByID", mock.Anything, op.ID).Return(op, nil)
+	mockOperationRelease(or, op)
+	or.mdi.On("GetNodeByID", mock.Anything, mock.Anything).Return(nil, fmt.Errorf("pop"))
+
+	_, err := or.RetryOperation(context.Background(), "ns1", op.ID.String())
+	assert.EqualError(t, err, "pop")
+}
+
+func TestRetryOperationBatchPin(t *testing.T) {
+	or := newTestOrchestrator()
+	op := newTestFailedOp(fftypes.OpTypeBlockchainBatchPin)
+	submission := &blockchain.BatchPinSubmission{
+		Signer: &fftypes.Identity{OnChain: "0x12345"},
+		BatchPin: &blockchain.BatchPin{
+			Namespace:     "ns1",
+			TransactionID: op.Transaction,
+			BatchID:       fftypes.NewUUID(),
+		},
+	}
+	op.Input = submission.OperationInput()
+	or.mdi.On("GetOperationByID", mock.Anything, op.ID).Return(op, nil)
+	or.mbi.On("SubmitBatchPin", mock.Anything, (*fftypes.UUID)(nil), submission.Signer, submission.BatchPin).Return("tracking2", nil)
+	mockOperationClaim(or, op)
+
+	retried, err := or.RetryOperation(context.Background(), "ns1", op.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, "tracking2", retried.BackendID)
+}
+
+func TestRetryOperationBatchPinNoInput(t *testing.T) {
+	or := newTestOrchestrator()
+	op := newTestFailedOp(fftypes.OpTypeBlockchainBatchPin)
+	or.mdi.On("GetOperationByID", mock.Anything, op.ID).Return(op, nil)
+	mockOperationRelease(or, op)
+
+	_, err := or.RetryOperation(context.Background(), "ns1", op.ID.String())
+	assert.Regexp(t, "FF10276", err)
+}
+
+func TestCancelOperation(t *testing.T) {
+	or := newTestOrchestrator()
+	op := newTestFailedOp(fftypes.OpTypeDataExchangeBatchSend)
+	or.mdi.On("GetOperationByID", mock.Anything, op.ID).Return(op, nil)
+	or.mem.On("CancelOperation", mock.Anything, op).Return(nil)
+
+	cancelled, err := or.CancelOperation(context.Background(), "ns1", op.ID.String())
+	assert.NoError(t, err)
+	assert.Equal(t, fftypes.OpStatusCancelled, cancelled.Status)
+
+	or.mem.AssertExpectations(t)
+}
+
+func TestCancelOperationNotFound(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mdi.On("GetOperationByID", mock.Anything, mock.Anything).Return(nil, nil)
+	_, err := or.CancelOperation(context.Background(), "ns1", fftypes.NewUUID().String())
+	assert.Regexp(t, "FF10109", err)
+}
+
+func TestCancelOperationSucceeded(t *testing.T) {
+	or := newTestOrchestrator()
+	op := newTestFailedOp(fftypes.OpTypeDataExchangeBatchSend)
+	op.Status = fftypes.OpStatusSucceeded
+	or.mdi.On("GetOperationByID", mock.Anything, op.ID).Return(op, nil)
+	_, err := or.CancelOperation(context.Background(), "ns1", op.ID.String())
+	assert.Regexp(t, "FF10297", err)
+}
+
+func TestCancelOperationFail(t *testing.T) {
+	or := newTestOrchestrator()
+	op := newTestFailedOp(fftypes.OpTypeDataExchangeBatchSend)
+	op.Status = fftypes.OpStatusPending
+	or.mdi.On("GetOperationByID", mock.Anything, op.ID).Return(op, nil)
+	or.mem.On("CancelOperation", mock.Anything, op).Return(fmt.Errorf("pop"))
+	_, err := or.CancelOperation(context.Background(), "ns1", op.ID.String())
+	assert.EqualError(t, err, "pop")
+}
@@ -19,6 +19,7 @@ package orchestrator
 import (
 	"context"
 	"fmt"
+	"sync"
 
 	"github.com/hyperledger-labs/firefly/internal/batch"
 	"github.com/hyperledger-labs/firefly/internal/blockchain/bifactory"
@@ -30,6 +31,7 @@ import (
 	"github.com/hyperledger-labs/firefly/internal/events"
 	"github.com/hyperledger-labs/firefly/internal/i18n"
 	"github.com/hyperledger-labs/firefly/internal/identity/iifactory"
+	"github.com/hyperledger-labs/firefly/internal/leader"
 	"github.com/hyperledger-labs/firefly/internal/log"
 	"github.com/hyperledger-labs/firefly/internal/networkmap"
 	"github.com/hyperledger-labs/firefly/internal/privatemessaging"
@@ -63,40 +65,51 @@ type Orchestrator interface {
 
 	// Status
 	GetStatus(ctx context.Context) (*fftypes.NodeStatus, error)
+	GetBlockchainStatus(ctx context.Context) (fftypes.JSONObject, error)
+
+	// Request/reply messaging
+	RequestReply(ctx context.Context, ns string, request *fftypes.MessageInput) (*fftypes.MessageInput, error)
+	SendReply(ctx context.Context, ns, id string, reply *fftypes.MessageInput) (*fftypes.Message, error)
+	GetMessageReplies(ctx context.Context, ns, id string, filter database.AndFilter) ([]*fftypes.Message, *database.FilterResult, error)
 
 	// Subscription management
-	GetSubscriptions(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Subscription, error)
+	GetSubscriptions(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Subscription, *database.FilterResult, error)
 	GetSubscriptionByID(ctx context.Context, ns, id string) (*fftypes.Subscription, error)
 	CreateSubscription(ctx context.Context, ns string, subDef *fftypes.Subscription) (*fftypes.Subscription, error)
 	DeleteSubscription(ctx context.Context, ns, id string) error
 
+	// Operation management
+	RetryOperation(ctx context.Context, ns, id string) (*fftypes.Operation, error)
+	CancelOperation(ctx context.Context, ns, id string) (*fftypes.Operation, error)
+
 	// Data Query
 	GetNamespace(ctx context.Context, ns string) (*fftypes.Namespace, error)
-	GetNamespaces(ctx context.Context, filter database.AndFilter) ([]*fftypes.Namespace, error)
+	GetNamespaces(ctx context.Context, filter database.AndFilter) ([]*fftypes.Namespace, *database.FilterResult, error)
 	GetTransactionByID(ctx context.Context, ns, id string) (*fftypes.Transaction, error)
 	GetTransactionOperations(ctx context.Context, ns, id string) ([]*fftypes.Operation, error)
-	GetTransactions(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Transaction, error)
+	GetTransactions(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Transaction, *database.FilterResult, error)
 	GetMessageByID(ctx context.Context, ns, id string, withValues bool) (*fftypes.MessageInput, error)
-	GetMessages(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Message, error)
+	GetMessages(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Message, *database.FilterResult, error)
 	GetMessageTransaction(ctx context.Context, ns, id string) (*fftypes.Transaction, error)
 	GetMessageOperations(ctx context.Context, ns, id string) ([]*fftypes.Operation, error)
-	GetMessageEvents(ctx context.Context, ns, id string, filter database.AndFilter) ([]*fftypes.Event, error)
+	GetMessageEvents(ctx context.Context, ns, id string, filter database.AndFilter) ([]*fftypes.Event, *database.FilterResult, error)
 	GetMessageData(ctx context.Context, ns, id string) ([]*fftypes.Data, error)
-	GetMessagesForData(ctx context.Context, ns, dataID string, filter database.AndFilter) ([]*fftypes.Message, error)
+	GetMessagesForData(ctx context.Context, ns, dataID string, filter database.AndFilter) ([]*fftypes.Message, *database.FilterResult, error)
+	GetMessagesForGroup(ctx context.Context, ns, groupHash string, filter database.AndFilter) ([]*fftypes.Message, *database.FilterResult, error)
 	GetBatchByID(ctx context.Context, ns, id string) (*fftypes.Batch, error)
-	GetBatches(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Batch, error)
+	GetBatches(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Batch, *database.FilterResult, error)
 	GetDataByID(ctx context.Context, ns, id string) (*fftypes.Data, error)
-	GetData(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Data, error)
+	GetData(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Data, *database.FilterResult, error)
 	GetDatatypeByID(ctx context.Context, ns, id string) (*fftypes.Datatype, error)
-	GetDatatypes(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Datatype, error)
+	GetDatatypes(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Datatype, *database.FilterResult, error)
 	GetOperationByID(ctx context.Context, ns, id string) (*fftypes.Operation, error)
-	GetOperations(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Operation, error)
+	GetOperations(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Operation, *database.FilterResult, error)
 	GetEventByID(ctx context.Context, ns, id string) (*fftypes.Event, error)
-	GetEvents(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Event, error)
+	GetEvents(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Event, *database.FilterResult, error)
 
 	// Config Managemnet
 	GetConfigRecord(ctx context.Context, key string) (*fftypes.ConfigRecord, error)
-	GetConfigRecords(ctx context.Context, filter database.AndFilter) ([]*fftypes.ConfigRecord, error)
+	GetConfigRecords(ctx context.Context, filter database.AndFilter) ([]*fftypes.ConfigRecord, *database.FilterResult, error)
 	PutConfigRecord(ctx context.Context, key string, configRecord fftypes.Byteable) (outputValue fftypes.Byteable, err error)
 	DeleteConfigRecord(ctx context.Context, key string) (err error)
 }
@@ -105,6 +118,8 @@ type orchestrator struct {
 	ctx           context.Context
 	cancelCtx     context.CancelFunc
 	started       bool
+	mux           sync.Mutex
+	leaderStarted bool
 	database      database.Plugin
 	blockchain    blockchain.Plugin
 	identity      identity.Plugin
@@ -116,6 +131,8 @@ type orchestrator struct {
 	broadcast     broadcast.Manager
 	messaging     privatemessaging.Manager
 	data          data.Manager
+	leader        leader.Elector
+	fence         *database.LeaseFence
 	bc            boundCallbacks
 }
 
@@ -149,28 +166,72 @@ func (or *orchestrator) Init(ctx context.Context, cancelCtx context.CancelFunc)
 }
 
 func (or *orchestrator) Start() error {
-	err := or.blockchain.Start()
+	err := or.events.Start()
 	if err == nil {
-		err = or.batch.Start()
+		err = or.broadcast.Start()
 	}
 	if err == nil {
-		err = or.events.Start()
+		// If we lose leadership, we restart the orchestrator to stop the singleton components and campaign again
+		err = or.leader.Start(or.startLeaderComponents, or.cancelCtx)
 	}
+	or.started = true
+	return err
+}
+
+// startLeaderComponents starts the components that only one instance sharing the database can run,
+// because they consume from a single offset or event stream
+func (or *orchestrator) startLeaderComponents() error {
+	err := or.trackPendingBatchPins()
 	if err == nil {
-		err = or.broadcast.Start()
+		err = or.blockchain.Start()
+	}
+	if err == nil {
+		err = or.batch.Start()
+	}
+	if err == nil {
+		err = or.events.StartAggregator()
 	}
 	if err == nil {
 		err = or.messaging.Start()
 	}
-	or.started = true
+	// We are called on the leader election goroutine
+	or.mux.Lock()
+	or.leaderStarted = true
+	or.mux.Unlock()
 	return err
 }
 
+// trackPendingBatchPins passes the blockchain plugin the batch pins we submitted that have not yet completed,
+// so plugins that track their own transactions can resume tracking them after a restart
+func (or *orchestrator) trackPendingBatchPins() error {
+	fb := database.OperationQueryFactory.NewFilter(or.ctx)
+	ops, _, err := or.database.GetOperations(or.ctx, fb.And(
+		fb.Eq("type", fftypes.OpTypeBlockchainBatchPin),
+		fb.Eq("status", fftypes.OpStatusPending),
+		fb.Eq("plugin", or.blockchain.Name()),
+	))
+	if err != nil {
+		return err
+	}
+	txTrackingIDs := make([]string, 0, len(ops))
+	for _, op := range ops {
+		if op.BackendID != "" {
+			txTrackingIDs = append(txTrackingIDs, op.BackendID)
+		}
+	}
+	or.blockchain.TrackPendingBatchPins(or.ctx, txTrackingIDs)
+	return nil
+}
+
 func (or *orchestrator) WaitStop() {
 	if !or.started {
 		return
 	}
-	if or.batch != nil {
+	or.leader.WaitStop()
+	or.mux.Lock()
+	leaderStarted := or.leaderStarted
+	or.mux.Unlock()
+	if or.batch != nil && leaderStarted {
 		or.batch.WaitStop()
 		or.batch = nil
 	}
@@ -216,7 +277,7 @@ func (or *orchestrator) initPlugins(ctx context.Context) (err error) {
 	// Read configuration from DB and merge with existing config
 	var configRecords []*fftypes.ConfigRecord
 	filter := database.ConfigRecordQueryFactory.NewFilter(ctx).And()
-	if configRecords, err = or.GetConfigRecords(ctx, filter); err != nil {
+	if configRecords, _, err = or.GetConfigRecords(ctx, filter); err != nil {
 		return err
 	}
 	if err = config.MergeConfig(configRecords); err != nil {
@@ -264,6 +325,13 @@ func (or *orchestrator) initPlugins(ctx context.Context) (err error) {
 
 func (or *orchestrator) initComponents(ctx context.Context) (err error) {
 
+	// The components that only run on the leader make their writes under the fence of the leader lease,
+	// so the database rejects them if another instance takes over
+	if or.fence == nil {
+		or.fence = &database.LeaseFence{}
+	}
+	leaderCtx := database.WithLeaseFence(ctx, or.fence)
+
 	if or.data == nil {
 		or.data, err = data.NewDataManager(ctx, or.database, or.dataexchange)
 		if err != nil {
@@ -272,7 +340,7 @@ func (or *orchestrator) initComponents(ctx context.Context) (err error) {
 	}
 
 	if or.batch == nil {
-		or.batch, err = batch.NewBatchManager(ctx, or.database, or.data)
+		or.batch, err = batch.NewBatchManager(leaderCtx, or.database, or.data)
 		if err != nil {
 			return err
 		}
@@ -291,7 +359,7 @@ func (or *orchestrator) initComponents(ctx context.Context) (err error) {
 	}
 
 	if or.events == nil {
-		or.events, err = events.NewEventManager(ctx, or.publicstorage, or.database, or.identity, or.broadcast, or.messaging, or.data)
+		or.events, err = events.NewEventManager(leaderCtx, or.publicstorage, or.database, or.identity, or.broadcast, or.messaging, or.data)
 		if err != nil {
 			return err
 		}
@@ -304,6 +372,13 @@ func (or *orchestrator) initComponents(ctx context.Context) (err error) {
 		}
 	}
 
+	if or.leader == nil {
+		or.leader, err = leader.NewElector(ctx, or.database, or.fence)
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
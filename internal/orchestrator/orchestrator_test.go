@@ -30,6 +30,7 @@ import (
 	"github.com/hyperledger-labs/firefly/mocks/datamocks"
 	"github.com/hyperledger-labs/firefly/mocks/eventmocks"
 	"github.com/hyperledger-labs/firefly/mocks/identitymocks"
+	"github.com/hyperledger-labs/firefly/mocks/leadermocks"
 	"github.com/hyperledger-labs/firefly/mocks/networkmapmocks"
 	"github.com/hyperledger-labs/firefly/mocks/privatemessagingmocks"
 	"github.com/hyperledger-labs/firefly/mocks/publicstoragemocks"
@@ -54,6 +55,7 @@ type testOrchestrator struct {
 	mbi *blockchainmocks.Plugin
 	mii *identitymocks.Plugin
 	mdx *dataexchangemocks.Plugin
+	mle *leadermocks.Elector
 }
 
 func newTestOrchestrator() *testOrchestrator {
@@ -72,6 +74,7 @@ func newTestOrchestrator() *testOrchestrator {
 		mbi: &blockchainmocks.Plugin{},
 		mii: &identitymocks.Plugin{},
 		mdx: &dataexchangemocks.Plugin{},
+		mle: &leadermocks.Elector{},
 	}
 	tor.orchestrator.database = tor.mdi
 	tor.orchestrator.data = tor.mdm
@@ -84,6 +87,7 @@ func newTestOrchestrator() *testOrchestrator {
 	tor.orchestrator.blockchain = tor.mbi
 	tor.orchestrator.identity = tor.mii
 	tor.orchestrator.dataexchange = tor.mdx
+	tor.orchestrator.leader = tor.mle
 	tor.mdi.On("Name").Return("mock-di").Maybe()
 	tor.mem.On("Name").Return("mock-ei").Maybe()
 	tor.mps.On("Name").Return("mock-ps").Maybe()
@@ -118,7 +122,7 @@ func TestBadDatabaseInitFail(t *testing.T) {
 
 func TestBadIdentityPlugin(t *testing.T) {
 	or := newTestOrchestrator()
-	or.mdi.On("GetConfigRecords", mock.Anything, mock.Anything, mock.Anything).Return([]*fftypes.ConfigRecord{}, nil)
+	or.mdi.On("GetConfigRecords", mock.Anything, mock.Anything, mock.Anything).Return([]*fftypes.ConfigRecord{}, nil, nil)
 	config.Set(config.IdentityType, "wrong")
 	or.identity = nil
 	or.mdi.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
@@ -129,7 +133,7 @@ func TestBadIdentityPlugin(t *testing.T) {
 
 func TestBadIdentityInitFail(t *testing.T) {
 	or := newTestOrchestrator()
-	or.mdi.On("GetConfigRecords", mock.Anything, mock.Anything, mock.Anything).Return([]*fftypes.ConfigRecord{}, nil)
+	or.mdi.On("GetConfigRecords", mock.Anything, mock.Anything, mock.Anything).Return([]*fftypes.ConfigRecord{}, nil, nil)
 	or.mdi.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 	or.mii.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
 	ctx, cancelCtx := context.WithCancel(context.Background())
@@ -141,7 +145,7 @@ func TestBadBlockchainPlugin(t *testing.T) {
 	or := newTestOrchestrator()
 	config.Set(config.BlockchainType, "wrong")
 	or.blockchain = nil
-	or.mdi.On("GetConfigRecords", mock.Anything, mock.Anything, mock.Anything).Return([]*fftypes.ConfigRecord{}, nil)
+	or.mdi.On("GetConfigRecords", mock.Anything, mock.Anything, mock.Anything).Return([]*fftypes.ConfigRecord{}, nil, nil)
 	or.mdi.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 	or.mii.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 	ctx, cancelCtx := context.WithCancel(context.Background())
@@ -151,7 +155,7 @@ func TestBadBlockchainPlugin(t *testing.T) {
 
 func TestBlockchaiInitFail(t *testing.T) {
 	or := newTestOrchestrator()
-	or.mdi.On("GetConfigRecords", mock.Anything, mock.Anything, mock.Anything).Return([]*fftypes.ConfigRecord{}, nil)
+	or.mdi.On("GetConfigRecords", mock.Anything, mock.Anything, mock.Anything).Return([]*fftypes.ConfigRecord{}, nil, nil)
 	or.mii.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 	or.mdi.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 	or.mbi.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(fmt.Errorf("pop"))
@@ -162,7 +166,7 @@ func TestBlockchaiInitFail(t *testing.T) {
 
 func TestBlockchaiInitGetConfigRecordsFail(t *testing.T) {
 	or := newTestOrchestrator()
-	or.mdi.On("GetConfigRecords", mock.Anything, mock.Anything, mock.Anything).Return(nil, fmt.Errorf("pop"))
+	or.mdi.On("GetConfigRecords", mock.Anything, mock.Anything, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
 	or.mii.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 	or.mdi.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 	or.mbi.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
@@ -178,7 +182,7 @@ func TestBlockchaiInitMergeConfigRecordsFail(t *testing.T) {
 			Key:   "pizza.toppings",
 			Value: []byte("cheese, pepperoni, mushrooms"),
 		},
-	}, nil)
+	}, nil, nil)
 	or.mii.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 	or.mdi.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 	or.mbi.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
@@ -192,7 +196,7 @@ func TestBadPublicStoragePlugin(t *testing.T) {
 	or := newTestOrchestrator()
 	config.Set(config.PublicStorageType, "wrong")
 	or.publicstorage = nil
-	or.mdi.On("GetConfigRecords", mock.Anything, mock.Anything, mock.Anything).Return([]*fftypes.ConfigRecord{}, nil)
+	or.mdi.On("GetConfigRecords", mock.Anything, mock.Anything, mock.Anything).Return([]*fftypes.ConfigRecord{}, nil, nil)
 	or.mdi.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 	or.mbi.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 	or.mii.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
@@ -204,7 +208,7 @@ func TestBadPublicStoragePlugin(t *testing.T) {
 
 func TestBadPublicStorageInitFail(t *testing.T) {
 	or := newTestOrchestrator()
-	or.mdi.On("GetConfigRecords", mock.Anything, mock.Anything, mock.Anything).Return([]*fftypes.ConfigRecord{}, nil)
+	or.mdi.On("GetConfigRecords", mock.Anything, mock.Anything, mock.Anything).Return([]*fftypes.ConfigRecord{}, nil, nil)
 	or.mdi.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 	or.mbi.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 	or.mii.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
@@ -219,7 +223,7 @@ func TestBadDataExchangePlugin(t *testing.T) {
 	or := newTestOrchestrator()
 	config.Set(config.DataexchangeType, "wrong")
 	or.dataexchange = nil
-	or.mdi.On("GetConfigRecords", mock.Anything, mock.Anything, mock.Anything).Return([]*fftypes.ConfigRecord{}, nil)
+	or.mdi.On("GetConfigRecords", mock.Anything, mock.Anything, mock.Anything).Return([]*fftypes.ConfigRecord{}, nil, nil)
 	or.mdi.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 	or.mbi.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 	or.mii.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
@@ -232,7 +236,7 @@ func TestBadDataExchangePlugin(t *testing.T) {
 
 func TestBadPDataExchangeInitFail(t *testing.T) {
 	or := newTestOrchestrator()
-	or.mdi.On("GetConfigRecords", mock.Anything, mock.Anything, mock.Anything).Return([]*fftypes.ConfigRecord{}, nil)
+	or.mdi.On("GetConfigRecords", mock.Anything, mock.Anything, mock.Anything).Return([]*fftypes.ConfigRecord{}, nil, nil)
 	or.mdi.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 	or.mbi.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 	or.mii.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
@@ -268,6 +272,14 @@ func TestInitNetworkMapComponentFail(t *testing.T) {
 	assert.Regexp(t, "FF10128", err)
 }
 
+func TestInitLeaderComponentFail(t *testing.T) {
+	or := newTestOrchestrator()
+	or.database = nil
+	or.leader = nil
+	err := or.initComponents(context.Background())
+	assert.Regexp(t, "FF10128", err)
+}
+
 func TestInitBatchComponentFail(t *testing.T) {
 	or := newTestOrchestrator()
 	or.database = nil
@@ -292,21 +304,64 @@ func TestInitDataComponentFail(t *testing.T) {
 	assert.Regexp(t, "FF10128", err)
 }
 
+func (or *testOrchestrator) electImmediately() {
+	startLeader := or.mle.On("Start", mock.Anything, mock.Anything)
+	startLeader.RunFn = func(a mock.Arguments) {
+		startLeader.ReturnArguments = mock.Arguments{a[0].(func() error)()}
+	}
+	or.mle.On("WaitStop").Return()
+}
+
+func TestStartEventsFail(t *testing.T) {
+	config.Reset()
+	or := newTestOrchestrator()
+	or.mem.On("Start").Return(fmt.Errorf("pop"))
+	or.mle.On("WaitStop").Return()
+	or.mbm.On("WaitStop").Return()
+	err := or.Start()
+	assert.Regexp(t, "pop", err)
+	or.WaitStop()
+}
+
 func TestStartBatchFail(t *testing.T) {
 	config.Reset()
 	or := newTestOrchestrator()
+	or.electImmediately()
+	or.mem.On("Start").Return(nil)
+	or.mbm.On("Start").Return(nil)
 	or.mba.On("Start").Return(fmt.Errorf("pop"))
 	or.mbi.On("Start").Return(nil)
+	or.mdi.On("GetOperations", mock.Anything, mock.Anything).Return([]*fftypes.Operation{}, nil, nil)
+	or.mbi.On("TrackPendingBatchPins", mock.Anything, []string{}).Return()
 	err := or.Start()
 	assert.Regexp(t, "pop", err)
 }
 
+func TestStartTrackPendingBatchPinsFail(t *testing.T) {
+	config.Reset()
+	or := newTestOrchestrator()
+	or.electImmediately()
+	or.mem.On("Start").Return(nil)
+	or.mbm.On("Start").Return(nil)
+	or.mdi.On("GetOperations", mock.Anything, mock.Anything).Return(nil, nil, fmt.Errorf("pop"))
+	err := or.Start()
+	assert.Regexp(t, "pop", err)
+	or.mbi.AssertNotCalled(t, "Start")
+}
+
 func TestStartStopOk(t *testing.T) {
 	config.Reset()
 	or := newTestOrchestrator()
+	or.electImmediately()
+	or.mdi.On("GetOperations", mock.Anything, mock.Anything).Return([]*fftypes.Operation{
+		{BackendID: "0x12345"},
+		{BackendID: ""},
+	}, nil, nil)
+	or.mbi.On("TrackPendingBatchPins", mock.Anything, []string{"0x12345"}).Return()
 	or.mbi.On("Start").Return(nil)
 	or.mba.On("Start").Return(nil)
 	or.mem.On("Start").Return(nil)
+	or.mem.On("StartAggregator").Return(nil)
 	or.mbm.On("Start").Return(nil)
 	or.mpm.On("Start").Return(nil)
 	or.mbi.On("WaitStop").Return(nil)
@@ -315,8 +370,25 @@ func TestStartStopOk(t *testing.T) {
 	or.mbm.On("WaitStop").Return(nil)
 	err := or.Start()
 	assert.NoError(t, err)
+	assert.True(t, or.leaderStarted)
 	or.WaitStop()
 	or.WaitStop() // swallows dups
+	or.mba.AssertExpectations(t)
+}
+
+func TestStartStopFollower(t *testing.T) {
+	config.Reset()
+	or := newTestOrchestrator()
+	or.mem.On("Start").Return(nil)
+	or.mbm.On("Start").Return(nil)
+	or.mle.On("Start", mock.Anything, mock.Anything).Return(nil)
+	or.mle.On("WaitStop").Return()
+	or.mbm.On("WaitStop").Return(nil)
+	err := or.Start()
+	assert.NoError(t, err)
+	assert.False(t, or.leaderStarted)
+	or.WaitStop()
+	or.mba.AssertNotCalled(t, "WaitStop")
 }
 
 func TestInitNamespacesBadName(t *testing.T) {
@@ -383,7 +455,7 @@ func TestInitNamespacesDupName(t *testing.T) {
 
 func TestInitOK(t *testing.T) {
 	or := newTestOrchestrator()
-	or.mdi.On("GetConfigRecords", mock.Anything, mock.Anything, mock.Anything).Return([]*fftypes.ConfigRecord{}, nil)
+	or.mdi.On("GetConfigRecords", mock.Anything, mock.Anything, mock.Anything).Return([]*fftypes.ConfigRecord{}, nil, nil)
 	or.mdi.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 	or.mii.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 	or.mbi.On("Init", mock.Anything, mock.Anything, mock.Anything).Return(nil)
@@ -403,4 +475,5 @@ func TestInitOK(t *testing.T) {
 	assert.Equal(t, or.mem, or.Events())
 	assert.Equal(t, or.mnm, or.NetworkMap())
 	assert.Equal(t, or.mdm, or.Data())
+	assert.NotNil(t, or.fence)
 }
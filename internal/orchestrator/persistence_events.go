@@ -21,7 +21,10 @@ import (
 )
 
 func (or *orchestrator) MessageCreated(sequence int64) {
-	or.batch.NewMessages() <- sequence
+	// Only the leader runs the batch manager to consume the notification
+	if or.leader.IsLeader() {
+		or.batch.NewMessages() <- sequence
+	}
 }
 
 func (or *orchestrator) PinCreated(sequence int64) {
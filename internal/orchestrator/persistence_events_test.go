@@ -21,20 +21,36 @@ import (
 
 	"github.com/hyperledger-labs/firefly/mocks/batchmocks"
 	"github.com/hyperledger-labs/firefly/mocks/eventmocks"
+	"github.com/hyperledger-labs/firefly/mocks/leadermocks"
 	"github.com/hyperledger-labs/firefly/pkg/fftypes"
 )
 
 func TestMessageCreated(t *testing.T) {
 	mb := &batchmocks.Manager{}
+	mle := &leadermocks.Elector{}
 	o := &orchestrator{
-		batch: mb,
+		batch:  mb,
+		leader: mle,
 	}
 	c := make(chan int64, 1)
+	mle.On("IsLeader").Return(true)
 	mb.On("NewMessages").Return((chan<- int64)(c))
 	o.MessageCreated(12345)
 	mb.AssertExpectations(t)
 }
 
+func TestMessageCreatedFollower(t *testing.T) {
+	mb := &batchmocks.Manager{}
+	mle := &leadermocks.Elector{}
+	o := &orchestrator{
+		batch:  mb,
+		leader: mle,
+	}
+	mle.On("IsLeader").Return(false)
+	o.MessageCreated(12345)
+	mb.AssertExpectations(t)
+}
+
 func TestPinCreated(t *testing.T) {
 	mem := &eventmocks.EventManager{}
 	o := &orchestrator{
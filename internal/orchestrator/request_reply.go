@@ -0,0 +1,70 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orchestrator
+
+import (
+	"context"
+
+	"github.com/hyperledger-labs/firefly/internal/config"
+	"github.com/hyperledger-labs/firefly/internal/i18n"
+	"github.com/hyperledger-labs/firefly/pkg/database"
+	"github.com/hyperledger-labs/firefly/pkg/fftypes"
+)
+
+// RequestReply sends a private message, and waits for a confirmed reply correlated to it by its cid.
+// The reply is returned with its data values resolved.
+func (or *orchestrator) RequestReply(ctx context.Context, ns string, request *fftypes.MessageInput) (*fftypes.MessageInput, error) {
+	msg, err := or.messaging.SendMessage(ctx, ns, request)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, config.GetDuration(config.PrivateMessagingRequestTimeout))
+	defer cancel()
+	reply, err := or.events.WaitForReply(ctx, ns, msg.Header.ID)
+	if err != nil {
+		return nil, err
+	}
+	return or.GetMessageByID(ctx, ns, reply.Header.ID.String(), true)
+}
+
+// SendReply sends a private message in reply to an existing message. The reply is sent to the same
+// group, on the same topics, with its cid set to the ID of the original message.
+func (or *orchestrator) SendReply(ctx context.Context, ns, id string, reply *fftypes.MessageInput) (*fftypes.Message, error) {
+	request, err := or.getMessageByID(ctx, ns, id)
+	if err != nil {
+		return nil, err
+	}
+	if request.Header.Group == nil {
+		return nil, i18n.NewError(ctx, i18n.MsgReplyRequiresGroup, request.Header.ID)
+	}
+	reply.Header.CID = request.Header.ID
+	reply.Header.Group = request.Header.Group
+	reply.Header.Topics = request.Header.Topics
+	reply.Group = nil
+	return or.messaging.SendMessage(ctx, ns, reply)
+}
+
+func (or *orchestrator) GetMessageReplies(ctx context.Context, ns, id string, filter database.AndFilter) ([]*fftypes.Message, *database.FilterResult, error) {
+	u, err := or.verifyIDAndNamespace(ctx, ns, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	filter = or.scopeNS(ns, filter)
+	filter = filter.Condition(filter.Builder().Eq("cid", u))
+	return or.database.GetMessages(ctx, filter)
+}
@@ -0,0 +1,127 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger-labs/firefly/pkg/database"
+	"github.com/hyperledger-labs/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRequestReplyOk(t *testing.T) {
+	or := newTestOrchestrator()
+	requestID := fftypes.NewUUID()
+	replyID := fftypes.NewUUID()
+	or.mpm.On("SendMessage", mock.Anything, "ns1", mock.Anything).Return(&fftypes.Message{
+		Header: fftypes.MessageHeader{ID: requestID},
+	}, nil)
+	or.mem.On("WaitForReply", mock.Anything, "ns1", requestID).Return(&fftypes.Message{
+		Header: fftypes.MessageHeader{ID: replyID, CID: requestID},
+	}, nil)
+	or.mdi.On("GetMessageByID", mock.Anything, replyID).Return(&fftypes.Message{
+		Header: fftypes.MessageHeader{ID: replyID, CID: requestID},
+	}, nil)
+	or.mdm.On("GetMessageData", mock.Anything, mock.Anything, true).Return([]*fftypes.Data{}, true, nil)
+
+	reply, err := or.RequestReply(context.Background(), "ns1", &fftypes.MessageInput{})
+	assert.NoError(t, err)
+	assert.Equal(t, *requestID, *reply.Header.CID)
+}
+
+func TestRequestReplySendFail(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mpm.On("SendMessage", mock.Anything, "ns1", mock.Anything).Return(nil, fmt.Errorf("pop"))
+
+	_, err := or.RequestReply(context.Background(), "ns1", &fftypes.MessageInput{})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestRequestReplyWaitFail(t *testing.T) {
+	or := newTestOrchestrator()
+	requestID := fftypes.NewUUID()
+	or.mpm.On("SendMessage", mock.Anything, "ns1", mock.Anything).Return(&fftypes.Message{
+		Header: fftypes.MessageHeader{ID: requestID},
+	}, nil)
+	or.mem.On("WaitForReply", mock.Anything, "ns1", requestID).Return(nil, fmt.Errorf("pop"))
+
+	_, err := or.RequestReply(context.Background(), "ns1", &fftypes.MessageInput{})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestSendReplyOk(t *testing.T) {
+	or := newTestOrchestrator()
+	requestID := fftypes.NewUUID()
+	group := fftypes.NewRandB32()
+	or.mdi.On("GetMessageByID", mock.Anything, requestID).Return(&fftypes.Message{
+		Header: fftypes.MessageHeader{ID: requestID, Group: group, Topics: fftypes.FFNameArray{"topic1"}},
+	}, nil)
+	or.mpm.On("SendMessage", mock.Anything, "ns1", mock.MatchedBy(func(reply *fftypes.MessageInput) bool {
+		return reply.Header.CID.Equals(requestID) &&
+			reply.Header.Group.Equals(group) &&
+			reply.Header.Topics.String() == "topic1" &&
+			reply.Group == nil
+	})).Return(&fftypes.Message{}, nil)
+
+	_, err := or.SendReply(context.Background(), "ns1", requestID.String(), &fftypes.MessageInput{
+		Group: &fftypes.InputGroup{},
+	})
+	assert.NoError(t, err)
+	or.mpm.AssertExpectations(t)
+}
+
+func TestSendReplyNotFound(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mdi.On("GetMessageByID", mock.Anything, mock.Anything).Return(nil, nil)
+
+	_, err := or.SendReply(context.Background(), "ns1", fftypes.NewUUID().String(), &fftypes.MessageInput{})
+	assert.Regexp(t, "FF10109", err)
+}
+
+func TestSendReplyNotPrivate(t *testing.T) {
+	or := newTestOrchestrator()
+	requestID := fftypes.NewUUID()
+	or.mdi.On("GetMessageByID", mock.Anything, requestID).Return(&fftypes.Message{
+		Header: fftypes.MessageHeader{ID: requestID},
+	}, nil)
+
+	_, err := or.SendReply(context.Background(), "ns1", requestID.String(), &fftypes.MessageInput{})
+	assert.Regexp(t, "FF10253", err)
+}
+
+func TestGetMessageReplies(t *testing.T) {
+	or := newTestOrchestrator()
+	u := fftypes.NewUUID()
+	or.mdi.On("GetMessages", mock.Anything, mock.Anything).Return([]*fftypes.Message{}, nil, nil)
+	fb := database.MessageQueryFactory.NewFilter(context.Background())
+	f := fb.And(fb.Eq("topics", "topic1"))
+	_, _, err := or.GetMessageReplies(context.Background(), "ns1", u.String(), f)
+	assert.NoError(t, err)
+	fi, _ := f.Finalize()
+	assert.Contains(t, fi.String(), fmt.Sprintf("cid == '%s'", u))
+}
+
+func TestGetMessageRepliesBadID(t *testing.T) {
+	or := newTestOrchestrator()
+	fb := database.MessageQueryFactory.NewFilter(context.Background())
+	_, _, err := or.GetMessageReplies(context.Background(), "ns1", "!bad", fb.And())
+	assert.Regexp(t, "FF10142", err)
+}
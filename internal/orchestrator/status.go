@@ -36,6 +36,7 @@ func (or *orchestrator) GetStatus(ctx context.Context) (status *fftypes.NodeStat
 		Defaults: fftypes.NodeStatusDefaults{
 			Namespace: config.GetString(config.NamespacesDefault),
 		},
+		Leader: or.leader.Status(),
 	}
 
 	org, err := or.database.GetOrganizationByName(ctx, status.Org.Name)
@@ -60,3 +61,10 @@ func (or *orchestrator) GetStatus(ctx context.Context) (status *fftypes.NodeStat
 
 	return status, nil
 }
+
+func (or *orchestrator) GetBlockchainStatus(ctx context.Context) (fftypes.JSONObject, error) {
+	return fftypes.JSONObject{
+		"plugin": or.blockchain.Name(),
+		"status": or.blockchain.Status(),
+	}, nil
+}
@@ -48,9 +48,17 @@ func TestGetStatusRegistered(t *testing.T) {
 		Name:  "node1",
 		Owner: "0x1111111",
 	}, nil)
+	or.mle.On("Status").Return(&fftypes.NodeStatusLeader{
+		Enabled: true,
+		ID:      "instance1",
+		Leader:  true,
+		Holder:  "instance1",
+	})
 
 	status, err := or.GetStatus(or.ctx)
 	assert.NoError(t, err)
+	assert.True(t, status.Leader.Leader)
+	assert.Equal(t, "instance1", status.Leader.Holder)
 
 	assert.Equal(t, "default", status.Defaults.Namespace)
 
@@ -76,6 +84,8 @@ func TestGetStatusUnregistered(t *testing.T) {
 	mdi := or.database.(*databasemocks.Plugin)
 	mdi.On("GetOrganizationByName", or.ctx, "org1").Return(nil, nil)
 
+	or.mle.On("Status").Return(&fftypes.NodeStatusLeader{})
+
 	status, err := or.GetStatus(or.ctx)
 	assert.NoError(t, err)
 
@@ -106,6 +116,7 @@ func TestGetStatusOrgOnlyRegistered(t *testing.T) {
 		Name:     "org1",
 	}, nil)
 	mdi.On("GetNode", or.ctx, "0x1111111", "node1").Return(nil, nil)
+	or.mle.On("Status").Return(&fftypes.NodeStatusLeader{})
 	status, err := or.GetStatus(or.ctx)
 	assert.NoError(t, err)
 
@@ -131,6 +142,7 @@ func TestGetStatuOrgError(t *testing.T) {
 
 	mdi := or.database.(*databasemocks.Plugin)
 	mdi.On("GetOrganizationByName", or.ctx, "org1").Return(nil, fmt.Errorf("pop"))
+	or.mle.On("Status").Return(&fftypes.NodeStatusLeader{})
 	_, err := or.GetStatus(or.ctx)
 	assert.EqualError(t, err, "pop")
 }
@@ -152,6 +164,17 @@ func TestGetStatusNodeError(t *testing.T) {
 		Name:     "org1",
 	}, nil)
 	mdi.On("GetNode", or.ctx, "0x1111111", "node1").Return(nil, fmt.Errorf("pop"))
+	or.mle.On("Status").Return(&fftypes.NodeStatusLeader{})
 	_, err := or.GetStatus(or.ctx)
 	assert.EqualError(t, err, "pop")
 }
+
+func TestGetBlockchainStatus(t *testing.T) {
+	or := newTestOrchestrator()
+	or.mbi.On("Status").Return(fftypes.JSONObject{"ledgers": []interface{}{}})
+
+	status, err := or.GetBlockchainStatus(or.ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "mock-bi", status.GetString("plugin"))
+	assert.Equal(t, fftypes.JSONObject{"ledgers": []interface{}{}}, status["status"])
+}
@@ -26,22 +26,31 @@ import (
 	"github.com/hyperledger-labs/firefly/internal/log"
 	"github.com/hyperledger-labs/firefly/pkg/database"
 	"github.com/hyperledger-labs/firefly/pkg/fftypes"
+	"github.com/hyperledger-labs/firefly/pkg/identity"
 	"github.com/karlseguin/ccache"
 )
 
 type GroupManager interface {
-	GetGroupByID(ctx context.Context, id string) (*fftypes.Group, error)
-	GetGroups(ctx context.Context, filter database.AndFilter) ([]*fftypes.Group, error)
+	GetGroupByID(ctx context.Context, ns, id string) (*fftypes.GroupResolved, error)
+	GetGroups(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Group, *database.FilterResult, error)
+	GetGroupLineage(ctx context.Context, ns, hash string) ([]*fftypes.Group, error)
 	ResolveInitGroup(ctx context.Context, msg *fftypes.Message) (*fftypes.Group, error)
+	HandleGroupUpdate(ctx context.Context, msg *fftypes.Message, data []*fftypes.Data) (*fftypes.Group, error)
 }
 
 type groupManager struct {
 	database      database.Plugin
+	identity      identity.Plugin
 	data          data.Manager
 	groupCacheTTL time.Duration
 	groupCache    *ccache.Cache
 }
 
+type groupNodes struct {
+	group *fftypes.Group
+	nodes []*fftypes.Node
+}
+
 func (gm *groupManager) groupInit(ctx context.Context, signer *fftypes.Identity, group *fftypes.Group) (err error) {
 
 	// Serialize it into a data object, as a piece of data we can write to a message
@@ -91,8 +100,11 @@ func (gm *groupManager) groupInit(ctx context.Context, signer *fftypes.Identity,
 		},
 	}
 
-	// Seal the message
+	// Seal and sign the message
 	err = msg.Seal(ctx)
+	if err == nil {
+		msg.Signature, err = gm.identity.Sign(ctx, msg.Header.Author, msg.Hash)
+	}
 	if err == nil {
 		// Store the message - this asynchronously triggers the next step in process
 		err = gm.database.InsertMessageLocal(ctx, msg)
@@ -104,31 +116,70 @@ func (gm *groupManager) groupInit(ctx context.Context, signer *fftypes.Identity,
 
 }
 
-func (gm *groupManager) GetGroupByID(ctx context.Context, hash string) (*fftypes.Group, error) {
+func (gm *groupManager) GetGroupByID(ctx context.Context, ns, hash string) (*fftypes.GroupResolved, error) {
+	if err := fftypes.ValidateFFNameField(ctx, ns, "namespace"); err != nil {
+		return nil, err
+	}
 	h, err := fftypes.ParseBytes32(ctx, hash)
 	if err != nil {
 		return nil, err
 	}
-	return gm.database.GetGroupByHash(ctx, h)
+	group, err := gm.database.GetGroupByHash(ctx, h)
+	if err != nil {
+		return nil, err
+	}
+	if group == nil || group.Namespace != ns {
+		return nil, i18n.NewError(ctx, i18n.Msg404NotFound)
+	}
+
+	// Resolve the names of the orgs and nodes, as the group only contains the identities and IDs
+	resolved := &fftypes.GroupResolved{
+		Group:   *group,
+		Members: make([]*fftypes.MemberResolved, len(group.Members)),
+	}
+	for i, member := range group.Members {
+		rm := &fftypes.MemberResolved{Member: *member}
+		org, err := gm.database.GetOrganizationByIdentity(ctx, member.Identity)
+		if err != nil {
+			return nil, err
+		}
+		if org != nil {
+			rm.OrgName = org.Name
+		}
+		node, err := gm.database.GetNodeByID(ctx, member.Node)
+		if err != nil {
+			return nil, err
+		}
+		if node != nil {
+			rm.NodeName = node.Name
+		}
+		resolved.Members[i] = rm
+	}
+	return resolved, nil
 }
 
-func (gm *groupManager) GetGroups(ctx context.Context, filter database.AndFilter) ([]*fftypes.Group, error) {
+func (gm *groupManager) GetGroups(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Group, *database.FilterResult, error) {
+	if err := fftypes.ValidateFFNameField(ctx, ns, "namespace"); err != nil {
+		return nil, nil, err
+	}
+	filter = filter.Condition(filter.Builder().Eq("namespace", ns))
 	return gm.database.GetGroups(ctx, filter)
 }
 
-func (gm *groupManager) getGroupNodes(ctx context.Context, groupHash *fftypes.Bytes32) ([]*fftypes.Node, error) {
+func (gm *groupManager) getGroupNodes(ctx context.Context, groupHash *fftypes.Bytes32) (*fftypes.Group, []*fftypes.Node, error) {
 
 	if cached := gm.groupCache.Get(groupHash.String()); cached != nil {
 		cached.Extend(gm.groupCacheTTL)
-		return cached.Value().([]*fftypes.Node), nil
+		gn := cached.Value().(*groupNodes)
+		return gn.group, gn.nodes, nil
 	}
 
 	group, err := gm.database.GetGroupByHash(ctx, groupHash)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	if group == nil {
-		return nil, i18n.NewError(ctx, i18n.MsgGroupNotFound, groupHash)
+		return nil, nil, i18n.NewError(ctx, i18n.MsgGroupNotFound, groupHash)
 	}
 
 	// We de-duplicate nodes in the case that the payload needs to be received by multiple org identities
@@ -138,10 +189,10 @@ func (gm *groupManager) getGroupNodes(ctx context.Context, groupHash *fftypes.By
 	for _, r := range group.Members {
 		node, err := gm.database.GetNodeByID(ctx, r.Node)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		if node == nil {
-			return nil, i18n.NewError(ctx, i18n.MsgNodeNotFound, r.Node)
+			return nil, nil, i18n.NewError(ctx, i18n.MsgNodeNotFound, r.Node)
 		}
 		if !knownIDs[*node.ID] {
 			knownIDs[*node.ID] = true
@@ -149,13 +200,14 @@ func (gm *groupManager) getGroupNodes(ctx context.Context, groupHash *fftypes.By
 		}
 	}
 
-	gm.groupCache.Set(group.Hash.String(), nodes, gm.groupCacheTTL)
-	return nodes, nil
+	gm.groupCache.Set(group.Hash.String(), &groupNodes{group: group, nodes: nodes}, gm.groupCacheTTL)
+	return group, nodes, nil
 }
 
 // ResolveInitGroup is called when a message comes in as the first private message on a particular context.
 // If the message is a group creation request, then it is validated and the group is created.
 // Otherwise, the existing group must exist.
+// A group that succeeds another group is not ready, until the update that retires its predecessor is processed.
 //
 // Errors are only returned for database issues. For validation issues, a nil group is returned without an error.
 func (gm *groupManager) ResolveInitGroup(ctx context.Context, msg *fftypes.Message) (*fftypes.Group, error) {
@@ -181,6 +233,9 @@ func (gm *groupManager) ResolveInitGroup(ctx context.Context, msg *fftypes.Messa
 			log.L(ctx).Warnf("Group %s definition in message %s invalid: mismatched hash with message '%s'", msg.Header.Group, msg.Header.ID, newGroup.Hash)
 			return nil, nil
 		}
+		if ready, err := gm.handoverComplete(ctx, &newGroup); err != nil || !ready {
+			return nil, err
+		}
 		newGroup.Message = msg.Header.ID
 		err = gm.database.UpsertGroup(ctx, &newGroup, true)
 		if err != nil {
@@ -198,5 +253,8 @@ func (gm *groupManager) ResolveInitGroup(ctx context.Context, msg *fftypes.Messa
 		log.L(ctx).Warnf("Group %s not found for first message in context. type=%s namespace=%s", msg.Header.Group, msg.Header.Type, msg.Header.Namespace)
 		return nil, nil
 	}
+	if ready, err := gm.handoverComplete(ctx, group); err != nil || !ready {
+		return nil, err
+	}
 	return group, nil
 }
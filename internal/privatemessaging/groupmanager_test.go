@@ -23,6 +23,7 @@ import (
 
 	"github.com/hyperledger-labs/firefly/mocks/databasemocks"
 	"github.com/hyperledger-labs/firefly/mocks/datamocks"
+	"github.com/hyperledger-labs/firefly/mocks/identitymocks"
 	"github.com/hyperledger-labs/firefly/pkg/database"
 	"github.com/hyperledger-labs/firefly/pkg/fftypes"
 	"github.com/stretchr/testify/assert"
@@ -81,6 +82,33 @@ func TestGroupInitWriteDataFail(t *testing.T) {
 	assert.Regexp(t, "pop", err)
 }
 
+func TestGroupInitSignFail(t *testing.T) {
+
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	mii := &identitymocks.Plugin{}
+	pm.groupManager.identity = mii
+	mii.On("Sign", pm.ctx, "org1", mock.Anything).Return("", fmt.Errorf("pop"))
+
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("UpsertGroup", mock.Anything, mock.Anything, true).Return(nil)
+	mdi.On("UpsertData", mock.Anything, mock.Anything, true, false).Return(nil)
+
+	group := &fftypes.Group{
+		GroupIdentity: fftypes.GroupIdentity{
+			Namespace: "ns1",
+			Members: fftypes.Members{
+				{Identity: "id1", Node: fftypes.NewUUID()},
+			},
+		},
+	}
+	group.Seal()
+	err := pm.groupInit(pm.ctx, &fftypes.Identity{Identifier: "org1"}, group)
+	assert.Regexp(t, "pop", err)
+	mdi.AssertNotCalled(t, "InsertMessageLocal", mock.Anything, mock.Anything)
+}
+
 func TestResolveInitGroupMissingData(t *testing.T) {
 	pm, cancel := newTestPrivateMessaging(t)
 	defer cancel()
@@ -255,6 +283,57 @@ func TestResolveInitGroupNewOk(t *testing.T) {
 
 }
 
+func TestResolveInitGroupSuccessorNotReady(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	predecessor := newTestPredecessor()
+	successor := newTestSuccessor(predecessor)
+	b, _ := json.Marshal(&successor)
+
+	mdm := pm.data.(*datamocks.Manager)
+	mdm.On("GetMessageData", pm.ctx, mock.Anything, true).Return([]*fftypes.Data{
+		{ID: fftypes.NewUUID(), Value: fftypes.Byteable(b)},
+	}, true, nil)
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetGroupByHash", pm.ctx, predecessor.Hash).Return(predecessor, nil)
+
+	group, err := pm.ResolveInitGroup(pm.ctx, &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			ID:        fftypes.NewUUID(),
+			Namespace: "ns1",
+			Tag:       string(fftypes.SystemTagDefineGroup),
+			Group:     successor.Hash,
+			Author:    "localorg",
+		},
+	})
+	assert.NoError(t, err)
+	assert.Nil(t, group)
+	mdi.AssertExpectations(t)
+}
+
+func TestResolveInitGroupExistingSuccessorFail(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	predecessor := newTestPredecessor()
+	successor := newTestSuccessor(predecessor)
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetGroupByHash", pm.ctx, successor.Hash).Return(successor, nil)
+	mdi.On("GetGroupByHash", pm.ctx, predecessor.Hash).Return(nil, fmt.Errorf("pop"))
+
+	_, err := pm.ResolveInitGroup(pm.ctx, &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			ID:        fftypes.NewUUID(),
+			Namespace: "ns1",
+			Tag:       "mytag",
+			Group:     successor.Hash,
+			Author:    "localorg",
+		},
+	})
+	assert.EqualError(t, err, "pop")
+}
+
 func TestResolveInitGroupExistingOK(t *testing.T) {
 	pm, cancel := newTestPrivateMessaging(t)
 	defer cancel()
@@ -319,34 +398,130 @@ func TestGetGroupByIDOk(t *testing.T) {
 	defer cancel()
 
 	groupID := fftypes.NewRandB32()
+	node1 := fftypes.NewUUID()
+	node2 := fftypes.NewUUID()
 	mdi := pm.database.(*databasemocks.Plugin)
-	mdi.On("GetGroupByHash", pm.ctx, mock.Anything).Return(&fftypes.Group{Hash: groupID}, nil)
+	mdi.On("GetGroupByHash", pm.ctx, groupID).Return(&fftypes.Group{
+		Hash: groupID,
+		GroupIdentity: fftypes.GroupIdentity{
+			Namespace: "ns1",
+			Members: fftypes.Members{
+				{Identity: "org1", Node: node1},
+				{Identity: "org2", Node: node2},
+			},
+		},
+	}, nil)
+	mdi.On("GetOrganizationByIdentity", pm.ctx, "org1").Return(&fftypes.Organization{Name: "Org 1"}, nil)
+	mdi.On("GetOrganizationByIdentity", pm.ctx, "org2").Return(nil, nil)
+	mdi.On("GetNodeByID", pm.ctx, node1).Return(&fftypes.Node{Name: "node1"}, nil)
+	mdi.On("GetNodeByID", pm.ctx, node2).Return(nil, nil)
 
-	group, err := pm.GetGroupByID(pm.ctx, groupID.String())
+	group, err := pm.GetGroupByID(pm.ctx, "ns1", groupID.String())
 	assert.NoError(t, err)
 	assert.Equal(t, *groupID, *group.Hash)
+	assert.Equal(t, "org1", group.Members[0].Identity)
+	assert.Equal(t, "Org 1", group.Members[0].OrgName)
+	assert.Equal(t, "node1", group.Members[0].NodeName)
+	assert.Equal(t, *node2, *group.Members[1].Node)
+	assert.Empty(t, group.Members[1].OrgName)
+	assert.Empty(t, group.Members[1].NodeName)
+}
+
+func TestGetGroupByIDBadNamespace(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+	_, err := pm.GetGroupByID(pm.ctx, "!wrong", fftypes.NewRandB32().String())
+	assert.Regexp(t, "FF10131", err)
 }
 
 func TestGetGroupByIDBadID(t *testing.T) {
 	pm, cancel := newTestPrivateMessaging(t)
 	defer cancel()
-	_, err := pm.GetGroupByID(pm.ctx, "!wrong")
+	_, err := pm.GetGroupByID(pm.ctx, "ns1", "!wrong")
 	assert.Regexp(t, "FF10232", err)
 }
 
+func TestGetGroupByIDLookupFail(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetGroupByHash", pm.ctx, mock.Anything).Return(nil, fmt.Errorf("pop"))
+
+	_, err := pm.GetGroupByID(pm.ctx, "ns1", fftypes.NewRandB32().String())
+	assert.EqualError(t, err, "pop")
+}
+
+func TestGetGroupByIDWrongNamespace(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetGroupByHash", pm.ctx, mock.Anything).Return(&fftypes.Group{
+		GroupIdentity: fftypes.GroupIdentity{Namespace: "ns2"},
+	}, nil)
+
+	_, err := pm.GetGroupByID(pm.ctx, "ns1", fftypes.NewRandB32().String())
+	assert.Regexp(t, "FF10109", err)
+}
+
+func TestGetGroupByIDOrgLookupFail(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetGroupByHash", pm.ctx, mock.Anything).Return(&fftypes.Group{
+		GroupIdentity: fftypes.GroupIdentity{
+			Namespace: "ns1",
+			Members:   fftypes.Members{{Identity: "org1", Node: fftypes.NewUUID()}},
+		},
+	}, nil)
+	mdi.On("GetOrganizationByIdentity", pm.ctx, "org1").Return(nil, fmt.Errorf("pop"))
+
+	_, err := pm.GetGroupByID(pm.ctx, "ns1", fftypes.NewRandB32().String())
+	assert.EqualError(t, err, "pop")
+}
+
+func TestGetGroupByIDNodeLookupFail(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetGroupByHash", pm.ctx, mock.Anything).Return(&fftypes.Group{
+		GroupIdentity: fftypes.GroupIdentity{
+			Namespace: "ns1",
+			Members:   fftypes.Members{{Identity: "org1", Node: fftypes.NewUUID()}},
+		},
+	}, nil)
+	mdi.On("GetOrganizationByIdentity", pm.ctx, "org1").Return(&fftypes.Organization{}, nil)
+	mdi.On("GetNodeByID", pm.ctx, mock.Anything).Return(nil, fmt.Errorf("pop"))
+
+	_, err := pm.GetGroupByID(pm.ctx, "ns1", fftypes.NewRandB32().String())
+	assert.EqualError(t, err, "pop")
+}
+
 func TestGetGroupsOk(t *testing.T) {
 	pm, cancel := newTestPrivateMessaging(t)
 	defer cancel()
 
 	mdi := pm.database.(*databasemocks.Plugin)
-	mdi.On("GetGroups", pm.ctx, mock.Anything).Return([]*fftypes.Group{}, nil)
+	mdi.On("GetGroups", pm.ctx, mock.Anything).Return([]*fftypes.Group{}, nil, nil)
 
 	fb := database.GroupQueryFactory.NewFilter(pm.ctx)
-	groups, err := pm.GetGroups(pm.ctx, fb.And(fb.Eq("description", "mygroup")))
+	groups, _, err := pm.GetGroups(pm.ctx, "ns1", fb.And(fb.Eq("name", "mygroup")))
 	assert.NoError(t, err)
 	assert.Empty(t, groups)
 }
 
+func TestGetGroupsBadNamespace(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	fb := database.GroupQueryFactory.NewFilter(pm.ctx)
+	_, _, err := pm.GetGroups(pm.ctx, "!wrong", fb.And(fb.Eq("name", "mygroup")))
+	assert.Regexp(t, "FF10131", err)
+}
+
 func TestGetGroupNodesCache(t *testing.T) {
 	pm, cancel := newTestPrivateMessaging(t)
 	defer cancel()
@@ -367,14 +542,16 @@ func TestGetGroupNodesCache(t *testing.T) {
 		ID: node1,
 	}, nil).Once()
 
-	nodes, err := pm.getGroupNodes(pm.ctx, group.Hash)
+	g, nodes, err := pm.getGroupNodes(pm.ctx, group.Hash)
 	assert.NoError(t, err)
 	assert.Equal(t, *node1, *nodes[0].ID)
+	assert.Equal(t, *group.Hash, *g.Hash)
 
 	// Note this validates the cache as we only mocked the calls once
-	nodes, err = pm.getGroupNodes(pm.ctx, group.Hash)
+	g, nodes, err = pm.getGroupNodes(pm.ctx, group.Hash)
 	assert.NoError(t, err)
 	assert.Equal(t, *node1, *nodes[0].ID)
+	assert.Equal(t, *group.Hash, *g.Hash)
 }
 
 func TestGetGroupNodesGetGroupFail(t *testing.T) {
@@ -385,7 +562,7 @@ func TestGetGroupNodesGetGroupFail(t *testing.T) {
 	mdi := pm.database.(*databasemocks.Plugin)
 	mdi.On("GetGroupByHash", pm.ctx, mock.Anything).Return(nil, fmt.Errorf("pop"))
 
-	_, err := pm.getGroupNodes(pm.ctx, groupID)
+	_, _, err := pm.getGroupNodes(pm.ctx, groupID)
 	assert.EqualError(t, err, "pop")
 }
 
@@ -397,7 +574,7 @@ func TestGetGroupNodesGetGroupNotFound(t *testing.T) {
 	mdi := pm.database.(*databasemocks.Plugin)
 	mdi.On("GetGroupByHash", pm.ctx, mock.Anything).Return(nil, nil)
 
-	_, err := pm.getGroupNodes(pm.ctx, groupID)
+	_, _, err := pm.getGroupNodes(pm.ctx, groupID)
 	assert.Regexp(t, "FF10226", err)
 }
 
@@ -419,7 +596,7 @@ func TestGetGroupNodesNodeLookupFail(t *testing.T) {
 	mdi.On("GetGroupByHash", pm.ctx, mock.Anything).Return(group, nil).Once()
 	mdi.On("GetNodeByID", pm.ctx, uuidMatches(node1)).Return(nil, fmt.Errorf("pop")).Once()
 
-	_, err := pm.getGroupNodes(pm.ctx, group.Hash)
+	_, _, err := pm.getGroupNodes(pm.ctx, group.Hash)
 	assert.EqualError(t, err, "pop")
 }
 
@@ -440,6 +617,6 @@ func TestGetGroupNodesNodeLookupNotFound(t *testing.T) {
 	mdi.On("GetGroupByHash", pm.ctx, mock.Anything).Return(group, nil).Once()
 	mdi.On("GetNodeByID", pm.ctx, uuidMatches(node1)).Return(nil, nil).Once()
 
-	_, err := pm.getGroupNodes(pm.ctx, group.Hash)
+	_, _, err := pm.getGroupNodes(pm.ctx, group.Hash)
 	assert.Regexp(t, "FF10224", err)
 }
@@ -0,0 +1,293 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package privatemessaging
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/hyperledger-labs/firefly/internal/i18n"
+	"github.com/hyperledger-labs/firefly/internal/log"
+	"github.com/hyperledger-labs/firefly/pkg/database"
+	"github.com/hyperledger-labs/firefly/pkg/fftypes"
+)
+
+// UpdateGroup replaces the membership of a group, by defining a successor group that is linked to its predecessor.
+//
+// The update is sent as a message to the existing group, on the group's own topic and on each of the topics
+// being handed over. That message defines the handover point in each of those ordering contexts - all messages
+// to the existing group before it are processed first, and messages to the successor are held until after it.
+// Once this node has confirmed the update, the successor is initialized to its own members - which might include
+// members new to the group. An update that is found to be invalid leaves the existing group in place.
+func (pm *privateMessaging) UpdateGroup(ctx context.Context, ns, hash string, in *fftypes.GroupUpdateInput) (successor *fftypes.Group, err error) {
+	if err := fftypes.ValidateFFNameField(ctx, ns, "namespace"); err != nil {
+		return nil, err
+	}
+	h, err := fftypes.ParseBytes32(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	if len(in.Members) == 0 {
+		return nil, i18n.NewError(ctx, i18n.MsgGroupMustHaveMembers)
+	}
+
+	signer, err := pm.identity.Resolve(ctx, pm.localOrgIdentity)
+	if err != nil {
+		return nil, i18n.WrapError(ctx, err, i18n.MsgAuthorInvalid)
+	}
+
+	err = pm.database.RunAsGroup(ctx, func(ctx context.Context) (err error) {
+		successor, err = pm.sendGroupUpdate(ctx, signer, ns, h, in)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return successor, nil
+}
+
+func (pm *privateMessaging) sendGroupUpdate(ctx context.Context, signer *fftypes.Identity, ns string, hash *fftypes.Bytes32, in *fftypes.GroupUpdateInput) (*fftypes.Group, error) {
+	predecessor, err := pm.database.GetGroupByHash(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	if predecessor == nil || predecessor.Namespace != ns {
+		return nil, i18n.NewError(ctx, i18n.Msg404NotFound)
+	}
+	if predecessor.Successor != nil {
+		return nil, i18n.NewError(ctx, i18n.MsgGroupRetired, predecessor.Hash, predecessor.Successor)
+	}
+	if !predecessor.IsMember(signer.Identifier) {
+		return nil, i18n.NewError(ctx, i18n.MsgNotGroupMember, signer.Identifier, predecessor.Hash)
+	}
+
+	// Resolve the new member list, in the same way as for a group declared in-line on a message
+	name := in.Name
+	if name == "" {
+		name = predecessor.Name
+	}
+	gi, err := pm.getReceipients(ctx, &fftypes.MessageInput{
+		Message: fftypes.Message{
+			Header: fftypes.MessageHeader{Namespace: ns},
+		},
+		Group: &fftypes.InputGroup{
+			Name:    name,
+			Ledger:  predecessor.Ledger,
+			Members: in.Members,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	gi.Predecessor = predecessor.Hash
+	successor := &fftypes.Group{
+		GroupIdentity: *gi,
+		Created:       fftypes.Now(),
+	}
+	successor.Seal()
+
+	// Serialize the successor into a data object, to send to the members of the predecessor
+	data := &fftypes.Data{
+		Validator: fftypes.ValidatorTypeSystemDefinition,
+		ID:        fftypes.NewUUID(),
+		Namespace: ns,
+		Created:   fftypes.Now(),
+	}
+	data.Value, err = json.Marshal(&successor)
+	if err == nil {
+		err = data.Seal(ctx)
+	}
+	if err != nil {
+		return nil, i18n.WrapError(ctx, err, i18n.MsgSerializationFailed)
+	}
+	if err = pm.database.UpsertData(ctx, data, true, false /* we just generated the ID, so it is new */); err != nil {
+		return nil, err
+	}
+
+	// The update is pinned on the group's own topic, and on each of the topics being handed over
+	topics := fftypes.FFNameArray{predecessor.Topic()}
+	for _, topic := range in.Topics {
+		if topic != predecessor.Topic() {
+			topics = append(topics, topic)
+		}
+	}
+	msg := &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			Group:     predecessor.Hash,
+			Namespace: ns,
+			Type:      fftypes.MessageTypeGroupUpdate,
+			Author:    signer.Identifier,
+			Tag:       string(fftypes.SystemTagUpdateGroup),
+			Topics:    topics,
+			TxType:    fftypes.TransactionTypeBatchPin,
+		},
+		Data: fftypes.DataRefs{
+			{ID: data.ID, Hash: data.Hash},
+		},
+	}
+	err = msg.Seal(ctx)
+	if err == nil {
+		msg.Signature, err = pm.identity.Sign(ctx, msg.Header.Author, msg.Hash)
+	}
+	if err == nil {
+		err = pm.database.InsertMessageLocal(ctx, msg)
+	}
+	if err != nil {
+		return nil, err
+	}
+	log.L(ctx).Infof("Sent update of group %s to successor %s", predecessor.Hash, successor.Hash)
+	return successor, nil
+}
+
+// HandleGroupUpdate is called when a group update message is dispatched, in the ordering context of the
+// group being retired. The successor is stored if it is not already known, and the predecessor is marked as
+// retired - which completes the handover, so messages can be processed on the successor.
+//
+// Errors are only returned for database issues, and failures to initialize the successor from this node.
+// For validation issues, a nil group is returned without an error.
+func (gm *groupManager) HandleGroupUpdate(ctx context.Context, msg *fftypes.Message, data []*fftypes.Data) (*fftypes.Group, error) {
+	l := log.L(ctx)
+	if msg.Header.Tag != string(fftypes.SystemTagUpdateGroup) || len(data) != 1 {
+		l.Warnf("Group %s update in message %s invalid: tag=%s data=%d", msg.Header.Group, msg.Header.ID, msg.Header.Tag, len(data))
+		return nil, nil
+	}
+	var successor fftypes.Group
+	err := json.Unmarshal(data[0].Value, &successor)
+	if err == nil {
+		err = successor.Validate(ctx, true)
+	}
+	if err != nil {
+		l.Warnf("Group %s update in message %s invalid: %s", msg.Header.Group, msg.Header.ID, err)
+		return nil, nil
+	}
+
+	predecessor, err := gm.database.GetGroupByHash(ctx, msg.Header.Group)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case predecessor == nil:
+		l.Warnf("Group %s update in message %s invalid: group not found", msg.Header.Group, msg.Header.ID)
+		return nil, nil
+	case predecessor.Successor != nil:
+		l.Warnf("Group %s update in message %s invalid: already retired in favor of %s", msg.Header.Group, msg.Header.ID, predecessor.Successor)
+		return nil, nil
+	case !predecessor.IsMember(msg.Header.Author):
+		l.Warnf("Group %s update in message %s invalid: author '%s' is not a member", msg.Header.Group, msg.Header.ID, msg.Header.Author)
+		return nil, nil
+	case !predecessor.Hash.Equals(successor.Predecessor) ||
+		predecessor.Namespace != successor.Namespace ||
+		!predecessor.Ledger.Equals(successor.Ledger):
+		l.Warnf("Group %s update in message %s invalid: successor %s does not follow the group", msg.Header.Group, msg.Header.ID, successor.Hash)
+		return nil, nil
+	}
+
+	existing, err := gm.database.GetGroupByHash(ctx, successor.Hash)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		successor.Message = msg.Header.ID
+		successor.Successor = nil
+		successor.Retired = nil
+		if err = gm.database.UpsertGroup(ctx, &successor, false); err != nil {
+			return nil, err
+		}
+	}
+
+	update := database.GroupQueryFactory.NewUpdate(ctx).
+		Set("successor", successor.Hash).
+		Set("retired", msg.Header.Created)
+	if err = gm.database.UpdateGroup(ctx, predecessor.Hash, update); err != nil {
+		return nil, err
+	}
+	gm.groupCache.Delete(predecessor.Hash.String())
+	l.Infof("Group %s retired in favor of successor %s", predecessor.Hash, successor.Hash)
+
+	// The node that sent the update initializes the successor to all of its members, including those that were
+	// not members of the predecessor - now the handover is confirmed, rather than when the update was sent
+	if msg.Local {
+		signer, err := gm.identity.Resolve(ctx, msg.Header.Author)
+		if err != nil {
+			return nil, err
+		}
+		if err = gm.groupInit(ctx, signer, &successor); err != nil {
+			return nil, err
+		}
+	}
+	return &successor, nil
+}
+
+// handoverComplete checks a group that succeeds another group can be used. Members of the predecessor
+// must first process the update that retires it. Nodes that were not members of the predecessor do not
+// know about it, and can use the successor straight away.
+func (gm *groupManager) handoverComplete(ctx context.Context, group *fftypes.Group) (bool, error) {
+	if group.Predecessor == nil {
+		return true, nil
+	}
+	predecessor, err := gm.database.GetGroupByHash(ctx, group.Predecessor)
+	if err != nil {
+		return false, err
+	}
+	if predecessor != nil && !group.Hash.Equals(predecessor.Successor) {
+		log.L(ctx).Debugf("Group %s waiting for handover from predecessor %s", group.Hash, predecessor.Hash)
+		return false, nil
+	}
+	return true, nil
+}
+
+// GetGroupLineage returns every known version of a group, from the oldest predecessor to the newest successor
+func (gm *groupManager) GetGroupLineage(ctx context.Context, ns, hash string) ([]*fftypes.Group, error) {
+	if err := fftypes.ValidateFFNameField(ctx, ns, "namespace"); err != nil {
+		return nil, err
+	}
+	h, err := fftypes.ParseBytes32(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	group, err := gm.database.GetGroupByHash(ctx, h)
+	if err != nil {
+		return nil, err
+	}
+	if group == nil || group.Namespace != ns {
+		return nil, i18n.NewError(ctx, i18n.Msg404NotFound)
+	}
+
+	lineage := []*fftypes.Group{group}
+	known := map[fftypes.Bytes32]bool{*group.Hash: true}
+	for g := group; g.Predecessor != nil && !known[*g.Predecessor]; {
+		if g, err = gm.database.GetGroupByHash(ctx, g.Predecessor); err != nil {
+			return nil, err
+		}
+		if g == nil {
+			break
+		}
+		known[*g.Hash] = true
+		lineage = append([]*fftypes.Group{g}, lineage...)
+	}
+	for g := group; g.Successor != nil && !known[*g.Successor]; {
+		if g, err = gm.database.GetGroupByHash(ctx, g.Successor); err != nil {
+			return nil, err
+		}
+		if g == nil {
+			break
+		}
+		known[*g.Hash] = true
+		lineage = append(lineage, g)
+	}
+	return lineage, nil
+}
@@ -0,0 +1,652 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package privatemessaging
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/hyperledger-labs/firefly/mocks/databasemocks"
+	"github.com/hyperledger-labs/firefly/mocks/identitymocks"
+	"github.com/hyperledger-labs/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func newTestPredecessor() *fftypes.Group {
+	group := &fftypes.Group{
+		GroupIdentity: fftypes.GroupIdentity{
+			Name:      "group1",
+			Namespace: "ns1",
+			Members: fftypes.Members{
+				{Identity: "localorg", Node: fftypes.NewUUID()},
+				{Identity: "org2", Node: fftypes.NewUUID()},
+			},
+		},
+	}
+	group.Seal()
+	return group
+}
+
+func newTestSuccessor(predecessor *fftypes.Group) *fftypes.Group {
+	group := &fftypes.Group{
+		GroupIdentity: fftypes.GroupIdentity{
+			Name:      predecessor.Name,
+			Namespace: predecessor.Namespace,
+			Ledger:    predecessor.Ledger,
+			Members: fftypes.Members{
+				{Identity: "localorg", Node: predecessor.Members[0].Node},
+				{Identity: "org3", Node: fftypes.NewUUID()},
+			},
+			Predecessor: predecessor.Hash,
+		},
+	}
+	group.Seal()
+	return group
+}
+
+func newTestGroupUpdateMsg(predecessor, successor *fftypes.Group) (*fftypes.Message, []*fftypes.Data) {
+	b, _ := json.Marshal(&successor)
+	return &fftypes.Message{
+		Header: fftypes.MessageHeader{
+			ID:        fftypes.NewUUID(),
+			Namespace: "ns1",
+			Type:      fftypes.MessageTypeGroupUpdate,
+			Tag:       string(fftypes.SystemTagUpdateGroup),
+			Group:     predecessor.Hash,
+			Author:    "org2",
+			Created:   fftypes.Now(),
+		},
+	}, []*fftypes.Data{
+		{ID: fftypes.NewUUID(), Value: fftypes.Byteable(b)},
+	}
+}
+
+func TestUpdateGroupOk(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	predecessor := newTestPredecessor()
+	nodeID := fftypes.NewUUID()
+
+	mii := pm.identity.(*identitymocks.Plugin)
+	mii.On("Resolve", pm.ctx, "localorg").Return(&fftypes.Identity{Identifier: "localorg"}, nil)
+	mdi := pm.database.(*databasemocks.Plugin)
+	rag := mdi.On("RunAsGroup", pm.ctx, mock.Anything).Return(nil)
+	rag.RunFn = func(a mock.Arguments) {
+		err := a[1].(func(context.Context) error)(a[0].(context.Context))
+		rag.ReturnArguments = mock.Arguments{err}
+	}
+	mdi.On("GetGroupByHash", pm.ctx, predecessor.Hash).Return(predecessor, nil)
+	mdi.On("GetOrganizationByName", pm.ctx, "localorg").Return(&fftypes.Organization{ID: fftypes.NewUUID(), Identity: "localorg"}, nil)
+	mdi.On("GetNodes", pm.ctx, mock.Anything).Return([]*fftypes.Node{{ID: nodeID, Name: "node1", Owner: "localorg"}}, nil, nil)
+	mdi.On("UpsertData", pm.ctx, mock.Anything, true, false).Return(nil)
+	mdi.On("InsertMessageLocal", pm.ctx, mock.MatchedBy(func(msg *fftypes.Message) bool {
+		return msg.Header.Type == fftypes.MessageTypeGroupUpdate &&
+			msg.Header.Group.Equals(predecessor.Hash) &&
+			len(msg.Header.Topics) == 2 &&
+			msg.Header.Topics[0] == predecessor.Topic() &&
+			msg.Header.Topics[1] == "topic1"
+	})).Return(nil).Once()
+
+	successor, err := pm.UpdateGroup(pm.ctx, "ns1", predecessor.Hash.String(), &fftypes.GroupUpdateInput{
+		Members: []fftypes.MemberInput{
+			{Identity: "localorg"},
+		},
+		Topics: fftypes.FFNameArray{predecessor.Topic(), "topic1"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "group1", successor.Name)
+	assert.Equal(t, *predecessor.Hash, *successor.Predecessor)
+	assert.Equal(t, *nodeID, *successor.Members[0].Node)
+	assert.Equal(t, *successor.GroupIdentity.Hash(), *successor.Hash)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestUpdateGroupBadNamespace(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	_, err := pm.UpdateGroup(pm.ctx, "!wrong", fftypes.NewRandB32().String(), &fftypes.GroupUpdateInput{})
+	assert.Regexp(t, "FF10131", err)
+}
+
+func TestUpdateGroupBadHash(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	_, err := pm.UpdateGroup(pm.ctx, "ns1", "!wrong", &fftypes.GroupUpdateInput{})
+	assert.Regexp(t, "FF10232", err)
+}
+
+func TestUpdateGroupNoMembers(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	_, err := pm.UpdateGroup(pm.ctx, "ns1", fftypes.NewRandB32().String(), &fftypes.GroupUpdateInput{})
+	assert.Regexp(t, "FF10219", err)
+}
+
+func TestUpdateGroupResolveFail(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	mii := pm.identity.(*identitymocks.Plugin)
+	mii.On("Resolve", pm.ctx, "localorg").Return(nil, fmt.Errorf("pop"))
+
+	_, err := pm.UpdateGroup(pm.ctx, "ns1", fftypes.NewRandB32().String(), &fftypes.GroupUpdateInput{
+		Members: []fftypes.MemberInput{{Identity: "localorg"}},
+	})
+	assert.Regexp(t, "FF10206.*pop", err)
+}
+
+func TestUpdateGroupRunAsGroupFail(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	mii := pm.identity.(*identitymocks.Plugin)
+	mii.On("Resolve", pm.ctx, "localorg").Return(&fftypes.Identity{Identifier: "localorg"}, nil)
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("RunAsGroup", pm.ctx, mock.Anything).Return(fmt.Errorf("pop"))
+
+	_, err := pm.UpdateGroup(pm.ctx, "ns1", fftypes.NewRandB32().String(), &fftypes.GroupUpdateInput{
+		Members: []fftypes.MemberInput{{Identity: "localorg"}},
+	})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestSendGroupUpdateGetGroupFail(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetGroupByHash", pm.ctx, mock.Anything).Return(nil, fmt.Errorf("pop"))
+
+	_, err := pm.sendGroupUpdate(pm.ctx, &fftypes.Identity{Identifier: "localorg"}, "ns1", fftypes.NewRandB32(), &fftypes.GroupUpdateInput{})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestSendGroupUpdateWrongNamespace(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetGroupByHash", pm.ctx, mock.Anything).Return(newTestPredecessor(), nil)
+
+	_, err := pm.sendGroupUpdate(pm.ctx, &fftypes.Identity{Identifier: "localorg"}, "ns2", fftypes.NewRandB32(), &fftypes.GroupUpdateInput{})
+	assert.Regexp(t, "FF10109", err)
+}
+
+func TestSendGroupUpdateRetired(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	predecessor := newTestPredecessor()
+	predecessor.Successor = fftypes.NewRandB32()
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetGroupByHash", pm.ctx, mock.Anything).Return(predecessor, nil)
+
+	_, err := pm.sendGroupUpdate(pm.ctx, &fftypes.Identity{Identifier: "localorg"}, "ns1", predecessor.Hash, &fftypes.GroupUpdateInput{})
+	assert.Regexp(t, "FF10293", err)
+}
+
+func TestSendGroupUpdateNotMember(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	predecessor := newTestPredecessor()
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetGroupByHash", pm.ctx, mock.Anything).Return(predecessor, nil)
+
+	_, err := pm.sendGroupUpdate(pm.ctx, &fftypes.Identity{Identifier: "org3"}, "ns1", predecessor.Hash, &fftypes.GroupUpdateInput{})
+	assert.Regexp(t, "FF10294", err)
+}
+
+func TestSendGroupUpdateResolveMembersFail(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	predecessor := newTestPredecessor()
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetGroupByHash", pm.ctx, mock.Anything).Return(predecessor, nil)
+	mdi.On("GetOrganizationByName", pm.ctx, "org3").Return(nil, fmt.Errorf("pop"))
+
+	_, err := pm.sendGroupUpdate(pm.ctx, &fftypes.Identity{Identifier: "localorg"}, "ns1", predecessor.Hash, &fftypes.GroupUpdateInput{
+		Members: []fftypes.MemberInput{{Identity: "org3"}},
+	})
+	assert.EqualError(t, err, "pop")
+}
+
+func mockSendGroupUpdateMembers(mdi *databasemocks.Plugin, ctx context.Context, predecessor *fftypes.Group) {
+	mdi.On("GetGroupByHash", ctx, predecessor.Hash).Return(predecessor, nil)
+	mdi.On("GetOrganizationByName", ctx, "localorg").Return(&fftypes.Organization{ID: fftypes.NewUUID(), Identity: "localorg"}, nil)
+	mdi.On("GetNodes", ctx, mock.Anything).Return([]*fftypes.Node{{ID: fftypes.NewUUID(), Name: "node1", Owner: "localorg"}}, nil, nil)
+}
+
+func TestSendGroupUpdateUpsertDataFail(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	predecessor := newTestPredecessor()
+	mdi := pm.database.(*databasemocks.Plugin)
+	mockSendGroupUpdateMembers(mdi, pm.ctx, predecessor)
+	mdi.On("UpsertData", pm.ctx, mock.Anything, true, false).Return(fmt.Errorf("pop"))
+
+	_, err := pm.sendGroupUpdate(pm.ctx, &fftypes.Identity{Identifier: "localorg"}, "ns1", predecessor.Hash, &fftypes.GroupUpdateInput{
+		Members: []fftypes.MemberInput{{Identity: "localorg"}},
+	})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestSendGroupUpdateInsertMessageFail(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	predecessor := newTestPredecessor()
+	mdi := pm.database.(*databasemocks.Plugin)
+	mockSendGroupUpdateMembers(mdi, pm.ctx, predecessor)
+	mdi.On("UpsertData", pm.ctx, mock.Anything, true, false).Return(nil)
+	mdi.On("InsertMessageLocal", pm.ctx, mock.Anything).Return(fmt.Errorf("pop"))
+
+	_, err := pm.sendGroupUpdate(pm.ctx, &fftypes.Identity{Identifier: "localorg"}, "ns1", predecessor.Hash, &fftypes.GroupUpdateInput{
+		Members: []fftypes.MemberInput{{Identity: "localorg"}},
+	})
+	assert.EqualError(t, err, "pop")
+}
+
+func TestHandleGroupUpdateNewSuccessor(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	predecessor := newTestPredecessor()
+	successor := newTestSuccessor(predecessor)
+	msg, data := newTestGroupUpdateMsg(predecessor, successor)
+	pm.groupCache.Set(predecessor.Hash.String(), &groupNodes{group: predecessor}, pm.groupCacheTTL)
+
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetGroupByHash", pm.ctx, predecessor.Hash).Return(predecessor, nil)
+	mdi.On("GetGroupByHash", pm.ctx, successor.Hash).Return(nil, nil)
+	mdi.On("UpsertGroup", pm.ctx, mock.MatchedBy(func(g *fftypes.Group) bool {
+		return g.Hash.Equals(successor.Hash) && g.Message.Equals(msg.Header.ID)
+	}), false).Return(nil)
+	mdi.On("UpdateGroup", pm.ctx, predecessor.Hash, mock.Anything).Return(nil)
+
+	group, err := pm.HandleGroupUpdate(pm.ctx, msg, data)
+	assert.NoError(t, err)
+	assert.Equal(t, *successor.Hash, *group.Hash)
+	assert.Nil(t, pm.groupCache.Get(predecessor.Hash.String()))
+
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleGroupUpdateExistingSuccessor(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	predecessor := newTestPredecessor()
+	successor := newTestSuccessor(predecessor)
+	msg, data := newTestGroupUpdateMsg(predecessor, successor)
+
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetGroupByHash", pm.ctx, predecessor.Hash).Return(predecessor, nil)
+	mdi.On("GetGroupByHash", pm.ctx, successor.Hash).Return(successor, nil)
+	mdi.On("UpdateGroup", pm.ctx, predecessor.Hash, mock.Anything).Return(nil)
+
+	group, err := pm.HandleGroupUpdate(pm.ctx, msg, data)
+	assert.NoError(t, err)
+	assert.Equal(t, *successor.Hash, *group.Hash)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleGroupUpdateLocalInitSuccessor(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	predecessor := newTestPredecessor()
+	successor := newTestSuccessor(predecessor)
+	msg, data := newTestGroupUpdateMsg(predecessor, successor)
+	msg.Local = true
+
+	mii := pm.identity.(*identitymocks.Plugin)
+	mii.On("Resolve", pm.ctx, msg.Header.Author).Return(&fftypes.Identity{Identifier: msg.Header.Author}, nil)
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetGroupByHash", pm.ctx, predecessor.Hash).Return(predecessor, nil)
+	mdi.On("GetGroupByHash", pm.ctx, successor.Hash).Return(successor, nil)
+	mdi.On("UpdateGroup", pm.ctx, predecessor.Hash, mock.Anything).Return(nil)
+	mdi.On("UpsertGroup", pm.ctx, mock.MatchedBy(func(g *fftypes.Group) bool {
+		return g.Hash.Equals(successor.Hash)
+	}), true).Return(nil)
+	mdi.On("UpsertData", pm.ctx, mock.Anything, true, false).Return(nil)
+	mdi.On("InsertMessageLocal", pm.ctx, mock.MatchedBy(func(m *fftypes.Message) bool {
+		return m.Header.Type == fftypes.MessageTypeGroupInit && m.Header.Group.Equals(successor.Hash)
+	})).Return(nil)
+
+	group, err := pm.HandleGroupUpdate(pm.ctx, msg, data)
+	assert.NoError(t, err)
+	assert.Equal(t, *successor.Hash, *group.Hash)
+
+	mii.AssertExpectations(t)
+	mdi.AssertExpectations(t)
+}
+
+func TestHandleGroupUpdateLocalResolveFail(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	predecessor := newTestPredecessor()
+	successor := newTestSuccessor(predecessor)
+	msg, data := newTestGroupUpdateMsg(predecessor, successor)
+	msg.Local = true
+
+	mii := pm.identity.(*identitymocks.Plugin)
+	mii.On("Resolve", pm.ctx, msg.Header.Author).Return(nil, fmt.Errorf("pop"))
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetGroupByHash", pm.ctx, predecessor.Hash).Return(predecessor, nil)
+	mdi.On("GetGroupByHash", pm.ctx, successor.Hash).Return(successor, nil)
+	mdi.On("UpdateGroup", pm.ctx, predecessor.Hash, mock.Anything).Return(nil)
+
+	_, err := pm.HandleGroupUpdate(pm.ctx, msg, data)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestHandleGroupUpdateLocalInitFail(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	predecessor := newTestPredecessor()
+	successor := newTestSuccessor(predecessor)
+	msg, data := newTestGroupUpdateMsg(predecessor, successor)
+	msg.Local = true
+
+	mii := pm.identity.(*identitymocks.Plugin)
+	mii.On("Resolve", pm.ctx, msg.Header.Author).Return(&fftypes.Identity{Identifier: msg.Header.Author}, nil)
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetGroupByHash", pm.ctx, predecessor.Hash).Return(predecessor, nil)
+	mdi.On("GetGroupByHash", pm.ctx, successor.Hash).Return(successor, nil)
+	mdi.On("UpdateGroup", pm.ctx, predecessor.Hash, mock.Anything).Return(nil)
+	mdi.On("UpsertGroup", pm.ctx, mock.Anything, true).Return(fmt.Errorf("pop"))
+
+	_, err := pm.HandleGroupUpdate(pm.ctx, msg, data)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestHandleGroupUpdateBadTag(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	predecessor := newTestPredecessor()
+	msg, data := newTestGroupUpdateMsg(predecessor, newTestSuccessor(predecessor))
+	msg.Header.Tag = "mytag"
+
+	group, err := pm.HandleGroupUpdate(pm.ctx, msg, data)
+	assert.NoError(t, err)
+	assert.Nil(t, group)
+}
+
+func TestHandleGroupUpdateBadData(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	predecessor := newTestPredecessor()
+	msg, _ := newTestGroupUpdateMsg(predecessor, newTestSuccessor(predecessor))
+
+	group, err := pm.HandleGroupUpdate(pm.ctx, msg, []*fftypes.Data{
+		{Value: fftypes.Byteable(`!json`)},
+	})
+	assert.NoError(t, err)
+	assert.Nil(t, group)
+}
+
+func TestHandleGroupUpdateBadValidation(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	predecessor := newTestPredecessor()
+	msg, _ := newTestGroupUpdateMsg(predecessor, newTestSuccessor(predecessor))
+
+	group, err := pm.HandleGroupUpdate(pm.ctx, msg, []*fftypes.Data{
+		{Value: fftypes.Byteable(`{}`)},
+	})
+	assert.NoError(t, err)
+	assert.Nil(t, group)
+}
+
+func TestHandleGroupUpdateGetGroupFail(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	predecessor := newTestPredecessor()
+	msg, data := newTestGroupUpdateMsg(predecessor, newTestSuccessor(predecessor))
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetGroupByHash", pm.ctx, predecessor.Hash).Return(nil, fmt.Errorf("pop"))
+
+	_, err := pm.HandleGroupUpdate(pm.ctx, msg, data)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestHandleGroupUpdateInvalid(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	predecessor := newTestPredecessor()
+	retired := newTestPredecessor()
+	retired.Successor = fftypes.NewRandB32()
+	otherLedger := newTestPredecessor()
+	otherLedger.Ledger = fftypes.NewUUID()
+
+	for _, existing := range []*fftypes.Group{nil, retired, otherLedger} {
+		mdi := &databasemocks.Plugin{}
+		pm.database = mdi
+		pm.groupManager.database = mdi
+		msg, data := newTestGroupUpdateMsg(predecessor, newTestSuccessor(predecessor))
+		mdi.On("GetGroupByHash", pm.ctx, predecessor.Hash).Return(existing, nil)
+		group, err := pm.HandleGroupUpdate(pm.ctx, msg, data)
+		assert.NoError(t, err)
+		assert.Nil(t, group)
+	}
+
+	// Author not a member
+	msg, data := newTestGroupUpdateMsg(predecessor, newTestSuccessor(predecessor))
+	msg.Header.Author = "org3"
+	mdi := &databasemocks.Plugin{}
+	pm.groupManager.database = mdi
+	mdi.On("GetGroupByHash", pm.ctx, predecessor.Hash).Return(predecessor, nil)
+	group, err := pm.HandleGroupUpdate(pm.ctx, msg, data)
+	assert.NoError(t, err)
+	assert.Nil(t, group)
+}
+
+func TestHandleGroupUpdateGetSuccessorFail(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	predecessor := newTestPredecessor()
+	successor := newTestSuccessor(predecessor)
+	msg, data := newTestGroupUpdateMsg(predecessor, successor)
+
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetGroupByHash", pm.ctx, predecessor.Hash).Return(predecessor, nil)
+	mdi.On("GetGroupByHash", pm.ctx, successor.Hash).Return(nil, fmt.Errorf("pop"))
+
+	_, err := pm.HandleGroupUpdate(pm.ctx, msg, data)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestHandleGroupUpdateUpsertSuccessorFail(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	predecessor := newTestPredecessor()
+	successor := newTestSuccessor(predecessor)
+	msg, data := newTestGroupUpdateMsg(predecessor, successor)
+
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetGroupByHash", pm.ctx, predecessor.Hash).Return(predecessor, nil)
+	mdi.On("GetGroupByHash", pm.ctx, successor.Hash).Return(nil, nil)
+	mdi.On("UpsertGroup", pm.ctx, mock.Anything, false).Return(fmt.Errorf("pop"))
+
+	_, err := pm.HandleGroupUpdate(pm.ctx, msg, data)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestHandleGroupUpdateRetireFail(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	predecessor := newTestPredecessor()
+	successor := newTestSuccessor(predecessor)
+	msg, data := newTestGroupUpdateMsg(predecessor, successor)
+
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetGroupByHash", pm.ctx, predecessor.Hash).Return(predecessor, nil)
+	mdi.On("GetGroupByHash", pm.ctx, successor.Hash).Return(successor, nil)
+	mdi.On("UpdateGroup", pm.ctx, predecessor.Hash, mock.Anything).Return(fmt.Errorf("pop"))
+
+	_, err := pm.HandleGroupUpdate(pm.ctx, msg, data)
+	assert.EqualError(t, err, "pop")
+}
+
+func TestHandoverComplete(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	predecessor := newTestPredecessor()
+	successor := newTestSuccessor(predecessor)
+	mdi := pm.database.(*databasemocks.Plugin)
+
+	// Predecessor not retired yet
+	mdi.On("GetGroupByHash", pm.ctx, predecessor.Hash).Return(predecessor, nil).Once()
+	ready, err := pm.handoverComplete(pm.ctx, successor)
+	assert.NoError(t, err)
+	assert.False(t, ready)
+
+	// Predecessor not known to this node
+	mdi.On("GetGroupByHash", pm.ctx, predecessor.Hash).Return(nil, nil).Once()
+	ready, err = pm.handoverComplete(pm.ctx, successor)
+	assert.NoError(t, err)
+	assert.True(t, ready)
+
+	// Predecessor retired in favor of this successor
+	retired := *predecessor
+	retired.Successor = successor.Hash
+	mdi.On("GetGroupByHash", pm.ctx, predecessor.Hash).Return(&retired, nil).Once()
+	ready, err = pm.handoverComplete(pm.ctx, successor)
+	assert.NoError(t, err)
+	assert.True(t, ready)
+
+	mdi.On("GetGroupByHash", pm.ctx, predecessor.Hash).Return(nil, fmt.Errorf("pop")).Once()
+	_, err = pm.handoverComplete(pm.ctx, successor)
+	assert.EqualError(t, err, "pop")
+
+	mdi.AssertExpectations(t)
+}
+
+func TestGetGroupLineage(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	group1 := newTestPredecessor()
+	group1.Predecessor = fftypes.NewRandB32()
+	group2 := newTestSuccessor(group1)
+	group3 := newTestSuccessor(group2)
+	group1.Successor = group2.Hash
+	group2.Successor = group3.Hash
+	group3.Successor = fftypes.NewRandB32()
+
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetGroupByHash", pm.ctx, group1.Predecessor).Return(nil, nil)
+	mdi.On("GetGroupByHash", pm.ctx, group1.Hash).Return(group1, nil)
+	mdi.On("GetGroupByHash", pm.ctx, group2.Hash).Return(group2, nil)
+	mdi.On("GetGroupByHash", pm.ctx, group3.Hash).Return(group3, nil)
+	mdi.On("GetGroupByHash", pm.ctx, group3.Successor).Return(nil, nil)
+
+	lineage, err := pm.GetGroupLineage(pm.ctx, "ns1", group2.Hash.String())
+	assert.NoError(t, err)
+	assert.Equal(t, []*fftypes.Group{group1, group2, group3}, lineage)
+
+	mdi.AssertExpectations(t)
+}
+
+func TestGetGroupLineageBadNamespace(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	_, err := pm.GetGroupLineage(pm.ctx, "!wrong", fftypes.NewRandB32().String())
+	assert.Regexp(t, "FF10131", err)
+}
+
+func TestGetGroupLineageBadHash(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	_, err := pm.GetGroupLineage(pm.ctx, "ns1", "!wrong")
+	assert.Regexp(t, "FF10232", err)
+}
+
+func TestGetGroupLineageGetGroupFail(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetGroupByHash", pm.ctx, mock.Anything).Return(nil, fmt.Errorf("pop"))
+
+	_, err := pm.GetGroupLineage(pm.ctx, "ns1", fftypes.NewRandB32().String())
+	assert.EqualError(t, err, "pop")
+}
+
+func TestGetGroupLineageNotFound(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetGroupByHash", pm.ctx, mock.Anything).Return(nil, nil)
+
+	_, err := pm.GetGroupLineage(pm.ctx, "ns1", fftypes.NewRandB32().String())
+	assert.Regexp(t, "FF10109", err)
+}
+
+func TestGetGroupLineagePredecessorFail(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	group1 := newTestPredecessor()
+	group2 := newTestSuccessor(group1)
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetGroupByHash", pm.ctx, group2.Hash).Return(group2, nil)
+	mdi.On("GetGroupByHash", pm.ctx, group1.Hash).Return(nil, fmt.Errorf("pop"))
+
+	_, err := pm.GetGroupLineage(pm.ctx, "ns1", group2.Hash.String())
+	assert.EqualError(t, err, "pop")
+}
+
+func TestGetGroupLineageSuccessorFail(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	group1 := newTestPredecessor()
+	group1.Successor = fftypes.NewRandB32()
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetGroupByHash", pm.ctx, group1.Hash).Return(group1, nil)
+	mdi.On("GetGroupByHash", pm.ctx, group1.Successor).Return(nil, fmt.Errorf("pop"))
+
+	_, err := pm.GetGroupLineage(pm.ctx, "ns1", group1.Hash.String())
+	assert.EqualError(t, err, "pop")
+}
@@ -30,8 +30,13 @@ func (pm *privateMessaging) SendMessage(ctx context.Context, ns string, in *ffty
 	if in.Header.Author == "" {
 		in.Header.Author = config.GetString(config.OrgIdentity)
 	}
-	if in.Header.TxType == "" {
+	switch in.Header.TxType {
+	case "":
 		in.Header.TxType = fftypes.TransactionTypeBatchPin
+	case fftypes.TransactionTypeBatchPin, fftypes.TransactionTypeNone:
+		// A type of none sends the message over data exchange only, without pinning it to the blockchain
+	default:
+		return nil, i18n.NewError(ctx, i18n.MsgTxTypeNotSupported, in.Header.TxType, in.Header.Type)
 	}
 
 	sender, err := pm.identity.Resolve(ctx, in.Header.Author)
@@ -67,6 +72,11 @@ func (pm *privateMessaging) resolveAndSend(ctx context.Context, sender *fftypes.
 		return err
 	}
 
+	// Sign the hash of the message, if our identity plugin holds keys for the author
+	if in.Message.Signature, err = pm.identity.Sign(ctx, in.Header.Author, in.Message.Hash); err != nil {
+		return err
+	}
+
 	// Store the message - this asynchronously triggers the next step in process
 	return pm.database.InsertMessageLocal(ctx, &in.Message)
 }
@@ -57,16 +57,16 @@ func TestSendMessageE2EOk(t *testing.T) {
 	}, nil)
 	mdi.On("GetNodes", pm.ctx, mock.Anything).Return([]*fftypes.Node{
 		{ID: fftypes.NewUUID(), Name: "node1", Owner: "localorg"},
-	}, nil).Once()
+	}, nil, nil).Once()
 	mdi.On("GetOrganizationByName", pm.ctx, "org1").Return(&fftypes.Organization{
 		ID: fftypes.NewUUID(),
 	}, nil)
 	mdi.On("GetNodes", pm.ctx, mock.Anything).Return([]*fftypes.Node{
 		{ID: fftypes.NewUUID(), Name: "node1", Owner: "org1"},
-	}, nil).Once()
+	}, nil, nil).Once()
 	mdi.On("GetGroups", pm.ctx, mock.Anything).Return([]*fftypes.Group{
 		{Hash: fftypes.NewRandB32()},
-	}, nil).Once()
+	}, nil, nil).Once()
 	mdi.On("InsertMessageLocal", pm.ctx, mock.Anything).Return(nil).Once()
 
 	msg, err := pm.SendMessage(pm.ctx, "ns1", &fftypes.MessageInput{
@@ -107,6 +107,22 @@ func TestSendMessageBadIdentity(t *testing.T) {
 
 }
 
+func TestSendMessageBadTxType(t *testing.T) {
+
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	_, err := pm.SendMessage(pm.ctx, "ns1", &fftypes.MessageInput{
+		Message: fftypes.Message{
+			Header: fftypes.MessageHeader{
+				TxType: "wrong",
+			},
+		},
+	})
+	assert.Regexp(t, "FF10295", err)
+
+}
+
 func TestSendMessageFail(t *testing.T) {
 
 	pm, cancel := newTestPrivateMessaging(t)
@@ -165,10 +181,10 @@ func TestResolveAndSendBadInputData(t *testing.T) {
 	}, nil)
 	mdi.On("GetNodes", pm.ctx, mock.Anything).Return([]*fftypes.Node{
 		{ID: fftypes.NewUUID(), Name: "node1", Owner: "localorg"},
-	}, nil).Once()
+	}, nil, nil).Once()
 	mdi.On("GetGroups", pm.ctx, mock.Anything).Return([]*fftypes.Group{
 		{Hash: fftypes.NewRandB32()},
-	}, nil).Once()
+	}, nil, nil).Once()
 
 	mdm := pm.data.(*datamocks.Manager)
 	mdm.On("ResolveInputData", pm.ctx, "ns1", mock.Anything).Return(nil, fmt.Errorf("pop"))
@@ -196,10 +212,10 @@ func TestResolveAndSendSealFail(t *testing.T) {
 	}, nil)
 	mdi.On("GetNodes", pm.ctx, mock.Anything).Return([]*fftypes.Node{
 		{ID: fftypes.NewUUID(), Name: "node1", Owner: "localorg"},
-	}, nil).Once()
+	}, nil, nil).Once()
 	mdi.On("GetGroups", pm.ctx, mock.Anything).Return([]*fftypes.Group{
 		{Hash: fftypes.NewRandB32()},
-	}, nil).Once()
+	}, nil, nil).Once()
 
 	mdm := pm.data.(*datamocks.Manager)
 	mdm.On("ResolveInputData", pm.ctx, "ns1", mock.Anything).Return(fftypes.DataRefs{
@@ -217,3 +233,41 @@ func TestResolveAndSendSealFail(t *testing.T) {
 	assert.Regexp(t, "FF10144", err)
 
 }
+
+func TestResolveAndSendSignFail(t *testing.T) {
+
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	mii := &identitymocks.Plugin{}
+	pm.identity = mii
+	mii.On("Sign", pm.ctx, "org1", mock.Anything).Return("", fmt.Errorf("pop"))
+
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("GetOrganizationByName", pm.ctx, "localorg").Return(&fftypes.Organization{
+		ID: fftypes.NewUUID(),
+	}, nil)
+	mdi.On("GetNodes", pm.ctx, mock.Anything).Return([]*fftypes.Node{
+		{ID: fftypes.NewUUID(), Name: "node1", Owner: "localorg"},
+	}, nil, nil).Once()
+	mdi.On("GetGroups", pm.ctx, mock.Anything).Return([]*fftypes.Group{
+		{Hash: fftypes.NewRandB32()},
+	}, nil, nil).Once()
+
+	mdm := pm.data.(*datamocks.Manager)
+	mdm.On("ResolveInputData", pm.ctx, "ns1", mock.Anything).Return(fftypes.DataRefs{
+		{ID: fftypes.NewUUID(), Hash: fftypes.NewRandB32()},
+	}, nil)
+
+	err := pm.resolveAndSend(pm.ctx, &fftypes.Identity{}, &fftypes.MessageInput{
+		Message: fftypes.Message{Header: fftypes.MessageHeader{Namespace: "ns1", Author: "org1"}},
+		Group: &fftypes.InputGroup{
+			Members: []fftypes.MemberInput{
+				{Identity: "localorg"},
+			},
+		},
+	})
+	assert.Regexp(t, "pop", err)
+	mdi.AssertNotCalled(t, "InsertMessageLocal", mock.Anything, mock.Anything)
+
+}
@@ -25,6 +25,7 @@ import (
 	"github.com/hyperledger-labs/firefly/internal/data"
 	"github.com/hyperledger-labs/firefly/internal/i18n"
 	"github.com/hyperledger-labs/firefly/internal/log"
+	"github.com/hyperledger-labs/firefly/internal/metrics"
 	"github.com/hyperledger-labs/firefly/internal/retry"
 	"github.com/hyperledger-labs/firefly/pkg/blockchain"
 	"github.com/hyperledger-labs/firefly/pkg/database"
@@ -39,6 +40,7 @@ type Manager interface {
 
 	Start() error
 	SendMessage(ctx context.Context, ns string, in *fftypes.MessageInput) (out *fftypes.Message, err error)
+	UpdateGroup(ctx context.Context, ns, hash string, in *fftypes.GroupUpdateInput) (successor *fftypes.Group, err error)
 }
 
 type privateMessaging struct {
@@ -74,6 +76,7 @@ func NewPrivateMessaging(ctx context.Context, di database.Plugin, ii identity.Pl
 		localOrgIdentity: config.GetString(config.OrgIdentity),
 		groupManager: groupManager{
 			database:      di,
+			identity:      ii,
 			data:          dm,
 			groupCacheTTL: config.GetDuration(config.GroupCacheTTL),
 		},
@@ -98,6 +101,7 @@ func NewPrivateMessaging(ctx context.Context, di database.Plugin, ii identity.Pl
 
 	ba.RegisterDispatcher([]fftypes.MessageType{
 		fftypes.MessageTypeGroupInit,
+		fftypes.MessageTypeGroupUpdate,
 		fftypes.MessageTypePrivate,
 	}, pm.dispatchBatch, bo)
 
@@ -117,17 +121,17 @@ func (pm *privateMessaging) dispatchBatch(ctx context.Context, batch *fftypes.Ba
 	}
 
 	// Retrieve the group
-	nodes, err := pm.groupManager.getGroupNodes(ctx, batch.Group)
+	group, nodes, err := pm.groupManager.getGroupNodes(ctx, batch.Group)
 	if err != nil {
 		return err
 	}
 
 	return pm.database.RunAsGroup(ctx, func(ctx context.Context) error {
-		return pm.sendAndSubmitBatch(ctx, batch, nodes, payload, contexts)
+		return pm.sendAndSubmitBatch(ctx, batch, group.Ledger, nodes, payload, contexts)
 	})
 }
 
-func (pm *privateMessaging) sendAndSubmitBatch(ctx context.Context, batch *fftypes.Batch, nodes []*fftypes.Node, payload fftypes.Byteable, contexts []*fftypes.Bytes32) (err error) {
+func (pm *privateMessaging) sendAndSubmitBatch(ctx context.Context, batch *fftypes.Batch, ledgerID *fftypes.UUID, nodes []*fftypes.Node, payload fftypes.Byteable, contexts []*fftypes.Bytes32) (err error) {
 	l := log.L(ctx)
 
 	id, err := pm.identity.Resolve(ctx, batch.Author)
@@ -139,10 +143,22 @@ func (pm *privateMessaging) sendAndSubmitBatch(ctx context.Context, batch *fftyp
 		return err
 	}
 
+	if batch.Payload.TX.Type == fftypes.TransactionTypeNone {
+		// There is no blockchain transaction, but the transaction records the status of delivery to each member
+		if err = pm.insertTransaction(ctx, id, batch, fftypes.TransactionTypeNone); err != nil {
+			return err
+		}
+	}
+
 	// Write it to the dataexchange for each member
 	for i, node := range nodes {
 		l.Infof("Sending batch %s:%s to group=%s node=%s (%d/%d)", batch.Namespace, batch.ID, batch.Group, node.ID, i+1, len(nodes))
 
+		// Any blobs are transferred first, as the batch cannot be dispatched on the receiving side until they arrive
+		if err = pm.transferBlobs(ctx, batch, node); err != nil {
+			return err
+		}
+
 		trackingID, err := pm.exchange.SendMessage(ctx, node, payload)
 		if err != nil {
 			return err
@@ -162,15 +178,53 @@ func (pm *privateMessaging) sendAndSubmitBatch(ctx context.Context, batch *fftyp
 
 	}
 
-	return pm.writeTransaction(ctx, id, batch, contexts)
+	if batch.Payload.TX.Type == fftypes.TransactionTypeNone {
+		// Each member confirms the batch in the order it arrives over data exchange, so there is nothing to pin
+		l.Infof("Batch %s:%s sent without a blockchain transaction", batch.Namespace, batch.ID)
+		return nil
+	}
+	return pm.writeTransaction(ctx, id, ledgerID, batch, contexts)
 }
 
-func (pm *privateMessaging) writeTransaction(ctx context.Context, signingID *fftypes.Identity, batch *fftypes.Batch, contexts []*fftypes.Bytes32) error {
+func (pm *privateMessaging) transferBlobs(ctx context.Context, batch *fftypes.Batch, node *fftypes.Node) error {
+	if node.Owner == pm.localOrgIdentity && node.Name == pm.localNodeName {
+		// The blobs are already stored in our local data exchange
+		return nil
+	}
 
+	for _, d := range batch.Payload.Data {
+		if d == nil || !d.Blobstore {
+			continue
+		}
+		log.L(ctx).Infof("Transferring blob %s:%s to node=%s", batch.Namespace, d.ID, node.ID)
+
+		trackingID, err := pm.exchange.TransferBLOB(ctx, node, batch.Namespace, *d.ID)
+		if err != nil {
+			return err
+		}
+
+		op := fftypes.NewTXOperation(
+			pm.exchange,
+			batch.Namespace,
+			batch.Payload.TX.ID,
+			trackingID,
+			fftypes.OpTypeDataExchangeBlobSend,
+			fftypes.OpStatusPending,
+			node.ID.String())
+		op.Input = fftypes.JSONObject{"data": d.ID.String()}
+		if err = pm.database.UpsertOperation(ctx, op, false); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (pm *privateMessaging) insertTransaction(ctx context.Context, signingID *fftypes.Identity, batch *fftypes.Batch, txType fftypes.TransactionType) error {
 	tx := &fftypes.Transaction{
 		ID: batch.Payload.TX.ID,
 		Subject: fftypes.TransactionSubject{
-			Type:      fftypes.TransactionTypeBatchPin,
+			Type:      txType,
 			Signer:    signingID.OnChain,
 			Namespace: batch.Namespace,
 			Reference: batch.ID,
@@ -179,20 +233,31 @@ func (pm *privateMessaging) writeTransaction(ctx context.Context, signingID *fft
 		Status:  fftypes.OpStatusPending,
 	}
 	tx.Hash = tx.Subject.Hash()
-	err := pm.database.UpsertTransaction(ctx, tx, true, false /* should be new, or idempotent replay */)
+	return pm.database.UpsertTransaction(ctx, tx, true, false /* should be new, or idempotent replay */)
+}
+
+func (pm *privateMessaging) writeTransaction(ctx context.Context, signingID *fftypes.Identity, ledgerID *fftypes.UUID, batch *fftypes.Batch, contexts []*fftypes.Bytes32) error {
+
+	err := pm.insertTransaction(ctx, signingID, batch, fftypes.TransactionTypeBatchPin)
 	if err != nil {
 		return err
 	}
 
-	// Write the batch pin to the blockchain
-	blockchainTrackingID, err := pm.blockchain.SubmitBatchPin(ctx, nil, signingID, &blockchain.BatchPin{
-		Namespace:      batch.Namespace,
-		TransactionID:  batch.Payload.TX.ID,
-		BatchID:        batch.ID,
-		BatchPaylodRef: batch.PayloadRef,
-		BatchHash:      batch.Hash,
-		Contexts:       contexts,
-	})
+	// Write the batch pin to the blockchain, on the ledger of the group
+	submission := &blockchain.BatchPinSubmission{
+		LedgerID: ledgerID,
+		Signer:   signingID,
+		BatchPin: &blockchain.BatchPin{
+			Namespace:      batch.Namespace,
+			TransactionID:  batch.Payload.TX.ID,
+			BatchID:        batch.ID,
+			BatchPaylodRef: batch.PayloadRef,
+			BatchHash:      batch.Hash,
+			Contexts:       contexts,
+		},
+	}
+	blockchainTrackingID, err := pm.blockchain.SubmitBatchPin(ctx, submission.LedgerID, submission.Signer, submission.BatchPin)
+	metrics.BlockchainSubmission(pm.blockchain.Name(), err)
 	if err != nil {
 		return err
 	}
@@ -206,6 +271,11 @@ func (pm *privateMessaging) writeTransaction(ctx context.Context, signingID *fft
 		fftypes.OpTypeBlockchainBatchPin,
 		fftypes.OpStatusPending,
 		"")
+	op.Input = submission.OperationInput()
+	if err := pm.database.UpsertOperation(ctx, op, false); err != nil {
+		return err
+	}
 
-	return pm.database.UpsertOperation(ctx, op, false)
+	event := fftypes.NewEvent(fftypes.EventTypeTransactionSubmitted, batch.Namespace, batch.Payload.TX.ID, nil)
+	return pm.database.UpsertEvent(ctx, event, false)
 }
@@ -48,7 +48,7 @@ func newTestPrivateMessaging(t *testing.T) (*privateMessaging, func()) {
 	mba := &batchmocks.Manager{}
 	mdm := &datamocks.Manager{}
 
-	mba.On("RegisterDispatcher", []fftypes.MessageType{fftypes.MessageTypeGroupInit, fftypes.MessageTypePrivate}, mock.Anything, mock.Anything).Return()
+	mba.On("RegisterDispatcher", []fftypes.MessageType{fftypes.MessageTypeGroupInit, fftypes.MessageTypeGroupUpdate, fftypes.MessageTypePrivate}, mock.Anything, mock.Anything).Return()
 
 	ctx, cancel := context.WithCancel(context.Background())
 	pm, err := NewPrivateMessaging(ctx, mdi, mii, mdx, mbi, mba, mdm)
@@ -65,6 +65,7 @@ func newTestPrivateMessaging(t *testing.T) (*privateMessaging, func()) {
 		Identifier: "org1", OnChain: "0x23456",
 	}, nil).Maybe()
 	mbi.On("VerifyIdentitySyntax", ctx, mock.MatchedBy(func(i *fftypes.Identity) bool { return i.OnChain == "0x23456" })).Return(nil).Maybe()
+	mii.On("Sign", ctx, mock.Anything, mock.Anything).Return("", nil).Maybe()
 
 	return pm.(*privateMessaging), cancel
 }
@@ -98,10 +99,12 @@ func TestDispatchBatch(t *testing.T) {
 		}
 	}
 
+	ledgerID := fftypes.NewUUID()
 	mdi.On("GetGroupByHash", pm.ctx, groupID).Return(&fftypes.Group{
 		Hash: fftypes.NewRandB32(),
 		GroupIdentity: fftypes.GroupIdentity{
-			Name: "group1",
+			Name:   "group1",
+			Ledger: ledgerID,
 			Members: fftypes.Members{
 				{Identity: "org1", Node: node1},
 				{Identity: "org2", Node: node2},
@@ -135,7 +138,7 @@ func TestDispatchBatch(t *testing.T) {
 	mdi.On("UpsertTransaction", pm.ctx, mock.MatchedBy(func(tx *fftypes.Transaction) bool {
 		return tx.Subject.Type == fftypes.TransactionTypeBatchPin && tx.ID.Equals(txID)
 	}), true, false).Return(nil, nil)
-	mbi.On("SubmitBatchPin", pm.ctx, mock.Anything, mock.Anything, mock.MatchedBy(func(bp *blockchain.BatchPin) bool {
+	mbi.On("SubmitBatchPin", pm.ctx, ledgerID, mock.Anything, mock.MatchedBy(func(bp *blockchain.BatchPin) bool {
 		assert.Equal(t, txID, bp.TransactionID)
 		assert.Equal(t, batchID, bp.BatchID)
 		assert.Equal(t, batchHash, bp.BatchHash)
@@ -144,8 +147,12 @@ func TestDispatchBatch(t *testing.T) {
 		return true
 	})).Return("tracking3", nil)
 	mdi.On("UpsertOperation", pm.ctx, mock.MatchedBy(func(op *fftypes.Operation) bool {
-		return op.BackendID == "tracking3" && op.Type == fftypes.OpTypeBlockchainBatchPin
+		return op.BackendID == "tracking3" && op.Type == fftypes.OpTypeBlockchainBatchPin &&
+			op.Input.GetString("ledger") == ledgerID.String()
 	}), false).Return(nil, nil)
+	mdi.On("UpsertEvent", pm.ctx, mock.MatchedBy(func(e *fftypes.Event) bool {
+		return e.Type == fftypes.EventTypeTransactionSubmitted && *e.Reference == *txID
+	}), false).Return(nil)
 
 	err := pm.dispatchBatch(pm.ctx, &fftypes.Batch{
 		ID:        batchID,
@@ -165,6 +172,162 @@ func TestDispatchBatch(t *testing.T) {
 	mdx.AssertExpectations(t)
 }
 
+func TestDispatchBatchWithBlobs(t *testing.T) {
+
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	batchID := fftypes.NewUUID()
+	groupID := fftypes.NewRandB32()
+	pin1 := fftypes.NewRandB32()
+	node1 := fftypes.NewUUID()
+	node2 := fftypes.NewUUID()
+	txID := fftypes.NewUUID()
+	batchHash := fftypes.NewRandB32()
+	dataID := fftypes.NewUUID()
+
+	mdi := pm.database.(*databasemocks.Plugin)
+	mbi := pm.blockchain.(*blockchainmocks.Plugin)
+	mdx := pm.exchange.(*dataexchangemocks.Plugin)
+
+	rag := mdi.On("RunAsGroup", pm.ctx, mock.Anything).Maybe()
+	rag.RunFn = func(a mock.Arguments) {
+		rag.ReturnArguments = mock.Arguments{
+			a[1].(func(context.Context) error)(a[0].(context.Context)),
+		}
+	}
+
+	mdi.On("GetGroupByHash", pm.ctx, groupID).Return(&fftypes.Group{
+		Hash: fftypes.NewRandB32(),
+		GroupIdentity: fftypes.GroupIdentity{
+			Name: "group1",
+			Members: fftypes.Members{
+				{Identity: "localorg", Node: node1},
+				{Identity: "org2", Node: node2},
+			},
+		},
+	}, nil)
+	mdi.On("GetNodeByID", pm.ctx, uuidMatches(node1)).Return(&fftypes.Node{
+		ID:    node1,
+		Name:  "node1",
+		Owner: "localorg",
+		DX: fftypes.DXInfo{
+			Peer:     "node1",
+			Endpoint: fftypes.JSONObject{"url": "https://node1.example.com"},
+		},
+	}, nil).Once()
+	mdi.On("GetNodeByID", pm.ctx, uuidMatches(node2)).Return(&fftypes.Node{
+		ID:    node2,
+		Name:  "node2",
+		Owner: "org2",
+		DX: fftypes.DXInfo{
+			Peer:     "node2",
+			Endpoint: fftypes.JSONObject{"url": "https://node2.example.com"},
+		},
+	}, nil).Once()
+
+	// Only the remote node receives the blob
+	mdx.On("TransferBLOB", pm.ctx, mock.MatchedBy(func(node *fftypes.Node) bool {
+		return node.ID.Equals(node2)
+	}), "ns1", *dataID).Return("tracking1", nil).Once()
+	mdi.On("UpsertOperation", pm.ctx, mock.MatchedBy(func(op *fftypes.Operation) bool {
+		return op.BackendID == "tracking1" && op.Type == fftypes.OpTypeDataExchangeBlobSend && op.Member == node2.String() &&
+			op.Input.GetString("data") == dataID.String()
+	}), false).Return(nil, nil)
+	mdx.On("SendMessage", pm.ctx, mock.Anything, mock.Anything).Return("tracking2", nil).Twice()
+	mdi.On("UpsertOperation", pm.ctx, mock.MatchedBy(func(op *fftypes.Operation) bool {
+		return op.BackendID == "tracking2" && op.Type == fftypes.OpTypeDataExchangeBatchSend
+	}), false).Return(nil, nil)
+
+	mdi.On("UpsertTransaction", pm.ctx, mock.Anything, true, false).Return(nil, nil)
+	mbi.On("SubmitBatchPin", pm.ctx, mock.Anything, mock.Anything, mock.Anything).Return("tracking3", nil)
+	mdi.On("UpsertOperation", pm.ctx, mock.MatchedBy(func(op *fftypes.Operation) bool {
+		return op.BackendID == "tracking3" && op.Type == fftypes.OpTypeBlockchainBatchPin
+	}), false).Return(nil, nil)
+	mdi.On("UpsertEvent", pm.ctx, mock.Anything, false).Return(nil)
+
+	err := pm.dispatchBatch(pm.ctx, &fftypes.Batch{
+		ID:        batchID,
+		Author:    "org1",
+		Group:     groupID,
+		Namespace: "ns1",
+		Payload: fftypes.BatchPayload{
+			TX: fftypes.TransactionRef{
+				ID: txID,
+			},
+			Data: []*fftypes.Data{
+				{ID: fftypes.NewUUID(), Value: fftypes.Byteable(`{}`)},
+				{ID: dataID, Blobstore: true},
+			},
+		},
+		Hash: batchHash,
+	}, []*fftypes.Bytes32{pin1})
+	assert.NoError(t, err)
+
+	mdi.AssertExpectations(t)
+	mdx.AssertExpectations(t)
+}
+
+func TestSendAndSubmitBatchTransferBlobsFail(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	mdx := pm.exchange.(*dataexchangemocks.Plugin)
+	mdx.On("TransferBLOB", pm.ctx, mock.Anything, "ns1", mock.Anything).Return("", fmt.Errorf("pop"))
+
+	err := pm.sendAndSubmitBatch(pm.ctx, &fftypes.Batch{
+		Author:    "org1",
+		Namespace: "ns1",
+		Payload: fftypes.BatchPayload{
+			Data: []*fftypes.Data{
+				{ID: fftypes.NewUUID(), Blobstore: true},
+			},
+		},
+	}, nil, []*fftypes.Node{
+		{ID: fftypes.NewUUID(), Name: "node2", Owner: "org2"},
+	}, fftypes.Byteable(`{}`), []*fftypes.Bytes32{})
+	assert.Regexp(t, "pop", err)
+}
+
+func TestTransferBlobsFail(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	mdx := pm.exchange.(*dataexchangemocks.Plugin)
+	mdx.On("TransferBLOB", pm.ctx, mock.Anything, "ns1", mock.Anything).Return("", fmt.Errorf("pop"))
+
+	err := pm.transferBlobs(pm.ctx, &fftypes.Batch{
+		Namespace: "ns1",
+		Payload: fftypes.BatchPayload{
+			Data: []*fftypes.Data{
+				{ID: fftypes.NewUUID(), Blobstore: true},
+			},
+		},
+	}, &fftypes.Node{ID: fftypes.NewUUID(), Name: "node2", Owner: "org2"})
+	assert.Regexp(t, "pop", err)
+}
+
+func TestTransferBlobsUpsertOperationFail(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	mdx := pm.exchange.(*dataexchangemocks.Plugin)
+	mdx.On("TransferBLOB", pm.ctx, mock.Anything, "ns1", mock.Anything).Return("tracking1", nil)
+
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("UpsertOperation", pm.ctx, mock.Anything, false).Return(fmt.Errorf("pop"))
+
+	err := pm.transferBlobs(pm.ctx, &fftypes.Batch{
+		Namespace: "ns1",
+		Payload: fftypes.BatchPayload{
+			Data: []*fftypes.Data{
+				{ID: fftypes.NewUUID(), Blobstore: true},
+			},
+		},
+	}, &fftypes.Node{ID: fftypes.NewUUID(), Name: "node2", Owner: "org2"})
+	assert.Regexp(t, "pop", err)
+}
+
 func TestNewPrivateMessagingMissingDeps(t *testing.T) {
 	_, err := NewPrivateMessaging(context.Background(), nil, nil, nil, nil, nil, nil)
 	assert.Regexp(t, "FF10128", err)
@@ -210,7 +373,7 @@ func TestSendAndSubmitBatchBadID(t *testing.T) {
 
 	err := pm.sendAndSubmitBatch(pm.ctx, &fftypes.Batch{
 		Author: "badauthor",
-	}, []*fftypes.Node{}, fftypes.Byteable(`{}`), []*fftypes.Bytes32{})
+	}, nil, []*fftypes.Node{}, fftypes.Byteable(`{}`), []*fftypes.Bytes32{})
 	assert.Regexp(t, "pop", err)
 }
 
@@ -223,7 +386,7 @@ func TestSendImmediateFail(t *testing.T) {
 
 	err := pm.sendAndSubmitBatch(pm.ctx, &fftypes.Batch{
 		Author: "org1",
-	}, []*fftypes.Node{
+	}, nil, []*fftypes.Node{
 		{
 			DX: fftypes.DXInfo{
 				Peer:     "node1",
@@ -246,7 +409,7 @@ func TestSendSubmitUpsertOperationFail(t *testing.T) {
 
 	err := pm.sendAndSubmitBatch(pm.ctx, &fftypes.Batch{
 		Author: "org1",
-	}, []*fftypes.Node{
+	}, nil, []*fftypes.Node{
 		{
 			DX: fftypes.DXInfo{
 				Peer:     "node1",
@@ -257,6 +420,64 @@ func TestSendSubmitUpsertOperationFail(t *testing.T) {
 	assert.Regexp(t, "pop", err)
 }
 
+func TestSendUnpinnedBatch(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	mdx := pm.exchange.(*dataexchangemocks.Plugin)
+	mdx.On("SendMessage", pm.ctx, mock.Anything, mock.Anything).Return("tracking1", nil)
+
+	txID := fftypes.NewUUID()
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("UpsertTransaction", pm.ctx, mock.MatchedBy(func(tx *fftypes.Transaction) bool {
+		return *tx.ID == *txID && tx.Subject.Type == fftypes.TransactionTypeNone
+	}), true, false).Return(nil)
+	mdi.On("UpsertOperation", pm.ctx, mock.MatchedBy(func(op *fftypes.Operation) bool {
+		return *op.Transaction == *txID
+	}), false).Return(nil)
+
+	err := pm.sendAndSubmitBatch(pm.ctx, &fftypes.Batch{
+		Author: "org1",
+		Payload: fftypes.BatchPayload{
+			TX: fftypes.TransactionRef{
+				Type: fftypes.TransactionTypeNone,
+				ID:   txID,
+			},
+		},
+	}, nil, []*fftypes.Node{
+		{
+			DX: fftypes.DXInfo{
+				Peer:     "node1",
+				Endpoint: fftypes.JSONObject{"url": "https://node1.example.com"},
+			},
+		},
+	}, fftypes.Byteable(`{}`), nil)
+	assert.NoError(t, err)
+
+	// The transaction records delivery, but nothing is submitted to the blockchain
+	mdx.AssertExpectations(t)
+	mdi.AssertExpectations(t)
+}
+
+func TestSendUnpinnedBatchUpsertTransactionFail(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("UpsertTransaction", pm.ctx, mock.Anything, true, false).Return(fmt.Errorf("pop"))
+
+	err := pm.sendAndSubmitBatch(pm.ctx, &fftypes.Batch{
+		Author: "org1",
+		Payload: fftypes.BatchPayload{
+			TX: fftypes.TransactionRef{
+				Type: fftypes.TransactionTypeNone,
+				ID:   fftypes.NewUUID(),
+			},
+		},
+	}, nil, []*fftypes.Node{}, fftypes.Byteable(`{}`), nil)
+	assert.EqualError(t, err, "pop")
+}
+
 func TestWriteTransactionUpsertFail(t *testing.T) {
 	pm, cancel := newTestPrivateMessaging(t)
 	defer cancel()
@@ -264,7 +485,7 @@ func TestWriteTransactionUpsertFail(t *testing.T) {
 	mdi := pm.database.(*databasemocks.Plugin)
 	mdi.On("UpsertTransaction", pm.ctx, mock.Anything, true, false).Return(fmt.Errorf("pop"))
 
-	err := pm.writeTransaction(pm.ctx, &fftypes.Identity{OnChain: "0x12345"}, &fftypes.Batch{}, []*fftypes.Bytes32{})
+	err := pm.writeTransaction(pm.ctx, &fftypes.Identity{OnChain: "0x12345"}, nil, &fftypes.Batch{}, []*fftypes.Bytes32{})
 	assert.Regexp(t, "pop", err)
 }
 
@@ -278,7 +499,7 @@ func TestWriteTransactionSubmitBatchPinFail(t *testing.T) {
 	mbi := pm.blockchain.(*blockchainmocks.Plugin)
 	mbi.On("SubmitBatchPin", pm.ctx, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return("", fmt.Errorf("pop"))
 
-	err := pm.writeTransaction(pm.ctx, &fftypes.Identity{OnChain: "0x12345"}, &fftypes.Batch{}, []*fftypes.Bytes32{})
+	err := pm.writeTransaction(pm.ctx, &fftypes.Identity{OnChain: "0x12345"}, nil, &fftypes.Batch{}, []*fftypes.Bytes32{})
 	assert.Regexp(t, "pop", err)
 }
 
@@ -294,7 +515,24 @@ func TestWriteTransactionUpsertOpFail(t *testing.T) {
 
 	mdi.On("UpsertOperation", pm.ctx, mock.Anything, false).Return(fmt.Errorf("pop"))
 
-	err := pm.writeTransaction(pm.ctx, &fftypes.Identity{OnChain: "0x12345"}, &fftypes.Batch{}, []*fftypes.Bytes32{})
+	err := pm.writeTransaction(pm.ctx, &fftypes.Identity{OnChain: "0x12345"}, nil, &fftypes.Batch{}, []*fftypes.Bytes32{})
+	assert.Regexp(t, "pop", err)
+}
+
+func TestWriteTransactionUpsertEventFail(t *testing.T) {
+	pm, cancel := newTestPrivateMessaging(t)
+	defer cancel()
+
+	mdi := pm.database.(*databasemocks.Plugin)
+	mdi.On("UpsertTransaction", pm.ctx, mock.Anything, true, false).Return(nil)
+
+	mbi := pm.blockchain.(*blockchainmocks.Plugin)
+	mbi.On("SubmitBatchPin", pm.ctx, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return("tracking1", nil)
+
+	mdi.On("UpsertOperation", pm.ctx, mock.Anything, false).Return(nil)
+	mdi.On("UpsertEvent", pm.ctx, mock.Anything, false).Return(fmt.Errorf("pop"))
+
+	err := pm.writeTransaction(pm.ctx, &fftypes.Identity{OnChain: "0x12345"}, nil, &fftypes.Batch{}, []*fftypes.Bytes32{})
 	assert.Regexp(t, "pop", err)
 }
 
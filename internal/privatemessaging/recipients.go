@@ -29,6 +29,13 @@ import (
 func (pm *privateMessaging) resolveReceipientList(ctx context.Context, sender *fftypes.Identity, in *fftypes.MessageInput) error {
 	if in.Header.Group != nil {
 		log.L(ctx).Debugf("Group '%s' specified for message", in.Header.Group)
+		group, err := pm.database.GetGroupByHash(ctx, in.Header.Group)
+		if err != nil {
+			return err
+		}
+		if group != nil && group.Successor != nil {
+			return i18n.NewError(ctx, i18n.MsgGroupRetired, group.Hash, group.Successor)
+		}
 		return nil // validity of existing group checked later
 	}
 	if in.Group == nil || len(in.Group.Members) == 0 {
@@ -64,6 +71,9 @@ func (pm *privateMessaging) resolveOrg(ctx context.Context, orgInput string) (or
 	if org == nil {
 		return nil, i18n.NewError(ctx, i18n.MsgOrgNotFound, orgInput)
 	}
+	if org.Revoked != nil {
+		return nil, i18n.NewError(ctx, i18n.MsgOrgRevoked, orgInput)
+	}
 	return org, nil
 }
 
@@ -81,8 +91,12 @@ func (pm *privateMessaging) resolveNode(ctx context.Context, org *fftypes.Organi
 		var nodes []*fftypes.Node
 		originalOrgName := fmt.Sprintf("%s/%s", org.Name, org.Identity)
 		for org != nil && node == nil {
-			filter := database.NodeQueryFactory.NewFilterLimit(ctx, 1).Eq("owner", org.Identity)
-			nodes, err = pm.database.GetNodes(ctx, filter)
+			fb := database.NodeQueryFactory.NewFilterLimit(ctx, 1)
+			filter := fb.And(
+				fb.Eq("owner", org.Identity),
+				fb.Eq("revoked", nil),
+			)
+			nodes, _, err = pm.database.GetNodes(ctx, filter)
 			switch {
 			case err == nil && len(nodes) > 0:
 				// This org owns a node
@@ -101,6 +115,9 @@ func (pm *privateMessaging) resolveNode(ctx context.Context, org *fftypes.Organi
 	if node == nil {
 		return nil, i18n.NewError(ctx, i18n.MsgNodeNotFound, nodeInput)
 	}
+	if node.Revoked != nil {
+		return nil, i18n.NewError(ctx, i18n.MsgNodeRevoked, nodeInput)
+	}
 	return node, nil
 }
 
@@ -142,11 +159,14 @@ func (pm *privateMessaging) findOrGenerateGroup(ctx context.Context, in *fftypes
 	}
 	hash := gi.Hash()
 	filter := database.GroupQueryFactory.NewFilterLimit(ctx, 1).Eq("hash", hash)
-	groups, err := pm.database.GetGroups(ctx, filter)
+	groups, _, err := pm.database.GetGroups(ctx, filter)
 	if err != nil {
 		return nil, false, err
 	}
 	if len(groups) > 0 {
+		if groups[0].Successor != nil {
+			return nil, false, i18n.NewError(ctx, i18n.MsgGroupRetired, groups[0].Hash, groups[0].Successor)
+		}
 		return groups[0], false, nil
 	}
 
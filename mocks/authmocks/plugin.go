@@ -0,0 +1,75 @@
+// Code generated by mockery v1.0.0. DO NOT EDIT.
+
+package authmocks
+
+import (
+	config "github.com/hyperledger-labs/firefly/internal/config"
+	auth "github.com/hyperledger-labs/firefly/pkg/auth"
+
+	context "context"
+
+	http "net/http"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// Plugin is an autogenerated mock type for the Plugin type
+type Plugin struct {
+	mock.Mock
+}
+
+// Authenticate provides a mock function with given fields: ctx, req
+func (_m *Plugin) Authenticate(ctx context.Context, req *http.Request) (*auth.Principal, error) {
+	ret := _m.Called(ctx, req)
+
+	var r0 *auth.Principal
+	if rf, ok := ret.Get(0).(func(context.Context, *http.Request) *auth.Principal); ok {
+		r0 = rf(ctx, req)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*auth.Principal)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *http.Request) error); ok {
+		r1 = rf(ctx, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Init provides a mock function with given fields: ctx, prefix
+func (_m *Plugin) Init(ctx context.Context, prefix config.Prefix) error {
+	ret := _m.Called(ctx, prefix)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, config.Prefix) error); ok {
+		r0 = rf(ctx, prefix)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// InitPrefix provides a mock function with given fields: prefix
+func (_m *Plugin) InitPrefix(prefix config.Prefix) {
+	_m.Called(prefix)
+}
+
+// Name provides a mock function with given fields:
+func (_m *Plugin) Name() string {
+	ret := _m.Called()
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func() string); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	return r0
+}
@@ -81,6 +81,22 @@ func (_m *Plugin) Start() error {
 	return r0
 }
 
+// Status provides a mock function with given fields:
+func (_m *Plugin) Status() fftypes.JSONObject {
+	ret := _m.Called()
+
+	var r0 fftypes.JSONObject
+	if rf, ok := ret.Get(0).(func() fftypes.JSONObject); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(fftypes.JSONObject)
+		}
+	}
+
+	return r0
+}
+
 // SubmitBatchPin provides a mock function with given fields: ctx, ledgerID, identity, batch
 func (_m *Plugin) SubmitBatchPin(ctx context.Context, ledgerID *fftypes.UUID, identity *fftypes.Identity, batch *blockchain.BatchPin) (string, error) {
 	ret := _m.Called(ctx, ledgerID, identity, batch)
@@ -102,6 +118,11 @@ func (_m *Plugin) SubmitBatchPin(ctx context.Context, ledgerID *fftypes.UUID, id
 	return r0, r1
 }
 
+// TrackPendingBatchPins provides a mock function with given fields: ctx, txTrackingIDs
+func (_m *Plugin) TrackPendingBatchPins(ctx context.Context, txTrackingIDs []string) {
+	_m.Called(ctx, txTrackingIDs)
+}
+
 // VerifyIdentitySyntax provides a mock function with given fields: ctx, identity
 func (_m *Plugin) VerifyIdentitySyntax(ctx context.Context, identity *fftypes.Identity) error {
 	ret := _m.Called(ctx, identity)
@@ -19,6 +19,27 @@ type Plugin struct {
 	mock.Mock
 }
 
+// AcquireLease provides a mock function with given fields: ctx, lease
+func (_m *Plugin) AcquireLease(ctx context.Context, lease *fftypes.Lease) (bool, error) {
+	ret := _m.Called(ctx, lease)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.Lease) bool); ok {
+		r0 = rf(ctx, lease)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *fftypes.Lease) error); ok {
+		r1 = rf(ctx, lease)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Capabilities provides a mock function with given fields:
 func (_m *Plugin) Capabilities() *database.Capabilities {
 	ret := _m.Called()
@@ -157,7 +178,7 @@ func (_m *Plugin) GetBatchByID(ctx context.Context, id *fftypes.UUID) (*fftypes.
 }
 
 // GetBatches provides a mock function with given fields: ctx, filter
-func (_m *Plugin) GetBatches(ctx context.Context, filter database.Filter) ([]*fftypes.Batch, error) {
+func (_m *Plugin) GetBatches(ctx context.Context, filter database.Filter) ([]*fftypes.Batch, *database.FilterResult, error) {
 	ret := _m.Called(ctx, filter)
 
 	var r0 []*fftypes.Batch
@@ -169,6 +190,38 @@ func (_m *Plugin) GetBatches(ctx context.Context, filter database.Filter) ([]*ff
 		}
 	}
 
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, database.Filter) *database.FilterResult); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, database.Filter) error); ok {
+		r2 = rf(ctx, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetBlobs provides a mock function with given fields: ctx, filter
+func (_m *Plugin) GetBlobs(ctx context.Context, filter database.Filter) ([]*fftypes.Blob, error) {
+	ret := _m.Called(ctx, filter)
+
+	var r0 []*fftypes.Blob
+	if rf, ok := ret.Get(0).(func(context.Context, database.Filter) []*fftypes.Blob); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*fftypes.Blob)
+		}
+	}
+
 	var r1 error
 	if rf, ok := ret.Get(1).(func(context.Context, database.Filter) error); ok {
 		r1 = rf(ctx, filter)
@@ -203,7 +256,7 @@ func (_m *Plugin) GetConfigRecord(ctx context.Context, key string) (*fftypes.Con
 }
 
 // GetConfigRecords provides a mock function with given fields: ctx, filter
-func (_m *Plugin) GetConfigRecords(ctx context.Context, filter database.Filter) ([]*fftypes.ConfigRecord, error) {
+func (_m *Plugin) GetConfigRecords(ctx context.Context, filter database.Filter) ([]*fftypes.ConfigRecord, *database.FilterResult, error) {
 	ret := _m.Called(ctx, filter)
 
 	var r0 []*fftypes.ConfigRecord
@@ -215,18 +268,27 @@ func (_m *Plugin) GetConfigRecords(ctx context.Context, filter database.Filter)
 		}
 	}
 
-	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, database.Filter) error); ok {
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, database.Filter) *database.FilterResult); ok {
 		r1 = rf(ctx, filter)
 	} else {
-		r1 = ret.Error(1)
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
 	}
 
-	return r0, r1
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, database.Filter) error); ok {
+		r2 = rf(ctx, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
 }
 
 // GetData provides a mock function with given fields: ctx, filter
-func (_m *Plugin) GetData(ctx context.Context, filter database.Filter) ([]*fftypes.Data, error) {
+func (_m *Plugin) GetData(ctx context.Context, filter database.Filter) ([]*fftypes.Data, *database.FilterResult, error) {
 	ret := _m.Called(ctx, filter)
 
 	var r0 []*fftypes.Data
@@ -238,14 +300,23 @@ func (_m *Plugin) GetData(ctx context.Context, filter database.Filter) ([]*fftyp
 		}
 	}
 
-	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, database.Filter) error); ok {
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, database.Filter) *database.FilterResult); ok {
 		r1 = rf(ctx, filter)
 	} else {
-		r1 = ret.Error(1)
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
 	}
 
-	return r0, r1
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, database.Filter) error); ok {
+		r2 = rf(ctx, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
 }
 
 // GetDataByID provides a mock function with given fields: ctx, id, withValue
@@ -341,7 +412,7 @@ func (_m *Plugin) GetDatatypeByName(ctx context.Context, ns string, name string,
 }
 
 // GetDatatypes provides a mock function with given fields: ctx, filter
-func (_m *Plugin) GetDatatypes(ctx context.Context, filter database.Filter) ([]*fftypes.Datatype, error) {
+func (_m *Plugin) GetDatatypes(ctx context.Context, filter database.Filter) ([]*fftypes.Datatype, *database.FilterResult, error) {
 	ret := _m.Called(ctx, filter)
 
 	var r0 []*fftypes.Datatype
@@ -353,14 +424,23 @@ func (_m *Plugin) GetDatatypes(ctx context.Context, filter database.Filter) ([]*
 		}
 	}
 
-	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, database.Filter) error); ok {
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, database.Filter) *database.FilterResult); ok {
 		r1 = rf(ctx, filter)
 	} else {
-		r1 = ret.Error(1)
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
 	}
 
-	return r0, r1
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, database.Filter) error); ok {
+		r2 = rf(ctx, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
 }
 
 // GetEventByID provides a mock function with given fields: ctx, id
@@ -387,7 +467,7 @@ func (_m *Plugin) GetEventByID(ctx context.Context, id *fftypes.UUID) (*fftypes.
 }
 
 // GetEvents provides a mock function with given fields: ctx, filter
-func (_m *Plugin) GetEvents(ctx context.Context, filter database.Filter) ([]*fftypes.Event, error) {
+func (_m *Plugin) GetEvents(ctx context.Context, filter database.Filter) ([]*fftypes.Event, *database.FilterResult, error) {
 	ret := _m.Called(ctx, filter)
 
 	var r0 []*fftypes.Event
@@ -399,14 +479,23 @@ func (_m *Plugin) GetEvents(ctx context.Context, filter database.Filter) ([]*fft
 		}
 	}
 
-	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, database.Filter) error); ok {
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, database.Filter) *database.FilterResult); ok {
 		r1 = rf(ctx, filter)
 	} else {
-		r1 = ret.Error(1)
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
 	}
 
-	return r0, r1
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, database.Filter) error); ok {
+		r2 = rf(ctx, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
 }
 
 // GetGroupByHash provides a mock function with given fields: ctx, hash
@@ -433,7 +522,7 @@ func (_m *Plugin) GetGroupByHash(ctx context.Context, hash *fftypes.Bytes32) (*f
 }
 
 // GetGroups provides a mock function with given fields: ctx, filter
-func (_m *Plugin) GetGroups(ctx context.Context, filter database.Filter) ([]*fftypes.Group, error) {
+func (_m *Plugin) GetGroups(ctx context.Context, filter database.Filter) ([]*fftypes.Group, *database.FilterResult, error) {
 	ret := _m.Called(ctx, filter)
 
 	var r0 []*fftypes.Group
@@ -445,9 +534,41 @@ func (_m *Plugin) GetGroups(ctx context.Context, filter database.Filter) ([]*fft
 		}
 	}
 
-	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, database.Filter) error); ok {
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, database.Filter) *database.FilterResult); ok {
 		r1 = rf(ctx, filter)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, database.Filter) error); ok {
+		r2 = rf(ctx, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetLease provides a mock function with given fields: ctx, name
+func (_m *Plugin) GetLease(ctx context.Context, name string) (*fftypes.Lease, error) {
+	ret := _m.Called(ctx, name)
+
+	var r0 *fftypes.Lease
+	if rf, ok := ret.Get(0).(func(context.Context, string) *fftypes.Lease); ok {
+		r0 = rf(ctx, name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.Lease)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, name)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -502,7 +623,7 @@ func (_m *Plugin) GetMessageRefs(ctx context.Context, filter database.Filter) ([
 }
 
 // GetMessages provides a mock function with given fields: ctx, filter
-func (_m *Plugin) GetMessages(ctx context.Context, filter database.Filter) ([]*fftypes.Message, error) {
+func (_m *Plugin) GetMessages(ctx context.Context, filter database.Filter) ([]*fftypes.Message, *database.FilterResult, error) {
 	ret := _m.Called(ctx, filter)
 
 	var r0 []*fftypes.Message
@@ -514,18 +635,27 @@ func (_m *Plugin) GetMessages(ctx context.Context, filter database.Filter) ([]*f
 		}
 	}
 
-	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, database.Filter) error); ok {
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, database.Filter) *database.FilterResult); ok {
 		r1 = rf(ctx, filter)
 	} else {
-		r1 = ret.Error(1)
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
 	}
 
-	return r0, r1
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, database.Filter) error); ok {
+		r2 = rf(ctx, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
 }
 
 // GetMessagesForData provides a mock function with given fields: ctx, dataID, filter
-func (_m *Plugin) GetMessagesForData(ctx context.Context, dataID *fftypes.UUID, filter database.Filter) ([]*fftypes.Message, error) {
+func (_m *Plugin) GetMessagesForData(ctx context.Context, dataID *fftypes.UUID, filter database.Filter) ([]*fftypes.Message, *database.FilterResult, error) {
 	ret := _m.Called(ctx, dataID, filter)
 
 	var r0 []*fftypes.Message
@@ -537,14 +667,23 @@ func (_m *Plugin) GetMessagesForData(ctx context.Context, dataID *fftypes.UUID,
 		}
 	}
 
-	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, *fftypes.UUID, database.Filter) error); ok {
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, *fftypes.UUID, database.Filter) *database.FilterResult); ok {
 		r1 = rf(ctx, dataID, filter)
 	} else {
-		r1 = ret.Error(1)
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
 	}
 
-	return r0, r1
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, *fftypes.UUID, database.Filter) error); ok {
+		r2 = rf(ctx, dataID, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
 }
 
 // GetNamespace provides a mock function with given fields: ctx, name
@@ -571,7 +710,7 @@ func (_m *Plugin) GetNamespace(ctx context.Context, name string) (*fftypes.Names
 }
 
 // GetNamespaces provides a mock function with given fields: ctx, filter
-func (_m *Plugin) GetNamespaces(ctx context.Context, filter database.Filter) ([]*fftypes.Namespace, error) {
+func (_m *Plugin) GetNamespaces(ctx context.Context, filter database.Filter) ([]*fftypes.Namespace, *database.FilterResult, error) {
 	ret := _m.Called(ctx, filter)
 
 	var r0 []*fftypes.Namespace
@@ -583,14 +722,23 @@ func (_m *Plugin) GetNamespaces(ctx context.Context, filter database.Filter) ([]
 		}
 	}
 
-	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, database.Filter) error); ok {
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, database.Filter) *database.FilterResult); ok {
 		r1 = rf(ctx, filter)
 	} else {
-		r1 = ret.Error(1)
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
 	}
 
-	return r0, r1
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, database.Filter) error); ok {
+		r2 = rf(ctx, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
 }
 
 // GetNextPinByContextAndIdentity provides a mock function with given fields: ctx, _a1, identity
@@ -708,8 +856,40 @@ func (_m *Plugin) GetNodeByID(ctx context.Context, id *fftypes.UUID) (*fftypes.N
 	return r0, r1
 }
 
+// GetNodeVersions provides a mock function with given fields: ctx, filter
+func (_m *Plugin) GetNodeVersions(ctx context.Context, filter database.Filter) ([]*fftypes.Node, *database.FilterResult, error) {
+	ret := _m.Called(ctx, filter)
+
+	var r0 []*fftypes.Node
+	if rf, ok := ret.Get(0).(func(context.Context, database.Filter) []*fftypes.Node); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*fftypes.Node)
+		}
+	}
+
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, database.Filter) *database.FilterResult); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, database.Filter) error); ok {
+		r2 = rf(ctx, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // GetNodes provides a mock function with given fields: ctx, filter
-func (_m *Plugin) GetNodes(ctx context.Context, filter database.Filter) ([]*fftypes.Node, error) {
+func (_m *Plugin) GetNodes(ctx context.Context, filter database.Filter) ([]*fftypes.Node, *database.FilterResult, error) {
 	ret := _m.Called(ctx, filter)
 
 	var r0 []*fftypes.Node
@@ -721,14 +901,23 @@ func (_m *Plugin) GetNodes(ctx context.Context, filter database.Filter) ([]*ffty
 		}
 	}
 
-	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, database.Filter) error); ok {
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, database.Filter) *database.FilterResult); ok {
 		r1 = rf(ctx, filter)
 	} else {
-		r1 = ret.Error(1)
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
 	}
 
-	return r0, r1
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, database.Filter) error); ok {
+		r2 = rf(ctx, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
 }
 
 // GetNonce provides a mock function with given fields: ctx, hash
@@ -847,7 +1036,7 @@ func (_m *Plugin) GetOperationByID(ctx context.Context, id *fftypes.UUID) (*ffty
 }
 
 // GetOperations provides a mock function with given fields: ctx, filter
-func (_m *Plugin) GetOperations(ctx context.Context, filter database.Filter) ([]*fftypes.Operation, error) {
+func (_m *Plugin) GetOperations(ctx context.Context, filter database.Filter) ([]*fftypes.Operation, *database.FilterResult, error) {
 	ret := _m.Called(ctx, filter)
 
 	var r0 []*fftypes.Operation
@@ -859,14 +1048,23 @@ func (_m *Plugin) GetOperations(ctx context.Context, filter database.Filter) ([]
 		}
 	}
 
-	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, database.Filter) error); ok {
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, database.Filter) *database.FilterResult); ok {
 		r1 = rf(ctx, filter)
 	} else {
-		r1 = ret.Error(1)
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
 	}
 
-	return r0, r1
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, database.Filter) error); ok {
+		r2 = rf(ctx, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
 }
 
 // GetOrganizationByID provides a mock function with given fields: ctx, id
@@ -938,8 +1136,40 @@ func (_m *Plugin) GetOrganizationByName(ctx context.Context, name string) (*ffty
 	return r0, r1
 }
 
+// GetOrganizationVersions provides a mock function with given fields: ctx, filter
+func (_m *Plugin) GetOrganizationVersions(ctx context.Context, filter database.Filter) ([]*fftypes.Organization, *database.FilterResult, error) {
+	ret := _m.Called(ctx, filter)
+
+	var r0 []*fftypes.Organization
+	if rf, ok := ret.Get(0).(func(context.Context, database.Filter) []*fftypes.Organization); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*fftypes.Organization)
+		}
+	}
+
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, database.Filter) *database.FilterResult); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, database.Filter) error); ok {
+		r2 = rf(ctx, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // GetOrganizations provides a mock function with given fields: ctx, filter
-func (_m *Plugin) GetOrganizations(ctx context.Context, filter database.Filter) ([]*fftypes.Organization, error) {
+func (_m *Plugin) GetOrganizations(ctx context.Context, filter database.Filter) ([]*fftypes.Organization, *database.FilterResult, error) {
 	ret := _m.Called(ctx, filter)
 
 	var r0 []*fftypes.Organization
@@ -951,14 +1181,23 @@ func (_m *Plugin) GetOrganizations(ctx context.Context, filter database.Filter)
 		}
 	}
 
-	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, database.Filter) error); ok {
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, database.Filter) *database.FilterResult); ok {
 		r1 = rf(ctx, filter)
 	} else {
-		r1 = ret.Error(1)
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
 	}
 
-	return r0, r1
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, database.Filter) error); ok {
+		r2 = rf(ctx, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
 }
 
 // GetPins provides a mock function with given fields: ctx, filter
@@ -1031,7 +1270,7 @@ func (_m *Plugin) GetSubscriptionByName(ctx context.Context, ns string, name str
 }
 
 // GetSubscriptions provides a mock function with given fields: ctx, filter
-func (_m *Plugin) GetSubscriptions(ctx context.Context, filter database.Filter) ([]*fftypes.Subscription, error) {
+func (_m *Plugin) GetSubscriptions(ctx context.Context, filter database.Filter) ([]*fftypes.Subscription, *database.FilterResult, error) {
 	ret := _m.Called(ctx, filter)
 
 	var r0 []*fftypes.Subscription
@@ -1043,14 +1282,23 @@ func (_m *Plugin) GetSubscriptions(ctx context.Context, filter database.Filter)
 		}
 	}
 
-	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, database.Filter) error); ok {
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, database.Filter) *database.FilterResult); ok {
 		r1 = rf(ctx, filter)
 	} else {
-		r1 = ret.Error(1)
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
 	}
 
-	return r0, r1
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, database.Filter) error); ok {
+		r2 = rf(ctx, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
 }
 
 // GetTransactionByID provides a mock function with given fields: ctx, id
@@ -1077,7 +1325,7 @@ func (_m *Plugin) GetTransactionByID(ctx context.Context, id *fftypes.UUID) (*ff
 }
 
 // GetTransactions provides a mock function with given fields: ctx, filter
-func (_m *Plugin) GetTransactions(ctx context.Context, filter database.Filter) ([]*fftypes.Transaction, error) {
+func (_m *Plugin) GetTransactions(ctx context.Context, filter database.Filter) ([]*fftypes.Transaction, *database.FilterResult, error) {
 	ret := _m.Called(ctx, filter)
 
 	var r0 []*fftypes.Transaction
@@ -1089,14 +1337,23 @@ func (_m *Plugin) GetTransactions(ctx context.Context, filter database.Filter) (
 		}
 	}
 
-	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, database.Filter) error); ok {
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, database.Filter) *database.FilterResult); ok {
 		r1 = rf(ctx, filter)
 	} else {
-		r1 = ret.Error(1)
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
 	}
 
-	return r0, r1
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, database.Filter) error); ok {
+		r2 = rf(ctx, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
 }
 
 // Init provides a mock function with given fields: ctx, prefix, callbacks
@@ -1118,6 +1375,20 @@ func (_m *Plugin) InitPrefix(prefix config.Prefix) {
 	_m.Called(prefix)
 }
 
+// InsertBlob provides a mock function with given fields: ctx, blob
+func (_m *Plugin) InsertBlob(ctx context.Context, blob *fftypes.Blob) error {
+	ret := _m.Called(ctx, blob)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.Blob) error); ok {
+		r0 = rf(ctx, blob)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // InsertMessageLocal provides a mock function with given fields: ctx, message
 func (_m *Plugin) InsertMessageLocal(ctx context.Context, message *fftypes.Message) error {
 	ret := _m.Called(ctx, message)
@@ -1146,6 +1417,34 @@ func (_m *Plugin) InsertNextPin(ctx context.Context, nextpin *fftypes.NextPin) e
 	return r0
 }
 
+// InsertNodeVersion provides a mock function with given fields: ctx, data
+func (_m *Plugin) InsertNodeVersion(ctx context.Context, data *fftypes.Node) error {
+	ret := _m.Called(ctx, data)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.Node) error); ok {
+		r0 = rf(ctx, data)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// InsertOrganizationVersion provides a mock function with given fields: ctx, data
+func (_m *Plugin) InsertOrganizationVersion(ctx context.Context, data *fftypes.Organization) error {
+	ret := _m.Called(ctx, data)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.Organization) error); ok {
+		r0 = rf(ctx, data)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Name provides a mock function with given fields:
 func (_m *Plugin) Name() string {
 	ret := _m.Called()
@@ -1356,6 +1655,27 @@ func (_m *Plugin) UpdateOperation(ctx context.Context, id *fftypes.UUID, update
 	return r0
 }
 
+// UpdateOperationIfStatus provides a mock function with given fields: ctx, id, statuses, update
+func (_m *Plugin) UpdateOperationIfStatus(ctx context.Context, id *fftypes.UUID, statuses []fftypes.OpStatus, update database.Update) (bool, error) {
+	ret := _m.Called(ctx, id, statuses, update)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.UUID, []fftypes.OpStatus, database.Update) bool); ok {
+		r0 = rf(ctx, id, statuses, update)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *fftypes.UUID, []fftypes.OpStatus, database.Update) error); ok {
+		r1 = rf(ctx, id, statuses, update)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // UpdateOrganization provides a mock function with given fields: ctx, id, update
 func (_m *Plugin) UpdateOrganization(ctx context.Context, id *fftypes.UUID, update database.Update) error {
 	ret := _m.Called(ctx, id, update)
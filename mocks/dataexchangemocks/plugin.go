@@ -137,6 +137,20 @@ func (_m *Plugin) Name() string {
 	return r0
 }
 
+// RemovePeer provides a mock function with given fields: ctx, node
+func (_m *Plugin) RemovePeer(ctx context.Context, node *fftypes.Node) error {
+	ret := _m.Called(ctx, node)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.Node) error); ok {
+		r0 = rf(ctx, node)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // SendMessage provides a mock function with given fields: ctx, node, data
 func (_m *Plugin) SendMessage(ctx context.Context, node *fftypes.Node, data []byte) (string, error) {
 	ret := _m.Called(ctx, node, data)
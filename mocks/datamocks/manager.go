@@ -31,6 +31,61 @@ func (_m *Manager) CheckDatatype(ctx context.Context, ns string, datatype *fftyp
 	return r0
 }
 
+// CopyBlobToDX provides a mock function with given fields: ctx, _a1, reader
+func (_m *Manager) CopyBlobToDX(ctx context.Context, _a1 *fftypes.Data, reader io.Reader) (*fftypes.Blob, error) {
+	ret := _m.Called(ctx, _a1, reader)
+
+	var r0 *fftypes.Blob
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.Data, io.Reader) *fftypes.Blob); ok {
+		r0 = rf(ctx, _a1, reader)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.Blob)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *fftypes.Data, io.Reader) error); ok {
+		r1 = rf(ctx, _a1, reader)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DownloadBLOB provides a mock function with given fields: ctx, ns, dataID
+func (_m *Manager) DownloadBLOB(ctx context.Context, ns string, dataID string) (*fftypes.Data, io.ReadCloser, error) {
+	ret := _m.Called(ctx, ns, dataID)
+
+	var r0 *fftypes.Data
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *fftypes.Data); ok {
+		r0 = rf(ctx, ns, dataID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.Data)
+		}
+	}
+
+	var r1 io.ReadCloser
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) io.ReadCloser); ok {
+		r1 = rf(ctx, ns, dataID)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(io.ReadCloser)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, string, string) error); ok {
+		r2 = rf(ctx, ns, dataID)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // GetMessageData provides a mock function with given fields: ctx, msg, withValue
 func (_m *Manager) GetMessageData(ctx context.Context, msg *fftypes.Message, withValue bool) ([]*fftypes.Data, bool, error) {
 	ret := _m.Called(ctx, msg, withValue)
@@ -84,13 +139,13 @@ func (_m *Manager) ResolveInputData(ctx context.Context, ns string, inData fftyp
 	return r0, r1
 }
 
-// UploadBLOB provides a mock function with given fields: ctx, ns, reader
-func (_m *Manager) UploadBLOB(ctx context.Context, ns string, reader io.Reader) (*fftypes.Data, error) {
-	ret := _m.Called(ctx, ns, reader)
+// UploadBLOB provides a mock function with given fields: ctx, ns, blob, reader
+func (_m *Manager) UploadBLOB(ctx context.Context, ns string, blob *fftypes.BlobRef, reader io.Reader) (*fftypes.Data, error) {
+	ret := _m.Called(ctx, ns, blob, reader)
 
 	var r0 *fftypes.Data
-	if rf, ok := ret.Get(0).(func(context.Context, string, io.Reader) *fftypes.Data); ok {
-		r0 = rf(ctx, ns, reader)
+	if rf, ok := ret.Get(0).(func(context.Context, string, *fftypes.BlobRef, io.Reader) *fftypes.Data); ok {
+		r0 = rf(ctx, ns, blob, reader)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).(*fftypes.Data)
@@ -98,8 +153,8 @@ func (_m *Manager) UploadBLOB(ctx context.Context, ns string, reader io.Reader)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, string, io.Reader) error); ok {
-		r1 = rf(ctx, ns, reader)
+	if rf, ok := ret.Get(1).(func(context.Context, string, *fftypes.BlobRef, io.Reader) error); ok {
+		r1 = rf(ctx, ns, blob, reader)
 	} else {
 		r1 = ret.Error(1)
 	}
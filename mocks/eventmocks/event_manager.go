@@ -19,18 +19,32 @@ type EventManager struct {
 	mock.Mock
 }
 
-// BLOBReceived provides a mock function with given fields: dx, peerID, ns, id
-func (_m *EventManager) BLOBReceived(dx dataexchange.Plugin, peerID string, ns string, id fftypes.UUID) {
-	_m.Called(dx, peerID, ns, id)
+// BLOBReceived provides a mock function with given fields: dx, peerID, hash, ns, id
+func (_m *EventManager) BLOBReceived(dx dataexchange.Plugin, peerID string, hash fftypes.Bytes32, ns string, id fftypes.UUID) {
+	_m.Called(dx, peerID, hash, ns, id)
 }
 
-// BatchPinComplete provides a mock function with given fields: bi, batch, signingIdentity, protocolTxID, additionalInfo
-func (_m *EventManager) BatchPinComplete(bi blockchain.Plugin, batch *blockchain.BatchPin, signingIdentity string, protocolTxID string, additionalInfo fftypes.JSONObject) error {
-	ret := _m.Called(bi, batch, signingIdentity, protocolTxID, additionalInfo)
+// BatchPinComplete provides a mock function with given fields: bi, ledgerID, batch, signingIdentity, protocolTxID, additionalInfo
+func (_m *EventManager) BatchPinComplete(bi blockchain.Plugin, ledgerID *fftypes.UUID, batch *blockchain.BatchPin, signingIdentity string, protocolTxID string, additionalInfo fftypes.JSONObject) error {
+	ret := _m.Called(bi, ledgerID, batch, signingIdentity, protocolTxID, additionalInfo)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(blockchain.Plugin, *blockchain.BatchPin, string, string, fftypes.JSONObject) error); ok {
-		r0 = rf(bi, batch, signingIdentity, protocolTxID, additionalInfo)
+	if rf, ok := ret.Get(0).(func(blockchain.Plugin, *fftypes.UUID, *blockchain.BatchPin, string, string, fftypes.JSONObject) error); ok {
+		r0 = rf(bi, ledgerID, batch, signingIdentity, protocolTxID, additionalInfo)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CancelOperation provides a mock function with given fields: ctx, op
+func (_m *EventManager) CancelOperation(ctx context.Context, op *fftypes.Operation) error {
+	ret := _m.Called(ctx, op)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.Operation) error); ok {
+		r0 = rf(ctx, op)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -149,6 +163,20 @@ func (_m *EventManager) Start() error {
 	return r0
 }
 
+// StartAggregator provides a mock function with given fields:
+func (_m *EventManager) StartAggregator() error {
+	ret := _m.Called()
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // TransferResult provides a mock function with given fields: dx, trackingID, status, info, additionalInfo
 func (_m *EventManager) TransferResult(dx dataexchange.Plugin, trackingID string, status fftypes.OpStatus, info string, additionalInfo fftypes.JSONObject) {
 	_m.Called(dx, trackingID, status, info, additionalInfo)
@@ -168,6 +196,52 @@ func (_m *EventManager) TxSubmissionUpdate(bi blockchain.Plugin, txTrackingID st
 	return r0
 }
 
+// WaitForMessage provides a mock function with given fields: ctx, ns, id
+func (_m *EventManager) WaitForMessage(ctx context.Context, ns string, id *fftypes.UUID) (*fftypes.Message, error) {
+	ret := _m.Called(ctx, ns, id)
+
+	var r0 *fftypes.Message
+	if rf, ok := ret.Get(0).(func(context.Context, string, *fftypes.UUID) *fftypes.Message); ok {
+		r0 = rf(ctx, ns, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.Message)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, *fftypes.UUID) error); ok {
+		r1 = rf(ctx, ns, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// WaitForReply provides a mock function with given fields: ctx, ns, requestID
+func (_m *EventManager) WaitForReply(ctx context.Context, ns string, requestID *fftypes.UUID) (*fftypes.Message, error) {
+	ret := _m.Called(ctx, ns, requestID)
+
+	var r0 *fftypes.Message
+	if rf, ok := ret.Get(0).(func(context.Context, string, *fftypes.UUID) *fftypes.Message); ok {
+		r0 = rf(ctx, ns, requestID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.Message)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, *fftypes.UUID) error); ok {
+		r1 = rf(ctx, ns, requestID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // WaitStop provides a mock function with given fields:
 func (_m *EventManager) WaitStop() {
 	_m.Called()
@@ -35,13 +35,13 @@ func (_m *Plugin) Capabilities() *events.Capabilities {
 	return r0
 }
 
-// DeliveryRequest provides a mock function with given fields: connID, event
-func (_m *Plugin) DeliveryRequest(connID string, event *fftypes.EventDelivery) error {
-	ret := _m.Called(connID, event)
+// DeliveryRequest provides a mock function with given fields: connID, sub, event
+func (_m *Plugin) DeliveryRequest(connID string, sub *fftypes.Subscription, event *fftypes.EventDelivery) error {
+	ret := _m.Called(connID, sub, event)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, *fftypes.EventDelivery) error); ok {
-		r0 = rf(connID, event)
+	if rf, ok := ret.Get(0).(func(string, *fftypes.Subscription, *fftypes.EventDelivery) error); ok {
+		r0 = rf(connID, sub, event)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -81,3 +81,17 @@ func (_m *Plugin) Name() string {
 
 	return r0
 }
+
+// ValidateOptions provides a mock function with given fields: options
+func (_m *Plugin) ValidateOptions(options *fftypes.SubscriptionOptions) error {
+	ret := _m.Called(options)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(*fftypes.SubscriptionOptions) error); ok {
+		r0 = rf(options)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
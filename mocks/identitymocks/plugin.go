@@ -19,6 +19,20 @@ type Plugin struct {
 	mock.Mock
 }
 
+// AttachCredentials provides a mock function with given fields: ctx, org
+func (_m *Plugin) AttachCredentials(ctx context.Context, org *fftypes.Organization) error {
+	ret := _m.Called(ctx, org)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.Organization) error); ok {
+		r0 = rf(ctx, org)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Capabilities provides a mock function with given fields:
 func (_m *Plugin) Capabilities() *identity.Capabilities {
 	ret := _m.Called()
@@ -68,6 +82,29 @@ func (_m *Plugin) Name() string {
 	return r0
 }
 
+// PublicKeys provides a mock function with given fields: ctx, identifier
+func (_m *Plugin) PublicKeys(ctx context.Context, identifier string) (fftypes.PublicKeys, error) {
+	ret := _m.Called(ctx, identifier)
+
+	var r0 fftypes.PublicKeys
+	if rf, ok := ret.Get(0).(func(context.Context, string) fftypes.PublicKeys); ok {
+		r0 = rf(ctx, identifier)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(fftypes.PublicKeys)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, identifier)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Resolve provides a mock function with given fields: ctx, identifier
 func (_m *Plugin) Resolve(ctx context.Context, identifier string) (*fftypes.Identity, error) {
 	ret := _m.Called(ctx, identifier)
@@ -91,6 +128,27 @@ func (_m *Plugin) Resolve(ctx context.Context, identifier string) (*fftypes.Iden
 	return r0, r1
 }
 
+// Sign provides a mock function with given fields: ctx, identifier, hash
+func (_m *Plugin) Sign(ctx context.Context, identifier string, hash *fftypes.Bytes32) (string, error) {
+	ret := _m.Called(ctx, identifier, hash)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(context.Context, string, *fftypes.Bytes32) string); ok {
+		r0 = rf(ctx, identifier, hash)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, *fftypes.Bytes32) error); ok {
+		r1 = rf(ctx, identifier, hash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Start provides a mock function with given fields:
 func (_m *Plugin) Start() error {
 	ret := _m.Called()
@@ -104,3 +162,17 @@ func (_m *Plugin) Start() error {
 
 	return r0
 }
+
+// VerifyOrganization provides a mock function with given fields: ctx, org, parent, at
+func (_m *Plugin) VerifyOrganization(ctx context.Context, org *fftypes.Organization, parent *fftypes.Organization, at *fftypes.FFTime) error {
+	ret := _m.Called(ctx, org, parent, at)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.Organization, *fftypes.Organization, *fftypes.FFTime) error); ok {
+		r0 = rf(ctx, org, parent, at)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
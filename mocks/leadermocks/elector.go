@@ -0,0 +1,63 @@
+// Code generated by mockery v1.0.0. DO NOT EDIT.
+
+package leadermocks
+
+import (
+	fftypes "github.com/hyperledger-labs/firefly/pkg/fftypes"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// Elector is an autogenerated mock type for the Elector type
+type Elector struct {
+	mock.Mock
+}
+
+// IsLeader provides a mock function with given fields:
+func (_m *Elector) IsLeader() bool {
+	ret := _m.Called()
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func() bool); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
+// Start provides a mock function with given fields: onElected, onLost
+func (_m *Elector) Start(onElected func() error, onLost func()) error {
+	ret := _m.Called(onElected, onLost)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(func() error, func()) error); ok {
+		r0 = rf(onElected, onLost)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Status provides a mock function with given fields:
+func (_m *Elector) Status() *fftypes.NodeStatusLeader {
+	ret := _m.Called()
+
+	var r0 *fftypes.NodeStatusLeader
+	if rf, ok := ret.Get(0).(func() *fftypes.NodeStatusLeader); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.NodeStatusLeader)
+		}
+	}
+
+	return r0
+}
+
+// WaitStop provides a mock function with given fields:
+func (_m *Elector) WaitStop() {
+	_m.Called()
+}
@@ -39,8 +39,31 @@ func (_m *Manager) GetNodeByID(ctx context.Context, id string) (*fftypes.Node, e
 	return r0, r1
 }
 
+// GetNodeVersions provides a mock function with given fields: ctx, id
+func (_m *Manager) GetNodeVersions(ctx context.Context, id string) ([]*fftypes.Node, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 []*fftypes.Node
+	if rf, ok := ret.Get(0).(func(context.Context, string) []*fftypes.Node); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*fftypes.Node)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetNodes provides a mock function with given fields: ctx, filter
-func (_m *Manager) GetNodes(ctx context.Context, filter database.AndFilter) ([]*fftypes.Node, error) {
+func (_m *Manager) GetNodes(ctx context.Context, filter database.AndFilter) ([]*fftypes.Node, *database.FilterResult, error) {
 	ret := _m.Called(ctx, filter)
 
 	var r0 []*fftypes.Node
@@ -52,14 +75,23 @@ func (_m *Manager) GetNodes(ctx context.Context, filter database.AndFilter) ([]*
 		}
 	}
 
-	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, database.AndFilter) error); ok {
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, database.AndFilter) *database.FilterResult); ok {
 		r1 = rf(ctx, filter)
 	} else {
-		r1 = ret.Error(1)
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
 	}
 
-	return r0, r1
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, database.AndFilter) error); ok {
+		r2 = rf(ctx, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
 }
 
 // GetOrganizationByID provides a mock function with given fields: ctx, id
@@ -85,8 +117,31 @@ func (_m *Manager) GetOrganizationByID(ctx context.Context, id string) (*fftypes
 	return r0, r1
 }
 
+// GetOrganizationVersions provides a mock function with given fields: ctx, id
+func (_m *Manager) GetOrganizationVersions(ctx context.Context, id string) ([]*fftypes.Organization, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 []*fftypes.Organization
+	if rf, ok := ret.Get(0).(func(context.Context, string) []*fftypes.Organization); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*fftypes.Organization)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // GetOrganizations provides a mock function with given fields: ctx, filter
-func (_m *Manager) GetOrganizations(ctx context.Context, filter database.AndFilter) ([]*fftypes.Organization, error) {
+func (_m *Manager) GetOrganizations(ctx context.Context, filter database.AndFilter) ([]*fftypes.Organization, *database.FilterResult, error) {
 	ret := _m.Called(ctx, filter)
 
 	var r0 []*fftypes.Organization
@@ -98,14 +153,23 @@ func (_m *Manager) GetOrganizations(ctx context.Context, filter database.AndFilt
 		}
 	}
 
-	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, database.AndFilter) error); ok {
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, database.AndFilter) *database.FilterResult); ok {
 		r1 = rf(ctx, filter)
 	} else {
-		r1 = ret.Error(1)
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
 	}
 
-	return r0, r1
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, database.AndFilter) error); ok {
+		r2 = rf(ctx, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
 }
 
 // RegisterNode provides a mock function with given fields: ctx
@@ -176,3 +240,95 @@ func (_m *Manager) RegisterOrganization(ctx context.Context, org *fftypes.Organi
 
 	return r0, r1
 }
+
+// RevokeNode provides a mock function with given fields: ctx, id
+func (_m *Manager) RevokeNode(ctx context.Context, id string) (*fftypes.Message, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 *fftypes.Message
+	if rf, ok := ret.Get(0).(func(context.Context, string) *fftypes.Message); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.Message)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RevokeOrganization provides a mock function with given fields: ctx, id
+func (_m *Manager) RevokeOrganization(ctx context.Context, id string) (*fftypes.Message, error) {
+	ret := _m.Called(ctx, id)
+
+	var r0 *fftypes.Message
+	if rf, ok := ret.Get(0).(func(context.Context, string) *fftypes.Message); ok {
+		r0 = rf(ctx, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.Message)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateNode provides a mock function with given fields: ctx, id, node
+func (_m *Manager) UpdateNode(ctx context.Context, id string, node *fftypes.Node) (*fftypes.Message, error) {
+	ret := _m.Called(ctx, id, node)
+
+	var r0 *fftypes.Message
+	if rf, ok := ret.Get(0).(func(context.Context, string, *fftypes.Node) *fftypes.Message); ok {
+		r0 = rf(ctx, id, node)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.Message)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, *fftypes.Node) error); ok {
+		r1 = rf(ctx, id, node)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateOrganization provides a mock function with given fields: ctx, id, org
+func (_m *Manager) UpdateOrganization(ctx context.Context, id string, org *fftypes.Organization) (*fftypes.Message, error) {
+	ret := _m.Called(ctx, id, org)
+
+	var r0 *fftypes.Message
+	if rf, ok := ret.Get(0).(func(context.Context, string, *fftypes.Organization) *fftypes.Message); ok {
+		r0 = rf(ctx, id, org)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.Message)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, *fftypes.Organization) error); ok {
+		r1 = rf(ctx, id, org)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
@@ -43,6 +43,29 @@ func (_m *Orchestrator) Broadcast() broadcast.Manager {
 	return r0
 }
 
+// CancelOperation provides a mock function with given fields: ctx, ns, id
+func (_m *Orchestrator) CancelOperation(ctx context.Context, ns string, id string) (*fftypes.Operation, error) {
+	ret := _m.Called(ctx, ns, id)
+
+	var r0 *fftypes.Operation
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *fftypes.Operation); ok {
+		r0 = rf(ctx, ns, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.Operation)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, ns, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // CreateSubscription provides a mock function with given fields: ctx, ns, subDef
 func (_m *Orchestrator) CreateSubscription(ctx context.Context, ns string, subDef *fftypes.Subscription) (*fftypes.Subscription, error) {
 	ret := _m.Called(ctx, ns, subDef)
@@ -150,7 +173,7 @@ func (_m *Orchestrator) GetBatchByID(ctx context.Context, ns string, id string)
 }
 
 // GetBatches provides a mock function with given fields: ctx, ns, filter
-func (_m *Orchestrator) GetBatches(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Batch, error) {
+func (_m *Orchestrator) GetBatches(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Batch, *database.FilterResult, error) {
 	ret := _m.Called(ctx, ns, filter)
 
 	var r0 []*fftypes.Batch
@@ -162,9 +185,41 @@ func (_m *Orchestrator) GetBatches(ctx context.Context, ns string, filter databa
 		}
 	}
 
-	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, string, database.AndFilter) error); ok {
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, string, database.AndFilter) *database.FilterResult); ok {
 		r1 = rf(ctx, ns, filter)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, string, database.AndFilter) error); ok {
+		r2 = rf(ctx, ns, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetBlockchainStatus provides a mock function with given fields: ctx
+func (_m *Orchestrator) GetBlockchainStatus(ctx context.Context) (fftypes.JSONObject, error) {
+	ret := _m.Called(ctx)
+
+	var r0 fftypes.JSONObject
+	if rf, ok := ret.Get(0).(func(context.Context) fftypes.JSONObject); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(fftypes.JSONObject)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -196,7 +251,7 @@ func (_m *Orchestrator) GetConfigRecord(ctx context.Context, key string) (*fftyp
 }
 
 // GetConfigRecords provides a mock function with given fields: ctx, filter
-func (_m *Orchestrator) GetConfigRecords(ctx context.Context, filter database.AndFilter) ([]*fftypes.ConfigRecord, error) {
+func (_m *Orchestrator) GetConfigRecords(ctx context.Context, filter database.AndFilter) ([]*fftypes.ConfigRecord, *database.FilterResult, error) {
 	ret := _m.Called(ctx, filter)
 
 	var r0 []*fftypes.ConfigRecord
@@ -208,18 +263,27 @@ func (_m *Orchestrator) GetConfigRecords(ctx context.Context, filter database.An
 		}
 	}
 
-	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, database.AndFilter) error); ok {
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, database.AndFilter) *database.FilterResult); ok {
 		r1 = rf(ctx, filter)
 	} else {
-		r1 = ret.Error(1)
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
 	}
 
-	return r0, r1
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, database.AndFilter) error); ok {
+		r2 = rf(ctx, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
 }
 
 // GetData provides a mock function with given fields: ctx, ns, filter
-func (_m *Orchestrator) GetData(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Data, error) {
+func (_m *Orchestrator) GetData(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Data, *database.FilterResult, error) {
 	ret := _m.Called(ctx, ns, filter)
 
 	var r0 []*fftypes.Data
@@ -231,14 +295,23 @@ func (_m *Orchestrator) GetData(ctx context.Context, ns string, filter database.
 		}
 	}
 
-	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, string, database.AndFilter) error); ok {
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, string, database.AndFilter) *database.FilterResult); ok {
 		r1 = rf(ctx, ns, filter)
 	} else {
-		r1 = ret.Error(1)
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
 	}
 
-	return r0, r1
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, string, database.AndFilter) error); ok {
+		r2 = rf(ctx, ns, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
 }
 
 // GetDataByID provides a mock function with given fields: ctx, ns, id
@@ -288,7 +361,7 @@ func (_m *Orchestrator) GetDatatypeByID(ctx context.Context, ns string, id strin
 }
 
 // GetDatatypes provides a mock function with given fields: ctx, ns, filter
-func (_m *Orchestrator) GetDatatypes(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Datatype, error) {
+func (_m *Orchestrator) GetDatatypes(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Datatype, *database.FilterResult, error) {
 	ret := _m.Called(ctx, ns, filter)
 
 	var r0 []*fftypes.Datatype
@@ -300,14 +373,23 @@ func (_m *Orchestrator) GetDatatypes(ctx context.Context, ns string, filter data
 		}
 	}
 
-	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, string, database.AndFilter) error); ok {
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, string, database.AndFilter) *database.FilterResult); ok {
 		r1 = rf(ctx, ns, filter)
 	} else {
-		r1 = ret.Error(1)
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
 	}
 
-	return r0, r1
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, string, database.AndFilter) error); ok {
+		r2 = rf(ctx, ns, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
 }
 
 // GetEventByID provides a mock function with given fields: ctx, ns, id
@@ -334,7 +416,7 @@ func (_m *Orchestrator) GetEventByID(ctx context.Context, ns string, id string)
 }
 
 // GetEvents provides a mock function with given fields: ctx, ns, filter
-func (_m *Orchestrator) GetEvents(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Event, error) {
+func (_m *Orchestrator) GetEvents(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Event, *database.FilterResult, error) {
 	ret := _m.Called(ctx, ns, filter)
 
 	var r0 []*fftypes.Event
@@ -346,14 +428,23 @@ func (_m *Orchestrator) GetEvents(ctx context.Context, ns string, filter databas
 		}
 	}
 
-	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, string, database.AndFilter) error); ok {
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, string, database.AndFilter) *database.FilterResult); ok {
 		r1 = rf(ctx, ns, filter)
 	} else {
-		r1 = ret.Error(1)
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
 	}
 
-	return r0, r1
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, string, database.AndFilter) error); ok {
+		r2 = rf(ctx, ns, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
 }
 
 // GetMessageByID provides a mock function with given fields: ctx, ns, id, withValues
@@ -403,7 +494,7 @@ func (_m *Orchestrator) GetMessageData(ctx context.Context, ns string, id string
 }
 
 // GetMessageEvents provides a mock function with given fields: ctx, ns, id, filter
-func (_m *Orchestrator) GetMessageEvents(ctx context.Context, ns string, id string, filter database.AndFilter) ([]*fftypes.Event, error) {
+func (_m *Orchestrator) GetMessageEvents(ctx context.Context, ns string, id string, filter database.AndFilter) ([]*fftypes.Event, *database.FilterResult, error) {
 	ret := _m.Called(ctx, ns, id, filter)
 
 	var r0 []*fftypes.Event
@@ -415,14 +506,23 @@ func (_m *Orchestrator) GetMessageEvents(ctx context.Context, ns string, id stri
 		}
 	}
 
-	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, string, string, database.AndFilter) error); ok {
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, database.AndFilter) *database.FilterResult); ok {
 		r1 = rf(ctx, ns, id, filter)
 	} else {
-		r1 = ret.Error(1)
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
 	}
 
-	return r0, r1
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, string, string, database.AndFilter) error); ok {
+		r2 = rf(ctx, ns, id, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
 }
 
 // GetMessageOperations provides a mock function with given fields: ctx, ns, id
@@ -448,6 +548,38 @@ func (_m *Orchestrator) GetMessageOperations(ctx context.Context, ns string, id
 	return r0, r1
 }
 
+// GetMessageReplies provides a mock function with given fields: ctx, ns, id, filter
+func (_m *Orchestrator) GetMessageReplies(ctx context.Context, ns string, id string, filter database.AndFilter) ([]*fftypes.Message, *database.FilterResult, error) {
+	ret := _m.Called(ctx, ns, id, filter)
+
+	var r0 []*fftypes.Message
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, database.AndFilter) []*fftypes.Message); ok {
+		r0 = rf(ctx, ns, id, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*fftypes.Message)
+		}
+	}
+
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, database.AndFilter) *database.FilterResult); ok {
+		r1 = rf(ctx, ns, id, filter)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, string, string, database.AndFilter) error); ok {
+		r2 = rf(ctx, ns, id, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
 // GetMessageTransaction provides a mock function with given fields: ctx, ns, id
 func (_m *Orchestrator) GetMessageTransaction(ctx context.Context, ns string, id string) (*fftypes.Transaction, error) {
 	ret := _m.Called(ctx, ns, id)
@@ -472,7 +604,7 @@ func (_m *Orchestrator) GetMessageTransaction(ctx context.Context, ns string, id
 }
 
 // GetMessages provides a mock function with given fields: ctx, ns, filter
-func (_m *Orchestrator) GetMessages(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Message, error) {
+func (_m *Orchestrator) GetMessages(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Message, *database.FilterResult, error) {
 	ret := _m.Called(ctx, ns, filter)
 
 	var r0 []*fftypes.Message
@@ -484,18 +616,27 @@ func (_m *Orchestrator) GetMessages(ctx context.Context, ns string, filter datab
 		}
 	}
 
-	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, string, database.AndFilter) error); ok {
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, string, database.AndFilter) *database.FilterResult); ok {
 		r1 = rf(ctx, ns, filter)
 	} else {
-		r1 = ret.Error(1)
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
 	}
 
-	return r0, r1
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, string, database.AndFilter) error); ok {
+		r2 = rf(ctx, ns, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
 }
 
 // GetMessagesForData provides a mock function with given fields: ctx, ns, dataID, filter
-func (_m *Orchestrator) GetMessagesForData(ctx context.Context, ns string, dataID string, filter database.AndFilter) ([]*fftypes.Message, error) {
+func (_m *Orchestrator) GetMessagesForData(ctx context.Context, ns string, dataID string, filter database.AndFilter) ([]*fftypes.Message, *database.FilterResult, error) {
 	ret := _m.Called(ctx, ns, dataID, filter)
 
 	var r0 []*fftypes.Message
@@ -507,14 +648,55 @@ func (_m *Orchestrator) GetMessagesForData(ctx context.Context, ns string, dataI
 		}
 	}
 
-	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, string, string, database.AndFilter) error); ok {
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, database.AndFilter) *database.FilterResult); ok {
 		r1 = rf(ctx, ns, dataID, filter)
 	} else {
-		r1 = ret.Error(1)
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
 	}
 
-	return r0, r1
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, string, string, database.AndFilter) error); ok {
+		r2 = rf(ctx, ns, dataID, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// GetMessagesForGroup provides a mock function with given fields: ctx, ns, groupHash, filter
+func (_m *Orchestrator) GetMessagesForGroup(ctx context.Context, ns string, groupHash string, filter database.AndFilter) ([]*fftypes.Message, *database.FilterResult, error) {
+	ret := _m.Called(ctx, ns, groupHash, filter)
+
+	var r0 []*fftypes.Message
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, database.AndFilter) []*fftypes.Message); ok {
+		r0 = rf(ctx, ns, groupHash, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*fftypes.Message)
+		}
+	}
+
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, database.AndFilter) *database.FilterResult); ok {
+		r1 = rf(ctx, ns, groupHash, filter)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, string, string, database.AndFilter) error); ok {
+		r2 = rf(ctx, ns, groupHash, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
 }
 
 // GetNamespace provides a mock function with given fields: ctx, ns
@@ -541,7 +723,7 @@ func (_m *Orchestrator) GetNamespace(ctx context.Context, ns string) (*fftypes.N
 }
 
 // GetNamespaces provides a mock function with given fields: ctx, filter
-func (_m *Orchestrator) GetNamespaces(ctx context.Context, filter database.AndFilter) ([]*fftypes.Namespace, error) {
+func (_m *Orchestrator) GetNamespaces(ctx context.Context, filter database.AndFilter) ([]*fftypes.Namespace, *database.FilterResult, error) {
 	ret := _m.Called(ctx, filter)
 
 	var r0 []*fftypes.Namespace
@@ -553,14 +735,23 @@ func (_m *Orchestrator) GetNamespaces(ctx context.Context, filter database.AndFi
 		}
 	}
 
-	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, database.AndFilter) error); ok {
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, database.AndFilter) *database.FilterResult); ok {
 		r1 = rf(ctx, filter)
 	} else {
-		r1 = ret.Error(1)
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
 	}
 
-	return r0, r1
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, database.AndFilter) error); ok {
+		r2 = rf(ctx, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
 }
 
 // GetOperationByID provides a mock function with given fields: ctx, ns, id
@@ -587,7 +778,7 @@ func (_m *Orchestrator) GetOperationByID(ctx context.Context, ns string, id stri
 }
 
 // GetOperations provides a mock function with given fields: ctx, ns, filter
-func (_m *Orchestrator) GetOperations(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Operation, error) {
+func (_m *Orchestrator) GetOperations(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Operation, *database.FilterResult, error) {
 	ret := _m.Called(ctx, ns, filter)
 
 	var r0 []*fftypes.Operation
@@ -599,14 +790,23 @@ func (_m *Orchestrator) GetOperations(ctx context.Context, ns string, filter dat
 		}
 	}
 
-	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, string, database.AndFilter) error); ok {
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, string, database.AndFilter) *database.FilterResult); ok {
 		r1 = rf(ctx, ns, filter)
 	} else {
-		r1 = ret.Error(1)
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
 	}
 
-	return r0, r1
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, string, database.AndFilter) error); ok {
+		r2 = rf(ctx, ns, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
 }
 
 // GetStatus provides a mock function with given fields: ctx
@@ -656,7 +856,7 @@ func (_m *Orchestrator) GetSubscriptionByID(ctx context.Context, ns string, id s
 }
 
 // GetSubscriptions provides a mock function with given fields: ctx, ns, filter
-func (_m *Orchestrator) GetSubscriptions(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Subscription, error) {
+func (_m *Orchestrator) GetSubscriptions(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Subscription, *database.FilterResult, error) {
 	ret := _m.Called(ctx, ns, filter)
 
 	var r0 []*fftypes.Subscription
@@ -668,14 +868,23 @@ func (_m *Orchestrator) GetSubscriptions(ctx context.Context, ns string, filter
 		}
 	}
 
-	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, string, database.AndFilter) error); ok {
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, string, database.AndFilter) *database.FilterResult); ok {
 		r1 = rf(ctx, ns, filter)
 	} else {
-		r1 = ret.Error(1)
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
 	}
 
-	return r0, r1
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, string, database.AndFilter) error); ok {
+		r2 = rf(ctx, ns, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
 }
 
 // GetTransactionByID provides a mock function with given fields: ctx, ns, id
@@ -725,7 +934,7 @@ func (_m *Orchestrator) GetTransactionOperations(ctx context.Context, ns string,
 }
 
 // GetTransactions provides a mock function with given fields: ctx, ns, filter
-func (_m *Orchestrator) GetTransactions(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Transaction, error) {
+func (_m *Orchestrator) GetTransactions(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Transaction, *database.FilterResult, error) {
 	ret := _m.Called(ctx, ns, filter)
 
 	var r0 []*fftypes.Transaction
@@ -737,14 +946,23 @@ func (_m *Orchestrator) GetTransactions(ctx context.Context, ns string, filter d
 		}
 	}
 
-	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, string, database.AndFilter) error); ok {
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, string, database.AndFilter) *database.FilterResult); ok {
 		r1 = rf(ctx, ns, filter)
 	} else {
-		r1 = ret.Error(1)
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
 	}
 
-	return r0, r1
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, string, database.AndFilter) error); ok {
+		r2 = rf(ctx, ns, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
 }
 
 // Init provides a mock function with given fields: ctx, cancelCtx
@@ -816,6 +1034,75 @@ func (_m *Orchestrator) PutConfigRecord(ctx context.Context, key string, configR
 	return r0, r1
 }
 
+// RequestReply provides a mock function with given fields: ctx, ns, request
+func (_m *Orchestrator) RequestReply(ctx context.Context, ns string, request *fftypes.MessageInput) (*fftypes.MessageInput, error) {
+	ret := _m.Called(ctx, ns, request)
+
+	var r0 *fftypes.MessageInput
+	if rf, ok := ret.Get(0).(func(context.Context, string, *fftypes.MessageInput) *fftypes.MessageInput); ok {
+		r0 = rf(ctx, ns, request)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.MessageInput)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, *fftypes.MessageInput) error); ok {
+		r1 = rf(ctx, ns, request)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RetryOperation provides a mock function with given fields: ctx, ns, id
+func (_m *Orchestrator) RetryOperation(ctx context.Context, ns string, id string) (*fftypes.Operation, error) {
+	ret := _m.Called(ctx, ns, id)
+
+	var r0 *fftypes.Operation
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *fftypes.Operation); ok {
+		r0 = rf(ctx, ns, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.Operation)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, ns, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SendReply provides a mock function with given fields: ctx, ns, id, reply
+func (_m *Orchestrator) SendReply(ctx context.Context, ns string, id string, reply *fftypes.MessageInput) (*fftypes.Message, error) {
+	ret := _m.Called(ctx, ns, id, reply)
+
+	var r0 *fftypes.Message
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, *fftypes.MessageInput) *fftypes.Message); ok {
+		r0 = rf(ctx, ns, id, reply)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.Message)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, *fftypes.MessageInput) error); ok {
+		r1 = rf(ctx, ns, id, reply)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Start provides a mock function with given fields:
 func (_m *Orchestrator) Start() error {
 	ret := _m.Called()
@@ -16,22 +16,22 @@ type Manager struct {
 	mock.Mock
 }
 
-// GetGroupByID provides a mock function with given fields: ctx, id
-func (_m *Manager) GetGroupByID(ctx context.Context, id string) (*fftypes.Group, error) {
-	ret := _m.Called(ctx, id)
+// GetGroupByID provides a mock function with given fields: ctx, ns, id
+func (_m *Manager) GetGroupByID(ctx context.Context, ns string, id string) (*fftypes.GroupResolved, error) {
+	ret := _m.Called(ctx, ns, id)
 
-	var r0 *fftypes.Group
-	if rf, ok := ret.Get(0).(func(context.Context, string) *fftypes.Group); ok {
-		r0 = rf(ctx, id)
+	var r0 *fftypes.GroupResolved
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) *fftypes.GroupResolved); ok {
+		r0 = rf(ctx, ns, id)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*fftypes.Group)
+			r0 = ret.Get(0).(*fftypes.GroupResolved)
 		}
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = rf(ctx, id)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, ns, id)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -39,13 +39,13 @@ func (_m *Manager) GetGroupByID(ctx context.Context, id string) (*fftypes.Group,
 	return r0, r1
 }
 
-// GetGroups provides a mock function with given fields: ctx, filter
-func (_m *Manager) GetGroups(ctx context.Context, filter database.AndFilter) ([]*fftypes.Group, error) {
-	ret := _m.Called(ctx, filter)
+// GetGroupLineage provides a mock function with given fields: ctx, ns, hash
+func (_m *Manager) GetGroupLineage(ctx context.Context, ns string, hash string) ([]*fftypes.Group, error) {
+	ret := _m.Called(ctx, ns, hash)
 
 	var r0 []*fftypes.Group
-	if rf, ok := ret.Get(0).(func(context.Context, database.AndFilter) []*fftypes.Group); ok {
-		r0 = rf(ctx, filter)
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) []*fftypes.Group); ok {
+		r0 = rf(ctx, ns, hash)
 	} else {
 		if ret.Get(0) != nil {
 			r0 = ret.Get(0).([]*fftypes.Group)
@@ -53,8 +53,63 @@ func (_m *Manager) GetGroups(ctx context.Context, filter database.AndFilter) ([]
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, database.AndFilter) error); ok {
-		r1 = rf(ctx, filter)
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, ns, hash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetGroups provides a mock function with given fields: ctx, ns, filter
+func (_m *Manager) GetGroups(ctx context.Context, ns string, filter database.AndFilter) ([]*fftypes.Group, *database.FilterResult, error) {
+	ret := _m.Called(ctx, ns, filter)
+
+	var r0 []*fftypes.Group
+	if rf, ok := ret.Get(0).(func(context.Context, string, database.AndFilter) []*fftypes.Group); ok {
+		r0 = rf(ctx, ns, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*fftypes.Group)
+		}
+	}
+
+	var r1 *database.FilterResult
+	if rf, ok := ret.Get(1).(func(context.Context, string, database.AndFilter) *database.FilterResult); ok {
+		r1 = rf(ctx, ns, filter)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).(*database.FilterResult)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, string, database.AndFilter) error); ok {
+		r2 = rf(ctx, ns, filter)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// HandleGroupUpdate provides a mock function with given fields: ctx, msg, data
+func (_m *Manager) HandleGroupUpdate(ctx context.Context, msg *fftypes.Message, data []*fftypes.Data) (*fftypes.Group, error) {
+	ret := _m.Called(ctx, msg, data)
+
+	var r0 *fftypes.Group
+	if rf, ok := ret.Get(0).(func(context.Context, *fftypes.Message, []*fftypes.Data) *fftypes.Group); ok {
+		r0 = rf(ctx, msg, data)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.Group)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *fftypes.Message, []*fftypes.Data) error); ok {
+		r1 = rf(ctx, msg, data)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -121,3 +176,26 @@ func (_m *Manager) Start() error {
 
 	return r0
 }
+
+// UpdateGroup provides a mock function with given fields: ctx, ns, hash, in
+func (_m *Manager) UpdateGroup(ctx context.Context, ns string, hash string, in *fftypes.GroupUpdateInput) (*fftypes.Group, error) {
+	ret := _m.Called(ctx, ns, hash, in)
+
+	var r0 *fftypes.Group
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, *fftypes.GroupUpdateInput) *fftypes.Group); ok {
+		r0 = rf(ctx, ns, hash, in)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*fftypes.Group)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, *fftypes.GroupUpdateInput) error); ok {
+		r1 = rf(ctx, ns, hash, in)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
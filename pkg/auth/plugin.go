@@ -0,0 +1,61 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package auth
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/hyperledger-labs/firefly/internal/config"
+	"github.com/hyperledger-labs/firefly/pkg/fftypes"
+)
+
+// Plugin is the interface implemented by each API authentication plugin
+type Plugin interface {
+	fftypes.Named
+
+	// InitPrefix initializes the set of configuration options that are valid, with defaults. Called on all plugins.
+	InitPrefix(prefix config.Prefix)
+
+	// Init initializes the plugin, with configuration
+	Init(ctx context.Context, prefix config.Prefix) error
+
+	// Authenticate resolves the principal making an API request, from the credentials supplied with the request.
+	// Returns a nil principal, and no error, if the request does not contain any credentials the plugin understands.
+	// Returns an error if credentials were supplied, but are not valid.
+	Authenticate(ctx context.Context, req *http.Request) (*Principal, error)
+}
+
+// Principal is the authenticated caller of an API
+type Principal struct {
+	Subject string   `json:"subject"`
+	Groups  []string `json:"groups,omitempty"`
+	Method  string   `json:"method"`
+}
+
+type principalKey struct{}
+
+// WithPrincipal stores the authenticated principal in the context of a request
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// GetPrincipal returns the authenticated principal for a request, or nil if authentication is not enabled
+func GetPrincipal(ctx context.Context) *Principal {
+	principal, _ := ctx.Value(principalKey{}).(*Principal)
+	return principal
+}
@@ -18,6 +18,7 @@ package blockchain
 
 import (
 	"context"
+	"encoding/json"
 
 	"github.com/hyperledger-labs/firefly/internal/config"
 	"github.com/hyperledger-labs/firefly/pkg/fftypes"
@@ -44,9 +45,18 @@ type Plugin interface {
 	// Can apply transformations to the supplied signing identity (only), such as lower case
 	VerifyIdentitySyntax(ctx context.Context, identity *fftypes.Identity) error
 
-	// SubmitBatchPin sequences a batch of message globally to all viewers of a given ledger
+	// SubmitBatchPin sequences a batch of message globally to all viewers of a given ledger.
+	// A nil ledgerID submits to the default ledger configured for the plugin.
 	// The returned tracking ID will be used to correlate with any subsequent transaction tracking updates
 	SubmitBatchPin(ctx context.Context, ledgerID *fftypes.UUID, identity *fftypes.Identity, batch *BatchPin) (txTrackingID string, err error)
+
+	// TrackPendingBatchPins is called before Start, with the tracking IDs of batch pins submitted by this node
+	// that have not yet completed - such as those submitted before a restart.
+	// Plugins that rely on a connector to track transactions through to completion can ignore this.
+	TrackPendingBatchPins(ctx context.Context, txTrackingIDs []string)
+
+	// Status returns protocol specific diagnostic information, such as the state of the event listeners for each ledger
+	Status() fftypes.JSONObject
 }
 
 // Callbacks is the interface provided to the blockchain plugin, to allow it to pass events back to firefly.
@@ -68,9 +78,10 @@ type Callbacks interface {
 	// Will be combined with he index within the batch, to allocate a sequence to each message in the batch.
 	// For example a padded block number, followed by a padded transaction index within that block.
 	// additionalInfo can be used to add opaque protocol specific JSON from the plugin (block numbers etc.)
+	// ledgerID identifies the ledger the event arrived on, and is nil for the default ledger.
 	//
 	// Error should will only be returned in shutdown scenarios
-	BatchPinComplete(batch *BatchPin, signingIdentity string, protocolTxID string, additionalInfo fftypes.JSONObject) error
+	BatchPinComplete(ledgerID *fftypes.UUID, batch *BatchPin, signingIdentity string, protocolTxID string, additionalInfo fftypes.JSONObject) error
 }
 
 // Capabilities the supported featureset of the blockchain
@@ -89,19 +100,19 @@ type TransactionStatus = fftypes.OpStatus
 type BatchPin struct {
 
 	// Namespace goes in the clear on the chain
-	Namespace string
+	Namespace string `json:"namespace"`
 
 	// TransactionID is the firefly transaction ID allocated before transaction submission for correlation with events (it's a UUID so no leakage)
-	TransactionID *fftypes.UUID
+	TransactionID *fftypes.UUID `json:"transactionId"`
 
 	// BatchID is the id of the batch - not strictly required, but writing this in plain text to the blockchain makes for easy human correlation on-chain/off-chain (it's a UUID so no leakage)
-	BatchID *fftypes.UUID
+	BatchID *fftypes.UUID `json:"batchId"`
 
 	// BatchHash is the SHA256 hash of the batch
-	BatchHash *fftypes.Bytes32
+	BatchHash *fftypes.Bytes32 `json:"batchHash"`
 
 	// BatchPaylodRef is a 32 byte fixed length binary value that can be passed to the storage interface to retrieve the payload. Nil for private messages
-	BatchPaylodRef *fftypes.Bytes32
+	BatchPaylodRef *fftypes.Bytes32 `json:"batchPayloadRef,omitempty"`
 
 	// Contexts is an array of hashes that allow the FireFly runtimes to identify whether one of the messgages in
 	// that batch is the next message for a sequence that involves that node. If so that means the FireFly runtime must
@@ -120,5 +131,32 @@ type BatchPin struct {
 	//   - The hashes are made unique to the sender
 	//   - The hashes contain a sender specific nonce that is a monotomically increasing number
 	//     for batches sent by that sender, within the context (maintined by the sender FireFly node)
-	Contexts []*fftypes.Bytes32
+	Contexts []*fftypes.Bytes32 `json:"contexts"`
+}
+
+// BatchPinSubmission is the full set of inputs passed to SubmitBatchPin. It is stored as the input of the
+// blockchain_batch_pin operation, so that the identical pin can be resubmitted if the transaction fails.
+type BatchPinSubmission struct {
+	LedgerID *fftypes.UUID     `json:"ledger,omitempty"`
+	Signer   *fftypes.Identity `json:"signer"`
+	BatchPin *BatchPin         `json:"batchPin"`
+}
+
+// OperationInput serializes the submission for storage on an operation
+func (s *BatchPinSubmission) OperationInput() fftypes.JSONObject {
+	var input fftypes.JSONObject
+	b, _ := json.Marshal(s)
+	_ = json.Unmarshal(b, &input)
+	return input
+}
+
+// BatchPinSubmissionFromOperation extracts the submission stored by OperationInput, returning false if
+// the operation does not have a complete submission stored (such as an operation from an earlier release)
+func BatchPinSubmissionFromOperation(op *fftypes.Operation) (*BatchPinSubmission, bool) {
+	var s BatchPinSubmission
+	b, _ := json.Marshal(op.Input)
+	if err := json.Unmarshal(b, &s); err != nil || s.Signer == nil || s.BatchPin == nil || s.BatchPin.TransactionID == nil || s.BatchPin.BatchID == nil {
+		return nil, false
+	}
+	return &s, true
 }
@@ -0,0 +1,57 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blockchain
+
+import (
+	"testing"
+
+	"github.com/hyperledger-labs/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBatchPinSubmissionRoundTrip(t *testing.T) {
+	submission := &BatchPinSubmission{
+		LedgerID: fftypes.NewUUID(),
+		Signer:   &fftypes.Identity{Identifier: "org1", OnChain: "0x12345"},
+		BatchPin: &BatchPin{
+			Namespace:      "ns1",
+			TransactionID:  fftypes.NewUUID(),
+			BatchID:        fftypes.NewUUID(),
+			BatchHash:      fftypes.NewRandB32(),
+			BatchPaylodRef: fftypes.NewRandB32(),
+			Contexts:       []*fftypes.Bytes32{fftypes.NewRandB32()},
+		},
+	}
+	op := &fftypes.Operation{Input: submission.OperationInput()}
+	assert.Equal(t, "ns1", op.Input.GetObject("batchPin").GetString("namespace"))
+
+	parsed, ok := BatchPinSubmissionFromOperation(op)
+	assert.True(t, ok)
+	assert.Equal(t, submission, parsed)
+}
+
+func TestBatchPinSubmissionFromOperationMissing(t *testing.T) {
+	_, ok := BatchPinSubmissionFromOperation(&fftypes.Operation{})
+	assert.False(t, ok)
+}
+
+func TestBatchPinSubmissionFromOperationBadInput(t *testing.T) {
+	_, ok := BatchPinSubmissionFromOperation(&fftypes.Operation{
+		Input: fftypes.JSONObject{"signer": "not an object"},
+	})
+	assert.False(t, ok)
+}
@@ -43,6 +43,9 @@ type Filter interface {
 	// Limit for pagination
 	Limit(uint64) Filter
 
+	// Request a count to be returned on the total number that match the query
+	Count(c bool) Filter
+
 	// Finalize completes the filter, and for the plugin to validated output structure to convert
 	Finalize() (*FilterInfo, error)
 
@@ -130,6 +133,11 @@ type FilterBuilder interface {
 	NotIContains(name string, value driver.Value) Filter
 }
 
+// FilterResult has additional info if requested on the query - currently only the total count
+type FilterResult struct {
+	TotalCount *int64
+}
+
 // FilterInfo is the structure returned by Finalize to the plugin, to serialize this filter
 // into the underlying database mechanism's filter language
 type FilterInfo struct {
@@ -137,6 +145,7 @@ type FilterInfo struct {
 	Skip       uint64
 	Limit      uint64
 	Descending bool
+	Count      bool
 	Field      string
 	Op         FilterOp
 	Values     []FieldSerialization
@@ -200,6 +209,9 @@ func (f *FilterInfo) String() string {
 	if f.Limit > 0 {
 		val.WriteString(fmt.Sprintf(" limit=%d", f.Limit))
 	}
+	if f.Count {
+		val.WriteString(" count=true")
+	}
 
 	return val.String()
 }
@@ -220,6 +232,7 @@ type filterBuilder struct {
 	sort        []string
 	skip        uint64
 	limit       uint64
+	count       bool
 	descending  bool
 }
 
@@ -284,6 +297,7 @@ func (f *baseFilter) Finalize() (fi *FilterInfo, err error) {
 		Skip:       f.fb.skip,
 		Limit:      f.fb.limit,
 		Descending: f.fb.descending,
+		Count:      f.fb.count,
 	}, nil
 }
 
@@ -306,6 +320,11 @@ func (f *baseFilter) Limit(limit uint64) Filter {
 	return f
 }
 
+func (f *baseFilter) Count(c bool) Filter {
+	f.fb.count = c
+	return f
+}
+
 func (f *baseFilter) Ascending() Filter {
 	f.fb.descending = false
 	return f
@@ -0,0 +1,62 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"context"
+	"sync"
+
+	"github.com/hyperledger-labs/firefly/pkg/fftypes"
+)
+
+// LeaseFence holds the lease this instance was granted, so the database plugin can reject writes made
+// after another instance has taken the lease over. It is armed each time the lease is acquired or renewed,
+// and never disarmed - an instance that loses the lease must restart before it can write under the fence again.
+type LeaseFence struct {
+	mux   sync.Mutex
+	lease *fftypes.Lease
+}
+
+type leaseFenceKey struct{}
+
+// Arm records the lease that writes under the fence must still hold
+func (f *LeaseFence) Arm(lease *fftypes.Lease) {
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	f.lease = lease
+}
+
+// Lease returns the lease writes must hold, or nil if the fence has not been armed
+func (f *LeaseFence) Lease() *fftypes.Lease {
+	if f == nil {
+		return nil
+	}
+	f.mux.Lock()
+	defer f.mux.Unlock()
+	return f.lease
+}
+
+// WithLeaseFence returns a context under which each new database transaction first checks the fence
+func WithLeaseFence(ctx context.Context, fence *LeaseFence) context.Context {
+	return context.WithValue(ctx, leaseFenceKey{}, fence)
+}
+
+// GetLeaseFence returns the fence of the context, if there is one
+func GetLeaseFence(ctx context.Context) *LeaseFence {
+	fence, _ := ctx.Value(leaseFenceKey{}).(*LeaseFence)
+	return fence
+}
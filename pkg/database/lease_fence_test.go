@@ -0,0 +1,39 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package database
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hyperledger-labs/firefly/pkg/fftypes"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLeaseFence(t *testing.T) {
+	assert.Nil(t, GetLeaseFence(context.Background()))
+	assert.Nil(t, GetLeaseFence(context.Background()).Lease())
+
+	fence := &LeaseFence{}
+	ctx := WithLeaseFence(context.Background(), fence)
+	assert.Equal(t, fence, GetLeaseFence(ctx))
+	assert.Nil(t, fence.Lease())
+
+	lease := &fftypes.Lease{Name: "leader", Holder: "instance1", Epoch: 2}
+	fence.Arm(lease)
+	assert.Equal(t, lease, GetLeaseFence(ctx).Lease())
+}
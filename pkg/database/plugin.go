@@ -71,7 +71,6 @@ type Plugin interface {
 // interface.
 // For SQL databases the process of adding a new database is simplified via the common SQL layer.
 // For NoSQL databases, the code should be straight forward to map the collections, indexes, and operations.
-//
 type PeristenceInterface interface {
 	fftypes.Named
 
@@ -94,7 +93,7 @@ type PeristenceInterface interface {
 	GetNamespace(ctx context.Context, name string) (offset *fftypes.Namespace, err error)
 
 	// GetNamespaces - Get namespaces
-	GetNamespaces(ctx context.Context, filter Filter) (offset []*fftypes.Namespace, err error)
+	GetNamespaces(ctx context.Context, filter Filter) (offset []*fftypes.Namespace, res *FilterResult, err error)
 
 	// UpsertMessage - Upsert a message, with all the embedded data references.
 	// allowHashUpdate=false throws HashMismatch error if the updated message has a different hash
@@ -114,13 +113,13 @@ type PeristenceInterface interface {
 	GetMessageByID(ctx context.Context, id *fftypes.UUID) (message *fftypes.Message, err error)
 
 	// GetMessages - List messages, reverse sorted (newest first) by Confirmed then Created, with pagination, and simple must filters
-	GetMessages(ctx context.Context, filter Filter) (message []*fftypes.Message, err error)
+	GetMessages(ctx context.Context, filter Filter) (message []*fftypes.Message, res *FilterResult, err error)
 
 	// GetMessageRefs - Lighter weight query to just get the reference info of messages
 	GetMessageRefs(ctx context.Context, filter Filter) ([]*fftypes.MessageRef, error)
 
 	// GetMessagesForData - List messages where there is a data reference to the specified ID
-	GetMessagesForData(ctx context.Context, dataID *fftypes.UUID, filter Filter) (message []*fftypes.Message, err error)
+	GetMessagesForData(ctx context.Context, dataID *fftypes.UUID, filter Filter) (message []*fftypes.Message, res *FilterResult, err error)
 
 	// UpsertData - Upsert a data record
 	// allowHashUpdate=false throws HashMismatch error if the updated message has a different hash
@@ -133,7 +132,7 @@ type PeristenceInterface interface {
 	GetDataByID(ctx context.Context, id *fftypes.UUID, withValue bool) (message *fftypes.Data, err error)
 
 	// GetData - Get data
-	GetData(ctx context.Context, filter Filter) (message []*fftypes.Data, err error)
+	GetData(ctx context.Context, filter Filter) (message []*fftypes.Data, res *FilterResult, err error)
 
 	// GetDataRefs - Get data references only (no data)
 	GetDataRefs(ctx context.Context, filter Filter) (message fftypes.DataRefs, err error)
@@ -149,7 +148,7 @@ type PeristenceInterface interface {
 	GetBatchByID(ctx context.Context, id *fftypes.UUID) (message *fftypes.Batch, err error)
 
 	// GetBatches - Get batches
-	GetBatches(ctx context.Context, filter Filter) (message []*fftypes.Batch, err error)
+	GetBatches(ctx context.Context, filter Filter) (message []*fftypes.Batch, res *FilterResult, err error)
 
 	// UpsertTransaction - Upsert a transaction
 	// allowHashUpdate=false throws HashMismatch error if the updated message has a different hash
@@ -162,7 +161,7 @@ type PeristenceInterface interface {
 	GetTransactionByID(ctx context.Context, id *fftypes.UUID) (message *fftypes.Transaction, err error)
 
 	// GetTransactions - Get transactions
-	GetTransactions(ctx context.Context, filter Filter) (message []*fftypes.Transaction, err error)
+	GetTransactions(ctx context.Context, filter Filter) (message []*fftypes.Transaction, res *FilterResult, err error)
 
 	// UpsertDatatype - Upsert a data definitino
 	UpsertDatatype(ctx context.Context, datadef *fftypes.Datatype, allowExisting bool) (err error)
@@ -177,7 +176,7 @@ type PeristenceInterface interface {
 	GetDatatypeByName(ctx context.Context, ns, name, version string) (datadef *fftypes.Datatype, err error)
 
 	// GetDatatypes - Get data definitions
-	GetDatatypes(ctx context.Context, filter Filter) (datadef []*fftypes.Datatype, err error)
+	GetDatatypes(ctx context.Context, filter Filter) (datadef []*fftypes.Datatype, res *FilterResult, err error)
 
 	// UpsertOffset - Upsert an offset
 	UpsertOffset(ctx context.Context, data *fftypes.Offset, allowExisting bool) (err error)
@@ -194,6 +193,13 @@ type PeristenceInterface interface {
 	// DeleteOffset - Delete an offset by name
 	DeleteOffset(ctx context.Context, t fftypes.OffsetType, ns, name string) (err error)
 
+	// AcquireLease - Claims, or renews, a lease for the holder. Only succeeds if the lease does not exist yet,
+	// is already held by the same holder, or has expired
+	AcquireLease(ctx context.Context, lease *fftypes.Lease) (acquired bool, err error)
+
+	// GetLease - Get a lease by name
+	GetLease(ctx context.Context, name string) (lease *fftypes.Lease, err error)
+
 	// UpsertPin - Will insert a pin at the end of the sequence, unless the batch+hash+index sequence already exists
 	UpsertPin(ctx context.Context, parked *fftypes.Pin) (err error)
 
@@ -212,11 +218,14 @@ type PeristenceInterface interface {
 	// UpdateOperation - Update operation by ID
 	UpdateOperation(ctx context.Context, id *fftypes.UUID, update Update) (err error)
 
+	// UpdateOperationIfStatus - Update operation by ID, only if it is currently in one of the supplied statuses
+	UpdateOperationIfStatus(ctx context.Context, id *fftypes.UUID, statuses []fftypes.OpStatus, update Update) (updated bool, err error)
+
 	// GetOperationByID - Get an operation by ID
 	GetOperationByID(ctx context.Context, id *fftypes.UUID) (operation *fftypes.Operation, err error)
 
 	// GetOperations - Get operation
-	GetOperations(ctx context.Context, filter Filter) (operation []*fftypes.Operation, err error)
+	GetOperations(ctx context.Context, filter Filter) (operation []*fftypes.Operation, res *FilterResult, err error)
 
 	// UpsertSubscription - Upsert a subscription
 	UpsertSubscription(ctx context.Context, data *fftypes.Subscription, allowExisting bool) (err error)
@@ -232,7 +241,7 @@ type PeristenceInterface interface {
 	GetSubscriptionByID(ctx context.Context, id *fftypes.UUID) (offset *fftypes.Subscription, err error)
 
 	// GetSubscriptions - Get subscriptions
-	GetSubscriptions(ctx context.Context, filter Filter) (offset []*fftypes.Subscription, err error)
+	GetSubscriptions(ctx context.Context, filter Filter) (offset []*fftypes.Subscription, res *FilterResult, err error)
 
 	// DeleteSubscriptionByID - Delete a subscription
 	DeleteSubscriptionByID(ctx context.Context, id *fftypes.UUID) (err error)
@@ -247,7 +256,7 @@ type PeristenceInterface interface {
 	GetEventByID(ctx context.Context, id *fftypes.UUID) (message *fftypes.Event, err error)
 
 	// GetEvents - Get events
-	GetEvents(ctx context.Context, filter Filter) (message []*fftypes.Event, err error)
+	GetEvents(ctx context.Context, filter Filter) (message []*fftypes.Event, res *FilterResult, err error)
 
 	// UpsertOrganization - Upsert an organization
 	UpsertOrganization(ctx context.Context, data *fftypes.Organization, allowExisting bool) (err error)
@@ -265,7 +274,13 @@ type PeristenceInterface interface {
 	GetOrganizationByID(ctx context.Context, id *fftypes.UUID) (org *fftypes.Organization, err error)
 
 	// GetOrganizations - Get organizations
-	GetOrganizations(ctx context.Context, filter Filter) (org []*fftypes.Organization, err error)
+	GetOrganizations(ctx context.Context, filter Filter) (org []*fftypes.Organization, res *FilterResult, err error)
+
+	// InsertOrganizationVersion - Record a version of an organization in its history
+	InsertOrganizationVersion(ctx context.Context, data *fftypes.Organization) (err error)
+
+	// GetOrganizationVersions - Get historical versions of organizations
+	GetOrganizationVersions(ctx context.Context, filter Filter) (org []*fftypes.Organization, res *FilterResult, err error)
 
 	// UpsertNode - Upsert a node
 	UpsertNode(ctx context.Context, data *fftypes.Node, allowExisting bool) (err error)
@@ -280,7 +295,13 @@ type PeristenceInterface interface {
 	GetNodeByID(ctx context.Context, id *fftypes.UUID) (node *fftypes.Node, err error)
 
 	// GetNodes - Get nodes
-	GetNodes(ctx context.Context, filter Filter) (node []*fftypes.Node, err error)
+	GetNodes(ctx context.Context, filter Filter) (node []*fftypes.Node, res *FilterResult, err error)
+
+	// InsertNodeVersion - Record a version of a node in its history
+	InsertNodeVersion(ctx context.Context, data *fftypes.Node) (err error)
+
+	// GetNodeVersions - Get historical versions of nodes
+	GetNodeVersions(ctx context.Context, filter Filter) (node []*fftypes.Node, res *FilterResult, err error)
 
 	// UpserGroup - Upsert a group
 	UpsertGroup(ctx context.Context, data *fftypes.Group, allowExisting bool) (err error)
@@ -292,7 +313,7 @@ type PeristenceInterface interface {
 	GetGroupByHash(ctx context.Context, hash *fftypes.Bytes32) (node *fftypes.Group, err error)
 
 	// GetGroups - Get groups
-	GetGroups(ctx context.Context, filter Filter) (node []*fftypes.Group, err error)
+	GetGroups(ctx context.Context, filter Filter) (node []*fftypes.Group, res *FilterResult, err error)
 
 	// UpsertNonceNext - Upsert a context, assigning zero if not found, or the next nonce if it is
 	UpsertNonceNext(ctx context.Context, context *fftypes.Nonce) (err error)
@@ -323,6 +344,13 @@ type PeristenceInterface interface {
 
 	// DeleteNextPin - delete a next hash, using its local database ID
 	DeleteNextPin(ctx context.Context, sequence int64) (err error)
+
+	// InsertBlob - insert a record of a blob being available in the local data exchange
+	InsertBlob(ctx context.Context, blob *fftypes.Blob) (err error)
+
+	// GetBlobs - get blob records
+	GetBlobs(ctx context.Context, filter Filter) (message []*fftypes.Blob, err error)
+
 	// UpsertConfigRecord - Upsert a config record
 	// Throws IDMismatch error if updating and ids don't match
 	UpsertConfigRecord(ctx context.Context, data *fftypes.ConfigRecord, allowExisting bool) (err error)
@@ -331,7 +359,7 @@ type PeristenceInterface interface {
 	GetConfigRecord(ctx context.Context, key string) (offset *fftypes.ConfigRecord, err error)
 
 	// GetConfigRecords - Get config records
-	GetConfigRecords(ctx context.Context, filter Filter) (offset []*fftypes.ConfigRecord, err error)
+	GetConfigRecords(ctx context.Context, filter Filter) (offset []*fftypes.ConfigRecord, res *FilterResult, err error)
 
 	// DeleteConfigRecord - Delete config record
 	DeleteConfigRecord(ctx context.Context, key string) (err error)
@@ -351,7 +379,9 @@ type PeristenceInterface interface {
 // a subsequent database query as the source of truth of the latest set/order of data, and it will periodically
 // check for new messages even if it does not receive any events.
 //
-// TODO: Clarify the relationship between Leader Election capabilities and Event capabilities
+// When leader election is enabled, only the leader runs the batch manager and aggregator that consume
+// MessageCreated and PinCreated, while EventCreated and the subscription changes are consumed by every
+// instance to deliver events to its connected applications.
 //
 type Callbacks interface {
 	MessageCreated(sequence int64)
@@ -435,6 +465,10 @@ var DataQueryFactory = &queryFields{
 	"datatype.version": &StringField{},
 	"hash":             &StringField{},
 	"created":          &TimeField{},
+	"blob.name":        &StringField{},
+	"blob.mimetype":    &StringField{},
+	"blob.payloadref":  &StringField{},
+	"blob.size":        &Int64Field{},
 }
 
 // DatatypeQueryFactory filter fields for data definitions
@@ -466,7 +500,9 @@ var OperationQueryFactory = &queryFields{
 	"status":    &StringField{},
 	"error":     &StringField{},
 	"plugin":    &StringField{},
+	"input":     &JSONField{},
 	"info":      &JSONField{},
+	"retries":   &JSONField{},
 	"backendid": &StringField{},
 	"created":   &TimeField{},
 	"updated":   &TimeField{},
@@ -493,6 +529,7 @@ var EventQueryFactory = &queryFields{
 	"namespace": &StringField{},
 	"reference": &UUIDField{},
 	"group":     &StringField{},
+	"unpinned":  &BoolField{},
 	"sequence":  &Int64Field{},
 	"created":   &TimeField{},
 }
@@ -500,6 +537,7 @@ var EventQueryFactory = &queryFields{
 // PinQueryFactory filter fields for parked contexts
 var PinQueryFactory = &queryFields{
 	"sequence":   &Int64Field{},
+	"ledger":     &UUIDField{},
 	"masked":     &BoolField{},
 	"hash":       &StringField{},
 	"batch":      &UUIDField{},
@@ -512,11 +550,15 @@ var PinQueryFactory = &queryFields{
 var OrganizationQueryFactory = &queryFields{
 	"id":          &UUIDField{},
 	"message":     &UUIDField{},
+	"name":        &StringField{},
 	"parent":      &StringField{},
 	"identity":    &StringField{},
 	"description": &StringField{},
 	"profile":     &JSONField{},
+	"version":     &Int64Field{},
 	"created":     &TimeField{},
+	"updated":     &TimeField{},
+	"revoked":     &TimeField{},
 }
 
 // NodeQueryFactory filter fields for nodes
@@ -528,7 +570,10 @@ var NodeQueryFactory = &queryFields{
 	"description": &StringField{},
 	"dx.peer":     &StringField{},
 	"dx.endpoint": &JSONField{},
+	"version":     &Int64Field{},
 	"created":     &TimeField{},
+	"updated":     &TimeField{},
+	"revoked":     &TimeField{},
 }
 
 // GroupQueryFactory filter fields for nodes
@@ -536,9 +581,12 @@ var GroupQueryFactory = &queryFields{
 	"hash":        &StringField{},
 	"message":     &UUIDField{},
 	"namespace":   &StringField{},
-	"description": &StringField{},
+	"name":        &StringField{},
 	"ledger":      &UUIDField{},
 	"created":     &TimeField{},
+	"predecessor": &StringField{},
+	"successor":   &StringField{},
+	"retired":     &TimeField{},
 }
 
 // NonceQueryFactory filter fields for nodes
@@ -557,6 +605,15 @@ var NextPinQueryFactory = &queryFields{
 	"nonce":    &Int64Field{},
 }
 
+// BlobQueryFactory filter fields for blobs
+var BlobQueryFactory = &queryFields{
+	"namespace": &StringField{},
+	"data":      &UUIDField{},
+	"hash":      &StringField{},
+	"peer":      &StringField{},
+	"created":   &TimeField{},
+}
+
 // ConfigRecordQueryFactory filter fields for config records
 var ConfigRecordQueryFactory = &queryFields{
 	"config_key":   &StringField{},
@@ -47,6 +47,9 @@ type Plugin interface {
 	// AddPeer translates the configuration published by another peer, into a reference string that is used between DX and FireFly to refer to the peer
 	AddPeer(ctx context.Context, node *fftypes.Node) (err error)
 
+	// RemovePeer stops data exchange with a peer that has left the network
+	RemovePeer(ctx context.Context, node *fftypes.Node) (err error)
+
 	// UploadBLOB streams a blob to storage
 	UploadBLOB(ctx context.Context, ns string, id fftypes.UUID, content io.Reader) (err error)
 
@@ -67,8 +70,9 @@ type Callbacks interface {
 	// MessageReceived notifies of a message received from another node in the network
 	MessageReceived(peerID string, data []byte)
 
-	// BLOBReceived notifies of the ID of a BLOB that has been stored by DX after being received from another node in the network
-	BLOBReceived(peerID string, ns string, id fftypes.UUID)
+	// BLOBReceived notifies of the ID of a BLOB that has been stored by DX after being received from another node in the network,
+	// along with the hash of the content that was calculated by DX as it was stored
+	BLOBReceived(peerID string, hash fftypes.Bytes32, ns string, id fftypes.UUID)
 
 	// TransferResult notifies of a status update of a transfer
 	TransferResult(trackingID string, status fftypes.OpStatus, info string, additionalInfo fftypes.JSONObject)
@@ -39,8 +39,11 @@ type Plugin interface {
 	// Capabilities returns capabilities - not called until after Init
 	Capabilities() *Capabilities
 
+	// ValidateOptions verifies the transport specific options of a subscription, when it is created
+	ValidateOptions(options *fftypes.SubscriptionOptions) error
+
 	// DeliveryRequest requests delivery of work on a connection, which must later be responded to
-	DeliveryRequest(connID string, event *fftypes.EventDelivery) error
+	DeliveryRequest(connID string, sub *fftypes.Subscription, event *fftypes.EventDelivery) error
 }
 
 type SubscriptionMatcher func(fftypes.SubscriptionRef) bool
@@ -66,6 +69,7 @@ type Callbacks interface {
 	//   * Note all gaps must fill before the offset can move forwards, so this message might still be redelivered if streaming ahead
 	// - Reject it: This resets the associated subscription back to the last committed offset
 	//   * Note all message since the last committed offet will be redelivered, so additional messages to be redelivered if streaming ahead
+	// An acknowledgement can include a reply, which is sent as a new message in response to the delivered message
 	DeliveryResponse(connID string, inflight fftypes.EventDeliveryResponse) error
 }
 
@@ -0,0 +1,32 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftypes
+
+// Blob records that the binary content of a data record is available in the local
+// data exchange blob store, along with the hash of the content as it was stored.
+//
+// Blobs are recorded when they are uploaded locally, and when they arrive from another
+// member of the network. The aggregator will not dispatch a message until a blob with
+// a matching hash is available for each of the blob data elements of the message.
+type Blob struct {
+	Sequence  int64    `json:"-"`
+	Namespace string   `json:"namespace"`
+	Data      *UUID    `json:"data"`
+	Hash      *Bytes32 `json:"hash"`
+	Peer      string   `json:"peer,omitempty"`
+	Created   *FFTime  `json:"created,omitempty"`
+}
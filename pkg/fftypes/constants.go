@@ -38,6 +38,21 @@ const (
 	// SystemTagDefineNode is the topic for messages that broadcast node definitions
 	SystemTagDefineNode SystemTag = "ff_define_node"
 
+	// SystemTagUpdateOrganization is the topic for messages that broadcast a new version of an existing organization
+	SystemTagUpdateOrganization SystemTag = "ff_update_organization"
+
+	// SystemTagRevokeOrganization is the topic for messages that broadcast an organization leaving the network
+	SystemTagRevokeOrganization SystemTag = "ff_revoke_organization"
+
+	// SystemTagUpdateNode is the topic for messages that broadcast a new version of an existing node
+	SystemTagUpdateNode SystemTag = "ff_update_node"
+
+	// SystemTagRevokeNode is the topic for messages that broadcast a node leaving the network
+	SystemTagRevokeNode SystemTag = "ff_revoke_node"
+
 	// SystemTagDefineGroup is the topic for messages that send the definition of a group, to all parties in that group
 	SystemTagDefineGroup SystemTag = "ff_define_group"
+
+	// SystemTagUpdateGroup is the topic for messages that send the definition of a successor group, to all parties in the retiring group
+	SystemTagUpdateGroup SystemTag = "ff_update_group"
 )
@@ -30,6 +30,15 @@ type DataRef struct {
 	Hash *Bytes32 `json:"hash,omitempty"`
 }
 
+// BlobRef describes the binary content of a data record, held in the data exchange blob store.
+// When the blob is broadcast, PayloadRef is the reference to the copy in public storage.
+type BlobRef struct {
+	Name       string   `json:"name,omitempty"`
+	MimeType   string   `json:"mimetype,omitempty"`
+	Size       int64    `json:"size"`
+	PayloadRef *Bytes32 `json:"payloadRef,omitempty"`
+}
+
 type Data struct {
 	ID        *UUID         `json:"id,omitempty"`
 	Validator ValidatorType `json:"validator"`
@@ -39,6 +48,7 @@ type Data struct {
 	Created   *FFTime       `json:"created,omitempty"`
 	Datatype  *DatatypeRef  `json:"datatype,omitempty"`
 	Value     Byteable      `json:"value"`
+	Blob      *BlobRef      `json:"blob,omitempty"`
 }
 
 type DatatypeRef struct {
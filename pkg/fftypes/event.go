@@ -21,7 +21,9 @@ type EventType = LowerCasedType
 
 const (
 	// EventTypeMessageConfirmed is the most important event type in the system. This means a message and all of its data
-	// is available for processing by an application. Most applications only need to listen to this event type
+	// is available for processing by an application. Most applications only need to listen to this event type.
+	// Private messages sent with a transaction type of "none" are confirmed in the order they arrived from each sender
+	// over data exchange, rather than an order proven on-chain - such events are marked as unpinned
 	EventTypeMessageConfirmed EventType = "message_confirmed"
 	// EventTypeMessageInvalid occurs if a message is received and confirmed from a sequencing perspective, but is invalid
 	EventTypeMessageInvalid EventType = "message_invalid"
@@ -31,6 +33,22 @@ const (
 	EventTypeDatatypeConfirmed EventType = "datatype_confirmed"
 	// EventTypeGroupConfirmed occurs when a new group is ready to use (on the namespace of the group, on all group participants)
 	EventTypeGroupConfirmed EventType = "group_confirmed"
+	// EventTypeGroupUpdated occurs when a group is retired, and replaced by a successor group (on the namespace of the group, on all participants in the retired group)
+	EventTypeGroupUpdated EventType = "group_updated"
+	// EventTypeOrganizationUpdated occurs when an organization is updated, re-keyed or revoked (on the organization)
+	EventTypeOrganizationUpdated EventType = "organization_updated"
+	// EventTypeNodeUpdated occurs when a node is updated or revoked (on the node)
+	EventTypeNodeUpdated EventType = "node_updated"
+	// EventTypeTransactionFailed occurs for each message in a batch, when the blockchain transaction to pin that batch has failed and will not be resubmitted
+	EventTypeTransactionFailed EventType = "transaction_failed"
+	// EventTypeTransactionSubmitted occurs when a blockchain transaction to pin a batch has been submitted, and is pending confirmation (on the transaction)
+	EventTypeTransactionSubmitted EventType = "transaction_submitted"
+	// EventTypeTransactionConfirmed occurs when the blockchain plugin reports a transaction has been successfully mined (on the transaction)
+	EventTypeTransactionConfirmed EventType = "transaction_confirmed"
+	// EventTypeOperationSucceeded occurs when a plugin reports an operation has completed successfully (on the operation)
+	EventTypeOperationSucceeded EventType = "operation_succeeded"
+	// EventTypeOperationFailed occurs when a plugin reports an operation has failed (on the operation)
+	EventTypeOperationFailed EventType = "operation_failed"
 )
 
 // Event is an activity in the system, delivered reliably to applications, that indicates something has happened in the network
@@ -41,6 +59,7 @@ type Event struct {
 	Namespace string    `json:"namespace"`
 	Reference *UUID     `json:"reference"`
 	Group     *Bytes32  `json:"group,omitempty"`
+	Unpinned  bool      `json:"unpinned,omitempty"` // the ordering of the referenced message was not proven on-chain
 	Created   *FFTime   `json:"created"`
 }
 
@@ -51,6 +70,8 @@ type EventDelivery struct {
 	Subscription SubscriptionRef `json:"subscription"`
 	Message      *Message        `json:"message,omitempty"`
 	Data         *DataRef        `json:"data,omitempty"`
+	Operation    *Operation      `json:"operation,omitempty"`
+	Transaction  *Transaction    `json:"transaction,omitempty"`
 }
 
 // EventDeliveryResponse is the payload an application sends back, to confirm it has accepted (or rejected) the event and as such
@@ -60,6 +81,7 @@ type EventDeliveryResponse struct {
 	Rejected     bool            `json:"rejected,omitempty"`
 	Info         string          `json:"info,omitempty"`
 	Subscription SubscriptionRef `json:"subscription"`
+	Reply        *MessageInput   `json:"reply,omitempty"`
 }
 
 func NewEvent(t EventType, ns string, ref *UUID, group *Bytes32) *Event {
@@ -26,17 +26,33 @@ import (
 )
 
 type GroupIdentity struct {
-	Ledger    *UUID   `json:"ledger,omitempty"`
-	Namespace string  `json:"namespace,omitempty"`
-	Name      string  `json:"name"`
-	Members   Members `json:"members"`
+	Ledger      *UUID    `json:"ledger,omitempty"`
+	Namespace   string   `json:"namespace,omitempty"`
+	Name        string   `json:"name"`
+	Members     Members  `json:"members"`
+	Predecessor *Bytes32 `json:"predecessor,omitempty"`
 }
 
 type Group struct {
 	GroupIdentity
-	Message *UUID    `json:"message,omitempty"`
-	Hash    *Bytes32 `json:"hash,omitempty"`
-	Created *FFTime  `json:"created,omitempty"`
+	Message   *UUID    `json:"message,omitempty"`
+	Hash      *Bytes32 `json:"hash,omitempty"`
+	Created   *FFTime  `json:"created,omitempty"`
+	Successor *Bytes32 `json:"successor,omitempty"`
+	Retired   *FFTime  `json:"retired,omitempty"`
+}
+
+// GroupUpdateInput declares the new membership of a group, that will replace an existing group as its successor
+type GroupUpdateInput struct {
+	Name    string        `json:"name,omitempty"`
+	Members []MemberInput `json:"members"`
+	Topics  FFNameArray   `json:"topics,omitempty"`
+}
+
+// GroupResolved is a group, with the names of the org and node of each member resolved
+type GroupResolved struct {
+	Group
+	Members []*MemberResolved `json:"members"`
 }
 
 type Members []*Member
@@ -46,6 +62,12 @@ type Member struct {
 	Node     *UUID  `json:"node,omitempty"`
 }
 
+type MemberResolved struct {
+	Member
+	OrgName  string `json:"orgName,omitempty"`
+	NodeName string `json:"nodeName,omitempty"`
+}
+
 type MemberInput struct {
 	Identity string `json:"identity,omitempty"`
 	Node     string `json:"node,omitempty"`
@@ -100,6 +122,16 @@ func (group *Group) Seal() {
 	group.Hash = group.GroupIdentity.Hash()
 }
 
+// IsMember returns true if the identity is one of the members of the group, on any node
+func (group *Group) IsMember(identity string) bool {
+	for _, m := range group.Members {
+		if m.Identity == identity {
+			return true
+		}
+	}
+	return false
+}
+
 func (group *Group) Topic() string {
 	return group.Hash.String()
 }
@@ -125,3 +125,28 @@ func TestGroupValidation(t *testing.T) {
 	def.SetBroadcastMessage(NewUUID())
 	assert.NotNil(t, group.Message)
 }
+
+func TestGroupSuccessor(t *testing.T) {
+
+	nodeID := MustParseUUID("8b5c0d39-925f-4579-9c60-54f3e846ab99")
+	predecessor := &Group{
+		GroupIdentity: GroupIdentity{
+			Name:      "ok",
+			Namespace: "ok",
+			Members: Members{
+				{Node: nodeID, Identity: "0x12345"},
+			},
+		},
+	}
+	predecessor.Seal()
+	assert.True(t, predecessor.IsMember("0x12345"))
+	assert.False(t, predecessor.IsMember("0x23456"))
+
+	successor := &Group{
+		GroupIdentity: predecessor.GroupIdentity,
+	}
+	successor.Predecessor = predecessor.Hash
+	successor.Seal()
+	assert.NoError(t, successor.Validate(context.Background(), true))
+	assert.NotEqual(t, predecessor.Hash.String(), successor.Hash.String())
+}
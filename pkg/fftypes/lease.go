@@ -0,0 +1,26 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftypes
+
+// Lease is a time limited claim by one instance in a cluster, to be the only instance running a singleton role.
+// The epoch increases each time the lease passes to a new holder, so it can be used as a fencing token.
+type Lease struct {
+	Name    string  `json:"name"`
+	Holder  string  `json:"holder"`
+	Expires *FFTime `json:"expires"`
+	Epoch   int64   `json:"epoch"`
+}
@@ -41,6 +41,8 @@ const (
 	MessageTypePrivate MessageType = "private"
 	// MessageTypeGroupInit is a special private message that contains the definition of the group
 	MessageTypeGroupInit MessageType = "groupinit"
+	// MessageTypeGroupUpdate is a special private message, sent to an existing group, that contains the definition of the successor group
+	MessageTypeGroupUpdate MessageType = "groupupdate"
 )
 
 // MessageHeader contains all fields that contribute to the hash
@@ -65,6 +67,7 @@ type MessageHeader struct {
 type Message struct {
 	Header    MessageHeader `json:"header"`
 	Hash      *Bytes32      `json:"hash,omitempty"`
+	Signature string        `json:"signature,omitempty"`
 	BatchID   *UUID         `json:"batchID,omitempty"`
 	Confirmed *FFTime       `json:"confirmed,omitempty"`
 	Data      DataRefs      `json:"data"`
@@ -178,3 +181,18 @@ func (m *Message) Verify(ctx context.Context) error {
 func (m *Message) LocalSequence() int64 {
 	return m.Sequence
 }
+
+// VerifySignature checks the signature over the message hash, against the public keys published by the author.
+// An author that has not published any keys does not sign its messages, and one that has must sign all of them.
+func (m *Message) VerifySignature(ctx context.Context, keys PublicKeys) error {
+	switch {
+	case len(keys) == 0 && m.Signature == "":
+		return nil
+	case len(keys) == 0:
+		return i18n.NewError(ctx, i18n.MsgUnverifiableSignature, m.Header.Author)
+	case m.Signature == "":
+		return i18n.NewError(ctx, i18n.MsgMissingSignature, m.Header.Author)
+	default:
+		return keys.VerifySignature(ctx, m.Hash, m.Signature)
+	}
+}
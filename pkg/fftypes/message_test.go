@@ -18,7 +18,10 @@ package fftypes
 
 import (
 	"context"
+	"crypto/ed25519"
+	"crypto/rand"
 	"crypto/sha256"
+	"encoding/base64"
 	"encoding/json"
 	"testing"
 
@@ -176,3 +179,28 @@ func TestSealKnownMessage(t *testing.T) {
 	var ls LocallySequenced = &msg
 	assert.Equal(t, int64(12345), ls.LocalSequence())
 }
+
+func TestMessageVerifySignature(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	keys := PublicKeys{{Type: KeyTypeEd25519, Key: base64.StdEncoding.EncodeToString(pub)}}
+
+	msg := &Message{Header: MessageHeader{Author: "0x12345"}}
+	err = msg.Seal(context.Background())
+	assert.NoError(t, err)
+
+	// Unsigned, and no keys published
+	assert.NoError(t, msg.VerifySignature(context.Background(), nil))
+
+	// Unsigned, but keys published
+	err = msg.VerifySignature(context.Background(), keys)
+	assert.Regexp(t, "FF10279.*0x12345", err)
+
+	// Signed, but no keys published
+	msg.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(priv, msg.Hash[:]))
+	err = msg.VerifySignature(context.Background(), nil)
+	assert.Regexp(t, "FF10280.*0x12345", err)
+
+	// Signed with a published key
+	assert.NoError(t, msg.VerifySignature(context.Background(), keys))
+}
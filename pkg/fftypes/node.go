@@ -30,7 +30,10 @@ type Node struct {
 	Name        string  `json:"name,omitempty"`
 	Description string  `json:"description,omitempty"`
 	DX          DXInfo  `json:"dx"`
+	Version     int64   `json:"version,omitempty"`
 	Created     *FFTime `json:"created,omitempty"`
+	Updated     *FFTime `json:"updated,omitempty"`
+	Revoked     *FFTime `json:"revoked,omitempty"`
 }
 
 // DXInfo is the data exchange information
@@ -21,6 +21,7 @@ type NodeStatus struct {
 	Node     NodeStatusNode     `json:"node"`
 	Org      NodeStatusOrg      `json:"org"`
 	Defaults NodeStatusDefaults `json:"defaults"`
+	Leader   *NodeStatusLeader  `json:"leader,omitempty"`
 }
 
 // NodeStatusNode is the information about the local node, returned in the node status
@@ -42,3 +43,13 @@ type NodeStatusOrg struct {
 type NodeStatusDefaults struct {
 	Namespace string `json:"namespace"`
 }
+
+// NodeStatusLeader is the leader election state of this instance, when multiple instances share a database
+type NodeStatusLeader struct {
+	Enabled bool    `json:"enabled"`
+	ID      string  `json:"id"`
+	Leader  bool    `json:"leader"`
+	Holder  string  `json:"holder,omitempty"`
+	Expires *FFTime `json:"expires,omitempty"`
+	Epoch   int64   `json:"epoch,omitempty"`
+}
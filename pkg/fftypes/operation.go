@@ -16,6 +16,14 @@
 
 package fftypes
 
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+
+	"github.com/hyperledger-labs/firefly/internal/i18n"
+)
+
 // OpType describes mechanical steps in the process that have to be performed,
 // might be asynchronous, and have results in the back-end systems that might need
 // to be correlated with messages by operators.
@@ -28,6 +36,8 @@ const (
 	OpTypePublicStorageBatchBroadcast OpType = "publicstorage_batch_broadcast"
 	// OpTypeDataExchangeBatchSend is a private send
 	OpTypeDataExchangeBatchSend OpType = "dataexchange_batch_send"
+	// OpTypeDataExchangeBlobSend is a private send of a blob attached to a message
+	OpTypeDataExchangeBlobSend OpType = "dataexchange_blob_send"
 )
 
 // OpStatus is the current status of an operation
@@ -40,6 +50,10 @@ const (
 	OpStatusSucceeded OpStatus = "Succeeded"
 	// OpStatusFailed happens when an error is reported by the infrastructure runtime
 	OpStatusFailed OpStatus = "Failed"
+	// OpStatusPartial only applies to transactions, when the private data was delivered to some but not all members
+	OpStatusPartial OpStatus = "Partial"
+	// OpStatusCancelled is set when a user cancels a pending or failed operation, after which updates from the runtime are ignored
+	OpStatusCancelled OpStatus = "Cancelled"
 )
 
 type Named interface {
@@ -63,16 +77,58 @@ func NewTXOperation(plugin Named, namespace string, tx *UUID, backendID string,
 
 // Operation is a description of an action performed as part of a transaction submitted by this node
 type Operation struct {
-	ID          *UUID      `json:"id"`
-	Namespace   string     `json:"namespace"`
-	Transaction *UUID      `json:"tx"`
-	Type        OpType     `json:"type"`
-	Member      string     `json:"member,omitempty"`
-	Status      OpStatus   `json:"status"`
-	Error       string     `json:"error,omitempty"`
-	Plugin      string     `json:"plugin"`
-	BackendID   string     `json:"backendID"`
-	Info        JSONObject `json:"info,omitempty"`
-	Created     *FFTime    `json:"created,omitempty"`
-	Updated     *FFTime    `json:"updated,omitempty"`
+	ID          *UUID            `json:"id"`
+	Namespace   string           `json:"namespace"`
+	Transaction *UUID            `json:"tx"`
+	Type        OpType           `json:"type"`
+	Member      string           `json:"member,omitempty"`
+	Status      OpStatus         `json:"status"`
+	Error       string           `json:"error,omitempty"`
+	Plugin      string           `json:"plugin"`
+	BackendID   string           `json:"backendID"`
+	Input       JSONObject       `json:"input,omitempty"`
+	Info        JSONObject       `json:"info,omitempty"`
+	Retries     OperationRetries `json:"retries,omitempty"`
+	Created     *FFTime          `json:"created,omitempty"`
+	Updated     *FFTime          `json:"updated,omitempty"`
+}
+
+// OperationRetry records the outcome of a previous attempt of an operation, that was superseded by a retry
+type OperationRetry struct {
+	BackendID string     `json:"backendID"`
+	Status    OpStatus   `json:"status"`
+	Error     string     `json:"error,omitempty"`
+	Info      JSONObject `json:"info,omitempty"`
+	Retried   *FFTime    `json:"retried"`
+}
+
+// OperationRetries is the history of retries of an operation, oldest first
+type OperationRetries []*OperationRetry
+
+// Value implements sql.Valuer
+func (or OperationRetries) Value() (driver.Value, error) {
+	if or == nil {
+		return nil, nil
+	}
+	return json.Marshal(&or)
+}
+
+// Scan implements sql.Scanner
+func (or *OperationRetries) Scan(src interface{}) error {
+	switch src := src.(type) {
+	case nil:
+		return nil
+	case []byte:
+		if len(src) == 0 {
+			return nil
+		}
+		return json.Unmarshal(src, &or)
+	case string:
+		if src == "" {
+			return nil
+		}
+		return json.Unmarshal([]byte(src), &or)
+	default:
+		return i18n.NewError(context.Background(), i18n.MsgScanFailed, src, or)
+	}
 }
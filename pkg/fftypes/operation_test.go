@@ -42,3 +42,41 @@ func TestNewPendingMessageOp(t *testing.T) {
 		Created:     op.Created,
 	}, *op)
 }
+
+func TestOperationRetriesDatabaseSerialization(t *testing.T) {
+
+	retries1 := OperationRetries{
+		{BackendID: "tracking1", Status: OpStatusFailed, Error: "pop", Retried: Now()},
+	}
+
+	// Verify it serializes as bytes to the database
+	b1, err := retries1.Value()
+	assert.NoError(t, err)
+	assert.Contains(t, string(b1.([]byte)), `"backendID":"tracking1"`)
+
+	// Verify it restores ok
+	var retries2 OperationRetries
+	err = retries2.Scan(b1)
+	assert.NoError(t, err)
+	assert.Equal(t, "tracking1", retries2[0].BackendID)
+
+	// Verify it works with string
+	var retries3 OperationRetries
+	err = retries3.Scan(string(b1.([]byte)))
+	assert.NoError(t, err)
+	assert.Equal(t, OpStatusFailed, retries3[0].Status)
+
+	// Verify empty values
+	var retries4 OperationRetries
+	v, err := retries4.Value()
+	assert.NoError(t, err)
+	assert.Nil(t, v)
+	assert.NoError(t, retries4.Scan(nil))
+	assert.NoError(t, retries4.Scan([]byte{}))
+	assert.NoError(t, retries4.Scan(""))
+	assert.Nil(t, retries4)
+
+	// Verify failure
+	err = retries4.Scan(false)
+	assert.Regexp(t, "FF10125", err)
+}
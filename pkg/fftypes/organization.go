@@ -18,6 +18,7 @@ package fftypes
 
 import (
 	"context"
+	"crypto/sha256"
 	"fmt"
 	"strings"
 	"unicode"
@@ -34,7 +35,13 @@ type Organization struct {
 	Name        string     `json:"name,omitempty"`
 	Description string     `json:"description,omitempty"`
 	Profile     JSONObject `json:"profile,omitempty"`
+	PublicKeys  PublicKeys `json:"publicKeys,omitempty"`
+	Version     int64      `json:"version,omitempty"`
 	Created     *FFTime    `json:"created,omitempty"`
+	Updated     *FFTime    `json:"updated,omitempty"`
+	Revoked     *FFTime    `json:"revoked,omitempty"`
+
+	IdentityProof string `json:"identityProof,omitempty"`
 }
 
 func (org *Organization) Validate(ctx context.Context, existing bool) (err error) {
@@ -71,6 +78,13 @@ func (org *Organization) Topic() string {
 	return orgTopic(org.Identity)
 }
 
+// IdentityClaimHash is the hash an organization signs with the keys of a new identity, when it moves to that identity,
+// as proof that it controls it. The claim is specific to the version that moves the organization.
+func (org *Organization) IdentityClaimHash(version int64) *Bytes32 {
+	var hash Bytes32 = sha256.Sum256([]byte(fmt.Sprintf("%s:%s:%d", org.ID, org.Identity, version)))
+	return &hash
+}
+
 func (org *Organization) SetBroadcastMessage(msgID *UUID) {
 	org.Message = msgID
 }
@@ -51,3 +51,12 @@ func TestOrganizationValidation(t *testing.T) {
 	def.SetBroadcastMessage(NewUUID())
 	assert.NotNil(t, org.Message)
 }
+
+func TestOrganizationIdentityClaimHash(t *testing.T) {
+	org := &Organization{ID: NewUUID(), Identity: "0x12345"}
+	hash := org.IdentityClaimHash(2)
+	assert.Equal(t, hash, org.IdentityClaimHash(2))
+	assert.NotEqual(t, hash, org.IdentityClaimHash(3))
+	org.Identity = "0x23456"
+	assert.NotEqual(t, hash, org.IdentityClaimHash(2))
+}
@@ -36,8 +36,12 @@ package fftypes
 // before receiving the blob data - we have to upgrade a batch-park, to a pin-park.
 // This is because the sequence must be in the order the pins arrive.
 //
+// Each pin records the ledger it arrived on (nil for the default ledger), as the
+// order of pins is only meaningful within a single ledger.
+//
 type Pin struct {
 	Sequence   int64    `json:"sequence,omitempty"`
+	Ledger     *UUID    `json:"ledger,omitempty"`
 	Masked     bool     `json:"masked,omitempty"`
 	Hash       *Bytes32 `json:"hash,omitempty"`
 	Batch      *UUID    `json:"batch,omitempty"`
@@ -0,0 +1,125 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftypes
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/hyperledger-labs/firefly/internal/i18n"
+)
+
+type KeyType = LowerCasedType
+
+const (
+	// KeyTypeEd25519 is an Ed25519 key, published as the 32 byte public key
+	KeyTypeEd25519 KeyType = "ed25519"
+	// KeyTypeSecp256k1 is an ECDSA key on the secp256k1 curve, published as the 33 byte compressed public key
+	KeyTypeSecp256k1 KeyType = "secp256k1"
+	// KeyTypeP256 is an ECDSA key on the NIST P-256 curve, as commonly issued in X.509 certificates, published as the 33 byte compressed public key
+	KeyTypeP256 KeyType = "p256"
+)
+
+// PublicKey is published in the registration of an organization, so that receivers can verify the signatures on its messages
+type PublicKey struct {
+	Type KeyType `json:"type"`
+	Key  string  `json:"key"` // base64 encoded
+}
+
+// PublicKeys is the set of keys that are currently valid for an organization
+type PublicKeys []*PublicKey
+
+// Verify checks a signature over a hash, returning false if it was not made with this key
+func (pk *PublicKey) Verify(hash *Bytes32, signature []byte) bool {
+	key, err := base64.StdEncoding.DecodeString(pk.Key)
+	if err != nil {
+		return false
+	}
+	switch pk.Type {
+	case KeyTypeEd25519:
+		if len(key) != ed25519.PublicKeySize {
+			return false
+		}
+		return ed25519.Verify(ed25519.PublicKey(key), hash[:], signature)
+	case KeyTypeSecp256k1:
+		pubKey, err := btcec.ParsePubKey(key, btcec.S256())
+		if err != nil {
+			return false
+		}
+		sig, err := btcec.ParseDERSignature(signature, btcec.S256())
+		if err != nil {
+			return false
+		}
+		return sig.Verify(hash[:], pubKey)
+	case KeyTypeP256:
+		x, y := elliptic.UnmarshalCompressed(elliptic.P256(), key)
+		if x == nil {
+			return false
+		}
+		return ecdsa.VerifyASN1(&ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}, hash[:], signature)
+	default:
+		return false
+	}
+}
+
+// VerifySignature checks a base64 encoded signature over a hash was made with one of the keys
+func (pks PublicKeys) VerifySignature(ctx context.Context, hash *Bytes32, signature string) error {
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil || hash == nil {
+		return i18n.NewError(ctx, i18n.MsgInvalidSignature)
+	}
+	for _, pk := range pks {
+		if pk != nil && pk.Verify(hash, sig) {
+			return nil
+		}
+	}
+	return i18n.NewError(ctx, i18n.MsgInvalidSignature)
+}
+
+// Value implements sql.Valuer
+func (pks PublicKeys) Value() (driver.Value, error) {
+	if pks == nil {
+		return nil, nil
+	}
+	return json.Marshal(&pks)
+}
+
+// Scan implements sql.Scanner
+func (pks *PublicKeys) Scan(src interface{}) error {
+	switch src := src.(type) {
+	case nil:
+		return nil
+	case []byte:
+		if len(src) == 0 {
+			return nil
+		}
+		return json.Unmarshal(src, &pks)
+	case string:
+		if src == "" {
+			return nil
+		}
+		return json.Unmarshal([]byte(src), &pks)
+	default:
+		return i18n.NewError(context.Background(), i18n.MsgScanFailed, src, pks)
+	}
+}
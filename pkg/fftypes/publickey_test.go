@@ -0,0 +1,142 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftypes
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/base64"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPublicKeyVerifyEd25519(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	pk := &PublicKey{Type: KeyTypeEd25519, Key: base64.StdEncoding.EncodeToString(pub)}
+
+	hash := NewRandB32()
+	sig := ed25519.Sign(priv, hash[:])
+	assert.True(t, pk.Verify(hash, sig))
+	assert.False(t, pk.Verify(NewRandB32(), sig))
+
+	pk.Key = base64.StdEncoding.EncodeToString(pub[0:16])
+	assert.False(t, pk.Verify(hash, sig))
+}
+
+func TestPublicKeyVerifySecp256k1(t *testing.T) {
+	priv, err := btcec.NewPrivateKey(btcec.S256())
+	assert.NoError(t, err)
+	pk := &PublicKey{Type: KeyTypeSecp256k1, Key: base64.StdEncoding.EncodeToString(priv.PubKey().SerializeCompressed())}
+
+	hash := NewRandB32()
+	sig, err := priv.Sign(hash[:])
+	assert.NoError(t, err)
+	assert.True(t, pk.Verify(hash, sig.Serialize()))
+	assert.False(t, pk.Verify(NewRandB32(), sig.Serialize()))
+	assert.False(t, pk.Verify(hash, []byte("not DER")))
+
+	pk.Key = base64.StdEncoding.EncodeToString([]byte("not a key"))
+	assert.False(t, pk.Verify(hash, sig.Serialize()))
+}
+
+func TestPublicKeyVerifyP256(t *testing.T) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	pk := &PublicKey{Type: KeyTypeP256, Key: base64.StdEncoding.EncodeToString(elliptic.MarshalCompressed(elliptic.P256(), priv.X, priv.Y))}
+
+	hash := NewRandB32()
+	sig, err := ecdsa.SignASN1(rand.Reader, priv, hash[:])
+	assert.NoError(t, err)
+	assert.True(t, pk.Verify(hash, sig))
+	assert.False(t, pk.Verify(NewRandB32(), sig))
+
+	pk.Key = base64.StdEncoding.EncodeToString([]byte("not a key"))
+	assert.False(t, pk.Verify(hash, sig))
+}
+
+func TestPublicKeyVerifyBadKey(t *testing.T) {
+	pk := &PublicKey{Type: KeyTypeEd25519, Key: "!!!not base64"}
+	assert.False(t, pk.Verify(NewRandB32(), []byte{}))
+
+	pk = &PublicKey{Type: "unknown", Key: "cHVibGlja2V5"}
+	assert.False(t, pk.Verify(NewRandB32(), []byte{}))
+}
+
+func TestPublicKeysVerifySignature(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+	pks := PublicKeys{
+		nil,
+		{Type: KeyTypeEd25519, Key: "cHVibGlja2V5"},
+		{Type: KeyTypeEd25519, Key: base64.StdEncoding.EncodeToString(pub)},
+	}
+
+	hash := NewRandB32()
+	sig := base64.StdEncoding.EncodeToString(ed25519.Sign(priv, hash[:]))
+	assert.NoError(t, pks.VerifySignature(context.Background(), hash, sig))
+
+	err = pks.VerifySignature(context.Background(), NewRandB32(), sig)
+	assert.Regexp(t, "FF10278", err)
+	err = pks.VerifySignature(context.Background(), hash, "!!!not base64")
+	assert.Regexp(t, "FF10278", err)
+	err = pks.VerifySignature(context.Background(), nil, sig)
+	assert.Regexp(t, "FF10278", err)
+}
+
+func TestPublicKeysDatabaseSerialization(t *testing.T) {
+
+	pks1 := PublicKeys{
+		{Type: KeyTypeEd25519, Key: "cHVibGlja2V5"},
+	}
+
+	// Verify it serializes as bytes to the database
+	b1, err := pks1.Value()
+	assert.NoError(t, err)
+	assert.Equal(t, `[{"type":"ed25519","key":"cHVibGlja2V5"}]`, string(b1.([]byte)))
+
+	// Verify it restores ok
+	var pks2 PublicKeys
+	err = pks2.Scan(b1)
+	assert.NoError(t, err)
+	assert.Equal(t, "cHVibGlja2V5", pks2[0].Key)
+
+	// Verify it works with string
+	var pks3 PublicKeys
+	err = pks3.Scan(string(b1.([]byte)))
+	assert.NoError(t, err)
+	assert.Equal(t, KeyTypeEd25519, pks3[0].Type)
+
+	// Verify empty values
+	var pks4 PublicKeys
+	v, err := pks4.Value()
+	assert.NoError(t, err)
+	assert.Nil(t, v)
+	assert.NoError(t, pks4.Scan(nil))
+	assert.NoError(t, pks4.Scan([]byte{}))
+	assert.NoError(t, pks4.Scan(""))
+	assert.Nil(t, pks4)
+
+	// Verify failure
+	err = pks4.Scan(false)
+	assert.Regexp(t, "FF10125", err)
+}
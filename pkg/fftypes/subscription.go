@@ -24,7 +24,9 @@ import (
 	"github.com/hyperledger-labs/firefly/internal/i18n"
 )
 
-// SubscriptionFilter contains regular expressions to match against events. All must match for an event to be dispatched to a subscription
+// SubscriptionFilter contains regular expressions to match against events. All must match for an event to be dispatched to a subscription.
+// Note that a subscription with no events filter receives every event type - including the transaction and operation
+// events emitted on the submitting node - so use a filter such as "^message_" to receive only message events.
 type SubscriptionFilter struct {
 	Events string `json:"events,omitempty"`
 	Topics string `json:"topics,omitempty"`
@@ -46,6 +48,7 @@ const (
 type SubscriptionOptions struct {
 	FirstEvent *SubOptsFirstEvent `json:"firstEvent,omitempty"`
 	ReadAhead  *uint16            `json:"readAhead,omitempty"`
+	Webhook    *WebhookSubOptions `json:"webhook,omitempty"`
 }
 
 // SubscriptionRef are the fields that can be used to refer to a subscription
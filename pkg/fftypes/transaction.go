@@ -24,7 +24,7 @@ import (
 type TransactionType = LowerCasedType
 
 const (
-	// TransactionTypeNone indicates no transaction should be used for this message/batch
+	// TransactionTypeNone indicates no transaction should be used for this message/batch. Private messages are sent over data exchange only, and are sequenced in the order they arrive from each sender
 	TransactionTypeNone TransactionType = "none"
 	// TransactionTypeBatchPin represents a pinning transaction, that verifies the originator of the data, and sequences the event deterministically between parties
 	TransactionTypeBatchPin TransactionType = "batch_pin"
@@ -62,4 +62,73 @@ type Transaction struct {
 	Status     OpStatus           `json:"status"`
 	ProtocolID string             `json:"protocolID,omitempty"`
 	Info       JSONObject         `json:"info,omitempty"`
+	Delivery   []*MemberDelivery  `json:"delivery,omitempty"`
+}
+
+// MemberDelivery is the status of delivering the private data of a transaction to one member node,
+// derived from the data exchange operations for that node. It is not persisted.
+type MemberDelivery struct {
+	Node   string   `json:"node"`
+	Status OpStatus `json:"status"`
+	Error  string   `json:"error,omitempty"`
+}
+
+// DeriveStatus computes the status of the transaction from the operations this node performed for it, and whether
+// the batch pin has been confirmed (which is when the protocol ID is set). A transaction of type none has no batch pin,
+// so succeeds once delivered to every member. The per-member delivery is also returned.
+func (tx *Transaction) DeriveStatus(ops []*Operation) (OpStatus, []*MemberDelivery) {
+	failed := false
+	pinOps, pinFailures := 0, 0
+	var delivery []*MemberDelivery
+	byMember := make(map[string]*MemberDelivery)
+	for _, op := range ops {
+		// A cancelled operation will never complete, so it counts as a failure
+		opFailed := op.Status == OpStatusFailed || op.Status == OpStatusCancelled
+		switch op.Type {
+		case OpTypeBlockchainBatchPin:
+			pinOps++
+			if opFailed {
+				pinFailures++
+			}
+		case OpTypePublicStorageBatchBroadcast:
+			failed = failed || opFailed
+		case OpTypeDataExchangeBatchSend, OpTypeDataExchangeBlobSend:
+			md, ok := byMember[op.Member]
+			if !ok {
+				md = &MemberDelivery{Node: op.Member, Status: OpStatusSucceeded}
+				byMember[op.Member] = md
+				delivery = append(delivery, md)
+			}
+			switch {
+			case opFailed && md.Status != OpStatusFailed:
+				md.Status = OpStatusFailed
+				md.Error = op.Error
+			case op.Status == OpStatusPending && md.Status == OpStatusSucceeded:
+				md.Status = OpStatusPending
+			}
+		}
+	}
+	// Resubmission of a failed batch pin creates a new operation, so the pin has only failed if they all have
+	failed = failed || (pinOps > 0 && pinFailures == pinOps)
+
+	undelivered, pending := 0, 0
+	for _, md := range delivery {
+		switch md.Status {
+		case OpStatusFailed:
+			undelivered++
+		case OpStatusPending:
+			pending++
+		}
+	}
+
+	switch {
+	case failed || (undelivered > 0 && undelivered == len(delivery)):
+		return OpStatusFailed, delivery
+	case undelivered > 0:
+		return OpStatusPartial, delivery
+	case pending > 0 || (tx.ProtocolID == "" && tx.Subject.Type != TransactionTypeNone):
+		return OpStatusPending, delivery
+	default:
+		return OpStatusSucceeded, delivery
+	}
 }
@@ -33,3 +33,91 @@ func TestTransactionHash(t *testing.T) {
 	}
 	assert.Equal(t, "0b4ba7041c826cd01a4eaffc22144f219164e21c58b04e7c76bbd58d1f72a1d3", tx.Subject.Hash().String())
 }
+
+func TestTransactionDeriveStatusReceiver(t *testing.T) {
+	tx := &Transaction{}
+	status, delivery := tx.DeriveStatus(nil)
+	assert.Equal(t, OpStatusPending, status)
+	assert.Empty(t, delivery)
+
+	tx.ProtocolID = "0x12345"
+	status, _ = tx.DeriveStatus(nil)
+	assert.Equal(t, OpStatusSucceeded, status)
+}
+
+func TestTransactionDeriveStatusBroadcast(t *testing.T) {
+	tx := &Transaction{}
+	ops := []*Operation{
+		{Type: OpTypePublicStorageBatchBroadcast, Status: OpStatusSucceeded},
+		{Type: OpTypeBlockchainBatchPin, Status: OpStatusPending},
+	}
+	status, _ := tx.DeriveStatus(ops)
+	assert.Equal(t, OpStatusPending, status)
+
+	ops[0].Status = OpStatusFailed
+	status, _ = tx.DeriveStatus(ops)
+	assert.Equal(t, OpStatusFailed, status)
+}
+
+func TestTransactionDeriveStatusPinResubmitted(t *testing.T) {
+	tx := &Transaction{}
+	ops := []*Operation{
+		{Type: OpTypeBlockchainBatchPin, Status: OpStatusFailed},
+		{Type: OpTypeBlockchainBatchPin, Status: OpStatusPending},
+	}
+	status, _ := tx.DeriveStatus(ops)
+	assert.Equal(t, OpStatusPending, status)
+
+	ops[1].Status = OpStatusCancelled
+	status, _ = tx.DeriveStatus(ops)
+	assert.Equal(t, OpStatusFailed, status)
+}
+
+func TestTransactionDeriveStatusPrivate(t *testing.T) {
+	tx := &Transaction{ProtocolID: "0x12345"}
+	ops := []*Operation{
+		{Type: OpTypeBlockchainBatchPin, Status: OpStatusSucceeded},
+		{Type: OpTypeDataExchangeBlobSend, Member: "node1", Status: OpStatusSucceeded},
+		{Type: OpTypeDataExchangeBatchSend, Member: "node1", Status: OpStatusSucceeded},
+		{Type: OpTypeDataExchangeBatchSend, Member: "node2", Status: OpStatusPending},
+	}
+	status, delivery := tx.DeriveStatus(ops)
+	assert.Equal(t, OpStatusPending, status)
+	assert.Equal(t, []*MemberDelivery{
+		{Node: "node1", Status: OpStatusSucceeded},
+		{Node: "node2", Status: OpStatusPending},
+	}, delivery)
+
+	ops[3].Status = OpStatusSucceeded
+	status, _ = tx.DeriveStatus(ops)
+	assert.Equal(t, OpStatusSucceeded, status)
+
+	ops[1].Status = OpStatusFailed
+	ops[1].Error = "pop"
+	ops[2].Status = OpStatusFailed
+	ops[2].Error = "bang"
+	status, delivery = tx.DeriveStatus(ops)
+	assert.Equal(t, OpStatusPartial, status)
+	assert.Equal(t, []*MemberDelivery{
+		{Node: "node1", Status: OpStatusFailed, Error: "pop"},
+		{Node: "node2", Status: OpStatusSucceeded},
+	}, delivery)
+
+	ops[3].Status = OpStatusFailed
+	status, _ = tx.DeriveStatus(ops)
+	assert.Equal(t, OpStatusFailed, status)
+}
+
+func TestTransactionDeriveStatusUnpinned(t *testing.T) {
+	tx := &Transaction{Subject: TransactionSubject{Type: TransactionTypeNone}}
+	ops := []*Operation{
+		{Type: OpTypeDataExchangeBatchSend, Member: "node1", Status: OpStatusPending},
+	}
+	status, _ := tx.DeriveStatus(ops)
+	assert.Equal(t, OpStatusPending, status)
+
+	// There is no batch pin to wait for, once delivered to every member
+	ops[0].Status = OpStatusSucceeded
+	status, _ = tx.DeriveStatus(ops)
+	assert.Equal(t, OpStatusSucceeded, status)
+}
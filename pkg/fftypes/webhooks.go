@@ -0,0 +1,37 @@
+// Copyright © 2021 Kaleido, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fftypes
+
+// WebhookSubOptions are the options for a subscription using the webhooks transport.
+// Each event is sent to the URL as an HTTP request, and a 2xx response acknowledges the event.
+type WebhookSubOptions struct {
+	URL      string             `json:"url"`
+	Method   string             `json:"method,omitempty"`
+	Headers  map[string]string  `json:"headers,omitempty"`
+	TLS      *WebhookTLSOptions `json:"tls,omitempty"`
+	Reply    bool               `json:"reply,omitempty"`
+	ReplyTag string             `json:"replytag,omitempty"`
+}
+
+// WebhookTLSOptions configure TLS for a webhook. The files are read from the TLS directory configured
+// for the webhooks plugin on the FireFly node, so that secrets do not need to be stored in the subscription
+type WebhookTLSOptions struct {
+	CAFile             string `json:"caFile,omitempty"`
+	CertFile           string `json:"certFile,omitempty"`
+	KeyFile            string `json:"keyFile,omitempty"`
+	InsecureSkipVerify bool   `json:"insecureSkipVerify,omitempty"`
+}
@@ -42,6 +42,23 @@ type Plugin interface {
 
 	// Resolve maps an identity identifier passed in a message to a full identity that includes the signing identity
 	Resolve(ctx context.Context, identifier string) (identity *fftypes.Identity, err error)
+
+	// Sign returns a base64 encoded signature over the hash of a message sent by the identity.
+	// Returns an empty signature if the plugin does not hold a key for the identity
+	Sign(ctx context.Context, identifier string, hash *fftypes.Bytes32) (signature string, err error)
+
+	// PublicKeys returns the keys that verify the signatures made for the identity, to be published when it is registered.
+	// Returns no keys if the plugin does not hold a key for the identity
+	PublicKeys(ctx context.Context, identifier string) (keys fftypes.PublicKeys, err error)
+
+	// AttachCredentials adds any credentials held for the identity of an organization (such as a certificate chain)
+	// to the organization, before it is registered
+	AttachCredentials(ctx context.Context, org *fftypes.Organization) error
+
+	// VerifyOrganization checks the credentials of an organization that is being registered, against those of its parent,
+	// as they were at the time of the registration. The parent is nil for a root organization.
+	// Plugins that do not use credentials accept all organizations
+	VerifyOrganization(ctx context.Context, org *fftypes.Organization, parent *fftypes.Organization, at *fftypes.FFTime) error
 }
 
 // Callbacks is the interface provided to the identity plugin, to allow it to request information from firefly, or pass events.